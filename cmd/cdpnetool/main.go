@@ -0,0 +1,490 @@
+// cdpnetool 命令行版本：无需图形界面即可启动或连接浏览器、加载规则并开启拦截，
+// 拦截事件以 NDJSON（每行一个 JSON 对象）写到标准输出，日志写到标准错误，适合在 CI 与服务器上运行。
+//
+// 用法：
+//
+//	cdpnetool -rules rules.json                        # 启动无头浏览器并拦截其所有页面
+//	cdpnetool -rules rules.json -watch                 # 同上，规则文件保存后自动重新加载
+//	cdpnetool -rules rules.json -test-events ev.ndjson # 以捕获的事件离线测试规则，结果与捕获时不同则失败
+//	cdpnetool -devtools http://127.0.0.1:9222 -traffic # 连接已运行的浏览器并输出全量流量
+//	cdpnetool -devtools https://chrome.internal -devtools-auth user:pass -devtools-host localhost:9222
+//	                                                   # 经 TLS 网关连接容器中的浏览器
+//	cdpnetool -rules rules.json -skip-types image,font,media
+//	                                                   # 图片、字体与音视频请求不经拦截直接发出
+//	cdpnetool -grpc 127.0.0.1:50051                    # 以 gRPC 控制面提供服务，由客户端管理会话
+//	cdpnetool -grpc :50051 -grpc-cert cert.pem -grpc-key key.pem -grpc-token-env CDPNETOOL_TOKEN
+//	                                                   # 监听非回环地址时必须启用 TLS 与访问令牌
+//	cdpnetool -grpc 127.0.0.1:50051 -grpc-token-env CDPNETOOL_TOKEN -grpc-readonly-token-env CDPNETOOL_VIEWER_TOKEN
+//	                                                   # 另设只读令牌，只能查看状态与订阅事件
+//	cdpnetool tail -session s1 -matched -url /api      # 从 gRPC 控制面订阅会话事件，过滤后以 NDJSON 输出
+//	cdpnetool tail -addr gw:50051 -ca ca.pem -token-env CDPNETOOL_VIEWER_TOKEN -session s1 -status 500 | jq .request.url
+//	                                                   # 经 TLS 连接远程控制面，只输出 500 响应的请求 URL
+//
+// 设置 OTEL_TRACES_EXPORTER=otlp 或 console 时以 OpenTelemetry 追踪每个请求的处理链路，
+// OTLP 导出地址等沿用 OTEL_EXPORTER_OTLP_* 标准环境变量。
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"cdpnetool/internal/browser"
+	"cdpnetool/internal/config"
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/tracing"
+	"cdpnetool/pkg/api"
+	"cdpnetool/pkg/apigrpc"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// eventBuffer 会话事件通道的容量，输出跟不上时超出的事件被丢弃
+const eventBuffer = 1024
+
+// options 命令行参数
+type options struct {
+	devToolsURL string
+	connection  domain.ConnectionOptions
+	browserPath string
+	engine      string
+	browserArgs stringList
+	headless    bool
+	port        int
+	rulesPath   string
+	watchRules  bool
+	dryRun      bool
+	testEvents  string
+	targets     stringList
+	traffic     bool
+	duration    time.Duration
+	concurrency int
+	network     string
+	include     []domain.ResourceType
+	exclude     []domain.ResourceType
+	replay      string
+	replayDir   string
+	logLevel    string
+	logFormat   string
+	grpcAddr    string
+	grpcCert    string
+	grpcKey     string
+	grpcToken   string // 保存访问令牌的环境变量名
+	grpcRead    string // 保存只读访问令牌的环境变量名
+}
+
+// stringList 可重复指定的字符串参数
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "cdpnetool:", err)
+		os.Exit(1)
+	}
+}
+
+// parseFlags 解析命令行参数，错误与用法说明写到 stderr
+func parseFlags(args []string, stderr io.Writer) (*options, error) {
+	opts := &options{}
+	fs := flag.NewFlagSet("cdpnetool", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.devToolsURL, "devtools", "", "DevTools URL of a running browser, e.g. http://127.0.0.1:9222; a new browser is launched when empty")
+	var headers stringList
+	var auth string
+	fs.Var(&headers, "devtools-header", "extra \"Name: value\" header sent to the -devtools endpoint (repeatable)")
+	fs.StringVar(&opts.connection.Host, "devtools-host", "", "Host header sent to the -devtools endpoint, e.g. localhost:9222 for a browser behind a tunnel")
+	fs.StringVar(&auth, "devtools-auth", "", "basic auth credentials for the -devtools endpoint as user:password")
+	fs.StringVar(&opts.connection.CAFile, "devtools-ca", "", "PEM file of an extra CA trusted for an https -devtools endpoint")
+	fs.BoolVar(&opts.connection.InsecureSkipVerify, "devtools-insecure", false, "skip TLS certificate verification of the -devtools endpoint")
+	fs.StringVar(&opts.connection.Proxy, "devtools-proxy", "", "http:// or socks5:// proxy used to reach the -devtools endpoint")
+	fs.StringVar(&opts.browserPath, "browser", "", "browser executable to launch; Chrome, Edge, Brave or Chromium is searched when empty")
+	fs.StringVar(&opts.engine, "engine", "auto", "browser searched when -browser is empty: auto, chrome, edge, brave or chromium")
+	fs.Var(&opts.browserArgs, "browser-arg", "extra argument for the launched browser (repeatable)")
+	fs.BoolVar(&opts.headless, "headless", true, "launch the browser in headless mode")
+	fs.IntVar(&opts.port, "port", 0, "remote debugging port of the launched browser, 0 picks a free port starting at 9222")
+	fs.StringVar(&opts.rulesPath, "rules", "", "rules config JSON file; without it events are only recorded")
+	fs.BoolVar(&opts.watchRules, "watch", false, "reload the -rules file whenever it changes")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "evaluate rules and report what they would change, but let all traffic through untouched")
+	fs.StringVar(&opts.testEvents, "test-events", "", "replay captured NDJSON events through the -rules file offline and report what would change, without a browser")
+	fs.Var(&opts.targets, "target", "target ID to attach (repeatable); all page targets are attached when omitted")
+	fs.BoolVar(&opts.traffic, "traffic", false, "stream all intercepted traffic instead of matched events only")
+	fs.DurationVar(&opts.duration, "duration", 0, "stop after this long, 0 runs until interrupted")
+	fs.IntVar(&opts.concurrency, "concurrency", 0, "number of paused requests processed concurrently, 0 means unlimited")
+	fs.StringVar(&opts.network, "network", "", "emulate network conditions with a preset: offline, slow-3g, fast-3g or 4g")
+	fs.Func("types", "only intercept these comma-separated resource types, e.g. document,xhr,fetch", func(v string) (err error) {
+		opts.include, err = domain.ParseResourceTypes(v)
+		return err
+	})
+	fs.Func("skip-types", "let these comma-separated resource types through without interception, e.g. image,font,media", func(v string) (err error) {
+		opts.exclude, err = domain.ParseResourceTypes(v)
+		return err
+	})
+	fs.StringVar(&opts.replay, "replay", "", "record-and-replay cache: record responses, replay them (recording misses) or offline (failing misses)")
+	fs.StringVar(&opts.replayDir, "replay-dir", "", "directory of the -replay cache, reused across runs; kept in memory only when empty")
+	fs.StringVar(&opts.logLevel, "log-level", "warn", "log level written to stderr: debug, info, warn or error")
+	fs.StringVar(&opts.logFormat, "log-format", "text", "log format written to stderr: text or json")
+	fs.StringVar(&opts.grpcAddr, "grpc", "", "serve the gRPC control plane on this address instead of running a session, e.g. 127.0.0.1:50051")
+	fs.StringVar(&opts.grpcCert, "grpc-cert", "", "PEM certificate file serving the -grpc control plane over TLS")
+	fs.StringVar(&opts.grpcKey, "grpc-key", "", "PEM private key file of -grpc-cert")
+	fs.StringVar(&opts.grpcToken, "grpc-token-env", "", "environment variable holding the access token -grpc clients send as \"authorization: Bearer <token>\"")
+	fs.StringVar(&opts.grpcRead, "grpc-readonly-token-env", "", "environment variable holding a read-only -grpc access token that can only list targets, read stats and breakpoint status and subscribe to events")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("-devtools-header %q must be \"Name: value\"", h)
+		}
+		if opts.connection.Headers == nil {
+			opts.connection.Headers = make(map[string]string)
+		}
+		opts.connection.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	if auth != "" {
+		opts.connection.Username, opts.connection.Password, _ = strings.Cut(auth, ":")
+	}
+	if !opts.connection.IsZero() && opts.devToolsURL == "" {
+		return nil, errors.New("-devtools-* options require -devtools")
+	}
+	if err := opts.connection.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.watchRules && opts.rulesPath == "" {
+		return nil, errors.New("-watch requires -rules")
+	}
+	if opts.testEvents != "" && opts.rulesPath == "" {
+		return nil, errors.New("-test-events requires -rules")
+	}
+	if _, err := browser.ParseEngine(opts.engine); err != nil {
+		return nil, err
+	}
+	if opts.network != "" {
+		if _, ok := domain.LookupNetworkPreset(opts.network); !ok {
+			return nil, fmt.Errorf("unknown network preset %q", opts.network)
+		}
+	}
+	if err := (domain.ReplayCacheOptions{Mode: domain.ReplayCacheMode(opts.replay)}).Validate(); err != nil {
+		return nil, fmt.Errorf("unknown replay mode %q", opts.replay)
+	}
+	if opts.replayDir != "" && opts.replay == "" {
+		return nil, errors.New("-replay-dir requires -replay")
+	}
+	if (opts.grpcCert != "" || opts.grpcKey != "" || opts.grpcToken != "" || opts.grpcRead != "") && opts.grpcAddr == "" {
+		return nil, errors.New("-grpc-* options require -grpc")
+	}
+	if (opts.grpcCert == "") != (opts.grpcKey == "") {
+		return nil, errors.New("-grpc-cert and -grpc-key must be set together")
+	}
+	if opts.grpcAddr != "" {
+		if err := apigrpc.CheckListenAddr(opts.grpcAddr, opts.grpcCert != "", opts.grpcToken != "" || opts.grpcRead != ""); err != nil {
+			return nil, err
+		}
+	}
+	switch opts.logLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return nil, fmt.Errorf("unknown log level %q", opts.logLevel)
+	}
+	if err := logger.ValidateFormat(opts.logFormat); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// run 运行一次拦截会话，直到 ctx 取消、达到 -duration 或事件流结束；第一个参数为 tail 时改为运行 tail 子命令
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) > 0 && args[0] == "tail" {
+		return tail(ctx, args[1:], stdout, stderr)
+	}
+	opts, err := parseFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+	log := logger.New(logger.Options{Level: opts.logLevel, Format: opts.logFormat, Writers: []string{"console"}})
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// 导出地址不可达时不长时间阻塞退出
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(sctx); err != nil {
+			log.Warn("导出剩余的追踪数据失败", "error", err)
+		}
+	}()
+	if opts.grpcAddr != "" {
+		return serveGRPC(ctx, opts, api.NewService(log), stderr)
+	}
+
+	var cfg *rulespec.Config
+	if opts.rulesPath != "" {
+		data, err := os.ReadFile(opts.rulesPath)
+		if err != nil {
+			return err
+		}
+		if cfg, _, err = rulespec.ParseConfig(data); err != nil {
+			return fmt.Errorf("parse rules %s: %w", opts.rulesPath, err)
+		}
+	}
+
+	if opts.testEvents != "" {
+		return testRules(ctx, api.NewService(log), cfg, opts.testEvents, stdout, stderr)
+	}
+
+	if opts.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	devToolsURL := opts.devToolsURL
+	if devToolsURL == "" {
+		engine, _ := browser.ParseEngine(opts.engine)
+		b, err := browser.Start(ctx, browser.Options{
+			Engine:              engine,
+			ExecPath:            opts.browserPath,
+			RemoteDebuggingPort: opts.port,
+			Headless:            opts.headless,
+			Args:                opts.browserArgs,
+			ClearUserData:       true,
+			Logger:              log,
+		})
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := b.Stop(5 * time.Second); err != nil {
+				log.Warn("关闭浏览器失败", "error", err)
+			}
+		}()
+		devToolsURL = b.DevToolsURL
+		fmt.Fprintln(stderr, "browser DevTools listening on", devToolsURL)
+	}
+
+	svc := api.NewService(log)
+	var network *domain.NetworkConditions
+	if opts.network != "" {
+		c, _ := domain.LookupNetworkPreset(opts.network)
+		network = &c
+	}
+	var connection *domain.ConnectionOptions
+	if !opts.connection.IsZero() {
+		connection = &opts.connection
+	}
+	id, err := svc.StartSession(ctx, domain.SessionConfig{
+		DevToolsURL:       devToolsURL,
+		Connection:        connection,
+		Concurrency:       opts.concurrency,
+		PendingCapacity:   eventBuffer,
+		ProcessTimeoutMS:  int(config.GetDefaultSettings().SessionProcessTimeout.Milliseconds()),
+		NetworkConditions: network,
+
+		IncludeResourceTypes: opts.include,
+		ExcludeResourceTypes: opts.exclude,
+	})
+	if err != nil {
+		return err
+	}
+	// 会话在 ctx 取消后结束，停止时不再受 ctx 约束
+	defer func() {
+		if err := svc.StopSession(context.WithoutCancel(ctx), id); err != nil {
+			log.Warn("停止会话失败", "error", err)
+		}
+	}()
+
+	if opts.dryRun {
+		if err := svc.SetDryRun(ctx, id, true); err != nil {
+			return err
+		}
+	}
+	if opts.replay != "" {
+		replay := domain.ReplayCacheOptions{Mode: domain.ReplayCacheMode(opts.replay), Dir: opts.replayDir}
+		if _, err := svc.SetReplayMode(ctx, id, replay); err != nil {
+			return err
+		}
+	}
+	if opts.watchRules {
+		if err := svc.WatchRulesFile(ctx, id, opts.rulesPath); err != nil {
+			return err
+		}
+	} else if cfg != nil {
+		if err := svc.LoadRules(ctx, id, cfg); err != nil {
+			return err
+		}
+	}
+	if err := attachTargets(ctx, svc, id, opts.targets); err != nil {
+		return err
+	}
+
+	var events <-chan domain.NetworkEvent
+	if opts.traffic {
+		if err := svc.EnableTrafficCapture(ctx, id, true); err != nil {
+			return err
+		}
+		events, err = svc.SubscribeTraffic(ctx, id)
+	} else {
+		events, err = svc.SubscribeEvents(ctx, id, 0)
+	}
+	if err != nil {
+		return err
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		return err
+	}
+	return writeEvents(ctx, events, id, stdout)
+}
+
+// testRules 读取 NDJSON 格式的捕获事件并以 cfg 离线重放，每个事件的结果逐行写入 stdout；
+// 存在与捕获时不同的结果时返回错误，便于在 CI 中做规则回归测试
+func testRules(ctx context.Context, svc api.Service, cfg *rulespec.Config, path string, stdout, stderr io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var opts domain.RuleTestOptions
+	dec := json.NewDecoder(f)
+	for {
+		var evt domain.NetworkEvent
+		if err := dec.Decode(&evt); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("parse events %s: %w", path, err)
+		}
+		opts.Events = append(opts.Events, evt)
+	}
+
+	res, err := svc.TestRules(ctx, cfg, opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(stdout)
+	for _, c := range res.Cases {
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(stderr, "%d events, %d matched, %d modified, %d changed\n", res.Events, res.Matched, res.Modified, res.Changed)
+	if res.Changed > 0 {
+		return fmt.Errorf("%d of %d events changed", res.Changed, res.Events)
+	}
+	return nil
+}
+
+// serveGRPC 在 -grpc 地址上提供 gRPC 控制面，直到 ctx 取消；设置了证书时启用 TLS，设置了令牌时校验每次调用
+func serveGRPC(ctx context.Context, opts *options, svc api.Service, stderr io.Writer) error {
+	var keys []apigrpc.Key
+	for _, k := range []struct {
+		flag, env string
+		scope     apigrpc.Scope
+	}{
+		{"-grpc-token-env", opts.grpcToken, apigrpc.ScopeFull},
+		{"-grpc-readonly-token-env", opts.grpcRead, apigrpc.ScopeReadOnly},
+	} {
+		if k.env == "" {
+			continue
+		}
+		token := os.Getenv(k.env)
+		if token == "" {
+			return fmt.Errorf("%s: environment variable %s is not set", k.flag, k.env)
+		}
+		keys = append(keys, apigrpc.Key{Token: token, Scope: k.scope})
+	}
+	if len(keys) == 2 && keys[0].Token == keys[1].Token {
+		return errors.New("-grpc-token-env and -grpc-readonly-token-env must hold different tokens")
+	}
+	serverOpts := apigrpc.ServerOptions(keys...)
+	if opts.grpcCert != "" {
+		creds, err := credentials.NewServerTLSFromFile(opts.grpcCert, opts.grpcKey)
+		if err != nil {
+			return err
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+
+	lis, err := net.Listen("tcp", opts.grpcAddr)
+	if err != nil {
+		return err
+	}
+	gs := grpc.NewServer(serverOpts...)
+	apigrpc.NewServer(svc).Register(gs)
+	fmt.Fprintln(stderr, "gRPC control plane listening on", lis.Addr())
+
+	go func() {
+		<-ctx.Done()
+		gs.Stop()
+	}()
+	return gs.Serve(lis)
+}
+
+// attachTargets 附着指定的目标，未指定时附着所有页面目标
+func attachTargets(ctx context.Context, svc api.Service, id domain.SessionID, targets []string) error {
+	if len(targets) == 0 {
+		infos, err := svc.ListTargets(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, t := range infos {
+			targets = append(targets, string(t.ID))
+		}
+	}
+	if len(targets) == 0 {
+		return domain.ErrNoTargetAttached
+	}
+	for _, t := range targets {
+		if err := svc.AttachTarget(ctx, id, domain.TargetID(t)); err != nil {
+			return fmt.Errorf("attach target %s: %w", t, err)
+		}
+	}
+	return nil
+}
+
+// writeEvents 将事件逐行编码为 JSON 写入 w，ctx 取消或事件流结束时返回
+func writeEvents(ctx context.Context, events <-chan domain.NetworkEvent, id domain.SessionID, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case evt, ok := <-events:
+			if !ok {
+				return nil
+			}
+			evt.Session = id
+			if err := enc.Encode(evt); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/cdptest"
+	"cdpnetool/pkg/apigrpc"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/protocol/network"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// syncBuffer 可在 run 写入时并发读取的缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestParseFlags(t *testing.T) {
+	opts, err := parseFlags([]string{"-devtools", "http://127.0.0.1:9222", "-target", "a", "-target", "b", "-traffic", "-duration", "30s", "-skip-types", "image,Font"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if opts.devToolsURL != "http://127.0.0.1:9222" || len(opts.targets) != 2 || !opts.traffic || opts.duration != 30*time.Second || !opts.headless ||
+		len(opts.exclude) != 2 || opts.exclude[1] != domain.ResourceTypeFont {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	opts, err = parseFlags([]string{"-devtools", "https://gw", "-devtools-header", "X-Token: t0k", "-devtools-auth", "alice:se:cret"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if c := opts.connection; c.Headers["X-Token"] != "t0k" || c.Username != "alice" || c.Password != "se:cret" {
+		t.Errorf("unexpected connection options: %+v", c)
+	}
+
+	for _, args := range [][]string{{"extra"}, {"-log-level", "verbose"}, {"-log-format", "xml"}, {"-engine", "safari"}, {"-devtools-host", "localhost:9222"}, {"-devtools", "https://gw", "-devtools-header", "token"}, {"-devtools", "https://gw", "-devtools-proxy", "https://proxy:3128"}, {"-network", "5g"}, {"-replay", "rewind"}, {"-replay-dir", "/tmp/cache"}, {"-types", "gif"}, {"-grpc", ":50051"}, {"-grpc", "0.0.0.0:50051", "-grpc-token-env", "TOKEN"}, {"-grpc-token-env", "TOKEN"}, {"-grpc-readonly-token-env", "TOKEN"}, {"-grpc", "0.0.0.0:50051", "-grpc-readonly-token-env", "TOKEN"}, {"-grpc", "127.0.0.1:0", "-grpc-cert", "cert.pem"}, {"-unknown"}} {
+		if _, err := parseFlags(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("parseFlags(%v) should fail", args)
+		}
+	}
+}
+
+func TestRun_StreamsEvents(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	cfg := rulespec.NewConfig("ci")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "block-ads", Name: "block ads", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/ads"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}}
+	data, _ := json.Marshal(cfg)
+	rulesPath := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(rulesPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	runCtx, stop := context.WithCancel(ctx)
+	var stdout syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(runCtx, []string{"-devtools", srv.URL(), "-rules", rulesPath}, &stdout, &bytes.Buffer{})
+	}()
+
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+	ev := &fetch.RequestPausedReply{
+		RequestID: "req1",
+		Request:   network.Request{URL: "https://example.com/ads/banner.js", Method: "GET", Headers: network.Headers([]byte(`{}`))},
+	}
+	if err := srv.Pause("page1", ev); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.fulfillRequest", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 等待事件写出后结束运行
+	for !strings.Contains(stdout.String(), "\n") {
+		select {
+		case <-ctx.Done():
+			t.Fatal("no event written")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	stop()
+	if err := <-done; err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(stdout.String()))
+	var events []domain.NetworkEvent
+	for sc.Scan() {
+		var evt domain.NetworkEvent
+		if err := json.Unmarshal(sc.Bytes(), &evt); err != nil {
+			t.Fatalf("line %q is not JSON: %v", sc.Text(), err)
+		}
+		events = append(events, evt)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	evt := events[0]
+	if evt.Session == "" || evt.Target != "page1" || evt.Request.URL != ev.Request.URL || evt.FinalResult != "blocked" {
+		t.Errorf("unexpected event: %+v", evt)
+	}
+	if len(evt.MatchedRules) != 1 || evt.MatchedRules[0].RuleID != "block-ads" {
+		t.Errorf("got matched rules %+v, want block-ads", evt.MatchedRules)
+	}
+}
+
+func TestRun_NoTargets(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := run(ctx, []string{"-devtools", srv.URL()}, &bytes.Buffer{}, &bytes.Buffer{})
+	if !errors.Is(err, domain.ErrNoTargetAttached) {
+		t.Errorf("got error %v, want ErrNoTargetAttached", err)
+	}
+}
+
+func TestRun_TestEvents(t *testing.T) {
+	dir := t.TempDir()
+	cfg := rulespec.NewConfig("ci")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "block-ads", Name: "block ads", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/ads"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}}
+	data, _ := json.Marshal(cfg)
+	rulesPath := filepath.Join(dir, "rules.json")
+	if err := os.WriteFile(rulesPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 以 NDJSON 写入捕获的事件，与会话模式的输出格式一致
+	var events bytes.Buffer
+	enc := json.NewEncoder(&events)
+	_ = enc.Encode(domain.NetworkEvent{
+		ID: "1", FinalResult: "blocked", IsMatched: true,
+		Request:      domain.Request{ID: "1", URL: "https://example.com/ads/a.js", Method: "GET"},
+		Response:     &domain.Response{StatusCode: 403},
+		MatchedRules: []domain.RuleMatch{{RuleID: "block-ads"}},
+	})
+	_ = enc.Encode(domain.NetworkEvent{
+		ID: "2", FinalResult: "passed",
+		Request:  domain.Request{ID: "2", URL: "https://example.com/", Method: "GET"},
+		Response: &domain.Response{StatusCode: 200},
+	})
+	eventsPath := filepath.Join(dir, "events.ndjson")
+	if err := os.WriteFile(eventsPath, events.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run(context.Background(), []string{"-rules", rulesPath, "-test-events", eventsPath}, &stdout, &stderr); err != nil {
+		t.Fatalf("run() error = %v, stderr: %s", err, stderr.String())
+	}
+	if lines := strings.Count(stdout.String(), "\n"); lines != 2 {
+		t.Errorf("got %d result lines, want 2: %s", lines, stdout.String())
+	}
+
+	// 规则集删除拦截规则后，捕获时被拦截的事件结果变化
+	cfg.Rules = nil
+	data, _ = json.Marshal(cfg)
+	if err := os.WriteFile(rulesPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := run(context.Background(), []string{"-rules", rulesPath, "-test-events", eventsPath}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 events changed") {
+		t.Errorf("got error %v, want 1 of 2 events changed", err)
+	}
+
+	if _, err := parseFlags([]string{"-test-events", eventsPath}, &bytes.Buffer{}); err == nil {
+		t.Error("-test-events without -rules should fail")
+	}
+}
+
+func TestRun_ServesGRPC(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	t.Setenv("CDPNETOOL_TEST_TOKEN", "t0k")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	runCtx, stop := context.WithCancel(ctx)
+	var stderr syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(runCtx, []string{"-grpc", "127.0.0.1:0", "-grpc-token-env", "CDPNETOOL_TEST_TOKEN"}, &bytes.Buffer{}, &stderr)
+	}()
+
+	const prefix = "gRPC control plane listening on "
+	var addr string
+	for addr == "" {
+		select {
+		case <-ctx.Done():
+			t.Fatal("gRPC server did not start")
+		case <-time.After(10 * time.Millisecond):
+		}
+		if _, rest, ok := strings.Cut(stderr.String(), prefix); ok && strings.Contains(rest, "\n") {
+			addr = strings.TrimSpace(rest)
+		}
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := apigrpc.NewControlClient(conn)
+	if _, err := client.StartSession(ctx, &apigrpc.StartSessionRequest{DevtoolsUrl: srv.URL()}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("got %v without token, want Unauthenticated", err)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, apigrpc.TokenMetadataKey, "Bearer t0k")
+	started, err := client.StartSession(ctx, &apigrpc.StartSessionRequest{DevtoolsUrl: srv.URL()})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	if _, err := client.AttachTarget(ctx, &apigrpc.TargetRequest{SessionId: started.GetSessionId(), TargetId: "page1"}); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+
+	stop()
+	if err := <-done; err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cdpnetool/pkg/apigrpc"
+	"cdpnetool/pkg/domain"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tailOptions tail 子命令参数
+type tailOptions struct {
+	addr     string
+	session  string
+	after    uint64
+	tokenEnv string // 保存访问令牌的环境变量名
+	tls      bool
+	caFile   string
+	insecure bool
+	filter   tailFilter
+}
+
+// tailFilter 事件过滤条件，零值不过滤
+type tailFilter struct {
+	urlContains string
+	method      string
+	status      int
+	matched     bool
+	rule        string
+	result      string
+}
+
+// match 判断事件是否满足全部过滤条件
+func (f tailFilter) match(evt domain.NetworkEvent) bool {
+	if f.urlContains != "" && !strings.Contains(evt.Request.URL, f.urlContains) {
+		return false
+	}
+	if f.method != "" && !strings.EqualFold(evt.Request.Method, f.method) {
+		return false
+	}
+	if f.status != 0 && (evt.Response == nil || evt.Response.StatusCode != f.status) {
+		return false
+	}
+	if f.matched && !evt.IsMatched {
+		return false
+	}
+	if f.result != "" && evt.FinalResult != f.result {
+		return false
+	}
+	if f.rule != "" {
+		for _, m := range evt.MatchedRules {
+			if m.RuleID == f.rule {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// parseTailFlags 解析 tail 子命令参数，错误与用法说明写到 stderr
+func parseTailFlags(args []string, stderr io.Writer) (*tailOptions, error) {
+	opts := &tailOptions{}
+	fs := flag.NewFlagSet("cdpnetool tail", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.addr, "addr", "127.0.0.1:50051", "address of the gRPC control plane started with -grpc")
+	fs.StringVar(&opts.session, "session", "", "ID of the session whose events are streamed (required)")
+	fs.Uint64Var(&opts.after, "after", 0, "only stream events with a sequence number greater than this, 0 starts at the oldest buffered event")
+	fs.StringVar(&opts.tokenEnv, "token-env", "", "environment variable holding the access token sent as \"authorization: Bearer <token>\"")
+	fs.BoolVar(&opts.tls, "tls", false, "connect over TLS, verifying the server with the system roots")
+	fs.StringVar(&opts.caFile, "ca", "", "PEM file of the CA that signed the server certificate, implies -tls")
+	fs.BoolVar(&opts.insecure, "insecure", false, "skip TLS certificate verification, implies -tls")
+	fs.StringVar(&opts.filter.urlContains, "url", "", "only print events whose request URL contains this substring")
+	fs.StringVar(&opts.filter.method, "method", "", "only print events with this request method, case-insensitive")
+	fs.IntVar(&opts.filter.status, "status", 0, "only print events whose response has this status code")
+	fs.BoolVar(&opts.filter.matched, "matched", false, "only print events matched by a rule")
+	fs.StringVar(&opts.filter.rule, "rule", "", "only print events matched by the rule with this ID")
+	fs.StringVar(&opts.filter.result, "result", "", "only print events with this final result: blocked, modified or passed")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if opts.session == "" {
+		return nil, errors.New("tail requires -session")
+	}
+	if opts.caFile != "" && opts.insecure {
+		return nil, errors.New("-ca and -insecure are mutually exclusive")
+	}
+	opts.tls = opts.tls || opts.caFile != "" || opts.insecure
+	switch opts.filter.result {
+	case "", "blocked", "modified", "passed":
+	default:
+		return nil, fmt.Errorf("unknown result %q", opts.filter.result)
+	}
+	return opts, nil
+}
+
+// tail 订阅远程会话的事件流，将满足过滤条件的事件逐行编码为 JSON 写入 stdout，直到 ctx 取消或服务端结束事件流
+func tail(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	opts, err := parseTailFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+	creds := insecure.NewCredentials()
+	switch {
+	case opts.caFile != "":
+		if creds, err = credentials.NewClientTLSFromFile(opts.caFile, ""); err != nil {
+			return err
+		}
+	case opts.tls:
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: opts.insecure})
+	}
+	if opts.tokenEnv != "" {
+		token := os.Getenv(opts.tokenEnv)
+		if token == "" {
+			return fmt.Errorf("-token-env: environment variable %s is not set", opts.tokenEnv)
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, apigrpc.TokenMetadataKey, "Bearer "+token)
+	}
+
+	conn, err := grpc.NewClient(opts.addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stream, err := apigrpc.NewControlClient(conn).SubscribeEvents(ctx, &apigrpc.SubscribeEventsRequest{SessionId: opts.session, After: opts.after})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(stdout)
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF || status.Code(err) == codes.Canceled && ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if evt := apigrpc.FromEvent(e); opts.filter.match(evt) {
+			if err := enc.Encode(evt); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/cdptest"
+	"cdpnetool/internal/logger"
+	"cdpnetool/pkg/api"
+	"cdpnetool/pkg/apigrpc"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/protocol/network"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseTailFlags(t *testing.T) {
+	opts, err := parseTailFlags([]string{"-session", "s1", "-after", "7", "-ca", "ca.pem", "-url", "/api", "-method", "post", "-status", "500", "-matched", "-result", "modified"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parseTailFlags() error = %v", err)
+	}
+	if opts.addr != "127.0.0.1:50051" || opts.session != "s1" || opts.after != 7 || !opts.tls ||
+		opts.filter != (tailFilter{urlContains: "/api", method: "post", status: 500, matched: true, result: "modified"}) {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	for _, args := range [][]string{{}, {"-session", "s1", "extra"}, {"-session", "s1", "-ca", "ca.pem", "-insecure"}, {"-session", "s1", "-result", "dropped"}, {"-session", "s1", "-unknown"}} {
+		if _, err := parseTailFlags(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("parseTailFlags(%v) should fail", args)
+		}
+	}
+}
+
+func TestTailFilter(t *testing.T) {
+	evt := domain.NetworkEvent{
+		IsMatched:    true,
+		FinalResult:  "modified",
+		Request:      domain.Request{URL: "https://example.com/api/users", Method: "POST"},
+		Response:     &domain.Response{StatusCode: 500},
+		MatchedRules: []domain.RuleMatch{{RuleID: "r1"}, {RuleID: "r2"}},
+	}
+	tests := map[string]struct {
+		filter tailFilter
+		want   bool
+	}{
+		"zero":   {tailFilter{}, true},
+		"all":    {tailFilter{urlContains: "/api", method: "post", status: 500, matched: true, rule: "r2", result: "modified"}, true},
+		"url":    {tailFilter{urlContains: "/static"}, false},
+		"method": {tailFilter{method: "GET"}, false},
+		"status": {tailFilter{status: 200}, false},
+		"rule":   {tailFilter{rule: "r3"}, false},
+		"result": {tailFilter{result: "blocked"}, false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.filter.match(evt); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 请求阶段结束的事件没有响应，指定 -status 时不输出；未匹配规则的事件在 -matched 下不输出
+	noReply := evt
+	noReply.Response = nil
+	if (tailFilter{status: 500}).match(noReply) {
+		t.Error("-status matched an event without a response")
+	}
+	unmatched := evt
+	unmatched.IsMatched = false
+	if (tailFilter{matched: true}).match(unmatched) {
+		t.Error("-matched matched an unmatched event")
+	}
+}
+
+func TestTail_StreamsFilteredEvents(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	t.Setenv("CDPNETOOL_TEST_TOKEN", "viewer")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer(apigrpc.ServerOptions(
+		apigrpc.Key{Token: "admin", Scope: apigrpc.ScopeFull},
+		apigrpc.Key{Token: "viewer", Scope: apigrpc.ScopeReadOnly},
+	)...)
+	apigrpc.NewServer(api.NewService(logger.NewNop())).Register(gs)
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := apigrpc.NewControlClient(conn)
+	admin := metadata.AppendToOutgoingContext(ctx, apigrpc.TokenMetadataKey, "Bearer admin")
+	started, err := client.StartSession(admin, &apigrpc.StartSessionRequest{DevtoolsUrl: srv.URL()})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	id := started.GetSessionId()
+	cfg := rulespec.NewConfig("tail")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "block", Name: "block", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AnyOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/ads"}, {Type: rulespec.ConditionURLContains, Value: "/track"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}}
+	data, _ := json.Marshal(cfg)
+	for _, call := range []func() error{
+		func() error {
+			_, err := client.AttachTarget(admin, &apigrpc.TargetRequest{SessionId: id, TargetId: "page1"})
+			return err
+		},
+		func() error {
+			_, err := client.LoadRules(admin, &apigrpc.LoadRulesRequest{SessionId: id, ConfigJson: string(data)})
+			return err
+		},
+		func() error {
+			_, err := client.EnableInterception(admin, &apigrpc.SessionRequest{SessionId: id})
+			return err
+		},
+	} {
+		if err := call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+	// 先暂停的请求被 -url 过滤掉，后暂停的请求写出时前一个事件必然已经过滤完
+	for i, url := range []string{"https://example.com/ads/banner.js", "https://example.com/track?e=view"} {
+		ev := &fetch.RequestPausedReply{
+			RequestID: fetch.RequestID(fmt.Sprintf("req%d", i+1)),
+			Request:   network.Request{URL: url, Method: "GET", Headers: network.Headers([]byte(`{}`))},
+		}
+		if err := srv.Pause("page1", ev); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := srv.WaitCall(ctx, "Fetch.fulfillRequest", i+1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tailCtx, stop := context.WithCancel(ctx)
+	var stdout syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(tailCtx, []string{"tail", "-addr", lis.Addr().String(), "-token-env", "CDPNETOOL_TEST_TOKEN", "-session", id, "-url", "/track", "-matched"}, &stdout, &bytes.Buffer{})
+	}()
+	for !strings.Contains(stdout.String(), "\n") {
+		select {
+		case <-ctx.Done():
+			t.Fatal("no event written")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	stop()
+	if err := <-done; err != nil {
+		t.Fatalf("tail error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want only the /track event: %s", len(lines), stdout.String())
+	}
+	var evt domain.NetworkEvent
+	if err := json.Unmarshal([]byte(lines[0]), &evt); err != nil {
+		t.Fatalf("line %q is not JSON: %v", lines[0], err)
+	}
+	if string(evt.Session) != id || evt.Target != "page1" || evt.Request.URL != "https://example.com/track?e=view" || evt.FinalResult != "blocked" || evt.Seq != 2 {
+		t.Errorf("unexpected event: %+v", evt)
+	}
+}
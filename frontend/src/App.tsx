@@ -15,6 +15,7 @@ import { AboutDialog } from '@/components/about'
 import { api } from '@/api'
 import { useTranslation } from 'react-i18next'
 import { getErrorMessage } from '@/lib/error-handler'
+import type { ConnectionEvent } from '@/types/events'
 import { 
   Link2,
   Link2Off,
@@ -204,13 +205,22 @@ function App() {
       const unsubscribeIntercept = window.runtime.EventsOn('intercept-event', addInterceptEvent)
       // @ts-ignore
       const unsubscribeTraffic = window.runtime.EventsOn('traffic-event', addTrafficEvent)
+      // @ts-ignore
+      const unsubscribeConnection = window.runtime.EventsOn('connection-event', (ev: ConnectionEvent) => {
+        toast({
+          variant: ev.type === 'reconnectFailed' ? 'destructive' : ev.type === 'reconnected' ? 'success' : 'default',
+          title: t(`targets.${ev.type}`, { target: ev.targetId }),
+          description: ev.error,
+        })
+      })
       
       return () => {
         if (unsubscribeIntercept) unsubscribeIntercept()
         if (unsubscribeTraffic) unsubscribeTraffic()
+        if (unsubscribeConnection) unsubscribeConnection()
       }
     }
-  }, [addInterceptEvent, addTrafficEvent])
+  }, [addInterceptEvent, addTrafficEvent, toast, t])
 
   return (
     <div className="h-screen flex flex-col bg-background text-foreground">
@@ -158,11 +158,14 @@ function EventDetailView({ event }: { event: MatchedEventWithId }) {
   const { t } = useTranslation()
   const { networkEvent } = event
   const { request, response, matchedRules, finalResult } = networkEvent
+  const secrets = [...(request.secrets || []), ...(response?.secrets || [])]
 
   // 状态管理：默认展开常规
   const [collapsed, setCollapsed] = useState({
     general: false,
     rules: true,
+    violations: false,
+    secrets: false,
     responseHeaders: true,
     requestHeaders: true,
   })
@@ -202,13 +205,26 @@ function EventDetailView({ event }: { event: MatchedEventWithId }) {
     return (bytes / (1024 * 1024)).toFixed(2) + ' MB'
   }
 
+  // 解码后的 JSON 格式化展示
+  const formatDecoded = (decoded: string): string => {
+    try {
+      return JSON.stringify(JSON.parse(decoded), null, 2)
+    } catch {
+      return decoded
+    }
+  }
+
   const formattedRequestBody = useMemo(() => {
     if (!request.body) return null
+    if (request.decoded) return formatDecoded(request.decoded)
     return decodeBase64(request.body)
-  }, [request.body])
+  }, [request.body, request.decoded])
 
   const formattedResponseBody = useMemo((): { isPreviewable: boolean; content?: string; size?: string; type?: string } | null => {
     if (!response?.body) return null
+    if (response.decoded) {
+      return { isPreviewable: true, content: formatDecoded(response.decoded) }
+    }
     
     const contentType = response.headers?.['content-type'] || ''
     
@@ -225,7 +241,7 @@ function EventDetailView({ event }: { event: MatchedEventWithId }) {
     // 文本类型：解码 base64
     const decoded = decodeBase64(response.body)
     return { isPreviewable: true, content: decoded }
-  }, [response?.body, response?.headers])
+  }, [response?.body, response?.decoded, response?.headers])
 
   return (
     <div className="border-t bg-card">
@@ -278,6 +294,18 @@ function EventDetailView({ event }: { event: MatchedEventWithId }) {
                         <span className="font-semibold text-blue-600 dark:text-blue-400 selectable">{request.resourceType}</span>
                       </div>
                     )}
+                    {request.correlationId && (
+                      <div className="flex gap-2">
+                        <span className="text-muted-foreground min-w-[140px] shrink-0">{t('events.fields.correlationId')}:</span>
+                        <span className="break-all selectable">{request.correlationId}</span>
+                      </div>
+                    )}
+                    {request.traceId && (
+                      <div className="flex gap-2">
+                        <span className="text-muted-foreground min-w-[140px] shrink-0">{t('events.fields.traceId')}:</span>
+                        <span className="break-all font-mono selectable">{request.traceId}</span>
+                      </div>
+                    )}
                     {response && response.statusCode !== undefined && response.statusCode !== null && (
                       <div className="flex gap-2">
                         <span className="text-muted-foreground min-w-[140px] shrink-0">{t('events.fields.statusCode')}:</span>
@@ -377,6 +405,50 @@ function EventDetailView({ event }: { event: MatchedEventWithId }) {
                   )}
                 </section>
               )}
+
+              {/* Schema 违规 */}
+              {response?.violations && response.violations.length > 0 && (
+                <section className="pb-2">
+                  <button 
+                    onClick={() => toggleSection('violations')}
+                    className="w-full flex items-center gap-1 text-[11px] font-bold text-muted-foreground uppercase hover:text-foreground transition-colors"
+                  >
+                    {collapsed.violations ? <ChevronRight className="w-3 h-3" /> : <ChevronDown className="w-3 h-3" />} {t('events.sections.violations')}
+                  </button>
+                  {!collapsed.violations && (
+                    <div className="mt-2 ml-4 space-y-1 text-xs font-mono">
+                      {response.violations.map((v, idx) => (
+                        <div key={idx} className="flex gap-2 py-0.5">
+                          <span className="text-orange-500 min-w-[140px] shrink-0 selectable">{v.kind ? `[${v.kind}] ` : ''}{v.path || '/'}</span>
+                          <span className="break-all selectable">{v.message}</span>
+                        </div>
+                      ))}
+                    </div>
+                  )}
+                </section>
+              )}
+
+              {/* 敏感信息 */}
+              {secrets.length > 0 && (
+                <section className="pb-2">
+                  <button 
+                    onClick={() => toggleSection('secrets')}
+                    className="w-full flex items-center gap-1 text-[11px] font-bold text-muted-foreground uppercase hover:text-foreground transition-colors"
+                  >
+                    {collapsed.secrets ? <ChevronRight className="w-3 h-3" /> : <ChevronDown className="w-3 h-3" />} {t('events.sections.secrets')}
+                  </button>
+                  {!collapsed.secrets && (
+                    <div className="mt-2 ml-4 space-y-1 text-xs font-mono">
+                      {secrets.map((f, idx) => (
+                        <div key={idx} className="flex gap-2 py-0.5">
+                          <span className="text-purple-500 min-w-[140px] shrink-0 selectable">{f.location}{f.field ? `: ${f.field}` : ''} @{f.offset}</span>
+                          <span className="break-all selectable">[{f.detector}] {f.match}</span>
+                        </div>
+                      ))}
+                    </div>
+                  )}
+                </section>
+              )}
             </div>
           </div>
         </TabsContent>
@@ -386,7 +458,7 @@ function EventDetailView({ event }: { event: MatchedEventWithId }) {
             {request.body ? (
               <>
                 <div className="flex items-center justify-between mb-2">
-                  <div className="text-[11px] font-bold text-muted-foreground uppercase">{t('events.payload.title')}</div>
+                  <div className="text-[11px] font-bold text-muted-foreground uppercase">{request.decoded ? t('events.payload.decoded') : t('events.payload.title')}</div>
                   <CopyButton content={formattedRequestBody || ''} />
                 </div>
                 <div className="max-h-[300px] overflow-auto">
@@ -408,7 +480,7 @@ function EventDetailView({ event }: { event: MatchedEventWithId }) {
             {response?.body ? (
               <>
                 <div className="flex items-center justify-between mb-2">
-                  <div className="text-[11px] font-bold text-muted-foreground uppercase">{t('events.response.title')}</div>
+                  <div className="text-[11px] font-bold text-muted-foreground uppercase">{response.decoded ? t('events.response.decoded') : t('events.response.title')}</div>
                   {formattedResponseBody && 'isPreviewable' in formattedResponseBody && formattedResponseBody.isPreviewable && formattedResponseBody.content && (
                     <CopyButton content={formattedResponseBody.content} />
                   )}
@@ -187,9 +187,19 @@ function TrafficDetailView({ request, response }: { request: TrafficRequest, res
     setCollapsed(prev => ({ ...prev, [key]: !prev[key] }))
   }
   
+  // 解码后的 JSON 格式化展示
+  const formatDecoded = (decoded: string): string => {
+    try {
+      return JSON.stringify(JSON.parse(decoded), null, 2)
+    } catch {
+      return decoded
+    }
+  }
+
   // 解码 Base64 编码的请求体
   const formattedRequestBody = useMemo(() => {
     if (!request.body) return null
+    if (request.decoded) return formatDecoded(request.decoded)
     try {
       const binaryString = atob(request.body)
       const bytes = new Uint8Array(binaryString.length)
@@ -200,11 +210,14 @@ function TrafficDetailView({ request, response }: { request: TrafficRequest, res
     } catch {
       return request.body
     }
-  }, [request.body])
+  }, [request.body, request.decoded])
 
   // 解码 Response Body
   const formattedResponseBody = useMemo((): { isPreviewable: boolean; content?: string; size?: string; type?: string } | null => {
     if (!response?.body) return null
+    if (response.decoded) {
+      return { isPreviewable: true, content: formatDecoded(response.decoded) }
+    }
     
     const contentType = response.headers?.['content-type'] || ''
     
@@ -247,7 +260,7 @@ function TrafficDetailView({ request, response }: { request: TrafficRequest, res
     } catch {
       return { isPreviewable: true, content: response.body }
     }
-  }, [response?.body, response?.headers])
+  }, [response?.body, response?.decoded, response?.headers])
 
   return (
     <div className="border-t bg-card">
@@ -368,7 +381,7 @@ function TrafficDetailView({ request, response }: { request: TrafficRequest, res
             {request.body ? (
               <>
                 <div className="flex items-center justify-between mb-2">
-                  <div className="text-[11px] font-bold text-muted-foreground uppercase">{t('events.payload.title')}</div>
+                  <div className="text-[11px] font-bold text-muted-foreground uppercase">{request.decoded ? t('events.payload.decoded') : t('events.payload.title')}</div>
                   <CopyButton content={formattedRequestBody || ''} />
                 </div>
                 <div className="max-h-[300px] overflow-auto">
@@ -390,7 +403,7 @@ function TrafficDetailView({ request, response }: { request: TrafficRequest, res
             {response?.body ? (
               <>
                 <div className="flex items-center justify-between mb-2">
-                  <div className="text-[11px] font-bold text-muted-foreground uppercase">{t('events.response.title')}</div>
+                  <div className="text-[11px] font-bold text-muted-foreground uppercase">{response.decoded ? t('events.response.decoded') : t('events.response.title')}</div>
                   {formattedResponseBody && 'isPreviewable' in formattedResponseBody && formattedResponseBody.isPreviewable && formattedResponseBody.content && (
                     <CopyButton content={formattedResponseBody.content} />
                   )}
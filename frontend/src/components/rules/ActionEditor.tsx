@@ -5,8 +5,9 @@ import { Textarea } from '@/components/ui/textarea'
 import { Badge } from '@/components/ui/badge'
 import { X, Plus, Trash2, GripVertical, AlertCircle } from 'lucide-react'
 import { useTranslation } from 'react-i18next'
-import type { Action, ActionType, Stage, JSONPatchOp, BodyEncoding } from '@/types/rules'
+import type { Action, ActionType, Stage, JSONPatchOp, BodyEncoding, MaskMode, ViolationMode, RateLimitKey, StickyKey, Variant, SignSpec, SignMethod, AuthSpec, AuthMode, CookieSpec, AugmentSpec, MapRemoteSpec, DelaySpec, DelayPoint, DelayDistribution } from '@/types/rules'
 import {
+  FAIL_REASONS,
   createEmptyAction,
   isTerminalAction,
   getActionsForStage,
@@ -18,18 +19,19 @@ interface ActionEditorProps {
   onChange: (action: Action) => void
   onRemove: () => void
   stage: Stage
+  nested?: boolean  // 是否为变体内的行为，变体不支持嵌套
 }
 
 // 获取行为类型选项
-function getActionTypeOptions(stage: Stage): { value: ActionType; label: string }[] {
-  const actions = getActionsForStage(stage)
+function getActionTypeOptions(stage: Stage, nested = false): { value: ActionType; label: string }[] {
+  const actions = getActionsForStage(stage).filter(type => !nested || type !== 'variant')
   return actions.map(type => ({
     value: type,
     label: getActionTypeLabel(type)
   }))
 }
 
-export function ActionEditor({ action, onChange, onRemove, stage }: ActionEditorProps) {
+export function ActionEditor({ action, onChange, onRemove, stage, nested }: ActionEditorProps) {
   const { t } = useTranslation()
   const handleTypeChange = (newType: ActionType) => {
     onChange(createEmptyAction(newType, stage))
@@ -48,7 +50,7 @@ export function ActionEditor({ action, onChange, onRemove, stage }: ActionEditor
             <Select
               value={action.type}
               onChange={(e) => handleTypeChange(e.target.value as ActionType)}
-              options={getActionTypeOptions(stage)}
+              options={getActionTypeOptions(stage, nested)}
               className="w-40"
             />
             {isTerminal && (
@@ -60,7 +62,7 @@ export function ActionEditor({ action, onChange, onRemove, stage }: ActionEditor
           </div>
 
           {/* 根据行为类型渲染字段 */}
-          {renderActionFields(action, onChange)}
+          {renderActionFields(action, onChange, stage)}
         </div>
 
         {/* 删除按钮 */}
@@ -73,11 +75,24 @@ export function ActionEditor({ action, onChange, onRemove, stage }: ActionEditor
 }
 
 // 渲染行为字段
-function renderActionFields(action: Action, onChange: (action: Action) => void) {
+function renderActionFields(action: Action, onChange: (action: Action) => void, stage: Stage) {
   const { t } = useTranslation()
   const updateField = <K extends keyof Action>(key: K, value: Action[K]) => {
     onChange({ ...action, [key]: value })
   }
+  // 故障注入行为共用的执行概率
+  const probabilityInput = (
+    <Input
+      type="number"
+      value={action.probability ?? ''}
+      onChange={(e) => updateField('probability', Math.min(1, Math.max(0, parseFloat(e.target.value) || 0)) || undefined)}
+      placeholder={t('rules.faultProbability')}
+      min={0}
+      max={1}
+      step={0.05}
+      className="w-36"
+    />
+  )
 
   switch (action.type) {
     case 'setUrl':
@@ -89,6 +104,100 @@ function renderActionFields(action: Action, onChange: (action: Action) => void)
         />
       )
 
+    case 'setUserAgent':
+      return (
+        <Input
+          value={(action.value as string) || ''}
+          onChange={(e) => updateField('value', e.target.value)}
+          placeholder={t('rules.userAgentValue')}
+        />
+      )
+
+    case 'mirror':
+      return (
+        <Input
+          value={(action.value as string) || ''}
+          onChange={(e) => updateField('value', e.target.value)}
+          placeholder={t('rules.mirrorValue')}
+        />
+      )
+
+    case 'canary':
+      return (
+        <div className="flex items-center gap-2">
+          <Input
+            value={(action.value as string) || ''}
+            onChange={(e) => updateField('value', e.target.value)}
+            placeholder={t('rules.canaryValue')}
+            className="flex-1"
+          />
+          <Input
+            type="number"
+            value={action.percent ?? 0}
+            onChange={(e) => updateField('percent', Math.min(100, Math.max(0, parseInt(e.target.value) || 0)))}
+            placeholder={t('rules.canaryPercent')}
+            min={0}
+            max={100}
+            className="w-24"
+          />
+          <span className="text-sm text-muted-foreground">%</span>
+        </div>
+      )
+
+    case 'mapRemote': {
+      const remote = action.remote || {}
+      const updateRemote = (patch: Partial<MapRemoteSpec>) => onChange({ ...action, remote: { ...remote, ...patch } })
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.mapRemoteHint')}</p>
+          <div className="flex items-center gap-2">
+            <Select
+              value={remote.scheme || ''}
+              onChange={(e) => updateRemote({ scheme: e.target.value || undefined })}
+              options={[
+                { value: '', label: t('rules.mapRemoteKeepScheme') },
+                { value: 'http', label: 'http' },
+                { value: 'https', label: 'https' },
+                { value: 'ws', label: 'ws' },
+                { value: 'wss', label: 'wss' },
+              ]}
+              className="w-28"
+            />
+            <Input
+              value={remote.host || ''}
+              onChange={(e) => updateRemote({ host: e.target.value || undefined })}
+              placeholder={t('rules.mapRemoteHost')}
+              className="flex-1"
+            />
+            <Input
+              type="number"
+              value={remote.port || ''}
+              onChange={(e) => updateRemote({ port: parseInt(e.target.value) || undefined })}
+              placeholder={t('rules.mapRemotePort')}
+              min={1}
+              max={65535}
+              className="w-24"
+            />
+          </div>
+          <div className="flex items-center gap-2">
+            <Input
+              value={remote.pathPrefix || ''}
+              onChange={(e) => updateRemote({ pathPrefix: e.target.value || undefined })}
+              placeholder={t('rules.mapRemotePathPrefix')}
+              className="flex-1 font-mono"
+            />
+            <span className="text-sm text-muted-foreground">→</span>
+            <Input
+              value={remote.newPathPrefix || ''}
+              onChange={(e) => updateRemote({ newPathPrefix: e.target.value || undefined })}
+              placeholder={t('rules.mapRemoteNewPathPrefix')}
+              className="flex-1 font-mono"
+            />
+          </div>
+        </div>
+      )
+    }
+
     case 'setMethod':
       return (
         <Select
@@ -170,6 +279,48 @@ function renderActionFields(action: Action, onChange: (action: Action) => void)
         </div>
       )
 
+    case 'setFormFile':
+      return (
+        <div className="space-y-2">
+          <div className="flex items-center gap-2">
+            <Input
+              value={action.name || ''}
+              onChange={(e) => updateField('name', e.target.value)}
+              placeholder={getNamePlaceholder(action.type)}
+              className="flex-1"
+            />
+            <Input
+              value={action.filename || ''}
+              onChange={(e) => updateField('filename', e.target.value)}
+              placeholder={t('rules.formFileName')}
+              className="flex-1"
+            />
+            <Input
+              value={action.contentType || ''}
+              onChange={(e) => updateField('contentType', e.target.value)}
+              placeholder={t('rules.formFileType')}
+              className="flex-1"
+            />
+            <Select
+              value={action.encoding || 'text'}
+              onChange={(e) => updateField('encoding', e.target.value as BodyEncoding)}
+              options={[
+                { value: 'text', label: t('rules.textEncoding') },
+                { value: 'base64', label: t('rules.base64Encoding') },
+              ]}
+              className="w-28"
+            />
+          </div>
+          <Textarea
+            value={(action.value as string) || ''}
+            onChange={(e) => updateField('value', e.target.value)}
+            placeholder={action.encoding === 'base64' ? t('rules.base64Content') : t('rules.formFileContent')}
+            rows={4}
+            className="font-mono text-sm"
+          />
+        </div>
+      )
+
     case 'replaceBodyText':
       return (
         <div className="space-y-2">
@@ -207,6 +358,82 @@ function renderActionFields(action: Action, onChange: (action: Action) => void)
         />
       )
 
+    case 'jqTransform':
+      return (
+        <Textarea
+          value={(action.value as string) || ''}
+          onChange={(e) => updateField('value', e.target.value)}
+          placeholder={t('rules.jqPlaceholder')}
+          rows={4}
+          className="font-mono text-sm"
+        />
+      )
+
+    case 'script':
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.scriptHint')}</p>
+          <Textarea
+            value={(action.value as string) || ''}
+            onChange={(e) => updateField('value', e.target.value)}
+            placeholder={t('rules.scriptPlaceholder')}
+            rows={6}
+            className="font-mono text-sm"
+          />
+        </div>
+      )
+
+    case 'setCache': {
+      const value = (action.value as string) || 'disable'
+      const isPreset = ['disable', '1h', 'immutable'].includes(value)
+      return (
+        <div className="flex items-center gap-2">
+          <Select
+            value={isPreset ? value : 'custom'}
+            onChange={(e) => updateField('value', e.target.value === 'custom' ? '30m' : e.target.value)}
+            options={[
+              { value: 'disable', label: t('rules.cacheDisable') },
+              { value: '1h', label: t('rules.cache1h') },
+              { value: 'immutable', label: t('rules.cacheImmutable') },
+              { value: 'custom', label: t('rules.cacheCustom') },
+            ]}
+            className="w-40"
+          />
+          {!isPreset && (
+            <Input
+              value={value}
+              onChange={(e) => updateField('value', e.target.value)}
+              placeholder={t('rules.cacheDuration')}
+              className="w-32 font-mono"
+            />
+          )}
+        </div>
+      )
+    }
+
+    case 'setSecurityHeaders':
+      return (
+        <div className="space-y-2">
+          <Select
+            value={(action.value as string) || ''}
+            onChange={(e) => updateField('value', e.target.value)}
+            options={[
+              { value: 'strict', label: t('rules.securityStrict') },
+              { value: 'baseline', label: t('rules.securityBaseline') },
+              { value: 'strip', label: t('rules.securityStrip') },
+              { value: '', label: t('rules.securityCustom') },
+            ]}
+            className="w-48"
+          />
+          <p className="text-xs text-muted-foreground">{t('rules.securityHeadersHint')}</p>
+          <KeyValueEditor
+            title={t('rules.responseHeaders')}
+            data={action.headers || {}}
+            onChange={(headers) => onChange({ ...action, headers })}
+          />
+        </div>
+      )
+
     case 'setStatus':
       return (
         <Input
@@ -220,6 +447,662 @@ function renderActionFields(action: Action, onChange: (action: Action) => void)
         />
       )
 
+    case 'saveBody':
+      return (
+        <div className="flex items-center gap-2">
+          <Input
+            value={(action.value as string) || ''}
+            onChange={(e) => updateField('value', e.target.value)}
+            placeholder={t('rules.saveBodyDir')}
+            className="flex-1"
+          />
+          <Input
+            value={action.filename || ''}
+            onChange={(e) => updateField('filename', e.target.value)}
+            placeholder={t('rules.saveBodyFilename')}
+            className="flex-1"
+          />
+        </div>
+      )
+
+    case 'maskJson':
+      return (
+        <div className="space-y-2">
+          <Select
+            value={action.maskMode || 'remove'}
+            onChange={(e) => updateField('maskMode', e.target.value as MaskMode)}
+            options={[
+              { value: 'remove', label: t('rules.maskRemove') },
+              { value: 'null', label: t('rules.maskNull') },
+            ]}
+            className="w-40"
+          />
+          <Textarea
+            value={(action.paths || []).join('\n')}
+            onChange={(e) => updateField('paths', e.target.value.split('\n'))}
+            placeholder={t('rules.maskPaths')}
+            rows={3}
+            className="font-mono text-sm"
+          />
+        </div>
+      )
+
+    case 'validateSchema':
+      return (
+        <div className="space-y-2">
+          <Select
+            value={action.onViolation || 'report'}
+            onChange={(e) => updateField('onViolation', e.target.value as ViolationMode)}
+            options={[
+              { value: 'report', label: t('rules.violationReport') },
+              { value: 'flag', label: t('rules.violationFlag') },
+              { value: 'fail', label: t('rules.violationFail') },
+            ]}
+            className="w-48"
+          />
+          <Textarea
+            value={typeof action.schema === 'string' ? action.schema : JSON.stringify(action.schema ?? {}, null, 2)}
+            onChange={(e) => updateField('schema', e.target.value)}
+            placeholder={t('rules.schemaPlaceholder')}
+            rows={8}
+            className="font-mono text-sm"
+          />
+        </div>
+      )
+
+    case 'augmentJson': {
+      const spec: AugmentSpec = action.augment || { source: '' }
+      const updateAugment = (patch: Partial<AugmentSpec>) => updateField('augment', { ...spec, ...patch })
+      return (
+        <div className="space-y-2">
+          <div className="flex items-center gap-2">
+            <Input
+              value={spec.source}
+              onChange={(e) => updateAugment({ source: e.target.value })}
+              placeholder={t('rules.augmentSource')}
+              className="flex-1 font-mono"
+            />
+            <Input
+              value={spec.timeout || ''}
+              onChange={(e) => updateAugment({ timeout: e.target.value || undefined })}
+              placeholder={t('rules.augmentTimeout')}
+              className="w-28 font-mono"
+            />
+          </div>
+          <KeyValueEditor
+            title={t('rules.augmentKeys')}
+            data={spec.keys || {}}
+            onChange={(keys) => updateAugment({ keys })}
+          />
+        </div>
+      )
+    }
+
+    case 'throttle': {
+      const delay = action.delay
+      const updateDelay = (patch: Partial<DelaySpec>) => updateField('delay', { ...delay, ...patch })
+      const msInput = (key: 'minMS' | 'maxMS' | 'meanMS' | 'stdDevMS', placeholder: string) => (
+        <Input
+          type="number"
+          value={delay?.[key] || ''}
+          onChange={(e) => updateDelay({ [key]: Math.max(0, parseInt(e.target.value) || 0) || undefined } as Partial<DelaySpec>)}
+          placeholder={placeholder}
+          min={0}
+          className="w-32"
+        />
+      )
+      const points = delay?.percentiles || []
+      const updatePoint = (index: number, patch: Partial<DelayPoint>) =>
+        updateDelay({ percentiles: points.map((pt, i) => (i === index ? { ...pt, ...patch } : pt)) })
+      const addPoint = () => {
+        const last = points[points.length - 1]
+        updateDelay({ percentiles: [...points, { p: Math.min(100, (last?.p ?? 0) + 10), ms: last?.ms ?? 0 }] })
+      }
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.throttleHint')}</p>
+          <div className="flex items-center gap-2">
+            <Select
+              value={delay ? delay.distribution || 'uniform' : ''}
+              onChange={(e) => updateField('delay', e.target.value
+                ? { ...delay, distribution: e.target.value as DelayDistribution }
+                : undefined)}
+              options={[
+                { value: '', label: t('rules.delayFixed') },
+                { value: 'uniform', label: t('rules.delayUniform') },
+                { value: 'normal', label: t('rules.delayNormal') },
+                { value: 'percentile', label: t('rules.delayPercentile') },
+              ]}
+              className="w-40"
+            />
+            {!delay && (
+              <Input
+                type="number"
+                value={action.latencyMS || ''}
+                onChange={(e) => updateField('latencyMS', Math.max(0, parseInt(e.target.value) || 0) || undefined)}
+                placeholder={t('rules.throttleLatency')}
+                min={0}
+                className="w-40"
+              />
+            )}
+            {delay?.distribution === 'normal' && msInput('meanMS', t('rules.delayMean'))}
+            {delay?.distribution === 'normal' && msInput('stdDevMS', t('rules.delayStdDev'))}
+            {delay && delay.distribution !== 'percentile' && msInput('minMS', t('rules.delayMin'))}
+            {delay && delay.distribution !== 'percentile' && msInput('maxMS', t('rules.delayMax'))}
+            <Input
+              type="number"
+              value={action.bandwidth || ''}
+              onChange={(e) => updateField('bandwidth', Math.max(0, parseInt(e.target.value) || 0) || undefined)}
+              placeholder={t('rules.throttleBandwidth')}
+              min={0}
+              className="w-48"
+            />
+          </div>
+          {delay?.distribution === 'percentile' && (
+            <div className="space-y-2">
+              <p className="text-xs text-muted-foreground">{t('rules.delayPercentileHint')}</p>
+              {points.map((pt, i) => (
+                <div key={i} className="flex items-center gap-2">
+                  <Input
+                    type="number"
+                    value={pt.p}
+                    onChange={(e) => updatePoint(i, { p: Math.min(100, Math.max(0, parseFloat(e.target.value) || 0)) })}
+                    placeholder="p"
+                    min={0}
+                    max={100}
+                    className="w-24"
+                  />
+                  <Input
+                    type="number"
+                    value={pt.ms}
+                    onChange={(e) => updatePoint(i, { ms: Math.max(0, parseInt(e.target.value) || 0) })}
+                    placeholder="ms"
+                    min={0}
+                    className="w-32"
+                  />
+                  <Button variant="ghost" size="icon" onClick={() => updateDelay({ percentiles: points.filter((_, j) => j !== i) })}>
+                    <Trash2 className="w-4 h-4" />
+                  </Button>
+                </div>
+              ))}
+              <Button variant="outline" size="sm" onClick={addPoint}>
+                <Plus className="w-4 h-4 mr-1" />
+                {t('rules.delayAddPercentile')}
+              </Button>
+            </div>
+          )}
+        </div>
+      )
+    }
+
+    case 'fail':
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.failHint')}</p>
+          <div className="flex items-center gap-2">
+            <Select
+              value={(action.value as string) || 'ConnectionReset'}
+              onChange={(e) => updateField('value', e.target.value)}
+              options={FAIL_REASONS.map(r => ({ value: r, label: r }))}
+              className="w-56"
+            />
+            {probabilityInput}
+          </div>
+        </div>
+      )
+
+    case 'randomStatus':
+      return (
+        <div className="flex items-center gap-2">
+          <Input
+            value={(action.value as string) || ''}
+            onChange={(e) => updateField('value', e.target.value)}
+            placeholder={t('rules.randomStatusCodes')}
+            className="flex-1 font-mono"
+          />
+          {probabilityInput}
+        </div>
+      )
+
+    case 'truncateBody':
+      return (
+        <div className="flex items-center gap-2">
+          <Input
+            type="number"
+            value={action.percent || ''}
+            onChange={(e) => updateField('percent', Math.min(99, Math.max(0, parseInt(e.target.value) || 0)) || undefined)}
+            placeholder={t('rules.truncateKeep')}
+            min={0}
+            max={99}
+            className="w-48"
+          />
+          <span className="text-sm text-muted-foreground">%</span>
+          {probabilityInput}
+        </div>
+      )
+
+    case 'corruptJson':
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.corruptJsonHint')}</p>
+          {probabilityInput}
+        </div>
+      )
+
+    case 'rateLimit':
+      return (
+        <div className="space-y-2">
+          <div className="flex items-center gap-2">
+            <Input
+              type="number"
+              value={action.limit ?? 5}
+              onChange={(e) => updateField('limit', Math.max(0, parseInt(e.target.value) || 0))}
+              placeholder={t('rules.rateLimit')}
+              min={0}
+              className="w-24"
+            />
+            <Input
+              value={action.window || ''}
+              onChange={(e) => updateField('window', e.target.value)}
+              placeholder={t('rules.rateWindow')}
+              className="w-24 font-mono"
+            />
+            <Input
+              type="number"
+              value={action.retryAfter || ''}
+              onChange={(e) => updateField('retryAfter', parseInt(e.target.value) || undefined)}
+              placeholder={t('rules.retryAfter')}
+              min={0}
+              className="w-32"
+            />
+          </div>
+          <div className="flex items-center gap-2">
+            <Select
+              value={action.rateKey || 'url'}
+              onChange={(e) => updateField('rateKey', e.target.value as RateLimitKey)}
+              options={[
+                { value: 'url', label: t('rules.rateKeyUrl') },
+                { value: 'header', label: t('rules.rateKeyHeader') },
+                { value: 'cookie', label: t('rules.rateKeyCookie') },
+              ]}
+              className="w-40"
+            />
+            {(action.rateKey === 'header' || action.rateKey === 'cookie') && (
+              <Input
+                value={action.name || ''}
+                onChange={(e) => updateField('name', e.target.value)}
+                placeholder={action.rateKey === 'header' ? 'Header 名' : 'Cookie 名'}
+                className="flex-1"
+              />
+            )}
+          </div>
+          <Textarea
+            value={action.body || ''}
+            onChange={(e) => updateField('body', e.target.value)}
+            placeholder={t('rules.rateLimitBody')}
+            rows={2}
+            className="font-mono text-sm"
+          />
+        </div>
+      )
+
+    case 'sign': {
+      const spec: SignSpec = action.sign || { method: 'hmac' }
+      const updateSign = (patch: Partial<SignSpec>) => updateField('sign', { ...spec, ...patch })
+      return (
+        <div className="space-y-2">
+          <Select
+            value={spec.method}
+            onChange={(e) => updateSign({ method: e.target.value as SignMethod })}
+            options={[
+              { value: 'hmac', label: 'HMAC' },
+              { value: 'awsSigV4', label: 'AWS SigV4' },
+            ]}
+            className="w-40"
+          />
+          {spec.method === 'awsSigV4' ? (
+            <>
+              <div className="flex items-center gap-2">
+                <Input
+                  value={spec.region || ''}
+                  onChange={(e) => updateSign({ region: e.target.value })}
+                  placeholder={t('rules.signRegion')}
+                  className="flex-1"
+                />
+                <Input
+                  value={spec.service || ''}
+                  onChange={(e) => updateSign({ service: e.target.value })}
+                  placeholder={t('rules.signService')}
+                  className="flex-1"
+                />
+              </div>
+              <div className="flex items-center gap-2">
+                <Input
+                  value={spec.accessKeyEnv || ''}
+                  onChange={(e) => updateSign({ accessKeyEnv: e.target.value })}
+                  placeholder="AWS_ACCESS_KEY_ID"
+                  className="flex-1 font-mono"
+                />
+                <Input
+                  value={spec.secretKeyEnv || ''}
+                  onChange={(e) => updateSign({ secretKeyEnv: e.target.value })}
+                  placeholder="AWS_SECRET_ACCESS_KEY"
+                  className="flex-1 font-mono"
+                />
+                <Input
+                  value={spec.sessionTokenEnv || ''}
+                  onChange={(e) => updateSign({ sessionTokenEnv: e.target.value })}
+                  placeholder="AWS_SESSION_TOKEN"
+                  className="flex-1 font-mono"
+                />
+              </div>
+            </>
+          ) : (
+            <>
+              <div className="flex items-center gap-2">
+                <Input
+                  value={spec.secretEnv || ''}
+                  onChange={(e) => updateSign({ secretEnv: e.target.value })}
+                  placeholder={t('rules.signSecretEnv')}
+                  className="flex-1 font-mono"
+                />
+                <Input
+                  value={spec.header || ''}
+                  onChange={(e) => updateSign({ header: e.target.value })}
+                  placeholder="X-Signature"
+                  className="flex-1"
+                />
+                <Select
+                  value={spec.algorithm || 'sha256'}
+                  onChange={(e) => updateSign({ algorithm: e.target.value as SignSpec['algorithm'] })}
+                  options={[
+                    { value: 'sha256', label: 'SHA-256' },
+                    { value: 'sha1', label: 'SHA-1' },
+                    { value: 'sha512', label: 'SHA-512' },
+                  ]}
+                  className="w-28"
+                />
+                <Select
+                  value={spec.encoding || 'hex'}
+                  onChange={(e) => updateSign({ encoding: e.target.value as SignSpec['encoding'] })}
+                  options={[
+                    { value: 'hex', label: 'Hex' },
+                    { value: 'base64', label: 'Base64' },
+                  ]}
+                  className="w-28"
+                />
+              </div>
+              <Input
+                value={spec.payload || ''}
+                onChange={(e) => updateSign({ payload: e.target.value })}
+                placeholder={t('rules.signPayload')}
+                className="font-mono"
+              />
+              <Input
+                value={spec.template || ''}
+                onChange={(e) => updateSign({ template: e.target.value })}
+                placeholder={t('rules.signTemplate')}
+                className="font-mono"
+              />
+            </>
+          )}
+        </div>
+      )
+    }
+
+    case 'auth': {
+      const spec: AuthSpec = action.auth || { mode: 'provide' }
+      const updateAuth = (patch: Partial<AuthSpec>) => updateField('auth', { ...spec, ...patch })
+      const mode = spec.mode || 'provide'
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.authHint')}</p>
+          <div className="flex items-center gap-2">
+            <Select
+              value={mode}
+              onChange={(e) => updateAuth({ mode: e.target.value as AuthMode })}
+              options={[
+                { value: 'provide', label: t('rules.authModes.provide') },
+                { value: 'approve', label: t('rules.authModes.approve') },
+                { value: 'cancel', label: t('rules.authModes.cancel') },
+              ]}
+              className="w-40"
+            />
+            <Select
+              value={spec.source || ''}
+              onChange={(e) => updateAuth({ source: e.target.value as AuthSpec['source'] })}
+              options={[
+                { value: '', label: t('rules.authSources.any') },
+                { value: 'server', label: t('rules.authSources.server') },
+                { value: 'proxy', label: t('rules.authSources.proxy') },
+              ]}
+              className="w-40"
+            />
+            {mode === 'approve' && (
+              <Input
+                type="number"
+                value={spec.timeoutMS || ''}
+                onChange={(e) => updateAuth({ timeoutMS: parseInt(e.target.value) || 0 })}
+                placeholder={t('rules.authTimeout')}
+                className="w-40"
+              />
+            )}
+          </div>
+          {mode !== 'cancel' && (
+            <div className="flex items-center gap-2">
+              <Input
+                value={spec.username || ''}
+                onChange={(e) => updateAuth({ username: e.target.value })}
+                placeholder={t('rules.authUsername')}
+                className="flex-1"
+              />
+              <Input
+                value={spec.passwordEnv || ''}
+                onChange={(e) => updateAuth({ passwordEnv: e.target.value })}
+                placeholder={t('rules.authPasswordEnv')}
+                className="flex-1 font-mono"
+              />
+            </div>
+          )}
+        </div>
+      )
+    }
+
+    case 'setBrowserCookie': {
+      const spec: CookieSpec = action.cookie || {}
+      const updateCookie = (patch: Partial<CookieSpec>) => updateField('cookie', { ...spec, ...patch })
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.browserCookieHint')}</p>
+          <div className="flex items-center gap-2">
+            <Input
+              value={action.name || ''}
+              onChange={(e) => updateField('name', e.target.value)}
+              placeholder={getNamePlaceholder(action.type)}
+              className="flex-1"
+            />
+            <Input
+              value={(action.value as string) || ''}
+              onChange={(e) => updateField('value', e.target.value)}
+              placeholder={t('rules.headerValue')}
+              className="flex-1"
+            />
+          </div>
+          <div className="flex items-center gap-2">
+            <Input
+              value={spec.domain || ''}
+              onChange={(e) => updateCookie({ domain: e.target.value })}
+              placeholder={t('rules.cookieDomain')}
+              className="flex-1"
+            />
+            <Input
+              value={spec.path || ''}
+              onChange={(e) => updateCookie({ path: e.target.value })}
+              placeholder={t('rules.cookiePath')}
+              className="w-32"
+            />
+            <Input
+              type="number"
+              value={spec.maxAge || ''}
+              onChange={(e) => updateCookie({ maxAge: parseInt(e.target.value) || 0 })}
+              placeholder={t('rules.cookieMaxAge')}
+              className="w-40"
+            />
+          </div>
+          <div className="flex items-center gap-4">
+            <Select
+              value={spec.sameSite || ''}
+              onChange={(e) => updateCookie({ sameSite: e.target.value as CookieSpec['sameSite'] })}
+              options={[
+                { value: '', label: t('rules.cookieSameSiteDefault') },
+                { value: 'Strict', label: 'Strict' },
+                { value: 'Lax', label: 'Lax' },
+                { value: 'None', label: 'None' },
+              ]}
+              className="w-40"
+            />
+            <label className="flex items-center gap-2 text-sm cursor-pointer">
+              <input
+                type="checkbox"
+                checked={spec.httpOnly || false}
+                onChange={(e) => updateCookie({ httpOnly: e.target.checked })}
+                className="rounded"
+              />
+              HttpOnly
+            </label>
+            <label className="flex items-center gap-2 text-sm cursor-pointer">
+              <input
+                type="checkbox"
+                checked={spec.secure || false}
+                onChange={(e) => updateCookie({ secure: e.target.checked })}
+                className="rounded"
+              />
+              Secure
+            </label>
+          </div>
+        </div>
+      )
+    }
+
+    case 'notModified':
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.notModifiedHint')}</p>
+          <KeyValueEditor
+            title={t('rules.responseHeaders')}
+            data={action.headers || {}}
+            onChange={(headers) => onChange({ ...action, headers })}
+          />
+        </div>
+      )
+
+    case 'redirect':
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.redirectHint')}</p>
+          <Input
+            value={action.pattern || ''}
+            onChange={(e) => updateField('pattern', e.target.value)}
+            placeholder={t('rules.redirectPattern')}
+            className="font-mono"
+          />
+          <div className="flex items-center gap-2">
+            <Select
+              value={String(action.statusCode || 302)}
+              onChange={(e) => onChange({ ...action, statusCode: parseInt(e.target.value) })}
+              options={['301', '302', '303', '307', '308'].map(code => ({ value: code, label: code }))}
+              className="w-24"
+            />
+            <Input
+              value={String(action.value || '')}
+              onChange={(e) => updateField('value', e.target.value)}
+              placeholder={t('rules.redirectLocation')}
+              className="flex-1 font-mono"
+            />
+          </div>
+          <label className="flex items-center gap-2 text-sm cursor-pointer">
+            <input
+              type="checkbox"
+              checked={action.preserveMethod || false}
+              onChange={(e) => updateField('preserveMethod', e.target.checked)}
+              className="rounded"
+            />
+            {t('rules.redirectPreserveMethod')}
+          </label>
+          <KeyValueEditor
+            title={t('rules.responseHeaders')}
+            data={action.headers || {}}
+            onChange={(headers) => onChange({ ...action, headers })}
+          />
+        </div>
+      )
+
+    case 'mapLocal':
+      return (
+        <div className="space-y-2">
+          <p className="text-xs text-muted-foreground">{t('rules.mapLocalHint')}</p>
+          <Input
+            value={String(action.value || '')}
+            onChange={(e) => updateField('value', e.target.value)}
+            placeholder={t('rules.mapLocalPath')}
+          />
+          <div className="flex items-center gap-2">
+            <Input
+              value={action.pattern || ''}
+              onChange={(e) => updateField('pattern', e.target.value)}
+              placeholder={t('rules.mapLocalPattern')}
+              className="flex-1 font-mono"
+            />
+            <Input
+              value={action.filename || ''}
+              onChange={(e) => updateField('filename', e.target.value)}
+              placeholder={t('rules.mapLocalFilename')}
+              className="flex-1 font-mono"
+            />
+          </div>
+          <KeyValueEditor
+            title={t('rules.responseHeaders')}
+            data={action.headers || {}}
+            onChange={(headers) => onChange({ ...action, headers })}
+          />
+        </div>
+      )
+
+    case 'stripValidators':
+      return (
+        <p className="text-xs text-muted-foreground">
+          {stage === 'request' ? t('rules.stripValidatorsRequest') : t('rules.stripValidatorsResponse')}
+        </p>
+      )
+
+    case 'variant':
+      return (
+        <div className="space-y-3">
+          <div className="flex items-center gap-2">
+            <Select
+              value={action.stickyBy || 'cookie'}
+              onChange={(e) => updateField('stickyBy', e.target.value as StickyKey)}
+              options={[
+                { value: 'cookie', label: t('rules.stickyCookie') },
+                { value: 'header', label: t('rules.stickyHeader') },
+              ]}
+              className="w-40"
+            />
+            <Input
+              value={action.name || ''}
+              onChange={(e) => updateField('name', e.target.value)}
+              placeholder={action.stickyBy === 'header' ? 'Header 名' : 'Cookie 名'}
+              className="flex-1"
+            />
+          </div>
+          <VariantsEditor
+            variants={action.variants || []}
+            onChange={(variants) => updateField('variants', variants)}
+            stage={stage}
+          />
+        </div>
+      )
+
     case 'block':
       return (
         <div className="space-y-3">
@@ -274,9 +1157,11 @@ function getNamePlaceholder(type: ActionType): string {
       return '参数名'
     case 'setCookie':
     case 'removeCookie':
+    case 'setBrowserCookie':
       return 'Cookie 名'
     case 'setFormField':
     case 'removeFormField':
+    case 'setFormFile':
       return '字段名'
     default:
       return '名称'
@@ -354,6 +1239,80 @@ function KeyValueEditor({ title, data, onChange }: KeyValueEditorProps) {
   )
 }
 
+interface VariantsEditorProps {
+  variants: Variant[]
+  onChange: (variants: Variant[]) => void
+  stage: Stage
+}
+
+// 变体编辑器
+function VariantsEditor({ variants, onChange, stage }: VariantsEditorProps) {
+  const { t } = useTranslation()
+
+  const updateVariant = (index: number, variant: Variant) => {
+    const newVariants = [...variants]
+    newVariants[index] = variant
+    onChange(newVariants)
+  }
+
+  const addVariant = () => {
+    onChange([...variants, { name: String.fromCharCode(65 + variants.length), weight: 50, actions: [] }])
+  }
+
+  return (
+    <div className="space-y-2">
+      {variants.map((variant, index) => (
+        <div key={index} className="p-2 rounded border border-dashed space-y-2">
+          <div className="flex items-center gap-2">
+            <Input
+              value={variant.name}
+              onChange={(e) => updateVariant(index, { ...variant, name: e.target.value })}
+              placeholder={t('rules.variantName')}
+              className="flex-1"
+            />
+            <Input
+              type="number"
+              value={variant.weight ?? 0}
+              onChange={(e) => updateVariant(index, { ...variant, weight: Math.max(0, parseInt(e.target.value) || 0) })}
+              placeholder={t('rules.variantWeight')}
+              min={0}
+              className="w-24"
+            />
+            <Button
+              variant="ghost"
+              size="icon"
+              onClick={() => updateVariant(index, { ...variant, actions: [...variant.actions, createEmptyAction('setHeader', stage)] })}
+            >
+              <Plus className="w-4 h-4" />
+            </Button>
+            <Button variant="ghost" size="icon" onClick={() => onChange(variants.filter((_, i) => i !== index))}>
+              <Trash2 className="w-4 h-4" />
+            </Button>
+          </div>
+          {variant.actions.length === 0 ? (
+            <div className="text-xs text-muted-foreground text-center">{t('rules.variantNoActions')}</div>
+          ) : (
+            variant.actions.map((a, i) => (
+              <ActionEditor
+                key={i}
+                action={a}
+                onChange={(na) => updateVariant(index, { ...variant, actions: variant.actions.map((x, j) => (j === i ? na : x)) })}
+                onRemove={() => updateVariant(index, { ...variant, actions: variant.actions.filter((_, j) => j !== i) })}
+                stage={stage}
+                nested
+              />
+            ))
+          )}
+        </div>
+      ))}
+      <Button variant="outline" size="sm" onClick={addVariant}>
+        <Plus className="w-4 h-4 mr-1" />
+        {t('rules.variantAdd')}
+      </Button>
+    </div>
+  )
+}
+
 interface JSONPatchEditorProps {
   patches: JSONPatchOp[]
   onChange: (patches: JSONPatchOp[]) => void
@@ -495,12 +1454,22 @@ export function ActionsEditor({ actions, onChange, stage, onStageChange }: Actio
             >
               {t('rules.responseStage')}
             </button>
+            <button
+              className={`px-3 py-1 text-xs font-medium rounded transition-all ${
+                stage === 'websocket' 
+                  ? 'bg-background text-foreground shadow-sm' 
+                  : 'text-muted-foreground hover:text-foreground'
+              }`}
+              onClick={() => onStageChange?.('websocket')}
+            >
+              {t('rules.websocketStage')}
+            </button>
           </div>
           <span className="text-xs text-muted-foreground">
-            {stage === 'request' ? t('rules.requestStageDesc') : t('rules.responseStageDesc')}
+            {stage === 'request' ? t('rules.requestStageDesc') : stage === 'response' ? t('rules.responseStageDesc') : t('rules.websocketStageDesc')}
           </span>
         </div>
-        <Button variant="outline" size="sm" onClick={addAction}>
+        <Button variant="outline" size="sm" onClick={addAction} disabled={stage === 'websocket'}>
           <Plus className="w-4 h-4 mr-1" />
           {t('rules.addAction')}
         </Button>
@@ -3,7 +3,7 @@ import { Select } from '@/components/ui/select'
 import { Button } from '@/components/ui/button'
 import { Badge } from '@/components/ui/badge'
 import { X, Plus } from 'lucide-react'
-import type { Condition, ConditionType } from '@/types/rules'
+import type { Condition, ConditionType, MatchScope } from '@/types/rules'
 import {
   CONDITION_GROUPS,
   HTTP_METHODS,
@@ -38,6 +38,10 @@ export function ConditionEditor({ condition, onChange, onRemove }: ConditionEdit
     ...CONDITION_GROUPS.cookie.map(t => ({ value: t as ConditionType, label: getConditionTypeShortLabel(t) })),
     // Body
     ...CONDITION_GROUPS.body.map(t => ({ value: t as ConditionType, label: getConditionTypeShortLabel(t) })),
+    // 规则依赖
+    ...CONDITION_GROUPS.rule.map(t => ({ value: t as ConditionType, label: getConditionTypeShortLabel(t) })),
+    // 响应
+    ...CONDITION_GROUPS.response.map(t => ({ value: t as ConditionType, label: getConditionTypeShortLabel(t) })),
   ]
   
   const handleTypeChange = (newType: ConditionType) => {
@@ -59,8 +63,13 @@ export function ConditionEditor({ condition, onChange, onRemove }: ConditionEdit
 
   const getValuePlaceholder = (type: ConditionType): string => {
     if (type.startsWith('url')) return 'URL...'
+    if (type === 'host') return 'example.com'
     if (type === 'bodyContains') return t('rules.text')
     if (type === 'bodyJsonPath') return t('rules.expected')
+    if (type === 'ruleMatched') return t('rules.precursorRuleId')
+    if (type === 'statusCode') return '5xx, 404, 500-503'
+    if (type === 'contentType') return 'json'
+    if (type === 'responseTime') return '>1000, 200-500'
     return 'Value...'
   }
 
@@ -106,6 +115,19 @@ export function ConditionEditor({ condition, onChange, onRemove }: ConditionEdit
           />
         )}
 
+        {/* 回溯范围 (ruleMatched) */}
+        {condition.type === 'ruleMatched' && (
+          <Select
+            value={condition.scope || 'session'}
+            onChange={(e) => updateField('scope', e.target.value as MatchScope)}
+            options={[
+              { value: 'session', label: t('rules.scopeSession') },
+              { value: 'pageLoad', label: t('rules.scopePageLoad') },
+            ]}
+            className="w-36"
+          />
+        )}
+
         {/* pattern 字段 */}
         {fields.includes('pattern') && (
           <Input
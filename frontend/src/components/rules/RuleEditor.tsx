@@ -126,7 +126,7 @@ export function RuleEditor({
           <Badge variant="secondary" className="text-xs shrink-0">
             {t('rules.priority')} {rule.priority}
           </Badge>
-          {hasTerminalAction && (
+          {(hasTerminalAction || rule.terminal) && (
             <Badge variant="destructive" className="text-xs shrink-0">
               {t('rules.terminal')}
             </Badge>
@@ -175,6 +175,24 @@ export function RuleEditor({
               />
             </div>
           </div>
+          <label className="flex items-center gap-2 text-sm cursor-pointer" title={t('rules.noSniffHint')}>
+            <input
+              type="checkbox"
+              checked={rule.noSniff || false}
+              onChange={(e) => onChange({ ...rule, noSniff: e.target.checked || undefined })}
+              className="rounded"
+            />
+            {t('rules.noSniff')}
+          </label>
+          <label className="flex items-center gap-2 text-sm cursor-pointer" title={t('rules.terminalRuleHint')}>
+            <input
+              type="checkbox"
+              checked={rule.terminal || false}
+              onChange={(e) => onChange({ ...rule, terminal: e.target.checked || undefined })}
+              className="rounded"
+            />
+            {t('rules.terminalRule')}
+          </label>
 
           {/* 匹配条件 */}
           <div className="space-y-4">
@@ -38,6 +38,7 @@ export function SettingsDialog({ open, onOpenChange }: SettingsDialogProps) {
     theme: 'system',
     browser_args: '',
     browser_path: '',
+    browser_engine: 'auto',
   })
 
   const [isLoading, setIsLoading] = useState(false)
@@ -61,6 +62,7 @@ export function SettingsDialog({ open, onOpenChange }: SettingsDialogProps) {
           theme: settings.theme || 'system',
           browser_args: settings.browser_args || '',
           browser_path: settings.browser_path || '',
+          browser_engine: settings.browser_engine || 'auto',
         })
       }
     } catch (e) {
@@ -119,6 +121,7 @@ export function SettingsDialog({ open, onOpenChange }: SettingsDialogProps) {
           theme: settings.theme || 'system',
           browser_args: settings.browser_args || '',
           browser_path: settings.browser_path || '',
+          browser_engine: settings.browser_engine || 'auto',
         })
         toast({
           variant: 'success',
@@ -304,6 +307,31 @@ export function SettingsDialog({ open, onOpenChange }: SettingsDialogProps) {
                               </p>
                             </div>
                           </div>
+
+                          {/* 自动检测的浏览器 */}
+                          <div className="grid grid-cols-4 gap-4 items-start">
+                            <Label htmlFor="browser_engine" className="text-right text-sm pt-2">
+                              {t('settings.browser.engine')}
+                            </Label>
+                            <div className="col-span-3 space-y-1">
+                              <Select
+                                id="browser_engine"
+                                value={formData.browser_engine}
+                                onChange={(e) => setFormData({ ...formData, browser_engine: e.target.value })}
+                                options={[
+                                  { value: 'auto', label: t('settings.browser.engineAuto') },
+                                  { value: 'chrome', label: 'Google Chrome' },
+                                  { value: 'edge', label: 'Microsoft Edge' },
+                                  { value: 'brave', label: 'Brave' },
+                                  { value: 'chromium', label: 'Chromium' },
+                                ]}
+                                className="w-full"
+                              />
+                              <p className="text-xs text-muted-foreground">
+                                {t('settings.browser.engineDesc')}
+                              </p>
+                            </div>
+                          </div>
                         </div>
                       </div>
                     </div>
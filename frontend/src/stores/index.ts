@@ -158,7 +158,8 @@ export const useSessionStore = create<SessionState>((set, get) => ({
   addInterceptEvent: (event) => set((state) => {
     console.log('[Store] 处理拦截事件:', event)
     
-    if (event.isMatched) {
+    // 重放产生的事件没有匹配规则，同样展示在匹配事件列表中
+    if (event.isMatched || event.finalResult === 'replayed') {
       const eventWithId: MatchedEventWithId = {
         networkEvent: event,
         id: generateEventId(event.timestamp),
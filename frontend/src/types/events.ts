@@ -6,7 +6,11 @@ export interface Request {
   method: string
   headers: Record<string, string>
   body: string
+  decoded?: string       // gRPC-web 等二进制消息解码后的 JSON
   resourceType?: string  // document/xhr/script/image等
+  secrets?: SecretFinding[]  // 检测到的敏感信息
+  correlationId?: string     // 注入或沿用的关联 ID
+  traceId?: string           // 处理该请求的 OpenTelemetry 追踪 ID
 }
 
 // 响应信息
@@ -14,12 +18,42 @@ export interface Response {
   statusCode: number
   headers: Record<string, string>
   body: string
+  decoded?: string       // gRPC-web 等二进制消息解码后的 JSON
+  violations?: SchemaViolation[]  // validateSchema 动作或 OpenAPI 契约检查发现的违规
+  secrets?: SecretFinding[]       // 检测到的敏感信息
   timing?: {
     startTime: number  // 开始时间
     endTime: number    // 结束时间
+    // 以下字段仅在开启网络计时采集时存在，耗时单位为毫秒
+    dns?: number              // DNS 解析
+    connect?: number          // 建立连接（含 TLS 握手）
+    ssl?: number              // TLS 握手
+    send?: number             // 发送请求
+    ttfb?: number             // 等待首字节
+    transfer?: number         // 接收响应体
+    transferSize?: number     // 网络传输总字节数
+    encodedBodySize?: number  // 压缩后的响应体字节数
   }
 }
 
+// JSON Schema 违规
+export interface SchemaViolation {
+  ruleId?: string
+  kind?: string     // OpenAPI 契约检查的违规类型：path/method/status/requestBody/responseBody
+  path: string      // 违规值位置（JSON Pointer）
+  keyword?: string  // 未通过的 schema 关键字位置
+  message: string
+}
+
+// 敏感信息检测结果
+export interface SecretFinding {
+  detector: string  // awsKey/jwt/apiKey/creditCard
+  location: 'url' | 'requestHeader' | 'requestBody' | 'responseHeader' | 'responseBody'
+  field?: string    // 所在的头部名称
+  offset: number    // 字节偏移
+  match: string     // 脱敏后的匹配内容
+}
+
 // 规则匹配信息
 export interface RuleMatch {
   ruleId: string
@@ -30,14 +64,28 @@ export interface RuleMatch {
 // 网络事件（通用结构）
 export interface NetworkEvent {
   id: string
+  seq?: number  // 会话内单调递增的事件序号，用于重连后 ReplayEvents 补齐
   session: string
   target: string
   timestamp: number
   isMatched: boolean
   request: Request
   response?: Response
-  finalResult?: 'blocked' | 'modified' | 'passed'
+  finalResult?: FinalResultType
   matchedRules?: RuleMatch[]
+  frame?: WebSocketFrame  // WebSocket 消息帧事件的帧信息，request 为所属连接的握手请求，body 为帧载荷
+  originalRequest?: Request    // 规则修改前的请求，未被修改时为空
+  originalResponse?: Response  // 规则修改前的响应，未被修改时为空
+}
+
+// WebSocket 消息帧（只读观察）
+export interface WebSocketFrame {
+  direction: 'sent' | 'received' | 'error'
+  index: number       // 连接内的帧序号，从 1 开始
+  opcode: number      // 1 文本帧，2 二进制帧，8 关闭帧等
+  size: number        // 载荷原始字节数
+  truncated?: boolean // 载荷超出记录上限被截断
+  error?: string
 }
 
 // 匹配的事件（会存入数据库）
@@ -51,13 +99,16 @@ export interface MatchedEventWithId extends MatchedEvent {
 }
 
 // 结果类型标签和颜色
-export type FinalResultType = 'blocked' | 'modified' | 'passed'
+export type FinalResultType = 'blocked' | 'modified' | 'passed' | 'schema-violation' | 'secret-detected' | 'replayed'
 
 // 结果类型标签
 export const FINAL_RESULT_LABELS: Record<FinalResultType, string> = {
   blocked: '阻断',
   modified: '修改',
   passed: '放行',
+  'schema-violation': 'Schema 违规',
+  'secret-detected': '敏感信息',
+  replayed: '重放',
 }
 
 // 结果类型颜色
@@ -65,4 +116,76 @@ export const FINAL_RESULT_COLORS: Record<FinalResultType, { bg: string; text: st
   blocked: { bg: 'bg-red-500/20', text: 'text-red-500' },
   modified: { bg: 'bg-yellow-500/20', text: 'text-yellow-500' },
   passed: { bg: 'bg-green-500/20', text: 'text-green-500' },
+  'schema-violation': { bg: 'bg-orange-500/20', text: 'text-orange-500' },
+  'secret-detected': { bg: 'bg-purple-500/20', text: 'text-purple-500' },
+  replayed: { bg: 'bg-blue-500/20', text: 'text-blue-500' },
+}
+
+// 规则修改前后的差异（GetEventDiff 返回）
+export interface FieldChange {
+  name: string
+  old?: string
+  new?: string
+}
+
+export interface FieldsDiff {
+  added: FieldChange[]
+  removed: FieldChange[]
+  changed: FieldChange[]
+}
+
+export interface ValueChange<T = string> {
+  old: T
+  new: T
+}
+
+export interface URLDelta {
+  old: string
+  new: string
+  scheme?: ValueChange
+  host?: ValueChange
+  path?: ValueChange
+  query?: FieldsDiff
+}
+
+export interface JSONChange {
+  op: 'add' | 'remove' | 'replace'
+  path: string  // JSON Pointer
+  old?: unknown
+  new?: unknown
+}
+
+export interface BodyPatch {
+  kind: 'text' | 'json' | 'binary'
+  oldSize: number
+  newSize: number
+  patch?: string      // 统一 diff 格式的补丁
+  json?: JSONChange[] // JSON 消息体的逐字段变更
+  tooLarge?: boolean  // 消息体过大，未计算补丁
+}
+
+export interface EventDiff {
+  request?: {
+    method?: ValueChange
+    url?: URLDelta
+    headers?: FieldsDiff
+    body?: BodyPatch
+  }
+  response?: {
+    statusCode?: ValueChange<number>
+    headers?: FieldsDiff
+    body?: BodyPatch
+  }
+}
+
+// 目标连接事件：意外断开、重新附着成功、放弃重连
+export interface ConnectionEvent {
+  type: 'disconnected' | 'reconnected' | 'reconnectFailed'
+  targetId: string
+  previousTarget?: string  // 按 URL 重新找到目标时的原目标
+  attempts?: number
+  resumed?: number
+  orphaned?: number
+  error?: string
+  timestamp: number
 }
@@ -6,9 +6,9 @@ import i18next from 'i18next'
 export type RuleID = string
 
 // 生命周期阶段
-export type Stage = 'request' | 'response'
+export type Stage = 'request' | 'response' | 'websocket'
 
-// V2 细粒度条件类型（25种）
+// V2 细粒度条件类型（27种）
 export type ConditionType =
   // URL 条件
   | 'urlEquals'
@@ -16,6 +16,7 @@ export type ConditionType =
   | 'urlSuffix'
   | 'urlContains'
   | 'urlRegex'
+  | 'host'
   // Method 和 ResourceType
   | 'method'
   | 'resourceType'
@@ -41,15 +42,25 @@ export type ConditionType =
   | 'bodyContains'
   | 'bodyRegex'
   | 'bodyJsonPath'
+  // 规则依赖条件
+  | 'ruleMatched'
+  // 响应条件（仅响应阶段）
+  | 'statusCode'
+  | 'contentType'
+  | 'responseTime'
+
+// ruleMatched 条件的回溯范围
+export type MatchScope = 'session' | 'pageLoad'
 
 // 条件定义
 export interface Condition {
   type: ConditionType
-  value?: string         // urlEquals, urlPrefix, urlSuffix, urlContains, *Equals, *Contains, bodyContains
+  value?: string         // urlEquals, urlPrefix, urlSuffix, urlContains, host, *Equals, *Contains, bodyContains, contentType；statusCode、responseTime 为范围列表
   values?: string[]      // method, resourceType
   pattern?: string       // urlRegex, *Regex
   name?: string          // header*, query*, cookie*
   path?: string          // bodyJsonPath
+  scope?: MatchScope     // ruleMatched，默认 session
 }
 
 export interface Match {
@@ -66,11 +77,30 @@ export type ActionType =
   | 'removeQueryParam'
   | 'setCookie'
   | 'removeCookie'
+  | 'setBrowserCookie'
   | 'setFormField'
   | 'removeFormField'
+  | 'setFormFile'
+  | 'setUserAgent'
+  | 'mirror'
+  | 'canary'
+  | 'mapRemote'
+  | 'sign'
+  | 'notModified'
+  | 'redirect'
+  | 'mapLocal'
   | 'block'
+  | 'rateLimit'
+  | 'auth'
   // 响应阶段专用
   | 'setStatus'
+  | 'setCache'
+  | 'setSecurityHeaders'
+  | 'saveBody'
+  | 'maskJson'
+  | 'validateSchema'
+  | 'augmentJson'
+  | 'randomStatus'
   // 通用
   | 'setHeader'
   | 'removeHeader'
@@ -78,10 +108,30 @@ export type ActionType =
   | 'appendBody'
   | 'replaceBodyText'
   | 'patchBodyJson'
+  | 'jqTransform'
+  | 'script'
+  | 'throttle'
+  | 'fail'
+  | 'truncateBody'
+  | 'corruptJson'
+  | 'variant'
+  | 'stripValidators'
 
 // Body 编码方式
 export type BodyEncoding = 'text' | 'base64'
 
+// JSON 字段屏蔽方式
+export type MaskMode = 'remove' | 'null'
+
+// JSON Schema 违规处理方式
+export type ViolationMode = 'report' | 'flag' | 'fail'
+
+// 限流计数键来源
+export type RateLimitKey = 'url' | 'header' | 'cookie'
+
+// 变体分配的客户端键来源
+export type StickyKey = 'cookie' | 'header'
+
 // JSON Patch 操作
 export interface JSONPatchOp {
   op: 'add' | 'remove' | 'replace' | 'move' | 'copy' | 'test'
@@ -90,20 +140,128 @@ export interface JSONPatchOp {
   from?: string
 }
 
+// 签名方式
+export type SignMethod = 'hmac' | 'awsSigV4'
+
+// 签名参数，密钥均以环境变量名引用
+export interface SignSpec {
+  method: SignMethod
+  secretEnv?: string            // hmac 密钥的环境变量名
+  header?: string               // hmac 写入签名的请求头，默认 X-Signature
+  algorithm?: 'sha256' | 'sha1' | 'sha512'
+  encoding?: 'hex' | 'base64'
+  payload?: string              // hmac 待签名内容模板，默认 {body}
+  template?: string             // hmac 请求头值模板，默认 {signature}
+  region?: string               // awsSigV4
+  service?: string              // awsSigV4
+  accessKeyEnv?: string         // awsSigV4，默认 AWS_ACCESS_KEY_ID
+  secretKeyEnv?: string         // awsSigV4，默认 AWS_SECRET_ACCESS_KEY
+  sessionTokenEnv?: string      // awsSigV4，默认 AWS_SESSION_TOKEN
+}
+
+// 认证质询的应答方式
+export type AuthMode = 'provide' | 'approve' | 'cancel'
+
+// 认证质询的应答参数，密码可以环境变量名引用
+export interface AuthSpec {
+  mode?: AuthMode               // 默认 provide
+  source?: '' | 'server' | 'proxy'  // 应答的质询来源，为空时都应答
+  username?: string             // provide 的用户名，approve 时作为默认凭据
+  password?: string
+  passwordEnv?: string          // 保存密码的环境变量名，设置后取代 password
+  timeoutMS?: number            // approve 等待人工处理的最长时间，默认 2 分钟
+}
+
+// setBrowserCookie 写入浏览器的 Cookie 属性，名称与值取自 name、value
+export interface CookieSpec {
+  domain?: string               // 为空时仅对当前请求的主机生效
+  path?: string
+  maxAge?: number               // 有效秒数，为 0 时为会话 Cookie
+  httpOnly?: boolean
+  secure?: boolean
+  sameSite?: '' | 'Strict' | 'Lax' | 'None'
+}
+
+// 响应增强参数
+export interface AugmentSpec {
+  source: string                // http(s) URL 或本地 JSON 文件路径
+  keys?: Record<string, string> // 目标路径 -> 来源路径，未设置时整体合并到响应根对象
+  timeout?: string              // 获取超时，如 500ms、2s，默认 3s
+}
+
+// throttle 延迟分布类型
+export type DelayDistribution = 'uniform' | 'normal' | 'percentile'
+
+// 延迟百分位表中的一项
+export interface DelayPoint {
+  p: number                     // 百分位，0-100
+  ms: number                    // 该百分位的延迟毫秒数
+}
+
+// throttle 延迟分布，设置后取代固定的 latencyMS，每条消息独立抽样
+export interface DelaySpec {
+  distribution?: DelayDistribution // 默认 uniform
+  minMS?: number                // 最小延迟（uniform、normal）
+  maxMS?: number                // 最大延迟（uniform、normal），normal 为 0 时不设上限
+  meanMS?: number               // 均值（normal）
+  stdDevMS?: number             // 标准差（normal）
+  percentiles?: DelayPoint[]    // 百分位表（percentile），按百分位升序
+}
+
+// 映射远程地址参数，为空的部分保留原值
+export interface MapRemoteSpec {
+  scheme?: string               // 新协议，如 https
+  host?: string                 // 新主机名，不含端口
+  port?: number                 // 新端口，0 保留原端口
+  pathPrefix?: string           // 要替换的原路径前缀，不匹配时不改写
+  newPathPrefix?: string        // 替换后的路径前缀，为空时去掉原前缀
+}
+
+// 变体定义
+export interface Variant {
+  name: string
+  weight?: number               // 分配权重，全部为 0 时平均分配
+  actions: Action[]             // 选中时执行的行为，不支持嵌套 variant
+}
+
 // 行为定义
 export interface Action {
   type: ActionType
-  value?: string | number       // setUrl, setMethod, setStatus, setBody, setHeader, setQueryParam, setCookie, setFormField
-  name?: string                 // setHeader, removeHeader, setQueryParam, removeQueryParam, setCookie, removeCookie, setFormField, removeFormField
+  value?: string | number       // setUrl, setMethod, setStatus, setBody, setHeader, setQueryParam, setCookie, setBrowserCookie, setFormField, setUserAgent, mirror, canary（备用后端地址）, setCache（缓存预设）, setSecurityHeaders（安全头部预设）, saveBody（保存目录）, jqTransform（jq 程序）, redirect（Location 模板）, mapLocal（本地文件或目录）, script（JavaScript 脚本）, fail（网络错误原因）, randomStatus（逗号分隔的候选状态码）
+  name?: string                 // setHeader, removeHeader, setQueryParam, removeQueryParam, setCookie, removeCookie, setBrowserCookie, setFormField, removeFormField, rateLimit, variant
   encoding?: BodyEncoding       // setBody
   search?: string               // replaceBodyText
   replace?: string              // replaceBodyText
   replaceAll?: boolean          // replaceBodyText
   patches?: JSONPatchOp[]       // patchBodyJson
-  statusCode?: number           // block
-  headers?: Record<string, string>  // block
-  body?: string                 // block
-  bodyEncoding?: BodyEncoding   // block
+  statusCode?: number           // block, redirect, mapLocal
+  headers?: Record<string, string>  // block, rateLimit, notModified, redirect, mapLocal, setSecurityHeaders（覆盖预设，值为空表示移除）
+  body?: string                 // block, rateLimit, redirect
+  bodyEncoding?: BodyEncoding   // block, rateLimit, redirect
+  pattern?: string              // redirect、mapLocal 匹配请求 URL 的正则，Location 模板与文件路径模板可用 $1、${name} 引用捕获组
+  preserveMethod?: boolean      // redirect 以原请求方法与请求体重发，使用 307/308
+  filename?: string             // saveBody 文件名模板，mapLocal 目录下的文件路径模板，setFormFile 上传的文件名
+  contentType?: string          // setFormFile 文件的 Content-Type
+  paths?: string[]              // maskJson 字段路径模式
+  maskMode?: MaskMode           // maskJson
+  schema?: string | object      // validateSchema JSON Schema
+  onViolation?: ViolationMode   // validateSchema
+  limit?: number                // rateLimit 窗口内允许的请求数
+  window?: string               // rateLimit 计数窗口，如 10s、1m
+  rateKey?: RateLimitKey        // rateLimit 计数键来源
+  retryAfter?: number           // rateLimit Retry-After 秒数，为 0 时使用窗口剩余时间
+  variants?: Variant[]          // variant 候选变体
+  stickyBy?: StickyKey          // variant 区分客户端的键来源
+  percent?: number              // canary 路由到备用后端的请求百分比，truncateBody 保留的消息体百分比（0 表示随机位置截断）
+  sign?: SignSpec               // sign 签名参数
+  auth?: AuthSpec               // auth 认证质询的应答方式与凭据
+  cookie?: CookieSpec           // setBrowserCookie 写入浏览器的 Cookie 属性
+  augment?: AugmentSpec         // augmentJson 次级数据源与合并方式
+  remote?: MapRemoteSpec        // mapRemote 改写后的地址
+  latencyMS?: number            // throttle 放行前的固定额外延迟毫秒数
+  delay?: DelaySpec             // throttle 额外延迟的分布，设置后取代 latencyMS
+  bandwidth?: number            // throttle 带宽上限（字节/秒），0 表示不限制
+  probability?: number          // fail、randomStatus、truncateBody、corruptJson 的执行概率（0-1），不填表示每次都执行
 }
 
 export interface Rule {
@@ -114,6 +272,8 @@ export interface Rule {
   stage: Stage
   match: Match
   actions: Action[]
+  noSniff?: boolean  // 关闭消息体内容嗅探，仅按 Content-Type 判断
+  terminal?: boolean // 终止规则：命中后不再匹配同阶段中排在其后的规则
 }
 
 // 配置版本常量
@@ -158,13 +318,15 @@ export type ResourceType = typeof RESOURCE_TYPES[number]
 export const HTTP_METHODS = ['GET', 'POST', 'PUT', 'DELETE', 'PATCH', 'HEAD', 'OPTIONS'] as const
 
 export const CONDITION_GROUPS = {
-  url: ['urlEquals', 'urlPrefix', 'urlSuffix', 'urlContains', 'urlRegex'],
+  url: ['urlEquals', 'urlPrefix', 'urlSuffix', 'urlContains', 'urlRegex', 'host'],
   method: ['method'],
   resourceType: ['resourceType'],
   header: ['headerExists', 'headerNotExists', 'headerEquals', 'headerContains', 'headerRegex'],
   query: ['queryExists', 'queryNotExists', 'queryEquals', 'queryContains', 'queryRegex'],
   cookie: ['cookieExists', 'cookieNotExists', 'cookieEquals', 'cookieContains', 'cookieRegex'],
-  body: ['bodyContains', 'bodyRegex', 'bodyJsonPath']
+  body: ['bodyContains', 'bodyRegex', 'bodyJsonPath'],
+  rule: ['ruleMatched'],
+  response: ['statusCode', 'contentType', 'responseTime']
 } as const
 
 // 条件类型标签
@@ -184,6 +346,7 @@ export const CONDITION_TYPE_LABELS: Record<ConditionType, string> = {
   urlSuffix: 'URL 后缀匹配',
   urlContains: 'URL 包含',
   urlRegex: 'URL 正则匹配',
+  host: '域名匹配（含子域名）',
   method: 'HTTP 方法',
   resourceType: '资源类型',
   headerExists: 'Header 存在',
@@ -203,7 +366,11 @@ export const CONDITION_TYPE_LABELS: Record<ConditionType, string> = {
   cookieRegex: 'Cookie 正则匹配',
   bodyContains: 'Body 包含',
   bodyRegex: 'Body 正则匹配',
-  bodyJsonPath: 'JSON Path 匹配'
+  bodyJsonPath: 'JSON Path 匹配',
+  ruleMatched: '规则已匹配',
+  statusCode: '响应状态码',
+  contentType: '响应 Content-Type 包含',
+  responseTime: '响应耗时（毫秒）'
 }
 
 // 保留原常量供兼容
@@ -213,6 +380,7 @@ export const CONDITION_TYPE_SHORT_LABELS: Record<ConditionType, string> = {
   urlSuffix: 'URL 后缀',
   urlContains: 'URL 含',
   urlRegex: 'URL 正则',
+  host: '域名',
   method: '方法',
   resourceType: '资源类型',
   headerExists: 'Header 存在',
@@ -232,21 +400,34 @@ export const CONDITION_TYPE_SHORT_LABELS: Record<ConditionType, string> = {
   cookieRegex: 'Cookie 正则',
   bodyContains: 'Body 含',
   bodyRegex: 'Body 正则',
-  bodyJsonPath: 'JSON Path'
+  bodyJsonPath: 'JSON Path',
+  ruleMatched: '规则已匹配',
+  statusCode: '状态码',
+  contentType: 'Content-Type',
+  responseTime: '耗时'
 }
 
 // 请求阶段可用行为
 export const REQUEST_ACTIONS: ActionType[] = [
   'setUrl', 'setMethod', 'setHeader', 'removeHeader',
-  'setQueryParam', 'removeQueryParam', 'setCookie', 'removeCookie',
-  'setBody', 'appendBody', 'replaceBodyText', 'patchBodyJson',
-  'setFormField', 'removeFormField', 'block'
+  'setQueryParam', 'removeQueryParam', 'setCookie', 'removeCookie', 'setBrowserCookie',
+  'setBody', 'appendBody', 'replaceBodyText', 'patchBodyJson', 'jqTransform', 'script',
+  'setFormField', 'removeFormField', 'setFormFile', 'setUserAgent', 'mirror', 'canary', 'mapRemote', 'variant', 'rateLimit', 'throttle', 'sign', 'stripValidators', 'notModified', 'redirect', 'mapLocal', 'auth',
+  'fail', 'truncateBody', 'corruptJson', 'block'
 ]
 
 // 响应阶段可用行为
 export const RESPONSE_ACTIONS: ActionType[] = [
-  'setStatus', 'setHeader', 'removeHeader',
-  'setBody', 'appendBody', 'replaceBodyText', 'patchBodyJson'
+  'setStatus', 'setCache', 'setSecurityHeaders', 'setHeader', 'removeHeader',
+  'setBody', 'appendBody', 'replaceBodyText', 'patchBodyJson', 'jqTransform', 'script', 'saveBody', 'maskJson', 'validateSchema', 'augmentJson', 'variant', 'stripValidators', 'throttle',
+  'fail', 'randomStatus', 'truncateBody', 'corruptJson'
+]
+
+// fail 行为可用的网络错误原因，与 CDP Network.ErrorReason 一致
+export const FAIL_REASONS = [
+  'ConnectionReset', 'ConnectionRefused', 'ConnectionClosed', 'ConnectionAborted', 'ConnectionFailed', 'TimedOut',
+  'NameNotResolved', 'InternetDisconnected', 'AddressUnreachable', 'AccessDenied', 'Aborted', 'Failed',
+  'BlockedByClient', 'BlockedByResponse'
 ]
 
 // 行为类型标签
@@ -264,13 +445,40 @@ export const ACTION_TYPE_LABELS: Record<ActionType, string> = {
   removeQueryParam: '移除 Query 参数',
   setCookie: '设置 Cookie',
   removeCookie: '移除 Cookie',
+  setBrowserCookie: '写入浏览器 Cookie',
   setBody: '替换 Body',
   appendBody: '追加 Body',
   replaceBodyText: '文本替换 Body',
   patchBodyJson: 'JSON Patch',
+  jqTransform: 'jq 转换 Body',
+  script: '执行脚本',
+  throttle: '模拟慢速网络',
+  fail: '模拟网络错误',
+  randomStatus: '随机错误状态码',
+  truncateBody: '截断 Body',
+  corruptJson: '破坏 JSON',
   setFormField: '设置表单字段',
   removeFormField: '移除表单字段',
+  setFormFile: '替换上传文件',
+  setUserAgent: '设置 User-Agent',
+  mirror: '复制到影子后端',
+  canary: '金丝雀路由',
+  mapRemote: '映射远程地址',
+  sign: '重新签名',
+  auth: '应答认证质询',
+  notModified: '模拟 304',
+  redirect: '重定向',
+  mapLocal: '映射本地文件',
+  stripValidators: '移除缓存验证',
   setStatus: '设置状态码',
+  setCache: '设置缓存策略',
+  setSecurityHeaders: '设置安全头部',
+  saveBody: '保存响应体',
+  maskJson: '屏蔽 JSON 字段',
+  validateSchema: '校验 JSON Schema',
+  augmentJson: '合并次级数据',
+  rateLimit: '模拟限流',
+  variant: '分组变体',
   block: '拦截请求'
 }
 
@@ -312,7 +520,10 @@ export function createEmptyAction(type: ActionType = 'setHeader', _stage: Stage
   switch (type) {
     case 'setUrl':
     case 'setMethod':
+    case 'mirror':
       return { type, value: '' }
+    case 'setUserAgent':
+      return { type, value: 'chrome-android' }
     case 'setHeader':
     case 'setQueryParam':
     case 'setCookie':
@@ -326,12 +537,65 @@ export function createEmptyAction(type: ActionType = 'setHeader', _stage: Stage
     case 'setBody':
     case 'appendBody':
       return { type, value: '', encoding: 'text' }
+    case 'setFormFile':
+      return { type, name: '', value: '', encoding: 'text', filename: '', contentType: '' }
     case 'replaceBodyText':
       return { type, search: '', replace: '', replaceAll: false }
     case 'patchBodyJson':
       return { type, patches: [] }
+    case 'jqTransform':
+      return { type, value: '.' }
+    case 'script':
+      return { type, value: '({})' }
     case 'setStatus':
       return { type, value: 200 }
+    case 'setCache':
+      return { type, value: 'disable' }
+    case 'setSecurityHeaders':
+      return { type, value: 'strict' }
+    case 'saveBody':
+      return { type, value: '', filename: '{host}/{ts}-{name}{ext}' }
+    case 'maskJson':
+      return { type, paths: [], maskMode: 'remove' }
+    case 'validateSchema':
+      return { type, schema: '{\n  "type": "object"\n}', onViolation: 'report' }
+    case 'augmentJson':
+      return { type, augment: { source: '', keys: {} } }
+    case 'rateLimit':
+      return { type, limit: 5, window: '1m', rateKey: 'url' }
+    case 'throttle':
+      return { type, latencyMS: 500, bandwidth: 0 }
+    case 'fail':
+      return { type, value: 'ConnectionReset' }
+    case 'randomStatus':
+      return { type, value: '500,502,503,504' }
+    case 'truncateBody':
+    case 'corruptJson':
+      return { type }
+    case 'canary':
+      return { type, value: '', percent: 10 }
+    case 'mapRemote':
+      return { type, remote: { host: '' } }
+    case 'sign':
+      return { type, sign: { method: 'hmac', secretEnv: '', header: 'X-Signature' } }
+    case 'auth':
+      return { type, auth: { mode: 'provide', source: '', username: '', passwordEnv: '' } }
+    case 'setBrowserCookie':
+      return { type, name: '', value: '', cookie: { path: '/', maxAge: 0 } }
+    case 'variant':
+      return {
+        type,
+        stickyBy: 'cookie',
+        name: '',
+        variants: [
+          { name: 'A', weight: 50, actions: [] },
+          { name: 'B', weight: 50, actions: [] }
+        ]
+      }
+    case 'redirect':
+      return { type, value: '', pattern: '', statusCode: 302 }
+    case 'mapLocal':
+      return { type, value: '', filename: '{host}/{path}' }
     case 'block':
       return { type, statusCode: 200, headers: { 'Content-Type': 'application/json' }, body: '{}' }
     default:
@@ -361,14 +625,15 @@ export function isTerminalAction(action: Action): boolean {
 
 // 判断行为是否适用于指定阶段
 export function isActionValidForStage(actionType: ActionType, stage: Stage): boolean {
-  if (stage === 'request') {
-    return REQUEST_ACTIONS.includes(actionType)
-  }
-  return RESPONSE_ACTIONS.includes(actionType)
+  return getActionsForStage(stage).includes(actionType)
 }
 
 // 获取阶段可用的行为类型
+// WebSocket 阶段的规则只记录匹配的消息帧，没有可用的行为
 export function getActionsForStage(stage: Stage): ActionType[] {
+  if (stage === 'websocket') {
+    return []
+  }
   return stage === 'request' ? REQUEST_ACTIONS : RESPONSE_ACTIONS
 }
 
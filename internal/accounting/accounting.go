@@ -0,0 +1,161 @@
+// Package accounting 按域名与资源类型统计会话流量
+package accounting
+
+import (
+	"maps"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cdpnetool/pkg/domain"
+)
+
+// key 统计维度
+type key struct {
+	host    string
+	resType domain.ResourceType
+}
+
+// Accountant 流量统计器，并发安全
+type Accountant struct {
+	mu       sync.Mutex
+	counters map[key]*domain.TrafficCounter
+	status   map[int]int64
+}
+
+// New 创建流量统计器
+func New() *Accountant {
+	return &Accountant{
+		counters: make(map[key]*domain.TrafficCounter),
+		status:   make(map[int]int64),
+	}
+}
+
+// AddRequest 记录一个请求及其上行字节数
+func (a *Accountant) AddRequest(req *domain.Request, blocked bool) {
+	a.update(req, func(c *domain.TrafficCounter) {
+		c.Requests++
+		c.RequestBytes += requestSize(req)
+		if blocked {
+			c.Blocked++
+		}
+	})
+}
+
+// AddResponse 记录请求对应响应的下行字节数与状态码，未获取响应体（Body 为 nil）时按 Content-Length 估算
+func (a *Accountant) AddResponse(req *domain.Request, res *domain.Response) {
+	bodySize := int64(len(res.Body))
+	if res.Body == nil {
+		bodySize = contentLength(res.Headers)
+	}
+	a.update(req, func(c *domain.TrafficCounter) {
+		c.ResponseBytes += headerSize(res.Headers) + bodySize
+		a.status[res.StatusCode]++
+	})
+}
+
+// update 在请求所属维度上更新计数
+func (a *Accountant) update(req *domain.Request, fn func(c *domain.TrafficCounter)) {
+	k := key{host: Host(req.URL), resType: req.ResourceType}
+	if k.resType == "" {
+		k.resType = domain.ResourceTypeOther
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c, ok := a.counters[k]
+	if !ok {
+		c = &domain.TrafficCounter{}
+		a.counters[k] = c
+	}
+	fn(c)
+}
+
+// Snapshot 返回当前统计快照，域名按总字节数降序排列
+func (a *Accountant) Snapshot() domain.TrafficStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := domain.TrafficStats{
+		Domains: []domain.DomainTraffic{},
+		ByType:  make(map[domain.ResourceType]domain.TrafficCounter),
+		Status:  maps.Clone(a.status),
+	}
+	byDomain := make(map[string]*domain.DomainTraffic)
+	for k, c := range a.counters {
+		d, ok := byDomain[k.host]
+		if !ok {
+			d = &domain.DomainTraffic{Domain: k.host, ByType: make(map[domain.ResourceType]domain.TrafficCounter)}
+			byDomain[k.host] = d
+		}
+		d.ByType[k.resType] = *c
+		add(&d.Total, *c)
+		add(&stats.Total, *c)
+		t := stats.ByType[k.resType]
+		add(&t, *c)
+		stats.ByType[k.resType] = t
+	}
+
+	for _, d := range byDomain {
+		stats.Domains = append(stats.Domains, *d)
+	}
+	sort.Slice(stats.Domains, func(i, j int) bool {
+		bi := stats.Domains[i].Total.RequestBytes + stats.Domains[i].Total.ResponseBytes
+		bj := stats.Domains[j].Total.RequestBytes + stats.Domains[j].Total.ResponseBytes
+		if bi != bj {
+			return bi > bj
+		}
+		return stats.Domains[i].Domain < stats.Domains[j].Domain
+	})
+	return stats
+}
+
+// Reset 清空统计
+func (a *Accountant) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.counters = make(map[key]*domain.TrafficCounter)
+	a.status = make(map[int]int64)
+}
+
+// Host 提取 URL 的主机名（不含端口），无法解析时返回空字符串
+func Host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// add 累加计数
+func add(dst *domain.TrafficCounter, c domain.TrafficCounter) {
+	dst.Requests += c.Requests
+	dst.Blocked += c.Blocked
+	dst.RequestBytes += c.RequestBytes
+	dst.ResponseBytes += c.ResponseBytes
+}
+
+// requestSize 估算请求的上行字节数
+func requestSize(req *domain.Request) int64 {
+	return int64(len(req.Method)+len(req.URL)) + headerSize(req.Headers) + int64(len(req.Body))
+}
+
+// headerSize 估算头部字节数，每个头部按 "Name: Value\r\n" 计算
+func headerSize(h domain.Header) int64 {
+	var n int64
+	for k, v := range h {
+		n += int64(len(k) + len(v) + 4)
+	}
+	return n
+}
+
+// contentLength 忽略大小写读取 Content-Length，缺失或无效时返回 0
+func contentLength(h domain.Header) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(h.Get("Content-Length")), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,107 @@
+package accounting_test
+
+import (
+	"sync"
+	"testing"
+
+	"cdpnetool/internal/accounting"
+	"cdpnetool/pkg/domain"
+)
+
+func newRequest(url string, resType domain.ResourceType, body string) *domain.Request {
+	req := domain.NewRequest()
+	req.URL = url
+	req.Method = "GET"
+	req.ResourceType = resType
+	req.Body = []byte(body)
+	return req
+}
+
+func TestAccountant_Snapshot(t *testing.T) {
+	a := accounting.New()
+
+	api := newRequest("https://api.example.com/v1", domain.ResourceTypeXHR, "0123456789")
+	a.AddRequest(api, false)
+	res := domain.NewResponse()
+	res.Body = make([]byte, 1000)
+	a.AddResponse(api, res)
+
+	img := newRequest("https://cdn.example.com:8443/a.png", domain.ResourceTypeImage, "")
+	a.AddRequest(img, false)
+	a.AddRequest(newRequest("https://cdn.example.com/track", "", ""), true)
+
+	stats := a.Snapshot()
+	if stats.Total.Requests != 3 || stats.Total.Blocked != 1 {
+		t.Errorf("got total %+v, want 3 requests and 1 blocked", stats.Total)
+	}
+	if len(stats.Domains) != 2 {
+		t.Fatalf("got %d domains, want 2", len(stats.Domains))
+	}
+	// 按字节数降序，api 域名的响应体最大
+	first := stats.Domains[0]
+	if first.Domain != "api.example.com" || first.Total.ResponseBytes != 1000 {
+		t.Errorf("unexpected first domain %+v", first)
+	}
+	wantReq := int64(len("GET") + len(api.URL) + len("0123456789"))
+	if first.Total.RequestBytes != wantReq {
+		t.Errorf("got request bytes %d, want %d", first.Total.RequestBytes, wantReq)
+	}
+
+	cdn := stats.Domains[1]
+	if cdn.Domain != "cdn.example.com" || cdn.Total.Requests != 2 {
+		t.Errorf("端口不同的同一主机应合并统计: %+v", cdn)
+	}
+	if cdn.ByType[domain.ResourceTypeOther].Blocked != 1 || cdn.ByType[domain.ResourceTypeImage].Requests != 1 {
+		t.Errorf("unexpected per-type stats %+v", cdn.ByType)
+	}
+	if stats.ByType[domain.ResourceTypeXHR].ResponseBytes != 1000 {
+		t.Errorf("unexpected global per-type stats %+v", stats.ByType)
+	}
+	if len(stats.Status) != 1 || stats.Status[200] != 1 {
+		t.Errorf("got status distribution %v, want one 200", stats.Status)
+	}
+
+	a.Reset()
+	if s := a.Snapshot(); s.Total.Requests != 0 || len(s.Domains) != 0 || len(s.Status) != 0 {
+		t.Errorf("got %+v after reset, want empty", s)
+	}
+}
+
+func TestAccountant_ResponseWithoutBody(t *testing.T) {
+	a := accounting.New()
+	req := newRequest("https://example.com/app.js", domain.ResourceTypeScript, "")
+
+	// 未获取响应体时按 Content-Length 估算
+	res := domain.NewResponse()
+	res.Headers.Set("content-length", "2048")
+	a.AddResponse(req, res)
+
+	// 已获取的空响应体按实际大小统计
+	empty := domain.NewResponse()
+	empty.Headers.Set("content-length", "2048")
+	empty.Body = []byte{}
+	a.AddResponse(req, empty)
+
+	header := int64(len("content-length") + len("2048") + 4)
+	if got, want := a.Snapshot().Total.ResponseBytes, 2*header+2048; got != want {
+		t.Errorf("got response bytes %d, want %d", got, want)
+	}
+}
+
+func TestAccountant_Concurrent(t *testing.T) {
+	a := accounting.New()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				a.AddRequest(newRequest("https://example.com/", domain.ResourceTypeFetch, ""), false)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := a.Snapshot().Total.Requests; got != 800 {
+		t.Errorf("got %d requests, want 800", got)
+	}
+}
@@ -0,0 +1,52 @@
+package cdp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/protocol/fetch"
+	cdpio "github.com/mafredri/cdp/protocol/io"
+)
+
+// ReadResponseBodyStream 以 Fetch.takeResponseBodyAsStream 取出暂停在响应阶段的请求的响应体，并以 IO.read 按 chunkSize 字节分块读取，
+// timeout 为每次 CDP 调用的超时。taken 表示响应体已被取出：此后浏览器不再收到原始响应体，调用方需以 FulfillRequest 应答或让请求失败
+func ReadResponseBodyStream(ctx context.Context, client *cdp.Client, id fetch.RequestID, chunkSize int, timeout time.Duration) (body []byte, taken bool, err error) {
+	takeCtx, cancel := context.WithTimeout(ctx, timeout)
+	sr, err := client.Fetch.TakeResponseBodyAsStream(takeCtx, fetch.NewTakeResponseBodyAsStreamArgs(id))
+	cancel()
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		_ = client.IO.Close(closeCtx, cdpio.NewCloseArgs(sr.Stream))
+	}()
+
+	var buf bytes.Buffer
+	// 流只支持顺序读取，指定读取位置时 IO.read 会失败，因此不设置 Offset
+	args := cdpio.NewReadArgs(sr.Stream).SetSize(chunkSize)
+	for {
+		readCtx, cancel := context.WithTimeout(ctx, timeout)
+		r, err := client.IO.Read(readCtx, args)
+		cancel()
+		if err != nil {
+			return nil, true, err
+		}
+		if r.Base64Encoded != nil && *r.Base64Encoded {
+			chunk, err := base64.StdEncoding.DecodeString(r.Data)
+			if err != nil {
+				return nil, true, err
+			}
+			buf.Write(chunk)
+		} else {
+			buf.WriteString(r.Data)
+		}
+		if r.EOF {
+			return buf.Bytes(), true, nil
+		}
+	}
+}
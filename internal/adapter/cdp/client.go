@@ -16,6 +16,7 @@ import (
 // TargetSession 代表一个已附着的浏览器目标会话
 type TargetSession struct {
 	ID     domain.TargetID
+	URL    string // 附着时目标页面的 URL
 	Client *cdp.Client
 	Conn   *rpcc.Conn
 	Ctx    context.Context    // 会话级上下文
@@ -24,35 +25,37 @@ type TargetSession struct {
 
 // ClientManager 负责管理与浏览器的 CDP 连接
 type ClientManager struct {
-	devtoolsURL string
-	log         logger.Logger
-	mu          sync.RWMutex
-	sessions    map[domain.TargetID]*TargetSession
+	endpoint *endpoint
+	log      logger.Logger
+	mu       sync.RWMutex
+	sessions map[domain.TargetID]*TargetSession
 }
 
-// NewClientManager 创建 CDP 客户端管理器
-func NewClientManager(url string, l logger.Logger) *ClientManager {
+// NewClientManager 创建 CDP 客户端管理器，opts 为连接远程端点的选项，直连时为零值
+func NewClientManager(url string, opts domain.ConnectionOptions, l logger.Logger) (*ClientManager, error) {
 	if l == nil {
 		l = logger.NewNop()
 	}
-	return &ClientManager{
-		devtoolsURL: url,
-		log:         l,
-		sessions:    make(map[domain.TargetID]*TargetSession),
+	ep, err := newEndpoint(url, opts)
+	if err != nil {
+		return nil, err
 	}
+	return &ClientManager{
+		endpoint: ep,
+		log:      l,
+		sessions: make(map[domain.TargetID]*TargetSession),
+	}, nil
 }
 
 // TestConnection 测试与浏览器的连通性
 func (m *ClientManager) TestConnection(ctx context.Context) error {
-	dt := devtool.New(m.devtoolsURL)
-	_, err := dt.List(ctx)
+	_, err := m.endpoint.list(ctx)
 	return err
 }
 
 // ListTargets 获取浏览器当前所有的标签页目标（仅返回 type == "page"）
 func (m *ClientManager) ListTargets(ctx context.Context) ([]domain.TargetInfo, error) {
-	dt := devtool.New(m.devtoolsURL)
-	targets, err := dt.List(ctx)
+	targets, err := m.endpoint.list(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -92,8 +95,7 @@ func (m *ClientManager) AttachTarget(ctx context.Context, id domain.TargetID) (*
 		return s, nil
 	}
 
-	dt := devtool.New(m.devtoolsURL)
-	targets, err := dt.List(ctx)
+	targets, err := m.endpoint.list(ctx)
 	if err != nil {
 		m.log.Err(err, "获取 Target 列表失败")
 		return nil, err
@@ -109,16 +111,14 @@ func (m *ClientManager) AttachTarget(ctx context.Context, id domain.TargetID) (*
 
 	if target == nil {
 		m.log.Warn("Target 未找到", "targetID", string(id))
-		return nil, fmt.Errorf("cdp: target not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrTargetNotFound, id)
 	}
 
-	// 派生 Session 级 Context
-	sessionCtx, sessionCancel := context.WithCancel(ctx)
+	// 派生 Session 级 Context：继承调用方的值但不继承其取消，
+	// 调用方的 ctx 仅约束本次附着操作，连接生命周期由 DetachTarget/Close 管理
+	sessionCtx, sessionCancel := context.WithCancel(context.WithoutCancel(ctx))
 
-	// 使用与旧版一致的连接配置：压缩 + 大写缓冲
-	conn, err := rpcc.DialContext(sessionCtx, target.WebSocketDebuggerURL,
-		rpcc.WithWriteBufferSize(16*1024*1024),
-		rpcc.WithCompression())
+	conn, err := m.endpoint.dial(ctx, target.WebSocketDebuggerURL)
 	if err != nil {
 		sessionCancel()
 		m.log.Err(err, "CDP 连接建立失败", "targetID", string(id), "wsURL", target.WebSocketDebuggerURL)
@@ -127,6 +127,7 @@ func (m *ClientManager) AttachTarget(ctx context.Context, id domain.TargetID) (*
 
 	s := &TargetSession{
 		ID:     id,
+		URL:    target.URL,
 		Client: cdp.NewClient(conn),
 		Conn:   conn,
 		Ctx:    sessionCtx,
@@ -155,6 +156,25 @@ func (m *ClientManager) DetachTarget(id domain.TargetID) error {
 	return nil
 }
 
+// Close 断开所有目标连接
+func (m *ClientManager) Close() {
+	m.mu.Lock()
+	sessions := m.sessions
+	m.sessions = make(map[domain.TargetID]*TargetSession)
+	m.mu.Unlock()
+
+	for id, s := range sessions {
+		if s.Cancel != nil {
+			s.Cancel()
+		}
+		if s.Conn != nil {
+			if err := s.Conn.Close(); err != nil {
+				m.log.Warn("关闭 Target 连接失败", "targetID", string(id), "error", err)
+			}
+		}
+	}
+}
+
 // GetSession 获取已存在的会话
 func (m *ClientManager) GetSession(id domain.TargetID) (*TargetSession, bool) {
 	m.mu.RLock()
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"sort"
 	"strings"
 
 	"cdpnetool/internal/transformer"
@@ -91,5 +92,7 @@ func ToHeaderEntries(h domain.Header) []fetch.HeaderEntry {
 	for k, v := range h {
 		entries = append(entries, fetch.HeaderEntry{Name: k, Value: v})
 	}
+	// 按名称排序，保证生成的 CDP 参数稳定可比对
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
 	return entries
 }
@@ -0,0 +1,222 @@
+package cdp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"cdpnetool/pkg/domain"
+
+	"github.com/gorilla/websocket"
+	"github.com/mafredri/cdp/devtool"
+	"github.com/mafredri/cdp/rpcc"
+)
+
+// writeBufferSize WebSocket 写缓冲大小，单条 CDP 消息（如整段响应体）需一次写出
+const writeBufferSize = 16 * 1024 * 1024
+
+// endpoint 按连接选项访问 DevTools 端点：设置了连接选项或端点为 https 时，
+// /json/list 由自带的 HTTP 客户端请求，目标的 WebSocket 由自带的拨号器连接；否则沿用 devtool 与 rpcc 的默认实现
+type endpoint struct {
+	url    string
+	host   string      // 覆盖的 Host 请求头
+	header http.Header // 附加的请求头，含 Basic 认证
+	client *http.Client
+	dialer *websocket.Dialer
+}
+
+// newEndpoint 按连接选项创建端点访问方式，CA 证书文件无法读取或解析时返回 ErrInvalidConfig
+func newEndpoint(rawURL string, opts domain.ConnectionOptions) (*endpoint, error) {
+	e := &endpoint{url: strings.TrimSuffix(rawURL, "/")}
+	if opts.IsZero() && !strings.HasPrefix(rawURL, "https://") {
+		return e, nil
+	}
+
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	e.host = opts.Host
+	e.header = make(http.Header)
+	for name, value := range opts.Headers {
+		e.header.Set(name, value)
+	}
+	if opts.Username != "" {
+		cred := base64.StdEncoding.EncodeToString([]byte(opts.Username + ":" + opts.Password))
+		e.header.Set("Authorization", "Basic "+cred)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	e.dialer = &websocket.Dialer{
+		Proxy:             http.ProxyFromEnvironment,
+		TLSClientConfig:   tlsConfig,
+		WriteBufferSize:   writeBufferSize,
+		EnableCompression: true,
+	}
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid proxy %q", domain.ErrInvalidConfig, opts.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+		e.dialer.Proxy = http.ProxyURL(proxyURL)
+	}
+	e.client = &http.Client{Transport: transport}
+	return e, nil
+}
+
+// newTLSConfig 按连接选项创建 TLS 配置，未设置 TLS 相关选项时返回 nil 使用默认配置
+func newTLSConfig(opts domain.ConnectionOptions) (*tls.Config, error) {
+	if opts.CAFile == "" && opts.ServerName == "" && !opts.InsecureSkipVerify {
+		return nil, nil
+	}
+	cfg := &tls.Config{ServerName: opts.ServerName, InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: read CA file: %v", domain.ErrInvalidConfig, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificates found in CA file %s", domain.ErrInvalidConfig, opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// list 获取端点上的所有目标。devtool 会把端点中的主机名解析为 IP 后再访问，
+// 与 Host 改写、TLS 证书校验及按域名转发的网关不兼容，设置了连接选项时直接请求 /json/list
+func (e *endpoint) list(ctx context.Context) ([]*devtool.Target, error) {
+	if e.client == nil {
+		return devtool.New(e.url).List(ctx)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.url+"/json/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range e.header {
+		req.Header[name] = values
+	}
+	if e.host != "" {
+		req.Host = e.host
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("list targets: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var targets []*devtool.Target
+	if err := json.NewDecoder(resp.Body).Decode(&targets); err != nil {
+		return nil, fmt.Errorf("list targets: %w", err)
+	}
+	return targets, nil
+}
+
+// dial 连接目标的 WebSocket 调试地址
+func (e *endpoint) dial(ctx context.Context, wsURL string) (*rpcc.Conn, error) {
+	wsURL = e.webSocketURL(wsURL)
+	if e.dialer == nil {
+		// 使用与旧版一致的连接配置：压缩 + 大写缓冲
+		return rpcc.DialContext(ctx, wsURL,
+			rpcc.WithWriteBufferSize(writeBufferSize),
+			rpcc.WithCompression())
+	}
+	return rpcc.DialContext(ctx, wsURL, rpcc.WithDialer(func(ctx context.Context, addr string) (io.ReadWriteCloser, error) {
+		header := e.header.Clone()
+		if e.host != "" {
+			header.Set("Host", e.host)
+		}
+		ws, resp, err := e.dialer.DialContext(ctx, addr, header)
+		if err != nil {
+			if resp != nil {
+				err = fmt.Errorf("%w: %s", err, resp.Status)
+			}
+			return nil, err
+		}
+		return &socketConn{ws: ws}, nil
+	}))
+}
+
+// webSocketURL 将目标的调试地址改写到端点上：浏览器按收到的 Host 生成该地址，经隧道、网关或改写 Host 访问时
+// 其中的主机不可达。协议随端点（https 对应 wss），端点地址带路径前缀时补上该前缀
+func (e *endpoint) webSocketURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	ep, err := url.Parse(e.url)
+	if err != nil || ep.Host == "" {
+		return raw
+	}
+	switch ep.Scheme {
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Host = ep.Host
+	if prefix := strings.TrimSuffix(ep.Path, "/"); prefix != "" && !strings.HasPrefix(u.Path, prefix+"/") {
+		u.Path = prefix + u.Path
+	}
+	return u.String()
+}
+
+// socketConn 以 io.ReadWriteCloser 收发 WebSocket 文本消息，每次 Write 写出一条完整消息
+type socketConn struct {
+	ws *websocket.Conn
+	r  io.Reader // 当前正在读取的消息
+}
+
+func (c *socketConn) Read(p []byte) (int, error) {
+	for {
+		if c.r == nil {
+			_, r, err := c.ws.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			c.r = r
+		}
+		n, err := c.r.Read(p)
+		if err == io.EOF {
+			c.r = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *socketConn) Write(p []byte) (int, error) {
+	w, err := c.ws.NextWriter(websocket.TextMessage)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, w.Close()
+}
+
+func (c *socketConn) Close() error {
+	return c.ws.Close()
+}
@@ -0,0 +1,64 @@
+package cdp
+
+import (
+	"context"
+
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/protocol/browser"
+	"github.com/mafredri/cdp/protocol/emulation"
+)
+
+// OverrideUserAgent 通过 Emulation 覆盖 User-Agent，浏览器据此发送一致的 Sec-CH-UA 客户端提示
+// 并同步 navigator.userAgent / navigator.userAgentData
+func OverrideUserAgent(ctx context.Context, client *cdp.Client, preset rulespec.UserAgentPreset) error {
+	args := emulation.NewSetUserAgentOverrideArgs(preset.UserAgent)
+	if preset.Platform != "" {
+		args.SetPlatform(preset.Platform)
+	}
+	if len(preset.Brands) > 0 {
+		brands := make([]emulation.UserAgentBrandVersion, len(preset.Brands))
+		for i, b := range preset.Brands {
+			brands[i] = emulation.UserAgentBrandVersion{Brand: b.Brand, Version: b.Version}
+		}
+		args.SetUserAgentMetadata(emulation.UserAgentMetadata{
+			Brands:          brands,
+			Platform:        preset.UAPlatform,
+			PlatformVersion: preset.PlatformVersion,
+			Model:           preset.Model,
+			Mobile:          preset.Mobile,
+		})
+	}
+	return client.Emulation.SetUserAgentOverride(ctx, args)
+}
+
+// OverrideGeolocation 覆盖地理位置并授予定位权限，loc 为 nil 时清除覆盖
+func OverrideGeolocation(ctx context.Context, client *cdp.Client, loc *domain.GeoLocation) error {
+	if loc == nil {
+		return client.Emulation.ClearGeolocationOverride(ctx)
+	}
+	// 未授权时页面会弹出定位授权提示，覆盖值无法被读取
+	_ = client.Browser.GrantPermissions(ctx, browser.NewGrantPermissionsArgs([]browser.PermissionType{browser.PermissionTypeGeolocation}))
+
+	args := emulation.NewSetGeolocationOverrideArgs().
+		SetLatitude(loc.Latitude).
+		SetLongitude(loc.Longitude).
+		SetAccuracy(loc.Accuracy)
+	return client.Emulation.SetGeolocationOverride(ctx, args)
+}
+
+// OverrideTimezone 覆盖时区，timezoneID 为空时清除覆盖
+func OverrideTimezone(ctx context.Context, client *cdp.Client, timezoneID string) error {
+	return client.Emulation.SetTimezoneOverride(ctx, emulation.NewSetTimezoneOverrideArgs(timezoneID))
+}
+
+// OverrideLocale 覆盖 ICU 区域设置（影响 Intl 与日期格式化），locale 为空时清除覆盖
+func OverrideLocale(ctx context.Context, client *cdp.Client, locale string) error {
+	args := emulation.NewSetLocaleOverrideArgs()
+	if locale != "" {
+		args.SetLocale(locale)
+	}
+	return client.Emulation.SetLocaleOverride(ctx, args)
+}
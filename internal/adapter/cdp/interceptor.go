@@ -2,19 +2,36 @@ package cdp
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"cdpnetool/internal/logger"
 	"cdpnetool/internal/pool"
+	"cdpnetool/pkg/domain"
 
 	"github.com/mafredri/cdp"
 	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/protocol/network"
 )
 
+// PausedRequest 仍处于暂停状态、尚未下发处理结果的请求
+type PausedRequest struct {
+	ID       fetch.RequestID
+	Response bool // 是否暂停在响应阶段
+}
+
 // Interceptor 物理拦截适配器
 type Interceptor struct {
 	log  logger.Logger
 	pool *pool.Pool
+
+	mu       sync.Mutex
+	orphaned map[*cdp.Client][]PausedRequest // 因连接断开未能放行的请求，按连接归类
+
+	onDegrade func(ev *fetch.RequestPausedReply, reason string, err error) // 事件未交给处理函数即被降级放行时的回调
+	filter    *ResourceFilter                                              // 资源类型筛选，为 nil 时不筛选
 }
 
 // NewInterceptor 创建物理拦截适配器
@@ -22,17 +39,88 @@ func NewInterceptor(l logger.Logger, p *pool.Pool) *Interceptor {
 	if l == nil {
 		l = logger.NewNop()
 	}
-	return &Interceptor{log: l, pool: p}
+	return &Interceptor{log: l, pool: p, orphaned: make(map[*cdp.Client][]PausedRequest)}
 }
 
-// Enable 开启指定 Client 的拦截
-func (i *Interceptor) Enable(ctx context.Context, client *cdp.Client) error {
-	p := "*"
-	patterns := []fetch.RequestPattern{
-		{URLPattern: &p, RequestStage: fetch.RequestStageRequest},
-		{URLPattern: &p, RequestStage: fetch.RequestStageResponse},
+// SetDegradeHook 设置降级回调：并发池已满或处理函数 panic 导致事件被直接放行时调用，
+// err 为降级放行本身的错误，需在开始消费事件前设置
+func (i *Interceptor) SetDegradeHook(fn func(ev *fetch.RequestPausedReply, reason string, err error)) {
+	i.onDegrade = fn
+}
+
+// SetResourceFilter 设置资源类型筛选，只在浏览器侧暂停允许的类型，需在启用拦截前设置
+func (i *Interceptor) SetResourceFilter(f *ResourceFilter) {
+	i.filter = f
+}
+
+// degrade 降级放行事件并通知回调
+func (i *Interceptor) degrade(ctx context.Context, client *cdp.Client, ev *fetch.RequestPausedReply, reason string) error {
+	var err error
+	if ev.ResponseStatusCode == nil {
+		err = i.ContinueRequest(ctx, client, ev.RequestID)
+	} else {
+		err = i.ContinueResponse(ctx, client, ev.RequestID)
+	}
+	if i.onDegrade != nil {
+		i.onDegrade(ev, reason, err)
 	}
-	return client.Fetch.Enable(ctx, &fetch.EnableArgs{Patterns: patterns})
+	return err
+}
+
+// maxFetchPatterns 按资源类型展开后的拦截范围上限，超出时不在浏览器侧按类型筛选，改由处理时放行
+const maxFetchPatterns = 512
+
+// Enable 开启指定 Client 的拦截，handleAuth 为 true 时同时接管认证质询（authRequired 事件）。
+// patterns 为 nil 时在请求与响应阶段暂停所有请求，否则只暂停范围内的请求，两个阶段使用相同的范围；
+// patterns 为空时不暂停任何请求（Fetch 仍保持启用，以便接管认证质询）。
+// 设置了资源类型筛选时范围按允许的类型展开；接管认证质询时不展开，以免被筛掉的请求收不到凭据
+func (i *Interceptor) Enable(ctx context.Context, client *cdp.Client, handleAuth bool, patterns []domain.InterceptPattern) error {
+	if patterns == nil {
+		patterns = []domain.InterceptPattern{{URLPattern: "*"}}
+	}
+	type scope struct {
+		url string
+		rt  *network.ResourceType
+	}
+	var scopes []scope
+	for _, p := range patterns {
+		types := cdpTypesOf[p.ResourceType]
+		if p.ResourceType == "" && i.filter != nil && !handleAuth {
+			types = i.filter.types
+		}
+		if len(types) == 0 {
+			scopes = append(scopes, scope{url: p.URLPattern})
+			continue
+		}
+		for _, rt := range types {
+			if i.filter.Allows(rt) {
+				scopes = append(scopes, scope{url: p.URLPattern, rt: &rt})
+			}
+		}
+	}
+	if len(scopes) > maxFetchPatterns {
+		scopes = scopes[:0]
+		for _, p := range patterns {
+			scopes = append(scopes, scope{url: p.URLPattern})
+		}
+	}
+
+	var rps []fetch.RequestPattern
+	for _, sc := range scopes {
+		for _, stage := range []fetch.RequestStage{fetch.RequestStageRequest, fetch.RequestStageResponse} {
+			rps = append(rps, fetch.RequestPattern{URLPattern: &sc.url, ResourceType: sc.rt, RequestStage: stage})
+		}
+	}
+	if len(rps) == 0 {
+		// 省略 patterns 等同于拦截所有请求，以不会匹配任何 URL 的空模式表示不暂停
+		never := ""
+		rps = []fetch.RequestPattern{{URLPattern: &never}}
+	}
+	args := &fetch.EnableArgs{Patterns: rps}
+	if handleAuth {
+		args.SetHandleAuthRequests(true)
+	}
+	return client.Fetch.Enable(ctx, args)
 }
 
 // Disable 关闭指定 Client 的拦截
@@ -47,6 +135,7 @@ func (i *Interceptor) ContinueRequest(ctx context.Context, client *cdp.Client, i
 	err := client.Fetch.ContinueRequest(ctx2, &fetch.ContinueRequestArgs{RequestID: id})
 	if err != nil {
 		i.log.Err(err, "物理放行请求失败", "requestID", id)
+		i.recordOrphan(client, PausedRequest{ID: id}, err)
 	}
 	return err
 }
@@ -58,28 +147,130 @@ func (i *Interceptor) ContinueResponse(ctx context.Context, client *cdp.Client,
 	err := client.Fetch.ContinueResponse(ctx2, &fetch.ContinueResponseArgs{RequestID: id})
 	if err != nil {
 		i.log.Err(err, "物理放行响应失败", "requestID", id)
+		i.recordOrphan(client, PausedRequest{ID: id, Response: true}, err)
 	}
 	return err
 }
 
-// Consume 开启事件消费循环
-func (i *Interceptor) Consume(ctx context.Context, client *cdp.Client, handler func(ev *fetch.RequestPausedReply)) {
+// Resume 在重新建立的连接上放行断开前遗留的暂停请求，返回成功放行的数量；
+// 浏览器通常已随旧连接释放这些请求，新连接无法识别其 ID 时放行失败
+func (i *Interceptor) Resume(ctx context.Context, client *cdp.Client, reqs []PausedRequest) int {
+	resumed := 0
+	for _, r := range reqs {
+		ctx2, cancel := context.WithTimeout(ctx, 1*time.Second)
+		var err error
+		if r.Response {
+			err = client.Fetch.ContinueResponse(ctx2, &fetch.ContinueResponseArgs{RequestID: r.ID})
+		} else {
+			err = client.Fetch.ContinueRequest(ctx2, &fetch.ContinueRequestArgs{RequestID: r.ID})
+		}
+		cancel()
+		if err != nil {
+			i.log.Warn("放行遗留的暂停请求失败", "requestID", r.ID, "error", err.Error())
+			continue
+		}
+		resumed++
+	}
+	return resumed
+}
+
+// recordOrphan 记录因连接断开而未能放行的请求，其他原因的失败不记录
+func (i *Interceptor) recordOrphan(client *cdp.Client, r PausedRequest, err error) {
+	var ce interface{ Closed() bool }
+	if !errors.As(err, &ce) || !ce.Closed() {
+		return
+	}
+	i.mu.Lock()
+	i.orphaned[client] = append(i.orphaned[client], r)
+	i.mu.Unlock()
+}
+
+// takeOrphans 取出并清除指定连接上未能放行的请求
+func (i *Interceptor) takeOrphans(client *cdp.Client) []PausedRequest {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	reqs := i.orphaned[client]
+	delete(i.orphaned, client)
+	return reqs
+}
+
+// Subscribe 订阅拦截事件流，需在启用拦截前调用以免丢失事件
+func (i *Interceptor) Subscribe(ctx context.Context, client *cdp.Client) (fetch.RequestPausedClient, error) {
 	rp, err := client.Fetch.RequestPaused(ctx)
 	if err != nil {
 		i.log.Err(err, "订阅拦截事件流失败")
-		return
+		return nil, err
+	}
+	return rp, nil
+}
+
+// SubscribeAuth 订阅认证质询事件流，需在启用拦截前调用
+func (i *Interceptor) SubscribeAuth(ctx context.Context, client *cdp.Client) (fetch.AuthRequiredClient, error) {
+	ar, err := client.Fetch.AuthRequired(ctx)
+	if err != nil {
+		i.log.Err(err, "订阅认证质询事件流失败")
+		return nil, err
+	}
+	return ar, nil
+}
+
+// ConsumeAuth 开启认证质询消费循环，由 handler 决定如何应答每个质询；handler 需以 ContinueWithAuth 应答，
+// 可在返回后再应答（如等待人工处理），此期间请求保持暂停
+func (i *Interceptor) ConsumeAuth(ctx context.Context, ar fetch.AuthRequiredClient, handler func(ev *fetch.AuthRequiredReply)) {
+	defer ar.Close()
+
+	for {
+		ev, err := ar.Recv()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			default:
+				i.log.Err(err, "接收认证质询事件失败")
+			}
+			return
+		}
+		i.log.Debug("[Interceptor] 收到认证质询", "requestID", ev.RequestID, "origin", ev.AuthChallenge.Origin, "scheme", ev.AuthChallenge.Scheme)
+		handler(ev)
 	}
+}
+
+// ContinueWithAuth 应答认证质询，creds 仅在以凭据应答时使用
+func (i *Interceptor) ContinueWithAuth(ctx context.Context, client *cdp.Client, id fetch.RequestID, response domain.AuthResponse, creds *domain.ProxyCredentials) error {
+	resp := fetch.AuthChallengeResponse{Response: "Default"}
+	switch response {
+	case domain.AuthProvide:
+		resp = fetch.AuthChallengeResponse{Response: "ProvideCredentials", Username: &creds.Username, Password: &creds.Password}
+	case domain.AuthCancel:
+		resp = fetch.AuthChallengeResponse{Response: "CancelAuth"}
+	}
+	i.log.Debug("[Interceptor] 应答认证质询", "requestID", id, "response", resp.Response)
+
+	ctx2, cancel := context.WithTimeout(ctx, 1*time.Second)
+	defer cancel()
+	err := client.Fetch.ContinueWithAuth(ctx2, &fetch.ContinueWithAuthArgs{RequestID: id, AuthChallengeResponse: resp})
+	if err != nil {
+		i.log.Err(err, "应答认证质询失败", "requestID", id)
+	}
+	return err
+}
+
+// Consume 开启事件消费循环，返回时关闭事件流。ctx 结束时返回 nil；
+// 事件流意外断开时等待已分发的处理完成后返回断开原因，以及因连接断开仍处于暂停状态的请求
+func (i *Interceptor) Consume(ctx context.Context, client *cdp.Client, rp fetch.RequestPausedClient, handler func(ev *fetch.RequestPausedReply)) ([]PausedRequest, error) {
 	defer rp.Close()
 
+	var inflight sync.WaitGroup
 	for {
 		ev, err := rp.Recv()
 		if err != nil {
 			select {
 			case <-ctx.Done():
-				return
+				i.takeOrphans(client)
+				return nil, nil
 			default:
 				i.log.Err(err, "接收拦截事件失败")
-				return
+				inflight.Wait()
+				return i.takeOrphans(client), err
 			}
 		}
 
@@ -90,44 +281,34 @@ func (i *Interceptor) Consume(ctx context.Context, client *cdp.Client, handler f
 		}
 		i.log.Debug("[Interceptor] 接收 CDP 事件", "requestID", ev.RequestID, "stage", stage, "url", ev.Request.URL)
 
+		inflight.Add(1)
 		if i.pool != nil {
 			submitted := i.pool.Submit(func() {
+				defer inflight.Done()
 				defer func() {
 					if r := recover(); r != nil {
 						i.log.Err(nil, "handler panic 捕获", "requestID", ev.RequestID, "panic", r)
 						// 尝试降级放行
-						if ev.ResponseStatusCode == nil {
-							_ = i.ContinueRequest(ctx, client, ev.RequestID)
-						} else {
-							_ = i.ContinueResponse(ctx, client, ev.RequestID)
-						}
+						_ = i.degrade(ctx, client, ev, fmt.Sprintf("handler panic: %v", r))
 					}
 				}()
 				handler(ev)
 			})
 			if !submitted {
 				i.log.Warn("[Interceptor] 并发池已满，执行降级放行", "requestID", ev.RequestID, "url", ev.Request.URL)
-				if ev.ResponseStatusCode == nil {
-					if err := i.ContinueRequest(ctx, client, ev.RequestID); err != nil {
-						i.log.Err(err, "降级放行请求失败", "requestID", ev.RequestID)
-					}
-				} else {
-					if err := i.ContinueResponse(ctx, client, ev.RequestID); err != nil {
-						i.log.Err(err, "降级放行响应失败", "requestID", ev.RequestID)
-					}
+				if err := i.degrade(ctx, client, ev, "worker pool full"); err != nil {
+					i.log.Err(err, "降级放行失败", "requestID", ev.RequestID)
 				}
+				inflight.Done()
 			}
 		} else {
 			go func(ev *fetch.RequestPausedReply) {
+				defer inflight.Done()
 				defer func() {
 					if r := recover(); r != nil {
 						i.log.Err(nil, "handler panic 捕获", "requestID", ev.RequestID, "panic", r)
 						// 尝试降级放行
-						if ev.ResponseStatusCode == nil {
-							_ = i.ContinueRequest(ctx, client, ev.RequestID)
-						} else {
-							_ = i.ContinueResponse(ctx, client, ev.RequestID)
-						}
+						_ = i.degrade(ctx, client, ev, fmt.Sprintf("handler panic: %v", r))
 					}
 				}()
 				handler(ev)
@@ -0,0 +1,131 @@
+package cdp
+
+import (
+	"context"
+
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/protocol/network"
+)
+
+// SetCacheDisabled 禁用或恢复目标的浏览器 HTTP 缓存。
+// 缓存命中的请求不会触发 Fetch 暂停，禁用后响应阶段规则才能稳定生效；该设置仅在 Network 域启用时有效
+func SetCacheDisabled(ctx context.Context, client *cdp.Client, disabled bool) error {
+	if disabled {
+		if err := client.Network.Enable(ctx, network.NewEnableArgs()); err != nil {
+			return err
+		}
+	}
+	return client.Network.SetCacheDisabled(ctx, network.NewSetCacheDisabledArgs(disabled))
+}
+
+// EmulateNetworkConditions 模拟断网、延迟与吞吐限制，c 为 nil 时恢复正常网络。
+// 该设置仅在 Network 域启用时有效
+func EmulateNetworkConditions(ctx context.Context, client *cdp.Client, c *domain.NetworkConditions) error {
+	if c == nil {
+		c = &domain.NetworkConditions{}
+	}
+	// CDP 以 -1 表示不限制吞吐，0 会使传输停滞
+	download, upload := c.DownloadThroughput, c.UploadThroughput
+	if download == 0 {
+		download = -1
+	}
+	if upload == 0 {
+		upload = -1
+	}
+	if err := client.Network.Enable(ctx, network.NewEnableArgs()); err != nil {
+		return err
+	}
+	return client.Network.EmulateNetworkConditions(ctx, network.NewEmulateNetworkConditionsArgs(c.Offline, c.LatencyMS, download, upload))
+}
+
+// EnableNetwork 启用目标的 Network 域。
+// Fetch 暂停事件只有在 Network 域启用后才携带网络请求 ID，按需获取请求体依赖该 ID
+func EnableNetwork(ctx context.Context, client *cdp.Client) error {
+	return client.Network.Enable(ctx, network.NewEnableArgs())
+}
+
+// RequestPostData 按网络请求 ID 获取请求的 POST 数据。
+// 请求体较大或为表单上传时，Fetch 暂停事件中不含 postData，仅通过 hasPostData 标记存在请求体
+func RequestPostData(ctx context.Context, client *cdp.Client, id network.RequestID) ([]byte, error) {
+	reply, err := client.Network.GetRequestPostData(ctx, network.NewGetRequestPostDataArgs(id))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(reply.PostData), nil
+}
+
+// GetCookies 获取目标可见的浏览器 Cookie，urls 为空时返回当前页面及其子框架可见的 Cookie
+func GetCookies(ctx context.Context, client *cdp.Client, urls []string) ([]domain.BrowserCookie, error) {
+	args := network.NewGetCookiesArgs()
+	if len(urls) > 0 {
+		args.SetURLs(urls)
+	}
+	reply, err := client.Network.GetCookies(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	cookies := make([]domain.BrowserCookie, 0, len(reply.Cookies))
+	for _, c := range reply.Cookies {
+		bc := domain.BrowserCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+			SameSite: domain.CookieSameSite(c.SameSite),
+			Session:  c.Session,
+		}
+		// 会话 Cookie 的 expires 为 -1
+		if !c.Session && c.Expires > 0 {
+			bc.Expires = c.Expires
+		}
+		cookies = append(cookies, bc)
+	}
+	return cookies, nil
+}
+
+// SetCookie 将 Cookie 写入浏览器的 Cookie 存储，之后同域请求由浏览器自动携带
+func SetCookie(ctx context.Context, client *cdp.Client, c domain.BrowserCookie) error {
+	args := network.NewSetCookieArgs(c.Name, c.Value)
+	if c.URL != "" {
+		args.SetURL(c.URL)
+	}
+	if c.Domain != "" {
+		args.SetDomain(c.Domain)
+	}
+	if c.Path != "" {
+		args.SetPath(c.Path)
+	}
+	if c.Secure {
+		args.SetSecure(true)
+	}
+	if c.HTTPOnly {
+		args.SetHTTPOnly(true)
+	}
+	if c.SameSite != "" {
+		args.SetSameSite(network.CookieSameSite(c.SameSite))
+	}
+	if c.Expires > 0 {
+		args.SetExpires(network.TimeSinceEpoch(c.Expires))
+	}
+	_, err := client.Network.SetCookie(ctx, args)
+	return err
+}
+
+// DeleteCookies 删除浏览器 Cookie 存储中匹配名称及 URL、域名、路径条件的 Cookie
+func DeleteCookies(ctx context.Context, client *cdp.Client, f domain.CookieFilter) error {
+	args := network.NewDeleteCookiesArgs(f.Name)
+	if f.URL != "" {
+		args.SetURL(f.URL)
+	}
+	if f.Domain != "" {
+		args.SetDomain(f.Domain)
+	}
+	if f.Path != "" {
+		args.SetPath(f.Path)
+	}
+	return client.Network.DeleteCookies(ctx, args)
+}
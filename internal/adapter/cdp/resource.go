@@ -0,0 +1,61 @@
+package cdp
+
+import (
+	"slices"
+
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp/protocol/network"
+)
+
+// cdpTypesOf 各资源类型对应的 CDP 类型，other 包含其余所有 CDP 类型
+var cdpTypesOf = map[domain.ResourceType][]network.ResourceType{
+	domain.ResourceTypeDocument:   {network.ResourceTypeDocument},
+	domain.ResourceTypeStylesheet: {network.ResourceTypeStylesheet},
+	domain.ResourceTypeImage:      {network.ResourceTypeImage},
+	domain.ResourceTypeMedia:      {network.ResourceTypeMedia},
+	domain.ResourceTypeFont:       {network.ResourceTypeFont},
+	domain.ResourceTypeScript:     {network.ResourceTypeScript},
+	domain.ResourceTypeXHR:        {network.ResourceTypeXHR},
+	domain.ResourceTypeFetch:      {network.ResourceTypeFetch},
+	domain.ResourceTypeWebSocket:  {network.ResourceTypeWebSocket},
+	domain.ResourceTypeOther: {
+		network.ResourceTypeTextTrack, network.ResourceTypePrefetch, network.ResourceTypeEventSource,
+		network.ResourceTypeManifest, network.ResourceTypeSignedExchange, network.ResourceTypePing,
+		network.ResourceTypeCSPViolationReport, network.ResourceTypePreflight, network.ResourceTypeOther,
+	},
+}
+
+// ResourceFilter 按浏览器上报的资源类型筛选需要拦截的请求。不按 URL 扩展名推断类型，
+// 使浏览器侧的拦截范围与处理时的判断一致。nil 表示不筛选
+type ResourceFilter struct {
+	types []network.ResourceType // 允许拦截的 CDP 类型
+}
+
+// NewResourceFilter 创建资源类型筛选：include 为空时从所有类型开始，再去掉 exclude 中的类型；两者都为空时返回 nil
+func NewResourceFilter(include, exclude []domain.ResourceType) *ResourceFilter {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+	f := &ResourceFilter{types: []network.ResourceType{}}
+	for _, t := range []domain.ResourceType{
+		domain.ResourceTypeDocument, domain.ResourceTypeStylesheet, domain.ResourceTypeImage, domain.ResourceTypeMedia, domain.ResourceTypeFont,
+		domain.ResourceTypeScript, domain.ResourceTypeXHR, domain.ResourceTypeFetch, domain.ResourceTypeWebSocket, domain.ResourceTypeOther,
+	} {
+		if (len(include) == 0 || slices.Contains(include, t)) && !slices.Contains(exclude, t) {
+			f.types = append(f.types, cdpTypesOf[t]...)
+		}
+	}
+	return f
+}
+
+// Allows 判断是否拦截该 CDP 类型的请求，未上报类型的请求按 other 处理
+func (f *ResourceFilter) Allows(t network.ResourceType) bool {
+	if f == nil {
+		return true
+	}
+	if t == network.ResourceTypeNotSet {
+		t = network.ResourceTypeOther
+	}
+	return slices.Contains(f.types, t)
+}
@@ -0,0 +1,101 @@
+package cdp
+
+import (
+	"context"
+
+	"cdpnetool/internal/logger"
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/protocol/network"
+)
+
+// TimingSink 接收 Network 域的加载计时
+type TimingSink interface {
+	// Response 收到响应头，headersEnd 为收到响应头的单调时钟时间（秒），headerSize 为响应头的传输字节数
+	Response(networkID string, t domain.ResponseTiming, headersEnd float64, headerSize int64)
+	// Finish 加载完成或失败，timestamp 为单调时钟时间（秒），encodedLength 为网络传输的总字节数
+	Finish(networkID string, timestamp float64, encodedLength int64)
+}
+
+// SubscribeTiming 订阅目标的 Network.responseReceived、loadingFinished 与 loadingFailed 事件，需在启用 Network 域前调用。
+// 事件在后台按到达顺序交给 sink，ctx 结束或连接关闭时停止
+func SubscribeTiming(ctx context.Context, client *cdp.Client, sink TimingSink, l logger.Logger) error {
+	received, err := client.Network.ResponseReceived(ctx)
+	if err != nil {
+		return err
+	}
+	finished, err := client.Network.LoadingFinished(ctx)
+	if err != nil {
+		_ = received.Close()
+		return err
+	}
+	failed, err := client.Network.LoadingFailed(ctx)
+	if err != nil {
+		_ = received.Close()
+		_ = finished.Close()
+		return err
+	}
+	// 三个事件流按到达顺序交付，保证完成事件不会先于响应事件处理
+	if err := cdp.Sync(received, finished, failed); err != nil {
+		l.Warn("同步 Network 事件流失败，计时可能缺少传输耗时", "error", err.Error())
+	}
+
+	go func() {
+		defer received.Close()
+		defer finished.Close()
+		defer failed.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-received.Ready():
+				ev, err := received.Recv()
+				if err != nil {
+					return
+				}
+				headersEnd := 0.0
+				var t domain.ResponseTiming
+				if rt := ev.Response.Timing; rt != nil {
+					t = ToResponseTiming(rt)
+					if rt.ReceiveHeadersEnd >= 0 {
+						headersEnd = rt.RequestTime + rt.ReceiveHeadersEnd/1000
+					}
+				}
+				sink.Response(string(ev.RequestID), t, headersEnd, int64(ev.Response.EncodedDataLength))
+			case <-finished.Ready():
+				ev, err := finished.Recv()
+				if err != nil {
+					return
+				}
+				sink.Finish(string(ev.RequestID), float64(ev.Timestamp), int64(ev.EncodedDataLength))
+			case <-failed.Ready():
+				ev, err := failed.Recv()
+				if err != nil {
+					return
+				}
+				sink.Finish(string(ev.RequestID), float64(ev.Timestamp), 0)
+			}
+		}
+	}()
+	return nil
+}
+
+// ToResponseTiming 将 ResourceTiming 的各阶段时间点（相对请求开始的毫秒数，未发生的阶段为 -1）转换为阶段耗时
+func ToResponseTiming(rt *network.ResourceTiming) domain.ResponseTiming {
+	return domain.ResponseTiming{
+		DNS:     span(rt.DNSStart, rt.DNSEnd),
+		Connect: span(rt.ConnectStart, rt.ConnectEnd),
+		SSL:     span(rt.SSLStart, rt.SSLEnd),
+		Send:    span(rt.SendStart, rt.SendEnd),
+		TTFB:    span(rt.SendEnd, rt.ReceiveHeadersEnd),
+	}
+}
+
+// span 计算两个时间点之间的耗时（毫秒，保留三位小数），任一时间点未发生时为 0
+func span(start, end float64) float64 {
+	if start < 0 || end < start {
+		return 0
+	}
+	return float64(int64((end-start)*1000+0.5)) / 1000
+}
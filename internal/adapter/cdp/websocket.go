@@ -0,0 +1,188 @@
+package cdp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/protocol/network"
+	"github.com/mafredri/cdp/rpcc"
+)
+
+// WebSocketSink 接收 Network 域的 WebSocket 消息帧
+type WebSocketSink interface {
+	// Frame 收发了一个消息帧或帧处理出错。conn 为所属连接的握手请求，订阅前已建立的连接只有 ID；
+	// payload 为解码后的原始载荷
+	Frame(conn *domain.Request, frame domain.WebSocketFrame, payload []byte)
+}
+
+// SubscribeWebSocket 订阅目标的 WebSocket 连接与消息帧事件，需在启用 Network 域前调用。
+// 事件在后台按到达顺序处理，记录连接的 URL 与握手请求头后将消息帧交给 sink，ctx 结束或连接关闭时停止。
+// CDP 只能观察消息帧，无法修改或丢弃
+func SubscribeWebSocket(ctx context.Context, client *cdp.Client, sink WebSocketSink, l logger.Logger) error {
+	var streams []rpcc.Stream
+	closeAll := func() {
+		for _, s := range streams {
+			_ = s.Close()
+		}
+	}
+
+	created, err := client.Network.WebSocketCreated(ctx)
+	if err != nil {
+		return err
+	}
+	streams = append(streams, created)
+	handshake, err := client.Network.WebSocketWillSendHandshakeRequest(ctx)
+	if err != nil {
+		closeAll()
+		return err
+	}
+	streams = append(streams, handshake)
+	sent, err := client.Network.WebSocketFrameSent(ctx)
+	if err != nil {
+		closeAll()
+		return err
+	}
+	streams = append(streams, sent)
+	received, err := client.Network.WebSocketFrameReceived(ctx)
+	if err != nil {
+		closeAll()
+		return err
+	}
+	streams = append(streams, received)
+	frameErr, err := client.Network.WebSocketFrameError(ctx)
+	if err != nil {
+		closeAll()
+		return err
+	}
+	streams = append(streams, frameErr)
+	closed, err := client.Network.WebSocketClosed(ctx)
+	if err != nil {
+		closeAll()
+		return err
+	}
+	streams = append(streams, closed)
+	// 按到达顺序交付，保证握手先于消息帧、消息帧先于关闭处理
+	if err := cdp.Sync(created, handshake, sent, received, frameErr, closed); err != nil {
+		l.Warn("同步 WebSocket 事件流失败，消息帧可能缺少握手信息", "error", err.Error())
+	}
+
+	go func() {
+		defer closeAll()
+		conns := make(map[network.RequestID]*wsConn)
+		conn := func(id network.RequestID) *wsConn {
+			c, ok := conns[id]
+			if !ok {
+				c = newWSConn(id, "")
+				conns[id] = c
+			}
+			return c
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-created.Ready():
+				ev, err := created.Recv()
+				if err != nil {
+					return
+				}
+				conns[ev.RequestID] = newWSConn(ev.RequestID, ev.URL)
+			case <-handshake.Ready():
+				ev, err := handshake.Recv()
+				if err != nil {
+					return
+				}
+				conn(ev.RequestID).setHeaders(ev.Request.Headers)
+			case <-sent.Ready():
+				ev, err := sent.Recv()
+				if err != nil {
+					return
+				}
+				conn(ev.RequestID).frame(sink, domain.WebSocketSent, ev.Response)
+			case <-received.Ready():
+				ev, err := received.Recv()
+				if err != nil {
+					return
+				}
+				conn(ev.RequestID).frame(sink, domain.WebSocketReceived, ev.Response)
+			case <-frameErr.Ready():
+				ev, err := frameErr.Recv()
+				if err != nil {
+					return
+				}
+				c := conn(ev.RequestID)
+				c.frames++
+				sink.Frame(c.req, domain.WebSocketFrame{Direction: domain.WebSocketError, Index: c.frames, Error: ev.ErrorMessage}, nil)
+			case <-closed.Ready():
+				ev, err := closed.Recv()
+				if err != nil {
+					return
+				}
+				delete(conns, ev.RequestID)
+			}
+		}
+	}()
+	return nil
+}
+
+// wsConn 订阅期间跟踪的 WebSocket 连接
+type wsConn struct {
+	req    *domain.Request // 握手请求，收到握手事件后不再修改
+	frames int             // 已交付的帧数
+}
+
+// newWSConn 创建连接的握手请求
+func newWSConn(id network.RequestID, rawURL string) *wsConn {
+	req := domain.NewRequest()
+	req.ID = string(id)
+	req.URL = rawURL
+	req.Method = "GET"
+	req.ResourceType = domain.ResourceTypeWebSocket
+	if u, err := url.Parse(rawURL); err == nil {
+		for k, v := range u.Query() {
+			req.Query[k] = v[0]
+		}
+	}
+	return &wsConn{req: req}
+}
+
+// setHeaders 记录握手请求头与 Cookie；已交付帧的事件共享握手请求，因此替换而不是修改
+func (c *wsConn) setHeaders(raw network.Headers) {
+	req := *c.req
+	req.Headers = make(domain.Header)
+	var m map[string]string
+	if err := json.Unmarshal(raw, &m); err == nil {
+		for k, v := range m {
+			req.Headers.Set(k, v)
+		}
+	}
+	req.Cookies = transformer.ParseCookies(req.Headers.Get("Cookie"))
+	c.req = &req
+}
+
+// frame 为消息帧编号并交给 sink
+func (c *wsConn) frame(sink WebSocketSink, dir domain.WebSocketDirection, f network.WebSocketFrame) {
+	c.frames++
+	frame, payload := ToWebSocketFrame(dir, f)
+	frame.Index = c.frames
+	sink.Frame(c.req, frame, payload)
+}
+
+// ToWebSocketFrame 将 CDP 消息帧转换为领域模型并解码载荷：文本帧为 UTF-8 原文，其他帧为 Base64
+func ToWebSocketFrame(dir domain.WebSocketDirection, f network.WebSocketFrame) (domain.WebSocketFrame, []byte) {
+	opcode := int(f.Opcode)
+	payload := []byte(f.PayloadData)
+	if opcode != 1 {
+		if decoded, err := base64.StdEncoding.DecodeString(f.PayloadData); err == nil {
+			payload = decoded
+		}
+	}
+	return domain.WebSocketFrame{Direction: dir, Opcode: opcode, Size: len(payload)}, payload
+}
@@ -1,9 +1,14 @@
 package auditor
 
 import (
+	"errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"cdpnetool/internal/eventstream"
 	"cdpnetool/internal/logger"
+	"cdpnetool/internal/timing"
 	"cdpnetool/pkg/domain"
 )
 
@@ -12,6 +17,15 @@ type Auditor struct {
 	enabled bool
 	events  chan domain.NetworkEvent
 	log     logger.Logger
+
+	unmatchedEvery atomic.Int64  // 未匹配事件的推送采样间隔，见 SetUnmatchedSampling
+	dryRun         atomic.Bool   // 演练模式，记录的事件标记 DryRun
+	unmatchedSeen  atomic.Uint64 // 已记录的未匹配事件数
+
+	streamsMu sync.Mutex
+	streams   []*eventstream.Stream // 确认式事件流订阅者
+
+	timing *timing.Collector // 网络阶段计时采集器，为 nil 表示不采集
 }
 
 // New 创建一个新的审计员
@@ -48,6 +62,75 @@ func (a *Auditor) IsEnabled() bool {
 	return a.enabled
 }
 
+// SetUnmatchedSampling 设置未匹配事件推送到实时通道的采样：0 或 1 全部推送，n 每 n 个推送 1 个，负数不推送；
+// 匹配事件与确认式事件流（如 HAR 录制）不受影响
+func (a *Auditor) SetUnmatchedSampling(n int) {
+	a.unmatchedEvery.Store(int64(n))
+}
+
+// SetDryRun 设置演练模式，开启后记录的事件标记 DryRun，可在会话运行期间调用
+func (a *Auditor) SetDryRun(dryRun bool) {
+	a.dryRun.Store(dryRun)
+}
+
+// sampled 判断事件是否推送到实时通道
+func (a *Auditor) sampled(evt domain.NetworkEvent) bool {
+	every := a.unmatchedEvery.Load()
+	if evt.IsMatched || every == 0 || every == 1 {
+		return true
+	}
+	if every < 0 {
+		return false
+	}
+	// 每 N 个未匹配事件推送第一个
+	return (a.unmatchedSeen.Add(1)-1)%uint64(every) == 0
+}
+
+// SetTiming 设置网络阶段计时采集器：已登记的请求的响应事件等待加载完成、补充计时后再分发，
+// 需在记录事件前设置
+func (a *Auditor) SetTiming(c *timing.Collector) {
+	a.timing = c
+}
+
+// AddStream 添加一个确认式事件流订阅者
+func (a *Auditor) AddStream(s *eventstream.Stream) {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+	a.streams = append(a.streams, s)
+}
+
+// RemoveStream 关闭并移除一个确认式事件流订阅者
+func (a *Auditor) RemoveStream(s *eventstream.Stream) {
+	a.streamsMu.Lock()
+	for i, st := range a.streams {
+		if st == s {
+			a.streams = append(a.streams[:i], a.streams[i+1:]...)
+			break
+		}
+	}
+	a.streamsMu.Unlock()
+	_ = s.Close()
+}
+
+// StreamCount 返回当前的确认式事件流订阅者数量
+func (a *Auditor) StreamCount() int {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+	return len(a.streams)
+}
+
+// CloseStreams 关闭并移除所有确认式事件流
+func (a *Auditor) CloseStreams() {
+	a.streamsMu.Lock()
+	streams := a.streams
+	a.streams = nil
+	a.streamsMu.Unlock()
+
+	for _, s := range streams {
+		_ = s.Close()
+	}
+}
+
 // Record 记录一个完整的流量事件
 func (a *Auditor) Record(
 	sessionID string,
@@ -56,6 +139,20 @@ func (a *Auditor) Record(
 	res *domain.Response,
 	result string,
 	matchedRules []domain.RuleMatch,
+) {
+	a.RecordModified(sessionID, targetID, req, res, nil, nil, result, matchedRules)
+}
+
+// RecordModified 记录一个流量事件，origReq、origRes 为规则修改前的请求与响应，未被修改的一方为 nil
+func (a *Auditor) RecordModified(
+	sessionID string,
+	targetID string,
+	req *domain.Request,
+	res *domain.Response,
+	origReq *domain.Request,
+	origRes *domain.Response,
+	result string,
+	matchedRules []domain.RuleMatch,
 ) {
 	if !a.enabled || req == nil {
 		if !a.enabled && req != nil {
@@ -76,10 +173,64 @@ func (a *Auditor) Record(
 		MatchedRules: matchedRules,
 		Request:      *req,
 		Response:     res,
+		DryRun:       a.dryRun.Load(),
+
+		OriginalRequest:  origReq,
+		OriginalResponse: origRes,
 	}
 
-	a.dispatch(evt)
-	a.log.Debug("[Auditor] 事件记录完成", "requestID", req.ID)
+	if res != nil && a.timing != nil {
+		// 计时在其他 goroutine 中补充，先复制响应避免与调用方共享对象
+		snapshot := *res
+		deferred := a.timing.Defer(string(req.ID), func(t domain.ResponseTiming) {
+			t.StartTime, t.EndTime = snapshot.Timing.StartTime, snapshot.Timing.EndTime
+			snapshot.Timing = t
+			evt.Response = &snapshot
+			a.emit(evt)
+		})
+		if deferred {
+			a.log.Debug("[Auditor] 事件等待网络计时", "requestID", req.ID)
+			return
+		}
+	}
+	a.emit(evt)
+}
+
+// RecordFrame 记录一个 WebSocket 消息帧事件，id 为帧事件的唯一 ID，req 为所属连接的握手请求且 Body 为帧载荷
+func (a *Auditor) RecordFrame(
+	sessionID string,
+	targetID string,
+	id string,
+	req *domain.Request,
+	frame *domain.WebSocketFrame,
+	result string,
+	matchedRules []domain.RuleMatch,
+) {
+	if !a.enabled || req == nil || frame == nil {
+		return
+	}
+	a.emit(domain.NetworkEvent{
+		ID:           id,
+		Session:      domain.SessionID(sessionID),
+		Target:       domain.TargetID(targetID),
+		Timestamp:    time.Now().UnixMilli(),
+		IsMatched:    len(matchedRules) > 0,
+		FinalResult:  result,
+		MatchedRules: matchedRules,
+		Request:      *req,
+		Frame:        frame,
+	})
+}
+
+// emit 分发并投递事件
+func (a *Auditor) emit(evt domain.NetworkEvent) {
+	if a.sampled(evt) {
+		a.dispatch(evt)
+	} else {
+		a.log.Debug("[Auditor] 未匹配事件未被采样，跳过分发", "requestID", evt.ID)
+	}
+	a.publish(evt)
+	a.log.Debug("[Auditor] 事件记录完成", "requestID", evt.ID)
 }
 
 // dispatch 分发事件到实时观察通道，通道满时丢弃
@@ -97,3 +248,29 @@ func (a *Auditor) dispatch(evt domain.NetworkEvent) {
 		a.log.Warn("[Auditor] 审计事件分发通道已满，丢弃事件", "id", evt.ID)
 	}
 }
+
+// publish 投递事件到确认式事件流，已关闭的流会被移除
+func (a *Auditor) publish(evt domain.NetworkEvent) {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+
+	if len(a.streams) == 0 {
+		return
+	}
+
+	alive := a.streams[:0]
+	for _, s := range a.streams {
+		err := s.Publish(evt)
+		if errors.Is(err, domain.ErrStreamClosed) {
+			continue
+		}
+		if errors.Is(err, domain.ErrStreamFull) {
+			a.log.Warn("[Auditor] 确认式事件流缓冲已满，拒绝事件", "id", evt.ID)
+		}
+		alive = append(alive, s)
+	}
+	for i := len(alive); i < len(a.streams); i++ {
+		a.streams[i] = nil
+	}
+	a.streams = alive
+}
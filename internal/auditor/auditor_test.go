@@ -1,11 +1,15 @@
 package auditor_test
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"cdpnetool/internal/auditor"
+	"cdpnetool/internal/eventstream"
 	"cdpnetool/internal/logger"
+	"cdpnetool/internal/timing"
 	"cdpnetool/pkg/domain"
 )
 
@@ -166,6 +170,66 @@ func TestRecord_NoMatchedRules(t *testing.T) {
 	}
 }
 
+func TestRecord_UnmatchedSampling(t *testing.T) {
+	matched := []domain.RuleMatch{{RuleID: "rule1", RuleName: "rule1"}}
+	tests := []struct {
+		name      string
+		sampling  int
+		unmatched int // 10 个未匹配事件中预期推送的数量
+	}{
+		{"默认全部推送", 0, 10},
+		{"每 1 个推送 1 个", 1, 10},
+		{"每 3 个推送 1 个", 3, 4},
+		{"仅推送匹配事件", -1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := make(chan domain.NetworkEvent, 32)
+			aud := auditor.New(events, logger.NewNop())
+			aud.SetUnmatchedSampling(tt.sampling)
+
+			for i := 0; i < 10; i++ {
+				req := &domain.Request{ID: fmt.Sprintf("req%d", i), URL: "https://example.com", Method: "GET"}
+				aud.Record("session1", "target1", req, nil, "passed", nil)
+			}
+			// 匹配事件始终推送
+			aud.Record("session1", "target1", &domain.Request{ID: "hit", URL: "https://example.com"}, nil, "modified", matched)
+
+			close(events)
+			var gotUnmatched, gotMatched int
+			for evt := range events {
+				if evt.IsMatched {
+					gotMatched++
+				} else {
+					gotUnmatched++
+				}
+			}
+			if gotUnmatched != tt.unmatched || gotMatched != 1 {
+				t.Errorf("got %d unmatched and %d matched events, want %d and 1", gotUnmatched, gotMatched, tt.unmatched)
+			}
+		})
+	}
+}
+
+func TestRecord_UnmatchedSamplingKeepsStreams(t *testing.T) {
+	aud := auditor.New(nil, logger.NewNop())
+	aud.SetUnmatchedSampling(-1)
+	stream := eventstream.New(domain.EventStreamOptions{BufferSize: 4})
+	aud.AddStream(stream)
+
+	aud.Record("session1", "target1", &domain.Request{ID: "req1", URL: "https://example.com"}, nil, "passed", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	d, err := stream.Next(ctx)
+	if err != nil {
+		t.Fatalf("stream should still receive unmatched events: %v", err)
+	}
+	if d.Event.ID != "req1" {
+		t.Errorf("got event %s, want req1", d.Event.ID)
+	}
+}
+
 func TestDispatch_FullChannel(t *testing.T) {
 	events := make(chan domain.NetworkEvent, 1)
 	aud := auditor.New(events, logger.NewNop())
@@ -247,3 +311,97 @@ func TestRecord_MultipleEvents(t *testing.T) {
 		t.Errorf("got %d events, want 3", count)
 	}
 }
+
+func TestRecord_Stream(t *testing.T) {
+	aud := auditor.New(nil, logger.NewNop())
+	stream := eventstream.New(domain.EventStreamOptions{BufferSize: 4})
+	aud.AddStream(stream)
+
+	req := &domain.Request{ID: "req1", URL: "https://example.com", Method: "GET"}
+	aud.Record("session1", "target1", req, nil, "passed", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	d, err := stream.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if d.Event.ID != "req1" {
+		t.Errorf("got event %s, want req1", d.Event.ID)
+	}
+
+	aud.CloseStreams()
+	if !stream.IsClosed() {
+		t.Error("stream should be closed by CloseStreams")
+	}
+}
+
+func TestRemoveStream(t *testing.T) {
+	aud := auditor.New(nil, logger.NewNop())
+	kept := eventstream.New(domain.EventStreamOptions{BufferSize: 4})
+	removed := eventstream.New(domain.EventStreamOptions{BufferSize: 4})
+	aud.AddStream(kept)
+	aud.AddStream(removed)
+
+	aud.RemoveStream(removed)
+	if !removed.IsClosed() || aud.StreamCount() != 1 {
+		t.Fatalf("got closed=%v and %d streams, want the removed stream closed and 1 stream left", removed.IsClosed(), aud.StreamCount())
+	}
+	select {
+	case <-removed.Done():
+	default:
+		t.Error("Done() should be closed after the stream is removed")
+	}
+
+	aud.Record("session1", "target1", &domain.Request{ID: "req1", URL: "https://example.com", Method: "GET"}, nil, "passed", nil)
+	if st := kept.Stats(); st.Pending != 1 {
+		t.Errorf("got %+v, want the remaining stream to receive the event", st)
+	}
+}
+
+func TestRecord_Timing(t *testing.T) {
+	events := make(chan domain.NetworkEvent, 10)
+	aud := auditor.New(events, logger.NewNop())
+	c := timing.New(time.Second)
+	defer c.Close()
+	aud.SetTiming(c)
+	c.Track("req1", "net1")
+
+	req := &domain.Request{ID: "req1", URL: "https://example.com", Method: "GET"}
+	res := &domain.Response{StatusCode: 200, Timing: domain.ResponseTiming{StartTime: 100, EndTime: 150}}
+	aud.Record("session1", "target1", req, res, "passed", nil)
+
+	// 加载完成前事件等待计时
+	select {
+	case <-events:
+		t.Fatal("event dispatched before loading finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	c.Response("net1", domain.ResponseTiming{TTFB: 12.5}, 10, 100)
+	c.Finish("net1", 10.02, 1100)
+
+	select {
+	case evt := <-events:
+		got := evt.Response.Timing
+		if got.StartTime != 100 || got.EndTime != 150 {
+			t.Errorf("got start/end %d/%d, want 100/150", got.StartTime, got.EndTime)
+		}
+		if got.TTFB != 12.5 || got.Transfer != 20 || got.TransferSize != 1100 || got.EncodedBodySize != 1000 {
+			t.Errorf("got timing %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not dispatched after loading finished")
+	}
+	if res.Timing.TTFB != 0 {
+		t.Error("caller response should not be modified")
+	}
+
+	// 未登记的请求直接分发
+	aud.Record("session1", "target1", &domain.Request{ID: "req2"}, res, "passed", nil)
+	select {
+	case <-events:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("untracked request should be dispatched immediately")
+	}
+}
@@ -0,0 +1,100 @@
+// Package augment 在响应阶段读取次级数据源（HTTP 接口或本地文件）的内容，供 augmentJson 动作合并到真实响应
+package augment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxSourceSize 次级数据源内容的大小上限
+const maxSourceSize = 10 << 20
+
+// Fetcher 次级数据源读取器，并发安全
+type Fetcher struct {
+	client *http.Client
+}
+
+// New 创建次级数据源读取器
+func New() *Fetcher {
+	return &Fetcher{
+		client: &http.Client{
+			// 超时由每次调用的 context 控制
+			CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Fetch 读取次级数据源的内容：http(s) URL 以 GET 获取且要求 2xx 状态码，其他视为本地文件路径（可带 file:// 前缀）。
+// timeout 为非正数时不额外限制时长
+func (f *Fetcher) Fetch(ctx context.Context, source string, timeout time.Duration) ([]byte, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, errors.New("empty augment source")
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	lower := strings.ToLower(source)
+	switch {
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		return f.fetchURL(ctx, source)
+	case strings.HasPrefix(lower, "file://"):
+		return readFile(source[len("file://"):])
+	default:
+		return readFile(source)
+	}
+}
+
+// fetchURL 以 GET 请求获取 URL 的响应体
+func (f *Fetcher) fetchURL(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("augment source returned status %d", resp.StatusCode)
+	}
+	return readLimited(resp.Body)
+}
+
+// readFile 读取本地文件内容
+func readFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readLimited(file)
+}
+
+// readLimited 读取内容，超过 maxSourceSize 时返回错误
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSourceSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSourceSize {
+		return nil, fmt.Errorf("augment source exceeds %d bytes", maxSourceSize)
+	}
+	return data, nil
+}
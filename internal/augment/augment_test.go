@@ -0,0 +1,69 @@
+package augment_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/augment"
+)
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"source":"http"}`))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "extra.json")
+	if err := os.WriteFile(path, []byte(`{"source":"file"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := augment.New()
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		source  string
+		timeout time.Duration
+		want    string
+		wantErr string
+	}{
+		{name: "HTTP 数据源", source: srv.URL + "/ok", want: `{"source":"http"}`},
+		{name: "本地文件路径", source: path, want: `{"source":"file"}`},
+		{name: "file URL", source: "file://" + filepath.ToSlash(path), want: `{"source":"file"}`},
+		{name: "非 2xx 状态码", source: srv.URL + "/missing", wantErr: "status 404"},
+		{name: "超时", source: srv.URL + "/slow", timeout: 50 * time.Millisecond, wantErr: "deadline exceeded"},
+		{name: "文件不存在", source: filepath.Join(t.TempDir(), "nope.json"), wantErr: "nope.json"},
+		{name: "空数据源", source: "  ", wantErr: "empty augment source"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.Fetch(ctx, tt.source, tt.timeout)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Package bench 提供吞吐基准测试：将合成的暂停事件送入 规则引擎+处理器 流水线，
+// 统计每秒处理量与延迟分位，用于评估 Concurrency 与队列容量等参数
+package bench
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cdpnetool/internal/auditor"
+	"cdpnetool/internal/engine"
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/pool"
+	"cdpnetool/internal/processor"
+	"cdpnetool/internal/tracker"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+const (
+	// DefaultRequests 默认合成请求总数
+	DefaultRequests = 10000
+	// DefaultWindow 未限制并发时闭环模式下的最大在途请求数
+	DefaultWindow = 256
+	// MaxRequests 单次基准测试允许的最大请求数
+	MaxRequests = 1000000
+)
+
+// Run 执行一次基准测试，ctx 取消时提前结束并返回已完成部分的统计
+func Run(ctx context.Context, cfg *rulespec.Config, opts domain.BenchmarkOptions, l logger.Logger) (domain.BenchmarkResult, error) {
+	if l == nil {
+		l = logger.NewNop()
+	}
+	if cfg == nil {
+		return domain.BenchmarkResult{}, domain.ErrInvalidConfig
+	}
+	if opts.Requests <= 0 {
+		opts.Requests = DefaultRequests
+	}
+	if opts.Requests > MaxRequests {
+		return domain.BenchmarkResult{}, fmt.Errorf("%w: requests must not exceed %d", domain.ErrInvalidConfig, MaxRequests)
+	}
+	urls := opts.URLs
+	if len(urls) == 0 {
+		urls = SampleURLs(cfg)
+	}
+	body := []byte(strings.Repeat("x", opts.BodySize))
+
+	// 工作池独立于调用方 ctx，保证已入队的任务在取消后仍能执行完毕
+	poolCtx, stopPool := context.WithCancel(context.Background())
+	defer stopPool()
+
+	// 与会话相同的组件装配，审计事件被直接丢弃
+	events := make(chan domain.NetworkEvent, 1024)
+	go drain(poolCtx, events)
+	trk := tracker.New(time.Minute, l)
+	defer trk.Stop()
+	eng := engine.New(cfg)
+	proc := processor.New(trk, eng, auditor.New(events, l), auditor.NewDisabled(nil, l), l)
+
+	workPool := pool.New(opts.Concurrency, opts.PendingCapacity)
+	workPool.Start(poolCtx)
+	defer workPool.Stop()
+
+	// 闭环模式通过在途窗口施加背压：在途数不超过队列容量时队列不会溢出
+	window := DefaultWindow
+	if workPool.IsEnabled() {
+		window = workPool.GetQueueCap()
+	}
+	inflight := make(chan struct{}, window)
+
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Second / time.Duration(opts.Rate)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		latencies = make([]time.Duration, 0, opts.Requests)
+		dropped   int64
+	)
+
+	start := time.Now()
+	next := start
+loop:
+	for i := 0; i < opts.Requests; i++ {
+		if interval > 0 {
+			next = next.Add(interval)
+			if d := time.Until(next); d > 0 {
+				select {
+				case <-ctx.Done():
+					break loop
+				case <-time.After(d):
+				}
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				break loop
+			case inflight <- struct{}{}:
+			}
+		}
+
+		req := syntheticRequest(i, urls[i%len(urls)])
+		submitted := time.Now()
+		wg.Add(1)
+		ok := workPool.Submit(func() {
+			defer wg.Done()
+			if interval == 0 {
+				defer func() { <-inflight }()
+			}
+			process(ctx, proc, req, body)
+			d := time.Since(submitted)
+			mu.Lock()
+			latencies = append(latencies, d)
+			mu.Unlock()
+		})
+		if !ok {
+			// 与拦截器一致：队列已满时直接降级放行
+			wg.Done()
+			if interval == 0 {
+				<-inflight
+			}
+			dropped++
+		}
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	_, matched, _ := eng.GetStats()
+	res := summarize(latencies, elapsed)
+	res.Dropped = dropped
+	res.Matched = matched
+
+	l.Info("基准测试完成", "requests", res.Requests, "dropped", res.Dropped, "rps", res.RPS, "p99MS", res.P99MS)
+	return res, ctx.Err()
+}
+
+// process 依次执行请求与响应阶段，模拟一次完整的拦截
+func process(ctx context.Context, proc *processor.Processor, req *domain.Request, body []byte) {
+	res := proc.ProcessRequest(ctx, "bench", "bench", req)
+	if res.Action == processor.ActionBlock {
+		return
+	}
+
+	resp := domain.NewResponse()
+	resp.Headers.Set("Content-Type", "application/json")
+	resp.Body = body
+	proc.ProcessResponse(ctx, "bench", "bench", req.ID, resp)
+}
+
+// syntheticRequest 构造第 i 个合成请求
+func syntheticRequest(i int, url string) *domain.Request {
+	req := domain.NewRequest()
+	req.ID = fmt.Sprintf("bench-%d", i)
+	req.URL = url
+	req.Method = "GET"
+	req.ResourceType = "xhr"
+	req.Headers.Set("Accept", "application/json")
+	req.Headers.Set("User-Agent", "cdpnetool-bench")
+	return req
+}
+
+// SampleURLs 根据规则的 URL 条件生成能命中规则的样本 URL，并附带一个不命中的 URL
+func SampleURLs(cfg *rulespec.Config) []string {
+	const base = "https://bench.local"
+	urls := []string{base + "/static/app.js"}
+	seen := map[string]bool{urls[0]: true}
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	for _, rule := range cfg.Rules {
+		for _, c := range append(append([]rulespec.Condition{}, rule.Match.AllOf...), rule.Match.AnyOf...) {
+			switch c.Type {
+			case rulespec.ConditionURLEquals, rulespec.ConditionURLPrefix:
+				add(c.Value)
+			case rulespec.ConditionURLContains:
+				add(base + "/" + strings.TrimPrefix(c.Value, "/"))
+			case rulespec.ConditionURLSuffix:
+				add(base + "/path" + c.Value)
+			case rulespec.ConditionHost:
+				add("https://" + c.Value + "/")
+			}
+		}
+	}
+	return urls
+}
+
+// summarize 计算吞吐与延迟分位
+func summarize(latencies []time.Duration, elapsed time.Duration) domain.BenchmarkResult {
+	res := domain.BenchmarkResult{
+		Requests:   int64(len(latencies)),
+		DurationMS: elapsed.Milliseconds(),
+	}
+	if len(latencies) == 0 {
+		return res
+	}
+	if elapsed > 0 {
+		res.RPS = float64(len(latencies)) / elapsed.Seconds()
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	res.P50MS = ms(percentile(latencies, 0.50))
+	res.P99MS = ms(percentile(latencies, 0.99))
+	res.MaxMS = ms(latencies[len(latencies)-1])
+	return res
+}
+
+// percentile 返回已排序样本的 p 分位值
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(float64(len(sorted))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// ms 将时长转换为毫秒浮点数
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// drain 丢弃审计事件直到 ctx 结束
+func drain(ctx context.Context, ch <-chan domain.NetworkEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+		}
+	}
+}
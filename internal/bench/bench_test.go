@@ -0,0 +1,104 @@
+package bench_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cdpnetool/internal/bench"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+func benchConfig() *rulespec.Config {
+	cfg := rulespec.NewConfig("bench")
+	cfg.Rules = []rulespec.Rule{
+		{
+			ID: "block", Name: "block", Enabled: true, Stage: rulespec.StageRequest,
+			Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/track"}}},
+			Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 204}},
+		},
+		{
+			ID: "body", Name: "body", Enabled: true, Stage: rulespec.StageResponse,
+			Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLPrefix, Value: "https://example.com/api"}}},
+			Actions: []rulespec.Action{{Type: rulespec.ActionSetBody, Value: "{}"}},
+		},
+	}
+	return cfg
+}
+
+func TestSampleURLs(t *testing.T) {
+	urls := bench.SampleURLs(benchConfig())
+	want := map[string]bool{
+		"https://bench.local/static/app.js": true,
+		"https://bench.local/track":         true,
+		"https://example.com/api":           true,
+	}
+	if len(urls) != len(want) {
+		t.Fatalf("got %v, want %d urls", urls, len(want))
+	}
+	for _, u := range urls {
+		if !want[u] {
+			t.Errorf("unexpected url %q", u)
+		}
+	}
+}
+
+func TestRun_ClosedLoop(t *testing.T) {
+	res, err := bench.Run(context.Background(), benchConfig(), domain.BenchmarkOptions{
+		Requests:        600,
+		Concurrency:     4,
+		PendingCapacity: 8,
+		BodySize:        256,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if res.Requests != 600 || res.Dropped != 0 {
+		t.Errorf("got requests=%d dropped=%d, want 600 and 0", res.Requests, res.Dropped)
+	}
+	// 样本 URL 由规则条件生成，应有请求命中规则
+	if res.Matched == 0 {
+		t.Error("expected matched requests")
+	}
+	if res.RPS <= 0 || res.P99MS < res.P50MS || res.MaxMS < res.P99MS {
+		t.Errorf("inconsistent result: %+v", res)
+	}
+}
+
+func TestRun_Rate(t *testing.T) {
+	res, err := bench.Run(context.Background(), benchConfig(), domain.BenchmarkOptions{
+		Requests: 50,
+		Rate:     1000,
+		URLs:     []string{"https://example.com/api/a"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if res.Requests != 50 {
+		t.Errorf("got %d requests, want 50", res.Requests)
+	}
+	if res.DurationMS < 40 {
+		t.Errorf("rate limit not applied, took %dms", res.DurationMS)
+	}
+}
+
+func TestRun_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := bench.Run(ctx, benchConfig(), domain.BenchmarkOptions{Requests: 100}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestRun_InvalidOptions(t *testing.T) {
+	if _, err := bench.Run(context.Background(), nil, domain.BenchmarkOptions{}, nil); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("nil config: got %v, want ErrInvalidConfig", err)
+	}
+	_, err := bench.Run(context.Background(), benchConfig(), domain.BenchmarkOptions{Requests: bench.MaxRequests + 1}, nil)
+	if !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("too many requests: got %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,96 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cdpnetool/internal/engine"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+const (
+	// DefaultIterations 规则耗时分析的默认评估轮数
+	DefaultIterations = 100
+	// MaxEvaluations 单次规则耗时分析允许的最大评估次数（规则数 × 请求数 × 轮数）
+	MaxEvaluations = 10000000
+)
+
+// ProfileRules 逐条隔离评估已启用的规则，统计每条规则的匹配耗时，用于定位拖慢评估的规则；
+// 请求上下文优先使用 opts.Requests，为空时根据规则条件合成
+func ProfileRules(ctx context.Context, cfg *rulespec.Config, opts domain.RuleBenchmarkOptions) (domain.RuleBenchmarkResult, error) {
+	if cfg == nil {
+		return domain.RuleBenchmarkResult{}, domain.ErrInvalidConfig
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultIterations
+	}
+
+	reqs := make([]*domain.Request, 0, len(opts.Requests))
+	for i := range opts.Requests {
+		reqs = append(reqs, &opts.Requests[i])
+	}
+	if len(reqs) == 0 {
+		for i, u := range SampleURLs(cfg) {
+			reqs = append(reqs, syntheticRequest(i, u))
+		}
+	}
+
+	var rules []rulespec.Rule
+	for _, rule := range cfg.Rules {
+		if rule.Enabled {
+			rules = append(rules, rule)
+		}
+	}
+	if total := int64(len(rules)) * int64(len(reqs)) * int64(opts.Iterations); total > MaxEvaluations {
+		return domain.RuleBenchmarkResult{}, fmt.Errorf("%w: evaluations must not exceed %d", domain.ErrInvalidConfig, MaxEvaluations)
+	}
+
+	res := domain.RuleBenchmarkResult{
+		Contexts:   len(reqs),
+		Iterations: opts.Iterations,
+		Rules:      make([]domain.RuleCost, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		res.Rules = append(res.Rules, profileRule(rule, reqs, opts.Iterations))
+	}
+
+	sort.SliceStable(res.Rules, func(i, j int) bool {
+		return res.Rules[i].AvgNS > res.Rules[j].AvgNS
+	})
+	return res, nil
+}
+
+// profileRule 以仅包含该规则的引擎评估全部请求，规则之间互不影响
+func profileRule(rule rulespec.Rule, reqs []*domain.Request, iterations int) domain.RuleCost {
+	single := rulespec.NewConfig("profile")
+	single.Rules = []rulespec.Rule{rule}
+	eng := engine.New(single)
+
+	cost := domain.RuleCost{
+		RuleID: domain.RuleID(rule.ID),
+		Name:   rule.Name,
+		Stage:  string(rule.Stage),
+	}
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		for _, req := range reqs {
+			if len(eng.Eval(req, rule.Stage)) > 0 {
+				cost.Matched++
+			}
+		}
+	}
+	elapsed := time.Since(start)
+
+	cost.Evaluations = int64(iterations) * int64(len(reqs))
+	cost.TotalMS = ms(elapsed)
+	if cost.Evaluations > 0 {
+		cost.AvgNS = float64(elapsed.Nanoseconds()) / float64(cost.Evaluations)
+	}
+	return cost
+}
@@ -0,0 +1,90 @@
+package bench_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cdpnetool/internal/bench"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+func TestProfileRules_Synthetic(t *testing.T) {
+	cfg := benchConfig()
+	cfg.Rules = append(cfg.Rules, rulespec.Rule{
+		ID: "off", Name: "off", Enabled: false, Stage: rulespec.StageRequest,
+		Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/track"}}},
+	})
+
+	res, err := bench.ProfileRules(context.Background(), cfg, domain.RuleBenchmarkOptions{Iterations: 5})
+	if err != nil {
+		t.Fatalf("ProfileRules error: %v", err)
+	}
+	if res.Contexts != 3 || res.Iterations != 5 {
+		t.Fatalf("got contexts=%d iterations=%d, want 3 and 5", res.Contexts, res.Iterations)
+	}
+	// 未启用的规则不参与分析
+	if len(res.Rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(res.Rules))
+	}
+	for _, c := range res.Rules {
+		if c.Evaluations != 15 {
+			t.Errorf("%s: got %d evaluations, want 15", c.RuleID, c.Evaluations)
+		}
+		// 每条规则在合成请求中恰好命中一个 URL
+		if c.Matched != 5 {
+			t.Errorf("%s: got %d matches, want 5", c.RuleID, c.Matched)
+		}
+	}
+	for i := 1; i < len(res.Rules); i++ {
+		if res.Rules[i].AvgNS > res.Rules[i-1].AvgNS {
+			t.Errorf("rules not sorted by cost: %+v", res.Rules)
+		}
+	}
+}
+
+func TestProfileRules_RecordedRequests(t *testing.T) {
+	reqs := []domain.Request{
+		{URL: "https://example.com/api/user", Method: "GET"},
+		{URL: "https://example.com/track?id=1", Method: "POST"},
+		{URL: "https://other.com/", Method: "GET"},
+		{URL: "https://example.com/api/order", Method: "GET"},
+	}
+	res, err := bench.ProfileRules(context.Background(), benchConfig(), domain.RuleBenchmarkOptions{Iterations: 1, Requests: reqs})
+	if err != nil {
+		t.Fatalf("ProfileRules error: %v", err)
+	}
+	if res.Contexts != 4 || res.Iterations != 1 {
+		t.Fatalf("got contexts=%d iterations=%d, want 4 and 1", res.Contexts, res.Iterations)
+	}
+	matched := map[domain.RuleID]int64{}
+	for _, c := range res.Rules {
+		matched[c.RuleID] = c.Matched
+	}
+	if matched["block"] != 1 || matched["body"] != 2 {
+		t.Errorf("got matches %v, want block=1 body=2", matched)
+	}
+}
+
+func TestProfileRules_InvalidOptions(t *testing.T) {
+	if _, err := bench.ProfileRules(context.Background(), nil, domain.RuleBenchmarkOptions{}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("nil config: got %v, want ErrInvalidConfig", err)
+	}
+	_, err := bench.ProfileRules(context.Background(), benchConfig(), domain.RuleBenchmarkOptions{Iterations: bench.MaxEvaluations})
+	if !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("too many evaluations: got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestProfileRules_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := bench.ProfileRules(ctx, benchConfig(), domain.RuleBenchmarkOptions{Iterations: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if len(res.Rules) != 0 {
+		t.Errorf("got %d rules after cancel, want 0", len(res.Rules))
+	}
+}
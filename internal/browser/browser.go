@@ -10,27 +10,78 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"cdpnetool/internal/logger"
+	"cdpnetool/pkg/domain"
 )
 
+// Engine 浏览器种类，决定自动查找的安装路径与默认的用户数据目录
+type Engine string
+
+const (
+	EngineAuto     Engine = ""         // 按 Chrome、Edge、Brave、Chromium 的顺序查找已安装的浏览器
+	EngineChrome   Engine = "chrome"   // Google Chrome
+	EngineEdge     Engine = "edge"     // Microsoft Edge
+	EngineBrave    Engine = "brave"    // Brave
+	EngineChromium Engine = "chromium" // Chromium
+)
+
+// autoEngines 自动查找时依次尝试的浏览器
+var autoEngines = []Engine{EngineChrome, EngineEdge, EngineBrave, EngineChromium}
+
+// ParseEngine 解析浏览器种类名称，auto 与空字符串表示自动查找
+func ParseEngine(name string) (Engine, error) {
+	switch e := Engine(strings.ToLower(strings.TrimSpace(name))); e {
+	case "auto":
+		return EngineAuto, nil
+	case EngineAuto, EngineChrome, EngineEdge, EngineBrave, EngineChromium:
+		return e, nil
+	}
+	return EngineAuto, fmt.Errorf("unknown browser engine %q", name)
+}
+
+// DetectEngine 按可执行文件名识别浏览器种类，无法识别时返回 EngineAuto
+func DetectEngine(path string) Engine {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.Contains(name, "edge"):
+		return EngineEdge
+	case strings.Contains(name, "brave"):
+		return EngineBrave
+	case strings.Contains(name, "chromium"):
+		return EngineChromium
+	case strings.Contains(name, "chrome"):
+		// Windows 上 Chromium 的可执行文件同样名为 chrome.exe
+		if strings.Contains(strings.ToLower(path), "chromium") {
+			return EngineChromium
+		}
+		return EngineChrome
+	}
+	return EngineAuto
+}
+
 // Options 浏览器启动选项
 type Options struct {
-	ExecPath            string        // 浏览器可执行文件路径
-	UserDataDir         string        // 用户数据目录
-	RemoteDebuggingPort int           // CDP端口，0表示自动选择
-	Headless            bool          // 是否以无头模式启动
-	Args                []string      // 额外启动参数
-	Env                 []string      // 额外环境变量
-	ClearUserData       bool          // 启动前是否清空用户数据目录
-	Logger              logger.Logger // 日志记录器
+	Engine              Engine               // 浏览器种类，ExecPath 为空时只查找该种类的安装路径，为空时自动查找
+	ExecPath            string               // 浏览器可执行文件路径
+	UserDataDir         string               // 用户数据目录
+	RemoteDebuggingPort int                  // CDP端口，0表示自动选择
+	Headless            bool                 // 是否以无头模式启动
+	Args                []string             // 额外启动参数
+	Env                 []string             // 额外环境变量
+	ClearUserData       bool                 // 启动前是否清空用户数据目录
+	HostMappings        []domain.HostMapping // 通过 --host-resolver-rules 生效的主机映射
+	Proxy               *domain.ProxyConfig  // 上游代理，认证凭据由会话通过 Fetch.authRequired 提供
+	Logger              logger.Logger        // 日志记录器
 }
 
 // Browser 已启动的浏览器进程句柄
 type Browser struct {
 	cmd         *exec.Cmd
 	DevToolsURL string
+	Engine      Engine // 实际启动的浏览器种类，自定义路径无法识别时为空
 	port        int
 	logger      logger.Logger
 }
@@ -42,15 +93,24 @@ func Start(ctx context.Context, opts Options) (*Browser, error) {
 		l = logger.NewNop()
 	}
 
-	exe := opts.ExecPath
+	exe, engine := opts.ExecPath, opts.Engine
 	if exe == "" {
-		exe = findExecutable()
+		exe, engine = findExecutable(engine)
+	} else if engine == EngineAuto {
+		engine = DetectEngine(exe)
+	}
+	// Firefox 未实现拦截所需的 CDP Fetch 域，不支持启动
+	if strings.Contains(strings.ToLower(filepath.Base(exe)), "firefox") {
+		return nil, fmt.Errorf("%w: firefox does not implement the CDP Fetch domain required for interception, use a Chromium-based browser (chrome/edge/brave/chromium)", domain.ErrBrowserStartFailed)
 	}
 	if exe == "" {
-		return nil, errors.New("browser executable not found (chrome/edge/chromium)")
+		if opts.Engine != EngineAuto {
+			return nil, fmt.Errorf("%w: %s executable not found", domain.ErrBrowserStartFailed, opts.Engine)
+		}
+		return nil, fmt.Errorf("%w: browser executable not found (chrome/edge/brave/chromium)", domain.ErrBrowserStartFailed)
 	}
 
-	l.Info("准备启动浏览器", "path", exe)
+	l.Info("准备启动浏览器", "path", exe, "engine", string(engine))
 
 	port := opts.RemoteDebuggingPort
 	if port == 0 {
@@ -64,7 +124,12 @@ func Start(ctx context.Context, opts Options) (*Browser, error) {
 	l.Debug("选用调试端口", "port", finalPort)
 
 	if opts.UserDataDir == "" {
-		opts.UserDataDir = filepath.Join(os.TempDir(), "cdpnetool-chrome-profile")
+		// 不同浏览器的用户数据互不兼容，各用一个目录
+		profile := engine
+		if profile == EngineAuto {
+			profile = EngineChrome
+		}
+		opts.UserDataDir = filepath.Join(os.TempDir(), "cdpnetool-"+string(profile)+"-profile")
 	}
 
 	if opts.ClearUserData {
@@ -91,12 +156,13 @@ func Start(ctx context.Context, opts Options) (*Browser, error) {
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start browser: %w", err)
+		return nil, fmt.Errorf("%w: %w", domain.ErrBrowserStartFailed, err)
 	}
 
 	b := &Browser{
 		cmd:         cmd,
 		DevToolsURL: fmt.Sprintf("http://127.0.0.1:%d", port),
+		Engine:      engine,
 		port:        port,
 		logger:      l,
 	}
@@ -108,7 +174,7 @@ func Start(ctx context.Context, opts Options) (*Browser, error) {
 		if stopErr := b.Stop(2 * time.Second); stopErr != nil {
 			l.Warn("启动失败后关闭浏览器出错", "error", stopErr)
 		}
-		return nil, fmt.Errorf("devtools not ready: %w", err)
+		return nil, fmt.Errorf("%w: devtools not ready: %w", domain.ErrBrowserStartFailed, err)
 	}
 
 	l.Info("浏览器启动成功", "url", b.DevToolsURL)
@@ -134,54 +200,105 @@ func (b *Browser) Stop(timeout time.Duration) error {
 	}
 }
 
-// findExecutable 查找可用的浏览器执行路径（Chrome/Edge/Chromium）
-func findExecutable() string {
-	candidates := getBrowserPaths()
-	for _, p := range candidates {
-		if _, err := os.Stat(p); err == nil {
-			return p
+// findExecutable 查找可用的浏览器执行路径，engine 为空时按 autoEngines 的顺序查找，返回找到的路径与浏览器种类
+func findExecutable(engine Engine) (string, Engine) {
+	engines := autoEngines
+	if engine != EngineAuto {
+		engines = []Engine{engine}
+	}
+	for _, e := range engines {
+		for _, p := range getBrowserPaths(e) {
+			if _, err := os.Stat(p); err == nil {
+				return p, e
+			}
+		}
+		// macOS 与 Linux 上再从 PATH 中查找
+		for _, name := range commandNames(e) {
+			if p, err := exec.LookPath(name); err == nil {
+				return p, e
+			}
 		}
 	}
-	return ""
+	return "", engine
 }
 
-// getBrowserPaths 返回各平台下 Chrome、Edge 和 Chromium 的默认安装路径
-func getBrowserPaths() []string {
+// getBrowserPaths 返回各平台下指定浏览器的默认安装路径
+func getBrowserPaths(engine Engine) []string {
+	home, _ := os.UserHomeDir()
 	switch runtime.GOOS {
 	case "windows":
-		return []string{
-			// Chrome
-			filepath.Join(os.Getenv("ProgramFiles"), "Google", "Chrome", "Application", "chrome.exe"),
-			filepath.Join(os.Getenv("ProgramFiles(x86)"), "Google", "Chrome", "Application", "chrome.exe"),
-			filepath.Join(os.Getenv("LOCALAPPDATA"), "Google", "Chrome", "Application", "chrome.exe"),
-			// Edge
-			filepath.Join(os.Getenv("ProgramFiles(x86)"), "Microsoft", "Edge", "Application", "msedge.exe"),
-			filepath.Join(os.Getenv("ProgramFiles"), "Microsoft", "Edge", "Application", "msedge.exe"),
-			// Chromium
-			filepath.Join(os.Getenv("ProgramFiles"), "Chromium", "Application", "chrome.exe"),
-			filepath.Join(os.Getenv("ProgramFiles(x86)"), "Chromium", "Application", "chrome.exe"),
-			filepath.Join(os.Getenv("LOCALAPPDATA"), "Chromium", "Application", "chrome.exe"),
+		programFiles, programFilesX86, localAppData := os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)"), os.Getenv("LOCALAPPDATA")
+		switch engine {
+		case EngineChrome:
+			return []string{
+				filepath.Join(programFiles, "Google", "Chrome", "Application", "chrome.exe"),
+				filepath.Join(programFilesX86, "Google", "Chrome", "Application", "chrome.exe"),
+				filepath.Join(localAppData, "Google", "Chrome", "Application", "chrome.exe"),
+			}
+		case EngineEdge:
+			return []string{
+				filepath.Join(programFilesX86, "Microsoft", "Edge", "Application", "msedge.exe"),
+				filepath.Join(programFiles, "Microsoft", "Edge", "Application", "msedge.exe"),
+			}
+		case EngineBrave:
+			return []string{
+				filepath.Join(programFiles, "BraveSoftware", "Brave-Browser", "Application", "brave.exe"),
+				filepath.Join(programFilesX86, "BraveSoftware", "Brave-Browser", "Application", "brave.exe"),
+				filepath.Join(localAppData, "BraveSoftware", "Brave-Browser", "Application", "brave.exe"),
+			}
+		case EngineChromium:
+			return []string{
+				filepath.Join(programFiles, "Chromium", "Application", "chrome.exe"),
+				filepath.Join(programFilesX86, "Chromium", "Application", "chrome.exe"),
+				filepath.Join(localAppData, "Chromium", "Application", "chrome.exe"),
+			}
 		}
 	case "darwin":
-		return []string{
-			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
-			filepath.Join(os.Getenv("HOME"), "Applications", "Google Chrome.app", "Contents", "MacOS", "Google Chrome"),
-			"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
-			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		var app string
+		switch engine {
+		case EngineChrome:
+			app = "Google Chrome.app/Contents/MacOS/Google Chrome"
+		case EngineEdge:
+			app = "Microsoft Edge.app/Contents/MacOS/Microsoft Edge"
+		case EngineBrave:
+			app = "Brave Browser.app/Contents/MacOS/Brave Browser"
+		case EngineChromium:
+			app = "Chromium.app/Contents/MacOS/Chromium"
+		default:
+			return nil
 		}
+		return []string{filepath.Join("/Applications", app), filepath.Join(home, "Applications", app)}
 	case "linux":
-		return []string{
-			"/usr/bin/google-chrome",
-			"/usr/bin/google-chrome-stable",
-			"/usr/bin/microsoft-edge",
-			"/usr/bin/microsoft-edge-stable",
-			"/usr/bin/chromium",
-			"/usr/bin/chromium-browser",
-			"/snap/bin/chromium",
+		switch engine {
+		case EngineChrome:
+			return []string{"/usr/bin/google-chrome", "/usr/bin/google-chrome-stable", "/opt/google/chrome/chrome"}
+		case EngineEdge:
+			return []string{"/usr/bin/microsoft-edge", "/usr/bin/microsoft-edge-stable", "/opt/microsoft/msedge/msedge"}
+		case EngineBrave:
+			return []string{"/usr/bin/brave-browser", "/usr/bin/brave", "/opt/brave.com/brave/brave", "/snap/bin/brave"}
+		case EngineChromium:
+			return []string{"/usr/bin/chromium", "/usr/bin/chromium-browser", "/snap/bin/chromium"}
 		}
-	default:
+	}
+	return nil
+}
+
+// commandNames 返回指定浏览器在 PATH 中的命令名，Windows 上不从 PATH 查找
+func commandNames(engine Engine) []string {
+	if runtime.GOOS == "windows" {
 		return nil
 	}
+	switch engine {
+	case EngineChrome:
+		return []string{"google-chrome", "google-chrome-stable"}
+	case EngineEdge:
+		return []string{"microsoft-edge", "microsoft-edge-stable"}
+	case EngineBrave:
+		return []string{"brave-browser", "brave"}
+	case EngineChromium:
+		return []string{"chromium", "chromium-browser"}
+	}
+	return nil
 }
 
 // pickPort 尝试使用指定端口，如果被占用则选择随机空闲端口
@@ -235,6 +352,19 @@ func buildLaunchArgs(port int, opts Options) []string {
 		args = append(args, "--headless=new", "--disable-gpu")
 	}
 
+	// 主机映射
+	if len(opts.HostMappings) > 0 {
+		args = append(args, "--host-resolver-rules="+domain.ResolverRules(opts.HostMappings))
+	}
+
+	// 上游代理
+	if opts.Proxy != nil && opts.Proxy.Server != "" {
+		args = append(args, "--proxy-server="+opts.Proxy.Server)
+		if len(opts.Proxy.Bypass) > 0 {
+			args = append(args, "--proxy-bypass-list="+strings.Join(opts.Proxy.Bypass, ";"))
+		}
+	}
+
 	// 额外参数
 	if len(opts.Args) > 0 {
 		args = append(args, opts.Args...)
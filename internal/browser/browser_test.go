@@ -0,0 +1,51 @@
+package browser_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cdpnetool/internal/browser"
+	"cdpnetool/pkg/domain"
+)
+
+func TestParseEngine(t *testing.T) {
+	for name, want := range map[string]browser.Engine{
+		"":        browser.EngineAuto,
+		"auto":    browser.EngineAuto,
+		"Chrome":  browser.EngineChrome,
+		"edge":    browser.EngineEdge,
+		" brave ": browser.EngineBrave,
+	} {
+		if got, err := browser.ParseEngine(name); err != nil || got != want {
+			t.Errorf("ParseEngine(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"safari", "firefox"} {
+		if _, err := browser.ParseEngine(name); err == nil {
+			t.Errorf("ParseEngine(%s) succeeded, want error", name)
+		}
+	}
+}
+
+func TestDetectEngine(t *testing.T) {
+	for path, want := range map[string]browser.Engine{
+		`C:\Program Files\Google\Chrome\Application\chrome.exe`:        browser.EngineChrome,
+		`C:\Program Files\Chromium\Application\chrome.exe`:             browser.EngineChromium,
+		`C:\Program Files (x86)\Microsoft\Edge\Application\msedge.exe`: browser.EngineEdge,
+		"/Applications/Brave Browser.app/Contents/MacOS/Brave Browser": browser.EngineBrave,
+		"/usr/bin/chromium-browser":                                    browser.EngineChromium,
+		"/opt/custom/headless_shell":                                   browser.EngineAuto,
+	} {
+		if got := browser.DetectEngine(path); got != want {
+			t.Errorf("DetectEngine(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestStart_Firefox(t *testing.T) {
+	_, err := browser.Start(context.Background(), browser.Options{ExecPath: "/usr/bin/firefox"})
+	if !errors.Is(err, domain.ErrBrowserStartFailed) {
+		t.Errorf("Start(firefox) error = %v, want ErrBrowserStartFailed", err)
+	}
+}
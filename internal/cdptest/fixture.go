@@ -0,0 +1,170 @@
+package cdptest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+)
+
+// terminalMethods 结束一次暂停的 Fetch 方法，出现即表示该事件处理完成
+var terminalMethods = map[string]bool{
+	"Fetch.continueRequest":  true,
+	"Fetch.continueResponse": true,
+	"Fetch.fulfillRequest":   true,
+	"Fetch.failRequest":      true,
+}
+
+// Fixture 回放夹具：一份规则配置与按顺序推送的暂停事件及其期望输出
+type Fixture struct {
+	Name   string          `json:"name"`
+	Config json.RawMessage `json:"config"`
+	Steps  []Step          `json:"steps"`
+}
+
+// Step 单个暂停事件及其触发的 CDP 调用（即执行结果）
+type Step struct {
+	Paused *fetch.RequestPausedReply `json:"paused"`
+	Expect []RecordedCall            `json:"expect"`
+}
+
+// RecordedCall 录制的一次 CDP 调用
+type RecordedCall struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// LoadFixture 读取夹具文件
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fx Fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return nil, fmt.Errorf("cdptest: parse fixture %s: %w", path, err)
+	}
+	if fx.Name == "" {
+		fx.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return &fx, nil
+}
+
+// Save 以缩进格式写回夹具文件
+func (fx *Fixture) Save(path string) error {
+	data, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Replay 依次向目标推送夹具中的暂停事件，返回每个事件触发的调用
+//
+// 每个事件都会等待对应 requestId 的终结调用（continue/fulfill/fail）出现后再推送下一个，
+// 因此返回结果与 Steps 一一对应，可直接写回 Expect（录制）或与 Expect 比对（回放）。
+func (s *Server) Replay(ctx context.Context, targetID string, fx *Fixture, stepTimeout time.Duration) ([][]RecordedCall, error) {
+	out := make([][]RecordedCall, 0, len(fx.Steps))
+	for i, step := range fx.Steps {
+		if step.Paused == nil {
+			return out, fmt.Errorf("cdptest: step %d has no paused event", i+1)
+		}
+		start := len(s.Calls())
+		if err := s.Pause(targetID, step.Paused); err != nil {
+			return out, err
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+		calls, err := s.waitTerminal(stepCtx, start, string(step.Paused.RequestID))
+		cancel()
+		if err != nil {
+			return out, fmt.Errorf("cdptest: step %d (%s): %w", i+1, step.Paused.RequestID, err)
+		}
+		out = append(out, calls)
+	}
+	return out, nil
+}
+
+// waitTerminal 等待 start 之后出现指定请求的终结调用，返回期间该请求相关的全部调用
+func (s *Server) waitTerminal(ctx context.Context, start int, requestID string) ([]RecordedCall, error) {
+	for {
+		s.mu.Lock()
+		var calls []RecordedCall
+		done := false
+		for _, c := range s.calls[start:] {
+			if callRequestID(c.Params) != requestID {
+				continue
+			}
+			calls = append(calls, RecordedCall{Method: c.Method, Params: c.Params})
+			if terminalMethods[c.Method] {
+				done = true
+				break
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		if done {
+			return calls, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// callRequestID 提取调用参数中的 requestId
+func callRequestID(params json.RawMessage) string {
+	var p struct {
+		RequestID string `json:"requestId"`
+	}
+	_ = json.Unmarshal(params, &p)
+	return p.RequestID
+}
+
+// Diff 比对实际调用与期望调用，一致时返回空字符串
+func Diff(want, got []RecordedCall) string {
+	if len(want) != len(got) {
+		return fmt.Sprintf("got %d calls %s, want %d calls %s", len(got), methods(got), len(want), methods(want))
+	}
+	for i := range want {
+		if want[i].Method != got[i].Method {
+			return fmt.Sprintf("call %d: got method %s, want %s", i+1, got[i].Method, want[i].Method)
+		}
+		w, g := normalize(want[i].Params), normalize(got[i].Params)
+		if !bytes.Equal(w, g) {
+			return fmt.Sprintf("call %d (%s): got params %s, want %s", i+1, got[i].Method, g, w)
+		}
+	}
+	return ""
+}
+
+// methods 列出调用的方法名
+func methods(calls []RecordedCall) []string {
+	names := make([]string, len(calls))
+	for i, c := range calls {
+		names[i] = c.Method
+	}
+	return names
+}
+
+// normalize 将 JSON 规整为紧凑且键有序的形式，便于比对
+func normalize(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return raw
+	}
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -0,0 +1,71 @@
+package cdptest_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"cdpnetool/internal/cdptest"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+)
+
+func TestDiff(t *testing.T) {
+	want := []cdptest.RecordedCall{
+		{Method: "Fetch.fulfillRequest", Params: json.RawMessage(`{"requestId":"r1","responseCode":204}`)},
+	}
+
+	// 键顺序与空白不影响比对结果
+	same := []cdptest.RecordedCall{
+		{Method: "Fetch.fulfillRequest", Params: json.RawMessage(`{ "responseCode": 204, "requestId": "r1" }`)},
+	}
+	if diff := cdptest.Diff(want, same); diff != "" {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+
+	tests := []struct {
+		name string
+		got  []cdptest.RecordedCall
+	}{
+		{"missing call", nil},
+		{"different method", []cdptest.RecordedCall{{Method: "Fetch.continueRequest", Params: want[0].Params}}},
+		{"different params", []cdptest.RecordedCall{{Method: "Fetch.fulfillRequest", Params: json.RawMessage(`{"requestId":"r1","responseCode":403}`)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cdptest.Diff(want, tt.got); diff == "" {
+				t.Error("expected diff")
+			}
+		})
+	}
+}
+
+func TestFixture_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "case.json")
+	fx := &cdptest.Fixture{
+		Config: json.RawMessage(`{"version":"1.0","rules":[]}`),
+		Steps: []cdptest.Step{
+			{
+				Paused: &fetch.RequestPausedReply{RequestID: "r1"},
+				Expect: []cdptest.RecordedCall{{Method: "Fetch.continueRequest", Params: json.RawMessage(`{"requestId":"r1"}`)}},
+			},
+		},
+	}
+	if err := fx.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := cdptest.LoadFixture(path)
+	if err != nil {
+		t.Fatalf("LoadFixture() error = %v", err)
+	}
+	if loaded.Name != "case" {
+		t.Errorf("got name %q, want name derived from file", loaded.Name)
+	}
+	if len(loaded.Steps) != 1 || loaded.Steps[0].Paused.RequestID != "r1" {
+		t.Fatalf("unexpected steps: %+v", loaded.Steps)
+	}
+	if diff := cdptest.Diff(fx.Steps[0].Expect, loaded.Steps[0].Expect); diff != "" {
+		t.Error(diff)
+	}
+}
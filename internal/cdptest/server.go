@@ -0,0 +1,298 @@
+// Package cdptest 提供用于测试的模拟 DevTools 服务，无需启动真实浏览器即可驱动拦截流程
+//
+// Server 模拟 /json 系列 HTTP 端点与每个目标的 CDP websocket：
+// 客户端发出的每个方法调用都会被记录，可通过 Handle 脚本化返回值，
+// 并可通过 Pause/Emit 主动推送 Fetch.requestPaused 等事件，通过 Disconnect 模拟连接中断。
+package cdptest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/mafredri/cdp/protocol/fetch"
+)
+
+// Call 客户端发起的一次 CDP 方法调用
+type Call struct {
+	TargetID string
+	Method   string
+	Params   json.RawMessage
+}
+
+// HandlerFunc 方法处理函数，返回值将作为 result 回传给客户端
+type HandlerFunc func(targetID string, params json.RawMessage) (any, error)
+
+// target 模拟的页面目标
+type target struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	WSURL string `json:"webSocketDebuggerUrl"`
+}
+
+// conn 单个目标的 websocket 连接
+type conn struct {
+	ws *websocket.Conn
+	mu sync.Mutex // 串行化写操作
+}
+
+// write 写入一条 JSON 消息
+func (c *conn) write(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteJSON(v)
+}
+
+// Server 模拟 DevTools 服务
+type Server struct {
+	srv      *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu       sync.Mutex
+	targets  []*target
+	conns    map[string]*conn
+	handlers map[string]HandlerFunc
+	calls    []Call
+	changed  chan struct{} // 每次记录调用或建立连接后关闭并重建，用于广播
+}
+
+// NewServer 创建并启动模拟 DevTools 服务
+func NewServer() *Server {
+	s := &Server{
+		upgrader: websocket.Upgrader{EnableCompression: true},
+		conns:    make(map[string]*conn),
+		handlers: make(map[string]HandlerFunc),
+		changed:  make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json/version", s.handleVersion)
+	mux.HandleFunc("/json/list", s.handleList)
+	mux.HandleFunc("/json", s.handleList)
+	mux.HandleFunc("/devtools/page/", s.handleWS)
+	s.srv = httptest.NewServer(mux)
+	return s
+}
+
+// URL 返回 DevTools HTTP 地址（可直接作为 SessionConfig.DevToolsURL）
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Close 关闭服务及所有连接
+func (s *Server) Close() {
+	s.mu.Lock()
+	for _, c := range s.conns {
+		_ = c.ws.Close()
+	}
+	s.mu.Unlock()
+	s.srv.Close()
+}
+
+// AddTarget 添加一个页面目标
+func (s *Server) AddTarget(id, url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wsURL := "ws" + strings.TrimPrefix(s.srv.URL, "http") + "/devtools/page/" + id
+	s.targets = append(s.targets, &target{ID: id, Type: "page", Title: id, URL: url, WSURL: wsURL})
+}
+
+// Handle 注册方法处理函数，未注册的方法返回空结果
+func (s *Server) Handle(method string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = fn
+}
+
+// Emit 向指定目标推送一个 CDP 事件
+func (s *Server) Emit(targetID, method string, params any) error {
+	s.mu.Lock()
+	c, ok := s.conns[targetID]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("cdptest: target %s not connected", targetID)
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(map[string]any{"method": method, "params": json.RawMessage(raw)})
+}
+
+// Pause 向指定目标推送 Fetch.requestPaused 事件
+func (s *Server) Pause(targetID string, ev *fetch.RequestPausedReply) error {
+	return s.Emit(targetID, "Fetch.requestPaused", ev)
+}
+
+// Disconnect 关闭指定目标的 websocket 连接，模拟 DevTools 连接意外中断；客户端可随后重新连接
+func (s *Server) Disconnect(targetID string) error {
+	s.mu.Lock()
+	c, ok := s.conns[targetID]
+	delete(s.conns, targetID)
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("cdptest: target %s not connected", targetID)
+	}
+	return c.ws.Close()
+}
+
+// RemoveTarget 移除指定目标并关闭其 websocket 连接，模拟页面关闭或浏览器重启后目标不复存在
+func (s *Server) RemoveTarget(targetID string) {
+	s.mu.Lock()
+	for i, t := range s.targets {
+		if t.ID == targetID {
+			s.targets = append(s.targets[:i], s.targets[i+1:]...)
+			break
+		}
+	}
+	c, ok := s.conns[targetID]
+	delete(s.conns, targetID)
+	s.mu.Unlock()
+	if ok {
+		_ = c.ws.Close()
+	}
+}
+
+// Calls 返回已记录的全部方法调用
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// WaitCall 等待第 n 次（从 1 开始）出现的指定方法调用
+func (s *Server) WaitCall(ctx context.Context, method string, n int) (Call, error) {
+	for {
+		s.mu.Lock()
+		seen := 0
+		for _, c := range s.calls {
+			if c.Method == method {
+				seen++
+				if seen == n {
+					s.mu.Unlock()
+					return c, nil
+				}
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Call{}, fmt.Errorf("cdptest: waiting for %s: %w", method, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// WaitConnected 等待指定目标建立 websocket 连接
+func (s *Server) WaitConnected(ctx context.Context, targetID string) error {
+	for {
+		s.mu.Lock()
+		_, ok := s.conns[targetID]
+		changed := s.changed
+		s.mu.Unlock()
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cdptest: waiting for target %s: %w", targetID, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// broadcast 唤醒所有等待者（调用方需持有锁）
+func (s *Server) broadcast() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// handleVersion 处理 /json/version
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"Browser":          "cdptest/1.0",
+		"Protocol-Version": "1.3",
+	})
+}
+
+// handleList 处理 /json 与 /json/list
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	targets := append([]*target(nil), s.targets...)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(targets)
+}
+
+// handleWS 处理目标的 websocket 连接并分发方法调用
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/devtools/page/")
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &conn{ws: ws}
+
+	s.mu.Lock()
+	s.conns[id] = c
+	s.broadcast()
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if s.conns[id] == c {
+			delete(s.conns, id)
+		}
+		s.mu.Unlock()
+		_ = ws.Close()
+	}()
+
+	for {
+		var req struct {
+			ID     uint64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := ws.ReadJSON(&req); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.calls = append(s.calls, Call{TargetID: id, Method: req.Method, Params: req.Params})
+		fn := s.handlers[req.Method]
+		s.broadcast()
+		s.mu.Unlock()
+
+		var result any = struct{}{}
+		if fn != nil {
+			result, err = fn(id, req.Params)
+		}
+
+		resp := map[string]any{"id": req.ID}
+		if err != nil {
+			resp["error"] = map[string]any{"code": -32000, "message": err.Error()}
+		} else {
+			if result == nil {
+				result = struct{}{}
+			}
+			resp["result"] = result
+		}
+		if err := c.write(resp); err != nil {
+			if !errors.Is(err, websocket.ErrCloseSent) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package cdptest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/cdptest"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/devtool"
+	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/rpcc"
+)
+
+func TestServer_List(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	targets, err := devtool.New(srv.URL()).List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(targets) != 1 || targets[0].ID != "page1" || targets[0].URL != "https://example.com" {
+		t.Errorf("unexpected targets: %+v", targets)
+	}
+}
+
+func TestServer_CallsAndEvents(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+		return nil, errors.New("no body")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	targets, err := devtool.New(srv.URL()).List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := rpcc.DialContext(ctx, targets[0].WebSocketDebuggerURL)
+	if err != nil {
+		t.Fatalf("dial error = %v", err)
+	}
+	defer conn.Close()
+	client := cdp.NewClient(conn)
+
+	rp, err := client.Fetch.RequestPaused(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+
+	if err := client.Fetch.Enable(ctx, nil); err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 脚本化的错误应原样返回给客户端
+	if _, err := client.Fetch.GetResponseBody(ctx, &fetch.GetResponseBodyArgs{RequestID: "r1"}); err == nil {
+		t.Error("expected scripted error")
+	}
+
+	if err := srv.Pause("page1", &fetch.RequestPausedReply{RequestID: "r1"}); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	ev, err := rp.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if ev.RequestID != "r1" {
+		t.Errorf("got requestId %q, want r1", ev.RequestID)
+	}
+
+	if got := len(srv.Calls()); got != 2 {
+		t.Errorf("got %d calls, want 2", got)
+	}
+}
+
+func TestServer_EmitNotConnected(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+
+	if err := srv.Pause("missing", &fetch.RequestPausedReply{}); err == nil {
+		t.Error("expected error for unconnected target")
+	}
+}
+
+func TestServer_Disconnect(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	targets, err := devtool.New(srv.URL()).List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := rpcc.DialContext(ctx, targets[0].WebSocketDebuggerURL)
+	if err != nil {
+		t.Fatalf("dial error = %v", err)
+	}
+	defer conn.Close()
+	if err := srv.WaitConnected(ctx, "page1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := srv.Disconnect("page1"); err != nil {
+		t.Fatalf("Disconnect() error = %v", err)
+	}
+	select {
+	case <-conn.Context().Done():
+	case <-ctx.Done():
+		t.Fatal("client connection not closed")
+	}
+	if err := srv.Disconnect("page1"); err == nil {
+		t.Error("expected error for unconnected target")
+	}
+
+	// 断开后可重新连接
+	conn2, err := rpcc.DialContext(ctx, targets[0].WebSocketDebuggerURL)
+	if err != nil {
+		t.Fatalf("redial error = %v", err)
+	}
+	defer conn2.Close()
+	if err := srv.WaitConnected(ctx, "page1"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -9,6 +9,7 @@ type Config struct {
 	} `yaml:"sqlite"`
 	Log struct {
 		Level  string   `yaml:"level"`
+		Format string   `yaml:"format"` // text / json
 		Writer []string `yaml:"writer"`
 	} `yaml:"log"`
 }
@@ -26,9 +27,11 @@ func NewConfig() *Config {
 		},
 		Log: struct {
 			Level  string   `yaml:"level"`
+			Format string   `yaml:"format"` // text / json
 			Writer []string `yaml:"writer"`
 		}{
-			Level: "debug",
+			Level:  "debug",
+			Format: "text",
 			// file需要在console之前，因为打包后浏览器控制台日志无法写入会影响文件日志
 			Writer: []string{"file", "console"},
 		},
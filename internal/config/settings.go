@@ -1,19 +1,285 @@
 package config
 
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"cdpnetool/internal/storage/model"
+	"cdpnetool/pkg/domain"
+)
+
 // DefaultSettings 定义所有设置的默认值
 type DefaultSettings struct {
-	Language    string
-	Theme       string
-	BrowserArgs string
-	BrowserPath string
+	Language                 string
+	Theme                    string
+	BrowserArgs              string
+	BrowserPath              string
+	BrowserHeadless          bool
+	BrowserEngine            string
+	LogLevel                 string
+	EventRetentionDays       int
+	SessionConcurrency       int
+	SessionPendingCapacity   int
+	SessionProcessTimeout    time.Duration
+	SessionDisableCache      bool
+	SessionCorrelationHeader string
+	SessionUnmatchedSampling int
+	SessionJournalDir        string
+	SessionCoalesceWindow    time.Duration
+	SessionBodyChunkSize     int
+	SessionBandwidthLimit    int
+	SessionMaxDecodedSize    int
+	SessionRecompressBody    bool
+	SessionCaptureTiming     bool
+	SessionReadOnly          bool
+	SessionCapabilityProfile domain.CapabilityProfile
+	SessionIncludeTypes      string
+	SessionExcludeTypes      string
+	HostMappings             string
+	HostMappingMode          domain.HostMappingMode
+	UserAgent                string
+	ProxyServer              string
+	ProxyBypass              string
+	ProxyUsername            string
+	ProxyPassword            string
+	GRPCDescriptorSet        string
+	RedactHeaders            string
+	RedactCookies            string
+	RedactJSONPaths          string
+	RedactPatterns           string
 }
 
 // GetDefaultSettings 返回默认设置
 func GetDefaultSettings() DefaultSettings {
 	return DefaultSettings{
-		Language:    "zh",
-		Theme:       "system",
-		BrowserArgs: "",
-		BrowserPath: "",
+		Language:                 "zh",
+		Theme:                    "system",
+		BrowserArgs:              "",
+		BrowserPath:              "",
+		BrowserHeadless:          false,
+		BrowserEngine:            "auto",
+		LogLevel:                 "debug",
+		EventRetentionDays:       0,
+		SessionConcurrency:       0,
+		SessionPendingCapacity:   0,
+		SessionProcessTimeout:    60 * time.Second,
+		SessionDisableCache:      false,
+		SessionCorrelationHeader: "",
+		SessionUnmatchedSampling: 0,
+		SessionJournalDir:        "",
+		SessionCoalesceWindow:    0,
+		SessionBodyChunkSize:     0,
+		SessionBandwidthLimit:    0,
+		SessionMaxDecodedSize:    32 << 20,
+		SessionRecompressBody:    false,
+		SessionCaptureTiming:     false,
+		SessionReadOnly:          false,
+		SessionCapabilityProfile: domain.CapabilityFull,
+		SessionIncludeTypes:      "",
+		SessionExcludeTypes:      "",
+		HostMappings:             "",
+		HostMappingMode:          domain.HostMappingRewrite,
+		UserAgent:                "",
+		ProxyServer:              "",
+		ProxyBypass:              "",
+		ProxyUsername:            "",
+		ProxyPassword:            "",
+		GRPCDescriptorSet:        "",
+		RedactHeaders:            "",
+		RedactCookies:            "",
+		RedactJSONPaths:          "",
+		RedactPatterns:           "",
+	}
+}
+
+// SettingType 设置值类型
+type SettingType string
+
+const (
+	SettingString   SettingType = "string"   // 任意字符串
+	SettingInt      SettingType = "int"      // 整数，可限定范围
+	SettingBool     SettingType = "bool"     // 布尔值 true/false
+	SettingEnum     SettingType = "enum"     // 枚举，取值限定在 Enum 中
+	SettingDuration SettingType = "duration" // 时长，如 30s、5m
+	SettingHostMap  SettingType = "hostmap"  // 主机映射表，见 domain.ParseHostMappings
+	SettingProxy    SettingType = "proxy"    // 代理地址，见 domain.ValidateProxyServer
+	SettingRegexes  SettingType = "regexes"  // 正则表达式列表，每行一个
+	SettingHeader   SettingType = "header"   // HTTP 头部名称，可为空
+	SettingTypes    SettingType = "types"    // 资源类型列表，逗号分隔，见 domain.ParseResourceTypes
+)
+
+// SettingSpec 单个设置项的类型定义
+type SettingSpec struct {
+	Key     string        `json:"key"`
+	Type    SettingType   `json:"type"`
+	Default string        `json:"default"`
+	Enum    []string      `json:"enum,omitempty"`
+	Min     int64         `json:"min,omitempty"` // 整数下限
+	Max     int64         `json:"max,omitempty"` // 整数上限，0 表示不限
+	MaxDur  time.Duration `json:"maxDuration,omitempty"`
+}
+
+// settingSchema 所有已声明的设置项，未声明的键按原样作为字符串存储
+var settingSchema = buildSchema(GetDefaultSettings())
+
+// buildSchema 根据默认值构建设置 schema
+func buildSchema(d DefaultSettings) []SettingSpec {
+	return []SettingSpec{
+		{Key: model.SettingKeyLanguage, Type: SettingEnum, Default: d.Language, Enum: []string{"zh", "en"}},
+		{Key: model.SettingKeyTheme, Type: SettingEnum, Default: d.Theme, Enum: []string{"light", "dark", "system"}},
+		{Key: model.SettingKeyBrowserArgs, Type: SettingString, Default: d.BrowserArgs},
+		{Key: model.SettingKeyBrowserPath, Type: SettingString, Default: d.BrowserPath},
+		{Key: model.SettingKeyLogLevel, Type: SettingEnum, Default: d.LogLevel, Enum: []string{"debug", "info", "warn", "error"}},
+		{Key: model.SettingKeyEventRetentionDays, Type: SettingInt, Default: strconv.Itoa(d.EventRetentionDays), Min: 0, Max: 3650},
+		{Key: model.SettingKeyBrowserHeadless, Type: SettingBool, Default: strconv.FormatBool(d.BrowserHeadless)},
+		{Key: model.SettingKeyBrowserEngine, Type: SettingEnum, Default: d.BrowserEngine, Enum: []string{"auto", "chrome", "edge", "brave", "chromium"}},
+		{Key: model.SettingKeySessionConcurrency, Type: SettingInt, Default: strconv.Itoa(d.SessionConcurrency), Min: 0, Max: 1024},
+		{Key: model.SettingKeySessionPendingCapacity, Type: SettingInt, Default: strconv.Itoa(d.SessionPendingCapacity), Min: 0, Max: 65536},
+		{Key: model.SettingKeySessionProcessTimeout, Type: SettingDuration, Default: d.SessionProcessTimeout.String(), MaxDur: 10 * time.Minute},
+		{Key: model.SettingKeySessionDisableCache, Type: SettingBool, Default: strconv.FormatBool(d.SessionDisableCache)},
+		{Key: model.SettingKeySessionCorrelationHeader, Type: SettingHeader, Default: d.SessionCorrelationHeader},
+		{Key: model.SettingKeySessionUnmatchedSampling, Type: SettingInt, Default: strconv.Itoa(d.SessionUnmatchedSampling), Min: -1, Max: 1000000},
+		{Key: model.SettingKeySessionJournalDir, Type: SettingString, Default: d.SessionJournalDir},
+		{Key: model.SettingKeySessionCoalesceWindow, Type: SettingDuration, Default: d.SessionCoalesceWindow.String(), MaxDur: time.Minute},
+		{Key: model.SettingKeySessionBodyChunkSize, Type: SettingInt, Default: strconv.Itoa(d.SessionBodyChunkSize), Min: 0, Max: 64 << 20},
+		{Key: model.SettingKeySessionBandwidthLimit, Type: SettingInt, Default: strconv.Itoa(d.SessionBandwidthLimit), Min: 0, Max: 1 << 30},
+		{Key: model.SettingKeySessionMaxDecodedSize, Type: SettingInt, Default: strconv.Itoa(d.SessionMaxDecodedSize), Min: 0, Max: 1 << 30},
+		{Key: model.SettingKeySessionRecompressBody, Type: SettingBool, Default: strconv.FormatBool(d.SessionRecompressBody)},
+		{Key: model.SettingKeySessionCaptureTiming, Type: SettingBool, Default: strconv.FormatBool(d.SessionCaptureTiming)},
+		{Key: model.SettingKeySessionReadOnly, Type: SettingBool, Default: strconv.FormatBool(d.SessionReadOnly)},
+		{Key: model.SettingKeySessionCapabilityProfile, Type: SettingEnum, Default: string(d.SessionCapabilityProfile),
+			Enum: []string{string(domain.CapabilityFull), string(domain.CapabilityNoBodyMutation), string(domain.CapabilityNoBlock), string(domain.CapabilityMockOnly)}},
+		{Key: model.SettingKeySessionIncludeTypes, Type: SettingTypes, Default: d.SessionIncludeTypes},
+		{Key: model.SettingKeySessionExcludeTypes, Type: SettingTypes, Default: d.SessionExcludeTypes},
+		{Key: model.SettingKeyHostMappings, Type: SettingHostMap, Default: d.HostMappings},
+		{Key: model.SettingKeyHostMappingMode, Type: SettingEnum, Default: string(d.HostMappingMode),
+			Enum: []string{string(domain.HostMappingOff), string(domain.HostMappingResolver), string(domain.HostMappingRewrite)}},
+		{Key: model.SettingKeyUserAgent, Type: SettingString, Default: d.UserAgent},
+		{Key: model.SettingKeyProxyServer, Type: SettingProxy, Default: d.ProxyServer},
+		{Key: model.SettingKeyProxyBypass, Type: SettingString, Default: d.ProxyBypass},
+		{Key: model.SettingKeyProxyUsername, Type: SettingString, Default: d.ProxyUsername},
+		{Key: model.SettingKeyProxyPassword, Type: SettingString, Default: d.ProxyPassword},
+		{Key: model.SettingKeyGRPCDescriptorSet, Type: SettingString, Default: d.GRPCDescriptorSet},
+		{Key: model.SettingKeyRedactHeaders, Type: SettingString, Default: d.RedactHeaders},
+		{Key: model.SettingKeyRedactCookies, Type: SettingString, Default: d.RedactCookies},
+		{Key: model.SettingKeyRedactJSONPaths, Type: SettingString, Default: d.RedactJSONPaths},
+		{Key: model.SettingKeyRedactPatterns, Type: SettingRegexes, Default: d.RedactPatterns},
+	}
+}
+
+// SettingSchema 返回所有已声明的设置项
+func SettingSchema() []SettingSpec {
+	return slices.Clone(settingSchema)
+}
+
+// LookupSetting 查找设置项定义
+func LookupSetting(key string) (SettingSpec, bool) {
+	for _, spec := range settingSchema {
+		if spec.Key == key {
+			return spec, true
+		}
+	}
+	return SettingSpec{}, false
+}
+
+// NormalizeSetting 校验设置值并返回规范化后的值，未声明的键原样返回
+func NormalizeSetting(key, value string) (string, error) {
+	spec, ok := LookupSetting(key)
+	if !ok {
+		return value, nil
+	}
+	v, err := spec.Normalize(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", domain.ErrInvalidSetting, key, err)
+	}
+	return v, nil
+}
+
+// Normalize 按类型校验设置值并返回规范化后的值
+func (s SettingSpec) Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch s.Type {
+	case SettingInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%q 不是整数", value)
+		}
+		if n < s.Min || (s.Max > 0 && n > s.Max) {
+			return "", fmt.Errorf("%d 超出范围 [%d, %d]", n, s.Min, s.Max)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case SettingBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%q 不是布尔值", value)
+		}
+		return strconv.FormatBool(b), nil
+	case SettingEnum:
+		if !slices.Contains(s.Enum, value) {
+			return "", fmt.Errorf("%q 不在可选值 %v 中", value, s.Enum)
+		}
+		return value, nil
+	case SettingDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return "", fmt.Errorf("%q 不是有效时长", value)
+		}
+		if d < 0 || (s.MaxDur > 0 && d > s.MaxDur) {
+			return "", fmt.Errorf("%s 超出范围 [0, %s]", d, s.MaxDur)
+		}
+		return d.String(), nil
+	case SettingHostMap:
+		if _, err := domain.ParseHostMappings(value); err != nil {
+			return "", err
+		}
+		return value, nil
+	case SettingProxy:
+		if err := domain.ValidateProxyServer(value); err != nil {
+			return "", err
+		}
+		return value, nil
+	case SettingHeader:
+		if value == "" {
+			return value, nil
+		}
+		if err := domain.ValidateHeaderName(value); err != nil {
+			return "", err
+		}
+		return value, nil
+	case SettingTypes:
+		types, err := domain.ParseResourceTypes(value)
+		if err != nil {
+			return "", err
+		}
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = string(t)
+		}
+		return strings.Join(names, ","), nil
+	case SettingRegexes:
+		for _, line := range strings.Split(value, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			if _, err := regexp.Compile(line); err != nil {
+				return "", fmt.Errorf("%q 不是有效的正则表达式", line)
+			}
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
+
+// DefaultSettingValues 返回所有已声明设置项的默认值
+func DefaultSettingValues() map[string]string {
+	values := make(map[string]string, len(settingSchema))
+	for _, spec := range settingSchema {
+		values[spec.Key] = spec.Default
 	}
+	return values
 }
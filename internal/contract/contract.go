@@ -0,0 +1,101 @@
+// Package contract 使用 JSON Schema 校验响应体，缓存编译后的 schema
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"cdpnetool/pkg/domain"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// maxViolations 单次校验最多记录的违规数
+const maxViolations = 20
+
+// schemaURL 编译内联 schema 时使用的虚拟地址
+const schemaURL = "inline://schema.json"
+
+// Checker JSON Schema 校验器，并发安全
+type Checker struct {
+	cache sync.Map // schema 文本 -> *jsonschema.Schema
+}
+
+// New 创建校验器
+func New() *Checker {
+	return &Checker{}
+}
+
+// Compile 编译 schema 并缓存，schema 为 JSON 文本。不加载外部 $ref 引用
+func (c *Checker) Compile(schema string) (*jsonschema.Schema, error) {
+	if val, ok := c.cache.Load(schema); ok {
+		return val.(*jsonschema.Schema), nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("external schema reference %q is not supported", s)
+	}
+	if err := compiler.AddResource(schemaURL, strings.NewReader(schema)); err != nil {
+		return nil, err
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.Store(schema, compiled)
+	return compiled, nil
+}
+
+// Check 按 schema 校验 JSON 消息体，返回违规列表；schema 本身无效时返回错误
+func (c *Checker) Check(schema string, body []byte) ([]domain.SchemaViolation, error) {
+	compiled, err := c.Compile(schema)
+	if err != nil {
+		return nil, err
+	}
+	return validate(compiled, body), nil
+}
+
+// validate 按已编译的 schema 校验 JSON 消息体，消息体不是合法 JSON 时记为一条违规
+func validate(schema *jsonschema.Schema, body []byte) []domain.SchemaViolation {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return []domain.SchemaViolation{{Message: "body is not valid JSON"}}
+	}
+
+	var ve *jsonschema.ValidationError
+	if err := schema.Validate(v); !errors.As(err, &ve) {
+		if err != nil {
+			return []domain.SchemaViolation{{Message: err.Error()}}
+		}
+		return nil
+	}
+	var out []domain.SchemaViolation
+	collect(ve, &out)
+	return out
+}
+
+// collect 收集最底层的校验错误，上层错误只是对子错误的汇总
+func collect(ve *jsonschema.ValidationError, out *[]domain.SchemaViolation) {
+	if len(*out) >= maxViolations {
+		return
+	}
+	if len(ve.Causes) == 0 {
+		*out = append(*out, domain.SchemaViolation{
+			Path:    ve.InstanceLocation,
+			Keyword: ve.KeywordLocation,
+			Message: ve.Message,
+		})
+		return
+	}
+	for _, cause := range ve.Causes {
+		collect(cause, out)
+	}
+}
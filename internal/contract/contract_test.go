@@ -0,0 +1,82 @@
+package contract_test
+
+import (
+	"strings"
+	"testing"
+
+	"cdpnetool/internal/contract"
+)
+
+const userSchema = `{
+  "type": "object",
+  "required": ["id", "name"],
+  "properties": {
+    "id": {"type": "integer"},
+    "name": {"type": "string"},
+    "tags": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+func TestCheck(t *testing.T) {
+	c := contract.New()
+	tests := []struct {
+		name      string
+		body      string
+		wantPaths []string
+	}{
+		{"valid", `{"id": 1, "name": "alice", "tags": ["a"]}`, nil},
+		{"wrong type", `{"id": "1", "name": "alice"}`, []string{"/id"}},
+		{"missing field", `{"id": 1}`, []string{""}},
+		{"nested items", `{"id": 1, "name": "a", "tags": ["x", 2]}`, []string{"/tags/1"}},
+		{"not json", `<html>`, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := c.Check(userSchema, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("Check error: %v", err)
+			}
+			if len(violations) != len(tt.wantPaths) {
+				t.Fatalf("got %d violations %+v, want %d", len(violations), violations, len(tt.wantPaths))
+			}
+			for i, v := range violations {
+				if v.Path != tt.wantPaths[i] {
+					t.Errorf("violation %d path = %q, want %q", i, v.Path, tt.wantPaths[i])
+				}
+				if v.Message == "" {
+					t.Errorf("violation %d has empty message", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCheck_InvalidSchema(t *testing.T) {
+	c := contract.New()
+	if _, err := c.Check(`{"type": 5}`, []byte(`{}`)); err == nil {
+		t.Error("expected error for invalid schema")
+	}
+	if _, err := c.Check(`not json`, []byte(`{}`)); err == nil {
+		t.Error("expected error for malformed schema")
+	}
+}
+
+func TestCheck_ExternalRef(t *testing.T) {
+	c := contract.New()
+	_, err := c.Check(`{"$ref": "file:///etc/passwd"}`, []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("got error %v, want external reference rejected", err)
+	}
+}
+
+func TestCompile_Cached(t *testing.T) {
+	c := contract.New()
+	s1, err := c.Compile(userSchema)
+	if err != nil {
+		t.Fatalf("Compile error: %v", err)
+	}
+	s2, _ := c.Compile(userSchema)
+	if s1 != s2 {
+		t.Error("expected cached schema to be reused")
+	}
+}
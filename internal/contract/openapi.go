@@ -0,0 +1,511 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// specURL 编译规范中的 schema 时使用的虚拟地址
+const specURL = "inline://openapi.json"
+
+// 契约违规类型
+const (
+	KindPath         = "path"         // 路径未在规范中定义
+	KindMethod       = "method"       // 路径存在但方法未定义
+	KindStatus       = "status"       // 响应状态码未在规范中定义
+	KindRequestBody  = "requestBody"  // 请求体缺失、类型未定义或不符合 schema
+	KindResponseBody = "responseBody" // 响应体类型未定义或不符合 schema
+)
+
+// methods OpenAPI 路径项中的操作方法
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// templateParam 匹配路径模板中的 {param}
+var templateParam = regexp.MustCompile(`\{[^/{}]+\}`)
+
+// Spec 已加载的 OpenAPI 3 规范，并发安全
+type Spec struct {
+	path       string
+	title      string
+	version    string
+	servers    []server
+	paths      []*pathItem // 字面路径在前，参数少的模板在前
+	operations int
+
+	doc     []byte // 规范化后的 JSON 文档，供 schema 编译
+	draft   *jsonschema.Draft
+	schemas sync.Map // JSON Pointer -> *jsonschema.Schema 或 error
+}
+
+// server 规范声明的服务地址
+type server struct {
+	host string // 小写的 host[:port]，相对地址为空
+	base string // 基础路径，不含末尾斜杠
+}
+
+// pathItem 路径模板及其操作
+type pathItem struct {
+	template string
+	re       *regexp.Regexp
+	params   int
+	ops      map[string]*Operation // 大写方法名 -> 操作
+}
+
+// Operation 规范中的单个操作
+type Operation struct {
+	spec         *Spec
+	Method       string // 大写方法名
+	Path         string // 路径模板
+	ID           string // operationId
+	body         *content
+	bodyRequired bool
+	responses    map[string]*content // 状态码、nXX 或 default -> 响应内容
+}
+
+// content 媒体类型到 schema 指针的映射，schema 指针为空表示未定义 schema
+type content struct {
+	media map[string]string
+}
+
+// LoadSpec 从 JSON 或 YAML 文件加载 OpenAPI 3.0/3.1 规范
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := ParseSpec(data)
+	if err != nil {
+		return nil, err
+	}
+	spec.path = path
+	return spec, nil
+}
+
+// ParseSpec 解析 JSON 或 YAML 格式的 OpenAPI 3.0/3.1 规范
+func ParseSpec(data []byte) (*Spec, error) {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		if yerr := yaml.Unmarshal(data, &raw); yerr != nil {
+			return nil, fmt.Errorf("parse OpenAPI spec: %w", yerr)
+		}
+	}
+	root, ok := normalize(raw).(map[string]any)
+	if !ok {
+		return nil, errors.New("OpenAPI spec must be an object")
+	}
+
+	s := &Spec{}
+	version, _ := root["openapi"].(string)
+	switch {
+	case strings.HasPrefix(version, "3.0"):
+		// 3.0 的 schema 基于 draft-04，nullable 需转换为类型数组
+		s.draft = jsonschema.Draft4
+		convertNullable(root)
+	case strings.HasPrefix(version, "3.1"):
+		s.draft = jsonschema.Draft2020
+	default:
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, only 3.0 and 3.1 are supported", version)
+	}
+	if info, ok := root["info"].(map[string]any); ok {
+		s.title, _ = info["title"].(string)
+		s.version, _ = info["version"].(string)
+	}
+
+	doc, err := json.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	s.doc = doc
+	s.servers = parseServers(root["servers"])
+
+	paths, _ := root["paths"].(map[string]any)
+	for tmpl, v := range paths {
+		item, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		pi, err := s.parsePathItem(root, tmpl, item)
+		if err != nil {
+			return nil, err
+		}
+		s.paths = append(s.paths, pi)
+	}
+	sort.Slice(s.paths, func(i, j int) bool {
+		if s.paths[i].params != s.paths[j].params {
+			return s.paths[i].params < s.paths[j].params
+		}
+		return s.paths[i].template < s.paths[j].template
+	})
+	return s, nil
+}
+
+// Status 返回契约检查状态
+func (s *Spec) Status() domain.ContractStatus {
+	return domain.ContractStatus{
+		Active:     true,
+		Path:       s.path,
+		Title:      s.title,
+		Version:    s.version,
+		Operations: s.operations,
+	}
+}
+
+// parsePathItem 解析路径项中的各个操作
+func (s *Spec) parsePathItem(root map[string]any, tmpl string, item map[string]any) (*pathItem, error) {
+	ptr := "/paths/" + escapePointer(tmpl)
+	// 参数匹配单个路径段，其余部分按字面匹配
+	var b strings.Builder
+	last := 0
+	for _, loc := range templateParam.FindAllStringIndex(tmpl, -1) {
+		b.WriteString(regexp.QuoteMeta(tmpl[last:loc[0]]))
+		b.WriteString(`[^/]+`)
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(tmpl[last:]))
+	re, err := regexp.Compile("^" + b.String() + "/?$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid path template %q: %w", tmpl, err)
+	}
+
+	pi := &pathItem{
+		template: tmpl,
+		re:       re,
+		params:   len(templateParam.FindAllString(tmpl, -1)),
+		ops:      make(map[string]*Operation),
+	}
+	for _, m := range methods {
+		node, ok := item[m].(map[string]any)
+		if !ok {
+			continue
+		}
+		opPtr := ptr + "/" + m
+		op := &Operation{spec: s, Method: strings.ToUpper(m), Path: tmpl, responses: make(map[string]*content)}
+		op.ID, _ = node["operationId"].(string)
+
+		if rb, rbPtr := resolve(root, node["requestBody"], opPtr+"/requestBody"); rb != nil {
+			op.body = parseContent(rb["content"], rbPtr+"/content")
+			op.bodyRequired, _ = rb["required"].(bool)
+		}
+		responses, _ := node["responses"].(map[string]any)
+		for code, v := range responses {
+			r, rPtr := resolve(root, v, opPtr+"/responses/"+escapePointer(code))
+			if r == nil {
+				continue
+			}
+			op.responses[strings.ToUpper(code)] = parseContent(r["content"], rPtr+"/content")
+		}
+		pi.ops[op.Method] = op
+		s.operations++
+	}
+	return pi, nil
+}
+
+// parseContent 解析 content 对象，记录各媒体类型的 schema 指针
+func parseContent(v any, ptr string) *content {
+	c := &content{media: make(map[string]string)}
+	m, _ := v.(map[string]any)
+	for mt, mv := range m {
+		schemaPtr := ""
+		if media, ok := mv.(map[string]any); ok && media["schema"] != nil {
+			schemaPtr = ptr + "/" + escapePointer(mt) + "/schema"
+		}
+		c.media[strings.ToLower(mt)] = schemaPtr
+	}
+	return c
+}
+
+// resolve 跟随本地 $ref 引用，返回最终对象及其 JSON Pointer
+func resolve(root map[string]any, v any, ptr string) (map[string]any, string) {
+	for i := 0; i < 16; i++ {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, ""
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m, ptr
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil, ""
+		}
+		ptr = ref[1:]
+		v = lookup(root, ptr)
+	}
+	return nil, ""
+}
+
+// lookup 按 JSON Pointer 查找文档中的值
+func lookup(root any, ptr string) any {
+	cur := root
+	for _, seg := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		seg = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[seg]
+	}
+	return cur
+}
+
+// CheckRequest 检查请求是否符合规范。inScope 为 false 表示请求不属于规范声明的服务，不做检查；
+// 匹配到操作时返回该操作，用于检查对应的响应
+func (s *Spec) CheckRequest(req *domain.Request) (op *Operation, violations []domain.SchemaViolation, inScope bool) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, nil, false
+	}
+	path, ok := s.relativePath(u, req.ResourceType)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var item *pathItem
+	for _, pi := range s.paths {
+		if pi.re.MatchString(path) {
+			item = pi
+			break
+		}
+	}
+	if item == nil {
+		return nil, []domain.SchemaViolation{{Kind: KindPath, Message: fmt.Sprintf("path %s is not defined in the OpenAPI spec", path)}}, true
+	}
+	op = item.ops[strings.ToUpper(req.Method)]
+	if op == nil {
+		return nil, []domain.SchemaViolation{{Kind: KindMethod, Message: fmt.Sprintf("method %s is not defined for %s", strings.ToUpper(req.Method), item.template)}}, true
+	}
+	return op, op.checkBody(KindRequestBody, op.body, op.bodyRequired, req.Body, req.Headers.Get("Content-Type")), true
+}
+
+// CheckResponse 检查响应的状态码与响应体是否符合操作的定义
+func (op *Operation) CheckResponse(res *domain.Response) []domain.SchemaViolation {
+	code := strconv.Itoa(res.StatusCode)
+	c, ok := op.responses[code]
+	if !ok && len(code) == 3 {
+		c, ok = op.responses[code[:1]+"XX"]
+	}
+	if !ok {
+		c, ok = op.responses["DEFAULT"]
+	}
+	if !ok {
+		return []domain.SchemaViolation{{Kind: KindStatus, Message: fmt.Sprintf("status %d is not documented for %s %s", res.StatusCode, op.Method, op.Path)}}
+	}
+	return op.checkBody(KindResponseBody, c, false, res.Body, res.Headers.Get("Content-Type"))
+}
+
+// checkBody 检查消息体的媒体类型与 schema
+func (op *Operation) checkBody(kind string, c *content, required bool, body []byte, contentType string) []domain.SchemaViolation {
+	if len(body) == 0 {
+		if required {
+			return []domain.SchemaViolation{{Kind: kind, Message: "body is required"}}
+		}
+		return nil
+	}
+	if c == nil || len(c.media) == 0 {
+		return nil
+	}
+
+	mt, _, _ := strings.Cut(contentType, ";")
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	schemaPtr, ok := matchMedia(c.media, mt)
+	if !ok {
+		return []domain.SchemaViolation{{Kind: kind, Message: fmt.Sprintf("content type %q is not documented", mt)}}
+	}
+	if schemaPtr == "" {
+		return nil
+	}
+
+	if codec := transformer.CodecFor(mt); codec != transformer.CodecNone {
+		doc, err := transformer.DecodeToJSON(body, codec)
+		if err != nil {
+			return []domain.SchemaViolation{{Kind: kind, Message: "body cannot be decoded: " + err.Error()}}
+		}
+		body = []byte(doc)
+	} else if !strings.Contains(mt, "json") {
+		// 仅校验 JSON 类消息体
+		return nil
+	}
+
+	schema, err := op.spec.schema(schemaPtr)
+	if err != nil {
+		return []domain.SchemaViolation{{Kind: kind, Message: "invalid schema in spec: " + err.Error()}}
+	}
+	violations := validate(schema, body)
+	for i := range violations {
+		violations[i].Kind = kind
+	}
+	return violations
+}
+
+// matchMedia 按精确类型、type/* 与 */* 的顺序查找媒体类型
+func matchMedia(media map[string]string, mt string) (string, bool) {
+	if p, ok := media[mt]; ok {
+		return p, true
+	}
+	if typ, _, ok := strings.Cut(mt, "/"); ok {
+		if p, ok := media[typ+"/*"]; ok {
+			return p, true
+		}
+	}
+	p, ok := media["*/*"]
+	return p, ok
+}
+
+// schema 编译并缓存规范中指定位置的 schema
+func (s *Spec) schema(ptr string) (*jsonschema.Schema, error) {
+	if v, ok := s.schemas.Load(ptr); ok {
+		if err, ok := v.(error); ok {
+			return nil, err
+		}
+		return v.(*jsonschema.Schema), nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = s.draft
+	compiler.LoadURL = func(u string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("external schema reference %q is not supported", u)
+	}
+	var compiled *jsonschema.Schema
+	err := compiler.AddResource(specURL, bytes.NewReader(s.doc))
+	if err == nil {
+		compiled, err = compiler.Compile(specURL + "#" + fragment(ptr))
+	}
+	if err != nil {
+		s.schemas.Store(ptr, err)
+		return nil, err
+	}
+	s.schemas.Store(ptr, compiled)
+	return compiled, nil
+}
+
+// relativePath 判断请求是否属于规范声明的服务，返回去掉基础路径后的请求路径。
+// 规范只声明了相对地址（或未声明）时，仅检查 XHR 与 Fetch 请求，避免页面资源被误报
+func (s *Spec) relativePath(u *url.URL, resType domain.ResourceType) (string, bool) {
+	for _, srv := range s.servers {
+		if srv.host != "" && !strings.EqualFold(u.Host, srv.host) {
+			continue
+		}
+		if srv.host == "" && resType != "" && resType != domain.ResourceTypeXHR && resType != domain.ResourceTypeFetch {
+			continue
+		}
+		p := u.Path
+		if p == "" {
+			p = "/"
+		}
+		if srv.base != "" {
+			if p != srv.base && !strings.HasPrefix(p, srv.base+"/") {
+				continue
+			}
+			p = strings.TrimPrefix(p, srv.base)
+			if p == "" {
+				p = "/"
+			}
+		}
+		return p, true
+	}
+	return "", false
+}
+
+// parseServers 解析 servers 列表，服务变量取默认值；未声明时视为根路径的相对地址
+func parseServers(v any) []server {
+	list, _ := v.([]any)
+	var out []server
+	for _, item := range list {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		raw, _ := m["url"].(string)
+		vars, _ := m["variables"].(map[string]any)
+		for name, vv := range vars {
+			if def, ok := vv.(map[string]any)["default"].(string); ok {
+				raw = strings.ReplaceAll(raw, "{"+name+"}", def)
+			}
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		out = append(out, server{host: strings.ToLower(u.Host), base: strings.TrimRight(u.Path, "/")})
+	}
+	if len(out) == 0 {
+		out = append(out, server{})
+	}
+	return out
+}
+
+// normalize 将 YAML 解析出的非字符串键转为字符串，使文档可序列化为 JSON
+func normalize(v any) any {
+	switch x := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(x))
+		for k, val := range x {
+			m[fmt.Sprint(k)] = normalize(val)
+		}
+		return m
+	case map[string]any:
+		for k, val := range x {
+			x[k] = normalize(val)
+		}
+		return x
+	case []any:
+		for i, val := range x {
+			x[i] = normalize(val)
+		}
+		return x
+	default:
+		return v
+	}
+}
+
+// convertNullable 将 OpenAPI 3.0 的 nullable: true 转换为包含 null 的类型数组
+func convertNullable(v any) {
+	switch x := v.(type) {
+	case map[string]any:
+		if nullable, _ := x["nullable"].(bool); nullable {
+			if t, ok := x["type"].(string); ok {
+				x["type"] = []any{t, "null"}
+			}
+			if enum, ok := x["enum"].([]any); ok {
+				x["enum"] = append(enum, nil)
+			}
+		}
+		for _, val := range x {
+			convertNullable(val)
+		}
+	case []any:
+		for _, val := range x {
+			convertNullable(val)
+		}
+	}
+}
+
+// escapePointer 转义 JSON Pointer 中的单个段
+func escapePointer(seg string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(seg, "~", "~0"), "/", "~1")
+}
+
+// fragment 将 JSON Pointer 编码为 URL 片段
+func fragment(ptr string) string {
+	segs := strings.Split(ptr, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
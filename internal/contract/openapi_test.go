@@ -0,0 +1,159 @@
+package contract_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"cdpnetool/internal/contract"
+	"cdpnetool/pkg/domain"
+)
+
+func loadPetstore(t *testing.T) *contract.Spec {
+	t.Helper()
+	spec, err := contract.LoadSpec(filepath.Join("testdata", "petstore.yaml"))
+	if err != nil {
+		t.Fatalf("LoadSpec error: %v", err)
+	}
+	return spec
+}
+
+func TestLoadSpec(t *testing.T) {
+	status := loadPetstore(t).Status()
+	if !status.Active || status.Title != "Petstore" || status.Version != "1.0.0" || status.Operations != 4 {
+		t.Errorf("got status %+v", status)
+	}
+}
+
+func TestParseSpec_Unsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"swagger 2.0", `{"swagger": "2.0", "paths": {}}`},
+		{"not an object", `[1, 2]`},
+		{"invalid", `{{{`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := contract.ParseSpec([]byte(tt.data)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func kinds(vs []domain.SchemaViolation) []string {
+	var out []string
+	for _, v := range vs {
+		out = append(out, v.Kind)
+	}
+	return out
+}
+
+func TestCheckRequest(t *testing.T) {
+	spec := loadPetstore(t)
+	tests := []struct {
+		name      string
+		req       domain.Request
+		inScope   bool
+		wantOp    string
+		wantKinds []string
+	}{
+		{"other host", domain.Request{URL: "https://cdn.example.com/v1/pets", Method: "GET"}, false, "", nil},
+		{"outside base path", domain.Request{URL: "https://api.example.com/v2/pets", Method: "GET"}, false, "", nil},
+		{"list", domain.Request{URL: "https://api.example.com/v1/pets?limit=1", Method: "GET"}, true, "listPets", nil},
+		{"literal before template", domain.Request{URL: "https://api.example.com/v1/pets/mine", Method: "GET"}, true, "", nil},
+		{"template", domain.Request{URL: "https://api.example.com/v1/pets/42", Method: "get"}, true, "getPet", nil},
+		{"unknown path", domain.Request{URL: "https://api.example.com/v1/owners", Method: "GET"}, true, "", []string{contract.KindPath}},
+		{"unknown method", domain.Request{URL: "https://api.example.com/v1/pets/42", Method: "DELETE"}, true, "", []string{contract.KindMethod}},
+		{"missing body", domain.Request{URL: "https://api.example.com/v1/pets", Method: "POST"}, true, "createPet", []string{contract.KindRequestBody}},
+		{"invalid body", domain.Request{
+			URL: "https://api.example.com/v1/pets", Method: "POST",
+			Headers: domain.Header{"content-type": "application/json"}, Body: []byte(`{"name": 1}`),
+		}, true, "createPet", []string{contract.KindRequestBody}},
+		{"wrong content type", domain.Request{
+			URL: "https://api.example.com/v1/pets", Method: "POST",
+			Headers: domain.Header{"Content-Type": "text/plain"}, Body: []byte(`name`),
+		}, true, "createPet", []string{contract.KindRequestBody}},
+		{"valid body", domain.Request{
+			URL: "https://api.example.com/v1/pets", Method: "POST",
+			Headers: domain.Header{"Content-Type": "application/json; charset=utf-8"}, Body: []byte(`{"name": "rex"}`),
+		}, true, "createPet", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, violations, inScope := spec.CheckRequest(&tt.req)
+			if inScope != tt.inScope {
+				t.Fatalf("got inScope %v, want %v", inScope, tt.inScope)
+			}
+			if tt.wantOp != "" && (op == nil || op.ID != tt.wantOp) {
+				t.Errorf("got operation %+v, want %s", op, tt.wantOp)
+			}
+			if got := kinds(violations); len(got) != len(tt.wantKinds) || (len(got) > 0 && got[0] != tt.wantKinds[0]) {
+				t.Errorf("got violations %+v, want kinds %v", violations, tt.wantKinds)
+			}
+		})
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	spec := loadPetstore(t)
+	op, _, _ := spec.CheckRequest(&domain.Request{URL: "https://api.example.com/v1/pets/1", Method: "GET"})
+	if op == nil {
+		t.Fatal("expected operation for /pets/{id}")
+	}
+	create, _, _ := spec.CheckRequest(&domain.Request{URL: "https://api.example.com/v1/pets", Method: "POST"})
+
+	json := domain.Header{"Content-Type": "application/json"}
+	tests := []struct {
+		name      string
+		op        *contract.Operation
+		status    int
+		headers   domain.Header
+		body      string
+		wantKinds []string
+	}{
+		{"valid", op, 200, json, `{"id": 1, "name": "rex", "tag": null}`, nil},
+		{"invalid field", op, 200, json, `{"id": "1", "name": "rex"}`, []string{contract.KindResponseBody}},
+		{"missing fields", op, 200, json, `{}`, []string{contract.KindResponseBody}},
+		{"default response", op, 500, json, `{"message": "boom"}`, nil},
+		{"undocumented content type", op, 200, domain.Header{"Content-Type": "text/html"}, `<html>`, []string{contract.KindResponseBody}},
+		{"range response", create, 404, json, `{"message": "no"}`, nil},
+		{"undocumented status", create, 500, json, `{}`, []string{contract.KindStatus}},
+		{"no content", create, 201, nil, ``, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &domain.Response{StatusCode: tt.status, Headers: tt.headers, Body: []byte(tt.body)}
+			violations := tt.op.CheckResponse(res)
+			got := kinds(violations)
+			if len(got) != len(tt.wantKinds) {
+				t.Fatalf("got violations %+v, want kinds %v", violations, tt.wantKinds)
+			}
+			for i := range got {
+				if got[i] != tt.wantKinds[i] {
+					t.Errorf("violation %d kind = %s, want %s", i, got[i], tt.wantKinds[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckRequest_RelativeServer(t *testing.T) {
+	spec, err := contract.ParseSpec([]byte(`{"openapi": "3.1.0", "info": {"title": "t", "version": "1"},
+		"servers": [{"url": "/api"}],
+		"paths": {"/items": {"get": {"responses": {"200": {"description": "ok"}}}}}}`))
+	if err != nil {
+		t.Fatalf("ParseSpec error: %v", err)
+	}
+
+	// 相对地址只检查 XHR 与 Fetch 请求
+	_, _, inScope := spec.CheckRequest(&domain.Request{URL: "https://any.host/api/app.js", Method: "GET", ResourceType: domain.ResourceTypeScript})
+	if inScope {
+		t.Error("script request should be out of scope")
+	}
+	_, violations, inScope := spec.CheckRequest(&domain.Request{URL: "https://any.host/api/users", Method: "GET", ResourceType: domain.ResourceTypeFetch})
+	if !inScope || len(violations) != 1 || violations[0].Kind != contract.KindPath {
+		t.Errorf("got inScope %v violations %+v, want unknown path", inScope, violations)
+	}
+}
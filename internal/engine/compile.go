@@ -0,0 +1,396 @@
+package engine
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cdpnetool/internal/regexutil"
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// compiledCondition 预处理后的条件：正则已编译，Header 名已转为小写
+type compiledCondition struct {
+	cond   *rulespec.Condition
+	re     *regexp.Regexp   // *Regex 条件或 regex: 匹配值的正则，编译失败时为 nil，条件恒不满足
+	regex  bool             // 匹配值以 regex: 开头，按正则匹配
+	name   string           // header* 条件为小写 Header 名，其他条件为原始键名
+	value  string           // host 条件为小写域名，其他条件为原始匹配值
+	header bool             // 是否为 header* 条件，需要小写 Header 视图
+	ranges []rulespec.Range // statusCode 与 responseTime 条件解析后的范围，无法解析时为空，条件恒不满足
+}
+
+// compiledRule 预处理后的规则
+type compiledRule struct {
+	rule     *rulespec.Rule
+	allOf    []compiledCondition
+	anyOf    []compiledCondition
+	anyIndex *urlIndex // anyOf 中可索引的 URL/域名条件，命中即满足 anyOf
+	anyRest  []int     // anyOf 中未被索引、需逐条评估的条件下标
+}
+
+// compiledStage 单个阶段已启用的规则，按评估顺序排列：优先级从大到小，同优先级保持配置顺序
+type compiledStage struct {
+	rules   []compiledRule
+	index   *urlIndex // 以 allOf 中的 URL/域名条件索引的规则下标
+	rest    []int     // 未被索引的规则下标，每次都需要评估
+	headers bool      // 是否有规则使用 header* 条件
+	hosts   bool      // 是否有规则使用 host 条件
+}
+
+// compiledConfig 加载规则时生成的匹配结构，按阶段划分
+type compiledConfig struct {
+	stages map[rulespec.Stage]*compiledStage
+}
+
+// compile 将规则配置预处理为匹配结构，避免每次评估时重复解析规则定义。
+// 已启用规则的条件正则或范围、redirect 与 mapLocal 行为的正则无法编译时返回第一个错误（*domain.RuleError），结构仍完整生成，出错的条件恒不满足
+func compile(config *rulespec.Config, cache *regexutil.Cache) (*compiledConfig, error) {
+	cc := &compiledConfig{stages: make(map[rulespec.Stage]*compiledStage)}
+	if config == nil {
+		return cc, nil
+	}
+	var firstErr error
+	firstIdx := len(config.Rules)
+	fail := func(i int, err error) {
+		// 按配置顺序报告第一个错误，与评估顺序无关
+		if err != nil && i < firstIdx {
+			firstErr, firstIdx = &domain.RuleError{RuleID: config.Rules[i].ID, Err: err}, i
+		}
+	}
+	for _, i := range evalOrder(config.Rules) {
+		rule := &config.Rules[i]
+		if !rule.Enabled {
+			continue
+		}
+		st := cc.stages[rule.Stage]
+		if st == nil {
+			st = &compiledStage{index: newURLIndex()}
+			cc.stages[rule.Stage] = st
+		}
+
+		allOf, err := compileConditions(rule.Match.AllOf, cache)
+		fail(i, err)
+		anyOf, err := compileConditions(rule.Match.AnyOf, cache)
+		fail(i, err)
+		fail(i, compileActions(rule.Actions, cache))
+		cr := compiledRule{rule: rule, allOf: allOf, anyOf: anyOf}
+		// anyOf 中的 URL/域名条件（如大型拦截名单）改为查表，其余条件逐条评估
+		for j := range cr.anyOf {
+			if indexable(&cr.anyOf[j]) {
+				if cr.anyIndex == nil {
+					cr.anyIndex = newURLIndex()
+				}
+				cr.anyIndex.add(&cr.anyOf[j], j)
+			} else {
+				cr.anyRest = append(cr.anyRest, j)
+			}
+		}
+
+		idx := len(st.rules)
+		st.rules = append(st.rules, cr)
+		st.headers = st.headers || uses(cr.allOf, isHeader) || uses(cr.anyOf, isHeader)
+		st.hosts = st.hosts || uses(cr.allOf, isHost) || uses(cr.anyOf, isHost)
+
+		// allOf 中的 URL/域名条件必须满足，取其中最具选择性的一条建立索引以缩小候选范围
+		if key := indexKey(cr.allOf); key != nil {
+			st.index.add(key, idx)
+		} else {
+			st.rest = append(st.rest, idx)
+		}
+	}
+	return cc, firstErr
+}
+
+// evalOrder 返回规则的评估顺序（配置中的下标）：优先级从大到小，同优先级保持配置顺序
+func evalOrder(rules []rulespec.Rule) []int {
+	order := make([]int, len(rules))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return rules[order[a]].Priority > rules[order[b]].Priority
+	})
+	return order
+}
+
+// indexable 判断条件能否通过 URL/域名索引查找
+func indexable(c *compiledCondition) bool {
+	switch c.cond.Type {
+	case rulespec.ConditionURLEquals, rulespec.ConditionURLPrefix, rulespec.ConditionHost:
+		return c.value != "" && !c.regex
+	}
+	return false
+}
+
+// indexKey 选出 allOf 中用于索引规则的条件：精确 URL 优先，其次域名，最后取最长的 URL 前缀
+func indexKey(conds []compiledCondition) *compiledCondition {
+	var key *compiledCondition
+	rank := func(c *compiledCondition) int {
+		switch c.cond.Type {
+		case rulespec.ConditionURLEquals:
+			return 2
+		case rulespec.ConditionHost:
+			return 1
+		}
+		return 0
+	}
+	for i := range conds {
+		c := &conds[i]
+		if !indexable(c) {
+			continue
+		}
+		if key == nil || rank(c) > rank(key) || (rank(c) == rank(key) && len(c.value) > len(key.value)) {
+			key = c
+		}
+	}
+	return key
+}
+
+// compileConditions 预处理条件列表，返回第一个正则编译或范围解析错误
+func compileConditions(conds []rulespec.Condition, cache *regexutil.Cache) ([]compiledCondition, error) {
+	out := make([]compiledCondition, len(conds))
+	var firstErr error
+	for i := range conds {
+		c := &conds[i]
+		cc := compiledCondition{cond: c, name: c.Name, value: c.Value}
+		if pattern, ok := c.RegexPattern(); ok {
+			var err error
+			cc.re, err = cache.Get(pattern)
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("condition %s: invalid regex %q: %v", c.Type, pattern, err)
+			}
+			switch c.Type {
+			case rulespec.ConditionURLRegex, rulespec.ConditionHeaderRegex, rulespec.ConditionQueryRegex,
+				rulespec.ConditionCookieRegex, rulespec.ConditionBodyRegex:
+			default:
+				cc.regex = true
+			}
+		}
+		switch c.Type {
+		case rulespec.ConditionHeaderExists, rulespec.ConditionHeaderNotExists, rulespec.ConditionHeaderEquals,
+			rulespec.ConditionHeaderContains, rulespec.ConditionHeaderRegex:
+			cc.name = strings.ToLower(c.Name)
+			cc.header = true
+		case rulespec.ConditionHost:
+			cc.value = normalizeHost(c.Value)
+		case rulespec.ConditionContentType:
+			cc.value = strings.ToLower(c.Value)
+		case rulespec.ConditionStatusCode, rulespec.ConditionResponseTime:
+			var err error
+			cc.ranges, err = rulespec.ParseRanges(c.Value)
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("condition %s: %v", c.Type, err)
+			}
+		}
+		out[i] = cc
+	}
+	return out, firstErr
+}
+
+// compileActions 预编译 redirect 与 mapLocal 行为（包括变体中的）匹配 URL 的正则与 script 行为的脚本，返回第一个编译错误
+func compileActions(actions []rulespec.Action, cache *regexutil.Cache) error {
+	for i := range actions {
+		a := &actions[i]
+		if a.Type == rulespec.ActionVariant {
+			for _, v := range a.Variants {
+				if err := compileActions(v.Actions, cache); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if (a.Type == rulespec.ActionRedirect || a.Type == rulespec.ActionMapLocal) && a.Pattern != "" {
+			if _, err := cache.Get(a.Pattern); err != nil {
+				return fmt.Errorf("action %s: invalid regex %q: %v", a.Type, a.Pattern, err)
+			}
+		}
+		if src, _ := a.Value.(string); a.Type == rulespec.ActionScript {
+			if _, err := transformer.CompileScript(src); err != nil {
+				return fmt.Errorf("action %s: %v", a.Type, err)
+			}
+		}
+	}
+	return nil
+}
+
+// uses 判断条件列表中是否包含满足 pred 的条件
+func uses(conds []compiledCondition, pred func(c *compiledCondition) bool) bool {
+	for i := range conds {
+		if pred(&conds[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHeader 判断是否为 header* 条件
+func isHeader(c *compiledCondition) bool {
+	return c.header
+}
+
+// isHost 判断是否为 host 条件
+func isHost(c *compiledCondition) bool {
+	return c.cond.Type == rulespec.ConditionHost
+}
+
+// candidates 返回可能匹配该请求的规则下标，按评估顺序（优先级）排列。
+// 下标即规则在 st.rules 中的位置，升序与评估顺序一致，合并索引命中的规则与 st.rest 后按下标排序即可保持该顺序
+func (st *compiledStage) candidates(url, host string) []int {
+	out := append([]int(nil), st.rest...)
+	st.index.lookup(url, host, func(idx int) {
+		out = append(out, idx)
+	})
+	if len(out) == len(st.rest) {
+		return out
+	}
+	sort.Ints(out)
+	// 同一规则可能经多个前缀命中，去除重复下标
+	n := 0
+	for i, idx := range out {
+		if i == 0 || idx != out[n-1] {
+			out[n] = idx
+			n++
+		}
+	}
+	return out[:n]
+}
+
+// urlIndex 按精确 URL、URL 前缀与域名后缀索引的条目
+type urlIndex struct {
+	exact    map[string][]int
+	prefixes *prefixTrie
+	hosts    map[string][]int
+}
+
+// newURLIndex 创建空索引
+func newURLIndex() *urlIndex {
+	return &urlIndex{
+		exact:    make(map[string][]int),
+		prefixes: newPrefixTrie(),
+		hosts:    make(map[string][]int),
+	}
+}
+
+// add 以条件的匹配值登记条目
+func (x *urlIndex) add(c *compiledCondition, idx int) {
+	switch c.cond.Type {
+	case rulespec.ConditionURLEquals:
+		x.exact[c.value] = append(x.exact[c.value], idx)
+	case rulespec.ConditionURLPrefix:
+		x.prefixes.insert(c.value, idx)
+	case rulespec.ConditionHost:
+		x.hosts[c.value] = append(x.hosts[c.value], idx)
+	}
+}
+
+// lookup 对条件被该 URL 满足的所有条目调用 fn，域名按 a.b.c、b.c、c 逐级查找
+func (x *urlIndex) lookup(url, host string, fn func(idx int)) {
+	for _, idx := range x.exact[url] {
+		fn(idx)
+	}
+	x.prefixes.walk(url, fn)
+	if len(x.hosts) == 0 {
+		return
+	}
+	for h := host; h != ""; {
+		for _, idx := range x.hosts[h] {
+			fn(idx)
+		}
+		dot := strings.IndexByte(h, '.')
+		if dot < 0 {
+			break
+		}
+		h = h[dot+1:]
+	}
+}
+
+// contains 判断索引中是否有条目被该 URL 满足
+func (x *urlIndex) contains(url, host string) bool {
+	found := false
+	x.lookup(url, host, func(int) {
+		found = true
+	})
+	return found
+}
+
+// prefixTrie 按字节索引 URL 前缀的字典树
+type prefixTrie struct {
+	children map[byte]*prefixTrie
+	rules    []int // 前缀恰好在此结束的规则下标
+}
+
+// newPrefixTrie 创建空字典树
+func newPrefixTrie() *prefixTrie {
+	return &prefixTrie{}
+}
+
+// insert 登记以 prefix 为前缀条件的规则
+func (t *prefixTrie) insert(prefix string, idx int) {
+	node := t
+	for i := 0; i < len(prefix); i++ {
+		if node.children == nil {
+			node.children = make(map[byte]*prefixTrie)
+		}
+		next, ok := node.children[prefix[i]]
+		if !ok {
+			next = &prefixTrie{}
+			node.children[prefix[i]] = next
+		}
+		node = next
+	}
+	node.rules = append(node.rules, idx)
+}
+
+// walk 沿 URL 逐字节下行，对前缀为 URL 前缀的所有规则调用 fn
+func (t *prefixTrie) walk(url string, fn func(idx int)) {
+	node := t
+	for i := 0; ; i++ {
+		for _, idx := range node.rules {
+			fn(idx)
+		}
+		if i == len(url) || node.children == nil {
+			return
+		}
+		next, ok := node.children[url[i]]
+		if !ok {
+			return
+		}
+		node = next
+	}
+}
+
+// normalizeHost 统一域名格式：小写并去掉末尾的点
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// hostOf 提取 URL 中的域名，解析失败时返回空字符串
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return normalizeHost(u.Hostname())
+}
+
+// matchHost 判断域名是否为 domain 本身或其子域名
+func matchHost(host, domain string) bool {
+	if host == "" || domain == "" {
+		return false
+	}
+	return host == domain || (strings.HasSuffix(host, domain) && host[len(host)-len(domain)-1] == '.')
+}
+
+// lowerHeaders 构造以小写名为键的 Header 视图，供 header* 条件不区分大小写地匹配
+func lowerHeaders(h domain.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
@@ -1,7 +1,8 @@
 package engine
 
 import (
-	"sort"
+	"maps"
+	"regexp"
 	"strings"
 	"sync"
 
@@ -19,61 +20,109 @@ type MatchedRule struct {
 
 // Engine 规则决策引擎
 type Engine struct {
-	config  *rulespec.Config
-	mu      sync.RWMutex
-	total   int64
-	matched int64
-	byRule  map[string]int64
-	cache   *regexutil.Cache
+	config    *rulespec.Config
+	compiled  *compiledConfig // 由 config 预处理得到的匹配结构
+	mu        sync.RWMutex
+	total     int64
+	matched   int64
+	byRule    map[string]int64
+	effective map[string]int64            // 规则产生实际修改的次数
+	degraded  map[string]int64            // 规则结果被降级放行的次数
+	variants  map[string]map[string]int64 // 规则各变体的分配次数
+	lastMatch map[string]int64            // 规则最近一次匹配时所在的页面加载序号
+	pageLoad  int64                       // 当前页面加载序号
+	cache     *regexutil.Cache
 }
 
-// New 创建一个新的规则引擎实例
+// New 创建一个新的规则引擎实例，无效的正则不报错，相应条件恒不满足；需要校验时使用 Validate 或 Update
 func New(config *rulespec.Config) *Engine {
+	cache := regexutil.New()
+	compiled, _ := compile(config, cache)
 	return &Engine{
-		config: config,
-		byRule: make(map[string]int64),
-		cache:  regexutil.New(),
+		config:    config,
+		compiled:  compiled,
+		byRule:    make(map[string]int64),
+		effective: make(map[string]int64),
+		degraded:  make(map[string]int64),
+		variants:  make(map[string]map[string]int64),
+		lastMatch: make(map[string]int64),
+		cache:     cache,
 	}
 }
 
-// Update 更新规则配置
-func (e *Engine) Update(config *rulespec.Config) {
+// Validate 校验已启用规则中的正则表达式（*Regex 条件与 regex: 匹配值），失败时返回 *domain.RuleError
+func Validate(config *rulespec.Config) error {
+	_, err := compile(config, regexutil.New())
+	return err
+}
+
+// Update 更新规则配置，并重新生成匹配结构；正则无法编译时返回 *domain.RuleError 并保留原配置
+func (e *Engine) Update(config *rulespec.Config) error {
+	compiled, err := compile(config, e.cache)
+	if err != nil {
+		return err
+	}
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	e.config = config
+	e.compiled = compiled
+	return nil
+}
+
+// Response 响应条件（statusCode、contentType、responseTime）评估所需的响应信息
+type Response struct {
+	StatusCode     int
+	ContentType    string
+	ResponseTimeMS int64 // 请求发出到收到响应头的耗时
 }
 
-// Eval 评估请求并返回匹配的规则列表 (按优先级降序)
+// Eval 评估请求并返回匹配的规则列表，按优先级降序、同优先级按配置顺序排列，截止到第一条命中的终止规则；
+// 没有响应信息，响应条件恒不满足，响应阶段已收到响应时使用 EvalResponse
 func (e *Engine) Eval(req *domain.Request, stage rulespec.Stage) []*MatchedRule {
+	return e.eval(req, stage, nil, false)
+}
+
+// EvalAll 同 Eval，但不在终止规则处截止，返回全部匹配的规则；
+// 用于查找不参与放行流程的行为（如应答认证质询的 auth 规则），避免被与之无关的终止规则遮蔽
+func (e *Engine) EvalAll(req *domain.Request, stage rulespec.Stage) []*MatchedRule {
+	return e.eval(req, stage, nil, true)
+}
+
+// EvalResponse 以收到的响应评估响应阶段的规则，规则的排列与截止方式同 Eval
+func (e *Engine) EvalResponse(req *domain.Request, res Response) []*MatchedRule {
+	return e.eval(req, rulespec.StageResponse, &res, false)
+}
+
+// eval 评估指定阶段的规则，res 为 nil 时响应条件恒不满足，all 为 true 时不在终止规则处截止
+func (e *Engine) eval(req *domain.Request, stage rulespec.Stage, res *Response, all bool) []*MatchedRule {
 	e.mu.RLock()
-	config := e.config
+	compiled := e.compiled
 	e.mu.RUnlock()
 
-	if config == nil || len(config.Rules) == 0 {
+	st := compiled.stages[stage]
+	if st == nil {
 		return nil
 	}
 
-	var matched []*MatchedRule
-	for i := range config.Rules {
-		rule := &config.Rules[i]
-		if !rule.Enabled || rule.Stage != stage {
-			continue
-		}
-
-		if e.matchRule(req, &rule.Match) {
-			matched = append(matched, &MatchedRule{Rule: rule})
-		}
+	ctx := &evalContext{req: req, res: res}
+	if st.headers {
+		ctx.headers = lowerHeaders(req.Headers)
 	}
-
-	if len(matched) == 0 {
-		return nil
+	if st.hosts {
+		ctx.host = hostOf(req.URL)
 	}
 
-	// 按优先级从大到小排序
-	sort.Slice(matched, func(i, j int) bool {
-		return matched[i].Rule.Priority > matched[j].Rule.Priority
-	})
-
+	// 候选规则按评估顺序排列，命中终止规则后不再评估其后的规则
+	var matched []*MatchedRule
+	for _, idx := range st.candidates(req.URL, ctx.host) {
+		cr := &st.rules[idx]
+		if e.matchRule(ctx, cr) {
+			matched = append(matched, &MatchedRule{Rule: cr.rule})
+			if cr.rule.Terminal && !all {
+				break
+			}
+		}
+	}
 	return matched
 }
 
@@ -86,28 +135,106 @@ func (e *Engine) RecordStats(matched []*MatchedRule) {
 		e.matched++
 		for _, m := range matched {
 			e.byRule[m.Rule.ID]++
+			e.lastMatch[m.Rule.ID] = e.pageLoad
 		}
 	}
 }
 
+// BeginPageLoad 标记新的页面加载开始，此前的匹配记录不再满足 pageLoad 范围的 ruleMatched 条件
+func (e *Engine) BeginPageLoad() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pageLoad++
+}
+
+// RecordEffect 记录规则产生了实际修改
+func (e *Engine) RecordEffect(ruleID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.effective[ruleID]++
+}
+
+// RecordDegraded 记录规则的处理结果下发失败并被降级放行
+func (e *Engine) RecordDegraded(ruleIDs []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range ruleIDs {
+		e.degraded[id]++
+	}
+}
+
+// RecordVariant 记录规则将请求分配到了指定变体
+func (e *Engine) RecordVariant(ruleID, variant string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.variants[ruleID] == nil {
+		e.variants[ruleID] = make(map[string]int64)
+	}
+	e.variants[ruleID][variant]++
+}
+
+// Coverage 基于当前配置中已启用的规则生成覆盖报告
+func (e *Engine) Coverage() domain.CoverageReport {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	report := domain.CoverageReport{
+		Rules:          []domain.RuleCoverage{},
+		NeverMatched:   []domain.RuleID{},
+		NoEffect:       []domain.RuleID{},
+		AlwaysDegraded: []domain.RuleID{},
+	}
+	if e.config == nil {
+		return report
+	}
+
+	for _, rule := range e.config.Rules {
+		if !rule.Enabled {
+			continue
+		}
+		c := domain.RuleCoverage{
+			RuleID:    domain.RuleID(rule.ID),
+			Name:      rule.Name,
+			Matched:   e.byRule[rule.ID],
+			Effective: e.effective[rule.ID],
+			Degraded:  e.degraded[rule.ID],
+			Variants:  maps.Clone(e.variants[rule.ID]),
+		}
+		report.Rules = append(report.Rules, c)
+
+		switch {
+		case c.Matched == 0:
+			report.NeverMatched = append(report.NeverMatched, c.RuleID)
+		case c.Degraded >= c.Matched:
+			report.AlwaysDegraded = append(report.AlwaysDegraded, c.RuleID)
+		case c.Effective == 0:
+			report.NoEffect = append(report.NoEffect, c.RuleID)
+		}
+	}
+	return report
+}
+
+// evalContext 单次评估的请求上下文
+type evalContext struct {
+	req     *domain.Request
+	res     *Response         // 响应阶段收到的响应，其他情况为 nil
+	headers map[string]string // 以小写名为键的 Header 视图
+	host    string            // 小写域名，仅在阶段内有 host 条件时解析
+}
+
 // matchRule 评估单个规则的匹配条件
-func (e *Engine) matchRule(req *domain.Request, m *rulespec.Match) bool {
+func (e *Engine) matchRule(ctx *evalContext, r *compiledRule) bool {
 	// allOf: 必须全部满足
-	if len(m.AllOf) > 0 {
-		for i := range m.AllOf {
-			if !e.evalCondition(req, &m.AllOf[i]) {
-				return false
-			}
+	for i := range r.allOf {
+		if !e.evalCondition(ctx, &r.allOf[i]) {
+			return false
 		}
 	}
-	// anyOf: 满足任一即可
-	if len(m.AnyOf) > 0 {
-		anyMatch := false
-		for i := range m.AnyOf {
-			if e.evalCondition(req, &m.AnyOf[i]) {
-				anyMatch = true
-				break
-			}
+	// anyOf: 满足任一即可，已索引的条件查表判断
+	if len(r.anyOf) > 0 {
+		anyMatch := r.anyIndex != nil && r.anyIndex.contains(ctx.req.URL, ctx.host)
+		for j := 0; !anyMatch && j < len(r.anyRest); j++ {
+			anyMatch = e.evalCondition(ctx, &r.anyOf[r.anyRest[j]])
 		}
 		if !anyMatch {
 			return false
@@ -117,7 +244,11 @@ func (e *Engine) matchRule(req *domain.Request, m *rulespec.Match) bool {
 }
 
 // evalCondition 评估单个条件
-func (e *Engine) evalCondition(req *domain.Request, c *rulespec.Condition) bool {
+func (e *Engine) evalCondition(ctx *evalContext, cc *compiledCondition) bool {
+	if cc.regex {
+		return e.evalRegexValue(ctx, cc)
+	}
+	req, c := ctx.req, cc.cond
 	switch c.Type {
 	case rulespec.ConditionURLEquals:
 		return req.URL == c.Value
@@ -128,7 +259,9 @@ func (e *Engine) evalCondition(req *domain.Request, c *rulespec.Condition) bool
 	case rulespec.ConditionURLContains:
 		return strings.Contains(req.URL, c.Value)
 	case rulespec.ConditionURLRegex:
-		return e.matchRegex(req.URL, c.Pattern)
+		return matchRegex(req.URL, cc.re)
+	case rulespec.ConditionHost:
+		return matchHost(ctx.host, cc.value)
 
 	case rulespec.ConditionMethod:
 		for _, v := range c.Values {
@@ -147,15 +280,15 @@ func (e *Engine) evalCondition(req *domain.Request, c *rulespec.Condition) bool
 		return false
 
 	case rulespec.ConditionHeaderExists:
-		return req.Headers.Get(c.Name) != ""
+		return ctx.headers[cc.name] != ""
 	case rulespec.ConditionHeaderNotExists:
-		return req.Headers.Get(c.Name) == ""
+		return ctx.headers[cc.name] == ""
 	case rulespec.ConditionHeaderEquals:
-		return req.Headers.Get(c.Name) == c.Value
+		return ctx.headers[cc.name] == c.Value
 	case rulespec.ConditionHeaderContains:
-		return strings.Contains(req.Headers.Get(c.Name), c.Value)
+		return strings.Contains(ctx.headers[cc.name], c.Value)
 	case rulespec.ConditionHeaderRegex:
-		return e.matchRegex(req.Headers.Get(c.Name), c.Pattern)
+		return matchRegex(ctx.headers[cc.name], cc.re)
 
 	case rulespec.ConditionQueryExists:
 		_, ok := req.Query[c.Name]
@@ -171,7 +304,7 @@ func (e *Engine) evalCondition(req *domain.Request, c *rulespec.Condition) bool
 		return ok && strings.Contains(v, c.Value)
 	case rulespec.ConditionQueryRegex:
 		v, ok := req.Query[c.Name]
-		return ok && e.matchRegex(v, c.Pattern)
+		return ok && matchRegex(v, cc.re)
 
 	case rulespec.ConditionCookieExists:
 		_, ok := req.Cookies[c.Name]
@@ -187,21 +320,69 @@ func (e *Engine) evalCondition(req *domain.Request, c *rulespec.Condition) bool
 		return ok && strings.Contains(v, c.Value)
 	case rulespec.ConditionCookieRegex:
 		v, ok := req.Cookies[c.Name]
-		return ok && e.matchRegex(v, c.Pattern)
+		return ok && matchRegex(v, cc.re)
 
 	case rulespec.ConditionBodyContains:
-		return strings.Contains(string(req.Body), c.Value)
+		return strings.Contains(req.MatchBody(), c.Value)
 	case rulespec.ConditionBodyRegex:
-		return e.matchRegex(string(req.Body), c.Pattern)
+		return matchRegex(req.MatchBody(), cc.re)
 	case rulespec.ConditionBodyJsonPath:
-		val, ok := e.evalJsonPath(string(req.Body), c.Path)
+		val, ok := e.evalJsonPath(req.MatchBody(), c.Path)
 		return ok && val == c.Value
 
+	case rulespec.ConditionRuleMatched:
+		return e.ruleMatched(c.Value, c.GetScope())
+
+	case rulespec.ConditionStatusCode:
+		return ctx.res != nil && inRanges(cc.ranges, int64(ctx.res.StatusCode))
+	case rulespec.ConditionContentType:
+		return ctx.res != nil && strings.Contains(strings.ToLower(ctx.res.ContentType), cc.value)
+	case rulespec.ConditionResponseTime:
+		return ctx.res != nil && inRanges(cc.ranges, ctx.res.ResponseTimeMS)
+
 	default:
 		return false
 	}
 }
 
+// evalRegexValue 评估匹配值以 regex: 开头的条件，正则作用于该条件原本比较的文本
+func (e *Engine) evalRegexValue(ctx *evalContext, cc *compiledCondition) bool {
+	req, c := ctx.req, cc.cond
+	switch c.Type {
+	case rulespec.ConditionURLEquals, rulespec.ConditionURLPrefix, rulespec.ConditionURLSuffix, rulespec.ConditionURLContains:
+		return matchRegex(req.URL, cc.re)
+	case rulespec.ConditionHeaderEquals, rulespec.ConditionHeaderContains:
+		v, ok := ctx.headers[cc.name]
+		return ok && matchRegex(v, cc.re)
+	case rulespec.ConditionQueryEquals, rulespec.ConditionQueryContains:
+		v, ok := req.Query[c.Name]
+		return ok && matchRegex(v, cc.re)
+	case rulespec.ConditionCookieEquals, rulespec.ConditionCookieContains:
+		v, ok := req.Cookies[c.Name]
+		return ok && matchRegex(v, cc.re)
+	case rulespec.ConditionBodyContains:
+		return matchRegex(req.MatchBody(), cc.re)
+	case rulespec.ConditionBodyJsonPath:
+		val, ok := e.evalJsonPath(req.MatchBody(), c.Path)
+		return ok && matchRegex(val, cc.re)
+	case rulespec.ConditionContentType:
+		return ctx.res != nil && matchRegex(ctx.res.ContentType, cc.re)
+	default:
+		return false
+	}
+}
+
+// ruleMatched 判断规则是否已在指定范围内匹配过
+func (e *Engine) ruleMatched(ruleID string, scope rulespec.MatchScope) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	load, ok := e.lastMatch[ruleID]
+	if !ok {
+		return false
+	}
+	return scope != rulespec.ScopePageLoad || load == e.pageLoad
+}
+
 // evalJsonPath 评估 JSON Path 表达式
 func (e *Engine) evalJsonPath(body, path string) (string, bool) {
 	if body == "" || path == "" {
@@ -218,13 +399,19 @@ func (e *Engine) evalJsonPath(body, path string) (string, bool) {
 	return result.String(), true
 }
 
-// matchRegex 正则匹配，使用缓存提升性能
-func (e *Engine) matchRegex(s, pattern string) bool {
-	re, err := e.cache.Get(pattern)
-	if err != nil {
-		return false
+// inRanges 判断 v 是否落在任一范围内，范围无效（为空）时视为不匹配
+func inRanges(ranges []rulespec.Range, v int64) bool {
+	for _, r := range ranges {
+		if r.Contains(v) {
+			return true
+		}
 	}
-	return re.MatchString(s)
+	return false
+}
+
+// matchRegex 使用预编译的正则匹配，正则无效时视为不匹配
+func matchRegex(s string, re *regexp.Regexp) bool {
+	return re != nil && re.MatchString(s)
 }
 
 // GetStats 获取统计信息
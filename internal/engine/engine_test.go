@@ -1,6 +1,9 @@
 package engine_test
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 
 	"cdpnetool/internal/engine"
@@ -457,6 +460,55 @@ func TestEval_BodyJsonPath(t *testing.T) {
 	}
 }
 
+func TestEval_RuleMatched(t *testing.T) {
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{
+		{
+			ID: "login", Name: "login", Enabled: true, Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/login"}}},
+		},
+		{
+			ID: "cart", Name: "cart", Enabled: true, Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLContains, Value: "/cart"},
+				{Type: rulespec.ConditionRuleMatched, Value: "login"},
+			}},
+		},
+		{
+			ID: "cart-page", Name: "cart-page", Enabled: true, Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLContains, Value: "/cart"},
+				{Type: rulespec.ConditionRuleMatched, Value: "login", Scope: rulespec.ScopePageLoad},
+			}},
+		},
+	}
+
+	eng := engine.New(cfg)
+	eval := func(url string) []string {
+		matched := eng.Eval(&domain.Request{ID: "req", URL: url, Method: "GET"}, rulespec.StageRequest)
+		eng.RecordStats(matched)
+		var ids []string
+		for _, m := range matched {
+			ids = append(ids, m.Rule.ID)
+		}
+		return ids
+	}
+
+	if got := eval("https://example.com/cart"); len(got) != 0 {
+		t.Errorf("got %v before login, want no matches", got)
+	}
+	eval("https://example.com/login")
+	if got := eval("https://example.com/cart"); len(got) != 2 {
+		t.Errorf("got %v after login, want cart and cart-page", got)
+	}
+
+	// 新的页面加载后仅会话范围的条件仍然满足
+	eng.BeginPageLoad()
+	if got := eval("https://example.com/cart"); len(got) != 1 || got[0] != "cart" {
+		t.Errorf("got %v after page load, want [cart]", got)
+	}
+}
+
 func TestEval_Priority(t *testing.T) {
 	cfg := rulespec.NewConfig("test")
 	cfg.Rules = []rulespec.Rule{
@@ -506,6 +558,74 @@ func TestEval_Priority(t *testing.T) {
 	}
 }
 
+func TestEval_Terminal(t *testing.T) {
+	rule := func(id string, priority int, terminal bool, url string) rulespec.Rule {
+		return rulespec.Rule{
+			ID: id, Name: id, Enabled: true, Priority: priority, Terminal: terminal, Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLPrefix, Value: url}}},
+		}
+	}
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{
+		rule("low", 0, false, "https://example.com/"),
+		rule("stop", 5, true, "https://example.com/api/"),
+		rule("tie", 5, false, "https://example.com/"),
+		rule("high", 10, false, "https://example.com/"),
+	}
+	eng := engine.New(cfg)
+
+	ids := func(url string) []string {
+		var out []string
+		for _, m := range eng.Eval(&domain.Request{URL: url, Method: "GET"}, rulespec.StageRequest) {
+			out = append(out, m.Rule.ID)
+		}
+		return out
+	}
+	// 终止规则之后（同优先级中配置靠后的规则与更低优先级的规则）不再匹配
+	if got := ids("https://example.com/api/users"); !reflect.DeepEqual(got, []string{"high", "stop"}) {
+		t.Errorf("got %v, want [high stop]", got)
+	}
+	// 终止规则未命中时不影响其他规则
+	if got := ids("https://example.com/page"); !reflect.DeepEqual(got, []string{"high", "tie", "low"}) {
+		t.Errorf("got %v, want [high tie low]", got)
+	}
+
+	// EvalAll 不在终止规则处截止，顺序与 Eval 相同
+	var all []string
+	for _, m := range eng.EvalAll(&domain.Request{URL: "https://example.com/api/users", Method: "GET"}, rulespec.StageRequest) {
+		all = append(all, m.Rule.ID)
+	}
+	if !reflect.DeepEqual(all, []string{"high", "stop", "tie", "low"}) {
+		t.Errorf("EvalAll got %v, want [high stop tie low]", all)
+	}
+}
+
+func TestEval_PriorityIndexedAndUnindexed(t *testing.T) {
+	rule := func(id string, priority int, cond rulespec.ConditionType, value string) rulespec.Rule {
+		return rulespec.Rule{
+			ID: id, Name: id, Enabled: true, Priority: priority, Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: cond, Value: value}}},
+		}
+	}
+	// urlPrefix 规则经索引命中，urlContains 规则每次都评估，两者合并后仍按优先级排列
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{
+		rule("contains-low", 1, rulespec.ConditionURLContains, "example.com"),
+		rule("prefix-high", 10, rulespec.ConditionURLPrefix, "https://example.com/"),
+		rule("contains-high", 20, rulespec.ConditionURLContains, "/api/"),
+		rule("prefix-low", 0, rulespec.ConditionURLPrefix, "https://example.com/api/"),
+	}
+	eng := engine.New(cfg)
+
+	var got []string
+	for _, m := range eng.Eval(&domain.Request{URL: "https://example.com/api/users", Method: "GET"}, rulespec.StageRequest) {
+		got = append(got, m.Rule.ID)
+	}
+	if want := []string{"contains-high", "prefix-high", "contains-low", "prefix-low"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func TestEval_Disabled(t *testing.T) {
 	cfg := rulespec.NewConfig("test")
 	cfg.Rules = []rulespec.Rule{
@@ -594,6 +714,423 @@ func TestEval_AnyOf(t *testing.T) {
 	}
 }
 
+func TestEval_HeaderNameCaseInsensitive(t *testing.T) {
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{
+		{
+			ID:      "rule1",
+			Name:    "test rule",
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match: rulespec.Match{
+				AllOf: []rulespec.Condition{
+					{Type: rulespec.ConditionHeaderEquals, Name: "Content-Type", Value: "application/json"},
+					{Type: rulespec.ConditionHeaderRegex, Name: "AUTHORIZATION", Pattern: "^Bearer "},
+				},
+			},
+		},
+	}
+
+	eng := engine.New(cfg)
+	req := &domain.Request{
+		ID:      "req1",
+		URL:     "https://example.com",
+		Method:  "GET",
+		Headers: make(domain.Header),
+	}
+	// CDP 上报的 HTTP/2 Header 名均为小写
+	req.Headers.Set("content-type", "application/json")
+	req.Headers.Set("authorization", "Bearer token")
+
+	matched := eng.Eval(req, rulespec.StageRequest)
+	if len(matched) != 1 {
+		t.Errorf("got %d matches, want 1", len(matched))
+	}
+}
+
+func TestEval_URLPrefixIndex(t *testing.T) {
+	prefixRule := func(id, prefix string) rulespec.Rule {
+		return rulespec.Rule{
+			ID:      id,
+			Name:    id,
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match: rulespec.Match{
+				AllOf: []rulespec.Condition{
+					{Type: rulespec.ConditionURLPrefix, Value: prefix},
+				},
+			},
+		}
+	}
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{
+		prefixRule("api", "https://example.com/api/"),
+		prefixRule("users", "https://example.com/api/users"),
+		prefixRule("other", "https://other.com/"),
+		// 同一前缀下的多条规则
+		prefixRule("api2", "https://example.com/api/"),
+		// 无前缀条件的规则始终参与评估
+		{
+			ID:      "contains",
+			Name:    "contains",
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match: rulespec.Match{
+				AllOf: []rulespec.Condition{
+					{Type: rulespec.ConditionURLContains, Value: "users"},
+				},
+			},
+		},
+		// 前缀与其他条件组合时，其他条件仍需满足
+		{
+			ID:      "post",
+			Name:    "post",
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match: rulespec.Match{
+				AllOf: []rulespec.Condition{
+					{Type: rulespec.ConditionURLPrefix, Value: "https://example.com/"},
+					{Type: rulespec.ConditionMethod, Values: []string{"POST"}},
+				},
+			},
+		},
+	}
+
+	eng := engine.New(cfg)
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"https://example.com/api/users/1", []string{"api", "users", "api2", "contains"}},
+		{"https://example.com/api/orders", []string{"api", "api2"}},
+		{"https://example.com/api", nil},
+		{"https://other.com/users", []string{"other", "contains"}},
+	}
+	for _, tt := range tests {
+		req := &domain.Request{ID: "req1", URL: tt.url, Method: "GET"}
+		matched := eng.Eval(req, rulespec.StageRequest)
+		var got []string
+		for _, m := range matched {
+			got = append(got, m.Rule.ID)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.url, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.url, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEval_Host(t *testing.T) {
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{
+		{
+			ID:      "rule1",
+			Name:    "test rule",
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match: rulespec.Match{
+				AllOf: []rulespec.Condition{
+					{Type: rulespec.ConditionHost, Value: "Example.com"},
+				},
+			},
+		},
+	}
+
+	eng := engine.New(cfg)
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/a", true},
+		{"https://ads.EXAMPLE.com:8443/a", true},
+		{"https://notexample.com/a", false},
+		{"https://example.com.evil.net/a", false},
+		{"https://other.com/?u=example.com", false},
+	}
+	for _, tt := range tests {
+		req := &domain.Request{ID: "req1", URL: tt.url, Method: "GET"}
+		if got := len(eng.Eval(req, rulespec.StageRequest)) == 1; got != tt.want {
+			t.Errorf("%s: got match %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestEval_LargeBlockList(t *testing.T) {
+	cfg := rulespec.NewConfig("test")
+	// 单条规则的 anyOf 中包含大量拦截条目
+	list := rulespec.Rule{
+		ID:      "list",
+		Name:    "block list",
+		Enabled: true,
+		Stage:   rulespec.StageRequest,
+		Match: rulespec.Match{
+			AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionMethod, Values: []string{"GET"}},
+			},
+		},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock}},
+	}
+	for i := 0; i < 5000; i++ {
+		list.Match.AnyOf = append(list.Match.AnyOf,
+			rulespec.Condition{Type: rulespec.ConditionHost, Value: fmt.Sprintf("tracker%d.net", i)},
+			rulespec.Condition{Type: rulespec.ConditionURLEquals, Value: fmt.Sprintf("https://cdn.com/pixel%d.gif", i)},
+		)
+	}
+	list.Match.AnyOf = append(list.Match.AnyOf, rulespec.Condition{Type: rulespec.ConditionURLContains, Value: "/beacon"})
+	cfg.Rules = append(cfg.Rules, list)
+	// 每个条目一条规则
+	for i := 0; i < 5000; i++ {
+		cfg.Rules = append(cfg.Rules, rulespec.Rule{
+			ID:      fmt.Sprintf("host%d", i),
+			Name:    "block host",
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match: rulespec.Match{
+				AllOf: []rulespec.Condition{
+					{Type: rulespec.ConditionHost, Value: fmt.Sprintf("ads%d.com", i)},
+				},
+			},
+		})
+	}
+
+	eng := engine.New(cfg)
+	tests := []struct {
+		url    string
+		method string
+		want   []string
+	}{
+		{"https://x.tracker42.net/t.js", "GET", []string{"list"}},
+		{"https://cdn.com/pixel4999.gif", "GET", []string{"list"}},
+		{"https://cdn.com/pixel5000.gif", "GET", nil},
+		{"https://site.com/beacon?id=1", "GET", []string{"list"}},
+		{"https://tracker42.net/t.js", "POST", nil},
+		{"https://img.ads123.com/a.png", "GET", []string{"host123"}},
+		{"https://ads123.com.cn/a.png", "GET", nil},
+	}
+	for _, tt := range tests {
+		req := &domain.Request{ID: "req1", URL: tt.url, Method: tt.method}
+		var got []string
+		for _, m := range eng.Eval(req, rulespec.StageRequest) {
+			got = append(got, m.Rule.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s %s: got %v, want %v", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestUpdate_Recompiles(t *testing.T) {
+	cfg1 := rulespec.NewConfig("test1")
+	cfg1.Rules = []rulespec.Rule{
+		{
+			ID:      "rule1",
+			Name:    "test rule",
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match: rulespec.Match{
+				AllOf: []rulespec.Condition{
+					{Type: rulespec.ConditionURLRegex, Pattern: `/v1/`},
+				},
+			},
+		},
+	}
+	cfg2 := rulespec.NewConfig("test2")
+	cfg2.Rules = []rulespec.Rule{
+		{
+			ID:      "rule2",
+			Name:    "test rule",
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match: rulespec.Match{
+				AllOf: []rulespec.Condition{
+					{Type: rulespec.ConditionURLRegex, Pattern: `/v2/`},
+				},
+			},
+		},
+	}
+
+	eng := engine.New(cfg1)
+	eng.Update(cfg2)
+
+	v1 := &domain.Request{ID: "req1", URL: "https://example.com/v1/a", Method: "GET"}
+	if matched := eng.Eval(v1, rulespec.StageRequest); matched != nil {
+		t.Errorf("got %d matches for old rule, want 0", len(matched))
+	}
+	v2 := &domain.Request{ID: "req2", URL: "https://example.com/v2/a", Method: "GET"}
+	matched := eng.Eval(v2, rulespec.StageRequest)
+	if len(matched) != 1 || matched[0].Rule.ID != "rule2" {
+		t.Errorf("got %v, want rule2", matched)
+	}
+}
+
+func TestEval_InvalidRegex(t *testing.T) {
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{
+		{
+			ID:      "rule1",
+			Name:    "test rule",
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match: rulespec.Match{
+				AllOf: []rulespec.Condition{
+					{Type: rulespec.ConditionURLRegex, Pattern: `([`},
+				},
+			},
+		},
+	}
+
+	eng := engine.New(cfg)
+	req := &domain.Request{ID: "req1", URL: "https://example.com/([", Method: "GET"}
+	if matched := eng.Eval(req, rulespec.StageRequest); matched != nil {
+		t.Errorf("got %d matches, want 0", len(matched))
+	}
+}
+
+func TestEval_RegexValue(t *testing.T) {
+	req := &domain.Request{
+		ID:      "req1",
+		URL:     "https://example.com/api/v2/users?id=42",
+		Method:  "POST",
+		Headers: domain.Header{"Authorization": "Bearer abc.def"},
+		Query:   map[string]string{"id": "42"},
+		Cookies: map[string]string{"session": "s-123"},
+		Body:    []byte(`{"user":{"role":"admin"}}`),
+	}
+	tests := []struct {
+		name string
+		cond rulespec.Condition
+		want bool
+	}{
+		{"URL 等于", rulespec.Condition{Type: rulespec.ConditionURLEquals, Value: `regex:/api/v\d+/`}, true},
+		{"URL 前缀不匹配", rulespec.Condition{Type: rulespec.ConditionURLPrefix, Value: `regex:^http://`}, false},
+		{"Header 包含", rulespec.Condition{Type: rulespec.ConditionHeaderContains, Name: "authorization", Value: `regex:^Bearer \S+$`}, true},
+		{"Header 不存在", rulespec.Condition{Type: rulespec.ConditionHeaderEquals, Name: "X-Missing", Value: `regex:.*`}, false},
+		{"Query 等于", rulespec.Condition{Type: rulespec.ConditionQueryEquals, Name: "id", Value: `regex:^\d+$`}, true},
+		{"Cookie 包含", rulespec.Condition{Type: rulespec.ConditionCookieContains, Name: "session", Value: `regex:^s-`}, true},
+		{"Body 包含", rulespec.Condition{Type: rulespec.ConditionBodyContains, Value: `regex:"role":"(admin|owner)"`}, true},
+		{"JSON Path 值", rulespec.Condition{Type: rulespec.ConditionBodyJsonPath, Path: "user.role", Value: `regex:^adm`}, true},
+		{"无前缀按原文比较", rulespec.Condition{Type: rulespec.ConditionURLContains, Value: `/api/v\d+/`}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := rulespec.NewConfig("test")
+			cfg.Rules = []rulespec.Rule{{
+				ID: "rule1", Name: "test rule", Enabled: true, Stage: rulespec.StageRequest,
+				Match: rulespec.Match{AllOf: []rulespec.Condition{tt.cond}},
+			}}
+			eng := engine.New(cfg)
+			if got := eng.Eval(req, rulespec.StageRequest) != nil; got != tt.want {
+				t.Errorf("got match %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalResponse(t *testing.T) {
+	req := &domain.Request{ID: "req1", URL: "https://example.com/api/users", Method: "GET"}
+	res := engine.Response{StatusCode: 503, ContentType: "application/problem+JSON; charset=utf-8", ResponseTimeMS: 1200}
+	tests := []struct {
+		name string
+		cond rulespec.Condition
+		want bool
+	}{
+		{"状态码类别", rulespec.Condition{Type: rulespec.ConditionStatusCode, Value: "5xx"}, true},
+		{"状态码列表", rulespec.Condition{Type: rulespec.ConditionStatusCode, Value: "404, 500-502"}, false},
+		{"Content-Type 包含", rulespec.Condition{Type: rulespec.ConditionContentType, Value: "json"}, true},
+		{"Content-Type 正则", rulespec.Condition{Type: rulespec.ConditionContentType, Value: `regex:^text/`}, false},
+		{"耗时超过", rulespec.Condition{Type: rulespec.ConditionResponseTime, Value: ">1000"}, true},
+		{"耗时区间", rulespec.Condition{Type: rulespec.ConditionResponseTime, Value: "0-500"}, false},
+		{"范围无效", rulespec.Condition{Type: rulespec.ConditionStatusCode, Value: "server errors"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := rulespec.NewConfig("test")
+			cfg.Rules = []rulespec.Rule{{
+				ID: "rule1", Name: "test rule", Enabled: true, Stage: rulespec.StageResponse,
+				Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLPrefix, Value: "https://example.com/api/"}, tt.cond}},
+			}}
+			eng := engine.New(cfg)
+			if got := eng.EvalResponse(req, res) != nil; got != tt.want {
+				t.Errorf("got match %v, want %v", got, tt.want)
+			}
+			// 没有响应信息时响应条件恒不满足
+			if eng.Eval(req, rulespec.StageResponse) != nil {
+				t.Error("Eval without response should not match response conditions")
+			}
+		})
+	}
+
+	cfg := rulespec.NewConfig("invalid")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "broken", Name: "broken", Enabled: true, Stage: rulespec.StageResponse,
+		Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionResponseTime, Value: "slow"}}},
+	}}
+	var ruleErr *domain.RuleError
+	if err := engine.Validate(cfg); !errors.As(err, &ruleErr) || ruleErr.RuleID != "broken" {
+		t.Errorf("Validate() error = %v, want RuleError for rule broken", err)
+	}
+}
+
+func TestUpdate_InvalidRegex(t *testing.T) {
+	valid := rulespec.NewConfig("valid")
+	valid.Rules = []rulespec.Rule{{
+		ID: "valid", Name: "valid", Enabled: true, Stage: rulespec.StageRequest,
+		Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}},
+	}}
+	eng := engine.New(valid)
+
+	invalid := rulespec.NewConfig("invalid")
+	invalid.Rules = []rulespec.Rule{
+		// 已停用的规则不参与校验
+		{ID: "disabled", Name: "disabled", Enabled: false, Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLRegex, Pattern: `(`}}}},
+		{ID: "broken", Name: "broken", Enabled: true, Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AnyOf: []rulespec.Condition{{Type: rulespec.ConditionHeaderEquals, Name: "X", Value: `regex:[`}}}},
+	}
+	err := eng.Update(invalid)
+	var ruleErr *domain.RuleError
+	if !errors.As(err, &ruleErr) || ruleErr.RuleID != "broken" || errors.Is(err, domain.ErrInvalidConfig) {
+		t.Fatalf("Update() error = %v, want RuleError for rule broken", err)
+	}
+	if err := engine.Validate(invalid); err == nil {
+		t.Error("Validate() error = nil, want invalid regex error")
+	}
+
+	// redirect 与 mapLocal 行为的正则同样需要校验
+	redirect := rulespec.NewConfig("redirect")
+	redirect.Rules = []rulespec.Rule{{ID: "redirect", Name: "redirect", Enabled: true, Stage: rulespec.StageRequest,
+		Actions: []rulespec.Action{{Type: rulespec.ActionRedirect, Pattern: `(`, Value: "/"}}}}
+	if err := engine.Validate(redirect); !errors.As(err, &ruleErr) || ruleErr.RuleID != "redirect" {
+		t.Errorf("Validate() error = %v, want RuleError for rule redirect", err)
+	}
+	mapLocal := rulespec.NewConfig("mapLocal")
+	mapLocal.Rules = []rulespec.Rule{{ID: "mapLocal", Name: "mapLocal", Enabled: true, Stage: rulespec.StageRequest,
+		Actions: []rulespec.Action{{Type: rulespec.ActionMapLocal, Pattern: `[`, Value: "/tmp"}}}}
+	if err := engine.Validate(mapLocal); !errors.As(err, &ruleErr) || ruleErr.RuleID != "mapLocal" {
+		t.Errorf("Validate() error = %v, want RuleError for rule mapLocal", err)
+	}
+
+	// script 行为的脚本语法同样需要校验
+	script := rulespec.NewConfig("script")
+	script.Rules = []rulespec.Rule{{ID: "script", Name: "script", Enabled: true, Stage: rulespec.StageResponse,
+		Actions: []rulespec.Action{{Type: rulespec.ActionScript, Value: "({"}}}}
+	if err := engine.Validate(script); !errors.As(err, &ruleErr) || ruleErr.RuleID != "script" {
+		t.Errorf("Validate() error = %v, want RuleError for rule script", err)
+	}
+
+	// 校验失败时保留原配置
+	req := &domain.Request{ID: "req1", URL: "https://example.com/api", Method: "GET"}
+	if matched := eng.Eval(req, rulespec.StageRequest); len(matched) != 1 || matched[0].Rule.ID != "valid" {
+		t.Errorf("got %d matches, want previous config kept", len(matched))
+	}
+}
+
 func TestRecordStats(t *testing.T) {
 	cfg := rulespec.NewConfig("test")
 	cfg.Rules = []rulespec.Rule{
@@ -647,3 +1184,65 @@ func TestGetStats(t *testing.T) {
 		t.Errorf("got byRule len %d, want 0", len(byRule))
 	}
 }
+
+func TestCoverage(t *testing.T) {
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{
+		{ID: "never", Name: "never", Enabled: true, Stage: rulespec.StageRequest},
+		{ID: "noop", Name: "noop", Enabled: true, Stage: rulespec.StageRequest},
+		{ID: "degraded", Name: "degraded", Enabled: true, Stage: rulespec.StageRequest},
+		{ID: "ok", Name: "ok", Enabled: true, Stage: rulespec.StageRequest},
+		{ID: "disabled", Name: "disabled", Enabled: false, Stage: rulespec.StageRequest},
+	}
+	eng := engine.New(cfg)
+
+	rule := func(id string) *engine.MatchedRule {
+		for i := range cfg.Rules {
+			if cfg.Rules[i].ID == id {
+				return &engine.MatchedRule{Rule: &cfg.Rules[i]}
+			}
+		}
+		return nil
+	}
+	eng.RecordStats([]*engine.MatchedRule{rule("noop"), rule("degraded"), rule("ok")})
+	eng.RecordStats([]*engine.MatchedRule{rule("degraded"), rule("ok")})
+	eng.RecordEffect("degraded")
+	eng.RecordEffect("ok")
+	eng.RecordDegraded([]string{"degraded", "ok"})
+	eng.RecordDegraded([]string{"degraded"})
+	eng.RecordVariant("ok", "canary")
+	eng.RecordVariant("ok", "baseline")
+	eng.RecordVariant("ok", "baseline")
+
+	report := eng.Coverage()
+	if len(report.Rules) != 4 {
+		t.Fatalf("got %d rules, want 4 enabled rules", len(report.Rules))
+	}
+
+	check := func(name string, got []domain.RuleID, want ...domain.RuleID) {
+		if len(got) != len(want) {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: got %v, want %v", name, got, want)
+			}
+		}
+	}
+	check("NeverMatched", report.NeverMatched, "never")
+	check("NoEffect", report.NoEffect, "noop")
+	check("AlwaysDegraded", report.AlwaysDegraded, "degraded")
+
+	for _, c := range report.Rules {
+		if c.RuleID == "ok" && (c.Matched != 2 || c.Effective != 1 || c.Degraded != 1) {
+			t.Errorf("unexpected coverage for ok: %+v", c)
+		}
+		if c.RuleID == "ok" && (c.Variants["canary"] != 1 || c.Variants["baseline"] != 2) {
+			t.Errorf("unexpected variants for ok: %v", c.Variants)
+		}
+		if c.RuleID == "noop" && c.Variants != nil {
+			t.Errorf("got variants %v for rule without variants", c.Variants)
+		}
+	}
+}
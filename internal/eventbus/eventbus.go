@@ -0,0 +1,160 @@
+// Package eventbus 为会话事件分配单调递增的序号并缓存最近的事件，
+// 订阅者可从指定序号之后开始接收，重连或晚到的订阅者不会漏掉期间产生的事件
+package eventbus
+
+import (
+	"context"
+	"sync"
+
+	"cdpnetool/pkg/domain"
+)
+
+// DefaultBufferSize 默认缓存的最近事件数
+const DefaultBufferSize = 1024
+
+// Bus 带回放缓冲的会话事件总线
+type Bus struct {
+	mu       sync.Mutex
+	capacity int
+	buf      []domain.NetworkEvent // 最近的事件，按序号递增
+	nextSeq  uint64
+	subs     map[chan domain.NetworkEvent]struct{}
+	done     chan struct{} // 总线关闭时关闭
+	closed   bool
+}
+
+// New 创建事件总线，capacity 为缓存的最近事件数，<=0 时使用默认值
+func New(capacity int) *Bus {
+	if capacity <= 0 {
+		capacity = DefaultBufferSize
+	}
+	return &Bus{
+		capacity: capacity,
+		subs:     make(map[chan domain.NetworkEvent]struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Publish 为事件分配序号、写入缓冲并分发给所有订阅者，返回带序号的事件。
+// 订阅者通道已满时丢弃该事件，订阅者可按序号的断档从最后收到的序号重新订阅
+func (b *Bus) Publish(evt domain.NetworkEvent) domain.NetworkEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return evt
+	}
+
+	b.nextSeq++
+	evt.Seq = b.nextSeq
+	b.buf = append(b.buf, evt)
+	if len(b.buf) > b.capacity {
+		b.buf[0] = domain.NetworkEvent{}
+		b.buf = b.buf[1:]
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+	return evt
+}
+
+// Subscribe 订阅序号大于 after 的事件：先回放缓冲中的事件，再接收新事件。
+// after 为 0 时回放缓冲中的全部事件；ctx 结束或总线关闭时通道被关闭
+func (b *Bus) Subscribe(ctx context.Context, after uint64) <-chan domain.NetworkEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan domain.NetworkEvent, b.capacity)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	for _, evt := range b.replay(after) {
+		ch <- evt
+	}
+	b.subs[ch] = struct{}{}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.unsubscribe(ch)
+		case <-b.done:
+		}
+	}()
+	return ch
+}
+
+// Seq 返回最近一个事件的序号，尚无事件时为 0
+func (b *Bus) Seq() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextSeq
+}
+
+// Find 返回缓冲中 ID 为 id 的最近一个事件，已移出缓冲或不存在时返回 false
+func (b *Bus) Find(id string) (domain.NetworkEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := len(b.buf) - 1; i >= 0; i-- {
+		if b.buf[i].ID == id {
+			return b.buf[i], true
+		}
+	}
+	return domain.NetworkEvent{}, false
+}
+
+// Pump 将通道中的事件发布到总线，直到通道关闭或 ctx 结束，随后关闭总线
+func (b *Bus) Pump(ctx context.Context, events <-chan domain.NetworkEvent) {
+	defer b.Close()
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			b.Publish(evt)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close 关闭总线及所有订阅通道
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.done)
+	for ch := range b.subs {
+		close(ch)
+	}
+	b.subs = nil
+	b.buf = nil
+}
+
+// replay 返回缓冲中序号大于 after 的事件（调用方需持有锁）
+func (b *Bus) replay(after uint64) []domain.NetworkEvent {
+	if len(b.buf) == 0 || after >= b.nextSeq {
+		return nil
+	}
+	first := b.buf[0].Seq
+	if after < first {
+		return b.buf
+	}
+	return b.buf[after-first+1:]
+}
+
+// unsubscribe 移除并关闭订阅通道
+func (b *Bus) unsubscribe(ch chan domain.NetworkEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,138 @@
+package eventbus_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/eventbus"
+	"cdpnetool/pkg/domain"
+)
+
+// drain 读取通道中当前可用的事件序号
+func drain(ch <-chan domain.NetworkEvent) []uint64 {
+	var seqs []uint64
+	for {
+		select {
+		case evt, ok := <-ch:
+			if !ok {
+				return seqs
+			}
+			seqs = append(seqs, evt.Seq)
+		case <-time.After(50 * time.Millisecond):
+			return seqs
+		}
+	}
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSubscribeReplay(t *testing.T) {
+	b := eventbus.New(3)
+	defer b.Close()
+	for i := 0; i < 5; i++ {
+		if evt := b.Publish(domain.NetworkEvent{ID: "e"}); evt.Seq != uint64(i+1) {
+			t.Fatalf("got seq %d, want %d", evt.Seq, i+1)
+		}
+	}
+
+	tests := []struct {
+		after uint64
+		want  []uint64
+	}{
+		{0, []uint64{3, 4, 5}}, // 早于缓冲的部分已丢弃
+		{3, []uint64{4, 5}},
+		{5, nil},
+		{9, nil},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, tt := range tests {
+		if got := drain(b.Subscribe(ctx, tt.after)); !equal(got, tt.want) {
+			t.Errorf("Subscribe(%d) got %v, want %v", tt.after, got, tt.want)
+		}
+	}
+	if b.Seq() != 5 {
+		t.Errorf("got Seq %d, want 5", b.Seq())
+	}
+}
+
+func TestFind(t *testing.T) {
+	b := eventbus.New(2)
+	defer b.Close()
+	b.Publish(domain.NetworkEvent{ID: "a"})
+	b.Publish(domain.NetworkEvent{ID: "b"})
+	b.Publish(domain.NetworkEvent{ID: "b"})
+
+	if evt, ok := b.Find("b"); !ok || evt.Seq != 3 {
+		t.Errorf("Find(b) got %+v, %v, want the latest event with seq 3", evt, ok)
+	}
+	// a 已移出缓冲
+	if _, ok := b.Find("a"); ok {
+		t.Error("Find(a) should miss after the event left the buffer")
+	}
+}
+
+func TestSubscribeLive(t *testing.T) {
+	b := eventbus.New(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	first := b.Subscribe(ctx, 0)
+	b.Publish(domain.NetworkEvent{ID: "e1"})
+
+	// 晚到的订阅者先回放缓冲中的事件
+	second := b.Subscribe(context.Background(), 0)
+	b.Publish(domain.NetworkEvent{ID: "e2"})
+	if got := drain(first); !equal(got, []uint64{1, 2}) {
+		t.Errorf("first subscriber got %v, want [1 2]", got)
+	}
+	if got := drain(second); !equal(got, []uint64{1, 2}) {
+		t.Errorf("second subscriber got %v, want [1 2]", got)
+	}
+
+	// 取消后通道关闭
+	cancel()
+	select {
+	case _, ok := <-first:
+		if ok {
+			t.Error("expected closed channel after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+
+	b.Close()
+	if _, ok := <-second; ok {
+		t.Error("expected closed channel after Close")
+	}
+	if _, ok := <-b.Subscribe(context.Background(), 0); ok {
+		t.Error("expected closed channel when subscribing to a closed bus")
+	}
+}
+
+func TestPump(t *testing.T) {
+	b := eventbus.New(0)
+	events := make(chan domain.NetworkEvent, 2)
+	ch := b.Subscribe(context.Background(), 0)
+	events <- domain.NetworkEvent{ID: "e1"}
+	events <- domain.NetworkEvent{ID: "e2"}
+	close(events)
+	b.Pump(context.Background(), events)
+
+	var ids []string
+	for evt := range ch {
+		ids = append(ids, evt.ID)
+	}
+	if len(ids) != 2 || ids[0] != "e1" || ids[1] != "e2" {
+		t.Errorf("got %v, want [e1 e2]", ids)
+	}
+}
@@ -0,0 +1,239 @@
+// Package eventstream 提供带确认机制的有界事件流，实现 domain.EventIterator
+package eventstream
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+// DefaultBufferSize 默认缓冲容量
+const DefaultBufferSize = 1024
+
+// item 流中的单个事件
+type item struct {
+	seq         uint64
+	attempts    int
+	deliveredAt time.Time
+	event       domain.NetworkEvent
+}
+
+// Stream 有界的确认式事件流
+type Stream struct {
+	mu         sync.Mutex
+	capacity   int
+	ackTimeout time.Duration
+	pending    []*item          // 等待投递的事件（FIFO）
+	inflight   map[uint64]*item // 已投递未确认的事件
+	nextSeq    uint64
+	notify     chan struct{} // 有新事件可投递时发出信号
+	done       chan struct{} // 关闭时关闭
+	closed     bool
+	delivered  int64
+	acked      int64
+	dropped    int64
+}
+
+// New 创建事件流
+func New(opts domain.EventStreamOptions) *Stream {
+	capacity := opts.BufferSize
+	if capacity <= 0 {
+		capacity = DefaultBufferSize
+	}
+	return &Stream{
+		capacity:   capacity,
+		ackTimeout: time.Duration(opts.AckTimeoutMS) * time.Millisecond,
+		inflight:   make(map[uint64]*item),
+		notify:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// Publish 写入事件，缓冲已满返回 ErrStreamFull，已关闭返回 ErrStreamClosed
+func (s *Stream) Publish(evt domain.NetworkEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return domain.ErrStreamClosed
+	}
+	if len(s.pending)+len(s.inflight) >= s.capacity {
+		s.dropped++
+		return domain.ErrStreamFull
+	}
+
+	s.nextSeq++
+	s.pending = append(s.pending, &item{seq: s.nextSeq, event: evt})
+	s.signal()
+	return nil
+}
+
+// Next 阻塞等待下一个事件
+func (s *Stream) Next(ctx context.Context) (domain.EventDelivery, error) {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return domain.EventDelivery{}, domain.ErrStreamClosed
+		}
+		s.requeueExpired()
+		if len(s.pending) > 0 {
+			it := s.pending[0]
+			s.pending[0] = nil
+			s.pending = s.pending[1:]
+			it.attempts++
+			it.deliveredAt = time.Now()
+			s.inflight[it.seq] = it
+			s.delivered++
+			if len(s.pending) > 0 {
+				// 唤醒其他可能在等待的消费者
+				s.signal()
+			}
+			d := domain.EventDelivery{Seq: it.seq, Attempts: it.attempts, Event: it.event}
+			s.mu.Unlock()
+			return d, nil
+		}
+		wait := s.nextExpiry()
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return domain.EventDelivery{}, ctx.Err()
+		case <-s.notify:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// Ack 确认事件
+func (s *Stream) Ack(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.inflight[seq]; !ok {
+		return domain.ErrUnknownDelivery
+	}
+	delete(s.inflight, seq)
+	s.acked++
+	return nil
+}
+
+// Nack 拒绝事件，事件回到队首等待重新投递
+func (s *Stream) Nack(seq uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, ok := s.inflight[seq]
+	if !ok {
+		return domain.ErrUnknownDelivery
+	}
+	delete(s.inflight, seq)
+	s.pending = append([]*item{it}, s.pending...)
+	s.signal()
+	return nil
+}
+
+// Stats 返回统计信息
+func (s *Stream) Stats() domain.EventStreamStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return domain.EventStreamStats{
+		Pending:   len(s.pending),
+		InFlight:  len(s.inflight),
+		Delivered: s.delivered,
+		Acked:     s.acked,
+		Dropped:   s.dropped,
+	}
+}
+
+// Close 关闭事件流并唤醒阻塞的 Next
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.pending = nil
+	s.inflight = make(map[uint64]*item)
+	close(s.notify)
+	close(s.done)
+	return nil
+}
+
+// Done 返回事件流关闭时关闭的通道
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// IsClosed 判断事件流是否已关闭
+func (s *Stream) IsClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// signal 非阻塞地通知等待者（调用方需持有锁）
+func (s *Stream) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// requeueExpired 将确认超时的事件放回队首（调用方需持有锁）
+func (s *Stream) requeueExpired() {
+	if s.ackTimeout <= 0 || len(s.inflight) == 0 {
+		return
+	}
+	now := time.Now()
+	var expired []*item
+	for seq, it := range s.inflight {
+		if now.Sub(it.deliveredAt) >= s.ackTimeout {
+			expired = append(expired, it)
+			delete(s.inflight, seq)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+	// 按序号排序后放回队首，保持原有顺序
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].seq < expired[j].seq
+	})
+	s.pending = append(expired, s.pending...)
+}
+
+// nextExpiry 返回距最近一个确认超时的时长，无需等待时返回 0（调用方需持有锁）
+func (s *Stream) nextExpiry() time.Duration {
+	if s.ackTimeout <= 0 || len(s.inflight) == 0 {
+		return 0
+	}
+	now := time.Now()
+	min := s.ackTimeout
+	for _, it := range s.inflight {
+		if d := s.ackTimeout - now.Sub(it.deliveredAt); d < min {
+			min = d
+		}
+	}
+	if min <= 0 {
+		min = time.Millisecond
+	}
+	return min
+}
@@ -0,0 +1,139 @@
+package eventstream_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/eventstream"
+	"cdpnetool/pkg/domain"
+)
+
+func TestNextAck(t *testing.T) {
+	s := eventstream.New(domain.EventStreamOptions{BufferSize: 4})
+	if err := s.Publish(domain.NetworkEvent{ID: "e1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	d, err := s.Next(context.Background())
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if d.Event.ID != "e1" || d.Attempts != 1 {
+		t.Errorf("got %+v, want e1 with 1 attempt", d)
+	}
+	if err := s.Ack(d.Seq); err != nil {
+		t.Errorf("Ack() error = %v", err)
+	}
+	if err := s.Ack(d.Seq); !errors.Is(err, domain.ErrUnknownDelivery) {
+		t.Errorf("second Ack() error = %v, want ErrUnknownDelivery", err)
+	}
+
+	stats := s.Stats()
+	if stats.Acked != 1 || stats.InFlight != 0 || stats.Pending != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestNackRedelivers(t *testing.T) {
+	s := eventstream.New(domain.EventStreamOptions{BufferSize: 4})
+	_ = s.Publish(domain.NetworkEvent{ID: "e1"})
+	_ = s.Publish(domain.NetworkEvent{ID: "e2"})
+
+	d1, _ := s.Next(context.Background())
+	if err := s.Nack(d1.Seq); err != nil {
+		t.Fatalf("Nack() error = %v", err)
+	}
+
+	again, _ := s.Next(context.Background())
+	if again.Event.ID != "e1" || again.Attempts != 2 {
+		t.Errorf("got %+v, want e1 redelivered with 2 attempts", again)
+	}
+}
+
+func TestAckTimeoutRedelivers(t *testing.T) {
+	s := eventstream.New(domain.EventStreamOptions{BufferSize: 4, AckTimeoutMS: 20})
+	_ = s.Publish(domain.NetworkEvent{ID: "e1"})
+
+	d, _ := s.Next(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	again, err := s.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if again.Seq != d.Seq || again.Attempts != 2 {
+		t.Errorf("got %+v, want redelivery of seq %d", again, d.Seq)
+	}
+}
+
+func TestBufferFull(t *testing.T) {
+	s := eventstream.New(domain.EventStreamOptions{BufferSize: 1})
+	_ = s.Publish(domain.NetworkEvent{ID: "e1"})
+
+	// 已投递未确认的事件同样占用容量
+	d, _ := s.Next(context.Background())
+	if err := s.Publish(domain.NetworkEvent{ID: "e2"}); !errors.Is(err, domain.ErrStreamFull) {
+		t.Errorf("Publish() error = %v, want ErrStreamFull", err)
+	}
+	if s.Stats().Dropped != 1 {
+		t.Errorf("Dropped = %d, want 1", s.Stats().Dropped)
+	}
+
+	_ = s.Ack(d.Seq)
+	if err := s.Publish(domain.NetworkEvent{ID: "e3"}); err != nil {
+		t.Errorf("Publish() after Ack error = %v", err)
+	}
+}
+
+func TestNextBlocksUntilPublish(t *testing.T) {
+	s := eventstream.New(domain.EventStreamOptions{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = s.Publish(domain.NetworkEvent{ID: "late"})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	d, err := s.Next(ctx)
+	if err != nil || d.Event.ID != "late" {
+		t.Errorf("got %+v, %v; want late event", d, err)
+	}
+}
+
+func TestNextContextCancel(t *testing.T) {
+	s := eventstream.New(domain.EventStreamOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := s.Next(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Next() error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	s := eventstream.New(domain.EventStreamOptions{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Next(context.Background())
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	_ = s.Close()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, domain.ErrStreamClosed) {
+			t.Errorf("Next() error = %v, want ErrStreamClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Next() not unblocked by Close()")
+	}
+
+	if err := s.Publish(domain.NetworkEvent{ID: "e1"}); !errors.Is(err, domain.ErrStreamClosed) {
+		t.Errorf("Publish() error = %v, want ErrStreamClosed", err)
+	}
+}
@@ -0,0 +1,229 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// maxRawDepth 无描述符解码时尝试展开嵌套消息的最大层数
+const maxRawDepth = 32
+
+// Decoder gRPC-web 消息解码器。加载了描述符集时按方法的输入/输出类型解码，
+// 否则按 protobuf 线格式尽力解码，字段以编号为键
+type Decoder struct {
+	files *protoregistry.Files
+	types *dynamicpb.Types // 解析 Any 等字段时使用的类型解析器
+}
+
+// Decoded 解码结果
+type Decoded struct {
+	Type     string            `json:"type,omitempty"`     // 消息的 protobuf 全名，无描述符时为空
+	Messages []json.RawMessage `json:"messages"`           // 按顺序解码的消息
+	Trailers map[string]string `json:"trailers,omitempty"` // 响应中的 trailer，如 grpc-status
+}
+
+// NewDecoder 创建解码器，descriptorSet 为 protoc --descriptor_set_out 生成的 FileDescriptorSet，为空时仅按线格式解码
+func NewDecoder(descriptorSet []byte) (*Decoder, error) {
+	if len(descriptorSet) == 0 {
+		return &Decoder{}, nil
+	}
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(descriptorSet, &fds); err != nil {
+		return nil, fmt.Errorf("parse descriptor set: %w", err)
+	}
+	files, err := protodesc.NewFiles(&fds)
+	if err != nil {
+		return nil, fmt.Errorf("load descriptor set: %w", err)
+	}
+	return &Decoder{files: files, types: dynamicpb.NewTypes(files)}, nil
+}
+
+// LoadDecoder 从文件加载描述符集并创建解码器，path 为空时仅按线格式解码
+func LoadDecoder(path string) (*Decoder, error) {
+	if path == "" {
+		return NewDecoder(nil)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewDecoder(data)
+}
+
+// Decode 解码 gRPC-web 消息体并返回 JSON。urlPath 为请求路径 /包名.服务名/方法名，用于确定消息类型；
+// response 为 true 时按方法的输出类型解码
+func (d *Decoder) Decode(body []byte, contentType, urlPath string, response bool) (string, error) {
+	frames, err := ParseFrames(body, contentType)
+	if err != nil && len(frames) == 0 {
+		return "", err
+	}
+
+	out := Decoded{Messages: []json.RawMessage{}}
+	md := d.messageType(urlPath, response)
+	if md != nil {
+		out.Type = string(md.FullName())
+	}
+	for _, f := range frames {
+		if f.Trailer {
+			out.Trailers = ParseTrailers(f.Payload)
+			continue
+		}
+		out.Messages = append(out.Messages, d.decodeMessage(f.Payload, md))
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// messageType 根据请求路径查找方法的输入或输出类型，找不到时返回 nil
+func (d *Decoder) messageType(urlPath string, response bool) protoreflect.MessageDescriptor {
+	if d.files == nil {
+		return nil
+	}
+	service, method, ok := strings.Cut(strings.Trim(urlPath, "/"), "/")
+	if !ok {
+		return nil
+	}
+	desc, err := d.files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	m := sd.Methods().ByName(protoreflect.Name(method))
+	if m == nil {
+		return nil
+	}
+	if response {
+		return m.Output()
+	}
+	return m.Input()
+}
+
+// decodeMessage 按类型解码单条消息，类型未知或解码失败时退回线格式解码
+func (d *Decoder) decodeMessage(payload []byte, md protoreflect.MessageDescriptor) json.RawMessage {
+	if md != nil {
+		msg := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(payload, msg); err == nil {
+			if data, err := (protojson.MarshalOptions{Resolver: d.types}).Marshal(msg); err == nil {
+				// protojson 的输出空白不稳定，压缩后便于匹配与比较
+				var buf bytes.Buffer
+				if json.Compact(&buf, data) == nil {
+					return buf.Bytes()
+				}
+				return data
+			}
+		}
+	}
+	var v any = base64.StdEncoding.EncodeToString(payload)
+	if fields, ok := decodeRaw(payload, 0); ok {
+		v = fields
+	}
+	data, _ := json.Marshal(v)
+	return data
+}
+
+// decodeRaw 按 protobuf 线格式解码消息，字段以编号为键，重复出现的字段合并为数组
+func decodeRaw(b []byte, depth int) (map[string]any, bool) {
+	fields := make(map[string]any)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, false
+		}
+		b = b[n:]
+
+		var v any
+		switch typ {
+		case protowire.VarintType:
+			var x uint64
+			x, n = protowire.ConsumeVarint(b)
+			v = x
+		case protowire.Fixed32Type:
+			var x uint32
+			x, n = protowire.ConsumeFixed32(b)
+			v = x
+		case protowire.Fixed64Type:
+			var x uint64
+			x, n = protowire.ConsumeFixed64(b)
+			v = x
+		case protowire.BytesType:
+			var x []byte
+			x, n = protowire.ConsumeBytes(b)
+			v = rawBytes(x, depth)
+		case protowire.StartGroupType:
+			var x []byte
+			x, n = protowire.ConsumeGroup(num, b)
+			v = base64.StdEncoding.EncodeToString(x)
+		default:
+			return nil, false
+		}
+		if n < 0 {
+			return nil, false
+		}
+		b = b[n:]
+
+		key := strconv.Itoa(int(num))
+		switch prev := fields[key].(type) {
+		case nil:
+			fields[key] = v
+		case []any:
+			fields[key] = append(prev, v)
+		default:
+			fields[key] = []any{prev, v}
+		}
+	}
+	return fields, true
+}
+
+// rawBytes 推断长度前缀字段的内容：可读文本、嵌套消息或二进制（base64）
+func rawBytes(b []byte, depth int) any {
+	if len(b) == 0 {
+		return ""
+	}
+	if b[0] >= 0x20 && printable(b) {
+		return string(b)
+	}
+	if depth < maxRawDepth {
+		if fields, ok := decodeRaw(b, depth+1); ok {
+			return fields
+		}
+	}
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// printable 判断是否为不含控制字符（制表、换行除外）的 UTF-8 文本
+func printable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
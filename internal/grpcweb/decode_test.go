@@ -0,0 +1,174 @@
+package grpcweb_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cdpnetool/internal/grpcweb"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptorSet 构造包含 demo.Users/Get 方法的描述符集
+func descriptorSet(t *testing.T) []byte {
+	t.Helper()
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     typ.Enum(),
+		}
+	}
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("demo.proto"),
+		Package: proto.String("demo"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+			}},
+			{Name: proto.String("User"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("age", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Users"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Get"),
+				InputType:  proto.String(".demo.GetRequest"),
+				OutputType: proto.String(".demo.User"),
+			}},
+		}},
+	}}}
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatalf("marshal descriptor set: %v", err)
+	}
+	return data
+}
+
+// userMessage 按线格式编码 User{name, age}
+func userMessage(name string, age uint64) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, age)
+	return b
+}
+
+func decode(t *testing.T, d *grpcweb.Decoder, body []byte, path string, response bool) grpcweb.Decoded {
+	t.Helper()
+	s, err := d.Decode(body, "application/grpc-web+proto", path, response)
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	var out grpcweb.Decoded
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		t.Fatalf("decoded output is not JSON: %v (%s)", err, s)
+	}
+	return out
+}
+
+func TestDecode_WithDescriptor(t *testing.T) {
+	d, err := grpcweb.NewDecoder(descriptorSet(t))
+	if err != nil {
+		t.Fatalf("NewDecoder error: %v", err)
+	}
+
+	body := append(frame(0x00, userMessage("alice", 30)), frame(0x80, []byte("grpc-status: 0\r\n"))...)
+	out := decode(t, d, body, "/demo.Users/Get", true)
+	if out.Type != "demo.User" {
+		t.Errorf("got type %q, want demo.User", out.Type)
+	}
+	if len(out.Messages) != 1 || string(out.Messages[0]) != `{"name":"alice","age":30}` {
+		t.Errorf("got messages %s", out.Messages)
+	}
+	if out.Trailers["grpc-status"] != "0" {
+		t.Errorf("got trailers %v, want grpc-status 0", out.Trailers)
+	}
+
+	// 请求按方法的输入类型解码
+	var req []byte
+	req = protowire.AppendTag(req, 1, protowire.VarintType)
+	req = protowire.AppendVarint(req, 42)
+	out = decode(t, d, frame(0x00, req), "/demo.Users/Get", false)
+	if out.Type != "demo.GetRequest" || string(out.Messages[0]) != `{"id":"42"}` {
+		t.Errorf("got %s %s, want demo.GetRequest with id 42", out.Type, out.Messages[0])
+	}
+}
+
+func TestDecode_Raw(t *testing.T) {
+	d, err := grpcweb.NewDecoder(nil)
+	if err != nil {
+		t.Fatalf("NewDecoder error: %v", err)
+	}
+
+	// 未知方法按线格式解码，字段以编号为键
+	msg := userMessage("bob", 7)
+	msg = protowire.AppendTag(msg, 3, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, userMessage("nested", 1))
+	out := decode(t, d, frame(0x00, msg), "/demo.Users/Get", true)
+	if out.Type != "" {
+		t.Errorf("got type %q, want empty", out.Type)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(out.Messages[0], &fields); err != nil {
+		t.Fatalf("message is not an object: %s", out.Messages[0])
+	}
+	if fields["1"] != "bob" || fields["2"] != float64(7) {
+		t.Errorf("got fields %v", fields)
+	}
+	nested, ok := fields["3"].(map[string]any)
+	if !ok || nested["1"] != "nested" {
+		t.Errorf("got nested field %v, want decoded message", fields["3"])
+	}
+}
+
+func TestDecode_RepeatedField(t *testing.T) {
+	d, _ := grpcweb.NewDecoder(nil)
+	var msg []byte
+	for _, v := range []uint64{1, 2, 3} {
+		msg = protowire.AppendTag(msg, 4, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, v)
+	}
+	out := decode(t, d, frame(0x00, msg), "", true)
+	if string(out.Messages[0]) != `{"4":[1,2,3]}` {
+		t.Errorf("got %s, want repeated field as array", out.Messages[0])
+	}
+}
+
+func TestNewDecoder_Invalid(t *testing.T) {
+	if _, err := grpcweb.NewDecoder([]byte("not a descriptor set")); err == nil {
+		t.Error("expected error for invalid descriptor set")
+	}
+}
+
+func TestLoadDecoder(t *testing.T) {
+	if _, err := grpcweb.LoadDecoder(""); err != nil {
+		t.Errorf("empty path should give raw decoder, got error %v", err)
+	}
+	if _, err := grpcweb.LoadDecoder(filepath.Join(t.TempDir(), "missing.pb")); err == nil {
+		t.Error("expected error for missing file")
+	}
+
+	path := filepath.Join(t.TempDir(), "demo.pb")
+	if err := os.WriteFile(path, descriptorSet(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := grpcweb.LoadDecoder(path)
+	if err != nil {
+		t.Fatalf("LoadDecoder error: %v", err)
+	}
+	out := decode(t, d, frame(0x00, userMessage("carol", 5)), "/demo.Users/Get", true)
+	if out.Type != "demo.User" {
+		t.Errorf("got type %q, want demo.User", out.Type)
+	}
+}
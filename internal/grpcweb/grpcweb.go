@@ -0,0 +1,131 @@
+// Package grpcweb 解析 gRPC-web 消息帧，并将其中的 protobuf 消息解码为 JSON 便于展示与匹配
+package grpcweb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	flagCompressed = 0x01 // 消息经过压缩（gzip）
+	flagTrailer    = 0x80 // 帧内容为 trailer 头部而非消息
+	headerLen      = 5    // 1 字节标志 + 4 字节大端长度
+)
+
+// maxMessageSize 单条消息解压后的最大长度，避免异常数据占用过多内存
+const maxMessageSize = 16 << 20
+
+// Frame gRPC-web 数据帧
+type Frame struct {
+	Trailer bool   // 是否为 trailer 帧
+	Payload []byte // 消息内容（已解压）或 trailer 文本
+}
+
+// IsGRPCWeb 判断 Content-Type 是否为 gRPC-web（含 +proto 与 -text 变体）
+func IsGRPCWeb(contentType string) bool {
+	return strings.HasPrefix(mediaType(contentType), "application/grpc-web")
+}
+
+// isText 判断是否为 base64 编码的 grpc-web-text 变体
+func isText(contentType string) bool {
+	return strings.HasPrefix(mediaType(contentType), "application/grpc-web-text")
+}
+
+// mediaType 取出 Content-Type 中不含参数的部分并转为小写
+func mediaType(contentType string) string {
+	mt, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mt))
+}
+
+// ParseFrames 拆分 gRPC-web 消息体中的数据帧，grpc-web-text 变体先做 base64 解码，压缩的消息按 gzip 解压
+func ParseFrames(body []byte, contentType string) ([]Frame, error) {
+	if isText(contentType) {
+		decoded, err := decodeText(body)
+		if err != nil {
+			return nil, err
+		}
+		body = decoded
+	}
+
+	var frames []Frame
+	for len(body) > 0 {
+		if len(body) < headerLen {
+			return frames, errors.New("truncated gRPC-web frame header")
+		}
+		flags := body[0]
+		n := binary.BigEndian.Uint32(body[1:headerLen])
+		if uint64(len(body)-headerLen) < uint64(n) {
+			return frames, errors.New("truncated gRPC-web frame")
+		}
+		payload := body[headerLen : headerLen+int(n)]
+		body = body[headerLen+int(n):]
+
+		if flags&flagCompressed != 0 {
+			p, err := gunzip(payload)
+			if err != nil {
+				return frames, err
+			}
+			payload = p
+		}
+		frames = append(frames, Frame{Trailer: flags&flagTrailer != 0, Payload: payload})
+	}
+	return frames, nil
+}
+
+// ParseTrailers 解析 trailer 帧中 "key: value" 形式的头部，键名转为小写
+func ParseTrailers(payload []byte) map[string]string {
+	trailers := make(map[string]string)
+	for _, line := range strings.Split(string(payload), "\n") {
+		k, v, ok := strings.Cut(strings.TrimRight(line, "\r"), ":")
+		if !ok {
+			continue
+		}
+		trailers[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return trailers
+}
+
+// decodeText 解码 grpc-web-text 消息体，流式响应可能由多段各自补齐的 base64 拼接而成
+func decodeText(body []byte) ([]byte, error) {
+	body = bytes.TrimSpace(body)
+	var out []byte
+	for len(body) > 0 {
+		// 每段以 = 填充结尾，找到填充之后的位置作为分段点
+		end := len(body)
+		if i := bytes.IndexByte(body, '='); i >= 0 {
+			end = i
+			for end < len(body) && body[end] == '=' {
+				end++
+			}
+		}
+		chunk, err := base64.StdEncoding.DecodeString(string(body[:end]))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, chunk...)
+		body = body[end:]
+	}
+	return out, nil
+}
+
+// gunzip 解压 gzip 压缩的消息
+func gunzip(payload []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxMessageSize {
+		return nil, errors.New("gRPC-web message too large")
+	}
+	return out, nil
+}
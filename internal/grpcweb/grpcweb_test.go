@@ -0,0 +1,102 @@
+package grpcweb_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+
+	"cdpnetool/internal/grpcweb"
+)
+
+// frame 构造一个 gRPC-web 数据帧
+func frame(flags byte, payload []byte) []byte {
+	b := make([]byte, 5, 5+len(payload))
+	b[0] = flags
+	binary.BigEndian.PutUint32(b[1:], uint32(len(payload)))
+	return append(b, payload...)
+}
+
+func TestIsGRPCWeb(t *testing.T) {
+	tests := []struct {
+		ct   string
+		want bool
+	}{
+		{"application/grpc-web", true},
+		{"application/grpc-web+proto", true},
+		{"application/grpc-web-text; charset=utf-8", true},
+		{"Application/GRPC-Web-Text+proto", true},
+		{"application/grpc", false},
+		{"application/json", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := grpcweb.IsGRPCWeb(tt.ct); got != tt.want {
+			t.Errorf("IsGRPCWeb(%q) = %v, want %v", tt.ct, got, tt.want)
+		}
+	}
+}
+
+func TestParseFrames(t *testing.T) {
+	body := append(frame(0x00, []byte("hello")), frame(0x80, []byte("grpc-status: 0\r\n"))...)
+	frames, err := grpcweb.ParseFrames(body, "application/grpc-web+proto")
+	if err != nil {
+		t.Fatalf("ParseFrames error: %v", err)
+	}
+	if len(frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(frames))
+	}
+	if frames[0].Trailer || string(frames[0].Payload) != "hello" {
+		t.Errorf("got first frame %+v, want message hello", frames[0])
+	}
+	if !frames[1].Trailer {
+		t.Errorf("second frame should be trailer")
+	}
+}
+
+func TestParseFrames_Text(t *testing.T) {
+	// 流式响应由多段各自补齐的 base64 拼接
+	body := base64.StdEncoding.EncodeToString(frame(0x00, []byte("a"))) +
+		base64.StdEncoding.EncodeToString(frame(0x80, []byte("grpc-status: 0")))
+	frames, err := grpcweb.ParseFrames([]byte(body), "application/grpc-web-text")
+	if err != nil {
+		t.Fatalf("ParseFrames error: %v", err)
+	}
+	if len(frames) != 2 || string(frames[0].Payload) != "a" || !frames[1].Trailer {
+		t.Errorf("got frames %+v, want message a followed by trailer", frames)
+	}
+}
+
+func TestParseFrames_Compressed(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte("compressed"))
+	_ = zw.Close()
+
+	frames, err := grpcweb.ParseFrames(frame(0x01, buf.Bytes()), "application/grpc-web")
+	if err != nil {
+		t.Fatalf("ParseFrames error: %v", err)
+	}
+	if len(frames) != 1 || string(frames[0].Payload) != "compressed" {
+		t.Errorf("got frames %+v, want decompressed payload", frames)
+	}
+}
+
+func TestParseFrames_Truncated(t *testing.T) {
+	body := append(frame(0x00, []byte("ok")), frame(0x00, []byte("cut"))[:6]...)
+	frames, err := grpcweb.ParseFrames(body, "application/grpc-web")
+	if err == nil {
+		t.Fatal("expected error for truncated frame")
+	}
+	if len(frames) != 1 || string(frames[0].Payload) != "ok" {
+		t.Errorf("got frames %+v, want complete frames before truncation", frames)
+	}
+}
+
+func TestParseTrailers(t *testing.T) {
+	got := grpcweb.ParseTrailers([]byte("Grpc-Status: 5\r\ngrpc-message: not found\r\n"))
+	if got["grpc-status"] != "5" || got["grpc-message"] != "not found" {
+		t.Errorf("got trailers %v", got)
+	}
+}
@@ -1,8 +1,10 @@
 package gui
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,11 +13,18 @@ import (
 
 	"cdpnetool/internal/browser"
 	"cdpnetool/internal/config"
+	"cdpnetool/internal/har"
 	"cdpnetool/internal/logger"
+	"cdpnetool/internal/redact"
+	"cdpnetool/internal/report"
+	"cdpnetool/internal/secrets"
+	"cdpnetool/internal/sessiondiff"
 	"cdpnetool/internal/storage/db"
 	"cdpnetool/internal/storage/model"
 	"cdpnetool/internal/storage/repo"
+	"cdpnetool/internal/tracing"
 	"cdpnetool/pkg/api"
+	"cdpnetool/pkg/diff"
 	"cdpnetool/pkg/domain"
 	"cdpnetool/pkg/rulespec"
 
@@ -39,13 +48,16 @@ type App struct {
 	isDirty         bool
 	cancelSubscribe context.CancelFunc
 	cancelTraffic   context.CancelFunc
+	shutdownTracing func(context.Context) error
 }
 
 // NewApp 创建并返回一个新的 App 实例。
 func NewApp() *App {
 	cfg := config.NewConfig()
+	har.CreatorVersion = cfg.Version
 	log := logger.New(logger.Options{
 		Level:   cfg.Log.Level,
+		Format:  cfg.Log.Format,
 		Writers: cfg.Log.Writer,
 	})
 	return &App{
@@ -60,6 +72,12 @@ func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
 	a.log.Info("应用启动")
 
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		a.log.Err(err, "追踪配置无效，不开启追踪")
+	}
+	a.shutdownTracing = shutdownTracing
+
 	gormLogger := db.NewLogger(a.log).LogMode(gl.Info)
 	gdb, err := db.New(db.Options{
 		Name:   a.cfg.Sqlite.Db,
@@ -85,9 +103,112 @@ func (a *App) Startup(ctx context.Context) {
 	a.settingsRepo = repo.NewSettingsRepo(gdb)
 	a.configRepo = repo.NewConfigRepo(gdb)
 	a.eventRepo = repo.NewEventRepo(gdb, a.log)
+	a.applyRedaction()
+	a.applyLogLevel()
+	if err := a.applyRetention(); err != nil {
+		a.log.Err(err, "按保留天数清理旧事件失败")
+	}
+	a.settingsRepo.OnChange(func(c repo.SettingChange) {
+		a.log.Info("设置已变更", "key", c.Key, "old", c.OldValue, "new", c.NewValue)
+		a.applySetting(c)
+	})
+
+	if n, err := a.configRepo.MigrateAll(ctx); err != nil {
+		a.log.Err(err, "规则配置版本迁移失败")
+	} else if n > 0 {
+		a.log.Info("规则配置已迁移到当前版本", "count", n, "version", rulespec.DefaultConfigVersion)
+	}
 	a.log.Debug("数据持久化层初始化完成")
 }
 
+// applyRedaction 按当前设置更新事件写入数据库前的脱敏器，新会话的 HAR 导出在启动时读取同一配置。
+func (a *App) applyRedaction() {
+	rd, err := redact.New(a.settingsRepo.GetRedactionConfig(a.ctx))
+	if err != nil {
+		a.log.Err(err, "脱敏配置无效，事件将不做脱敏")
+	}
+	a.eventRepo.SetRedactor(rd)
+}
+
+// applySetting 将变更的设置应用到日志、事件存储与运行中的会话，无需重启，
+// 并推送 "config-applied" 事件告知前端实际生效的范围
+func (a *App) applySetting(c repo.SettingChange) {
+	applied := ConfigAppliedData{Key: c.Key, Value: c.NewValue}
+	var err error
+	switch {
+	case c.Key == model.SettingKeyLogLevel:
+		applied.Scope = "logger"
+		err = a.applyLogLevel()
+	case c.Key == model.SettingKeyEventRetentionDays:
+		applied.Scope = "events"
+		err = a.applyRetention()
+	case strings.HasPrefix(c.Key, "redact_"):
+		applied.Scope = "redaction"
+		a.applyRedaction()
+	case c.Key == model.SettingKeySessionProcessTimeout,
+		c.Key == model.SettingKeySessionUnmatchedSampling,
+		c.Key == model.SettingKeySessionDisableCache:
+		if a.currentSession == "" {
+			return
+		}
+		applied.Scope = "session"
+		applied.SessionID = a.currentSession
+		err = a.service.UpdateRuntimeSettings(a.ctx, a.currentSession, a.settingsRepo.GetRuntimeSettings(a.ctx))
+	default:
+		// 其余设置在下次启动会话或浏览器时读取
+		return
+	}
+	if err != nil {
+		a.log.Err(err, "应用设置失败", "key", c.Key)
+		applied.Error = err.Error()
+	}
+	runtime.EventsEmit(a.ctx, "config-applied", applied)
+}
+
+// applyLogLevel 按当前设置调整日志级别
+func (a *App) applyLogLevel() error {
+	lv, ok := a.log.(logger.Leveler)
+	if !ok {
+		return nil
+	}
+	return lv.SetLevel(a.settingsRepo.GetWithDefault(a.ctx, model.SettingKeyLogLevel, a.cfg.Log.Level))
+}
+
+// applyRetention 按保留天数清理旧事件，未设置保留天数时不清理
+func (a *App) applyRetention() error {
+	days := a.settingsRepo.GetInt(a.ctx, model.SettingKeyEventRetentionDays)
+	if days <= 0 {
+		return nil
+	}
+	deleted, err := a.eventRepo.CleanupOldEvents(a.ctx, days)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		a.log.Info("已按保留天数清理旧事件", "retentionDays", days, "deletedCount", deleted)
+	}
+	return nil
+}
+
+// DomReady 在前端页面加载完成后调用，包括前端刷新或崩溃后重新加载；
+// 重新推送当前会话的断点状态与等待中的认证质询，使仍在等待的请求不因前端重载而丢失。
+func (a *App) DomReady(ctx context.Context) {
+	if a.currentSession == "" {
+		return
+	}
+	status, err := a.service.GetBreakpointStatus(ctx, a.currentSession)
+	if err != nil {
+		return
+	}
+	if len(status.Held) > 0 {
+		a.log.Info("前端重新加载，重新推送待处理的断点请求", "sessionID", a.currentSession, "held", len(status.Held))
+	}
+	runtime.EventsEmit(a.ctx, "breakpoint-status", BreakpointData{Status: status})
+	if challenges, err := a.service.ListAuthChallenges(ctx, a.currentSession); err == nil {
+		runtime.EventsEmit(a.ctx, "auth-challenges", AuthChallengesData{Challenges: challenges})
+	}
+}
+
 // Shutdown 负责清理资源。
 func (a *App) Shutdown(ctx context.Context) {
 	a.log.Info("应用关闭中...")
@@ -117,6 +238,12 @@ func (a *App) Shutdown(ctx context.Context) {
 		}
 	}
 
+	if a.shutdownTracing != nil {
+		if err := a.shutdownTracing(ctx); err != nil {
+			a.log.Err(err, "导出剩余的追踪数据失败")
+		}
+	}
+
 	a.log.Info("应用已关闭")
 }
 
@@ -134,7 +261,7 @@ func (a *App) StartSession(devToolsURL string) api.Response[SessionData] {
 		a.cancelTraffic = nil
 	}
 
-	cfg := domain.SessionConfig{DevToolsURL: devToolsURL}
+	cfg := a.settingsRepo.GetSessionConfig(a.ctx, devToolsURL)
 	sid, err := a.service.StartSession(a.ctx, cfg)
 	if err != nil {
 		code, msg := a.translateError(err)
@@ -147,6 +274,9 @@ func (a *App) StartSession(devToolsURL string) api.Response[SessionData] {
 	subCtx, subCancel := context.WithCancel(a.ctx)
 	a.cancelSubscribe = subCancel
 	go a.subscribeEvents(subCtx, sid)
+	go a.subscribeBreakpoint(subCtx, sid)
+	go a.subscribeConnection(subCtx, sid)
+	go a.subscribeAuthChallenges(subCtx, sid)
 
 	// 启动全量流量订阅
 	trafficCtx, trafficCancel := context.WithCancel(a.ctx)
@@ -264,88 +394,845 @@ func (a *App) ExportConfig(name, rulesJSON string) api.Response[api.EmptyData] {
 
 	if err != nil {
 		code, msg := a.translateError(err)
-		return api.Fail[api.EmptyData](code, msg)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	if path == "" {
+		return api.OK(api.EmptyData{})
+	}
+
+	err = os.WriteFile(path, []byte(rulesJSON), 0644)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// EnableInterception 启用指定会话的网络拦截功能。
+func (a *App) EnableInterception(sessionID string) api.Response[api.EmptyData] {
+	err := a.service.EnableInterception(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	a.log.Info("已启用拦截", "sessionID", sessionID)
+	return api.OK(api.EmptyData{})
+}
+
+// DisableInterception 停用指定会话的网络拦截功能。
+func (a *App) DisableInterception(sessionID string) api.Response[api.EmptyData] {
+	err := a.service.DisableInterception(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	a.log.Info("已停用拦截", "sessionID", sessionID)
+	return api.OK(api.EmptyData{})
+}
+
+// LoadRules 从 JSON 字符串加载规则配置到指定会话。
+func (a *App) LoadRules(sessionID string, rulesJSON string) api.Response[api.EmptyData] {
+	cfg, _, err := rulespec.ParseConfig([]byte(rulesJSON))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	err = a.service.LoadRules(a.ctx, domain.SessionID(sessionID), cfg)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	a.log.Info("规则加载成功", "sessionID", sessionID, "ruleCount", len(cfg.Rules))
+	return api.OK(api.EmptyData{})
+}
+
+// ValidateRules 在加载前校验规则配置 JSON，返回逐条规则的错误与警告；JSON 无法解析时返回失败。
+func (a *App) ValidateRules(rulesJSON string) api.Response[ValidationData] {
+	cfg, _, err := rulespec.ParseConfig([]byte(rulesJSON))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ValidationData](code, msg)
+	}
+	return api.OK(ValidationData{Report: rulespec.Validate(cfg)})
+}
+
+// WatchRulesFile 从规则 JSON 文件加载规则并在文件变化时自动重新加载，path 为空时停止监听。
+func (a *App) WatchRulesFile(sessionID string, path string) api.Response[api.EmptyData] {
+	if err := a.service.WatchRulesFile(a.ctx, domain.SessionID(sessionID), path); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+	return api.OK(api.EmptyData{})
+}
+
+// SetRuleSchedule 设置会话的规则集定时切换计划，scheduleJSON 对应 RuleScheduleInput，为空时取消计划。
+func (a *App) SetRuleSchedule(sessionID string, scheduleJSON string) api.Response[api.EmptyData] {
+	if scheduleJSON == "" {
+		if err := a.service.SetRuleSchedule(a.ctx, domain.SessionID(sessionID), nil); err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[api.EmptyData](code, msg)
+		}
+		return api.OK(api.EmptyData{})
+	}
+
+	var input RuleScheduleInput
+	if err := json.Unmarshal([]byte(scheduleJSON), &input); err != nil {
+		code, msg := a.translateError(fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err))
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	schedule := &rulespec.Schedule{}
+	for _, w := range input.Windows {
+		cfg, err := a.loadSavedConfig(w.ConfigID)
+		if err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[api.EmptyData](code, msg)
+		}
+		schedule.Windows = append(schedule.Windows, rulespec.ScheduleWindow{Name: w.Name, Start: w.Start, End: w.End, Config: cfg})
+	}
+	if input.DefaultConfigID != 0 {
+		cfg, err := a.loadSavedConfig(input.DefaultConfigID)
+		if err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[api.EmptyData](code, msg)
+		}
+		schedule.Default = cfg
+	}
+
+	if err := a.service.SetRuleSchedule(a.ctx, domain.SessionID(sessionID), schedule); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	a.log.Info("规则集定时计划已设置", "sessionID", sessionID, "windows", len(schedule.Windows))
+	return api.OK(api.EmptyData{})
+}
+
+// GetRuleSchedule 获取会话的规则集定时计划状态与切换记录。
+func (a *App) GetRuleSchedule(sessionID string) api.Response[ScheduleStatusData] {
+	status, err := a.service.GetRuleSchedule(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ScheduleStatusData](code, msg)
+	}
+
+	return api.OK(ScheduleStatusData{Status: status})
+}
+
+// loadSavedConfig 按数据库 ID 读取已保存的配置
+func (a *App) loadSavedConfig(id uint) (*rulespec.Config, error) {
+	record, err := a.configRepo.FindOne(a.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.ID == 0 {
+		return nil, domain.ErrConfigNotFound
+	}
+	return a.configRepo.ToRulespecConfig(record)
+}
+
+// RunBenchmark 对给定规则配置执行吞吐基准测试，optionsJSON 对应 domain.BenchmarkOptions。
+func (a *App) RunBenchmark(configJSON, optionsJSON string) api.Response[BenchmarkData] {
+	cfg, _, err := rulespec.ParseConfig([]byte(configJSON))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[BenchmarkData](code, msg)
+	}
+
+	var opts domain.BenchmarkOptions
+	if optionsJSON != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &opts); err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[BenchmarkData](code, msg)
+		}
+	}
+
+	res, err := a.service.RunBenchmark(a.ctx, cfg, opts)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[BenchmarkData](code, msg)
+	}
+
+	return api.OK(BenchmarkData{Result: res})
+}
+
+// BenchmarkRules 分析给定规则配置中每条规则的评估耗时，optionsJSON 对应 domain.RuleBenchmarkOptions；
+// sessionID 非空时以该会话最近的匹配事件历史作为请求上下文，否则使用 optionsJSON 中的请求或合成请求。
+func (a *App) BenchmarkRules(configJSON, optionsJSON, sessionID string) api.Response[RuleBenchmarkData] {
+	cfg, _, err := rulespec.ParseConfig([]byte(configJSON))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[RuleBenchmarkData](code, msg)
+	}
+
+	var opts domain.RuleBenchmarkOptions
+	if optionsJSON != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &opts); err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[RuleBenchmarkData](code, msg)
+		}
+	}
+
+	if sessionID != "" {
+		if a.eventRepo == nil {
+			code, msg := a.translateError(domain.ErrDatabaseNotInitialized)
+			return api.Fail[RuleBenchmarkData](code, msg)
+		}
+		page, err := a.eventRepo.Query(a.ctx, repo.QueryOptions{SessionID: sessionID, Limit: 1000})
+		if err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[RuleBenchmarkData](code, msg)
+		}
+		for _, record := range page.Records {
+			var req domain.Request
+			if err := json.Unmarshal([]byte(record.RequestJSON), &req); err != nil {
+				a.log.Warn("跳过无法解析的录制请求", "id", record.ID, "error", err)
+				continue
+			}
+			opts.Requests = append(opts.Requests, req)
+		}
+	}
+
+	res, err := a.service.BenchmarkRules(a.ctx, cfg, opts)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[RuleBenchmarkData](code, msg)
+	}
+
+	return api.OK(RuleBenchmarkData{Result: res})
+}
+
+// TestRules 以给定规则配置离线重放捕获的事件，报告每个事件会命中的规则与产生的修改，eventsJSON 对应 domain.RuleTestOptions；
+// sessionID 非空时追加该会话已持久化的匹配事件，持久化记录只保存规则修改后的请求与响应，会以其作为输入。
+func (a *App) TestRules(configJSON, eventsJSON, sessionID string) api.Response[RuleTestData] {
+	cfg, _, err := rulespec.ParseConfig([]byte(configJSON))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[RuleTestData](code, msg)
+	}
+
+	var opts domain.RuleTestOptions
+	if eventsJSON != "" {
+		if err := json.Unmarshal([]byte(eventsJSON), &opts); err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[RuleTestData](code, msg)
+		}
+	}
+
+	if sessionID != "" {
+		if a.eventRepo == nil {
+			code, msg := a.translateError(domain.ErrDatabaseNotInitialized)
+			return api.Fail[RuleTestData](code, msg)
+		}
+		records, err := a.eventRepo.ListBySession(a.ctx, sessionID)
+		if err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[RuleTestData](code, msg)
+		}
+		for _, record := range records {
+			evt, err := recordEvent(record)
+			if err != nil {
+				a.log.Warn("跳过无法解析的事件记录", "id", record.ID, "error", err)
+				continue
+			}
+			opts.Events = append(opts.Events, evt)
+		}
+	}
+
+	res, err := a.service.TestRules(a.ctx, cfg, opts)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[RuleTestData](code, msg)
+	}
+
+	diffs := make([]diff.EventDiff, len(res.Cases))
+	for i, c := range res.Cases {
+		diffs[i] = diff.Event(c.Event)
+	}
+	return api.OK(RuleTestData{Result: res, Diffs: diffs})
+}
+
+// recordEvent 将持久化的事件记录还原为事件
+func recordEvent(record model.NetworkEventRecord) (domain.NetworkEvent, error) {
+	evt := domain.NetworkEvent{
+		Session:     domain.SessionID(record.SessionID),
+		Target:      domain.TargetID(record.TargetID),
+		Timestamp:   record.Timestamp,
+		IsMatched:   true,
+		FinalResult: record.FinalResult,
+	}
+	if err := json.Unmarshal([]byte(record.RequestJSON), &evt.Request); err != nil {
+		return evt, err
+	}
+	evt.ID = evt.Request.ID
+	if record.ResponseJSON != "" && record.ResponseJSON != "null" {
+		if err := json.Unmarshal([]byte(record.ResponseJSON), &evt.Response); err != nil {
+			return evt, err
+		}
+	}
+	if record.MatchedRulesJSON != "" {
+		if err := json.Unmarshal([]byte(record.MatchedRulesJSON), &evt.MatchedRules); err != nil {
+			return evt, err
+		}
+	}
+	return evt, nil
+}
+
+// EnableTrafficCapture 启用或禁用全量流量捕获。
+func (a *App) EnableTrafficCapture(sessionID string, enabled bool) api.Response[api.EmptyData] {
+	err := a.service.EnableTrafficCapture(a.ctx, domain.SessionID(sessionID), enabled)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+	return api.OK(api.EmptyData{})
+}
+
+// StartHARStream 弹出保存对话框选择文件，开始将全量流量持续写入 HAR，format 为 har 或 jsonl，为空时按扩展名推断。
+func (a *App) StartHARStream(sessionID, format string) api.Response[HARStreamData] {
+	ext := ".har"
+	filter := runtime.FileFilter{DisplayName: "HAR Files (*.har)", Pattern: "*.har"}
+	if domain.HARFormat(format) == domain.HARFormatJSONL {
+		ext = ".jsonl"
+		filter = runtime.FileFilter{DisplayName: "HAR JSON Lines (*.jsonl)", Pattern: "*.jsonl"}
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: "traffic-" + time.Now().Format("20060102-150405") + ext,
+		Title:           "Stream Traffic to HAR",
+		Filters:         []runtime.FileFilter{filter},
+	})
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[HARStreamData](code, msg)
+	}
+
+	if path == "" {
+		return api.OK(HARStreamData{})
+	}
+
+	opts := domain.HARStreamOptions{Path: path, Format: domain.HARFormat(format)}
+	if err := a.service.StartHARStream(a.ctx, domain.SessionID(sessionID), opts); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[HARStreamData](code, msg)
+	}
+
+	return a.GetHARStreamStatus(sessionID)
+}
+
+// StopHARStream 停止持续 HAR 导出，返回最终写入的条目数等状态。
+func (a *App) StopHARStream(sessionID string) api.Response[HARStreamData] {
+	status, err := a.service.StopHARStream(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[HARStreamData](code, msg)
+	}
+
+	return api.OK(HARStreamData{Status: status})
+}
+
+// GetHARStreamStatus 获取持续 HAR 导出的状态。
+func (a *App) GetHARStreamStatus(sessionID string) api.Response[HARStreamData] {
+	status, err := a.service.GetHARStreamStatus(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[HARStreamData](code, msg)
+	}
+
+	return api.OK(HARStreamData{Status: status})
+}
+
+// StartContractCheck 弹出文件选择对话框选择 OpenAPI 规范（JSON 或 YAML），开启契约检查。
+func (a *App) StartContractCheck(sessionID string) api.Response[ContractData] {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select OpenAPI Spec",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "OpenAPI Specs (*.json, *.yaml, *.yml)", Pattern: "*.json;*.yaml;*.yml"},
+			{DisplayName: "All Files", Pattern: "*.*"},
+		},
+	})
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ContractData](code, msg)
+	}
+
+	if path == "" {
+		return api.OK(ContractData{})
+	}
+
+	status, err := a.service.StartContractCheck(a.ctx, domain.SessionID(sessionID), path)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ContractData](code, msg)
+	}
+
+	return api.OK(ContractData{Status: status})
+}
+
+// StopContractCheck 关闭 OpenAPI 契约检查。
+func (a *App) StopContractCheck(sessionID string) api.Response[api.EmptyData] {
+	if err := a.service.StopContractCheck(a.ctx, domain.SessionID(sessionID)); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// GetContractStatus 获取 OpenAPI 契约检查状态。
+func (a *App) GetContractStatus(sessionID string) api.Response[ContractData] {
+	status, err := a.service.GetContractStatus(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ContractData](code, msg)
+	}
+
+	return api.OK(ContractData{Status: status})
+}
+
+// SetSecretDetectors 设置会话启用的敏感信息检测器，为空时关闭检测。
+func (a *App) SetSecretDetectors(sessionID string, detectors []string) api.Response[SecretDetectorsData] {
+	if err := a.service.SetSecretDetectors(a.ctx, domain.SessionID(sessionID), detectors); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[SecretDetectorsData](code, msg)
+	}
+
+	return a.GetSecretDetectors(sessionID)
+}
+
+// GetSecretDetectors 获取会话启用的敏感信息检测器及所有可用检测器。
+func (a *App) GetSecretDetectors(sessionID string) api.Response[SecretDetectorsData] {
+	enabled, err := a.service.GetSecretDetectors(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[SecretDetectorsData](code, msg)
+	}
+
+	return api.OK(SecretDetectorsData{Enabled: enabled, Available: secrets.Detectors()})
+}
+
+// ArmBreakpoint 布置一次性断点，下一个 URL 包含 urlContains 且方法为 method 的请求将暂停等待人工处理，条件为空表示不限制；
+// stage 为暂停的阶段 request（默认）或 response，timeoutMS 为请求等待处理的最长时间，超时后按规则自动放行，0 表示使用默认值。
+func (a *App) ArmBreakpoint(sessionID, urlContains, method, stage string, timeoutMS int64) api.Response[BreakpointData] {
+	filter := domain.BreakpointFilter{URLContains: urlContains, Method: method, Stage: domain.BreakpointStage(stage), TimeoutMS: timeoutMS}
+	if err := a.service.ArmBreakpoint(a.ctx, domain.SessionID(sessionID), filter); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[BreakpointData](code, msg)
+	}
+
+	return a.GetBreakpointStatus(sessionID)
+}
+
+// DisarmBreakpoint 解除尚未命中的断点。
+func (a *App) DisarmBreakpoint(sessionID string) api.Response[BreakpointData] {
+	if err := a.service.DisarmBreakpoint(a.ctx, domain.SessionID(sessionID)); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[BreakpointData](code, msg)
+	}
+
+	return a.GetBreakpointStatus(sessionID)
+}
+
+// GetBreakpointStatus 获取断点是否布置及等待人工处理的请求。
+func (a *App) GetBreakpointStatus(sessionID string) api.Response[BreakpointData] {
+	status, err := a.service.GetBreakpointStatus(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[BreakpointData](code, msg)
+	}
+
+	return api.OK(BreakpointData{Status: status})
+}
+
+// ResolveHeldRequest 放行或拒绝被断点暂停的请求，放行后请求仍按规则正常处理。
+func (a *App) ResolveHeldRequest(sessionID, requestID string, approve bool) api.Response[BreakpointData] {
+	if err := a.service.ResolveHeldRequest(a.ctx, domain.SessionID(sessionID), requestID, approve); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[BreakpointData](code, msg)
+	}
+
+	return a.GetBreakpointStatus(sessionID)
+}
+
+// ApproveHeldRequest 以编辑后的请求放行在请求阶段暂停的请求，requestJSON 为修改后的请求（请求体以 base64 编码），
+// 省略的字段沿用暂停时的值，为空时等同于直接放行。
+func (a *App) ApproveHeldRequest(sessionID, requestID, requestJSON string) api.Response[BreakpointData] {
+	var edit *domain.Request
+	if requestJSON != "" {
+		edit = &domain.Request{}
+		if err := json.Unmarshal([]byte(requestJSON), edit); err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[BreakpointData](code, msg)
+		}
+	}
+
+	if err := a.service.ApproveHeldRequest(a.ctx, domain.SessionID(sessionID), requestID, edit); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[BreakpointData](code, msg)
+	}
+	return a.GetBreakpointStatus(sessionID)
+}
+
+// ApproveHeldResponse 以编辑后的响应放行在响应阶段暂停的请求，responseJSON 为修改后的响应（响应体以 base64 编码），
+// 省略的字段沿用暂停时的值，为空时等同于直接放行。
+func (a *App) ApproveHeldResponse(sessionID, requestID, responseJSON string) api.Response[BreakpointData] {
+	var edit *domain.Response
+	if responseJSON != "" {
+		edit = &domain.Response{}
+		if err := json.Unmarshal([]byte(responseJSON), edit); err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[BreakpointData](code, msg)
+		}
+	}
+
+	if err := a.service.ApproveHeldResponse(a.ctx, domain.SessionID(sessionID), requestID, edit); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[BreakpointData](code, msg)
+	}
+	return a.GetBreakpointStatus(sessionID)
+}
+
+// ListAuthChallenges 获取 auth 规则暂停、等待人工处理的认证质询。
+func (a *App) ListAuthChallenges(sessionID string) api.Response[AuthChallengesData] {
+	challenges, err := a.service.ListAuthChallenges(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[AuthChallengesData](code, msg)
+	}
+	return api.OK(AuthChallengesData{Challenges: challenges})
+}
+
+// ResolveAuthChallenge 处理等待中的认证质询，response 为 provide、cancel 或 default；
+// 以凭据应答且 username 为空时使用规则中的凭据。
+func (a *App) ResolveAuthChallenge(sessionID, challengeID, response, username, password string) api.Response[AuthChallengesData] {
+	decision := domain.AuthDecision{Response: domain.AuthResponse(response), Username: username, Password: password}
+	if err := a.service.ResolveAuthChallenge(a.ctx, domain.SessionID(sessionID), challengeID, decision); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[AuthChallengesData](code, msg)
+	}
+	return a.ListAuthChallenges(sessionID)
+}
+
+// GetRuleStats 获取指定会话的规则命中统计信息。
+func (a *App) GetRuleStats(sessionID string) api.Response[StatsData] {
+	stats, err := a.service.GetRuleStats(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[StatsData](code, msg)
+	}
+
+	return api.OK(StatsData{Stats: stats})
+}
+
+// GetRuleCoverage 获取指定会话的规则覆盖报告，会话停止后仍可获取最终报告。
+func (a *App) GetRuleCoverage(sessionID string) api.Response[CoverageData] {
+	report, err := a.service.GetRuleCoverage(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[CoverageData](code, msg)
+	}
+
+	return api.OK(CoverageData{Report: report})
+}
+
+// CheckAssertions 校验规则匹配次数断言，断言形如 "login-mock>=1"、"block-analytics==0"。
+func (a *App) CheckAssertions(sessionID string, assertions []string) api.Response[AssertionsData] {
+	parsed := make([]domain.RuleAssertion, 0, len(assertions))
+	for _, s := range assertions {
+		assertion, err := domain.ParseRuleAssertion(s)
+		if err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[AssertionsData](code, msg)
+		}
+		parsed = append(parsed, assertion)
+	}
+
+	report, err := a.service.CheckAssertions(a.ctx, domain.SessionID(sessionID), parsed)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[AssertionsData](code, msg)
+	}
+
+	return api.OK(AssertionsData{Report: report})
+}
+
+// SetGeolocation 设置地理位置覆盖，targetID 为空时作用于整个会话，location 为 nil 时清除覆盖。
+func (a *App) SetGeolocation(sessionID, targetID string, location *domain.GeoLocation) api.Response[api.EmptyData] {
+	err := a.service.SetGeolocation(a.ctx, domain.SessionID(sessionID), domain.TargetID(targetID), location)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// SetNetworkConditions 设置网络条件模拟，targetID 为空时作用于整个会话，conditions 为 nil 时清除模拟。
+func (a *App) SetNetworkConditions(sessionID, targetID string, conditions *domain.NetworkConditions) api.Response[api.EmptyData] {
+	err := a.service.SetNetworkConditions(a.ctx, domain.SessionID(sessionID), domain.TargetID(targetID), conditions)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// SetCacheDisabled 禁用或恢复会话内所有目标的浏览器 HTTP 缓存。
+func (a *App) SetCacheDisabled(sessionID string, disabled bool) api.Response[api.EmptyData] {
+	err := a.service.SetCacheDisabled(a.ctx, domain.SessionID(sessionID), disabled)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// SetDryRun 开启或关闭会话的演练模式，规则只记录本会产生的修改，流量原样放行。
+func (a *App) SetDryRun(sessionID string, enabled bool) api.Response[api.EmptyData] {
+	err := a.service.SetDryRun(a.ctx, domain.SessionID(sessionID), enabled)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// SetReplayMode 设置会话的录制回放缓存，opts.Mode 为空时关闭，已录制的响应保留。
+func (a *App) SetReplayMode(sessionID string, opts domain.ReplayCacheOptions) api.Response[ReplayCacheData] {
+	status, err := a.service.SetReplayMode(a.ctx, domain.SessionID(sessionID), opts)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ReplayCacheData](code, msg)
+	}
+
+	return api.OK(ReplayCacheData{Status: status})
+}
+
+// GetReplayStatus 获取录制回放缓存的配置、记录数与命中统计。
+func (a *App) GetReplayStatus(sessionID string) api.Response[ReplayCacheData] {
+	status, err := a.service.GetReplayStatus(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ReplayCacheData](code, msg)
+	}
+
+	return api.OK(ReplayCacheData{Status: status})
+}
+
+// SetTimezone 设置目标的时区覆盖，timezoneID 为空时清除覆盖。
+func (a *App) SetTimezone(sessionID, targetID, timezoneID string) api.Response[api.EmptyData] {
+	err := a.service.SetTimezone(a.ctx, domain.SessionID(sessionID), domain.TargetID(targetID), timezoneID)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// SetLocale 设置目标的区域覆盖，locale 为空时清除覆盖。
+func (a *App) SetLocale(sessionID, targetID, locale string) api.Response[api.EmptyData] {
+	err := a.service.SetLocale(a.ctx, domain.SessionID(sessionID), domain.TargetID(targetID), locale)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// ListCookies 获取目标可见的浏览器 Cookie，urls 为空时返回当前页面可见的 Cookie。
+func (a *App) ListCookies(sessionID, targetID string, urls []string) api.Response[CookiesData] {
+	cookies, err := a.service.ListCookies(a.ctx, domain.SessionID(sessionID), domain.TargetID(targetID), urls)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[CookiesData](code, msg)
+	}
+
+	return api.OK(CookiesData{Cookies: cookies})
+}
+
+// SetCookie 将 Cookie 写入目标所在浏览器上下文的 Cookie 存储。
+func (a *App) SetCookie(sessionID, targetID string, cookie domain.BrowserCookie) api.Response[api.EmptyData] {
+	err := a.service.SetCookie(a.ctx, domain.SessionID(sessionID), domain.TargetID(targetID), cookie)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// DeleteCookies 删除目标所在浏览器上下文中匹配条件的 Cookie。
+func (a *App) DeleteCookies(sessionID, targetID string, filter domain.CookieFilter) api.Response[api.EmptyData] {
+	err := a.service.DeleteCookies(a.ctx, domain.SessionID(sessionID), domain.TargetID(targetID), filter)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
+// ListGeoPresets 获取内置的地理位置预设。
+func (a *App) ListGeoPresets() api.Response[GeoPresetsData] {
+	return api.OK(GeoPresetsData{Presets: domain.GeoPresets()})
+}
+
+// ListNetworkPresets 获取内置的网络条件预设。
+func (a *App) ListNetworkPresets() api.Response[NetworkPresetsData] {
+	return api.OK(NetworkPresetsData{Presets: domain.NetworkPresets()})
+}
+
+// GetTrafficStats 获取会话按域名与资源类型的流量统计。
+func (a *App) GetTrafficStats(sessionID string) api.Response[TrafficStatsData] {
+	stats, err := a.service.GetTrafficStats(a.ctx, domain.SessionID(sessionID))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[TrafficStatsData](code, msg)
 	}
 
-	if path == "" {
-		return api.OK(api.EmptyData{})
-	}
+	return api.OK(TrafficStatsData{Stats: stats})
+}
 
-	err = os.WriteFile(path, []byte(rulesJSON), 0644)
+// GetReconnectStats 获取会话中目标连接意外断开后的重连统计，包括断开时遗留的暂停请求数。
+func (a *App) GetReconnectStats(sessionID string) api.Response[ReconnectStatsData] {
+	stats, err := a.service.GetReconnectStats(a.ctx, domain.SessionID(sessionID))
 	if err != nil {
 		code, msg := a.translateError(err)
-		return api.Fail[api.EmptyData](code, msg)
+		return api.Fail[ReconnectStatsData](code, msg)
 	}
 
-	return api.OK(api.EmptyData{})
+	return api.OK(ReconnectStatsData{Stats: stats})
 }
 
-// EnableInterception 启用指定会话的网络拦截功能。
-func (a *App) EnableInterception(sessionID string) api.Response[api.EmptyData] {
-	err := a.service.EnableInterception(a.ctx, domain.SessionID(sessionID))
+// GenerateReport 生成会话汇总报告，format 为 html 或 markdown。
+func (a *App) GenerateReport(sessionID, format string) api.Response[ReportData] {
+	f, err := report.ParseFormat(format)
 	if err != nil {
 		code, msg := a.translateError(err)
-		return api.Fail[api.EmptyData](code, msg)
+		return api.Fail[ReportData](code, msg)
 	}
 
-	a.log.Info("已启用拦截", "sessionID", sessionID)
-	return api.OK(api.EmptyData{})
+	content, err := a.service.GenerateReport(a.ctx, domain.SessionID(sessionID), f)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ReportData](code, msg)
+	}
+
+	return api.OK(ReportData{Format: f, Content: string(content)})
 }
 
-// DisableInterception 停用指定会话的网络拦截功能。
-func (a *App) DisableInterception(sessionID string) api.Response[api.EmptyData] {
-	err := a.service.DisableInterception(a.ctx, domain.SessionID(sessionID))
+// ExportReport 生成会话汇总报告并通过保存对话框写入文件。
+func (a *App) ExportReport(sessionID, format string) api.Response[api.EmptyData] {
+	f, err := report.ParseFormat(format)
 	if err != nil {
 		code, msg := a.translateError(err)
 		return api.Fail[api.EmptyData](code, msg)
 	}
 
-	a.log.Info("已停用拦截", "sessionID", sessionID)
-	return api.OK(api.EmptyData{})
-}
-
-// LoadRules 从 JSON 字符串加载规则配置到指定会话。
-func (a *App) LoadRules(sessionID string, rulesJSON string) api.Response[api.EmptyData] {
-	var cfg rulespec.Config
-	if err := json.Unmarshal([]byte(rulesJSON), &cfg); err != nil {
+	content, err := a.service.GenerateReport(a.ctx, domain.SessionID(sessionID), f)
+	if err != nil {
 		code, msg := a.translateError(err)
 		return api.Fail[api.EmptyData](code, msg)
 	}
 
-	err := a.service.LoadRules(a.ctx, domain.SessionID(sessionID), &cfg)
+	filter := runtime.FileFilter{DisplayName: "Markdown Files (*.md)", Pattern: "*.md"}
+	ext := ".md"
+	if f == domain.ReportFormatHTML {
+		filter = runtime.FileFilter{DisplayName: "HTML Files (*.html)", Pattern: "*.html"}
+		ext = ".html"
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: "report-" + sessionID + ext,
+		Title:           "Export Session Report",
+		Filters:         []runtime.FileFilter{filter},
+	})
 	if err != nil {
 		code, msg := a.translateError(err)
 		return api.Fail[api.EmptyData](code, msg)
 	}
 
-	a.log.Info("规则加载成功", "sessionID", sessionID, "ruleCount", len(cfg.Rules))
+	if path == "" {
+		return api.OK(api.EmptyData{})
+	}
+
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
 	return api.OK(api.EmptyData{})
 }
 
-// EnableTrafficCapture 启用或禁用全量流量捕获。
-func (a *App) EnableTrafficCapture(sessionID string, enabled bool) api.Response[api.EmptyData] {
-	err := a.service.EnableTrafficCapture(a.ctx, domain.SessionID(sessionID), enabled)
+// ReplayEvents 返回会话缓冲中序号大于 after 的事件，供前端重连后补齐期间错过的事件。
+func (a *App) ReplayEvents(sessionID string, after uint64) api.Response[ReplayEventsData] {
+	ctx, cancel := context.WithCancel(a.ctx)
+	defer cancel()
+	ch, err := a.service.SubscribeEvents(ctx, domain.SessionID(sessionID), after)
 	if err != nil {
 		code, msg := a.translateError(err)
-		return api.Fail[api.EmptyData](code, msg)
+		return api.Fail[ReplayEventsData](code, msg)
+	}
+
+	// 回放的事件在订阅时已写入通道，取完即返回
+	events := []domain.NetworkEvent{}
+	for {
+		select {
+		case evt, ok := <-ch:
+			if !ok {
+				return api.OK(ReplayEventsData{Events: events})
+			}
+			evt.Session = domain.SessionID(sessionID)
+			events = append(events, evt)
+		default:
+			return api.OK(ReplayEventsData{Events: events})
+		}
 	}
-	return api.OK(api.EmptyData{})
 }
 
-// GetRuleStats 获取指定会话的规则命中统计信息。
-func (a *App) GetRuleStats(sessionID string) api.Response[StatsData] {
-	stats, err := a.service.GetRuleStats(a.ctx, domain.SessionID(sessionID))
+// ReplayRequest 重新发出会话事件缓冲中 eventID 对应的请求，mode 为 page（在页面中发出）或 native（直接发出），为空时使用 page；
+// requestJSON 非空时为修改后的请求，代替原请求发出。重放的响应记录为新的事件并返回。
+func (a *App) ReplayRequest(sessionID, eventID, mode, requestJSON string) api.Response[ReplayData] {
+	opts := domain.ReplayOptions{EventID: eventID, Mode: domain.ReplayMode(mode)}
+	if requestJSON != "" {
+		opts.Request = &domain.Request{}
+		if err := json.Unmarshal([]byte(requestJSON), opts.Request); err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[ReplayData](code, msg)
+		}
+	}
+
+	evt, err := a.service.Replay(a.ctx, domain.SessionID(sessionID), opts)
 	if err != nil {
 		code, msg := a.translateError(err)
-		return api.Fail[StatsData](code, msg)
+		return api.Fail[ReplayData](code, msg)
 	}
+	return api.OK(ReplayData{Event: evt})
+}
 
-	return api.OK(StatsData{Stats: stats})
+// GetEventDiff 计算会话事件缓冲中 eventID 对应事件被规则修改前后的差异（URL、头部增删改、消息体补丁），
+// 请求或响应未被修改时对应部分为空。
+func (a *App) GetEventDiff(sessionID, eventID string) api.Response[EventDiffData] {
+	evt, err := a.service.GetEvent(a.ctx, domain.SessionID(sessionID), eventID)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[EventDiffData](code, msg)
+	}
+	return api.OK(EventDiffData{Diff: diff.Event(evt)})
 }
 
 // subscribeEvents 订阅拦截事件并通过 Wails 事件系统推送到前端。
 func (a *App) subscribeEvents(ctx context.Context, sessionID domain.SessionID) {
-	ch, err := a.service.SubscribeEvents(ctx, sessionID)
+	ch, err := a.service.SubscribeEvents(ctx, sessionID, 0)
 	if err != nil {
 		a.log.Err(err, "订阅事件失败", "sessionID", sessionID)
 		return
@@ -378,6 +1265,48 @@ func (a *App) subscribeEvents(ctx context.Context, sessionID domain.SessionID) {
 	}
 }
 
+// subscribeBreakpoint 订阅断点状态并通过 Wails 事件系统推送到前端。
+func (a *App) subscribeBreakpoint(ctx context.Context, sessionID domain.SessionID) {
+	ch, err := a.service.SubscribeBreakpoint(ctx, sessionID)
+	if err != nil {
+		a.log.Err(err, "订阅断点状态失败", "sessionID", sessionID)
+		return
+	}
+
+	for status := range ch {
+		runtime.EventsEmit(a.ctx, "breakpoint-status", BreakpointData{Status: status})
+	}
+	a.log.Debug("断点状态订阅结束", "sessionID", sessionID)
+}
+
+// subscribeConnection 订阅目标连接事件并通过 Wails 事件系统推送到前端。
+func (a *App) subscribeConnection(ctx context.Context, sessionID domain.SessionID) {
+	ch, err := a.service.SubscribeConnection(ctx, sessionID)
+	if err != nil {
+		a.log.Err(err, "订阅连接事件失败", "sessionID", sessionID)
+		return
+	}
+
+	for ev := range ch {
+		runtime.EventsEmit(a.ctx, "connection-event", ev)
+	}
+	a.log.Debug("连接事件订阅结束", "sessionID", sessionID)
+}
+
+// subscribeAuthChallenges 订阅等待中的认证质询并通过 Wails 事件系统推送到前端。
+func (a *App) subscribeAuthChallenges(ctx context.Context, sessionID domain.SessionID) {
+	ch, err := a.service.SubscribeAuthChallenges(ctx, sessionID)
+	if err != nil {
+		a.log.Err(err, "订阅认证质询失败", "sessionID", sessionID)
+		return
+	}
+
+	for challenges := range ch {
+		runtime.EventsEmit(a.ctx, "auth-challenges", AuthChallengesData{Challenges: challenges})
+	}
+	a.log.Debug("认证质询订阅结束", "sessionID", sessionID)
+}
+
 // subscribeTraffic 订阅全量流量事件并通过 Wails 事件系统推送到前端。
 func (a *App) subscribeTraffic(ctx context.Context, sessionID domain.SessionID) {
 	ch, err := a.service.SubscribeTraffic(ctx, sessionID)
@@ -431,13 +1360,23 @@ func (a *App) LaunchBrowser(headless bool) api.Response[BrowserData] {
 		}
 	}
 
+	// 设置中的值已校验，auto 表示自动查找
+	engine, _ := browser.ParseEngine(a.settingsRepo.GetBrowserEngine(a.ctx))
+
 	opts := browser.Options{
 		Logger:        a.log,
 		Headless:      headless,
 		ClearUserData: true,
+		Engine:        engine,
 		ExecPath:      browserPath,
 		Args:          browserArgs,
 	}
+	if mode, mappings := a.settingsRepo.GetHostMappings(a.ctx); mode == domain.HostMappingResolver {
+		opts.HostMappings = mappings
+	}
+	if proxy := a.settingsRepo.GetProxyConfig(a.ctx); proxy.Server != "" {
+		opts.Proxy = &proxy
+	}
 
 	b, err := browser.Start(a.ctx, opts)
 	if err != nil {
@@ -450,6 +1389,28 @@ func (a *App) LaunchBrowser(headless bool) api.Response[BrowserData] {
 	return api.OK(BrowserData{DevToolsURL: b.DevToolsURL})
 }
 
+// GetProxyConfig 获取上游代理配置。
+func (a *App) GetProxyConfig() api.Response[ProxyConfigData] {
+	return api.OK(ProxyConfigData{Config: a.settingsRepo.GetProxyConfig(a.ctx)})
+}
+
+// SetProxyConfig 保存上游代理配置；代理地址在下次启动浏览器时生效，认证凭据立即应用到当前会话。
+func (a *App) SetProxyConfig(cfg domain.ProxyConfig) api.Response[api.EmptyData] {
+	if err := a.settingsRepo.SetProxyConfig(a.ctx, cfg); err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
+
+	if a.currentSession != "" {
+		if err := a.service.SetProxyAuth(a.ctx, a.currentSession, cfg.Credentials()); err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[api.EmptyData](code, msg)
+		}
+	}
+
+	return api.OK(api.EmptyData{})
+}
+
 // CloseBrowser 关闭已启动的浏览器实例。
 func (a *App) CloseBrowser() api.Response[api.EmptyData] {
 	if a.browser == nil {
@@ -504,6 +1465,11 @@ func (a *App) SetSetting(key, value string) api.Response[api.EmptyData] {
 	return api.OK(api.EmptyData{})
 }
 
+// SetLogLevel 调整日志级别并保存到设置，立即对所有日志生效，级别为 debug、info、warn 或 error。
+func (a *App) SetLogLevel(level string) api.Response[api.EmptyData] {
+	return a.SetSetting(model.SettingKeyLogLevel, level)
+}
+
 // SetMultipleSettings 批量设置多个配置项。
 func (a *App) SetMultipleSettings(settingsJSON string) api.Response[api.EmptyData] {
 	var settings map[string]string
@@ -576,13 +1542,13 @@ func (a *App) GenerateNewRule(name string, existingCount int) api.Response[NewRu
 
 // SaveConfig 保存配置（创建或更新），dbID 为 0 时创建新配置。
 func (a *App) SaveConfig(dbID uint, configJSON string) api.Response[ConfigData] {
-	var cfg rulespec.Config
-	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+	cfg, _, err := rulespec.ParseConfig([]byte(configJSON))
+	if err != nil {
 		code, msg := a.translateError(err)
 		return api.Fail[ConfigData](code, msg)
 	}
 
-	config, err := a.configRepo.Save(a.ctx, dbID, &cfg)
+	config, err := a.configRepo.Save(a.ctx, dbID, cfg)
 	if err != nil {
 		code, msg := a.translateError(err)
 		return api.Fail[ConfigData](code, msg)
@@ -592,6 +1558,63 @@ func (a *App) SaveConfig(dbID uint, configJSON string) api.Response[ConfigData]
 	return api.OK(ConfigData{Config: config})
 }
 
+// MergeRuleSets 将来源配置的规则合并到目标配置，strategy 为 rename/skip/overwrite，决定规则 ID 冲突时的处理方式。
+func (a *App) MergeRuleSets(targetID, sourceID uint, strategy string) api.Response[MergeData] {
+	s, err := rulespec.ParseMergeStrategy(strategy)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[MergeData](code, msg)
+	}
+
+	config, res, err := a.configRepo.MergeRuleSets(a.ctx, targetID, sourceID, s)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[MergeData](code, msg)
+	}
+
+	a.log.Info("规则集已合并", "targetID", targetID, "sourceID", sourceID, "strategy", s,
+		"added", res.Added, "renamed", res.Renamed, "skipped", res.Skipped, "overwritten", res.Overwritten)
+	return api.OK(MergeData{Config: config, Result: res})
+}
+
+// DiffConfigs 计算两个已保存配置之间的差异（规则的新增、删除、字段变更与顺序调整）。
+func (a *App) DiffConfigs(fromID, toID uint) api.Response[DiffData] {
+	diff, err := a.configRepo.DiffConfigs(a.ctx, fromID, toID)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[DiffData](code, msg)
+	}
+	return api.OK(DiffData{Diff: diff})
+}
+
+// DiffConfigJSON 计算已保存配置与待导入 JSON 之间的差异，fromID 为 0 时与当前激活配置比较。
+func (a *App) DiffConfigJSON(fromID uint, configJSON string) api.Response[DiffData] {
+	to, _, err := rulespec.ParseConfig([]byte(configJSON))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[DiffData](code, msg)
+	}
+
+	var record *model.ConfigRecord
+	if fromID == 0 {
+		record, err = a.configRepo.GetActive(a.ctx)
+	} else if record, err = a.configRepo.FindOne(a.ctx, fromID); err == nil && record.ID == 0 {
+		err = domain.ErrConfigNotFound
+	}
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[DiffData](code, msg)
+	}
+
+	// 没有可比较的配置时视为与空配置比较
+	from, err := a.configRepo.ToRulespecConfig(record)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[DiffData](code, msg)
+	}
+	return api.OK(DiffData{Diff: rulespec.Diff(from, to)})
+}
+
 // DeleteConfig 删除指定 ID 的配置。
 func (a *App) DeleteConfig(id uint) api.Response[api.EmptyData] {
 	if err := a.configRepo.Delete(a.ctx, id); err != nil {
@@ -642,13 +1665,13 @@ func (a *App) RenameConfig(id uint, newName string) api.Response[api.EmptyData]
 
 // ImportConfig 导入配置（根据配置 ID 判断覆盖或新增）。
 func (a *App) ImportConfig(configJSON string) api.Response[ConfigData] {
-	var cfg rulespec.Config
-	if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+	cfg, _, err := rulespec.ParseConfig([]byte(configJSON))
+	if err != nil {
 		code, msg := a.translateError(err)
 		return api.Fail[ConfigData](code, msg)
 	}
 
-	config, err := a.configRepo.Upsert(a.ctx, &cfg)
+	config, err := a.configRepo.Upsert(a.ctx, cfg)
 	if err != nil {
 		code, msg := a.translateError(err)
 		return api.Fail[ConfigData](code, msg)
@@ -658,6 +1681,84 @@ func (a *App) ImportConfig(configJSON string) api.Response[ConfigData] {
 	return api.OK(ConfigData{Config: config})
 }
 
+// ExportShareCode 将配置编码为可通过聊天工具传递的压缩分享码。
+func (a *App) ExportShareCode(configJSON string) api.Response[ShareCodeData] {
+	cfg, _, err := rulespec.ParseConfig([]byte(configJSON))
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ShareCodeData](code, msg)
+	}
+
+	shareCode, err := rulespec.EncodeShareCode(cfg)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ShareCodeData](code, msg)
+	}
+
+	a.log.Debug("配置分享码已生成", "configID", cfg.ID, "length", len(shareCode))
+	return api.OK(ShareCodeData{Code: shareCode})
+}
+
+// ImportShareCode 解码分享码并导入配置（与 ImportConfig 相同，根据配置 ID 判断覆盖或新增）。
+func (a *App) ImportShareCode(shareCode string) api.Response[ConfigData] {
+	cfg, err := rulespec.DecodeShareCode(shareCode)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ConfigData](code, msg)
+	}
+
+	config, err := a.configRepo.Upsert(a.ctx, cfg)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ConfigData](code, msg)
+	}
+
+	a.log.Info("已从分享码导入配置", "dbID", config.ID, "configID", cfg.ID, "name", cfg.Name)
+	return api.OK(ConfigData{Config: config})
+}
+
+// ImportHARAsRules 弹出文件选择对话框选择 HAR 文件，将录制的响应转换为按方法与 URL 应答的回放规则并保存为新配置；
+// sessionID 不为空时同时加载到该会话，离线回放录制的流量。
+func (a *App) ImportHARAsRules(sessionID string) api.Response[ConfigData] {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select HAR File",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "HAR Files (*.har, *.jsonl)", Pattern: "*.har;*.jsonl"},
+			{DisplayName: "All Files", Pattern: "*.*"},
+		},
+	})
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ConfigData](code, msg)
+	}
+
+	if path == "" {
+		return api.OK(ConfigData{})
+	}
+
+	cfg, err := har.ImportMockRules(path)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ConfigData](code, msg)
+	}
+
+	config, err := a.configRepo.Upsert(a.ctx, cfg)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[ConfigData](code, msg)
+	}
+
+	if sessionID != "" {
+		if err := a.service.LoadRules(a.ctx, domain.SessionID(sessionID), cfg); err != nil {
+			code, msg := a.translateError(err)
+			return api.Fail[ConfigData](code, msg)
+		}
+	}
+
+	a.log.Info("已从 HAR 导入回放规则", "dbID", config.ID, "configID", cfg.ID, "rules", len(cfg.Rules), "path", path)
+	return api.OK(ConfigData{Config: config})
+}
+
 // LoadActiveConfigToSession 加载当前激活的配置到活跃会话。
 func (a *App) LoadActiveConfigToSession() api.Response[api.EmptyData] {
 	if a.currentSession == "" {
@@ -690,21 +1791,21 @@ func (a *App) LoadActiveConfigToSession() api.Response[api.EmptyData] {
 	return api.OK(api.EmptyData{})
 }
 
-// QueryMatchedEventHistory 根据条件查询匹配事件历史记录。
-func (a *App) QueryMatchedEventHistory(sessionID, finalResult, url, method string, startTime, endTime int64, offset, limit int) api.Response[EventHistoryData] {
+// QueryMatchedEventHistory 根据条件按时间倒序分页查询匹配事件历史记录，cursor 为上一页返回的 nextCursor，为空时从最新的记录开始。
+func (a *App) QueryMatchedEventHistory(sessionID, finalResult, url, method string, startTime, endTime int64, cursor string, limit int) api.Response[EventHistoryData] {
 	if a.eventRepo == nil {
 		code, msg := a.translateError(domain.ErrDatabaseNotInitialized)
 		return api.Fail[EventHistoryData](code, msg)
 	}
 
-	events, total, err := a.eventRepo.Query(a.ctx, repo.QueryOptions{
+	page, err := a.eventRepo.Query(a.ctx, repo.QueryOptions{
 		SessionID:   sessionID,
 		FinalResult: finalResult,
 		URL:         url,
 		Method:      method,
 		StartTime:   startTime,
 		EndTime:     endTime,
-		Offset:      offset,
+		Cursor:      cursor,
 		Limit:       limit,
 	})
 	if err != nil {
@@ -712,7 +1813,99 @@ func (a *App) QueryMatchedEventHistory(sessionID, finalResult, url, method strin
 		return api.Fail[EventHistoryData](code, msg)
 	}
 
-	return api.OK(EventHistoryData{Events: events, Total: total})
+	return api.OK(EventHistoryData{Events: page.Records, Total: page.Total, NextCursor: page.NextCursor})
+}
+
+// ExportEventHistory 将满足条件的全部匹配事件历史以 JSON Lines 格式流式写入保存对话框选择的文件。
+func (a *App) ExportEventHistory(sessionID, finalResult, url, method string, startTime, endTime int64) api.Response[EventExportData] {
+	if a.eventRepo == nil {
+		code, msg := a.translateError(domain.ErrDatabaseNotInitialized)
+		return api.Fail[EventExportData](code, msg)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		DefaultFilename: "events-" + time.Now().Format("20060102-150405") + ".jsonl",
+		Title:           "Export Event History",
+		Filters:         []runtime.FileFilter{{DisplayName: "JSON Lines (*.jsonl)", Pattern: "*.jsonl"}},
+	})
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[EventExportData](code, msg)
+	}
+
+	if path == "" {
+		return api.OK(EventExportData{})
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[EventExportData](code, msg)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	var count int64
+	err = a.eventRepo.Export(a.ctx, repo.QueryOptions{
+		SessionID:   sessionID,
+		FinalResult: finalResult,
+		URL:         url,
+		Method:      method,
+		StartTime:   startTime,
+		EndTime:     endTime,
+	}, func(rec *model.NetworkEventRecord) error {
+		count++
+		return enc.Encode(rec)
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[EventExportData](code, msg)
+	}
+
+	a.log.Info("已导出匹配事件历史", "path", path, "count", count)
+	return api.OK(EventExportData{Path: path, Count: count})
+}
+
+// FindEventsByCorrelationID 按关联 ID 查询匹配事件，用于与代理、后端日志中的同一请求对应。
+func (a *App) FindEventsByCorrelationID(correlationID string) api.Response[EventHistoryData] {
+	if a.eventRepo == nil {
+		code, msg := a.translateError(domain.ErrDatabaseNotInitialized)
+		return api.Fail[EventHistoryData](code, msg)
+	}
+
+	page, err := a.eventRepo.Query(a.ctx, repo.QueryOptions{CorrelationID: correlationID})
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[EventHistoryData](code, msg)
+	}
+
+	return api.OK(EventHistoryData{Events: page.Records, Total: page.Total, NextCursor: page.NextCursor})
+}
+
+// CompareSessions 对比两次会话的匹配事件历史（新增/消失的接口、状态码变化、载荷大小变化）。
+func (a *App) CompareSessions(fromSessionID, toSessionID string) api.Response[SessionDiffData] {
+	if a.eventRepo == nil {
+		code, msg := a.translateError(domain.ErrDatabaseNotInitialized)
+		return api.Fail[SessionDiffData](code, msg)
+	}
+
+	from, err := a.eventRepo.ListBySession(a.ctx, fromSessionID)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[SessionDiffData](code, msg)
+	}
+	to, err := a.eventRepo.ListBySession(a.ctx, toSessionID)
+	if err != nil {
+		code, msg := a.translateError(err)
+		return api.Fail[SessionDiffData](code, msg)
+	}
+
+	diff := sessiondiff.Compare(domain.SessionID(fromSessionID), domain.SessionID(toSessionID), from, to)
+	return api.OK(SessionDiffData{Diff: diff})
 }
 
 // CleanupEventHistory 清理指定天数之前的旧事件记录。
@@ -732,6 +1925,11 @@ func (a *App) CleanupEventHistory(retentionDays int) api.Response[api.EmptyData]
 	return api.OK(api.EmptyData{})
 }
 
+// ListUserAgentPresets 获取内置的 User-Agent 预设
+func (a *App) ListUserAgentPresets() api.Response[UserAgentPresetsData] {
+	return api.OK(UserAgentPresetsData{Presets: rulespec.UserAgentPresets()})
+}
+
 // GetVersion 获取应用版本号
 func (a *App) GetVersion() api.Response[VersionData] {
 	return api.OK(VersionData{Version: a.cfg.Version})
@@ -751,6 +1949,10 @@ func (a *App) GetSettings() api.Response[SettingsData] {
 func (a *App) SaveSettings(settings map[string]string) api.Response[api.EmptyData] {
 	ctx := context.Background()
 	err := a.settingsRepo.SetMultiple(ctx, settings)
+	if errors.Is(err, domain.ErrInvalidSetting) {
+		code, msg := a.translateError(err)
+		return api.Fail[api.EmptyData](code, msg)
+	}
 	if err != nil {
 		return api.Fail[api.EmptyData]("SAVE_SETTINGS_FAILED", "")
 	}
@@ -760,14 +1962,7 @@ func (a *App) SaveSettings(settings map[string]string) api.Response[api.EmptyDat
 // ResetSettings 恢复默认设置
 func (a *App) ResetSettings() api.Response[SettingsData] {
 	ctx := context.Background()
-	defaults := config.GetDefaultSettings()
-
-	settings := map[string]string{
-		model.SettingKeyLanguage:    defaults.Language,
-		model.SettingKeyTheme:       defaults.Theme,
-		model.SettingKeyBrowserArgs: defaults.BrowserArgs,
-		model.SettingKeyBrowserPath: defaults.BrowserPath,
-	}
+	settings := config.DefaultSettingValues()
 
 	err := a.settingsRepo.SetMultiple(ctx, settings)
 	if err != nil {
@@ -12,28 +12,50 @@ import (
 const (
 	CodeSessionNotFound     = "SESSION_NOT_FOUND"
 	CodeSessionStartFailed  = "SESSION_START_FAILED"
+	CodeSessionReadOnly     = "SESSION_READ_ONLY"
 	CodeNoTargetAttached    = "NO_TARGET_ATTACHED"
 	CodeTargetNotFound      = "TARGET_NOT_FOUND"
+	CodeTargetNotAttached   = "TARGET_NOT_ATTACHED"
 	CodeDevToolsUnreachable = "DEVTOOLS_UNREACHABLE"
 	CodeNetworkError        = "NETWORK_ERROR"
 	CodeInvalidConfig       = "INVALID_CONFIG"
 	CodeConfigNotFound      = "CONFIG_NOT_FOUND"
+	CodeRuleInvalid         = "RULE_INVALID"
+	CodeInvalidSetting      = "INVALID_SETTING"
 	CodeBrowserNotRunning   = "BROWSER_NOT_RUNNING"
 	CodeBrowserStartFailed  = "BROWSER_START_FAILED"
 	CodeDatabaseError       = "DATABASE_ERROR"
+	CodeRequestNotHeld      = "REQUEST_NOT_HELD"
+	CodeEventNotFound       = "EVENT_NOT_FOUND"
+	CodeChallengeNotPending = "AUTH_CHALLENGE_NOT_PENDING"
 	CodeUnknown             = "UNKNOWN_ERROR"
 )
 
-// 错误映射表（仅返回错误码，前端根据错误码进行国际化）
-var errorMappings = map[error]string{
-	domain.ErrSessionNotFound:        CodeSessionNotFound,
-	domain.ErrDevToolsUnreachable:    CodeDevToolsUnreachable,
-	domain.ErrNoTargetAttached:       CodeNoTargetAttached,
-	domain.ErrBrowserNotRunning:      CodeBrowserNotRunning,
-	domain.ErrBrowserStartFailed:     CodeBrowserStartFailed,
-	domain.ErrInvalidConfig:          CodeInvalidConfig,
-	domain.ErrConfigNotFound:         CodeConfigNotFound,
-	domain.ErrDatabaseNotInitialized: CodeDatabaseError,
+// errorMappings 错误映射表（仅返回错误码，前端根据错误码进行国际化）。
+// 按顺序匹配，同时匹配多个哨兵错误时（如 RuleError 包装了其他错误）取靠前的，因此具体的错误放在前面
+var errorMappings = []struct {
+	err  error
+	code string
+}{
+	{domain.ErrRuleInvalid, CodeRuleInvalid},
+	{domain.ErrSessionNotFound, CodeSessionNotFound},
+	{domain.ErrDevToolsUnreachable, CodeDevToolsUnreachable},
+	{domain.ErrNoTargetAttached, CodeNoTargetAttached},
+	{domain.ErrTargetNotFound, CodeTargetNotFound},
+	{domain.ErrTargetNotAttached, CodeTargetNotAttached},
+	{domain.ErrNetworkTimeout, CodeNetworkError},
+	{domain.ErrConnectionRefused, CodeNetworkError},
+	{domain.ErrSessionStartFailed, CodeSessionStartFailed},
+	{domain.ErrSessionReadOnly, CodeSessionReadOnly},
+	{domain.ErrBrowserNotRunning, CodeBrowserNotRunning},
+	{domain.ErrBrowserStartFailed, CodeBrowserStartFailed},
+	{domain.ErrConfigNotFound, CodeConfigNotFound},
+	{domain.ErrInvalidSetting, CodeInvalidSetting},
+	{domain.ErrDatabaseNotInitialized, CodeDatabaseError},
+	{domain.ErrRequestNotHeld, CodeRequestNotHeld},
+	{domain.ErrEventNotFound, CodeEventNotFound},
+	{domain.ErrChallengeNotPending, CodeChallengeNotPending},
+	{domain.ErrInvalidConfig, CodeInvalidConfig},
 }
 
 // translateError 将领域错误转换为错误码（前端根据错误码进行国际化）
@@ -43,10 +65,10 @@ func (a *App) translateError(err error) (code, message string) {
 	}
 
 	// 尝试匹配已知的领域错误
-	for domainErr, errorCode := range errorMappings {
-		if errors.Is(err, domainErr) {
-			a.log.Err(err, "业务错误", "code", errorCode)
-			return errorCode, ""
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			a.log.Err(err, "业务错误", "code", m.code)
+			return m.code, ""
 		}
 	}
 
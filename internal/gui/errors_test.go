@@ -0,0 +1,37 @@
+package gui
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/cdptest"
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/service"
+	"cdpnetool/pkg/domain"
+)
+
+func TestLoadRules_InvalidRegexCode(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), PendingCapacity: 16})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+
+	a := &App{ctx: ctx, log: logger.NewNop(), service: svc}
+	rules := `{"version":"1.0","name":"test","rules":[{"id":"broken","name":"broken","enabled":true,"stage":"request",
+		"match":{"allOf":[{"type":"urlContains","value":"regex:("}]},"actions":[]}]}`
+	// 映射按顺序匹配，同一错误每次都必须得到同一个错误码
+	for i := 0; i < 50; i++ {
+		if res := a.LoadRules(string(id), rules); res.Success || res.Code != CodeRuleInvalid {
+			t.Fatalf("attempt %d: got %+v, want %s", i, res, CodeRuleInvalid)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package gui
 
 import (
 	"cdpnetool/internal/storage/model"
+	"cdpnetool/pkg/diff"
 	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
 )
 
 // SessionData 会话数据
@@ -40,6 +42,27 @@ type ConfigListData struct {
 	Configs []model.ConfigRecord `json:"configs"`
 }
 
+// MergeData 规则集合并结果数据
+type MergeData struct {
+	Config *model.ConfigRecord  `json:"config"`
+	Result rulespec.MergeResult `json:"result"`
+}
+
+// DiffData 配置差异数据
+type DiffData struct {
+	Diff rulespec.ConfigDiff `json:"diff"`
+}
+
+// ValidationData 规则校验结果数据
+type ValidationData struct {
+	Report rulespec.ValidationReport `json:"report"`
+}
+
+// ShareCodeData 配置分享码数据
+type ShareCodeData struct {
+	Code string `json:"code"`
+}
+
 // NewConfigData 新配置数据
 type NewConfigData struct {
 	Config     *model.ConfigRecord `json:"config"`
@@ -56,10 +79,163 @@ type StatsData struct {
 	Stats domain.EngineStats `json:"stats"`
 }
 
+// RuleScheduleWindow 定时计划的时间窗口，按数据库 ID 引用已保存的配置
+type RuleScheduleWindow struct {
+	Name     string `json:"name"`
+	Start    string `json:"start"` // HH:MM
+	End      string `json:"end"`   // HH:MM，早于开始时间时跨越午夜
+	ConfigID uint   `json:"configId"`
+}
+
+// RuleScheduleInput 规则集定时计划，窗口之外使用 DefaultConfigID 指定的配置，为 0 时不启用任何规则
+type RuleScheduleInput struct {
+	Windows         []RuleScheduleWindow `json:"windows"`
+	DefaultConfigID uint                 `json:"defaultConfigId"`
+}
+
+// ScheduleStatusData 规则集定时计划状态数据
+type ScheduleStatusData struct {
+	Status domain.ScheduleStatus `json:"status"`
+}
+
+// CoverageData 规则覆盖报告数据
+type CoverageData struct {
+	Report domain.CoverageReport `json:"report"`
+}
+
+// AssertionsData 规则断言校验结果
+type AssertionsData struct {
+	Report domain.AssertionReport `json:"report"`
+}
+
+// BreakpointData 断点状态数据
+type BreakpointData struct {
+	Status domain.BreakpointStatus `json:"status"`
+}
+
+// CookiesData 浏览器 Cookie 列表数据
+type CookiesData struct {
+	Cookies []domain.BrowserCookie `json:"cookies"`
+}
+
+// AuthChallengesData 等待人工处理的认证质询
+type AuthChallengesData struct {
+	Challenges []domain.AuthChallenge `json:"challenges"`
+}
+
+// ConfigAppliedData 设置热更新的结果，随 "config-applied" 事件推送
+type ConfigAppliedData struct {
+	Key       string           `json:"key"`
+	Value     string           `json:"value"`
+	Scope     string           `json:"scope"`               // 生效范围：logger、events、session、redaction
+	SessionID domain.SessionID `json:"sessionId,omitempty"` // 作用于运行中会话时的会话 ID
+	Error     string           `json:"error,omitempty"`     // 应用失败的原因
+}
+
+// ReconnectStatsData 重连统计数据
+type ReconnectStatsData struct {
+	Stats domain.ReconnectStats `json:"stats"`
+}
+
+// TrafficStatsData 流量统计数据
+type TrafficStatsData struct {
+	Stats domain.TrafficStats `json:"stats"`
+}
+
+// ReportData 会话报告数据
+type ReportData struct {
+	Format  domain.ReportFormat `json:"format"`
+	Content string              `json:"content"`
+}
+
+// HARStreamData 持续 HAR 导出状态数据
+type HARStreamData struct {
+	Status domain.HARStreamStatus `json:"status"`
+}
+
+// ContractData OpenAPI 契约检查状态数据
+type ContractData struct {
+	Status domain.ContractStatus `json:"status"`
+}
+
+// ReplayCacheData 录制回放缓存状态数据
+type ReplayCacheData struct {
+	Status domain.ReplayCacheStatus `json:"status"`
+}
+
+// SecretDetectorsData 敏感信息检测器数据
+type SecretDetectorsData struct {
+	Enabled   []string `json:"enabled"`   // 会话启用的检测器
+	Available []string `json:"available"` // 所有内置检测器
+}
+
+// SessionDiffData 会话对比数据
+type SessionDiffData struct {
+	Diff domain.SessionDiff `json:"diff"`
+}
+
+// ProxyConfigData 上游代理配置数据
+type ProxyConfigData struct {
+	Config domain.ProxyConfig `json:"config"`
+}
+
+// BenchmarkData 基准测试结果数据
+type BenchmarkData struct {
+	Result domain.BenchmarkResult `json:"result"`
+}
+
+// RuleBenchmarkData 规则耗时分析结果数据
+type RuleBenchmarkData struct {
+	Result domain.RuleBenchmarkResult `json:"result"`
+}
+
+// RuleTestData 规则离线测试结果数据，Diffs 与 Result.Cases 一一对应，为候选规则产生的修改
+type RuleTestData struct {
+	Result domain.RuleTestResult `json:"result"`
+	Diffs  []diff.EventDiff      `json:"diffs"`
+}
+
 // EventHistoryData 事件历史数据
 type EventHistoryData struct {
-	Events []model.NetworkEventRecord `json:"events"`
-	Total  int64                      `json:"total"`
+	Events     []model.NetworkEventRecord `json:"events"`
+	Total      int64                      `json:"total"`      // 仅首页返回总数，翻页时为 0
+	NextCursor string                     `json:"nextCursor"` // 下一页游标，为空表示没有更多记录
+}
+
+// EventExportData 事件历史导出结果
+type EventExportData struct {
+	Path  string `json:"path"`  // 导出文件路径，用户取消时为空
+	Count int64  `json:"count"` // 导出的记录数
+}
+
+// ReplayEventsData 事件回放数据
+type ReplayEventsData struct {
+	Events []domain.NetworkEvent `json:"events"`
+}
+
+// ReplayData 请求重放数据
+type ReplayData struct {
+	Event domain.NetworkEvent `json:"event"`
+}
+
+// EventDiffData 事件修改前后的差异数据
+type EventDiffData struct {
+	Diff diff.EventDiff `json:"diff"`
+}
+
+// UserAgentPresetsData User-Agent 预设列表数据
+type UserAgentPresetsData struct {
+	Presets []rulespec.UserAgentPreset `json:"presets"`
+}
+
+// GeoPresetsData 地理位置预设列表数据
+type GeoPresetsData struct {
+	Presets []domain.GeoPreset `json:"presets"`
+}
+
+// NetworkPresetsData 网络条件预设列表数据
+type NetworkPresetsData struct {
+	Presets []domain.NetworkPreset `json:"presets"`
 }
 
 // VersionData 版本数据
@@ -0,0 +1,225 @@
+// Package har 将网络事件转换为 HAR 1.2 条目并持续写入磁盘，也可将 HAR 文件转换为回放录制流量的规则
+package har
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cdpnetool/pkg/domain"
+)
+
+// Version HAR 规范版本
+const Version = "1.2"
+
+// CreatorVersion 写入 HAR creator 字段的版本号，由应用启动时设置
+var CreatorVersion = "dev"
+
+// NameVersion HAR creator 字段
+type NameVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Pair HAR 中的名称/值对，用于头部、查询参数与 Cookie
+type Pair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Entry 单条 HAR 记录，以下划线开头的字段为 cdpnetool 扩展
+type Entry struct {
+	StartedDateTime string        `json:"startedDateTime"`
+	Time            float64       `json:"time"`
+	Request         Request       `json:"request"`
+	Response        Response      `json:"response"`
+	Cache           struct{}      `json:"cache"`
+	Timings         Timings       `json:"timings"`
+	ResourceType    string        `json:"_resourceType,omitempty"`
+	FinalResult     string        `json:"_finalResult,omitempty"`
+	MatchedRules    []MatchedRule `json:"_matchedRules,omitempty"`
+}
+
+// Request HAR 请求
+type Request struct {
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	HTTPVersion string    `json:"httpVersion"`
+	Cookies     []Pair    `json:"cookies"`
+	Headers     []Pair    `json:"headers"`
+	QueryString []Pair    `json:"queryString"`
+	PostData    *PostData `json:"postData,omitempty"`
+	HeadersSize int       `json:"headersSize"`
+	BodySize    int       `json:"bodySize"`
+}
+
+// PostData HAR 请求体
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"_encoding,omitempty"` // 非 UTF-8 请求体以 base64 编码
+}
+
+// Response HAR 响应
+type Response struct {
+	Status      int     `json:"status"`
+	StatusText  string  `json:"statusText"`
+	HTTPVersion string  `json:"httpVersion"`
+	Cookies     []Pair  `json:"cookies"`
+	Headers     []Pair  `json:"headers"`
+	Content     Content `json:"content"`
+	RedirectURL string  `json:"redirectURL"`
+	HeadersSize int     `json:"headersSize"`
+	BodySize    int     `json:"bodySize"`
+
+	TransferSize int64 `json:"_transferSize,omitempty"` // 网络传输的总字节数，采集了网络计时时填充
+}
+
+// Content HAR 响应内容
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Timings HAR 耗时分解（毫秒），未采集网络计时时全部计入 wait
+type Timings struct {
+	DNS     float64 `json:"dns,omitempty"`
+	Connect float64 `json:"connect,omitempty"`
+	SSL     float64 `json:"ssl,omitempty"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// MatchedRule 命中的规则
+type MatchedRule struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Actions []string `json:"actions,omitempty"`
+}
+
+// FormatFor 返回导出格式，format 为空时按文件扩展名推断
+func FormatFor(path string, format domain.HARFormat) domain.HARFormat {
+	if format != "" {
+		return format
+	}
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		return domain.HARFormatJSONL
+	}
+	return domain.HARFormatJSON
+}
+
+// NewEntry 将网络事件转换为 HAR 条目；没有计时信息时以事件时间作为开始时间
+func NewEntry(evt domain.NetworkEvent) Entry {
+	req := evt.Request
+	started := evt.Timestamp
+	var elapsed float64
+	if evt.Response != nil && evt.Response.Timing.StartTime > 0 {
+		started = evt.Response.Timing.StartTime
+		if end := evt.Response.Timing.EndTime; end >= started {
+			elapsed = float64(end - started)
+		}
+	}
+
+	e := Entry{
+		StartedDateTime: time.UnixMilli(started).UTC().Format("2006-01-02T15:04:05.000Z"),
+		Time:            elapsed,
+		Request: Request{
+			Method:      req.Method,
+			URL:         req.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     sortedPairs(req.Cookies),
+			Headers:     sortedPairs(req.Headers),
+			QueryString: queryString(req.URL),
+			HeadersSize: -1,
+			BodySize:    len(req.Body),
+		},
+		Response: Response{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []Pair{},
+			Headers:     []Pair{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings:      Timings{Send: 0, Wait: elapsed, Receive: 0},
+		ResourceType: string(req.ResourceType),
+		FinalResult:  evt.FinalResult,
+	}
+
+	if len(req.Body) > 0 {
+		text, encoding := encodeBody(req.Body)
+		e.Request.PostData = &PostData{MimeType: req.Headers.Get("Content-Type"), Text: text, Encoding: encoding}
+	}
+
+	if res := evt.Response; res != nil {
+		e.Response.Status = res.StatusCode
+		e.Response.StatusText = http.StatusText(res.StatusCode)
+		e.Response.Headers = sortedPairs(res.Headers)
+		e.Response.RedirectURL = res.Headers.Get("Location")
+		e.Response.BodySize = len(res.Body)
+		e.Response.Content = Content{Size: len(res.Body), MimeType: res.Headers.Get("Content-Type")}
+		if len(res.Body) > 0 {
+			e.Response.Content.Text, e.Response.Content.Encoding = encodeBody(res.Body)
+		}
+		if t := res.Timing; t.HasPhases() {
+			e.Timings = Timings{DNS: t.DNS, Connect: t.Connect, SSL: t.SSL, Send: t.Send, Wait: t.TTFB, Receive: t.Transfer}
+			if t.EncodedBodySize > 0 {
+				e.Response.BodySize = int(t.EncodedBodySize)
+			}
+			e.Response.TransferSize = t.TransferSize
+		}
+	}
+
+	for _, m := range evt.MatchedRules {
+		e.MatchedRules = append(e.MatchedRules, MatchedRule{ID: m.RuleID, Name: m.RuleName, Actions: m.Actions})
+	}
+	return e
+}
+
+// sortedPairs 将映射按名称排序后转换为名称/值对
+func sortedPairs[M ~map[string]string](m M) []Pair {
+	pairs := make([]Pair, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, Pair{Name: k, Value: v})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// queryString 按 URL 中的原始顺序解析查询参数，保留重复的参数
+func queryString(rawURL string) []Pair {
+	pairs := []Pair{}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return pairs
+	}
+	for _, part := range strings.Split(u.RawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		pairs = append(pairs, Pair{Name: name, Value: value})
+	}
+	return pairs
+}
+
+// encodeBody 文本内容原样返回，二进制内容以 base64 编码
+func encodeBody(body []byte) (text, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
@@ -0,0 +1,110 @@
+package har_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"cdpnetool/internal/har"
+	"cdpnetool/pkg/domain"
+)
+
+func TestFormatFor(t *testing.T) {
+	tests := []struct {
+		path   string
+		format domain.HARFormat
+		want   domain.HARFormat
+	}{
+		{"out.har", "", domain.HARFormatJSON},
+		{"out.JSONL", "", domain.HARFormatJSONL},
+		{"out.har", domain.HARFormatJSONL, domain.HARFormatJSONL},
+	}
+	for _, tt := range tests {
+		if got := har.FormatFor(tt.path, tt.format); got != tt.want {
+			t.Errorf("FormatFor(%q, %q) = %q, want %q", tt.path, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestNewEntry(t *testing.T) {
+	evt := domain.NetworkEvent{
+		ID:          "req1",
+		Timestamp:   1700000000123,
+		FinalResult: "modified",
+		Request: domain.Request{
+			Method:       "POST",
+			URL:          "https://example.com/api?b=2&a=1&a=3",
+			Headers:      domain.Header{"X-B": "2", "Content-Type": "application/json"},
+			Cookies:      map[string]string{"sid": "abc"},
+			Body:         []byte(`{"k":1}`),
+			ResourceType: domain.ResourceTypeXHR,
+		},
+		Response: &domain.Response{
+			StatusCode: 302,
+			Headers:    domain.Header{"location": "/next", "content-type": "image/png"},
+			Body:       []byte{0x89, 'P', 'N', 'G', 0xff},
+		},
+		MatchedRules: []domain.RuleMatch{{RuleID: "r1", RuleName: "rule", Actions: []string{"setHeader"}}},
+	}
+
+	e := har.NewEntry(evt)
+	if e.StartedDateTime != "2023-11-14T22:13:20.123Z" {
+		t.Errorf("startedDateTime = %q", e.StartedDateTime)
+	}
+	if len(e.Request.Headers) != 2 || e.Request.Headers[0].Name != "Content-Type" {
+		t.Errorf("headers not sorted: %+v", e.Request.Headers)
+	}
+	// 查询参数保留原始顺序与重复项
+	if q := e.Request.QueryString; len(q) != 3 || q[0].Name != "b" || q[2].Value != "3" {
+		t.Errorf("unexpected queryString %+v", q)
+	}
+	if e.Request.PostData == nil || e.Request.PostData.Text != `{"k":1}` || e.Request.PostData.MimeType != "application/json" {
+		t.Errorf("unexpected postData %+v", e.Request.PostData)
+	}
+	if len(e.Request.Cookies) != 1 || e.Request.BodySize != 7 {
+		t.Errorf("unexpected request %+v", e.Request)
+	}
+
+	res := e.Response
+	if res.Status != 302 || res.StatusText != "Found" || res.RedirectURL != "/next" {
+		t.Errorf("unexpected response %+v", res)
+	}
+	if res.Content.Encoding != "base64" || res.Content.Text != base64.StdEncoding.EncodeToString(evt.Response.Body) || res.Content.MimeType != "image/png" {
+		t.Errorf("binary body not base64 encoded: %+v", res.Content)
+	}
+	if e.FinalResult != "modified" || len(e.MatchedRules) != 1 || e.ResourceType != "xhr" {
+		t.Errorf("unexpected extension fields %+v", e)
+	}
+}
+
+func TestNewEntry_Timing(t *testing.T) {
+	e := har.NewEntry(domain.NetworkEvent{
+		Timestamp: 1,
+		Request:   domain.Request{Method: "GET", URL: "https://example.com/app.js"},
+		Response: &domain.Response{
+			StatusCode: 200,
+			Body:       []byte("console.log(1)"),
+			Timing: domain.ResponseTiming{
+				DNS: 3, Connect: 20, SSL: 12, Send: 0.5, TTFB: 40, Transfer: 8,
+				TransferSize: 300, EncodedBodySize: 100,
+			},
+		},
+	})
+	want := har.Timings{DNS: 3, Connect: 20, SSL: 12, Send: 0.5, Wait: 40, Receive: 8}
+	if e.Timings != want {
+		t.Errorf("timings = %+v, want %+v", e.Timings, want)
+	}
+	// bodySize 为压缩后的传输大小，content.size 为解码后的大小
+	if e.Response.BodySize != 100 || e.Response.TransferSize != 300 || e.Response.Content.Size != 14 {
+		t.Errorf("unexpected sizes %+v", e.Response)
+	}
+}
+
+func TestNewEntry_NoResponse(t *testing.T) {
+	e := har.NewEntry(domain.NetworkEvent{Timestamp: 1, Request: domain.Request{Method: "GET", URL: "wss://example.com/ws"}})
+	if e.Response.Status != 0 || e.Response.BodySize != -1 || e.Response.Headers == nil {
+		t.Errorf("unexpected response %+v", e.Response)
+	}
+	if e.Request.PostData != nil || e.Request.QueryString == nil {
+		t.Errorf("unexpected request %+v", e.Request)
+	}
+}
@@ -0,0 +1,129 @@
+package har
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// replaySkipHeaders 回放时不再适用的响应头：录制的响应体已解码，长度与传输方式由浏览器按应答重新确定
+var replaySkipHeaders = []string{"Content-Encoding", "Content-Length", "Transfer-Encoding", "Connection", "Keep-Alive"}
+
+// document 文件中的一个 JSON 值：标准 HAR 文档或 JSON Lines 格式中的一条 entry
+type document struct {
+	Log *struct {
+		Entries []Entry `json:"entries"`
+	} `json:"log"`
+	Entry
+}
+
+// ReadEntries 读取 HAR 条目，支持标准 HAR 文档与每行一条 entry 的 JSON Lines 格式
+func ReadEntries(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	dec := json.NewDecoder(r)
+	for {
+		var doc document
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return nil, fmt.Errorf("%w: invalid HAR: %v", domain.ErrInvalidConfig, err)
+		}
+		if doc.Log != nil {
+			entries = append(entries, doc.Log.Entries...)
+		} else {
+			entries = append(entries, doc.Entry)
+		}
+	}
+}
+
+// MockRules 将 HAR 条目转换为离线回放录制流量的规则配置：每个方法与 URL 生成一条请求阶段的 block 规则，
+// 以录制的状态码、响应头与响应体直接应答。同一方法与 URL 有多条记录时使用最后一条，
+// 没有响应的条目与 WebSocket 连接被跳过
+func MockRules(name string, entries []Entry) *rulespec.Config {
+	cfg := rulespec.NewConfig(name)
+	index := make(map[string]int) // 方法与 URL -> 规则在配置中的位置
+	for _, e := range entries {
+		if e.Response.Status == 0 || e.ResourceType == string(domain.ResourceTypeWebSocket) {
+			continue
+		}
+		method := strings.ToUpper(e.Request.Method)
+		if method == "" {
+			method = "GET"
+		}
+		action := mockAction(e.Response)
+		key := method + " " + e.Request.URL
+		if i, ok := index[key]; ok {
+			cfg.Rules[i].Actions = []rulespec.Action{action}
+			continue
+		}
+
+		rule := rulespec.NewRule(key, len(cfg.Rules))
+		rule.Match.AllOf = []rulespec.Condition{
+			{Type: rulespec.ConditionURLEquals, Value: e.Request.URL},
+			{Type: rulespec.ConditionMethod, Values: []string{method}},
+		}
+		rule.Actions = []rulespec.Action{action}
+		index[key] = len(cfg.Rules)
+		cfg.Rules = append(cfg.Rules, rule)
+	}
+	return cfg
+}
+
+// ImportMockRules 读取 HAR 文件并转换为回放规则配置，配置以文件名命名；文件中没有可回放的响应时返回错误
+func ImportMockRules(path string) (*rulespec.Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := ReadEntries(f)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	cfg := MockRules(name, entries)
+	if len(cfg.Rules) == 0 {
+		return nil, fmt.Errorf("%w: HAR contains no recorded responses", domain.ErrInvalidConfig)
+	}
+	return cfg, nil
+}
+
+// mockAction 以录制的响应生成 block 行为
+func mockAction(res Response) rulespec.Action {
+	action := rulespec.Action{
+		Type:       rulespec.ActionBlock,
+		StatusCode: res.Status,
+		Headers:    make(map[string]string, len(res.Headers)),
+		Body:       res.Content.Text,
+	}
+	for _, h := range res.Headers {
+		if skipReplayHeader(h.Name) {
+			continue
+		}
+		// 与拦截到的响应一致，同名头部保留最后一个值
+		action.Headers[h.Name] = h.Value
+	}
+	if res.Content.Encoding == "base64" {
+		action.BodyEncoding = rulespec.BodyEncodingBase64
+	}
+	return action
+}
+
+// skipReplayHeader 判断响应头是否在回放时移除
+func skipReplayHeader(name string) bool {
+	for _, h := range replaySkipHeaders {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
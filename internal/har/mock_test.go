@@ -0,0 +1,92 @@
+package har_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cdpnetool/internal/har"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+const recorded = `{"log":{"version":"1.2","creator":{"name":"test","version":"1"},"entries":[
+{"request":{"method":"GET","url":"https://example.com/api/items?page=1"},"response":{"status":200,"headers":[{"name":"Content-Type","value":"application/json"},{"name":"content-encoding","value":"gzip"},{"name":"Content-Length","value":"12"}],"content":{"size":12,"mimeType":"application/json","text":"{\"items\":[]}"}}},
+{"request":{"method":"post","url":"https://example.com/api/items"},"response":{"status":201,"headers":[],"content":{"size":0,"mimeType":""}}},
+{"request":{"method":"GET","url":"https://example.com/logo.png"},"response":{"status":200,"headers":[{"name":"Content-Type","value":"image/png"}],"content":{"size":4,"mimeType":"image/png","text":"iVBORw==","encoding":"base64"}}},
+{"request":{"method":"GET","url":"https://example.com/api/items?page=1"},"response":{"status":200,"headers":[],"content":{"size":15,"mimeType":"application/json","text":"{\"items\":[1,2]}"}}},
+{"request":{"method":"GET","url":"https://example.com/failed"},"response":{"status":0,"headers":[],"content":{"size":0,"mimeType":""}}},
+{"request":{"method":"GET","url":"wss://example.com/ws"},"response":{"status":101,"headers":[],"content":{"size":0,"mimeType":""}},"_resourceType":"websocket"}
+]}}`
+
+func TestMockRules(t *testing.T) {
+	entries, err := har.ReadEntries(strings.NewReader(recorded))
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	cfg := har.MockRules("recorded", entries)
+	if err := rulespec.ValidateRuleIDs(cfg.Rules); err != nil {
+		t.Fatalf("generated config invalid: %v", err)
+	}
+	if len(cfg.Rules) != 3 {
+		t.Fatalf("got %d rules, want 3 (failed and websocket entries skipped, duplicates merged)", len(cfg.Rules))
+	}
+
+	// 同一方法与 URL 使用最后一条记录，编码相关的响应头被移除
+	items := cfg.Rules[0]
+	if items.Stage != rulespec.StageRequest || items.Match.AllOf[0].Value != "https://example.com/api/items?page=1" || items.Match.AllOf[1].Values[0] != "GET" {
+		t.Errorf("unexpected match %+v", items.Match)
+	}
+	if a := items.Actions[0]; a.Type != rulespec.ActionBlock || a.StatusCode != 200 || a.Body != `{"items":[1,2]}` || len(a.Headers) != 0 {
+		t.Errorf("got action %+v, want last recorded response", a)
+	}
+	// 方法统一为大写，二进制响应体保留 base64 编码
+	if m := cfg.Rules[1].Match.AllOf[1].Values[0]; m != "POST" || cfg.Rules[1].Actions[0].StatusCode != 201 {
+		t.Errorf("got method %q status %d", m, cfg.Rules[1].Actions[0].StatusCode)
+	}
+	if a := cfg.Rules[2].Actions[0]; a.BodyEncoding != rulespec.BodyEncodingBase64 || a.Body != "iVBORw==" || a.Headers["Content-Type"] != "image/png" {
+		t.Errorf("got action %+v, want base64 body", a)
+	}
+}
+
+func TestReadEntries_JSONL(t *testing.T) {
+	lines := `{"request":{"method":"GET","url":"https://example.com/a"},"response":{"status":200,"content":{"text":"a"}}}
+{"request":{"method":"GET","url":"https://example.com/b"},"response":{"status":404,"content":{"text":"b"}}}
+`
+	entries, err := har.ReadEntries(strings.NewReader(lines))
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[1].Response.Status != 404 {
+		t.Errorf("got entries %+v", entries)
+	}
+
+	if _, err := har.ReadEntries(strings.NewReader(`{"log":`)); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got error %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestImportMockRules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkout.har")
+	if err := os.WriteFile(path, []byte(recorded), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := har.ImportMockRules(path)
+	if err != nil {
+		t.Fatalf("ImportMockRules() error = %v", err)
+	}
+	if cfg.Name != "checkout" || len(cfg.Rules) != 3 {
+		t.Errorf("got config %q with %d rules", cfg.Name, len(cfg.Rules))
+	}
+
+	empty := filepath.Join(dir, "empty.har")
+	if err := os.WriteFile(empty, []byte(`{"log":{"entries":[]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := har.ImportMockRules(empty); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got error %v, want ErrInvalidConfig for HAR without responses", err)
+	}
+}
@@ -0,0 +1,113 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"cdpnetool/pkg/domain"
+)
+
+// trailer har 格式文件的结尾，每次追加条目前回退覆盖
+const trailer = "\n]}}\n"
+
+// Writer 持续写入 HAR 条目的文件写入器，并发安全。
+// har 格式在每条写入后重写文件结尾，保证文件随时是合法的 HAR；jsonl 格式每行一条 entry
+type Writer struct {
+	mu      sync.Mutex
+	f       *os.File
+	path    string
+	format  domain.HARFormat
+	entries int64
+}
+
+// Create 创建输出文件并写入文件头，已存在的文件被覆盖
+func Create(path string, format domain.HARFormat) (*Writer, error) {
+	format = FormatFor(path, format)
+	if format != domain.HARFormatJSON && format != domain.HARFormatJSONL {
+		return nil, fmt.Errorf("%w: unknown HAR format %q", domain.ErrInvalidConfig, format)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("%w: empty HAR output path", domain.ErrInvalidConfig)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{f: f, path: path, format: format}
+	if format == domain.HARFormatJSON {
+		creator, _ := json.Marshal(NameVersion{Name: "cdpnetool", Version: CreatorVersion})
+		header := fmt.Sprintf(`{"log":{"version":%q,"creator":%s,"entries":[`, Version, creator)
+		if _, err := io.WriteString(f, header+trailer); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Path 返回输出文件路径
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// Format 返回输出格式
+func (w *Writer) Format() domain.HARFormat {
+	return w.format
+}
+
+// Entries 返回已写入的条目数
+func (w *Writer) Entries() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.entries
+}
+
+// Write 追加一条网络事件
+func (w *Writer) Write(evt domain.NetworkEvent) error {
+	data, err := json.Marshal(NewEntry(evt))
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return os.ErrClosed
+	}
+
+	var buf []byte
+	if w.format == domain.HARFormatJSONL {
+		buf = append(data, '\n')
+	} else {
+		if _, err := w.f.Seek(-int64(len(trailer)), io.SeekEnd); err != nil {
+			return err
+		}
+		if w.entries > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '\n')
+		buf = append(buf, data...)
+		buf = append(buf, trailer...)
+	}
+	if _, err := w.f.Write(buf); err != nil {
+		return err
+	}
+	w.entries++
+	return nil
+}
+
+// Close 关闭输出文件，重复调用无副作用
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
@@ -0,0 +1,122 @@
+package har_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cdpnetool/internal/har"
+	"cdpnetool/pkg/domain"
+)
+
+func event(url string) domain.NetworkEvent {
+	return domain.NetworkEvent{
+		Timestamp: 1700000000000,
+		Request:   domain.Request{Method: "GET", URL: url},
+		Response:  &domain.Response{StatusCode: 200, Body: []byte("ok")},
+	}
+}
+
+type harFile struct {
+	Log struct {
+		Version string            `json:"version"`
+		Creator har.NameVersion   `json:"creator"`
+		Entries []json.RawMessage `json:"entries"`
+	} `json:"log"`
+}
+
+func readHAR(t *testing.T, path string) harFile {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f harFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatalf("invalid HAR: %v\n%s", err, data)
+	}
+	return f
+}
+
+func TestWriter_HAR(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.har")
+	w, err := har.Create(path, "")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer w.Close()
+
+	// 尚未写入条目时文件已是合法 HAR
+	if f := readHAR(t, path); f.Log.Version != "1.2" || f.Log.Creator.Name != "cdpnetool" || len(f.Log.Entries) != 0 {
+		t.Errorf("unexpected empty HAR %+v", f)
+	}
+
+	for i, url := range []string{"https://a.example/1", "https://a.example/2", "https://a.example/3"} {
+		if err := w.Write(event(url)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		// 每次写入后文件都可被完整解析
+		if f := readHAR(t, path); len(f.Log.Entries) != i+1 {
+			t.Errorf("after %d writes got %d entries", i+1, len(f.Log.Entries))
+		}
+	}
+	if w.Entries() != 3 {
+		t.Errorf("Entries() = %d, want 3", w.Entries())
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := w.Write(event("https://a.example/4")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write() after Close = %v, want os.ErrClosed", err)
+	}
+}
+
+func TestWriter_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.jsonl")
+	w, err := har.Create(path, "")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if w.Format() != domain.HARFormatJSONL {
+		t.Fatalf("Format() = %q, want jsonl", w.Format())
+	}
+	for _, url := range []string{"https://a.example/1", "https://a.example/2"} {
+		if err := w.Write(event(url)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var urls []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e har.Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("invalid line %q: %v", sc.Text(), err)
+		}
+		urls = append(urls, e.Request.URL)
+	}
+	if len(urls) != 2 || urls[1] != "https://a.example/2" {
+		t.Errorf("got urls %v", urls)
+	}
+}
+
+func TestCreate_Invalid(t *testing.T) {
+	if _, err := har.Create(filepath.Join(t.TempDir(), "out.har"), "xml"); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+	if _, err := har.Create("", ""); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+}
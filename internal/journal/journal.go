@@ -0,0 +1,99 @@
+// Package journal 提供只追加的拦截决策日志，独立于事件数据库写入，用于崩溃或降级后的事后复盘
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"cdpnetool/internal/logger"
+	"cdpnetool/pkg/domain"
+)
+
+// Journal 以 JSON Lines 格式追加写入决策记录的日志文件
+// 每条记录以单次 write 直接写入文件，不经过用户态缓冲，进程崩溃时已追加的记录不会丢失
+type Journal struct {
+	mu     sync.Mutex
+	f      *os.File
+	log    logger.Logger
+	closed bool
+}
+
+// Open 以追加模式打开决策日志文件，目录不存在时自动创建
+func Open(path string, l logger.Logger) (*Journal, error) {
+	if l == nil {
+		l = logger.NewNop()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create journal dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	return &Journal{f: f, log: l}, nil
+}
+
+// Path 返回日志文件路径
+func (j *Journal) Path() string {
+	return j.f.Name()
+}
+
+// Append 追加一条决策记录；降级记录额外落盘，保证异常路径可复盘。j 为 nil 时不做任何事
+func (j *Journal) Append(e domain.DecisionEntry) {
+	if j == nil {
+		return
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		j.log.Warn("序列化决策记录失败", "requestID", e.RequestID, "error", err)
+		return
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return
+	}
+	if _, err := j.f.Write(line); err != nil {
+		j.log.Warn("写入决策日志失败", "requestID", e.RequestID, "error", err)
+		return
+	}
+	if e.Degraded {
+		_ = j.f.Sync()
+	}
+}
+
+// Close 落盘并关闭日志文件，重复调用安全
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return nil
+	}
+	j.closed = true
+	if err := j.f.Sync(); err != nil {
+		_ = j.f.Close()
+		return err
+	}
+	return j.f.Close()
+}
+
+// Fingerprint 计算请求指纹：方法、URL 与请求体的 SHA-256 摘要前 16 位十六进制
+func Fingerprint(method, url string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(url))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,143 @@
+package journal_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"cdpnetool/internal/journal"
+	"cdpnetool/pkg/domain"
+)
+
+func readEntries(t *testing.T, path string) []domain.DecisionEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	defer f.Close()
+
+	var entries []domain.DecisionEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e domain.DecisionEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("malformed line %q: %v", sc.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestJournal_AppendAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "decisions.jsonl")
+	j, err := journal.Open(path, nil)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	if j.Path() != path {
+		t.Errorf("got path %q, want %q", j.Path(), path)
+	}
+	j.Append(domain.DecisionEntry{RequestID: "r1", Action: "block", Call: "Fetch.fulfillRequest", Rules: []string{"rule1"}})
+	j.Append(domain.DecisionEntry{RequestID: "r2", Action: "pass", Call: "Fetch.continueRequest", Degraded: true, Error: "boom"})
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	// 重复关闭安全，关闭后追加被忽略
+	if err := j.Close(); err != nil {
+		t.Errorf("second Close error: %v", err)
+	}
+	j.Append(domain.DecisionEntry{RequestID: "late"})
+
+	// 重新打开时在原文件末尾追加
+	j, err = journal.Open(path, nil)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	j.Append(domain.DecisionEntry{RequestID: "r3", Action: "modify"})
+	_ = j.Close()
+
+	entries := readEntries(t, path)
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if e := entries[0]; e.RequestID != "r1" || e.Call != "Fetch.fulfillRequest" || len(e.Rules) != 1 {
+		t.Errorf("got first entry %+v", e)
+	}
+	if e := entries[1]; !e.Degraded || e.Error != "boom" {
+		t.Errorf("got degraded entry %+v", e)
+	}
+	if entries[2].RequestID != "r3" {
+		t.Errorf("got last entry %q, want r3", entries[2].RequestID)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm&0o077 != 0 {
+		t.Errorf("got mode %v, want owner-only", perm)
+	}
+}
+
+func TestJournal_ConcurrentAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.jsonl")
+	j, err := journal.Open(path, nil)
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				j.Append(domain.DecisionEntry{RequestID: fmt.Sprintf("%d-%d", w, i), URL: "https://example.com/api"})
+			}
+		}(w)
+	}
+	wg.Wait()
+	_ = j.Close()
+
+	// 每条记录独占一行，并发写入不会交错
+	if got := len(readEntries(t, path)); got != 400 {
+		t.Errorf("got %d entries, want 400", got)
+	}
+}
+
+func TestJournal_Nil(t *testing.T) {
+	var j *journal.Journal
+	j.Append(domain.DecisionEntry{RequestID: "r1"})
+	if err := j.Close(); err != nil {
+		t.Errorf("nil Close error: %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := journal.Fingerprint("POST", "https://example.com/api", []byte(`{"a":1}`))
+	if len(a) != 16 {
+		t.Fatalf("got fingerprint %q, want 16 hex chars", a)
+	}
+	if b := journal.Fingerprint("POST", "https://example.com/api", []byte(`{"a":1}`)); a != b {
+		t.Errorf("fingerprint not stable: %q vs %q", a, b)
+	}
+	cases := []struct {
+		name, method, url, body string
+	}{
+		{"不同方法", "PUT", "https://example.com/api", `{"a":1}`},
+		{"不同 URL", "POST", "https://example.com/api2", `{"a":1}`},
+		{"不同请求体", "POST", "https://example.com/api", `{"a":2}`},
+		{"字段边界", "POSThttps://example.com/api", "", `{"a":1}`},
+	}
+	for _, tc := range cases {
+		if got := journal.Fingerprint(tc.method, tc.url, []byte(tc.body)); got == a {
+			t.Errorf("%s: got same fingerprint %q", tc.name, got)
+		}
+	}
+}
@@ -1,13 +1,15 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
-	"github.com/rs/zerolog"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
@@ -24,130 +26,212 @@ type Logger interface {
 // Options 日志配置选项
 type Options struct {
 	Level      string   // 日志级别: debug, info, warn, error
+	Format     string   // 输出格式: text（默认）, json
 	Writers    []string // 输出目标: console, file
 	Dir        string   // 日志目录（如果为空则使用默认目录）
 	Filename   string   // 日志文件名（如果为空则使用默认文件名 "app.log"）
-	MaxSize    int      // 每个日志文件最大 MB
+	MaxSize    int      // 每个日志文件最大 MB，超出后轮转
 	MaxBackups int      // 保留的最大旧文件数
 	MaxAge     int      // 保留的最大天数
 	Compress   bool     // 是否压缩旧文件
 }
 
-type zeroLogger struct {
-	logger zerolog.Logger
+// Leveler 支持运行时调整日志级别的日志记录器，调整对其 With 派生的记录器同样生效
+type Leveler interface {
+	SetLevel(level string) error
 }
 
-// New 创建一个新的结构化日志记录器
-func New(opts Options) Logger {
-	level := zerolog.DebugLevel
-	switch opts.Level {
+// levelDisabled 高于所有级别，不输出任何日志
+const levelDisabled = slog.Level(1 << 20)
+
+// timeFormat 日志时间格式
+const timeFormat = "2006-01-02 15:04:05.000"
+
+type slogLogger struct {
+	logger *slog.Logger
+	level  *slog.LevelVar // 当前生效的级别，与派生的记录器共享
+}
+
+// parseLevel 解析日志级别名称
+func parseLevel(name string) (slog.Level, error) {
+	switch name {
+	case "debug":
+		return slog.LevelDebug, nil
 	case "info":
-		level = zerolog.InfoLevel
+		return slog.LevelInfo, nil
 	case "warn":
-		level = zerolog.WarnLevel
+		return slog.LevelWarn, nil
 	case "error":
-		level = zerolog.ErrorLevel
+		return slog.LevelError, nil
+	}
+	return levelDisabled, fmt.Errorf("unknown log level %q", name)
+}
+
+// ValidateFormat 校验输出格式名称，为空时使用 text
+func ValidateFormat(format string) error {
+	switch format {
+	case "", "text", "json":
+		return nil
+	}
+	return fmt.Errorf("unknown log format %q", format)
+}
+
+// New 创建一个新的结构化日志记录器
+func New(opts Options) Logger {
+	level, err := parseLevel(opts.Level)
+	if err != nil {
+		level = slog.LevelDebug
 	}
 
 	var writers []io.Writer
 	for _, w := range opts.Writers {
 		switch w {
 		case "console":
-			writers = append(writers, zerolog.ConsoleWriter{
-				Out:        os.Stderr,
-				TimeFormat: "2006-01-02 15:04:05.000",
-			})
+			writers = append(writers, os.Stderr)
 		case "file":
-			// 获取日志目录
-			logDir := opts.Dir
-			if logDir == "" {
-				var err error
-				logDir, err = GetDefaultLogDir()
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "无法获取默认日志目录: %v\n", err)
-					continue
-				}
+			if rotated := newFileWriter(opts); rotated != nil {
+				writers = append(writers, rotated)
 			}
+		}
+	}
 
-			// 获取日志文件名
-			logFilename := opts.Filename
-			if logFilename == "" {
-				logFilename = "app.log" // 默认文件名
-			}
-
-			// 拼接完整的日志文件路径
-			logPath := filepath.Join(logDir, logFilename)
-
-			// 确保日志目录存在
-			if err := os.MkdirAll(logDir, 0755); err != nil {
-				fmt.Fprintf(os.Stderr, "无法创建日志目录 %s: %v\n", logDir, err)
-				continue
-			}
+	if len(writers) == 0 {
+		return NewNop()
+	}
 
-			lumberjackLogger := &lumberjack.Logger{
-				Filename:   logPath,
-				MaxSize:    opts.MaxSize,
-				MaxBackups: opts.MaxBackups,
-				MaxAge:     opts.MaxAge,
-				Compress:   opts.Compress,
-				LocalTime:  true,
+	lv := new(slog.LevelVar)
+	lv.Set(level)
+	handlerOpts := &slog.HandlerOptions{
+		AddSource: true,
+		Level:     lv,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
 			}
-			if lumberjackLogger.MaxSize <= 0 {
-				lumberjackLogger.MaxSize = 10 // 默认 10MB
-			}
-			if lumberjackLogger.MaxBackups <= 0 {
-				lumberjackLogger.MaxBackups = 5
-			}
-			if lumberjackLogger.MaxAge <= 0 {
-				lumberjackLogger.MaxAge = 30
+			switch a.Key {
+			case slog.TimeKey:
+				a.Value = slog.StringValue(a.Value.Time().Format(timeFormat))
+			case slog.SourceKey:
+				// 只保留文件名与行号
+				if src, ok := a.Value.Any().(*slog.Source); ok {
+					a.Value = slog.StringValue(fmt.Sprintf("%s:%d", filepath.Base(src.File), src.Line))
+				}
 			}
+			return a
+		},
+	}
+	multi := io.MultiWriter(writers...)
+	var h slog.Handler
+	if opts.Format == "json" {
+		h = slog.NewJSONHandler(multi, handlerOpts)
+	} else {
+		h = slog.NewTextHandler(multi, handlerOpts)
+	}
+	return &slogLogger{logger: slog.New(h), level: lv}
+}
 
-			writers = append(writers, lumberjackLogger)
+// newFileWriter 创建按大小轮转的日志文件，目录为空时写到平台的数据目录，无法创建目录时返回 nil
+func newFileWriter(opts Options) io.Writer {
+	// 获取日志目录
+	logDir := opts.Dir
+	if logDir == "" {
+		var err error
+		logDir, err = GetDefaultLogDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法获取默认日志目录: %v\n", err)
+			return nil
 		}
 	}
 
-	if len(writers) == 0 {
-		return NewNop()
+	// 获取日志文件名
+	logFilename := opts.Filename
+	if logFilename == "" {
+		logFilename = "app.log" // 默认文件名
 	}
 
-	multi := io.MultiWriter(writers...)
-	l := zerolog.New(multi).
-		With().
-		Timestamp().
-		CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + 1).
-		Logger().
-		Level(level)
+	// 确保日志目录存在
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "无法创建日志目录 %s: %v\n", logDir, err)
+		return nil
+	}
 
-	return &zeroLogger{logger: l}
+	lumberjackLogger := &lumberjack.Logger{
+		Filename:   filepath.Join(logDir, logFilename),
+		MaxSize:    opts.MaxSize,
+		MaxBackups: opts.MaxBackups,
+		MaxAge:     opts.MaxAge,
+		Compress:   opts.Compress,
+		LocalTime:  true,
+	}
+	if lumberjackLogger.MaxSize <= 0 {
+		lumberjackLogger.MaxSize = 10 // 默认 10MB
+	}
+	if lumberjackLogger.MaxBackups <= 0 {
+		lumberjackLogger.MaxBackups = 5
+	}
+	if lumberjackLogger.MaxAge <= 0 {
+		lumberjackLogger.MaxAge = 30
+	}
+	return lumberjackLogger
 }
 
 // NewNop 返回一个不执行任何操作的日志记录器
 func NewNop() Logger {
-	return &zeroLogger{logger: zerolog.Nop()}
+	lv := new(slog.LevelVar)
+	lv.Set(levelDisabled)
+	return &slogLogger{logger: slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: lv})), level: lv}
 }
 
-func (z *zeroLogger) Debug(msg string, fields ...any) {
-	z.logger.Debug().Fields(fields).Msg(msg)
+// SetLevel 调整日志级别，立即对所有派生的记录器生效
+func (l *slogLogger) SetLevel(level string) error {
+	lv, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.Set(lv)
+	return nil
 }
 
-func (z *zeroLogger) Info(msg string, fields ...any) {
-	z.logger.Info().Fields(fields).Msg(msg)
+// log 按当前级别输出一条日志，调用位置取日志方法的调用方
+func (l *slogLogger) log(level slog.Level, msg string, fields []any) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // 跳过 Callers、log 与日志方法本身
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(fields...)
+	_ = l.logger.Handler().Handle(ctx, r)
 }
 
-func (z *zeroLogger) Warn(msg string, fields ...any) {
-	z.logger.Warn().Fields(fields).Msg(msg)
+func (l *slogLogger) Debug(msg string, fields ...any) {
+	l.log(slog.LevelDebug, msg, fields)
 }
 
-func (z *zeroLogger) Error(msg string, fields ...any) {
-	z.logger.Error().Fields(fields).Msg(msg)
+func (l *slogLogger) Info(msg string, fields ...any) {
+	l.log(slog.LevelInfo, msg, fields)
 }
 
-func (z *zeroLogger) Err(err error, msg string, fields ...any) {
-	z.logger.Err(err).Fields(fields).Msg(msg)
+func (l *slogLogger) Warn(msg string, fields ...any) {
+	l.log(slog.LevelWarn, msg, fields)
+}
+
+func (l *slogLogger) Error(msg string, fields ...any) {
+	l.log(slog.LevelError, msg, fields)
+}
+
+func (l *slogLogger) Err(err error, msg string, fields ...any) {
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		fields = append([]any{"error", err}, fields...)
+	}
+	l.log(level, msg, fields)
 }
 
-func (z *zeroLogger) With(fields ...any) Logger {
-	return &zeroLogger{logger: z.logger.With().Fields(fields).Logger()}
+func (l *slogLogger) With(fields ...any) Logger {
+	return &slogLogger{logger: l.logger.With(fields...), level: l.level}
 }
 
 // GetDefaultLogDir 获取平台相关的默认日志目录（不包含文件名）
@@ -0,0 +1,87 @@
+package logger_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cdpnetool/internal/logger"
+)
+
+func TestSetLevel(t *testing.T) {
+	dir := t.TempDir()
+	l := logger.New(logger.Options{Level: "info", Writers: []string{"file"}, Dir: dir})
+	child := l.With("component", "child")
+
+	l.Debug("debug-hidden")
+	l.Info("info-shown")
+
+	lv, ok := l.(logger.Leveler)
+	if !ok {
+		t.Fatal("logger does not implement Leveler")
+	}
+	if err := lv.SetLevel("verbose"); err == nil {
+		t.Error("SetLevel(verbose) succeeded, want error")
+	}
+	if err := lv.SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel(debug) error: %v", err)
+	}
+	// 派生的记录器共享级别
+	child.Debug("child-debug-shown")
+	if err := lv.SetLevel("error"); err != nil {
+		t.Fatalf("SetLevel(error) error: %v", err)
+	}
+	child.Warn("child-warn-hidden")
+	l.Error("error-shown")
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, msg := range []string{"info-shown", "child-debug-shown", "error-shown"} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("log missing %q:\n%s", msg, out)
+		}
+	}
+	for _, msg := range []string{"debug-hidden", "child-warn-hidden"} {
+		if strings.Contains(out, msg) {
+			t.Errorf("log contains %q:\n%s", msg, out)
+		}
+	}
+	// 调用位置指向调用方而非日志封装
+	if !strings.Contains(out, "logger_test.go") {
+		t.Errorf("log caller does not point to the test file:\n%s", out)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	dir := t.TempDir()
+	l := logger.New(logger.Options{Level: "info", Format: "json", Writers: []string{"file"}, Dir: dir})
+	l.With("component", "proxy").Err(errors.New("dial failed"), "connect", "attempt", 2)
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, data)
+	}
+	want := map[string]any{"level": "ERROR", "msg": "connect", "component": "proxy", "error": "dial failed", "attempt": float64(2)}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("got %s = %v, want %v", k, entry[k], v)
+		}
+	}
+	if src, _ := entry["source"].(string); !strings.HasPrefix(src, "logger_test.go:") {
+		t.Errorf("got source %q, want the test file", src)
+	}
+
+	if err := logger.ValidateFormat("xml"); err == nil {
+		t.Error("ValidateFormat(xml) succeeded, want error")
+	}
+}
@@ -0,0 +1,166 @@
+// Package mirror 将请求异步复制到影子后端，不影响浏览器的真实请求
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cdpnetool/internal/logger"
+	"cdpnetool/pkg/domain"
+)
+
+const (
+	defaultTimeout     = 10 * time.Second // 单个影子请求的超时
+	defaultMaxInFlight = 16               // 同时进行的影子请求上限，超出时丢弃
+)
+
+// hopHeaders 不转发的逐跳头部及由 net/http 自行维护的头部
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Connection", "Proxy-Authorization", "Te", "Trailer",
+	"Transfer-Encoding", "Upgrade", "Host", "Content-Length",
+}
+
+// Mirror 影子流量发送器，并发安全
+type Mirror struct {
+	client  *http.Client
+	sem     chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	sent    atomic.Int64
+	failed  atomic.Int64
+	dropped atomic.Int64
+	log     logger.Logger
+}
+
+// Stats 影子流量统计
+type Stats struct {
+	Sent    int64 // 已收到影子后端响应的请求数
+	Failed  int64 // 发送失败的请求数
+	Dropped int64 // 因并发已满被丢弃的请求数
+}
+
+// New 创建影子流量发送器
+func New(l logger.Logger) *Mirror {
+	if l == nil {
+		l = logger.NewNop()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Mirror{
+		client: &http.Client{
+			Timeout: defaultTimeout,
+			// 影子请求不跟随重定向，避免对影子后端以外的地址产生副作用
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		sem:    make(chan struct{}, defaultMaxInFlight),
+		ctx:    ctx,
+		cancel: cancel,
+		log:    l,
+	}
+}
+
+// Send 异步将请求复制到 baseURL，返回 false 表示目标地址无效、发送器已关闭或并发已满而未发送
+func (m *Mirror) Send(req *domain.Request, baseURL string) bool {
+	target, err := TargetURL(baseURL, req.URL)
+	if err != nil {
+		m.log.Warn("影子请求目标地址无效", "baseURL", baseURL, "error", err)
+		return false
+	}
+	if m.ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case m.sem <- struct{}{}:
+	default:
+		m.dropped.Add(1)
+		m.log.Warn("影子请求并发已满，丢弃", "url", target)
+		return false
+	}
+
+	// 复制请求内容，调用方可继续修改原请求
+	method := req.Method
+	body := bytes.Clone(req.Body)
+	headers := make(http.Header, len(req.Headers))
+	for k, v := range req.Headers {
+		headers.Set(k, v)
+	}
+	for _, h := range hopHeaders {
+		headers.Del(h)
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() { <-m.sem }()
+		m.do(method, target, headers, body)
+	}()
+	return true
+}
+
+// do 发送影子请求并丢弃响应
+func (m *Mirror) do(method, target string, headers http.Header, body []byte) {
+	var rd io.Reader
+	if len(body) > 0 {
+		rd = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(m.ctx, method, target, rd)
+	if err != nil {
+		m.failed.Add(1)
+		m.log.Warn("构造影子请求失败", "url", target, "error", err)
+		return
+	}
+	httpReq.Header = headers
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		m.failed.Add(1)
+		m.log.Debug("影子请求失败", "url", target, "error", err)
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	m.sent.Add(1)
+	m.log.Debug("影子请求完成", "url", target, "status", resp.StatusCode)
+}
+
+// Stats 返回影子流量统计
+func (m *Mirror) Stats() Stats {
+	return Stats{Sent: m.sent.Load(), Failed: m.failed.Load(), Dropped: m.dropped.Load()}
+}
+
+// Close 取消进行中的影子请求并等待其结束，关闭后 Send 不再发送
+func (m *Mirror) Close() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// TargetURL 将原请求的路径与查询参数拼接到影子后端 baseURL 上
+func TargetURL(baseURL, rawURL string) (string, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	if (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return "", fmt.Errorf("base URL must be an absolute http(s) URL: %q", baseURL)
+	}
+	orig, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	out := *base
+	out.Path = strings.TrimSuffix(base.Path, "/") + orig.Path
+	out.RawPath = ""
+	out.RawQuery = orig.RawQuery
+	out.Fragment = ""
+	return out.String(), nil
+}
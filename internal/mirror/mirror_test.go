@@ -0,0 +1,84 @@
+package mirror_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/mirror"
+	"cdpnetool/pkg/domain"
+)
+
+func TestTargetURL(t *testing.T) {
+	tests := []struct {
+		base, url, want string
+	}{
+		{"http://shadow:8080", "https://example.com/api/a?x=1", "http://shadow:8080/api/a?x=1"},
+		{"http://shadow:8080/mirror/", "https://example.com/api/a#frag", "http://shadow:8080/mirror/api/a"},
+		{"https://shadow", "https://example.com", "https://shadow"},
+	}
+	for _, tt := range tests {
+		got, err := mirror.TargetURL(tt.base, tt.url)
+		if err != nil || got != tt.want {
+			t.Errorf("TargetURL(%q, %q) = %q, %v; want %q", tt.base, tt.url, got, err, tt.want)
+		}
+	}
+	for _, base := range []string{"", "shadow:8080", "ftp://shadow", "/relative"} {
+		if _, err := mirror.TargetURL(base, "https://example.com/"); err == nil {
+			t.Errorf("TargetURL(%q) should fail", base)
+		}
+	}
+}
+
+func TestMirror_Send(t *testing.T) {
+	type received struct {
+		method, uri, body, header, host string
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.Method, r.RequestURI, string(body), r.Header.Get("X-Test"), r.Host}
+	}))
+	defer srv.Close()
+
+	m := mirror.New(logger.NewNop())
+	req := domain.NewRequest()
+	req.URL = "https://example.com/api/save?id=7"
+	req.Method = "POST"
+	req.Body = []byte(`{"a":1}`)
+	req.Headers.Set("X-Test", "1")
+	req.Headers.Set("Host", "example.com")
+	if !m.Send(req, srv.URL) {
+		t.Fatal("Send() = false, want true")
+	}
+	// 发送后修改原请求不影响影子请求
+	req.Body[0] = 'X'
+
+	select {
+	case r := <-got:
+		if r.method != "POST" || r.uri != "/api/save?id=7" || r.body != `{"a":1}` || r.header != "1" {
+			t.Errorf("unexpected mirrored request %+v", r)
+		}
+		if r.host == "example.com" {
+			t.Errorf("Host header should not be forwarded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("mirrored request not received")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Stats().Sent == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s := m.Stats(); s.Sent != 1 || s.Failed != 0 {
+		t.Errorf("got stats %+v, want 1 sent", s)
+	}
+
+	m.Close()
+	if m.Send(req, srv.URL) {
+		t.Error("Send() after Close() = true, want false")
+	}
+}
@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"context"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// augmentResponse 获取 augmentJson 行为的次级数据源并合并到响应体，MessagePack 与 CBOR 响应体先解码再重新编码。
+// 获取或合并失败时记录日志并保留原响应体
+func (p *Processor) augmentResponse(ctx context.Context, res *domain.Response, ruleID string, action rulespec.Action, reqID string) {
+	if action.Augment == nil || action.Augment.Source == "" {
+		return
+	}
+	extra, err := p.augment.Fetch(ctx, action.Augment.Source, action.GetAugmentTimeout())
+	if err != nil {
+		p.log.Err(err, "获取增强数据源失败", "requestID", reqID, "ruleID", ruleID, "source", action.Augment.Source)
+		return
+	}
+
+	codec := transformer.CodecFor(res.Headers.Get("Content-Type"))
+	body := string(res.Body)
+	if codec != transformer.CodecNone {
+		if body, err = transformer.DecodeToJSON(res.Body, codec); err != nil {
+			p.log.Err(err, "响应体解码失败", "requestID", reqID, "ruleID", ruleID)
+			return
+		}
+	}
+	merged, err := transformer.MergeJSON(body, string(extra), action.Augment.Keys)
+	if err != nil {
+		p.log.Err(err, "合并增强数据失败", "requestID", reqID, "ruleID", ruleID, "source", action.Augment.Source)
+		return
+	}
+	if codec == transformer.CodecNone {
+		res.Body = []byte(merged)
+		return
+	}
+	out, err := transformer.EncodeFromJSON(merged, codec)
+	if err != nil {
+		p.log.Err(err, "响应体编码失败", "requestID", reqID, "ruleID", ruleID)
+		return
+	}
+	res.Body = out
+}
@@ -0,0 +1,35 @@
+package processor
+
+import (
+	"math/rand/v2"
+
+	"cdpnetool/internal/mirror"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// 金丝雀路由的变体名称，用于规则覆盖报告中的分流计数
+const (
+	canaryVariant   = "canary"
+	baselineVariant = "baseline"
+)
+
+// routeCanary 按 Percent 将请求的路径与查询参数拼接到备用后端地址上，其余请求保持原地址；
+// 返回请求是否被路由到备用后端
+func (p *Processor) routeCanary(req *domain.Request, ruleID string, action rulespec.Action) bool {
+	base, _ := action.Value.(string)
+	if base == "" || rand.IntN(100) >= action.Percent {
+		p.engine.RecordVariant(ruleID, baselineVariant)
+		return false
+	}
+	target, err := mirror.TargetURL(base, req.URL)
+	if err != nil {
+		p.log.Err(err, "金丝雀路由地址无效", "requestID", req.ID, "ruleID", ruleID)
+		p.engine.RecordVariant(ruleID, baselineVariant)
+		return false
+	}
+	p.log.Debug("[Processor] 请求路由到金丝雀后端", "requestID", req.ID, "ruleID", ruleID, "url", target)
+	req.URL = target
+	p.engine.RecordVariant(ruleID, canaryVariant)
+	return true
+}
@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"net/http"
+	"strings"
+
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// 缓存验证相关的请求头与响应头
+var (
+	requestValidators  = []string{"If-None-Match", "If-Modified-Since", "If-Range"}
+	responseValidators = []string{"ETag", "Last-Modified"}
+)
+
+// notModified 执行 notModified 动作：非条件请求返回 nil，否则返回回显验证信息的 304 响应
+func (p *Processor) notModified(req *domain.Request, ruleID string, action rulespec.Action) *domain.Response {
+	etags := req.Headers.Get("If-None-Match")
+	since := req.Headers.Get("If-Modified-Since")
+	if etags == "" && since == "" {
+		return nil
+	}
+
+	p.log.Info("[Processor] 以 304 响应条件请求", "requestID", req.ID, "ruleID", ruleID)
+	res := p.mockResponse(req.ID, action, http.StatusNotModified)
+	// 304 响应不能携带消息体
+	res.Body = nil
+	if etag, _, _ := strings.Cut(etags, ","); strings.TrimSpace(etag) != "" && strings.TrimSpace(etag) != "*" {
+		res.Headers.Set("ETag", strings.TrimSpace(etag))
+	}
+	if since != "" {
+		res.Headers.Set("Last-Modified", since)
+	}
+	return res
+}
+
+// delHeaders 不区分大小写地删除头部
+func delHeaders(h domain.Header, names []string) {
+	for k := range h {
+		for _, name := range names {
+			if strings.EqualFold(k, name) {
+				delete(h, k)
+				break
+			}
+		}
+	}
+}
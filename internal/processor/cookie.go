@@ -0,0 +1,30 @@
+package processor
+
+import (
+	"time"
+
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// browserCookie 按 setBrowserCookie 行为生成写入浏览器的 Cookie，以页面发起请求时的 URL 推导默认域名与路径；
+// Cookie 不合法（如 SameSite=None 用于 HTTP 请求）时返回 false
+func (p *Processor) browserCookie(req *domain.Request, pageURL, ruleID string, action rulespec.Action) (domain.BrowserCookie, bool) {
+	value, _ := action.Value.(string)
+	c := domain.BrowserCookie{Name: action.Name, Value: value, URL: pageURL}
+	if spec := action.Cookie; spec != nil {
+		c.Domain = spec.Domain
+		c.Path = spec.Path
+		c.HTTPOnly = spec.HTTPOnly
+		c.Secure = spec.Secure
+		c.SameSite = spec.SameSite
+		if spec.MaxAge > 0 {
+			c.Expires = float64(time.Now().Add(time.Duration(spec.MaxAge) * time.Second).Unix())
+		}
+	}
+	if err := c.Validate(); err != nil {
+		p.log.Warn("[Processor] 浏览器 Cookie 不合法，已跳过", "requestID", req.ID, "ruleID", ruleID, "name", action.Name, "error", err.Error())
+		return domain.BrowserCookie{}, false
+	}
+	return c, true
+}
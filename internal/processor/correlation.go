@@ -0,0 +1,27 @@
+package processor
+
+import (
+	"cdpnetool/pkg/domain"
+
+	"github.com/google/uuid"
+)
+
+// SetCorrelationHeader 设置注入关联 ID 的请求头，需在处理事件前调用；为空时不注入
+func (p *Processor) SetCorrelationHeader(name string) {
+	p.correlationHeader = name
+}
+
+// injectCorrelationID 为请求生成关联 ID 并写入请求头，请求已携带该头部时沿用原值；
+// 返回是否新写入了头部
+func (p *Processor) injectCorrelationID(req *domain.Request) bool {
+	if p.correlationHeader == "" {
+		return false
+	}
+	if v := req.Headers.Get(p.correlationHeader); v != "" {
+		req.CorrelationID = v
+		return false
+	}
+	req.CorrelationID = uuid.NewString()
+	req.Headers.Set(p.correlationHeader, req.CorrelationID)
+	return true
+}
@@ -0,0 +1,95 @@
+package processor
+
+import (
+	"net/url"
+
+	"cdpnetool/internal/grpcweb"
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// decodeBody 将 MessagePack、CBOR 与 gRPC-web 等二进制消息体解码为 JSON，
+// 其他类型或解码失败时返回空字符串
+func (p *Processor) decodeBody(reqID string, body []byte, headers domain.Header, rawURL string, response bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	ct := headers.Get("Content-Type")
+	if codec := transformer.CodecFor(ct); codec != transformer.CodecNone {
+		decoded, err := transformer.DecodeToJSON(body, codec)
+		if err != nil {
+			p.log.Debug("[Processor] 消息体解码失败", "requestID", reqID, "codec", codec, "response", response, "error", err)
+			return ""
+		}
+		return decoded
+	}
+	if p.grpc == nil || !grpcweb.IsGRPCWeb(ct) {
+		return ""
+	}
+
+	var path string
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	decoded, err := p.grpc.Decode(body, ct, path, response)
+	if err != nil {
+		p.log.Debug("[Processor] gRPC-web 消息解码失败", "requestID", reqID, "response", response, "error", err)
+		return ""
+	}
+	return decoded
+}
+
+// patchBody 对消息体应用 JSON Patch，MessagePack 与 CBOR 消息体先解码再重新编码
+func patchBody(body []byte, headers domain.Header, action rulespec.Action) ([]byte, error) {
+	if codec := transformer.CodecFor(headers.Get("Content-Type")); codec != transformer.CodecNone {
+		return transformer.PatchBinary(body, codec, action.Patches)
+	}
+	newBody, err := transformer.PatchJSON(string(body), action.Patches)
+	if err != nil {
+		return body, err
+	}
+	return []byte(newBody), nil
+}
+
+// jqBody 对消息体执行 jqTransform 行为的 jq 程序，MessagePack 与 CBOR 消息体先解码再重新编码
+func jqBody(body []byte, headers domain.Header, action rulespec.Action) ([]byte, error) {
+	program, _ := action.Value.(string)
+	if codec := transformer.CodecFor(headers.Get("Content-Type")); codec != transformer.CodecNone {
+		return transformer.TransformBinaryJQ(body, codec, program)
+	}
+	newBody, err := transformer.TransformJQ(string(body), program)
+	if err != nil {
+		return body, err
+	}
+	return []byte(newBody), nil
+}
+
+// skipBodyAction 判断文本与 JSON 行为是否应跳过当前消息体。
+// Content-Type 缺失或为 octet-stream 等通用类型时嗅探内容：JSON 行为仅作用于 JSON 内容，文本行为跳过二进制内容；
+// 其他情况或规则关闭嗅探时按 Content-Type 判断，仅跳过声明为图片、音视频等二进制类型的消息体。
+// 声明了压缩编码而内容仍是二进制（未能解压）的消息体总是跳过，避免按文本修改压缩数据
+func skipBodyAction(action rulespec.Action, body []byte, headers domain.Header, noSniff bool) bool {
+	jsonOnly := false
+	switch action.Type {
+	case rulespec.ActionPatchBodyJson, rulespec.ActionMaskJson, rulespec.ActionJqTransform, rulespec.ActionAugmentJson, rulespec.ActionCorruptJson:
+		jsonOnly = true
+	case rulespec.ActionReplaceBodyText, rulespec.ActionSetFormField, rulespec.ActionRemoveFormField:
+	default:
+		return false
+	}
+	if transformer.EncodingFor(headers.Get("Content-Encoding")) != transformer.EncodingNone &&
+		transformer.SniffBody(body) == transformer.BodyBinary {
+		return true
+	}
+	ct := headers.Get("Content-Type")
+	if transformer.CodecFor(ct) != transformer.CodecNone {
+		// MessagePack 与 CBOR 消息体由编解码器处理
+		return false
+	}
+	if !noSniff && transformer.IsGenericContentType(ct) {
+		kind := transformer.SniffBody(body)
+		return kind == transformer.BodyBinary || jsonOnly && kind != transformer.BodyJSON
+	}
+	return transformer.IsBinaryContentType(ct)
+}
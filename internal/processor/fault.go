@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand/v2"
+
+	"cdpnetool/internal/engine"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// rollFaults 按 probability 决定本次执行哪些故障注入行为，未被选中的故障注入行为跳过
+func (p *Processor) rollFaults(req *domain.Request, ruleID string, actions []rulespec.Action) []rulespec.Action {
+	var kept []rulespec.Action
+	for i := range actions {
+		if faultFires(&actions[i]) {
+			if kept != nil {
+				kept = append(kept, actions[i])
+			}
+			continue
+		}
+		if kept == nil {
+			kept = append(make([]rulespec.Action, 0, len(actions)), actions[:i]...)
+		}
+		p.log.Debug("[Processor] 故障注入未命中概率，跳过动作", "requestID", req.ID, "ruleID", ruleID, "actionType", actions[i].Type, "probability", actions[i].Probability)
+	}
+	if kept == nil {
+		return actions
+	}
+	return kept
+}
+
+// faultFires 判断行为本次是否执行：非故障注入行为与未设置概率的故障注入行为总是执行
+func faultFires(a *rulespec.Action) bool {
+	if !a.IsFault() || a.Probability <= 0 || a.Probability >= 1 {
+		return true
+	}
+	return rand.Float64() < a.Probability
+}
+
+// failResult 生成以网络错误结束请求的结果，并以 blocked 记录审计；响应阶段 origRes 为服务端返回的原始响应
+func (p *Processor) failResult(sessionID, targetID string, req, origReq *domain.Request, origRes *domain.Response, matched []*engine.MatchedRule, ruleID string, action rulespec.Action) Result {
+	reason := action.GetFailReason()
+	p.log.Info("[Processor] 执行 Fail 动作", "requestID", req.ID, "ruleID", ruleID, "reason", reason)
+	p.engine.RecordEffect(ruleID)
+
+	matches := p.toRuleMatches(matched)
+	p.trafficAuditor.RecordModified(sessionID, targetID, req, nil, origReq, origRes, "blocked", matches)
+	p.matchedAuditor.RecordModified(sessionID, targetID, req, nil, origReq, origRes, "blocked", matches)
+	return Result{Action: ActionFail, FailReason: reason, RuleIDs: ruleIDs(matched), WebSocket: req.IsWebSocket()}
+}
+
+// randomStatus 从候选状态码中随机选取一个
+func randomStatus(action rulespec.Action) (int, bool) {
+	codes, err := action.FaultStatuses()
+	if err != nil || len(codes) == 0 {
+		return 0, false
+	}
+	return codes[rand.IntN(len(codes))], true
+}
+
+// truncateBody 保留消息体开头 percent% 的字节，percent 为 0 时在随机位置截断
+func truncateBody(body []byte, percent int) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	n := len(body) * percent / 100
+	if percent <= 0 || percent >= 100 {
+		n = rand.IntN(len(body))
+	}
+	return bytes.Clone(body[:n])
+}
+
+// corruptJSON 在随机位置截断 JSON 消息体；截断后仍是合法 JSON（如数字的前缀）时追加逗号，保证无法解析
+func corruptJSON(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	out := bytes.Clone(body[:rand.IntN(len(body))])
+	if json.Valid(out) {
+		out = append(out, ',')
+	}
+	return out
+}
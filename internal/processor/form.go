@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"errors"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// setFormField 设置表单字段：multipart 表单替换同名部分的内容，其他请求体按 x-www-form-urlencoded 处理
+func (p *Processor) setFormField(req *domain.Request, action rulespec.Action) {
+	v, ok := action.Value.(string)
+	if !ok {
+		return
+	}
+	var newBody []byte
+	var err error
+	if boundary := transformer.MultipartBoundary(req.Headers.Get("Content-Type")); boundary != "" {
+		newBody, err = transformer.SetMultipartField(req.Body, boundary, action.Name, v)
+	} else {
+		var form string
+		form, err = transformer.SetFormUrlencoded(string(req.Body), action.Name, v)
+		newBody = []byte(form)
+	}
+	if err != nil {
+		p.log.Err(err, "设置表单字段失败", "requestID", req.ID)
+		return
+	}
+	req.Body = newBody
+}
+
+// removeFormField 移除表单字段：multipart 表单移除所有同名部分（包括文件），其他请求体按 x-www-form-urlencoded 处理
+func (p *Processor) removeFormField(req *domain.Request, action rulespec.Action) {
+	var newBody []byte
+	var err error
+	if boundary := transformer.MultipartBoundary(req.Headers.Get("Content-Type")); boundary != "" {
+		newBody, err = transformer.RemoveMultipartField(req.Body, boundary, action.Name)
+	} else {
+		var form string
+		form, err = transformer.RemoveFormUrlencoded(string(req.Body), action.Name)
+		newBody = []byte(form)
+	}
+	if err != nil {
+		p.log.Err(err, "移除表单字段失败", "requestID", req.ID)
+		return
+	}
+	req.Body = newBody
+}
+
+// setFormFile 替换 multipart 表单中上传文件的内容，请求体不是 multipart 表单时不修改
+func (p *Processor) setFormFile(req *domain.Request, action rulespec.Action) {
+	boundary := transformer.MultipartBoundary(req.Headers.Get("Content-Type"))
+	if boundary == "" {
+		p.log.Err(errors.New("request body is not multipart/form-data"), "替换表单文件失败", "requestID", req.ID)
+		return
+	}
+	v, _ := action.Value.(string)
+	content, err := transformer.DecodeBody(v, action.GetEncoding())
+	if err != nil {
+		p.log.Err(err, "表单文件内容解码失败", "requestID", req.ID)
+		return
+	}
+	newBody, err := transformer.SetMultipartFile(req.Body, boundary, action.Name, action.Filename, action.ContentType, []byte(content))
+	if err != nil {
+		p.log.Err(err, "替换表单文件失败", "requestID", req.ID)
+		return
+	}
+	req.Body = newBody
+}
@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// NeedsResponseBody 判断处理响应前是否需要获取原始响应体。
+// 未开启全量流量捕获、契约检查与敏感信息检测时，命中的响应阶段规则只修改状态码与头部、
+// 或未命中任何规则的响应无需获取，此时响应可直接以 ContinueResponse 放行（必要时覆盖状态码与头部），省去 GetResponseBody 的往返；
+// res 为尚未获取响应体的响应，用于评估响应条件，评估结果在处理响应时复用
+func (p *Processor) NeedsResponseBody(reqID string, res *domain.Response) bool {
+	stateVal, ok := p.tracker.Peek(reqID)
+	if !ok {
+		// 找不到对应请求时直接放行，无需响应体
+		return false
+	}
+	state := stateVal.(*PendingState)
+	if p.readOnly || p.trafficAuditor.IsEnabled() || p.scanner.Load() != nil || state.Operation != nil {
+		return true
+	}
+
+	matched := p.matchResponse(state, res)
+	if len(matched) == 0 {
+		// 未命中响应规则时，只有请求阶段命中规则的事件需要记录响应体
+		return state.IsMatched()
+	}
+	for _, mr := range matched {
+		for _, action := range mr.Rule.Actions {
+			if needsBody(action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// needsBody 判断响应阶段行为是否读取或修改响应体，variant 行为任一变体需要即视为需要
+func needsBody(action rulespec.Action) bool {
+	switch action.Type {
+	case rulespec.ActionSetStatus, rulespec.ActionSetHeader, rulespec.ActionRemoveHeader,
+		rulespec.ActionStripValidators, rulespec.ActionSetCache, rulespec.ActionSetSecurityHeaders, rulespec.ActionThrottle,
+		rulespec.ActionFail, rulespec.ActionRandomStatus:
+		return false
+	case rulespec.ActionVariant:
+		for _, v := range action.Variants {
+			for _, va := range v.Actions {
+				if needsBody(va) {
+					return true
+				}
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
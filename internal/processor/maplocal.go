@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cdpnetool/internal/saver"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// mapLocalIndex URL 映射到目录时返回的文件
+const mapLocalIndex = "index.html"
+
+// mapLocal 执行 mapLocal 动作：以本地文件的内容应答请求。value 为文件时直接返回该文件；
+// 为目录时按 filename 模板（默认 {path}）在目录下定位文件，模板可用 saveBody 的变量及正则的捕获组。
+// 设置了正则且不匹配、文件不存在或路径解析到目录之外时返回 nil
+func (p *Processor) mapLocal(req *domain.Request, ruleID string, action rulespec.Action) *domain.Response {
+	root, _ := action.Value.(string)
+	if strings.TrimSpace(root) == "" {
+		p.log.Warn("[Processor] 本地映射路径为空，已跳过", "requestID", req.ID, "ruleID", ruleID)
+		return nil
+	}
+	tmpl := action.Filename
+	if action.Pattern != "" {
+		re, err := p.regexes.Get(action.Pattern)
+		if err != nil {
+			p.log.Err(err, "[Processor] 本地映射正则编译失败，已跳过", "requestID", req.ID, "ruleID", ruleID, "pattern", action.Pattern)
+			return nil
+		}
+		m := re.FindStringSubmatchIndex(req.URL)
+		if m == nil {
+			return nil
+		}
+		if tmpl != "" {
+			tmpl = string(re.ExpandString(nil, tmpl, req.URL, m))
+		}
+	}
+
+	file, err := resolveLocal(root, tmpl, saver.Vars{RequestID: req.ID, RuleID: ruleID, Method: req.Method, URL: req.URL})
+	if err != nil {
+		p.log.Debug("[Processor] 本地映射未找到文件，继续执行后续行为", "requestID", req.ID, "ruleID", ruleID, "error", err)
+		return nil
+	}
+	body, err := os.ReadFile(file)
+	if err != nil {
+		p.log.Err(err, "[Processor] 读取本地映射文件失败，已跳过", "requestID", req.ID, "ruleID", ruleID, "path", file)
+		return nil
+	}
+
+	status := action.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	p.log.Info("[Processor] 以本地文件应答请求", "requestID", req.ID, "ruleID", ruleID, "path", file, "size", len(body))
+	res := domain.NewResponse()
+	res.StatusCode = status
+	res.Body = body
+	for k, v := range action.Headers {
+		res.Headers.Set(k, v)
+	}
+	if res.Headers.Get("Content-Type") == "" {
+		res.Headers.Set("Content-Type", contentTypeOf(file, body))
+	}
+	return res
+}
+
+// resolveLocal 返回 root 下按模板定位的文件路径；root 为文件时直接返回。
+// 模板逐段清理后拼接到 root 下，解析符号链接后仍须位于 root 之内，指向目录时使用其中的 index.html
+func resolveLocal(root, tmpl string, vars saver.Vars) (string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return root, nil
+	}
+
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = "{path}"
+	}
+	file := filepath.Join(root, filepath.FromSlash(saver.Expand(tmpl, vars, time.Now())))
+	if info, err := os.Stat(file); err != nil {
+		return "", err
+	} else if info.IsDir() {
+		file = filepath.Join(file, mapLocalIndex)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	realFile, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(realRoot, realFile); err != nil || !filepath.IsLocal(rel) {
+		return "", errors.New("path escapes mapped directory")
+	}
+	return realFile, nil
+}
+
+// contentTypeOf 按扩展名推断 Content-Type，无法推断时按内容嗅探
+func contentTypeOf(file string, body []byte) string {
+	if ct := mime.TypeByExtension(filepath.Ext(file)); ct != "" {
+		return ct
+	}
+	return http.DetectContentType(body)
+}
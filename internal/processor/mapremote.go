@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// defaultPorts 各协议的默认端口，改写后的端口与之相同时省略
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+}
+
+// mapRemote 执行 mapRemote 动作：按 Remote 改写请求 URL 的协议、主机、端口与路径前缀，
+// 其余路径、查询参数、片段与请求头保持不变；返回请求是否被改写
+func (p *Processor) mapRemote(req *domain.Request, ruleID string, action rulespec.Action) bool {
+	if action.Remote == nil {
+		return false
+	}
+	target, ok := mapRemoteURL(req.URL, action.Remote)
+	if !ok {
+		p.log.Debug("[Processor] 请求 URL 不匹配路径前缀，未改写", "requestID", req.ID, "ruleID", ruleID, "pathPrefix", action.Remote.PathPrefix)
+		return false
+	}
+	if target == req.URL {
+		return false
+	}
+	p.log.Debug("[Processor] 改写请求地址", "requestID", req.ID, "ruleID", ruleID, "from", req.URL, "to", target)
+	req.URL = target
+	return true
+}
+
+// mapRemoteURL 按 spec 改写 raw，URL 无法解析或路径不以 PathPrefix 开头时返回 false
+func mapRemoteURL(raw string, spec *rulespec.MapRemoteSpec) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	// 在转义形式的路径上替换前缀，保留路径中原有的编码
+	escaped := u.EscapedPath()
+	if spec.PathPrefix != "" || spec.NewPathPrefix != "" {
+		from := cleanPrefix(spec.PathPrefix)
+		if escaped != from && !strings.HasPrefix(escaped, from+"/") {
+			return "", false
+		}
+		escaped = cleanPrefix(spec.NewPathPrefix) + strings.TrimPrefix(escaped, from)
+		if escaped == "" {
+			escaped = "/"
+		}
+		if u.Path, err = url.PathUnescape(escaped); err != nil {
+			return "", false
+		}
+		u.RawPath = escaped
+	}
+
+	host, port := u.Hostname(), u.Port()
+	if spec.Scheme != "" {
+		if port == defaultPorts[strings.ToLower(u.Scheme)] {
+			port = ""
+		}
+		u.Scheme = strings.ToLower(spec.Scheme)
+	}
+	if spec.Host != "" {
+		host = spec.Host
+	}
+	if spec.Port != 0 {
+		port = strconv.Itoa(spec.Port)
+	}
+	if (spec.Scheme != "" || spec.Port != 0) && port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	switch {
+	case port != "":
+		u.Host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+	return u.String(), true
+}
+
+// cleanPrefix 规范化路径前缀：以 / 开头、不以 / 结尾，根路径为空串
+func cleanPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
@@ -1,17 +1,34 @@
 package processor
 
 import (
+	"bytes"
 	"context"
+	"maps"
+	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"cdpnetool/internal/accounting"
 	"cdpnetool/internal/auditor"
+	"cdpnetool/internal/augment"
+	"cdpnetool/internal/contract"
 	"cdpnetool/internal/engine"
+	"cdpnetool/internal/grpcweb"
 	"cdpnetool/internal/logger"
+	"cdpnetool/internal/mirror"
+	"cdpnetool/internal/regexutil"
+	"cdpnetool/internal/saver"
+	"cdpnetool/internal/secrets"
+	"cdpnetool/internal/tracing"
 	"cdpnetool/internal/tracker"
 	"cdpnetool/internal/transformer"
 	"cdpnetool/pkg/domain"
 	"cdpnetool/pkg/rulespec"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Result 处理结果
@@ -20,6 +37,14 @@ type Result struct {
 	ModifiedReq *domain.Request  // 修改后的请求
 	ModifiedRes *domain.Response // 修改后的响应
 	MockRes     *domain.Response // 伪造的响应
+	RuleIDs     []string         // 产生该结果的规则，用于统计降级
+	WebSocket   bool             // 是否为 WebSocket 握手请求，握手没有可拦截的响应阶段
+	HeadersOnly bool             // 响应阶段未获取响应体，修改只能覆盖状态码与头部
+	Streamed    bool             // 响应体已以流方式取出，浏览器不再收到原始响应体，放行时以 ModifiedRes 中的原始响应应答
+	Throttle    Throttle         // 命中的 throttle 行为要求的节流，由调用方在下发结果前等待
+	FailReason  string           // fail 行为要求的网络错误原因，Action 为 ActionFail 时有效
+
+	BrowserCookies []domain.BrowserCookie // setBrowserCookie 行为要求写入浏览器 Cookie 存储的 Cookie，由调用方在下发结果前写入
 }
 
 type Action string
@@ -28,6 +53,7 @@ const (
 	ActionPass   Action = "pass"
 	ActionModify Action = "modify"
 	ActionBlock  Action = "block"
+	ActionFail   Action = "fail" // 以网络错误结束请求，浏览器收不到响应
 )
 
 // PendingState 暂存在 tracker 中的请求上下文
@@ -35,15 +61,37 @@ type PendingState struct {
 	Request      *domain.Request
 	MatchedRules []*engine.MatchedRule
 	IsModified   bool
+	Original     *domain.Request          // 规则修改前的请求，未被修改时为 nil
+	Operation    *contract.Operation      // 请求匹配的 OpenAPI 操作，用于检查响应
+	Violations   []domain.SchemaViolation // 请求阶段发现的契约违规
+	Started      time.Time                // 请求阶段开始处理的时间，用于计算响应耗时
+
+	responseRules     []*engine.MatchedRule // NeedsResponseBody 已评估的响应阶段规则，处理响应时复用
+	responseEvaluated bool                  // 响应阶段规则是否已评估
 }
 
 // Processor 业务处理编排中心
 type Processor struct {
-	tracker        *tracker.Tracker
-	engine         *engine.Engine
-	matchedAuditor *auditor.Auditor // 匹配事件审计器
-	trafficAuditor *auditor.Auditor // 全量流量审计器
-	log            logger.Logger
+	tracker           *tracker.Tracker
+	engine            *engine.Engine
+	matchedAuditor    *auditor.Auditor // 匹配事件审计器
+	trafficAuditor    *auditor.Auditor // 全量流量审计器
+	hostMappings      []domain.HostMapping
+	traffic           *accounting.Accountant          // 按域名与资源类型的流量统计
+	mirror            *mirror.Mirror                  // 影子流量发送器，为 nil 时忽略 mirror 动作
+	saver             *saver.Saver                    // 响应体落盘器，为 nil 时忽略 saveBody 动作
+	grpc              *grpcweb.Decoder                // gRPC-web 消息解码器，为 nil 时不解码
+	contracts         *contract.Checker               // validateSchema 动作使用的 JSON Schema 校验器
+	augment           *augment.Fetcher                // augmentJson 动作的次级数据源读取器
+	spec              atomic.Pointer[contract.Spec]   // OpenAPI 契约，为 nil 时不做契约检查
+	scanner           atomic.Pointer[secrets.Scanner] // 敏感信息扫描器，为 nil 时不检测
+	limiter           *rateLimiter                    // rateLimit 动作的计数器
+	regexes           *regexutil.Cache                // redirect 与 mapLocal 动作匹配 URL 的正则缓存
+	correlationHeader string                          // 注入关联 ID 的请求头，为空时不注入
+	readOnly          bool                            // 只读观察模式，不评估规则
+	dryRun            atomic.Bool                     // 演练模式，不发送影子请求、不落盘响应体
+	capabilities      domain.CapabilityProfile        // 能力配置档，不被允许的行为在执行时跳过
+	log               logger.Logger
 }
 
 // New 创建一个新的处理器
@@ -56,66 +104,284 @@ func New(t *tracker.Tracker, e *engine.Engine, matchedAud, trafficAud *auditor.A
 		engine:         e,
 		matchedAuditor: matchedAud,
 		trafficAuditor: trafficAud,
+		traffic:        accounting.New(),
+		contracts:      contract.New(),
+		augment:        augment.New(),
+		limiter:        newRateLimiter(),
+		regexes:        regexutil.New(),
 		log:            l,
 	}
 }
 
-// ProcessRequest 处理请求阶段逻辑
-func (p *Processor) ProcessRequest(ctx context.Context, sessionID, targetID string, req *domain.Request) Result {
-	p.log.Debug("[Processor] 开始处理请求", "requestID", req.ID, "url", req.URL, "method", req.Method)
+// TrafficStats 返回按域名与资源类型的流量统计
+func (p *Processor) TrafficStats() domain.TrafficStats {
+	return p.traffic.Snapshot()
+}
+
+// SetHostMappings 设置以 URL 改写方式生效的主机映射，需在处理事件前调用
+func (p *Processor) SetHostMappings(mappings []domain.HostMapping) {
+	p.hostMappings = mappings
+}
+
+// SetMirror 设置影子流量发送器，需在处理事件前调用；未设置时 mirror 动作被忽略
+func (p *Processor) SetMirror(m *mirror.Mirror) {
+	p.mirror = m
+}
+
+// SetSaver 设置响应体落盘器，需在处理事件前调用；未设置时 saveBody 动作被忽略
+func (p *Processor) SetSaver(s *saver.Saver) {
+	p.saver = s
+}
+
+// SetContract 设置 OpenAPI 契约，可在会话运行期间调用；为 nil 时关闭契约检查
+func (p *Processor) SetContract(s *contract.Spec) {
+	p.spec.Store(s)
+}
 
-	matched := p.engine.Eval(req, rulespec.StageRequest)
+// SetSecretScanner 设置敏感信息扫描器，可在会话运行期间调用；为 nil 时关闭检测
+func (p *Processor) SetSecretScanner(s *secrets.Scanner) {
+	p.scanner.Store(s)
+}
+
+// SetReadOnly 设置只读观察模式，需在处理事件前调用：不评估任何规则，请求与响应仅被记录而不被修改
+func (p *Processor) SetReadOnly(readOnly bool) {
+	p.readOnly = readOnly
+}
+
+// SetDryRun 设置演练模式，可在会话运行期间调用：规则照常评估并记录结果，
+// mirror 与 saveBody 等在流量之外产生副作用的动作不执行，结果是否下发由调用方决定
+func (p *Processor) SetDryRun(dryRun bool) {
+	p.dryRun.Store(dryRun)
+	p.matchedAuditor.SetDryRun(dryRun)
+	p.trafficAuditor.SetDryRun(dryRun)
+}
+
+// SetCapabilityProfile 设置能力配置档，需在处理事件前调用：不被允许的行为在执行时跳过
+func (p *Processor) SetCapabilityProfile(profile domain.CapabilityProfile) {
+	p.capabilities = profile
+}
+
+// eval 评估匹配当前阶段的规则，只读观察模式下不匹配任何规则
+func (p *Processor) eval(ctx context.Context, req *domain.Request, stage rulespec.Stage) []*engine.MatchedRule {
+	if p.readOnly {
+		return nil
+	}
+	_, span := tracing.Tracer().Start(ctx, "engine.Eval", trace.WithAttributes(attribute.String("cdpnetool.stage", string(stage))))
+	matched := p.engine.Eval(req, stage)
+	endEvalSpan(span, matched)
 	p.engine.RecordStats(matched)
+	return matched
+}
+
+// evalResponse 以收到的响应评估响应阶段的规则，只读观察模式下不匹配任何规则
+func (p *Processor) evalResponse(ctx context.Context, state *PendingState, res *domain.Response) []*engine.MatchedRule {
+	if p.readOnly {
+		return nil
+	}
+	_, span := tracing.Tracer().Start(ctx, "engine.EvalResponse", trace.WithAttributes(attribute.String("cdpnetool.stage", string(rulespec.StageResponse))))
+	matched := p.matchResponse(state, res)
+	endEvalSpan(span, matched)
+	p.engine.RecordStats(matched)
+	return matched
+}
+
+// matchResponse 返回命中的响应阶段规则，NeedsResponseBody 已评估过时复用其结果，避免同一响应评估两次
+func (p *Processor) matchResponse(state *PendingState, res *domain.Response) []*engine.MatchedRule {
+	if !state.responseEvaluated {
+		state.responseRules = p.engine.EvalResponse(state.Request, responseInfo(state, res))
+		state.responseEvaluated = true
+	}
+	return state.responseRules
+}
+
+// endEvalSpan 在规则评估的 span 上记录匹配的规则并结束 span
+func endEvalSpan(span trace.Span, matched []*engine.MatchedRule) {
+	span.SetAttributes(attribute.StringSlice("cdpnetool.rule.ids", ruleIDs(matched)))
+	span.End()
+}
+
+// endProcessSpan 在行为执行的 span 上记录处理结果并结束 span
+func endProcessSpan(span trace.Span, res Result) {
+	span.SetAttributes(attribute.String("cdpnetool.action", string(res.Action)), attribute.StringSlice("cdpnetool.rule.ids", res.RuleIDs))
+	span.End()
+}
+
+// responseInfo 提取响应条件所需的信息；响应带有计时（如回放或离线测试的捕获事件）时以计时计算耗时，
+// 否则为请求阶段开始处理到收到响应头的时间
+func responseInfo(state *PendingState, res *domain.Response) engine.Response {
+	info := engine.Response{StatusCode: res.StatusCode, ContentType: res.Headers.Get("Content-Type")}
+	switch t := res.Timing; {
+	case t.StartTime > 0 && t.EndTime >= t.StartTime:
+		info.ResponseTimeMS = t.EndTime - t.StartTime
+	case t.HasPhases():
+		info.ResponseTimeMS = int64(t.DNS + t.Connect + t.Send + t.TTFB)
+	case !state.Started.IsZero():
+		info.ResponseTimeMS = time.Since(state.Started).Milliseconds()
+	}
+	return info
+}
+
+// SetGRPCDecoder 设置 gRPC-web 消息解码器，需在处理事件前调用；未设置时不解码
+func (p *Processor) SetGRPCDecoder(d *grpcweb.Decoder) {
+	p.grpc = d
+}
+
+// pendingSave 待执行的 saveBody 动作
+type pendingSave struct {
+	ruleID string
+	action rulespec.Action
+}
+
+// ProcessRequest 处理请求阶段逻辑，ctx 中带有追踪时在其下记录行为执行与规则评估的 span，并将追踪 ID 记入请求
+func (p *Processor) ProcessRequest(ctx context.Context, sessionID, targetID string, req *domain.Request) Result {
+	ctx, span := tracing.Tracer().Start(ctx, "processor.ProcessRequest")
+	req.TraceID = tracing.TraceID(ctx)
+	res := p.processRequest(ctx, sessionID, targetID, req)
+	endProcessSpan(span, res)
+	return res
+}
+
+// processRequest 处理请求阶段逻辑
+func (p *Processor) processRequest(ctx context.Context, sessionID, targetID string, req *domain.Request) Result {
+	p.log.Debug("[Processor] 开始处理请求", "requestID", req.ID, "url", req.URL, "method", req.Method)
+	started := time.Now()
+
+	req.Decoded = p.decodeBody(req.ID, req.Body, req.Headers, req.URL, false)
+	original := snapshotRequest(req)
+	// 先注入关联 ID，使拦截事件与规则条件都能看到该头部
+	injected := p.injectCorrelationID(req)
+	if req.ResourceType == domain.ResourceTypeDocument {
+		p.engine.BeginPageLoad()
+	}
+	matched := p.eval(ctx, req, rulespec.StageRequest)
 
 	// 记录匹配情况
 	if len(matched) == 0 {
 		p.log.Debug("[Processor] 请求未匹配规则", "requestID", req.ID)
 	} else {
-		ruleIDs := make([]string, len(matched))
-		for i, m := range matched {
-			ruleIDs[i] = m.Rule.ID
-		}
-		p.log.Debug("[Processor] 请求匹配规则", "requestID", req.ID, "matchedCount", len(matched), "ruleIDs", ruleIDs)
+		p.log.Debug("[Processor] 请求匹配规则", "requestID", req.ID, "matchedCount", len(matched), "ruleIDs", ruleIDs(matched))
 	}
 
-	res := Result{Action: ActionPass}
+	res := Result{Action: ActionPass, WebSocket: req.IsWebSocket()}
 	isModified := false
 
+	var handshake domain.Header
+	origURL := req.URL
+	if res.WebSocket {
+		handshake = snapshotHandshake(req.Headers)
+	}
+
+	var mirrors []string
+	var signs []pendingSign
 	for _, mr := range matched {
-		for _, action := range mr.Rule.Actions {
-			if action.Type == rulespec.ActionBlock {
-				p.log.Info("[Processor] 执行 Block 动作", "requestID", req.ID, "ruleID", mr.Rule.ID, "statusCode", action.StatusCode)
-				res.Action = ActionBlock
-				res.MockRes = domain.NewResponse()
-				res.MockRes.StatusCode = action.StatusCode
-				if action.Body != "" {
-					body, err := transformer.DecodeBody(action.Body, action.GetBodyEncoding())
-					if err != nil {
-						p.log.Err(err, "Block 动作中响应体解码失败", "requestID", req.ID)
-						res.MockRes.Body = []byte(action.Body)
-					} else {
-						res.MockRes.Body = []byte(body)
-					}
+		before := cloneRequest(req)
+		mirrored, throttled, persisted := false, false, false
+		for _, action := range p.ruleActions(req, mr.Rule, rulespec.StageRequest) {
+			if action.Type == rulespec.ActionFail {
+				p.traffic.AddRequest(req, true)
+				return p.failResult(sessionID, targetID, req, originalRequest(original, req), nil, matched, mr.Rule.ID, action)
+			}
+			if action.Type == rulespec.ActionBlock || action.Type == rulespec.ActionRateLimit || action.Type == rulespec.ActionNotModified ||
+				action.Type == rulespec.ActionRedirect || action.Type == rulespec.ActionMapLocal {
+				var mock *domain.Response
+				switch action.Type {
+				case rulespec.ActionBlock:
+					p.log.Info("[Processor] 执行 Block 动作", "requestID", req.ID, "ruleID", mr.Rule.ID, "statusCode", action.StatusCode)
+					mock = p.mockResponse(req.ID, action, action.StatusCode)
+				case rulespec.ActionRateLimit:
+					mock = p.rateLimit(req, mr.Rule.ID, action)
+				case rulespec.ActionRedirect:
+					mock = p.redirect(req, mr.Rule.ID, action)
+				case rulespec.ActionMapLocal:
+					mock = p.mapLocal(req, mr.Rule.ID, action)
+				default:
+					mock = p.notModified(req, mr.Rule.ID, action)
 				}
-				res.MockRes.Headers = make(domain.Header)
-				for k, v := range action.Headers {
-					res.MockRes.Headers.Set(k, v)
+				if mock == nil {
+					// 未超出限流阈值、不是条件请求、URL 不匹配重定向正则或本地文件不存在，继续执行后续行为
+					continue
 				}
+				res.Action = ActionBlock
+				res.MockRes = mock
+				res.RuleIDs = ruleIDs(matched)
+				p.engine.RecordEffect(mr.Rule.ID)
+				p.traffic.AddRequest(req, true)
 
 				// Block 动作需立即记录审计（响应阶段不会再执行）
 				// 1. 全量流量审计
-				p.trafficAuditor.Record(sessionID, targetID, req, res.MockRes, "blocked", p.toRuleMatches(matched))
+				origReq := originalRequest(original, req)
+				p.trafficAuditor.RecordModified(sessionID, targetID, req, res.MockRes, origReq, nil, "blocked", p.toRuleMatches(matched))
 				// 2. 匹配事件审计（仅匹配时记录）
 				if len(matched) > 0 {
-					p.matchedAuditor.Record(sessionID, targetID, req, res.MockRes, "blocked", p.toRuleMatches(matched))
+					p.matchedAuditor.RecordModified(sessionID, targetID, req, res.MockRes, origReq, nil, "blocked", p.toRuleMatches(matched))
 				}
 				p.log.Debug("[Processor] Block 执行完成", "requestID", req.ID)
 				return res
 			}
 
+			if action.Type == rulespec.ActionAuth {
+				// 认证质询由会话在浏览器收到 401/407 时应答，不修改请求
+				continue
+			}
+			if res.WebSocket && !handshakeAllowed(action.Type) {
+				p.log.Warn("[Processor] WebSocket 握手请求不支持该动作，已忽略", "requestID", req.ID, "ruleID", mr.Rule.ID, "actionType", action.Type)
+				continue
+			}
+			if action.Type == rulespec.ActionSetBrowserCookie {
+				// 当前请求直接携带该 Cookie，之后的请求由浏览器从 Cookie 存储中携带
+				if c, ok := p.browserCookie(req, origURL, mr.Rule.ID, action); ok {
+					req.Cookies[c.Name] = c.Value
+					res.BrowserCookies = append(res.BrowserCookies, c)
+					isModified, persisted = true, true
+				}
+				continue
+			}
+			if action.Type == rulespec.ActionSign {
+				// 签名在所有规则执行完后计算，覆盖最终修改后的请求
+				signs = append(signs, pendingSign{ruleID: mr.Rule.ID, action: action})
+				continue
+			}
+			if action.Type == rulespec.ActionCanary {
+				if p.routeCanary(req, mr.Rule.ID, action) {
+					isModified = true
+				}
+				continue
+			}
+			if action.Type == rulespec.ActionMapRemote {
+				if p.mapRemote(req, mr.Rule.ID, action) {
+					isModified = true
+				}
+				continue
+			}
+			if action.Type == rulespec.ActionThrottle {
+				// 节流不修改请求，由调用方在放行前等待
+				res.Throttle.add(action)
+				throttled = true
+				continue
+			}
+			if action.Type == rulespec.ActionMirror {
+				// 影子请求在所有规则执行完后发送，携带最终修改后的请求
+				if v, ok := action.Value.(string); ok && p.mirror != nil {
+					if !p.dryRun.Load() {
+						mirrors = append(mirrors, v)
+					}
+					mirrored = true
+				}
+				continue
+			}
+			if skipBodyAction(action, req.Body, req.Headers, mr.Rule.NoSniff) {
+				p.log.Debug("[Processor] 请求体不是文本或 JSON，跳过动作", "requestID", req.ID, "ruleID", mr.Rule.ID, "actionType", action.Type)
+				continue
+			}
 			p.applyRequestAction(req, action)
 			isModified = true
 		}
+		if res.WebSocket {
+			restoreHandshake(req, handshake, origURL)
+		}
+		if mirrored || throttled || persisted || !requestEqual(before, req) {
+			p.engine.RecordEffect(mr.Rule.ID)
+		}
 	}
 
 	if isModified {
@@ -128,23 +394,84 @@ func (p *Processor) ProcessRequest(ctx context.Context, sessionID, targetID stri
 			req.Headers.Del("Cookie")
 		}
 
+		// 消息体可能已被改写，重新解码以便审计展示与响应阶段匹配
+		req.Decoded = p.decodeBody(req.ID, req.Body, req.Headers, req.URL, false)
+
 		res.Action = ActionModify
 		res.ModifiedReq = req
+		res.RuleIDs = ruleIDs(matched)
 		p.log.Debug("[Processor] 请求已修改", "requestID", req.ID, "matchedCount", len(matched))
 	}
+	if p.signRequest(req, signs) && !isModified {
+		isModified = true
+		res.Action = ActionModify
+		res.ModifiedReq = req
+		res.RuleIDs = ruleIDs(matched)
+	}
+	if injected && res.Action == ActionPass {
+		// 仅注入关联 ID 不视为规则修改，事件结果保持不变
+		res.Action = ActionModify
+		res.ModifiedReq = req
+	}
 
-	p.tracker.Set(req.ID, &PendingState{
+	// 扫描实际发往服务端的请求（规则修改之后）
+	req.Secrets = p.scanner.Load().ScanRequest(req)
+
+	// 按规则处理后的逻辑地址统计，主机映射不影响归属的域名
+	p.traffic.AddRequest(req, false)
+	for _, baseURL := range mirrors {
+		p.mirror.Send(req, baseURL)
+	}
+	p.applyHostMapping(req, &res)
+
+	// WebSocket 握手不会进入响应阶段，直接记录审计而不入池
+	if res.WebSocket {
+		finalResult := "passed"
+		if len(matched) > 0 {
+			finalResult = "matched"
+		}
+		if isModified {
+			finalResult = "modified"
+		}
+		if len(req.Secrets) > 0 {
+			finalResult = "secret-detected"
+		}
+		origReq := originalRequest(original, req)
+		p.trafficAuditor.RecordModified(sessionID, targetID, req, nil, origReq, nil, finalResult, p.toRuleMatches(matched))
+		if len(matched) > 0 || len(req.Secrets) > 0 {
+			p.matchedAuditor.RecordModified(sessionID, targetID, req, nil, origReq, nil, finalResult, p.toRuleMatches(matched))
+		}
+		p.log.Debug("[Processor] WebSocket 握手处理完成", "requestID", req.ID, "finalResult", finalResult)
+		return res
+	}
+
+	pending := &PendingState{
 		Request:      req,
 		MatchedRules: matched,
 		IsModified:   isModified,
-	})
+		Original:     originalRequest(original, req),
+		Started:      started,
+	}
+	if spec := p.spec.Load(); spec != nil {
+		// 检查实际发往服务端的请求（规则修改之后）
+		pending.Operation, pending.Violations, _ = spec.CheckRequest(req)
+	}
+	p.tracker.Set(req.ID, pending)
 	p.log.Debug("[Processor] 请求已入池", "requestID", req.ID)
 
 	return res
 }
 
-// ProcessResponse 处理响应阶段逻辑
+// ProcessResponse 处理响应阶段逻辑，ctx 中带有追踪时在其下记录行为执行与规则评估的 span
 func (p *Processor) ProcessResponse(ctx context.Context, sessionID, targetID, reqID string, res *domain.Response) Result {
+	ctx, span := tracing.Tracer().Start(ctx, "processor.ProcessResponse")
+	result := p.processResponse(ctx, sessionID, targetID, reqID, res)
+	endProcessSpan(span, result)
+	return result
+}
+
+// processResponse 处理响应阶段逻辑
+func (p *Processor) processResponse(ctx context.Context, sessionID, targetID, reqID string, res *domain.Response) Result {
 	p.log.Debug("[Processor] 开始处理响应", "requestID", reqID, "statusCode", res.StatusCode)
 
 	stateVal, ok := p.tracker.Get(reqID)
@@ -155,15 +482,10 @@ func (p *Processor) ProcessResponse(ctx context.Context, sessionID, targetID, re
 	state := stateVal.(*PendingState)
 	p.log.Debug("[Processor] 从池中获取请求", "requestID", reqID, "url", state.Request.URL)
 
-	matched := p.engine.Eval(state.Request, rulespec.StageResponse)
-	p.engine.RecordStats(matched)
+	matched := p.evalResponse(ctx, state, res)
 
 	if len(matched) > 0 {
-		ruleIDs := make([]string, len(matched))
-		for i, m := range matched {
-			ruleIDs[i] = m.Rule.ID
-		}
-		p.log.Debug("[Processor] 响应匹配规则", "requestID", reqID, "matchedCount", len(matched), "ruleIDs", ruleIDs)
+		p.log.Debug("[Processor] 响应匹配规则", "requestID", reqID, "matchedCount", len(matched), "ruleIDs", ruleIDs(matched))
 	}
 
 	finalResult := "passed"
@@ -174,33 +496,247 @@ func (p *Processor) ProcessResponse(ctx context.Context, sessionID, targetID, re
 		finalResult = "modified"
 	}
 
+	// 契约检查针对服务端返回的原始响应，在规则修改之前进行
+	res.Violations = append(res.Violations, state.Violations...)
+	if state.Operation != nil {
+		res.Violations = append(res.Violations, state.Operation.CheckResponse(res)...)
+	}
+
+	var saves []pendingSave
+	var throttle Throttle
+	var original *domain.Response
 	if len(matched) > 0 {
-		for _, mr := range matched {
-			for _, action := range mr.Rule.Actions {
+		original = snapshotResponse(res)
+	}
+	effective := make(map[string]bool)
+	for _, mr := range matched {
+		before := cloneResponse(res)
+		violated, throttled := false, false
+		for _, action := range p.ruleActions(state.Request, mr.Rule, rulespec.StageResponse) {
+			if action.Type == rulespec.ActionFail {
+				// 浏览器收不到响应，事件中以原始响应记录服务端实际返回的内容
+				return p.failResult(sessionID, targetID, state.Request, state.Original, original, append(state.MatchedRules, matched...), mr.Rule.ID, action)
+			}
+			if action.Type == rulespec.ActionThrottle {
+				// 节流不修改响应，由调用方在放行前等待
+				throttle.add(action)
+				throttled = true
+				continue
+			}
+			if action.Type == rulespec.ActionSaveBody {
+				// 落盘在所有规则执行完后进行，保存最终的响应体
+				if p.saver != nil && !p.dryRun.Load() {
+					saves = append(saves, pendingSave{ruleID: mr.Rule.ID, action: action})
+				}
+				continue
+			}
+			if action.Type == rulespec.ActionValidateSchema {
+				// 校验当前的响应体，仅在发现违规且需要标记或替换响应时视为修改
+				if p.validateSchema(res, mr.Rule.ID, action, reqID) {
+					violated = true
+					if action.GetOnViolation() != rulespec.ViolationReport {
+						finalResult = "modified"
+					}
+				}
+				continue
+			}
+			if skipBodyAction(action, res.Body, res.Headers, mr.Rule.NoSniff) {
+				p.log.Debug("[Processor] 响应体不是文本或 JSON，跳过动作", "requestID", reqID, "ruleID", mr.Rule.ID, "actionType", action.Type)
+				continue
+			}
+			switch action.Type {
+			case rulespec.ActionAugmentJson:
+				// 需要访问网络或文件，单独处理以沿用事件处理的 context
+				p.augmentResponse(ctx, res, mr.Rule.ID, action, reqID)
+			case rulespec.ActionScript:
+				// 脚本可读取对应的请求
+				p.scriptResponse(state.Request, res, action, reqID)
+			default:
 				p.applyResponseAction(res, action, reqID)
-				finalResult = "modified"
 			}
+			finalResult = "modified"
 		}
+		if violated || throttled || !responseEqual(before, res) {
+			p.engine.RecordEffect(mr.Rule.ID)
+			effective[mr.Rule.ID] = true
+		}
+	}
+	if len(matched) > 0 && finalResult == "passed" {
+		// 仅执行了 saveBody 等不修改响应的动作
+		finalResult = "matched"
 	}
+	modified := finalResult == "modified"
+	if len(res.Violations) > 0 {
+		finalResult = "schema-violation"
+	}
+	p.saveBodies(state.Request, res, saves, effective)
+	res.Decoded = p.decodeBody(reqID, res.Body, res.Headers, state.Request.URL, true)
+	res.Secrets = p.scanner.Load().ScanResponse(res)
+	secretDetected := len(state.Request.Secrets) > 0 || len(res.Secrets) > 0
+	if secretDetected {
+		finalResult = "secret-detected"
+	}
+
+	p.traffic.AddResponse(state.Request, res)
 
 	allMatched := append(state.MatchedRules, matched...)
 	ruleMatches := p.toRuleMatches(allMatched)
 
+	origRes := originalResponse(original, res)
 	// 1. 全量流量审计
-	p.trafficAuditor.Record(sessionID, targetID, state.Request, res, finalResult, ruleMatches)
-	// 2. 匹配事件审计（仅匹配时记录）
-	if len(allMatched) > 0 {
-		p.matchedAuditor.Record(sessionID, targetID, state.Request, res, finalResult, ruleMatches)
+	p.trafficAuditor.RecordModified(sessionID, targetID, state.Request, res, state.Original, origRes, finalResult, ruleMatches)
+	// 2. 匹配事件审计（匹配规则、存在违规或检测到敏感信息时记录）
+	if len(allMatched) > 0 || len(res.Violations) > 0 || secretDetected {
+		p.matchedAuditor.RecordModified(sessionID, targetID, state.Request, res, state.Original, origRes, finalResult, ruleMatches)
 	}
 	p.log.Debug("[Processor] 响应处理完成", "requestID", reqID, "finalResult", finalResult)
 
-	if finalResult == "modified" {
+	if modified {
 		return Result{
 			Action:      ActionModify,
 			ModifiedRes: res,
+			RuleIDs:     ruleIDs(allMatched),
+			Throttle:    throttle,
+		}
+	}
+	return Result{Action: ActionPass, Throttle: throttle}
+}
+
+// saveBodies 将最终响应体写入各 saveBody 动作指定的目录，写入失败只记录日志
+func (p *Processor) saveBodies(req *domain.Request, res *domain.Response, saves []pendingSave, effective map[string]bool) {
+	for _, s := range saves {
+		dir, _ := s.action.Value.(string)
+		vars := saver.Vars{
+			RequestID: req.ID,
+			RuleID:    s.ruleID,
+			Method:    req.Method,
+			URL:       req.URL,
+			Status:    res.StatusCode,
+			Headers:   res.Headers,
+		}
+		_, err := p.saver.Save(dir, s.action.Filename, vars, res.Body)
+		if err != nil {
+			p.log.Warn("[Processor] 保存响应体失败", "requestID", req.ID, "ruleID", s.ruleID, "dir", dir, "error", err)
+			continue
+		}
+		if !effective[s.ruleID] {
+			p.engine.RecordEffect(s.ruleID)
+			effective[s.ruleID] = true
+		}
+	}
+}
+
+// applyHostMapping 将命中映射的请求改写到目标主机并保留原 Host 头。
+// 改写只作用于发往浏览器的副本，审计与响应阶段的规则匹配仍使用原 URL
+func (p *Processor) applyHostMapping(req *domain.Request, res *Result) {
+	if len(p.hostMappings) == 0 {
+		return
+	}
+	mapped, host, ok := domain.MapURL(p.hostMappings, req.URL)
+	if !ok {
+		return
+	}
+
+	out := *req
+	out.Headers = make(domain.Header, len(req.Headers)+1)
+	for k, v := range req.Headers {
+		if !strings.EqualFold(k, "Host") {
+			out.Headers.Set(k, v)
 		}
 	}
-	return Result{Action: ActionPass}
+	out.Headers.Set("Host", host)
+	out.URL = mapped
+
+	res.ModifiedReq = &out
+	if res.Action == ActionPass {
+		res.Action = ActionModify
+	}
+	p.log.Debug("[Processor] 应用主机映射", "requestID", req.ID, "url", req.URL, "mapped", mapped)
+}
+
+// ruleIDs 提取匹配规则的 ID 列表
+func ruleIDs(matched []*engine.MatchedRule) []string {
+	ids := make([]string, len(matched))
+	for i, m := range matched {
+		ids[i] = m.Rule.ID
+	}
+	return ids
+}
+
+// cloneRequest 复制请求中可被规则修改的部分，用于判断规则是否产生实际修改
+func cloneRequest(req *domain.Request) *domain.Request {
+	return &domain.Request{
+		URL:     req.URL,
+		Method:  req.Method,
+		Headers: maps.Clone(req.Headers),
+		Body:    bytes.Clone(req.Body),
+		Query:   maps.Clone(req.Query),
+		Cookies: maps.Clone(req.Cookies),
+	}
+}
+
+// snapshotRequest 记录规则修改前请求的 URL、方法、头部与消息体；
+// 规则以新切片替换消息体而不原地修改，消息体可与请求共享
+func snapshotRequest(req *domain.Request) *domain.Request {
+	return &domain.Request{
+		ID:      req.ID,
+		URL:     req.URL,
+		Method:  req.Method,
+		Headers: maps.Clone(req.Headers),
+		Body:    req.Body,
+	}
+}
+
+// originalRequest 返回规则修改前的请求，请求未被修改时返回 nil
+func originalRequest(orig, req *domain.Request) *domain.Request {
+	if orig == nil || orig.URL == req.URL && orig.Method == req.Method &&
+		maps.Equal(orig.Headers, req.Headers) && bytes.Equal(orig.Body, req.Body) {
+		return nil
+	}
+	return orig
+}
+
+// requestEqual 判断两个请求的可修改部分是否一致
+func requestEqual(a, b *domain.Request) bool {
+	return a.URL == b.URL &&
+		a.Method == b.Method &&
+		maps.Equal(a.Headers, b.Headers) &&
+		bytes.Equal(a.Body, b.Body) &&
+		maps.Equal(a.Query, b.Query) &&
+		maps.Equal(a.Cookies, b.Cookies)
+}
+
+// cloneResponse 复制响应中可被规则修改的部分
+func cloneResponse(res *domain.Response) *domain.Response {
+	return &domain.Response{
+		StatusCode: res.StatusCode,
+		Headers:    maps.Clone(res.Headers),
+		Body:       bytes.Clone(res.Body),
+	}
+}
+
+// snapshotResponse 记录规则修改前响应的状态码、头部与消息体，消息体可与响应共享
+func snapshotResponse(res *domain.Response) *domain.Response {
+	return &domain.Response{
+		StatusCode: res.StatusCode,
+		Headers:    maps.Clone(res.Headers),
+		Body:       res.Body,
+	}
+}
+
+// originalResponse 返回规则修改前的响应，响应未被修改时返回 nil
+func originalResponse(orig, res *domain.Response) *domain.Response {
+	if orig == nil || responseEqual(orig, res) {
+		return nil
+	}
+	return orig
+}
+
+// responseEqual 判断两个响应的可修改部分是否一致
+func responseEqual(a, b *domain.Response) bool {
+	return a.StatusCode == b.StatusCode &&
+		maps.Equal(a.Headers, b.Headers) &&
+		bytes.Equal(a.Body, b.Body)
 }
 
 // toRuleMatches 将内部匹配结果转换为领域模型
@@ -238,6 +774,12 @@ func (p *Processor) applyRequestAction(req *domain.Request, action rulespec.Acti
 		}
 	case rulespec.ActionRemoveHeader:
 		req.Headers.Del(action.Name)
+	case rulespec.ActionStripValidators:
+		delHeaders(req.Headers, requestValidators)
+	case rulespec.ActionSetUserAgent:
+		if v, ok := action.Value.(string); ok {
+			p.applyUserAgent(req, v)
+		}
 	case rulespec.ActionSetQueryParam:
 		if v, ok := action.Value.(string); ok {
 			req.Query[action.Name] = v
@@ -250,6 +792,10 @@ func (p *Processor) applyRequestAction(req *domain.Request, action rulespec.Acti
 		}
 	case rulespec.ActionRemoveCookie:
 		delete(req.Cookies, action.Name)
+	case rulespec.ActionTruncateBody:
+		req.Body = truncateBody(req.Body, action.Percent)
+	case rulespec.ActionCorruptJson:
+		req.Body = corruptJSON(req.Body)
 	case rulespec.ActionSetBody:
 		if v, ok := action.Value.(string); ok {
 			body, err := transformer.DecodeBody(v, action.GetEncoding())
@@ -272,28 +818,27 @@ func (p *Processor) applyRequestAction(req *domain.Request, action rulespec.Acti
 		newBody := transformer.ReplaceText(string(req.Body), action.Search, action.Replace, action.ReplaceAll)
 		req.Body = []byte(newBody)
 	case rulespec.ActionPatchBodyJson:
-		newBody, err := transformer.PatchJSON(string(req.Body), action.Patches)
+		newBody, err := patchBody(req.Body, req.Headers, action)
 		if err != nil {
 			p.log.Err(err, "请求体 JSON Patch 失败", "requestID", req.ID)
 		} else {
-			req.Body = []byte(newBody)
+			req.Body = newBody
 		}
-	case rulespec.ActionSetFormField:
-		if v, ok := action.Value.(string); ok {
-			newBody, err := transformer.SetFormUrlencoded(string(req.Body), action.Name, v)
-			if err != nil {
-				p.log.Err(err, "设置表单字段失败", "requestID", req.ID)
-			} else {
-				req.Body = []byte(newBody)
-			}
-		}
-	case rulespec.ActionRemoveFormField:
-		newBody, err := transformer.RemoveFormUrlencoded(string(req.Body), action.Name)
+	case rulespec.ActionJqTransform:
+		newBody, err := jqBody(req.Body, req.Headers, action)
 		if err != nil {
-			p.log.Err(err, "移除表单字段失败", "requestID", req.ID)
+			p.log.Err(err, "请求体 jq 转换失败", "requestID", req.ID)
 		} else {
-			req.Body = []byte(newBody)
+			req.Body = newBody
 		}
+	case rulespec.ActionScript:
+		p.scriptRequest(req, action)
+	case rulespec.ActionSetFormField:
+		p.setFormField(req, action)
+	case rulespec.ActionRemoveFormField:
+		p.removeFormField(req, action)
+	case rulespec.ActionSetFormFile:
+		p.setFormFile(req, action)
 	}
 }
 
@@ -307,12 +852,22 @@ func (p *Processor) applyResponseAction(res *domain.Response, action rulespec.Ac
 		} else if v, ok := action.Value.(int); ok {
 			res.StatusCode = v
 		}
+	case rulespec.ActionRandomStatus:
+		if code, ok := randomStatus(action); ok {
+			res.StatusCode = code
+		}
+	case rulespec.ActionTruncateBody:
+		res.Body = truncateBody(res.Body, action.Percent)
+	case rulespec.ActionCorruptJson:
+		res.Body = corruptJSON(res.Body)
 	case rulespec.ActionSetHeader:
 		if v, ok := action.Value.(string); ok {
 			res.Headers.Set(action.Name, v)
 		}
 	case rulespec.ActionRemoveHeader:
 		res.Headers.Del(action.Name)
+	case rulespec.ActionStripValidators:
+		delHeaders(res.Headers, responseValidators)
 	case rulespec.ActionSetBody:
 		if v, ok := action.Value.(string); ok {
 			body, err := transformer.DecodeBody(v, action.GetEncoding())
@@ -335,15 +890,94 @@ func (p *Processor) applyResponseAction(res *domain.Response, action rulespec.Ac
 		newBody := transformer.ReplaceText(string(res.Body), action.Search, action.Replace, action.ReplaceAll)
 		res.Body = []byte(newBody)
 	case rulespec.ActionPatchBodyJson:
-		newBody, err := transformer.PatchJSON(string(res.Body), action.Patches)
+		newBody, err := patchBody(res.Body, res.Headers, action)
 		if err != nil {
 			p.log.Err(err, "响应体 JSON Patch 失败", "requestID", reqID)
+		} else {
+			res.Body = newBody
+		}
+	case rulespec.ActionJqTransform:
+		newBody, err := jqBody(res.Body, res.Headers, action)
+		if err != nil {
+			p.log.Err(err, "响应体 jq 转换失败", "requestID", reqID)
+		} else {
+			res.Body = newBody
+		}
+	case rulespec.ActionSetCache:
+		if v, ok := action.Value.(string); ok {
+			p.applyCache(res, v, reqID)
+		}
+	case rulespec.ActionSetSecurityHeaders:
+		value, _ := action.Value.(string)
+		p.applySecurityHeaders(res, value, action.Headers, reqID)
+	case rulespec.ActionMaskJson:
+		newBody, err := transformer.MaskJSON(string(res.Body), action.Paths, action.GetMaskMode())
+		if err != nil {
+			p.log.Err(err, "响应体字段屏蔽失败", "requestID", reqID)
 		} else {
 			res.Body = []byte(newBody)
 		}
 	}
 }
 
+// applyUserAgent 设置 User-Agent，并替换 Sec-CH-UA 客户端提示使其与之保持一致
+func (p *Processor) applyUserAgent(req *domain.Request, value string) {
+	preset, err := rulespec.ResolveUserAgent(value)
+	if err != nil {
+		p.log.Err(err, "User-Agent 预设解析失败", "requestID", req.ID)
+		return
+	}
+	for k := range req.Headers {
+		if strings.EqualFold(k, "User-Agent") || strings.HasPrefix(strings.ToLower(k), "sec-ch-ua") {
+			delete(req.Headers, k)
+		}
+	}
+	req.Headers.Set("User-Agent", preset.UserAgent)
+	for k, v := range preset.ClientHints() {
+		req.Headers.Set(k, v)
+	}
+}
+
+// applyCache 按缓存预设替换 Cache-Control、Expires 与 Pragma 响应头
+func (p *Processor) applyCache(res *domain.Response, value, reqID string) {
+	policy, err := rulespec.ResolveCache(value)
+	if err != nil {
+		p.log.Err(err, "缓存预设解析失败", "requestID", reqID)
+		return
+	}
+	for k := range res.Headers {
+		switch strings.ToLower(k) {
+		case "cache-control", "expires", "pragma":
+			delete(res.Headers, k)
+		}
+	}
+	res.Headers.Set("Cache-Control", policy.CacheControl)
+	if policy.Disable {
+		res.Headers.Set("Pragma", "no-cache")
+		res.Headers.Set("Expires", "0")
+		return
+	}
+	res.Headers.Set("Expires", time.Now().Add(policy.MaxAge).UTC().Format(http.TimeFormat))
+}
+
+// applySecurityHeaders 按预设与自定义头部一次性替换安全相关响应头
+func (p *Processor) applySecurityHeaders(res *domain.Response, preset string, overrides map[string]string, reqID string) {
+	bundle, err := rulespec.ResolveSecurityHeaders(preset, overrides)
+	if err != nil {
+		p.log.Err(err, "安全头部预设解析失败", "requestID", reqID)
+		return
+	}
+	// 不区分大小写地移除原有头部，避免与新值以不同大小写并存
+	names := bundle.Remove
+	for name := range bundle.Set {
+		names = append(names, name)
+	}
+	delHeaders(res.Headers, names)
+	for name, v := range bundle.Set {
+		res.Headers.Set(name, v)
+	}
+}
+
 // IsMatched 判断请求是否匹配了任何规则
 func (s *PendingState) IsMatched() bool {
 	return len(s.MatchedRules) > 0
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// maxRateKeys 计数键数量上限，超出时清理已过期的窗口
+const maxRateKeys = 10000
+
+// rateLimiter 按规则与键计数的固定窗口限流器
+type rateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	now     func() time.Time
+}
+
+// rateWindow 单个键的计数窗口
+type rateWindow struct {
+	end   time.Time
+	count int
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		windows: make(map[string]*rateWindow),
+		now:     time.Now,
+	}
+}
+
+// hit 记录一次命中，超出 limit 时返回 false 与窗口剩余时间；窗口从键的首次命中开始
+func (l *rateLimiter) hit(key string, limit int, window time.Duration) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.end) {
+		if len(l.windows) >= maxRateKeys {
+			l.prune(now)
+		}
+		w = &rateWindow{end: now.Add(window)}
+		l.windows[key] = w
+	}
+	w.count++
+	if w.count > limit {
+		return false, w.end.Sub(now)
+	}
+	return true, 0
+}
+
+// prune 清理已过期的窗口
+func (l *rateLimiter) prune(now time.Time) {
+	for k, w := range l.windows {
+		if !now.Before(w.end) {
+			delete(l.windows, k)
+		}
+	}
+}
+
+// rateLimit 执行 rateLimit 动作：未超出阈值时返回 nil，否则返回带 Retry-After 的 429 响应
+func (p *Processor) rateLimit(req *domain.Request, ruleID string, action rulespec.Action) *domain.Response {
+	key := ruleID + "\x00" + rateKey(req, action)
+	allowed, remaining := p.limiter.hit(key, action.Limit, action.GetWindow())
+	if allowed {
+		return nil
+	}
+
+	retryAfter := action.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = max(1, int(math.Ceil(remaining.Seconds())))
+	}
+	p.log.Info("[Processor] 超出限流阈值", "requestID", req.ID, "ruleID", ruleID, "limit", action.Limit, "retryAfter", retryAfter)
+	res := p.mockResponse(req.ID, action, http.StatusTooManyRequests)
+	res.Headers.Set("Retry-After", strconv.Itoa(retryAfter))
+	return res
+}
+
+// mockResponse 按 block、rateLimit 行为的响应头与响应体构造伪造响应
+func (p *Processor) mockResponse(reqID string, action rulespec.Action, statusCode int) *domain.Response {
+	res := domain.NewResponse()
+	res.StatusCode = statusCode
+	if action.Body != "" {
+		body, err := transformer.DecodeBody(action.Body, action.GetBodyEncoding())
+		if err != nil {
+			p.log.Err(err, "伪造响应体解码失败", "requestID", reqID, "actionType", action.Type)
+			res.Body = []byte(action.Body)
+		} else {
+			res.Body = []byte(body)
+		}
+	}
+	for k, v := range action.Headers {
+		res.Headers.Set(k, v)
+	}
+	return res
+}
+
+// rateKey 返回请求的计数键：去除查询参数的 URL、指定请求头或 Cookie 的值
+func rateKey(req *domain.Request, action rulespec.Action) string {
+	switch action.GetRateKey() {
+	case rulespec.RateKeyHeader:
+		return req.Headers.Get(action.Name)
+	case rulespec.RateKeyCookie:
+		return req.Cookies[action.Name]
+	default:
+		u, _, _ := strings.Cut(req.URL, "#")
+		u, _, _ = strings.Cut(u, "?")
+		return u
+	}
+}
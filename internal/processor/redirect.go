@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// redirect 执行 redirect 动作：设置了正则且不匹配请求 URL 时返回 nil，否则返回 Location 为模板展开结果的 30x 响应。
+// 模板中的 $1、${name} 引用正则的捕获组，未设置正则时按原样使用
+func (p *Processor) redirect(req *domain.Request, ruleID string, action rulespec.Action) *domain.Response {
+	location, _ := action.Value.(string)
+	if action.Pattern != "" {
+		re, err := p.regexes.Get(action.Pattern)
+		if err != nil {
+			p.log.Err(err, "[Processor] 重定向正则编译失败，已跳过", "requestID", req.ID, "ruleID", ruleID, "pattern", action.Pattern)
+			return nil
+		}
+		m := re.FindStringSubmatchIndex(req.URL)
+		if m == nil {
+			return nil
+		}
+		location = string(re.ExpandString(nil, location, req.URL, m))
+	}
+	if location == "" {
+		p.log.Warn("[Processor] 重定向地址为空，已跳过", "requestID", req.ID, "ruleID", ruleID)
+		return nil
+	}
+
+	status := action.GetRedirectStatus()
+	p.log.Info("[Processor] 重定向请求", "requestID", req.ID, "ruleID", ruleID, "statusCode", status, "location", location)
+	res := p.mockResponse(req.ID, action, status)
+	res.Headers.Set("Location", location)
+	return res
+}
@@ -0,0 +1,62 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// violationHeader flag 方式下添加的响应头，值为违规数
+const violationHeader = "X-Schema-Violation"
+
+// validateSchema 按 JSON Schema 校验响应体，违规追加到响应中并按处理方式标记或替换响应，返回是否存在违规
+func (p *Processor) validateSchema(res *domain.Response, ruleID string, action rulespec.Action, reqID string) bool {
+	schema := action.SchemaText()
+	if schema == "" {
+		return false
+	}
+	body := res.Body
+	if codec := transformer.CodecFor(res.Headers.Get("Content-Type")); codec != transformer.CodecNone {
+		if doc, err := transformer.DecodeToJSON(res.Body, codec); err == nil {
+			body = []byte(doc)
+		}
+	}
+
+	violations, err := p.contracts.Check(schema, body)
+	if err != nil {
+		p.log.Err(err, "JSON Schema 无效", "requestID", reqID, "ruleID", ruleID)
+		return false
+	}
+	if len(violations) == 0 {
+		return false
+	}
+	for i := range violations {
+		violations[i].RuleID = ruleID
+	}
+	res.Violations = append(res.Violations, violations...)
+	p.log.Info("[Processor] 响应体不符合 JSON Schema", "requestID", reqID, "ruleID", ruleID, "violations", len(violations))
+
+	switch action.GetOnViolation() {
+	case rulespec.ViolationFlag:
+		res.Headers.Set(violationHeader, strconv.Itoa(len(res.Violations)))
+	case rulespec.ViolationFail:
+		data, _ := json.Marshal(map[string]any{
+			"error":      "response does not match JSON schema",
+			"violations": res.Violations,
+		})
+		for k := range res.Headers {
+			if strings.EqualFold(k, "Content-Type") || strings.EqualFold(k, "Content-Encoding") {
+				delete(res.Headers, k)
+			}
+		}
+		res.Headers.Set("Content-Type", "application/json")
+		res.StatusCode = http.StatusBadGateway
+		res.Body = data
+	}
+	return true
+}
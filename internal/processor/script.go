@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"net/url"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// scriptRequest 执行请求阶段 script 行为的脚本并应用其返回的修改，执行失败时记录日志并保留原请求。
+// 脚本修改 URL 时查询参数以新 URL 为准，之后再应用脚本返回的查询参数修改
+func (p *Processor) scriptRequest(req *domain.Request, action rulespec.Action) {
+	src, _ := action.Value.(string)
+	result, err := transformer.RunScript(src, scriptRequestOf(req), nil)
+	if err != nil {
+		p.log.Err(err, "请求脚本执行失败", "requestID", req.ID)
+		return
+	}
+	if result.URL != nil {
+		req.URL = *result.URL
+		req.Query = make(map[string]string)
+		if u, err := url.Parse(req.URL); err == nil {
+			for k, v := range u.Query() {
+				req.Query[k] = v[0]
+			}
+		}
+	}
+	if result.Method != nil {
+		req.Method = *result.Method
+	}
+	applyScriptHeaders(req.Headers, result.Headers)
+	applyScriptMap(req.Query, result.Query)
+	applyScriptMap(req.Cookies, result.Cookies)
+	if result.Body != nil {
+		req.Body = []byte(*result.Body)
+	}
+}
+
+// scriptResponse 执行响应阶段 script 行为的脚本并应用其返回的修改，脚本可读取对应的请求，执行失败时记录日志并保留原响应
+func (p *Processor) scriptResponse(req *domain.Request, res *domain.Response, action rulespec.Action, reqID string) {
+	src, _ := action.Value.(string)
+	result, err := transformer.RunScript(src, scriptRequestOf(req), &transformer.ScriptResponse{
+		Status:  res.StatusCode,
+		Headers: res.Headers,
+		Body:    string(res.Body),
+	})
+	if err != nil {
+		p.log.Err(err, "响应脚本执行失败", "requestID", reqID)
+		return
+	}
+	if result.Status != nil {
+		res.StatusCode = *result.Status
+	}
+	applyScriptHeaders(res.Headers, result.Headers)
+	if result.Body != nil {
+		res.Body = []byte(*result.Body)
+	}
+}
+
+// scriptRequestOf 转换为脚本读取的请求
+func scriptRequestOf(req *domain.Request) transformer.ScriptRequest {
+	return transformer.ScriptRequest{
+		Method:  req.Method,
+		URL:     req.URL,
+		Headers: req.Headers,
+		Query:   req.Query,
+		Cookies: req.Cookies,
+		Body:    string(req.Body),
+	}
+}
+
+// applyScriptHeaders 应用脚本返回的头部修改，值为 nil 时移除
+func applyScriptHeaders(h domain.Header, changes map[string]*string) {
+	for k, v := range changes {
+		if v == nil {
+			h.Del(k)
+		} else {
+			h.Set(k, *v)
+		}
+	}
+}
+
+// applyScriptMap 应用脚本返回的查询参数或 Cookie 修改，值为 nil 时移除
+func applyScriptMap(m map[string]string, changes map[string]*string) {
+	for k, v := range changes {
+		if v == nil {
+			delete(m, k)
+		} else {
+			m[k] = *v
+		}
+	}
+}
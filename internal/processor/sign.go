@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"time"
+
+	"cdpnetool/internal/signer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// pendingSign 待执行的 sign 动作
+type pendingSign struct {
+	ruleID string
+	action rulespec.Action
+}
+
+// signRequest 按规则顺序对最终请求签名，签名失败只记录日志；返回是否写入了签名
+func (p *Processor) signRequest(req *domain.Request, signs []pendingSign) bool {
+	signed := false
+	now := time.Now()
+	for _, s := range signs {
+		if err := sign(req, s.action.Sign, now); err != nil {
+			p.log.Warn("[Processor] 请求签名失败", "requestID", req.ID, "ruleID", s.ruleID, "error", err)
+			continue
+		}
+		p.log.Debug("[Processor] 请求已重新签名", "requestID", req.ID, "ruleID", s.ruleID, "method", s.action.Sign.Method)
+		p.engine.RecordEffect(s.ruleID)
+		signed = true
+	}
+	return signed
+}
+
+// sign 按签名方式计算签名，密钥从环境变量读取
+func sign(req *domain.Request, spec *rulespec.SignSpec, now time.Time) error {
+	if spec == nil {
+		return fmt.Errorf("missing sign spec")
+	}
+	switch spec.Method {
+	case rulespec.SignHMAC:
+		key, err := lookupEnv(spec.SecretEnv, "")
+		if err != nil {
+			return err
+		}
+		return signer.HMAC(req, signer.HMACOptions{
+			Key:       []byte(key),
+			Header:    spec.Header,
+			Algorithm: spec.Algorithm,
+			Encoding:  spec.Encoding,
+			Payload:   spec.Payload,
+			Template:  spec.Template,
+		}, now)
+	case rulespec.SignAWSSigV4:
+		accessKey, err := lookupEnv(spec.AccessKeyEnv, "AWS_ACCESS_KEY_ID")
+		if err != nil {
+			return err
+		}
+		secretKey, err := lookupEnv(spec.SecretKeyEnv, "AWS_SECRET_ACCESS_KEY")
+		if err != nil {
+			return err
+		}
+		// 会话令牌仅临时凭证需要，未设置时不报错
+		token := os.Getenv(cmp.Or(spec.SessionTokenEnv, "AWS_SESSION_TOKEN"))
+		cred := signer.Credentials{AccessKey: accessKey, SecretKey: secretKey, SessionToken: token}
+		return signer.SigV4(req, cred, spec.Region, spec.Service, now)
+	default:
+		return fmt.Errorf("unsupported sign method %q", spec.Method)
+	}
+}
+
+// lookupEnv 读取环境变量，name 为空时使用 def，变量未设置或为空时返回错误
+func lookupEnv(name, def string) (string, error) {
+	name = cmp.Or(name, def)
+	if name == "" {
+		return "", fmt.Errorf("secret environment variable not specified")
+	}
+	v := os.Getenv(name)
+	if v == "" {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return v, nil
+}
@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"cdpnetool/pkg/rulespec"
+)
+
+// Throttle 命中的 throttle 行为汇总的节流参数，零值表示不节流
+type Throttle struct {
+	Latency   time.Duration // 放行前的额外延迟，多个行为累加
+	Bandwidth int           // 带宽上限（字节/秒），多个行为取最小值，0 表示不限制
+}
+
+// add 合并一个 throttle 行为，设置了延迟分布时每次调用重新抽样，非正数参数视为不限制
+func (t *Throttle) add(action rulespec.Action) {
+	if action.Delay != nil {
+		t.Latency += sampleDelay(action.Delay)
+	} else if action.LatencyMS > 0 {
+		t.Latency += time.Duration(action.LatencyMS) * time.Millisecond
+	}
+	if bw := action.Bandwidth; bw > 0 && (t.Bandwidth == 0 || bw < t.Bandwidth) {
+		t.Bandwidth = bw
+	}
+}
+
+// sampleDelay 按延迟分布抽样一次延迟，结果不小于 0
+func sampleDelay(d *rulespec.DelaySpec) time.Duration {
+	var ms float64
+	switch d.Distribution {
+	case rulespec.DelayNormal:
+		ms = float64(d.MeanMS) + rand.NormFloat64()*float64(d.StdDevMS)
+		ms = max(ms, float64(d.MinMS))
+		if d.MaxMS > 0 {
+			ms = min(ms, float64(d.MaxMS))
+		}
+	case rulespec.DelayPercentile:
+		ms = percentileDelay(d.Percentiles, rand.Float64()*100)
+	default:
+		ms = float64(d.MinMS)
+		if d.MaxMS > d.MinMS {
+			ms += rand.Float64() * float64(d.MaxMS-d.MinMS)
+		}
+	}
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// percentileDelay 在百分位表中查找百分位 p 对应的延迟毫秒数，相邻两项之间线性插值，
+// 低于第一项时取第一项的延迟，高于最后一项时取最后一项的延迟
+func percentileDelay(table []rulespec.DelayPoint, p float64) float64 {
+	if len(table) == 0 {
+		return 0
+	}
+	if p <= table[0].P {
+		return float64(table[0].MS)
+	}
+	for i := 1; i < len(table); i++ {
+		lo, hi := table[i-1], table[i]
+		if p > hi.P {
+			continue
+		}
+		if hi.P <= lo.P {
+			return float64(hi.MS)
+		}
+		return float64(lo.MS) + (p-lo.P)/(hi.P-lo.P)*float64(hi.MS-lo.MS)
+	}
+	return float64(table[len(table)-1].MS)
+}
+
+// Active 判断是否需要节流
+func (t Throttle) Active() bool {
+	return t.Latency > 0 || t.Bandwidth > 0
+}
+
+// Transfer 按带宽上限计算传输 size 字节的消息体需要的时间，不含额外延迟
+func (t Throttle) Transfer(size int) time.Duration {
+	return TransferTime(size, t.Bandwidth)
+}
+
+// TransferTime 计算以 bandwidth 字节/秒传输 size 字节需要的时间，bandwidth 非正数时为 0
+func TransferTime(size, bandwidth int) time.Duration {
+	if bandwidth <= 0 || size <= 0 {
+		return 0
+	}
+	return time.Duration(int64(size) * int64(time.Second) / int64(bandwidth))
+}
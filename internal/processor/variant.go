@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"hash/fnv"
+
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// ruleActions 返回规则在指定阶段实际执行的行为，variant 行为展开为客户端所分配变体的行为，
+// 故障注入行为按 probability 决定本次是否执行
+func (p *Processor) ruleActions(req *domain.Request, rule *rulespec.Rule, stage rulespec.Stage) []rulespec.Action {
+	hasVariant := false
+	for _, action := range rule.Actions {
+		if action.Type == rulespec.ActionVariant {
+			hasVariant = true
+			break
+		}
+	}
+	if !hasVariant {
+		return p.rollFaults(req, rule.ID, p.allowedActions(req, rule.ID, rule.Actions))
+	}
+
+	actions := make([]rulespec.Action, 0, len(rule.Actions))
+	for _, action := range rule.Actions {
+		if action.Type != rulespec.ActionVariant {
+			actions = append(actions, action)
+			continue
+		}
+		v := pickVariant(req, rule.ID, action)
+		if v == nil {
+			p.log.Debug("[Processor] 请求缺少变体分配键，跳过 variant 动作", "requestID", req.ID, "ruleID", rule.ID, "stickyBy", action.GetStickyBy(), "name", action.Name)
+			continue
+		}
+		p.log.Debug("[Processor] 选中变体", "requestID", req.ID, "ruleID", rule.ID, "variant", v.Name)
+		p.engine.RecordVariant(rule.ID, v.Name)
+		for _, va := range v.Actions {
+			if va.Type == rulespec.ActionVariant || !va.IsValidForStage(stage) {
+				p.log.Warn("[Processor] 变体包含当前阶段不支持的动作，已忽略", "requestID", req.ID, "ruleID", rule.ID, "variant", v.Name, "actionType", va.Type)
+				continue
+			}
+			actions = append(actions, va)
+		}
+	}
+	return p.rollFaults(req, rule.ID, p.allowedActions(req, rule.ID, actions))
+}
+
+// allowedActions 按能力配置档过滤行为，作为规则加载时校验之外的兜底
+func (p *Processor) allowedActions(req *domain.Request, ruleID string, actions []rulespec.Action) []rulespec.Action {
+	if p.capabilities == "" || p.capabilities == domain.CapabilityFull {
+		return actions
+	}
+	allowed := make([]rulespec.Action, 0, len(actions))
+	for i := range actions {
+		if !rulespec.ActionAllowed(p.capabilities, &actions[i]) {
+			p.log.Warn("[Processor] 能力配置档不允许该动作，已跳过", "requestID", req.ID, "ruleID", ruleID, "profile", p.capabilities, "actionType", actions[i].Type)
+			continue
+		}
+		allowed = append(allowed, actions[i])
+	}
+	return allowed
+}
+
+// pickVariant 按客户端键的哈希在变体间按权重分配，同一客户端在同一规则下始终得到相同变体；
+// 请求缺少客户端键或没有候选变体时返回 nil
+func pickVariant(req *domain.Request, ruleID string, action rulespec.Action) *rulespec.Variant {
+	key := stickyKey(req, action)
+	if key == "" || len(action.Variants) == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, v := range action.Variants {
+		total += max(v.Weight, 0)
+	}
+	h := fnv.New64a()
+	h.Write([]byte(ruleID))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	sum := h.Sum64()
+
+	if total == 0 {
+		// 未设置权重时平均分配
+		return &action.Variants[sum%uint64(len(action.Variants))]
+	}
+	n := int(sum % uint64(total))
+	for i, v := range action.Variants {
+		if n < max(v.Weight, 0) {
+			return &action.Variants[i]
+		}
+		n -= max(v.Weight, 0)
+	}
+	return nil
+}
+
+// stickyKey 返回区分客户端的键：指定 Cookie 或请求头的值
+func stickyKey(req *domain.Request, action rulespec.Action) string {
+	if action.GetStickyBy() == rulespec.StickyHeader {
+		return req.Headers.Get(action.Name)
+	}
+	return req.Cookies[action.Name]
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"cdpnetool/internal/engine"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// maxFramePayload 消息帧事件中保留的最大载荷字节数，超出部分截断；规则匹配使用完整载荷
+const maxFramePayload = 64 << 10
+
+// handshakeHeaders WebSocket 握手必需的请求头，规则不允许修改或删除
+var handshakeHeaders = []string{"Upgrade", "Connection", "Sec-WebSocket-Key", "Sec-WebSocket-Version"}
+
+// wsSchemes http 与 ws 协议的对应关系
+var wsSchemes = map[string]string{
+	"http":  "ws",
+	"https": "wss",
+	"ws":    "http",
+	"wss":   "https",
+}
+
+// handshakeAllowed 判断动作能否应用于 WebSocket 握手请求，握手固定为无请求体的 GET
+func handshakeAllowed(t rulespec.ActionType) bool {
+	switch t {
+	case rulespec.ActionSetUrl, rulespec.ActionMapRemote, rulespec.ActionSetHeader, rulespec.ActionRemoveHeader,
+		rulespec.ActionSetQueryParam, rulespec.ActionRemoveQueryParam,
+		rulespec.ActionSetCookie, rulespec.ActionRemoveCookie, rulespec.ActionSetUserAgent:
+		return true
+	default:
+		return false
+	}
+}
+
+// snapshotHandshake 记录握手必需请求头的原始值
+func snapshotHandshake(h domain.Header) domain.Header {
+	saved := make(domain.Header)
+	for k, v := range h {
+		for _, name := range handshakeHeaders {
+			if strings.EqualFold(k, name) {
+				saved[k] = v
+			}
+		}
+	}
+	return saved
+}
+
+// restoreHandshake 还原握手必需请求头，并使 URL 协议与原始请求保持同一形式（ws/wss 或 http/https）
+func restoreHandshake(req *domain.Request, saved domain.Header, origURL string) {
+	for k := range req.Headers {
+		for _, name := range handshakeHeaders {
+			if strings.EqualFold(k, name) {
+				delete(req.Headers, k)
+			}
+		}
+	}
+	for k, v := range saved {
+		req.Headers.Set(k, v)
+	}
+	req.URL = matchScheme(origURL, req.URL)
+}
+
+// matchScheme 规则将 ws:// 改写为 http(s):// 形式（或相反）时，换算为原始请求使用的协议形式
+func matchScheme(origURL, newURL string) string {
+	orig, err := url.Parse(origURL)
+	if err != nil {
+		return newURL
+	}
+	u, err := url.Parse(newURL)
+	if err != nil {
+		return newURL
+	}
+	origScheme, scheme := strings.ToLower(orig.Scheme), strings.ToLower(u.Scheme)
+	if scheme == origScheme {
+		return newURL
+	}
+	if mapped, ok := wsSchemes[scheme]; ok && isWSScheme(mapped) == isWSScheme(origScheme) {
+		u.Scheme = mapped
+		return u.String()
+	}
+	return newURL
+}
+
+// isWSScheme 判断是否为 ws/wss 协议
+func isWSScheme(s string) bool {
+	return s == "ws" || s == "wss"
+}
+
+// ProcessFrame 处理 WebSocket 消息帧：按 websocket 阶段的规则匹配并记录事件。
+// 消息帧无法修改，匹配的规则只记录不执行行为；conn 为所属连接的握手请求，不会被修改
+func (p *Processor) ProcessFrame(sessionID, targetID string, conn *domain.Request, frame domain.WebSocketFrame, payload []byte) {
+	req := *conn
+	req.Body = payload
+	var matched []*engine.MatchedRule
+	if frame.Direction != domain.WebSocketError {
+		// 消息帧不属于任何请求的处理链路，不记录追踪
+		matched = p.eval(context.Background(), &req, rulespec.StageWebSocket)
+	}
+	if len(payload) > maxFramePayload {
+		req.Body = payload[:maxFramePayload]
+		frame.Truncated = true
+	}
+
+	finalResult := "passed"
+	if len(matched) > 0 {
+		finalResult = "matched"
+		p.log.Debug("[Processor] WebSocket 消息帧匹配规则", "requestID", conn.ID, "frame", frame.Index, "ruleIDs", ruleIDs(matched))
+	}
+	id := conn.ID + "#" + strconv.Itoa(frame.Index)
+	matches := p.toRuleMatches(matched)
+	p.trafficAuditor.RecordFrame(sessionID, targetID, id, &req, &frame, finalResult, matches)
+	if len(matched) > 0 {
+		p.matchedAuditor.RecordFrame(sessionID, targetID, id, &req, &frame, finalResult, matches)
+	}
+}
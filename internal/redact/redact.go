@@ -0,0 +1,230 @@
+// Package redact 在事件写入存储或导出前移除其中的凭据等敏感信息，使抓包结果可以安全分享
+package redact
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+)
+
+// Mask 替换敏感内容的占位文本
+const Mask = "[REDACTED]"
+
+// Redactor 按配置对网络事件脱敏，nil 脱敏器原样返回事件
+type Redactor struct {
+	headers  []string // 小写的头部名称
+	cookies  []string
+	paths    []string
+	patterns []*regexp.Regexp
+}
+
+// New 创建脱敏器，配置为空时返回 nil 表示不脱敏
+func New(cfg domain.RedactionConfig) (*Redactor, error) {
+	if cfg.IsZero() {
+		return nil, nil
+	}
+	r := &Redactor{
+		cookies: slices.Clone(cfg.Cookies),
+		paths:   slices.Clone(cfg.JSONPaths),
+	}
+	for _, h := range cfg.Headers {
+		r.headers = append(r.headers, strings.ToLower(h))
+	}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Event 返回脱敏后的事件副本，不修改原事件
+func (r *Redactor) Event(evt domain.NetworkEvent) domain.NetworkEvent {
+	if r == nil {
+		return evt
+	}
+	evt.Request = r.request(evt.Request)
+	if evt.Response != nil {
+		res := r.response(*evt.Response)
+		evt.Response = &res
+	}
+	if evt.OriginalRequest != nil {
+		req := r.request(*evt.OriginalRequest)
+		evt.OriginalRequest = &req
+	}
+	if evt.OriginalResponse != nil {
+		res := r.response(*evt.OriginalResponse)
+		evt.OriginalResponse = &res
+	}
+	return evt
+}
+
+// request 对请求的 URL、头部、Cookie 与消息体脱敏
+func (r *Redactor) request(req domain.Request) domain.Request {
+	req.URL = r.text(req.URL)
+	if len(req.Query) > 0 {
+		req.Query = parseQuery(req.URL)
+	}
+	req.Headers = r.headerMap(req.Headers, "cookie", redactCookieHeader)
+	if len(req.Cookies) > 0 {
+		cookies := maps.Clone(req.Cookies)
+		for name, v := range cookies {
+			if slices.Contains(r.cookies, name) {
+				cookies[name] = Mask
+			} else {
+				cookies[name] = r.text(v)
+			}
+		}
+		req.Cookies = cookies
+	}
+	req.Body = r.body(req.Body, req.Headers)
+	req.Decoded = r.json(req.Decoded)
+	return req
+}
+
+// response 对响应的头部、Set-Cookie 与消息体脱敏
+func (r *Redactor) response(res domain.Response) domain.Response {
+	res.Headers = r.headerMap(res.Headers, "set-cookie", redactSetCookie)
+	res.Body = r.body(res.Body, res.Headers)
+	res.Decoded = r.json(res.Decoded)
+	return res
+}
+
+// headerMap 复制并脱敏头部；cookieHeader 为承载 Cookie 的头部，按 Cookie 名称脱敏
+func (r *Redactor) headerMap(h domain.Header, cookieHeader string, redactCookies func(string, []string) string) domain.Header {
+	if h == nil {
+		return nil
+	}
+	out := make(domain.Header, len(h))
+	for name, v := range h {
+		lower := strings.ToLower(name)
+		switch {
+		case slices.Contains(r.headers, lower):
+			v = Mask
+		case lower == cookieHeader && len(r.cookies) > 0:
+			v = r.text(redactCookies(v, r.cookies))
+		default:
+			v = r.text(v)
+		}
+		out[name] = v
+	}
+	return out
+}
+
+// body 对消息体脱敏：JSON 按字段路径替换，MessagePack 与 CBOR 解码后替换再重新编码，
+// 文本按正则替换，其他二进制内容保持不变
+func (r *Redactor) body(body []byte, h domain.Header) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	if codec := transformer.CodecFor(h.Get("Content-Type")); codec != transformer.CodecNone {
+		if len(r.paths) == 0 && len(r.patterns) == 0 {
+			return body
+		}
+		doc, err := transformer.DecodeToJSON(body, codec)
+		if err != nil {
+			return body
+		}
+		if redacted := r.json(doc); redacted != doc {
+			if out, err := transformer.EncodeFromJSON(redacted, codec); err == nil {
+				return out
+			}
+		}
+		return body
+	}
+	if !utf8.Valid(body) {
+		return body
+	}
+	return []byte(r.json(string(body)))
+}
+
+// json 对 JSON 文本按字段路径脱敏后再按正则脱敏，非 JSON 文本仅按正则脱敏
+func (r *Redactor) json(text string) string {
+	if text == "" {
+		return text
+	}
+	if len(r.paths) > 0 {
+		if redacted, err := transformer.RedactJSON(text, r.paths, Mask); err == nil {
+			text = redacted
+		}
+	}
+	return r.text(text)
+}
+
+// text 将正则匹配的内容替换为占位文本，含捕获组时仅替换捕获组
+func (r *Redactor) text(s string) string {
+	for _, re := range r.patterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllLiteralString(s, Mask)
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+			for g := 2; g < len(m); g += 2 {
+				if m[g] < last {
+					// 未参与匹配或与已替换的捕获组重叠
+					continue
+				}
+				b.WriteString(s[last:m[g]])
+				b.WriteString(Mask)
+				last = m[g+1]
+			}
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// redactCookieHeader 替换 Cookie 头中指定名称的值，保留原有顺序
+func redactCookieHeader(v string, names []string) string {
+	parts := strings.Split(v, ";")
+	for i, part := range parts {
+		name, _, ok := strings.Cut(part, "=")
+		if ok && slices.Contains(names, strings.TrimSpace(name)) {
+			parts[i] = name + "=" + Mask
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// redactSetCookie 替换 Set-Cookie 头中指定名称的值，多个 Cookie 以换行分隔
+func redactSetCookie(v string, names []string) string {
+	lines := strings.Split(v, "\n")
+	for i, line := range lines {
+		pair, attrs, _ := strings.Cut(line, ";")
+		name, _, ok := strings.Cut(pair, "=")
+		if !ok || !slices.Contains(names, strings.TrimSpace(name)) {
+			continue
+		}
+		lines[i] = name + "=" + Mask
+		if attrs != "" {
+			lines[i] += ";" + attrs
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseQuery 按请求转换时的方式从 URL 解析查询参数
+func parseQuery(rawURL string) map[string]string {
+	query := make(map[string]string)
+	_, q, ok := strings.Cut(rawURL, "?")
+	if !ok {
+		return query
+	}
+	for _, pair := range strings.Split(q, "&") {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			query[k] = v
+		}
+	}
+	return query
+}
@@ -0,0 +1,134 @@
+package redact_test
+
+import (
+	"strings"
+	"testing"
+
+	"cdpnetool/internal/redact"
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+)
+
+func newRedactor(t *testing.T, cfg domain.RedactionConfig) *redact.Redactor {
+	t.Helper()
+	r, err := redact.New(cfg)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	return r
+}
+
+func TestNew(t *testing.T) {
+	if r, err := redact.New(domain.RedactionConfig{}); r != nil || err != nil {
+		t.Errorf("New(empty) = %v, %v; want nil redactor", r, err)
+	}
+	if _, err := redact.New(domain.RedactionConfig{Patterns: []string{"(unclosed"}}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+
+	// nil 脱敏器原样返回
+	var none *redact.Redactor
+	evt := domain.NetworkEvent{Request: domain.Request{URL: "https://example.com/?token=abc"}}
+	if got := none.Event(evt); got.Request.URL != evt.Request.URL {
+		t.Errorf("got %s from nil redactor", got.Request.URL)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	r := newRedactor(t, domain.RedactionConfig{
+		Headers:   []string{"authorization"},
+		Cookies:   []string{"sid"},
+		JSONPaths: []string{"**.password"},
+		Patterns:  []string{`token=([^&]+)`, `\d{3}-\d{4}`},
+	})
+	evt := domain.NetworkEvent{
+		Request: domain.Request{
+			URL: "https://example.com/login?token=abc&page=1",
+			Headers: domain.Header{
+				"Authorization": "Bearer xyz",
+				"Cookie":        "sid=s3cret; theme=dark",
+				"Content-Type":  "application/json",
+			},
+			Query:   map[string]string{"token": "abc", "page": "1"},
+			Cookies: map[string]string{"sid": "s3cret", "theme": "dark"},
+			Body:    []byte(`{"user":{"name":"a","password":"p"},"phone":"555-1234"}`),
+		},
+		Response: &domain.Response{
+			StatusCode: 200,
+			Headers:    domain.Header{"Set-Cookie": "sid=new; Path=/; HttpOnly\nlang=en"},
+			Body:       []byte("next=/home?token=def"),
+		},
+	}
+	got := r.Event(evt)
+
+	req := got.Request
+	if req.URL != "https://example.com/login?token=[REDACTED]&page=1" {
+		t.Errorf("got URL %s", req.URL)
+	}
+	if req.Query["token"] != redact.Mask || req.Query["page"] != "1" {
+		t.Errorf("got query %v", req.Query)
+	}
+	if req.Headers["Authorization"] != redact.Mask || req.Headers["Cookie"] != "sid=[REDACTED]; theme=dark" {
+		t.Errorf("got headers %v", req.Headers)
+	}
+	if req.Cookies["sid"] != redact.Mask || req.Cookies["theme"] != "dark" {
+		t.Errorf("got cookies %v", req.Cookies)
+	}
+	if string(req.Body) != `{"user":{"name":"a","password":"[REDACTED]"},"phone":"[REDACTED]"}` {
+		t.Errorf("got body %s", req.Body)
+	}
+
+	res := got.Response
+	if res.Headers["Set-Cookie"] != "sid=[REDACTED]; Path=/; HttpOnly\nlang=en" {
+		t.Errorf("got Set-Cookie %q", res.Headers["Set-Cookie"])
+	}
+	if string(res.Body) != "next=/home?token=[REDACTED]" {
+		t.Errorf("got response body %s", res.Body)
+	}
+
+	// 规则修改前的快照同样脱敏
+	evt.OriginalRequest = &domain.Request{URL: "https://example.com/login?token=abc", Headers: domain.Header{"Authorization": "Bearer old"}}
+	evt.OriginalResponse = &domain.Response{StatusCode: 200, Body: []byte("call 555-0000")}
+	got = r.Event(evt)
+	if o := got.OriginalRequest; o.URL != "https://example.com/login?token=[REDACTED]" || o.Headers["Authorization"] != redact.Mask {
+		t.Errorf("got original request %+v", o)
+	}
+	if string(got.OriginalResponse.Body) != "call [REDACTED]" {
+		t.Errorf("got original response body %s", got.OriginalResponse.Body)
+	}
+
+	// 原事件不被修改
+	if evt.Request.Headers["Authorization"] != "Bearer xyz" || evt.Request.Cookies["sid"] != "s3cret" ||
+		!strings.Contains(string(evt.Request.Body), `"p"`) || evt.Response.Headers["Set-Cookie"] == res.Headers["Set-Cookie"] {
+		t.Error("original event should not be modified")
+	}
+}
+
+func TestEvent_BinaryBodies(t *testing.T) {
+	r := newRedactor(t, domain.RedactionConfig{JSONPaths: []string{"token"}, Patterns: []string{"secret"}})
+
+	packed, err := transformer.EncodeFromJSON(`{"token":"abc","n":1}`, transformer.CodecMsgpack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evt := domain.NetworkEvent{Response: &domain.Response{
+		Headers: domain.Header{"content-type": "application/msgpack"},
+		Body:    packed,
+		Decoded: `{"n":1,"token":"abc"}`,
+	}}
+	got := r.Event(evt).Response
+	doc, err := transformer.DecodeToJSON(got.Body, transformer.CodecMsgpack)
+	if err != nil || doc != `{"n":1,"token":"[REDACTED]"}` {
+		t.Errorf("got msgpack body %s, %v", doc, err)
+	}
+	if got.Decoded != `{"n":1,"token":"[REDACTED]"}` {
+		t.Errorf("got decoded %s", got.Decoded)
+	}
+
+	// 非 UTF-8 的二进制内容保持不变
+	raw := []byte{0xff, 0xfe, 's', 'e', 'c', 'r', 'e', 't'}
+	evt = domain.NetworkEvent{Response: &domain.Response{Headers: domain.Header{"Content-Type": "image/png"}, Body: raw}}
+	if got := r.Event(evt).Response; string(got.Body) != string(raw) {
+		t.Errorf("got binary body %v, want unchanged", got.Body)
+	}
+}
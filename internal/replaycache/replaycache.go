@@ -0,0 +1,184 @@
+// Package replaycache 录制回放缓存：按匹配键保存浏览器最终收到的响应，之后以录制的响应应答相同的请求，
+// 可选保存到目录以便跨会话离线回放
+package replaycache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+// fileExt 保存目录中记录文件的扩展名，文件名为匹配键
+const fileExt = ".json"
+
+// Entry 一条录制的响应
+type Entry struct {
+	Method     string        `json:"method"`         // 录制时的请求方法
+	URL        string        `json:"url"`            // 录制时的请求 URL
+	StatusCode int           `json:"statusCode"`     // 状态码
+	Headers    domain.Header `json:"headers"`        // 响应头
+	Body       []byte        `json:"body,omitempty"` // 响应体
+	StoredAt   time.Time     `json:"storedAt"`       // 录制时间
+}
+
+// Response 返回记录对应的响应副本
+func (e *Entry) Response() *domain.Response {
+	res := domain.NewResponse()
+	res.StatusCode = e.StatusCode
+	for k, v := range e.Headers {
+		res.Headers.Set(k, v)
+	}
+	res.Body = slices.Clone(e.Body)
+	return res
+}
+
+// Key 按配置计算请求的匹配键：方法、去掉忽略的查询参数与片段并排序查询参数后的 URL、
+// 请求体（IgnoreBody 时不计入）以及 KeyHeaders 中请求头的值
+func Key(req *domain.Request, opts domain.ReplayCacheOptions) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizeURL(req.URL, opts.IgnoreQuery)))
+	h.Write([]byte{0})
+	if !opts.IgnoreBody {
+		h.Write(req.Body)
+	}
+	if len(opts.KeyHeaders) > 0 {
+		values := make(map[string]string, len(req.Headers))
+		for k, v := range req.Headers {
+			values[strings.ToLower(k)] = v
+		}
+		names := make([]string, 0, len(opts.KeyHeaders))
+		for _, name := range opts.KeyHeaders {
+			names = append(names, strings.ToLower(strings.TrimSpace(name)))
+		}
+		slices.Sort(names)
+		for _, name := range slices.Compact(names) {
+			h.Write([]byte{0})
+			h.Write([]byte(name + ":" + values[name]))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// normalizeURL 去掉 URL 片段与忽略的查询参数，其余查询参数按名称排序，无法解析时原样返回
+func normalizeURL(raw string, ignore []string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.RawQuery != "" {
+		q := u.Query()
+		for _, name := range ignore {
+			q.Del(name)
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// Store 录制的响应，并发安全；指定保存目录时每条记录写入一个文件，读取时按需从目录加载
+type Store struct {
+	mu      sync.Mutex
+	dir     string
+	entries map[string]*Entry // 已加载的记录：匹配键 -> 记录
+	onDisk  map[string]bool   // 保存目录中已有、尚未加载的记录
+}
+
+// Open 创建录制存储，dir 为空时只保存在内存中；目录不存在时自动创建，已有的记录可直接命中
+func Open(dir string) (*Store, error) {
+	s := &Store{dir: dir, entries: make(map[string]*Entry), onDisk: make(map[string]bool)}
+	if dir == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create replay cache dir: %w", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read replay cache dir: %w", err)
+	}
+	for _, f := range files {
+		if name := f.Name(); !f.IsDir() && strings.HasSuffix(name, fileExt) {
+			s.onDisk[strings.TrimSuffix(name, fileExt)] = true
+		}
+	}
+	return s, nil
+}
+
+// Dir 返回保存目录，只保存在内存中时为空
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get 查找匹配键对应的记录，ttl 大于 0 时录制时间早于 now - ttl 的记录视为未命中；
+// 保存目录中无法读取或解析的记录视为未命中
+func (s *Store) Get(key string, ttl time.Duration, now time.Time) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok && s.onDisk[key] {
+		e, ok = s.load(key)
+	}
+	if !ok || ttl > 0 && now.Sub(e.StoredAt) > ttl {
+		return nil, false
+	}
+	return e, true
+}
+
+// load 从保存目录读取一条记录，调用方需持有锁
+func (s *Store) load(key string) (*Entry, bool) {
+	data, err := os.ReadFile(filepath.Join(s.dir, key+fileExt))
+	if err != nil {
+		return nil, false
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+	delete(s.onDisk, key)
+	s.entries[key] = &e
+	return &e, true
+}
+
+// Put 保存一条记录，覆盖相同匹配键的旧记录；指定了保存目录时同时写入文件，
+// 写入失败时记录仍保留在内存中并返回错误
+func (s *Store) Put(key string, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = e
+	delete(s.onDisk, key)
+	if s.dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再改名，避免进程中断时留下不完整的记录
+	path := filepath.Join(s.dir, key+fileExt)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Len 返回记录数，含保存目录中尚未加载的记录
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries) + len(s.onDisk)
+}
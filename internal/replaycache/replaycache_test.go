@@ -0,0 +1,96 @@
+package replaycache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/replaycache"
+	"cdpnetool/pkg/domain"
+)
+
+func TestKey(t *testing.T) {
+	req := func(url, body string, headers domain.Header) *domain.Request {
+		return &domain.Request{Method: "POST", URL: url, Headers: headers, Body: []byte(body)}
+	}
+	opts := domain.ReplayCacheOptions{IgnoreQuery: []string{"_t"}, KeyHeaders: []string{"Accept-Language"}}
+	base := replaycache.Key(req("https://example.com/a?x=1&y=2", "{}", domain.Header{"accept-language": "en"}), opts)
+
+	same := []*domain.Request{
+		req("https://example.com/a?y=2&x=1", "{}", domain.Header{"accept-language": "en"}),
+		req("https://example.com/a?x=1&y=2&_t=123#top", "{}", domain.Header{"Accept-Language": "en", "X-Trace": "1"}),
+	}
+	for _, r := range same {
+		if got := replaycache.Key(r, opts); got != base {
+			t.Errorf("Key(%s) = %s, want %s", r.URL, got, base)
+		}
+	}
+	different := []*domain.Request{
+		req("https://example.com/a?x=2&y=2", "{}", domain.Header{"accept-language": "en"}),
+		req("https://example.com/a?x=1&y=2", `{"a":1}`, domain.Header{"accept-language": "en"}),
+		req("https://example.com/a?x=1&y=2", "{}", domain.Header{"accept-language": "zh"}),
+	}
+	for _, r := range different {
+		if got := replaycache.Key(r, opts); got == base {
+			t.Errorf("Key(%s, %s, %v) = base key, want a different key", r.URL, r.Body, r.Headers)
+		}
+	}
+
+	opts.IgnoreBody = true
+	if replaycache.Key(req("https://example.com/a", "1", nil), opts) != replaycache.Key(req("https://example.com/a", "2", nil), opts) {
+		t.Error("got different keys for different bodies with IgnoreBody")
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := replaycache.Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	now := time.Now()
+	e := &replaycache.Entry{Method: "GET", URL: "https://example.com/a", StatusCode: 201,
+		Headers: domain.Header{"Content-Type": "text/plain"}, Body: []byte("hello"), StoredAt: now.Add(-time.Minute)}
+	if err := s.Put("k1", e); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "k1.json")); err != nil {
+		t.Errorf("record file not written: %v", err)
+	}
+
+	// 重新打开目录后仍可命中，超过有效期的记录视为未命中
+	s, err = replaycache.Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if s.Len() != 1 {
+		t.Errorf("got %d entries, want 1", s.Len())
+	}
+	got, ok := s.Get("k1", 0, now)
+	if !ok {
+		t.Fatal("got miss after reopening, want hit")
+	}
+	res := got.Response()
+	if res.StatusCode != 201 || string(res.Body) != "hello" || res.Headers.Get("Content-Type") != "text/plain" {
+		t.Errorf("got response %d %q %v, want the recorded response", res.StatusCode, res.Body, res.Headers)
+	}
+	if _, ok := s.Get("k1", 30*time.Second, now); ok {
+		t.Error("got hit for an expired entry, want miss")
+	}
+	if _, ok := s.Get("k2", 0, now); ok {
+		t.Error("got hit for an unknown key, want miss")
+	}
+
+	// 不指定目录时只保存在内存中
+	mem, err := replaycache.Open("")
+	if err != nil {
+		t.Fatalf("Open(\"\") error = %v", err)
+	}
+	if err := mem.Put("k1", e); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, ok := mem.Get("k1", 0, now); !ok || mem.Len() != 1 {
+		t.Error("got miss from the in-memory store, want hit")
+	}
+}
@@ -0,0 +1,417 @@
+// Package report 将会话汇总渲染为 HTML 或 Markdown 报告，便于附加到缺陷单或测试产物中
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+// topDomains 报告中展示的域名数量上限
+const topDomains = 10
+
+// ParseFormat 解析报告格式，空字符串默认为 Markdown
+func ParseFormat(s string) (domain.ReportFormat, error) {
+	switch f := domain.ReportFormat(strings.ToLower(s)); f {
+	case "", "md":
+		return domain.ReportFormatMarkdown, nil
+	case domain.ReportFormatHTML, domain.ReportFormatMarkdown:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: unsupported report format %q", domain.ErrInvalidConfig, s)
+	}
+}
+
+// Render 按指定格式渲染会话报告
+func Render(s domain.SessionSummary, format domain.ReportFormat) ([]byte, error) {
+	v := newView(s)
+	switch format {
+	case domain.ReportFormatMarkdown:
+		return renderMarkdown(v), nil
+	case domain.ReportFormatHTML:
+		var buf bytes.Buffer
+		if err := htmlTemplate.Execute(&buf, v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported report format %q", domain.ErrInvalidConfig, format)
+	}
+}
+
+// statusRow 单个状态码的响应数
+type statusRow struct {
+	Code  int
+	Count int64
+}
+
+// variantRow 规则单个变体的分配次数
+type variantRow struct {
+	Name    string
+	RuleID  domain.RuleID
+	Variant string
+	Count   int64
+}
+
+// domainRow 单个域名的流量
+type domainRow struct {
+	Domain   string
+	Requests int64
+	Blocked  int64
+	Bytes    string
+}
+
+// view 报告模板使用的数据
+type view struct {
+	SessionID   string
+	StartedAt   string
+	EndedAt     string
+	GeneratedAt string
+	Duration    string
+
+	Requests  int64
+	Blocked   int64
+	Uploaded  string
+	Received  string
+	Mutations int64
+	Degraded  int64
+	Client    int64 // 4xx 响应数
+	Server    int64 // 5xx 响应数
+
+	Domains        []domainRow
+	Status         []statusRow
+	RulesFired     []domain.RuleCoverage // 按命中次数降序
+	Mutated        []domain.RuleCoverage // 产生实际修改的规则，按修改次数降序
+	Variants       []variantRow          // 按规则命中次数与变体名称排序
+	NeverMatched   []domain.RuleID
+	AlwaysDegraded []domain.RuleID
+
+	Assertions *domain.AssertionReport // 会话配置了断言时的校验结果
+}
+
+// newView 从会话汇总整理报告数据
+func newView(s domain.SessionSummary) view {
+	v := view{
+		SessionID:      string(s.SessionID),
+		StartedAt:      formatTime(s.StartedAt),
+		EndedAt:        formatTime(s.EndedAt),
+		GeneratedAt:    formatTime(s.GeneratedAt),
+		Requests:       s.Traffic.Total.Requests,
+		Blocked:        s.Traffic.Total.Blocked,
+		Uploaded:       formatBytes(s.Traffic.Total.RequestBytes),
+		Received:       formatBytes(s.Traffic.Total.ResponseBytes),
+		NeverMatched:   s.Coverage.NeverMatched,
+		AlwaysDegraded: s.Coverage.AlwaysDegraded,
+		Assertions:     s.Assertions,
+	}
+	if s.EndedAt == 0 {
+		v.EndedAt = "running"
+	}
+	end := s.EndedAt
+	if end == 0 {
+		end = s.GeneratedAt
+	}
+	if s.StartedAt > 0 && end >= s.StartedAt {
+		v.Duration = (time.Duration(end-s.StartedAt) * time.Millisecond).Round(time.Second).String()
+	}
+
+	for i, d := range s.Traffic.Domains {
+		if i == topDomains {
+			break
+		}
+		v.Domains = append(v.Domains, domainRow{
+			Domain:   d.Domain,
+			Requests: d.Total.Requests,
+			Blocked:  d.Total.Blocked,
+			Bytes:    formatBytes(d.Total.RequestBytes + d.Total.ResponseBytes),
+		})
+	}
+
+	for code, n := range s.Traffic.Status {
+		v.Status = append(v.Status, statusRow{Code: code, Count: n})
+		switch {
+		case code >= 500:
+			v.Server += n
+		case code >= 400:
+			v.Client += n
+		}
+	}
+	sort.Slice(v.Status, func(i, j int) bool { return v.Status[i].Code < v.Status[j].Code })
+
+	for _, r := range s.Coverage.Rules {
+		v.Degraded += r.Degraded
+		if r.Matched > 0 {
+			v.RulesFired = append(v.RulesFired, r)
+		}
+		if r.Effective > 0 {
+			v.Mutations += r.Effective
+			v.Mutated = append(v.Mutated, r)
+		}
+	}
+	sort.SliceStable(v.RulesFired, func(i, j int) bool { return v.RulesFired[i].Matched > v.RulesFired[j].Matched })
+	sort.SliceStable(v.Mutated, func(i, j int) bool { return v.Mutated[i].Effective > v.Mutated[j].Effective })
+
+	for _, r := range v.RulesFired {
+		names := make([]string, 0, len(r.Variants))
+		for name := range r.Variants {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v.Variants = append(v.Variants, variantRow{Name: r.Name, RuleID: r.RuleID, Variant: name, Count: r.Variants[name]})
+		}
+	}
+	return v
+}
+
+// renderMarkdown 渲染 Markdown 报告
+func renderMarkdown(v view) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session Report: %s\n\n", mdEscape(v.SessionID))
+	fmt.Fprintf(&b, "- Started: %s\n- Ended: %s\n", v.StartedAt, v.EndedAt)
+	if v.Duration != "" {
+		fmt.Fprintf(&b, "- Duration: %s\n", v.Duration)
+	}
+	fmt.Fprintf(&b, "- Generated: %s\n\n", v.GeneratedAt)
+
+	b.WriteString("## Overview\n\n")
+	fmt.Fprintf(&b, "| Requests | Blocked | Mutations | Uploaded | Received |\n|---:|---:|---:|---:|---:|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %s | %s |\n\n", v.Requests, v.Blocked, v.Mutations, v.Uploaded, v.Received)
+
+	if a := v.Assertions; a != nil {
+		fmt.Fprintf(&b, "## Assertions: %s\n\n", passLabel(a.Passed))
+		b.WriteString("| Assertion | Matched | Result |\n|---|---:|---|\n")
+		for _, r := range a.Results {
+			fmt.Fprintf(&b, "| `%s` | %d | %s |\n", r.Assertion, r.Matched, passLabel(r.Passed))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Top Domains\n\n")
+	if len(v.Domains) == 0 {
+		b.WriteString("_No traffic recorded._\n\n")
+	} else {
+		b.WriteString("| Domain | Requests | Blocked | Bytes |\n|---|---:|---:|---:|\n")
+		for _, d := range v.Domains {
+			fmt.Fprintf(&b, "| %s | %d | %d | %s |\n", mdEscape(d.Domain), d.Requests, d.Blocked, d.Bytes)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Status Codes\n\n")
+	if len(v.Status) == 0 {
+		b.WriteString("_No responses recorded._\n\n")
+	} else {
+		b.WriteString("| Status | Responses |\n|---|---:|\n")
+		for _, s := range v.Status {
+			fmt.Fprintf(&b, "| %d | %d |\n", s.Code, s.Count)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Rules Fired\n\n")
+	if len(v.RulesFired) == 0 {
+		b.WriteString("_No rules matched._\n\n")
+	} else {
+		b.WriteString("| Rule | ID | Matched | Effective | Degraded |\n|---|---|---:|---:|---:|\n")
+		for _, r := range v.RulesFired {
+			fmt.Fprintf(&b, "| %s | `%s` | %d | %d | %d |\n", mdEscape(r.Name), r.RuleID, r.Matched, r.Effective, r.Degraded)
+		}
+		b.WriteString("\n")
+	}
+	if len(v.NeverMatched) > 0 {
+		fmt.Fprintf(&b, "Never matched: %s\n\n", mdIDs(v.NeverMatched))
+	}
+
+	if len(v.Variants) > 0 {
+		b.WriteString("## Variant Split\n\n| Rule | ID | Variant | Requests |\n|---|---|---|---:|\n")
+		for _, r := range v.Variants {
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %d |\n", mdEscape(r.Name), r.RuleID, mdEscape(r.Variant), r.Count)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Mutations\n\n")
+	if len(v.Mutated) == 0 {
+		b.WriteString("_No requests or responses were modified._\n\n")
+	} else {
+		for _, r := range v.Mutated {
+			fmt.Fprintf(&b, "- %s (`%s`): %d\n", mdEscape(r.Name), r.RuleID, r.Effective)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Errors\n\n")
+	fmt.Fprintf(&b, "- Client errors (4xx): %d\n- Server errors (5xx): %d\n- Degraded results: %d\n", v.Client, v.Server, v.Degraded)
+	if len(v.AlwaysDegraded) > 0 {
+		fmt.Fprintf(&b, "- Always degraded: %s\n", mdIDs(v.AlwaysDegraded))
+	}
+	return []byte(b.String())
+}
+
+// passLabel 返回断言结果的文本
+func passLabel(passed bool) string {
+	if passed {
+		return "PASS"
+	}
+	return "FAIL"
+}
+
+// mdEscape 转义 Markdown 表格中的特殊字符
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "").Replace(s)
+}
+
+// mdIDs 将规则 ID 列表渲染为行内代码
+func mdIDs(ids []domain.RuleID) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = "`" + string(id) + "`"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatTime 将毫秒时间戳格式化为 RFC 3339，0 返回 "-"
+func formatTime(ms int64) string {
+	if ms == 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Format(time.RFC3339)
+}
+
+// formatBytes 以二进制单位格式化字节数
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// htmlTemplate HTML 报告模板，内容由 html/template 自动转义
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Session Report: {{.SessionID}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ddd; padding: 4px 10px; text-align: left; }
+td.num, th.num { text-align: right; }
+th { background: #f5f5f5; }
+code { background: #f0f0f0; padding: 0 3px; }
+.muted { color: #888; font-style: italic; }
+.pass { color: #1a7f37; }
+.fail { color: #cf222e; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Session Report: {{.SessionID}}</h1>
+<ul>
+<li>Started: {{.StartedAt}}</li>
+<li>Ended: {{.EndedAt}}</li>
+{{- if .Duration}}
+<li>Duration: {{.Duration}}</li>
+{{- end}}
+<li>Generated: {{.GeneratedAt}}</li>
+</ul>
+
+<h2>Overview</h2>
+<table>
+<tr><th class="num">Requests</th><th class="num">Blocked</th><th class="num">Mutations</th><th class="num">Uploaded</th><th class="num">Received</th></tr>
+<tr><td class="num">{{.Requests}}</td><td class="num">{{.Blocked}}</td><td class="num">{{.Mutations}}</td><td class="num">{{.Uploaded}}</td><td class="num">{{.Received}}</td></tr>
+</table>
+{{- with .Assertions}}
+
+<h2>Assertions: {{if .Passed}}PASS{{else}}FAIL{{end}}</h2>
+<table>
+<tr><th>Assertion</th><th class="num">Matched</th><th>Result</th></tr>
+{{- range .Results}}
+<tr><td><code>{{.Assertion}}</code></td><td class="num">{{.Matched}}</td><td class="{{if .Passed}}pass{{else}}fail{{end}}">{{if .Passed}}PASS{{else}}FAIL{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2>Top Domains</h2>
+{{- if .Domains}}
+<table>
+<tr><th>Domain</th><th class="num">Requests</th><th class="num">Blocked</th><th class="num">Bytes</th></tr>
+{{- range .Domains}}
+<tr><td>{{.Domain}}</td><td class="num">{{.Requests}}</td><td class="num">{{.Blocked}}</td><td class="num">{{.Bytes}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p class="muted">No traffic recorded.</p>
+{{- end}}
+
+<h2>Status Codes</h2>
+{{- if .Status}}
+<table>
+<tr><th>Status</th><th class="num">Responses</th></tr>
+{{- range .Status}}
+<tr><td>{{.Code}}</td><td class="num">{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p class="muted">No responses recorded.</p>
+{{- end}}
+
+<h2>Rules Fired</h2>
+{{- if .RulesFired}}
+<table>
+<tr><th>Rule</th><th>ID</th><th class="num">Matched</th><th class="num">Effective</th><th class="num">Degraded</th></tr>
+{{- range .RulesFired}}
+<tr><td>{{.Name}}</td><td><code>{{.RuleID}}</code></td><td class="num">{{.Matched}}</td><td class="num">{{.Effective}}</td><td class="num">{{.Degraded}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p class="muted">No rules matched.</p>
+{{- end}}
+{{- if .NeverMatched}}
+<p>Never matched: {{range $i, $id := .NeverMatched}}{{if $i}}, {{end}}<code>{{$id}}</code>{{end}}</p>
+{{- end}}
+{{- if .Variants}}
+
+<h2>Variant Split</h2>
+<table>
+<tr><th>Rule</th><th>ID</th><th>Variant</th><th class="num">Requests</th></tr>
+{{- range .Variants}}
+<tr><td>{{.Name}}</td><td><code>{{.RuleID}}</code></td><td>{{.Variant}}</td><td class="num">{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2>Mutations</h2>
+{{- if .Mutated}}
+<ul>
+{{- range .Mutated}}
+<li>{{.Name}} (<code>{{.RuleID}}</code>): {{.Effective}}</li>
+{{- end}}
+</ul>
+{{- else}}
+<p class="muted">No requests or responses were modified.</p>
+{{- end}}
+
+<h2>Errors</h2>
+<ul>
+<li>Client errors (4xx): {{.Client}}</li>
+<li>Server errors (5xx): {{.Server}}</li>
+<li>Degraded results: {{.Degraded}}</li>
+{{- if .AlwaysDegraded}}
+<li>Always degraded: {{range $i, $id := .AlwaysDegraded}}{{if $i}}, {{end}}<code>{{$id}}</code>{{end}}</li>
+{{- end}}
+</ul>
+</body>
+</html>
+`))
@@ -0,0 +1,135 @@
+package report_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"cdpnetool/internal/report"
+	"cdpnetool/pkg/domain"
+)
+
+func sampleSummary() domain.SessionSummary {
+	return domain.SessionSummary{
+		SessionID:   "sess_1",
+		StartedAt:   1700000000000,
+		EndedAt:     1700000090000,
+		GeneratedAt: 1700000090000,
+		Coverage: domain.CoverageReport{
+			Rules: []domain.RuleCoverage{
+				{RuleID: "r1", Name: "mock <api>", Matched: 3, Effective: 2},
+				{RuleID: "r2", Name: "a|b", Matched: 5, Effective: 5, Degraded: 1, Variants: map[string]int64{"canary": 1, "baseline": 4}},
+				{RuleID: "r3", Name: "unused"},
+			},
+			NeverMatched: []domain.RuleID{"r3"},
+		},
+		Traffic: domain.TrafficStats{
+			Total: domain.TrafficCounter{Requests: 8, Blocked: 1, RequestBytes: 512, ResponseBytes: 4096},
+			Domains: []domain.DomainTraffic{
+				{Domain: "api.example.com", Total: domain.TrafficCounter{Requests: 8, Blocked: 1, RequestBytes: 512, ResponseBytes: 4096}},
+			},
+			Status: map[int]int64{200: 5, 404: 1, 500: 2},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]domain.ReportFormat{"": domain.ReportFormatMarkdown, "md": domain.ReportFormatMarkdown, "HTML": domain.ReportFormatHTML} {
+		got, err := report.ParseFormat(in)
+		if err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := report.ParseFormat("pdf"); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestRender_Markdown(t *testing.T) {
+	out, err := report.Render(sampleSummary(), domain.ReportFormatMarkdown)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	md := string(out)
+	for _, want := range []string{
+		"# Session Report: sess_1",
+		"- Duration: 1m30s",
+		"| 8 | 1 | 7 | 512 B | 4.0 KiB |",
+		"| api.example.com | 8 | 1 | 4.5 KiB |",
+		"| 404 | 1 |",
+		"Never matched: `r3`",
+		"| a\\|b | `r2` | baseline | 4 |\n| a\\|b | `r2` | canary | 1 |",
+		"- Client errors (4xx): 1\n- Server errors (5xx): 2\n- Degraded results: 1",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	// 命中次数多的规则排在前面，表格中的竖线需转义
+	if strings.Index(md, `a\|b`) > strings.Index(md, "mock <api>") {
+		t.Errorf("rules not sorted by matched count:\n%s", md)
+	}
+}
+
+func TestRender_HTML(t *testing.T) {
+	out, err := report.Render(sampleSummary(), domain.ReportFormatHTML)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	html := string(out)
+	if !strings.Contains(html, "mock &lt;api&gt;") || strings.Contains(html, "mock <api>") {
+		t.Errorf("rule name not escaped:\n%s", html)
+	}
+	if !strings.Contains(html, `<td>500</td><td class="num">2</td>`) {
+		t.Errorf("status distribution missing:\n%s", html)
+	}
+	if !strings.Contains(html, `<td>canary</td><td class="num">1</td>`) {
+		t.Errorf("variant split missing:\n%s", html)
+	}
+}
+
+func TestRender_Empty(t *testing.T) {
+	out, err := report.Render(domain.SessionSummary{SessionID: "sess_2", GeneratedAt: 1700000000000}, domain.ReportFormatMarkdown)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	md := string(out)
+	for _, want := range []string{"- Ended: running", "_No traffic recorded._", "_No rules matched._"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestRender_Assertions(t *testing.T) {
+	zero := int64(0)
+	s := sampleSummary()
+	s.Assertions = &domain.AssertionReport{Results: []domain.AssertionResult{
+		{Assertion: domain.RuleAssertion{RuleID: "r1", Min: 1}, Matched: 3, Passed: true},
+		{Assertion: domain.RuleAssertion{RuleID: "r2", Max: &zero}, Matched: 5},
+	}}
+
+	out, err := report.Render(s, domain.ReportFormatMarkdown)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	md := string(out)
+	for _, want := range []string{"## Assertions: FAIL", "| `r1 >= 1` | 3 | PASS |", "| `r2 == 0` | 5 | FAIL |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	out, err = report.Render(s, domain.ReportFormatHTML)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if html := string(out); !strings.Contains(html, `<td><code>r2 == 0</code></td><td class="num">5</td><td class="fail">FAIL</td>`) {
+		t.Errorf("assertion result missing:\n%s", html)
+	}
+
+	out, _ = report.Render(sampleSummary(), domain.ReportFormatMarkdown)
+	if strings.Contains(string(out), "Assertions") {
+		t.Error("assertions section rendered without assertions")
+	}
+}
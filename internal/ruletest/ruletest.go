@@ -0,0 +1,221 @@
+// Package ruletest 将捕获的事件离线重放到候选规则配置，报告每个事件会命中哪些规则、
+// 产生什么修改，以及与捕获时的结果有何不同，用于在不连接浏览器的情况下对规则集做回归测试
+package ruletest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"cdpnetool/internal/auditor"
+	"cdpnetool/internal/engine"
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/processor"
+	"cdpnetool/internal/tracker"
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// MaxEvents 单次离线测试允许的最大事件数
+const MaxEvents = 100000
+
+// Run 以候选规则配置依次处理捕获的事件：请求与响应均取规则修改前的版本，经过与会话相同的
+// 规则引擎与处理器，得到候选规则下本会记录的事件。处理器不装配影子流量与落盘，
+// mirror、saveBody 动作不执行；augmentJson、mapLocal 等动作仍会读取其数据源
+func Run(ctx context.Context, cfg *rulespec.Config, opts domain.RuleTestOptions, l logger.Logger) (domain.RuleTestResult, error) {
+	if l == nil {
+		l = logger.NewNop()
+	}
+	if cfg == nil {
+		return domain.RuleTestResult{}, domain.ErrInvalidConfig
+	}
+	if err := engine.Validate(cfg); err != nil {
+		return domain.RuleTestResult{}, err
+	}
+
+	events := slices.Clone(opts.Events)
+	for _, req := range opts.Requests {
+		events = append(events, domain.NetworkEvent{ID: req.ID, Request: req})
+	}
+	if len(events) > MaxEvents {
+		return domain.RuleTestResult{}, fmt.Errorf("%w: events must not exceed %d", domain.ErrInvalidConfig, MaxEvents)
+	}
+
+	// 与会话相同的组件装配，全量流量审计器逐个收集处理结果
+	records := make(chan domain.NetworkEvent, 1)
+	trk := tracker.New(time.Minute, l)
+	defer trk.Stop()
+	proc := processor.New(trk, engine.New(cfg), auditor.NewDisabled(nil, l), auditor.New(records, l), l)
+
+	res := domain.RuleTestResult{
+		Rules: make(map[string]int),
+		Cases: make([]domain.RuleTestCase, 0, len(events)),
+	}
+	for i, evt := range events {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		c := evaluate(ctx, proc, trk, records, evt, i)
+		res.Events++
+		if c.Event.IsMatched {
+			res.Matched++
+		}
+		if c.Event.FinalResult == "modified" || c.Event.FinalResult == "blocked" {
+			res.Modified++
+		}
+		if c.Changed {
+			res.Changed++
+		}
+		for _, m := range c.Event.MatchedRules {
+			res.Rules[m.RuleID]++
+		}
+		res.Cases = append(res.Cases, c)
+	}
+
+	l.Info("规则离线测试完成", "events", res.Events, "matched", res.Matched, "modified", res.Modified, "changed", res.Changed)
+	return res, nil
+}
+
+// evaluate 处理单个事件的请求与响应阶段，并与捕获时的结果比较
+func evaluate(ctx context.Context, proc *processor.Processor, trk *tracker.Tracker, records chan domain.NetworkEvent, evt domain.NetworkEvent, i int) domain.RuleTestCase {
+	req := inputRequest(evt)
+	if req.ID == "" {
+		req.ID = fmt.Sprintf("ruletest-%d", i)
+	}
+	c := domain.RuleTestCase{EventID: evt.ID, CapturedResult: evt.FinalResult}
+	if c.EventID == "" {
+		c.EventID = req.ID
+	}
+	for _, m := range evt.MatchedRules {
+		c.CapturedRules = append(c.CapturedRules, m.RuleID)
+	}
+
+	session, target := string(evt.Session), string(evt.Target)
+	result := proc.ProcessRequest(ctx, session, target, req)
+	if result.Action != processor.ActionBlock && result.Action != processor.ActionFail && !result.WebSocket {
+		if res := inputResponse(evt); res != nil {
+			proc.ProcessResponse(ctx, session, target, req.ID, res)
+		} else if v, ok := trk.Get(req.ID); ok {
+			c.ResponseSkipped = true
+			records <- requestOnly(session, target, v.(*processor.PendingState))
+		}
+	}
+	select {
+	case c.Event = <-records:
+	default:
+	}
+	if evt.Timestamp != 0 {
+		c.Event.Timestamp = evt.Timestamp
+	}
+
+	c.Changed = changed(c, evt)
+	return c
+}
+
+// inputRequest 取事件中规则修改前的请求，重新解析查询参数与 Cookie；
+// 修改前的快照只包含 URL、方法、头部与消息体，资源类型沿用最终请求
+func inputRequest(evt domain.NetworkEvent) *domain.Request {
+	src := &evt.Request
+	if evt.OriginalRequest != nil {
+		src = evt.OriginalRequest
+	}
+
+	req := domain.NewRequest()
+	req.ID = evt.Request.ID
+	if req.ID == "" {
+		req.ID = evt.ID
+	}
+	req.URL = src.URL
+	req.Method = src.Method
+	req.ResourceType = evt.Request.ResourceType
+	maps.Copy(req.Headers, src.Headers)
+	req.Body = bytes.Clone(src.Body)
+	if _, query, ok := strings.Cut(req.URL, "?"); ok && query != "" {
+		for _, pair := range strings.Split(query, "&") {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				req.Query[k] = v
+			}
+		}
+	}
+	req.Cookies = transformer.ParseCookies(req.Headers.Get("Cookie"))
+	return req
+}
+
+// inputResponse 取事件中规则修改前的响应；捕获时被拦截的事件只有伪造的响应，
+// 仅在响应阶段以网络错误结束时原始响应中保留了服务端返回的内容，其余返回 nil
+func inputResponse(evt domain.NetworkEvent) *domain.Response {
+	src := evt.OriginalResponse
+	if src == nil && evt.FinalResult != "blocked" {
+		src = evt.Response
+	}
+	if src == nil {
+		return nil
+	}
+
+	res := domain.NewResponse()
+	res.StatusCode = src.StatusCode
+	maps.Copy(res.Headers, src.Headers)
+	res.Body = bytes.Clone(src.Body)
+	res.Timing = src.Timing
+	return res
+}
+
+// requestOnly 以请求阶段的处理结果构造事件，用于没有响应可评估的事件
+func requestOnly(session, target string, state *processor.PendingState) domain.NetworkEvent {
+	result := "passed"
+	if state.IsMatched() {
+		result = "matched"
+	}
+	if state.IsModified {
+		result = "modified"
+	}
+
+	matches := make([]domain.RuleMatch, len(state.MatchedRules))
+	for i, m := range state.MatchedRules {
+		actions := make([]string, len(m.Rule.Actions))
+		for j, action := range m.Rule.Actions {
+			actions[j] = string(action.Type)
+		}
+		matches[i] = domain.RuleMatch{RuleID: m.Rule.ID, RuleName: m.Rule.Name, Actions: actions}
+	}
+
+	return domain.NetworkEvent{
+		ID:              state.Request.ID,
+		Session:         domain.SessionID(session),
+		Target:          domain.TargetID(target),
+		Timestamp:       time.Now().UnixMilli(),
+		IsMatched:       len(matches) > 0,
+		FinalResult:     result,
+		MatchedRules:    matches,
+		Request:         *state.Request,
+		OriginalRequest: state.Original,
+	}
+}
+
+// changed 判断候选规则下的结果是否与捕获时不同；请求快照没有捕获结果，不做比较。
+// 契约违规与敏感信息检测依赖会话设置，捕获时为这两种结果时只比较命中的规则
+func changed(c domain.RuleTestCase, evt domain.NetworkEvent) bool {
+	if evt.FinalResult == "" {
+		return false
+	}
+	got := make([]string, 0, len(c.Event.MatchedRules))
+	for _, m := range c.Event.MatchedRules {
+		got = append(got, m.RuleID)
+	}
+	want := slices.Clone(c.CapturedRules)
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		return true
+	}
+	switch evt.FinalResult {
+	case "schema-violation", "secret-detected":
+		return false
+	}
+	return c.Event.FinalResult != evt.FinalResult
+}
@@ -0,0 +1,154 @@
+package ruletest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cdpnetool/internal/ruletest"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// candidate 拦截广告请求并改写 API 响应状态码的候选规则
+func candidate() *rulespec.Config {
+	cfg := rulespec.NewConfig("candidate")
+	cfg.Rules = []rulespec.Rule{
+		{
+			ID: "block-ads", Name: "block ads", Enabled: true, Stage: rulespec.StageRequest,
+			Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/ads"}}},
+			Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+		},
+		{
+			ID: "api-status", Name: "api status", Enabled: true, Stage: rulespec.StageResponse,
+			Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLPrefix, Value: "https://example.com/api/"}}},
+			Actions: []rulespec.Action{{Type: rulespec.ActionSetStatus, Value: 503}},
+		},
+	}
+	return cfg
+}
+
+func captured(id, url, result string, status int, rules ...string) domain.NetworkEvent {
+	evt := domain.NetworkEvent{
+		ID:          id,
+		Session:     "s1",
+		Timestamp:   1700000000000,
+		FinalResult: result,
+		Request:     domain.Request{ID: id, URL: url, Method: "GET", Headers: domain.Header{"Accept": "*/*"}},
+		Response:    &domain.Response{StatusCode: status, Headers: domain.Header{"Content-Type": "application/json"}, Body: []byte(`{}`)},
+	}
+	for _, r := range rules {
+		evt.IsMatched = true
+		evt.MatchedRules = append(evt.MatchedRules, domain.RuleMatch{RuleID: r})
+	}
+	return evt
+}
+
+func TestRun(t *testing.T) {
+	// 捕获时 API 响应已被旧规则改为 500，离线测试应以修改前的 200 作为输入
+	api := captured("2", "https://example.com/api/users", "modified", 500, "old-status")
+	api.OriginalResponse = &domain.Response{StatusCode: 200, Headers: domain.Header{"Content-Type": "application/json"}, Body: []byte(`{}`)}
+
+	opts := domain.RuleTestOptions{
+		Events: []domain.NetworkEvent{
+			captured("1", "https://example.com/ads/banner.js", "blocked", 403, "block-ads"),
+			api,
+			captured("3", "https://example.com/index.html", "passed", 200),
+		},
+		Requests: []domain.Request{{ID: "4", URL: "https://example.com/ads/pixel.gif", Method: "GET"}},
+	}
+
+	res, err := ruletest.Run(context.Background(), candidate(), opts, nil)
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if res.Events != 4 || res.Matched != 3 || res.Modified != 3 || res.Changed != 1 {
+		t.Fatalf("got events=%d matched=%d modified=%d changed=%d, want 4 3 3 1", res.Events, res.Matched, res.Modified, res.Changed)
+	}
+	if res.Rules["block-ads"] != 2 || res.Rules["api-status"] != 1 {
+		t.Errorf("unexpected rule counts: %v", res.Rules)
+	}
+
+	byID := make(map[string]domain.RuleTestCase)
+	for _, c := range res.Cases {
+		byID[c.EventID] = c
+	}
+
+	if c := byID["1"]; c.Changed || c.Event.FinalResult != "blocked" || c.Event.Response == nil || c.Event.Response.StatusCode != 403 {
+		t.Errorf("ads event: %+v", c)
+	}
+
+	c := byID["2"]
+	if !c.Changed || c.Event.FinalResult != "modified" || c.Event.Response.StatusCode != 503 {
+		t.Errorf("api event: changed=%v result=%q response=%+v", c.Changed, c.Event.FinalResult, c.Event.Response)
+	}
+	if c.Event.OriginalResponse == nil || c.Event.OriginalResponse.StatusCode != 200 {
+		t.Errorf("api event original response = %+v, want status 200", c.Event.OriginalResponse)
+	}
+	if c.Event.Timestamp != 1700000000000 || c.Event.Session != "s1" {
+		t.Errorf("api event should keep captured timestamp and session, got %d %q", c.Event.Timestamp, c.Event.Session)
+	}
+
+	if c := byID["3"]; c.Changed || c.Event.FinalResult != "passed" || c.Event.IsMatched {
+		t.Errorf("page event: %+v", c)
+	}
+
+	// 请求快照没有捕获结果，不参与比较
+	if c := byID["4"]; c.Changed || c.Event.FinalResult != "blocked" {
+		t.Errorf("request snapshot: %+v", c)
+	}
+
+	// 输入事件不被修改
+	if api.Response.StatusCode != 500 || api.OriginalResponse.StatusCode != 200 {
+		t.Errorf("captured event was modified: %+v %+v", api.Response, api.OriginalResponse)
+	}
+}
+
+func TestRun_ResponseSkipped(t *testing.T) {
+	cfg := candidate()
+	cfg.Rules = append(cfg.Rules, rulespec.Rule{
+		ID: "tag", Name: "tag", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLPrefix, Value: "https://example.com/api/"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionSetHeader, Name: "X-Test", Value: "1"}},
+	})
+
+	// 捕获时被拦截的事件只有伪造的响应，新规则放行后不评估响应阶段
+	evt := captured("1", "https://example.com/api/feed", "blocked", 403, "old-block")
+	res, err := ruletest.Run(context.Background(), cfg, domain.RuleTestOptions{Events: []domain.NetworkEvent{evt}}, nil)
+	if err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	c := res.Cases[0]
+	if !c.ResponseSkipped || !c.Changed || c.Event.FinalResult != "modified" || c.Event.Response != nil {
+		t.Fatalf("unexpected case: %+v", c)
+	}
+	if len(c.Event.MatchedRules) != 1 || c.Event.MatchedRules[0].RuleID != "tag" {
+		t.Errorf("matched rules = %+v, want only tag", c.Event.MatchedRules)
+	}
+	if c.Event.Request.Headers.Get("X-Test") != "1" || c.Event.OriginalRequest == nil {
+		t.Errorf("request mutation not reported: %+v", c.Event)
+	}
+}
+
+func TestRun_Invalid(t *testing.T) {
+	if _, err := ruletest.Run(context.Background(), nil, domain.RuleTestOptions{}, nil); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("nil config: got %v, want ErrInvalidConfig", err)
+	}
+
+	cfg := rulespec.NewConfig("bad")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "bad", Name: "bad", Enabled: true, Stage: rulespec.StageRequest,
+		Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLRegex, Pattern: "("}}},
+	}}
+	var ruleErr *domain.RuleError
+	if _, err := ruletest.Run(context.Background(), cfg, domain.RuleTestOptions{}, nil); !errors.As(err, &ruleErr) {
+		t.Errorf("invalid regex: got %v, want *domain.RuleError", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := domain.RuleTestOptions{Requests: []domain.Request{{ID: "1", URL: "https://example.com/", Method: "GET"}}}
+	if _, err := ruletest.Run(ctx, candidate(), opts, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled: got %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,177 @@
+// Package saver 将匹配请求的最终响应体按文件名模板写入磁盘
+package saver
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"cdpnetool/internal/logger"
+	"cdpnetool/pkg/domain"
+)
+
+// DefaultFilename 未指定文件名模板时使用的模板
+const DefaultFilename = "{host}/{ts}-{name}{ext}"
+
+// maxSegment 文件名中单个路径段的最大长度
+const maxSegment = 120
+
+// Saver 响应体落盘器，并发安全
+type Saver struct {
+	log logger.Logger
+	now func() time.Time
+}
+
+// New 创建响应体落盘器
+func New(l logger.Logger) *Saver {
+	if l == nil {
+		l = logger.NewNop()
+	}
+	return &Saver{log: l, now: time.Now}
+}
+
+// Vars 文件名模板可用的变量
+type Vars struct {
+	RequestID string
+	RuleID    string
+	Method    string
+	URL       string
+	Status    int
+	Headers   domain.Header // 响应头，用于推断扩展名
+}
+
+// Save 将响应体写入 dir 下按模板 tmpl 生成的文件，同名文件存在时追加序号，返回写入的文件路径
+func (s *Saver) Save(dir, tmpl string, vars Vars, body []byte) (string, error) {
+	if strings.TrimSpace(dir) == "" {
+		return "", errors.New("empty directory")
+	}
+	name := Filename(tmpl, vars, s.now())
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(target)
+	base := strings.TrimSuffix(target, ext)
+	for n := 1; ; n++ {
+		p := target
+		if n > 1 {
+			p = fmt.Sprintf("%s-%d%s", base, n, ext)
+		}
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		_, err = f.Write(body)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return "", err
+		}
+		s.log.Debug("响应体已保存", "requestID", vars.RequestID, "path", p, "size", len(body))
+		return p, nil
+	}
+}
+
+// Filename 展开文件名模板，返回以 / 分隔的相对路径。支持的变量：
+// {host} 主机名、{path} URL 路径、{name} 路径最后一段（不含扩展名）、{ext} 扩展名（含点，优先取 URL 后缀，否则按 Content-Type 推断）、
+// {method} 请求方法、{status} 状态码、{id} 请求 ID、{rule} 规则 ID、{ts} 毫秒时间戳、{date} 日期 YYYYMMDD
+func Filename(tmpl string, vars Vars, now time.Time) string {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = DefaultFilename
+	}
+	if name := Expand(tmpl, vars, now); name != "" {
+		return name
+	}
+	return "response"
+}
+
+// Expand 按 Filename 的变量展开模板并逐段清理，返回以 / 分隔的相对路径，结果不含 . 与 .. 段，全部段为空时返回空串
+func Expand(tmpl string, vars Vars, now time.Time) string {
+	var host, urlPath string
+	if u, err := url.Parse(vars.URL); err == nil {
+		host = u.Hostname()
+		urlPath = u.Path
+	}
+	ext := path.Ext(urlPath)
+	name := strings.TrimSuffix(path.Base(urlPath), ext)
+	if name == "" || name == "/" || name == "." {
+		name = "index"
+	}
+	if ext == "" {
+		ext = extByType(vars.Headers)
+	}
+
+	r := strings.NewReplacer(
+		"{host}", host,
+		"{path}", strings.Trim(urlPath, "/"),
+		"{name}", name,
+		"{ext}", ext,
+		"{method}", vars.Method,
+		"{status}", strconv.Itoa(vars.Status),
+		"{id}", vars.RequestID,
+		"{rule}", vars.RuleID,
+		"{ts}", strconv.FormatInt(now.UnixMilli(), 10),
+		"{date}", now.Format("20060102"),
+	)
+
+	// 逐段清理，防止通过 .. 或绝对路径写出目标目录
+	var segments []string
+	for _, seg := range strings.Split(r.Replace(tmpl), "/") {
+		if seg = sanitize(seg); seg != "" {
+			segments = append(segments, seg)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// sanitize 替换文件名中的非法字符，丢弃 . 与 .. 段
+func sanitize(seg string) string {
+	seg = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(seg))
+	if seg == "." || seg == ".." {
+		return ""
+	}
+	if len(seg) > maxSegment {
+		seg = seg[:maxSegment]
+	}
+	return seg
+}
+
+// extByType 根据 Content-Type 推断扩展名，无法推断时返回 .bin
+func extByType(h domain.Header) string {
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return ".bin"
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return ".json"
+	case mediaType == "text/html":
+		return ".html"
+	case mediaType == "text/plain":
+		return ".txt"
+	case mediaType == "application/javascript" || mediaType == "text/javascript":
+		return ".js"
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
@@ -0,0 +1,79 @@
+package saver_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/saver"
+	"cdpnetool/pkg/domain"
+)
+
+func TestFilename(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	vars := saver.Vars{
+		RequestID: "42.1",
+		RuleID:    "r1",
+		Method:    "GET",
+		URL:       "https://api.example.com:8443/v1/users/list.json?page=2",
+		Status:    200,
+	}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"", "api.example.com/1700000000123-list.json"},
+		{"{path}", "v1/users/list.json"},
+		{"{method}_{status}_{id}_{rule}{ext}", "GET_200_42.1_r1.json"},
+		{"../../{name}", "list"},
+		{"/etc/{host}:{name}", "etc/api.example.com_list"},
+		{"///", "response"},
+	}
+	for _, tt := range tests {
+		if got := saver.Filename(tt.tmpl, vars, now); got != tt.want {
+			t.Errorf("Filename(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestFilename_ExtFromContentType(t *testing.T) {
+	vars := saver.Vars{URL: "https://example.com/", Headers: domain.Header{"content-type": "application/json; charset=utf-8"}}
+	if got := saver.Filename("{name}{ext}", vars, time.Now()); got != "index.json" {
+		t.Errorf("got %q, want index.json", got)
+	}
+	vars.Headers = nil
+	if got := saver.Filename("{name}{ext}", vars, time.Now()); got != "index.bin" {
+		t.Errorf("got %q, want index.bin", got)
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	s := saver.New(logger.NewNop())
+	vars := saver.Vars{URL: "https://example.com/a/b.txt"}
+
+	first, err := s.Save(dir, "{host}/{name}{ext}", vars, []byte("one"))
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	second, err := s.Save(dir, "{host}/{name}{ext}", vars, []byte("two"))
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if want := filepath.Join(dir, "example.com", "b.txt"); first != want {
+		t.Errorf("first path = %q, want %q", first, want)
+	}
+	if want := filepath.Join(dir, "example.com", "b-2.txt"); second != want {
+		t.Errorf("second path = %q, want %q", second, want)
+	}
+	if data, _ := os.ReadFile(first); string(data) != "one" {
+		t.Errorf("first file = %q, want one", data)
+	}
+
+	if _, err := s.Save("", "", vars, nil); err == nil {
+		t.Error("expected error for empty directory")
+	}
+}
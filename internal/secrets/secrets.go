@@ -0,0 +1,312 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"cdpnetool/pkg/domain"
+)
+
+// 内置检测器名称
+const (
+	DetectorAWSKey     = "awsKey"     // AWS 访问密钥 ID 与 secret access key
+	DetectorJWT        = "jwt"        // JSON Web Token
+	DetectorAPIKey     = "apiKey"     // 常见服务商的 API 密钥（Stripe、Google、GitHub、Slack、OpenAI 等）
+	DetectorCreditCard = "creditCard" // 通过 Luhn 校验的银行卡号
+)
+
+const (
+	maxScanBytes = 1 << 20 // 单个消息体最多扫描的字节数
+	maxFindings  = 20      // 单个请求或响应最多记录的发现数
+)
+
+// detector 单个检测器，pattern 含捕获组时以第一个捕获组为匹配内容
+type detector struct {
+	name    string
+	pattern *regexp.Regexp
+	verify  func(match string) bool // 进一步校验以降低误报，为 nil 时不校验
+	redact  func(match string) string
+}
+
+var detectors = []detector{
+	{
+		name:    DetectorAWSKey,
+		pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA|A3T[A-Z0-9])[A-Z0-9]{16}\b`),
+		redact:  redact,
+	},
+	{
+		name:    DetectorAWSKey,
+		pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})\b`),
+		redact:  redact,
+	},
+	{
+		name:    DetectorJWT,
+		pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]*`),
+		verify:  isJWT,
+		redact:  redact,
+	},
+	{
+		name: DetectorAPIKey,
+		pattern: regexp.MustCompile(`\b(?:` +
+			`(?:sk|rk|pk)_(?:live|test)_[0-9A-Za-z]{16,}` + // Stripe
+			`|AIza[0-9A-Za-z_-]{35}` + // Google
+			`|gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,}` + // GitHub
+			`|xox[abposr]-[A-Za-z0-9-]{10,}` + // Slack
+			`|sk-(?:proj-)?[A-Za-z0-9_-]{32,}` + // OpenAI
+			`|glpat-[A-Za-z0-9_-]{20}` + // GitLab
+			`)`),
+		redact: redact,
+	},
+	{
+		name:    DetectorCreditCard,
+		pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		verify:  isCardNumber,
+		redact:  redactCard,
+	},
+}
+
+// Detectors 返回所有内置检测器名称
+func Detectors() []string {
+	var names []string
+	for _, d := range detectors {
+		if !slices.Contains(names, d.name) {
+			names = append(names, d.name)
+		}
+	}
+	return names
+}
+
+// Scanner 按启用的检测器扫描请求与响应中的敏感信息，nil 扫描器不做任何检测
+type Scanner struct {
+	names     []string
+	detectors []detector
+}
+
+// New 创建启用指定检测器的扫描器，names 为空时返回 nil 表示不检测
+func New(names []string) (*Scanner, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	s := &Scanner{}
+	known := Detectors()
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("unknown secret detector %q, available: %s", name, strings.Join(known, ", "))
+		}
+		if !slices.Contains(s.names, name) {
+			s.names = append(s.names, name)
+		}
+	}
+	for _, d := range detectors {
+		if slices.Contains(s.names, d.name) {
+			s.detectors = append(s.detectors, d)
+		}
+	}
+	return s, nil
+}
+
+// Names 返回启用的检测器名称
+func (s *Scanner) Names() []string {
+	if s == nil {
+		return nil
+	}
+	return slices.Clone(s.names)
+}
+
+// ScanRequest 扫描请求 URL、请求头与请求体，有解码结果时扫描解码后的 JSON
+func (s *Scanner) ScanRequest(req *domain.Request) []domain.SecretFinding {
+	if s == nil {
+		return nil
+	}
+	f := &findings{}
+	s.scan(f, req.URL, domain.SecretInURL, "")
+	s.scanHeaders(f, req.Headers, domain.SecretInRequestHeader)
+	s.scanBody(f, req.Body, req.Decoded, req.Headers, domain.SecretInRequestBody)
+	return f.list
+}
+
+// ScanResponse 扫描响应头与响应体，有解码结果时扫描解码后的 JSON
+func (s *Scanner) ScanResponse(res *domain.Response) []domain.SecretFinding {
+	if s == nil {
+		return nil
+	}
+	f := &findings{}
+	s.scanHeaders(f, res.Headers, domain.SecretInResponseHeader)
+	s.scanBody(f, res.Body, res.Decoded, res.Headers, domain.SecretInResponseBody)
+	return f.list
+}
+
+// scanHeaders 按名称顺序扫描头部值
+func (s *Scanner) scanHeaders(f *findings, h domain.Header, loc domain.SecretLocation) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.scan(f, h[name], loc, name)
+	}
+}
+
+// scanBody 扫描消息体，跳过图片、音视频、字体等二进制内容，超出上限的部分不扫描
+func (s *Scanner) scanBody(f *findings, body []byte, decoded string, h domain.Header, loc domain.SecretLocation) {
+	text := decoded
+	if text == "" {
+		if len(body) == 0 || isBinaryMedia(h) {
+			return
+		}
+		if len(body) > maxScanBytes {
+			body = body[:maxScanBytes]
+		}
+		text = string(body)
+	}
+	s.scan(f, text, loc, "")
+}
+
+// scan 以所有启用的检测器扫描文本
+func (s *Scanner) scan(f *findings, text string, loc domain.SecretLocation, field string) {
+	if text == "" {
+		return
+	}
+	for _, d := range s.detectors {
+		for _, m := range d.pattern.FindAllStringSubmatchIndex(text, -1) {
+			if f.full() {
+				return
+			}
+			start, end := m[0], m[1]
+			if len(m) > 2 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			match := text[start:end]
+			if d.verify != nil && !d.verify(match) {
+				continue
+			}
+			f.add(d.name, match, domain.SecretFinding{
+				Detector: d.name,
+				Location: loc,
+				Field:    field,
+				Offset:   start,
+				Match:    d.redact(match),
+			})
+		}
+	}
+}
+
+// findings 单个请求或响应的发现列表，同一内容只记录首次出现的位置
+type findings struct {
+	list []domain.SecretFinding
+	seen map[string]bool
+}
+
+func (f *findings) full() bool {
+	return len(f.list) >= maxFindings
+}
+
+func (f *findings) add(detector, match string, finding domain.SecretFinding) {
+	key := detector + "\x00" + match
+	if f.seen[key] {
+		return
+	}
+	if f.seen == nil {
+		f.seen = make(map[string]bool)
+	}
+	f.seen[key] = true
+	f.list = append(f.list, finding)
+}
+
+// isJWT 校验 JWT 头部是否为包含 alg 字段的 JSON 对象
+func isJWT(match string) bool {
+	header, _, _ := strings.Cut(match, ".")
+	data, err := base64.RawURLEncoding.DecodeString(header)
+	if err != nil {
+		return false
+	}
+	var v struct {
+		Alg *string `json:"alg"`
+	}
+	return json.Unmarshal(data, &v) == nil && v.Alg != nil
+}
+
+// isCardNumber 校验卡号长度、发卡机构号段与 Luhn 校验位
+func isCardNumber(match string) bool {
+	digits := cardDigits(match)
+	n := len(digits)
+	if n < 13 || n > 19 || !hasCardPrefix(digits) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < n; i++ {
+		d := int(digits[n-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// hasCardPrefix 判断是否为 Visa、Mastercard、American Express、Discover、JCB 或银联号段
+func hasCardPrefix(digits string) bool {
+	prefix := func(n int) int {
+		v := 0
+		for _, c := range digits[:n] {
+			v = v*10 + int(c-'0')
+		}
+		return v
+	}
+	n := len(digits)
+	switch {
+	case digits[0] == '4':
+		return n == 13 || n == 16 || n == 19
+	case prefix(2) >= 51 && prefix(2) <= 55, prefix(4) >= 2221 && prefix(4) <= 2720:
+		return n == 16
+	case prefix(2) == 34 || prefix(2) == 37:
+		return n == 15
+	case prefix(4) == 6011, prefix(2) == 65, prefix(3) >= 644 && prefix(3) <= 649:
+		return n >= 16
+	case prefix(4) >= 3528 && prefix(4) <= 3589:
+		return n >= 16
+	case prefix(2) == 62:
+		return n >= 16
+	default:
+		return false
+	}
+}
+
+// cardDigits 去除卡号中的空格与连字符
+func cardDigits(match string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(match)
+}
+
+// redact 仅保留首尾各 4 个字符
+func redact(match string) string {
+	if len(match) <= 12 {
+		return "****"
+	}
+	return match[:4] + "****" + match[len(match)-4:]
+}
+
+// redactCard 仅保留卡号末 4 位
+func redactCard(match string) string {
+	digits := cardDigits(match)
+	return "****" + digits[len(digits)-4:]
+}
+
+// isBinaryMedia 判断 Content-Type 是否为不含文本的二进制媒体
+func isBinaryMedia(h domain.Header) bool {
+	ct := strings.ToLower(h.Get("Content-Type"))
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/", "application/octet-stream", "application/zip", "application/pdf"} {
+		if strings.HasPrefix(ct, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cdpnetool/internal/adapter/cdp"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+)
+
+// pendingChallenge 等待人工处理的认证质询及应答所需的上下文
+type pendingChallenge struct {
+	info  domain.AuthChallenge
+	ts    *cdp.TargetSession
+	spec  rulespec.AuthSpec // 命中规则的应答参数，人工以凭据应答但未填写用户名时使用其中的凭据
+	timer *time.Timer       // 超时交由浏览器处理的定时器
+}
+
+// handleAuth 应答目标的认证质询：会话设置了代理凭据时以其应答代理质询，其余质询按第一条匹配且应答该来源的 auth 规则处理。
+// 拦截未开启、没有匹配的规则、演练模式或只读会话时交由浏览器默认处理（弹出登录对话框或显示 401/407 页面）
+func (o *Orchestrator) handleAuth(state *sessionState, ts *cdp.TargetSession, ev *fetch.AuthRequiredReply) {
+	source := domain.AuthSourceServer
+	if ev.AuthChallenge.Source != nil && *ev.AuthChallenge.Source == "Proxy" {
+		source = domain.AuthSourceProxy
+	}
+	if source == domain.AuthSourceProxy {
+		if creds := state.proxyCredentials(); creds != nil {
+			o.provideCredentials(state, ts, ev, creds)
+			return
+		}
+	}
+
+	ruleID, spec := o.authRule(state, ev, source)
+	if spec == nil {
+		o.answerAuth(state, ts, ev.RequestID, domain.AuthDefault, nil)
+		return
+	}
+	if state.isDryRun() {
+		o.log.Info("演练模式，认证质询交由浏览器处理", "sessionID", string(state.id), "ruleID", ruleID, "url", ev.Request.URL)
+		o.answerAuth(state, ts, ev.RequestID, domain.AuthDefault, nil)
+		return
+	}
+	state.engine.RecordEffect(ruleID)
+
+	switch spec.GetMode() {
+	case rulespec.AuthModeCancel:
+		o.log.Info("按规则取消认证", "sessionID", string(state.id), "ruleID", ruleID, "url", ev.Request.URL)
+		o.answerAuth(state, ts, ev.RequestID, domain.AuthCancel, nil)
+	case rulespec.AuthModeApprove:
+		o.holdChallenge(state, ts, ev, source, ruleID, spec)
+	default:
+		username, password, err := spec.Credentials()
+		if err != nil {
+			o.log.Err(err, "读取认证凭据失败，交由浏览器处理", "sessionID", string(state.id), "ruleID", ruleID)
+			o.answerAuth(state, ts, ev.RequestID, domain.AuthDefault, nil)
+			return
+		}
+		o.provideCredentials(state, ts, ev, &domain.ProxyCredentials{Username: username, Password: password})
+	}
+}
+
+// authRule 返回第一条匹配发起质询的请求、且应答该来源的已启用 auth 规则；拦截未开启或只读会话时不应答。
+// 认证质询不经过放行流程，查找时不受终止规则截止，更高优先级的终止规则不会遮蔽 auth 规则
+func (o *Orchestrator) authRule(state *sessionState, ev *fetch.AuthRequiredReply, source domain.AuthSource) (string, *rulespec.AuthSpec) {
+	state.mu.Lock()
+	active := state.interceptionEnabled && state.authRules && !state.cfg.ReadOnly
+	state.mu.Unlock()
+	if !active {
+		return "", nil
+	}
+
+	req := cdp.ToNeutralRequest(&fetch.RequestPausedReply{RequestID: ev.RequestID, Request: ev.Request, ResourceType: ev.ResourceType})
+	for _, mr := range state.engine.EvalAll(req, rulespec.StageRequest) {
+		for _, a := range mr.Rule.Actions {
+			if a.Type == rulespec.ActionAuth && a.Auth != nil && a.Auth.Answers(source) {
+				return mr.Rule.ID, a.Auth
+			}
+		}
+	}
+	return "", nil
+}
+
+// provideCredentials 以凭据应答质询；同一请求再次质询说明凭据被拒绝，改为取消认证，避免循环
+func (o *Orchestrator) provideCredentials(state *sessionState, ts *cdp.TargetSession, ev *fetch.AuthRequiredReply, creds *domain.ProxyCredentials) {
+	state.mu.Lock()
+	_, rejected := state.authAttempts[ev.RequestID]
+	if rejected {
+		delete(state.authAttempts, ev.RequestID)
+	} else {
+		// 请求再次暂停时即删除记录，超出上限时只淘汰最早的一条，不影响正在认证的请求
+		if len(state.authAttempts) >= maxAuthAttempts {
+			evictOldestAuthAttempt(state.authAttempts)
+		}
+		state.authAttempts[ev.RequestID] = time.Now()
+	}
+	state.mu.Unlock()
+
+	if rejected {
+		o.log.Warn("认证凭据被拒绝，取消认证", "sessionID", string(state.id), "origin", ev.AuthChallenge.Origin)
+		o.answerAuth(state, ts, ev.RequestID, domain.AuthCancel, nil)
+		return
+	}
+	o.answerAuth(state, ts, ev.RequestID, domain.AuthProvide, creds)
+}
+
+// evictOldestAuthAttempt 删除最早提供凭据的记录
+func evictOldestAuthAttempt(attempts map[fetch.RequestID]time.Time) {
+	var oldest fetch.RequestID
+	var at time.Time
+	for id, t := range attempts {
+		if at.IsZero() || t.Before(at) {
+			oldest, at = id, t
+		}
+	}
+	delete(attempts, oldest)
+}
+
+// finishAuthAttempt 请求在认证后再次暂停，说明凭据已被接受，删除其记录
+func (s *sessionState) finishAuthAttempt(id fetch.RequestID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.authAttempts, id)
+}
+
+// continueAuth 应答认证质询
+func (o *Orchestrator) continueAuth(state *sessionState, ts *cdp.TargetSession, id fetch.RequestID, response domain.AuthResponse, creds *domain.ProxyCredentials) error {
+	return state.interceptor.ContinueWithAuth(state.ctx, ts.Client, id, response, creds)
+}
+
+// answerAuth 应答认证质询并记录失败：应答失败时请求会一直暂停在浏览器中
+func (o *Orchestrator) answerAuth(state *sessionState, ts *cdp.TargetSession, id fetch.RequestID, response domain.AuthResponse, creds *domain.ProxyCredentials) {
+	if err := o.continueAuth(state, ts, id, response, creds); err != nil {
+		o.log.Err(err, "应答认证质询失败，请求仍暂停在浏览器中", "sessionID", string(state.id), "requestID", id, "response", string(response))
+	}
+}
+
+// holdChallenge 暂停认证质询等待人工处理，超时后交由浏览器处理
+func (o *Orchestrator) holdChallenge(state *sessionState, ts *cdp.TargetSession, ev *fetch.AuthRequiredReply, source domain.AuthSource, ruleID string, spec *rulespec.AuthSpec) {
+	timeout := spec.Timeout()
+	now := time.Now()
+	c := &pendingChallenge{
+		info: domain.AuthChallenge{
+			ID:           string(ev.RequestID),
+			TargetID:     ts.ID,
+			URL:          ev.Request.URL,
+			Method:       ev.Request.Method,
+			Source:       source,
+			Origin:       ev.AuthChallenge.Origin,
+			Scheme:       ev.AuthChallenge.Scheme,
+			Realm:        ev.AuthChallenge.Realm,
+			RuleID:       ruleID,
+			ChallengedAt: now.UnixMilli(),
+			ExpiresAt:    now.Add(timeout).UnixMilli(),
+		},
+		ts:   ts,
+		spec: *spec,
+	}
+
+	state.mu.Lock()
+	state.challenges[ev.RequestID] = c
+	c.timer = time.AfterFunc(timeout, func() {
+		o.expireChallenge(state, ev.RequestID)
+	})
+	o.notifyChallengesLocked(state)
+	state.mu.Unlock()
+	o.log.Info("认证质询等待人工处理", "sessionID", string(state.id), "requestID", ev.RequestID, "origin", c.info.Origin, "timeout", timeout)
+}
+
+// expireChallenge 认证质询等待人工处理超时，交由浏览器处理
+func (o *Orchestrator) expireChallenge(state *sessionState, requestID fetch.RequestID) {
+	if state.ctx.Err() != nil {
+		return
+	}
+	state.mu.Lock()
+	c, ok := state.challenges[requestID]
+	if ok {
+		delete(state.challenges, requestID)
+		o.notifyChallengesLocked(state)
+	}
+	state.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	o.log.Warn("认证质询等待超时，交由浏览器处理", "sessionID", string(state.id), "requestID", requestID, "origin", c.info.Origin)
+	o.answerAuth(state, c.ts, requestID, domain.AuthDefault, nil)
+	if err := o.updatePhysicalInterception(state.ctx, state); err != nil {
+		o.log.Err(err, "恢复物理拦截状态失败", "sessionID", string(state.id))
+	}
+}
+
+// ListAuthChallenges 获取等待人工处理的认证质询，按收到的时间排序
+func (o *Orchestrator) ListAuthChallenges(ctx context.Context, id domain.SessionID) ([]domain.AuthChallenge, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return challengesLocked(state), nil
+}
+
+// ResolveAuthChallenge 处理等待中的认证质询：以凭据应答、取消认证或交由浏览器处理。
+// 以凭据应答且未填写用户名时使用命中规则中的凭据，规则也未设置用户名时返回 ErrInvalidConfig 并保持等待
+func (o *Orchestrator) ResolveAuthChallenge(ctx context.Context, id domain.SessionID, challengeID string, decision domain.AuthDecision) error {
+	if err := decision.Validate(); err != nil {
+		return err
+	}
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	c, ok := state.challenges[fetch.RequestID(challengeID)]
+	if !ok {
+		state.mu.Unlock()
+		return domain.ErrChallengeNotPending
+	}
+	var creds *domain.ProxyCredentials
+	if decision.Response == domain.AuthProvide {
+		creds = &domain.ProxyCredentials{Username: decision.Username, Password: decision.Password}
+		if creds.Username == "" {
+			username, password, err := c.spec.Credentials()
+			if err != nil || username == "" {
+				state.mu.Unlock()
+				return fmt.Errorf("%w: auth challenge %s needs a username", domain.ErrInvalidConfig, challengeID)
+			}
+			creds = &domain.ProxyCredentials{Username: username, Password: password}
+		}
+	}
+	c.timer.Stop()
+	delete(state.challenges, fetch.RequestID(challengeID))
+	o.notifyChallengesLocked(state)
+	state.mu.Unlock()
+
+	o.log.Info("处理等待中的认证质询", "sessionID", string(id), "requestID", challengeID, "response", string(decision.Response))
+	err := o.continueAuth(state, c.ts, fetch.RequestID(challengeID), decision.Response, creds)
+	if uerr := o.updatePhysicalInterception(ctx, state); uerr != nil && err == nil {
+		err = uerr
+	}
+	return err
+}
+
+// SubscribeAuthChallenges 订阅等待人工处理的认证质询：立即推送当前列表，之后每次变化推送最新列表；
+// 消费不及时时只保留最新列表。ctx 结束或会话停止时通道关闭
+func (o *Orchestrator) SubscribeAuthChallenges(ctx context.Context, id domain.SessionID) (<-chan []domain.AuthChallenge, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	ch := make(chan []domain.AuthChallenge, 1)
+	state.mu.Lock()
+	ch <- challengesLocked(state)
+	state.authWatchers = append(state.authWatchers, ch)
+	state.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-state.ctx.Done():
+		}
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		for i, w := range state.authWatchers {
+			if w == ch {
+				state.authWatchers = append(state.authWatchers[:i], state.authWatchers[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// challengesLocked 返回按收到时间排序的等待中质询，调用方需持有 state.mu
+func challengesLocked(state *sessionState) []domain.AuthChallenge {
+	out := make([]domain.AuthChallenge, 0, len(state.challenges))
+	for _, c := range state.challenges {
+		out = append(out, c.info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ChallengedAt < out[j].ChallengedAt })
+	return out
+}
+
+// notifyChallengesLocked 向订阅者推送最新的等待中质询，替换尚未消费的旧列表，调用方需持有 state.mu
+func (o *Orchestrator) notifyChallengesLocked(state *sessionState) {
+	if len(state.authWatchers) == 0 {
+		return
+	}
+	list := challengesLocked(state)
+	for _, ch := range state.authWatchers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- list
+	}
+}
+
+// proxyCredentials 返回会话的上游代理认证凭据，未设置时返回 nil
+func (s *sessionState) proxyCredentials() *domain.ProxyCredentials {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.proxyAuth
+}
+
+// handlesAuth 判断是否需要接管认证质询：设置了代理凭据，或拦截已开启且规则中包含 auth 行为
+func (s *sessionState) handlesAuth() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.proxyAuth != nil || (s.interceptionEnabled && s.authRules) || len(s.challenges) > 0
+}
@@ -0,0 +1,398 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"sort"
+	"time"
+
+	"cdpnetool/internal/adapter/cdp"
+	"cdpnetool/internal/processor"
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/protocol/network"
+)
+
+// heldRequest 被断点暂停的请求及放行所需的上下文
+type heldRequest struct {
+	info     domain.HeldRequest
+	ts       *cdp.TargetSession
+	ev       *fetch.RequestPausedReply
+	body     []byte      // 响应阶段暂停时已取出的原始响应体
+	streamed bool        // 响应体以流的方式取出，浏览器不再收到原始响应体
+	timer    *time.Timer // 超时自动放行的定时器
+}
+
+// ArmBreakpoint 布置一次性断点：下一个匹配过滤条件的请求将被暂停等待人工处理，命中后断点自动解除；
+// 只读会话返回 ErrSessionReadOnly
+func (o *Orchestrator) ArmBreakpoint(ctx context.Context, id domain.SessionID, filter domain.BreakpointFilter) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	if state.cfg.ReadOnly {
+		// 被暂停的请求可能被人工拒绝，只读会话不允许
+		return domain.ErrSessionReadOnly
+	}
+	if stage := filter.HoldStage(); stage != domain.BreakpointRequest && stage != domain.BreakpointResponse {
+		return fmt.Errorf("%w: unknown breakpoint stage %q", domain.ErrInvalidConfig, filter.Stage)
+	}
+
+	state.mu.Lock()
+	state.breakpoint = &filter
+	o.notifyBreakpointLocked(state)
+	state.mu.Unlock()
+
+	if err := o.updatePhysicalInterception(ctx, state); err != nil {
+		return err
+	}
+	o.log.Info("布置一次性断点", "sessionID", string(id), "urlContains", filter.URLContains, "method", filter.Method, "stage", filter.HoldStage())
+	return nil
+}
+
+// DisarmBreakpoint 解除尚未命中的断点，已暂停的请求不受影响
+func (o *Orchestrator) DisarmBreakpoint(ctx context.Context, id domain.SessionID) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	state.breakpoint = nil
+	o.notifyBreakpointLocked(state)
+	state.mu.Unlock()
+
+	return o.updatePhysicalInterception(ctx, state)
+}
+
+// GetBreakpointStatus 获取断点是否布置及等待处理的请求
+func (o *Orchestrator) GetBreakpointStatus(ctx context.Context, id domain.SessionID) (domain.BreakpointStatus, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.BreakpointStatus{}, domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return breakpointStatusLocked(state), nil
+}
+
+// SubscribeBreakpoint 订阅断点状态：立即推送当前状态（含仍在等待的请求及剩余时间），之后每次变化推送最新状态；
+// 消费不及时时只保留最新状态。ctx 结束或会话停止时通道关闭
+func (o *Orchestrator) SubscribeBreakpoint(ctx context.Context, id domain.SessionID) (<-chan domain.BreakpointStatus, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	ch := make(chan domain.BreakpointStatus, 1)
+	state.mu.Lock()
+	ch <- breakpointStatusLocked(state)
+	state.bpWatchers = append(state.bpWatchers, ch)
+	state.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-state.ctx.Done():
+		}
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		for i, w := range state.bpWatchers {
+			if w == ch {
+				state.bpWatchers = append(state.bpWatchers[:i], state.bpWatchers[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// breakpointStatusLocked 生成当前断点状态，调用方需持有 state.mu
+func breakpointStatusLocked(state *sessionState) domain.BreakpointStatus {
+	status := domain.BreakpointStatus{
+		Armed: state.breakpoint != nil,
+		Held:  make([]domain.HeldRequest, 0, len(state.held)),
+	}
+	if state.breakpoint != nil {
+		filter := *state.breakpoint
+		status.Filter = &filter
+	}
+	now := time.Now().UnixMilli()
+	for _, h := range state.held {
+		info := h.info
+		info.RemainingMS = max(info.ExpiresAt-now, 0)
+		status.Held = append(status.Held, info)
+	}
+	sort.Slice(status.Held, func(i, j int) bool { return status.Held[i].HeldAt < status.Held[j].HeldAt })
+	return status
+}
+
+// notifyBreakpointLocked 向订阅者推送最新断点状态，替换尚未消费的旧状态，调用方需持有 state.mu
+func (o *Orchestrator) notifyBreakpointLocked(state *sessionState) {
+	if len(state.bpWatchers) == 0 {
+		return
+	}
+	status := breakpointStatusLocked(state)
+	for _, ch := range state.bpWatchers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- status
+	}
+}
+
+// ResolveHeldRequest 处理被断点暂停的请求：approve 为 true 时交给规则正常处理，否则以客户端拦截的网络错误终止
+func (o *Orchestrator) ResolveHeldRequest(ctx context.Context, id domain.SessionID, requestID string, approve bool) error {
+	state, h, err := o.takeHeld(id, requestID, "")
+	if err != nil {
+		return err
+	}
+
+	if approve {
+		o.log.Info("放行断点暂停的请求", "requestID", requestID, "url", h.info.URL)
+		o.releaseHeld(state, h)
+	} else {
+		o.log.Info("拒绝断点暂停的请求", "requestID", requestID, "url", h.info.URL)
+		err = h.ts.Client.Fetch.FailRequest(ctx, &fetch.FailRequestArgs{
+			RequestID:   h.ev.RequestID,
+			ErrorReason: network.ErrorReasonBlockedByClient,
+		})
+		if h.info.Stage == domain.BreakpointResponse {
+			o.releaseCoalesced(state, h.ev.RequestID)
+		}
+	}
+	return o.afterHeld(ctx, state, err)
+}
+
+// ApproveHeldRequest 以编辑后的请求放行在请求阶段暂停的请求，编辑后的请求仍交给规则处理，
+// 规则未作修改时按编辑后的 URL、方法、头部与请求体发出。edit 中为空的 URL、方法及为 nil 的头部、请求体沿用暂停时的值；
+// Cookies 不为 nil 时据此重建 Cookie 头。edit 为 nil 时等同于直接放行，暂停在响应阶段或 URL 无效时返回 ErrInvalidConfig
+func (o *Orchestrator) ApproveHeldRequest(ctx context.Context, id domain.SessionID, requestID string, edit *domain.Request) error {
+	if edit == nil {
+		return o.ResolveHeldRequest(ctx, id, requestID, true)
+	}
+	if edit.URL != "" {
+		if u, err := url.Parse(edit.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%w: invalid request url %q", domain.ErrInvalidConfig, edit.URL)
+		}
+	}
+	state, h, err := o.takeHeld(id, requestID, domain.BreakpointRequest)
+	if err != nil {
+		return err
+	}
+
+	edited := editedEvent(h.ev, h.info.Request, edit)
+	req := cdp.ToNeutralRequest(edited)
+	o.log.Info("以编辑后的请求放行断点暂停的请求", "requestID", requestID, "url", req.URL, "method", req.Method)
+	res := processor.Result{Action: processor.ActionPass}
+	if state.processingEnabled() {
+		res = state.processor.ProcessRequest(state.traceContext(h.ev.RequestID), string(state.id), string(h.ts.ID), req)
+	}
+	if state.isDryRun() {
+		// 演练模式下规则的修改只记录不下发，处理器会就地修改请求，以编辑后的原始内容放行
+		res = passThrough(res, nil)
+		req = cdp.ToNeutralRequest(edited)
+	}
+	if res.Action == processor.ActionPass {
+		// 规则未修改时仍需将人工编辑的内容下发
+		res.Action = processor.ActionModify
+		res.ModifiedReq = req
+	}
+	o.applyResult(state, h.ts, h.ev, res)
+	return o.afterHeld(ctx, state, nil)
+}
+
+// ApproveHeldResponse 以编辑后的响应放行在响应阶段暂停的请求，编辑后的响应仍交给规则处理，规则未作修改时以其应答页面。
+// edit 中为 0 的状态码及为 nil 的头部、响应体沿用暂停时的值；保留 Content-Encoding 时按会话设置重新压缩或以明文下发。
+// edit 为 nil 时等同于直接放行，暂停在请求阶段或状态码无效时返回 ErrInvalidConfig
+func (o *Orchestrator) ApproveHeldResponse(ctx context.Context, id domain.SessionID, requestID string, edit *domain.Response) error {
+	if edit == nil {
+		return o.ResolveHeldRequest(ctx, id, requestID, true)
+	}
+	if edit.StatusCode != 0 && (edit.StatusCode < 100 || edit.StatusCode > 599) {
+		return fmt.Errorf("%w: invalid status code %d", domain.ErrInvalidConfig, edit.StatusCode)
+	}
+	state, h, err := o.takeHeld(id, requestID, domain.BreakpointResponse)
+	if err != nil {
+		return err
+	}
+
+	o.log.Info("以编辑后的响应放行断点暂停的请求", "requestID", requestID, "url", h.info.URL)
+	o.processResponse(state, h.ts, h.ev, h.body, h.streamed, edit)
+	return o.afterHeld(ctx, state, nil)
+}
+
+// takeHeld 取出等待处理的请求并停止其超时定时器；stage 不为空时要求请求暂停在该阶段，否则保持暂停并返回 ErrInvalidConfig
+func (o *Orchestrator) takeHeld(id domain.SessionID, requestID string, stage domain.BreakpointStage) (*sessionState, *heldRequest, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return nil, nil, domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	h, ok := state.held[fetch.RequestID(requestID)]
+	if !ok {
+		return nil, nil, domain.ErrRequestNotHeld
+	}
+	if stage != "" && h.info.Stage != stage {
+		return nil, nil, fmt.Errorf("%w: request %s is held at the %s stage", domain.ErrInvalidConfig, requestID, h.info.Stage)
+	}
+	h.timer.Stop()
+	delete(state.held, fetch.RequestID(requestID))
+	o.notifyBreakpointLocked(state)
+	return state, h, nil
+}
+
+// releaseHeld 将未经编辑的暂停请求交给规则正常处理
+func (o *Orchestrator) releaseHeld(state *sessionState, h *heldRequest) {
+	if h.info.Stage == domain.BreakpointResponse {
+		o.processResponse(state, h.ts, h.ev, h.body, h.streamed, nil)
+		return
+	}
+	o.processEvent(state, h.ts, h.ev)
+}
+
+// afterHeld 处理完暂停的请求后，断点已解除且无待处理请求时按业务状态恢复物理拦截
+func (o *Orchestrator) afterHeld(ctx context.Context, state *sessionState, err error) error {
+	if uerr := o.updatePhysicalInterception(ctx, state); uerr != nil && err == nil {
+		err = uerr
+	}
+	return err
+}
+
+// editedEvent 以人工编辑的内容替换暂停事件中的请求，便于按常规流程转换出 Query 与 Cookies；
+// 未编辑的部分沿用暂停时的快照 snapshot
+func editedEvent(ev *fetch.RequestPausedReply, snapshot, edit *domain.Request) *fetch.RequestPausedReply {
+	out := *ev
+	if edit.URL != "" {
+		out.Request.URL = edit.URL
+	}
+	if edit.Method != "" {
+		out.Request.Method = edit.Method
+	}
+
+	headers := snapshot.Headers
+	if edit.Headers != nil {
+		headers = edit.Headers
+	}
+	if edit.Cookies != nil {
+		headers = maps.Clone(headers)
+		if headers == nil {
+			headers = make(domain.Header)
+		}
+		if key := headerKey(headers, "Cookie"); key != "" {
+			headers.Del(key)
+		}
+		if cookie := transformer.BuildCookieString(edit.Cookies); cookie != "" {
+			headers.Set("Cookie", cookie)
+		}
+	}
+	out.Request.Headers, _ = json.Marshal(headers)
+
+	body := snapshot.Body
+	if edit.Body != nil {
+		body = edit.Body
+	}
+	hasBody := len(body) > 0
+	out.Request.HasPostData = &hasBody
+	out.Request.PostData = nil
+	out.Request.PostDataEntries = nil
+	if hasBody {
+		encoded := base64.StdEncoding.EncodeToString(body)
+		out.Request.PostDataEntries = []network.PostDataEntry{{Bytes: &encoded}}
+	}
+	return &out
+}
+
+// holdAtBreakpoint 请求在断点的阶段命中已布置的断点时将其暂停并解除断点，返回请求是否被暂停（或已在暂停过程中结束）。
+// 暂停时记录请求的完整快照，响应阶段还会取出响应体
+func (o *Orchestrator) holdAtBreakpoint(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply) bool {
+	stage := domain.BreakpointRequest
+	if ev.ResponseStatusCode != nil {
+		stage = domain.BreakpointResponse
+	}
+	state.mu.Lock()
+	bp := state.breakpoint
+	if bp == nil || bp.HoldStage() != stage || !bp.Match(ev.Request.URL, ev.Request.Method) {
+		state.mu.Unlock()
+		return false
+	}
+	state.breakpoint = nil
+	state.mu.Unlock()
+
+	h := &heldRequest{
+		info: domain.HeldRequest{
+			ID:       string(ev.RequestID),
+			TargetID: ts.ID,
+			URL:      ev.Request.URL,
+			Method:   ev.Request.Method,
+			Stage:    stage,
+			Request:  cdp.ToNeutralRequest(ev),
+		},
+		ts: ts,
+		ev: ev,
+	}
+	if len(h.info.Request.Body) == 0 && ev.Request.HasPostData != nil && *ev.Request.HasPostData {
+		o.fetchPostData(state, ts, ev, h.info.Request)
+	}
+	if stage == domain.BreakpointResponse {
+		body, streamed, err := o.fetchResponseBody(state, ts, ev)
+		if err != nil {
+			o.responseBodyFailed(state, ts, ev, streamed, err)
+			state.mu.Lock()
+			o.notifyBreakpointLocked(state)
+			state.mu.Unlock()
+			return true
+		}
+		h.body, h.streamed = body, streamed
+		h.info.Response = cdp.ToNeutralResponse(ev, body)
+		o.decodeResponseBody(state, ev.RequestID, h.info.Response)
+	}
+
+	timeout := bp.HoldTimeout()
+	now := time.Now()
+	h.info.HeldAt = now.UnixMilli()
+	h.info.ExpiresAt = now.Add(timeout).UnixMilli()
+	state.mu.Lock()
+	state.held[ev.RequestID] = h
+	h.timer = time.AfterFunc(timeout, func() {
+		o.expireHeld(state, ev.RequestID)
+	})
+	o.notifyBreakpointLocked(state)
+	state.mu.Unlock()
+	o.log.Info("请求命中断点，等待人工处理", "sessionID", string(state.id), "requestID", ev.RequestID, "url", ev.Request.URL, "stage", stage, "timeout", timeout)
+	return true
+}
+
+// expireHeld 请求等待人工处理超时，与降级放行一致按规则继续处理
+func (o *Orchestrator) expireHeld(state *sessionState, requestID fetch.RequestID) {
+	if state.ctx.Err() != nil {
+		return
+	}
+	state.mu.Lock()
+	h, ok := state.held[requestID]
+	if ok {
+		delete(state.held, requestID)
+		o.notifyBreakpointLocked(state)
+	}
+	state.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	o.log.Warn("断点暂停的请求等待超时，自动放行", "sessionID", string(state.id), "requestID", requestID, "url", h.info.URL)
+	o.releaseHeld(state, h)
+	if err := o.updatePhysicalInterception(state.ctx, state); err != nil {
+		o.log.Err(err, "恢复物理拦截状态失败", "sessionID", string(state.id))
+	}
+}
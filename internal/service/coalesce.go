@@ -0,0 +1,137 @@
+package service
+
+import (
+	"time"
+
+	"cdpnetool/internal/adapter/cdp"
+	"cdpnetool/internal/journal"
+	"cdpnetool/internal/processor"
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+)
+
+// coalesceHoldTimeout 重复请求等待首个请求响应的最长时间，超时后各自按规则正常处理
+const coalesceHoldTimeout = 30 * time.Second
+
+// actionCoalesce 决策日志中以首个请求的响应应答的合并请求的处理动作
+const actionCoalesce processor.Action = "coalesce"
+
+// coalesceGroup 一组进行中的相同请求：首个请求正常发出，窗口内到达的重复请求暂停等待其响应
+type coalesceGroup struct {
+	key       string
+	leader    fetch.RequestID
+	started   time.Time
+	followers []*coalescedRequest
+	timer     *time.Timer // 等待超时后放行重复请求的定时器
+}
+
+// coalescedRequest 被合并、等待首个请求响应的重复请求
+type coalescedRequest struct {
+	ts  *cdp.TargetSession
+	ev  *fetch.RequestPausedReply
+	req *domain.Request
+}
+
+// coalesceKey 计算相同请求的判定键：方法、URL 与请求体的摘要
+func coalesceKey(req *domain.Request) string {
+	return journal.Fingerprint(req.Method, req.URL, req.Body)
+}
+
+// coalesceRequest 会话开启请求合并时登记请求阶段的暂停事件：
+// 窗口内已有相同的进行中请求时将其暂停等待首个请求的响应并返回 true，否则登记为新一组的首个请求
+func (o *Orchestrator) coalesceRequest(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply, req *domain.Request) bool {
+	window := time.Duration(state.cfg.CoalesceWindowMS) * time.Millisecond
+	if window <= 0 || state.isDryRun() {
+		return false
+	}
+	key := coalesceKey(req)
+	now := time.Now()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if g, ok := state.coalesce[key]; ok && now.Sub(g.started) <= window {
+		g.followers = append(g.followers, &coalescedRequest{ts: ts, ev: ev, req: req})
+		o.log.Debug("[Orchestrator] 合并相同的进行中请求", "requestID", ev.RequestID, "leader", g.leader, "url", req.URL)
+		return true
+	}
+	g := &coalesceGroup{key: key, leader: ev.RequestID, started: now}
+	g.timer = time.AfterFunc(coalesceHoldTimeout, func() {
+		o.log.Warn("等待首个请求响应超时，放行合并的请求", "sessionID", string(state.id), "requestID", g.leader)
+		o.releaseCoalesced(state, g.leader)
+	})
+	// 窗口过期后的相同请求成为新一组的首个请求，旧组仍等待自己的首个请求响应
+	state.coalesce[key] = g
+	state.coalesceLeaders[ev.RequestID] = g
+	return false
+}
+
+// takeCoalesceGroup 取出以该请求为首个请求的合并组，没有时返回 nil
+func (o *Orchestrator) takeCoalesceGroup(state *sessionState, leader fetch.RequestID) *coalesceGroup {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	g, ok := state.coalesceLeaders[leader]
+	if !ok {
+		return nil
+	}
+	g.timer.Stop()
+	delete(state.coalesceLeaders, leader)
+	if state.coalesce[g.key] == g {
+		delete(state.coalesce, g.key)
+	}
+	return g
+}
+
+// hasCoalesceGroup 判断该请求是否为某个合并组的首个请求
+func (s *sessionState) hasCoalesceGroup(leader fetch.RequestID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.coalesceLeaders[leader]
+	return ok
+}
+
+// releaseCoalesced 首个请求未得到可复用的响应，合并的请求各自按规则正常处理
+func (o *Orchestrator) releaseCoalesced(state *sessionState, leader fetch.RequestID) {
+	g := o.takeCoalesceGroup(state, leader)
+	if g == nil || state.ctx.Err() != nil {
+		return
+	}
+	for _, f := range g.followers {
+		o.processRequest(state, f.ts, f.ev, f.req)
+	}
+}
+
+// fulfillCoalesced 以首个请求的最终响应应答合并的请求
+func (o *Orchestrator) fulfillCoalesced(state *sessionState, leader fetch.RequestID, res *domain.Response) {
+	g := o.takeCoalesceGroup(state, leader)
+	if g == nil {
+		return
+	}
+	for _, f := range g.followers {
+		var entry domain.DecisionEntry
+		if state.journal != nil {
+			entry = decisionEntry(state, f.ts.ID, f.ev, processor.Result{Action: actionCoalesce})
+			entry.Call = "Fetch.fulfillRequest"
+		}
+		err := f.ts.Client.Fetch.FulfillRequest(state.ctx, &fetch.FulfillRequestArgs{
+			RequestID:       f.ev.RequestID,
+			ResponseCode:    res.StatusCode,
+			ResponseHeaders: cdp.ToHeaderEntries(res.Headers),
+			Body:            res.Body,
+		})
+		if err != nil {
+			o.log.Err(err, "[Orchestrator] 应答合并的请求失败，降级为正常处理", "requestID", f.ev.RequestID, "leader", leader)
+			if state.journal != nil {
+				entry.Error = err.Error()
+				entry.Degraded = true
+				state.journal.Append(entry)
+			}
+			o.processRequest(state, f.ts, f.ev, f.req)
+			continue
+		}
+		o.log.Debug("[Orchestrator] 以首个请求的响应应答合并的请求", "requestID", f.ev.RequestID, "leader", leader)
+		if state.journal != nil {
+			state.journal.Append(entry)
+		}
+	}
+}
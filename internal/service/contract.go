@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"cdpnetool/internal/contract"
+	"cdpnetool/pkg/domain"
+)
+
+// StartContractCheck 加载 OpenAPI 规范并开启契约检查，不符合规范的请求与响应记录到事件历史；
+// 已开启时替换为新规范
+func (o *Orchestrator) StartContractCheck(ctx context.Context, id domain.SessionID, specPath string) (domain.ContractStatus, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ContractStatus{}, domain.ErrSessionNotFound
+	}
+
+	spec, err := contract.LoadSpec(specPath)
+	if err != nil {
+		return domain.ContractStatus{}, fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+	}
+	state.mu.Lock()
+	state.contract = spec
+	state.mu.Unlock()
+	state.processor.SetContract(spec)
+
+	if err := o.updatePhysicalInterception(ctx, state); err != nil {
+		return domain.ContractStatus{}, err
+	}
+	status := spec.Status()
+	o.log.Info("开启 OpenAPI 契约检查", "sessionID", string(id), "path", specPath, "operations", status.Operations)
+	return status, nil
+}
+
+// StopContractCheck 关闭契约检查
+func (o *Orchestrator) StopContractCheck(ctx context.Context, id domain.SessionID) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	active := state.contract != nil
+	state.contract = nil
+	state.mu.Unlock()
+	if !active {
+		return nil
+	}
+	state.processor.SetContract(nil)
+
+	if err := o.updatePhysicalInterception(ctx, state); err != nil {
+		return err
+	}
+	o.log.Info("关闭 OpenAPI 契约检查", "sessionID", string(id))
+	return nil
+}
+
+// GetContractStatus 获取契约检查状态，未开启时返回 Active 为 false 的空状态
+func (o *Orchestrator) GetContractStatus(ctx context.Context, id domain.SessionID) (domain.ContractStatus, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ContractStatus{}, domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	spec := state.contract
+	state.mu.Unlock()
+	if spec == nil {
+		return domain.ContractStatus{}, nil
+	}
+	return spec.Status(), nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+
+	"cdpnetool/internal/adapter/cdp"
+	"cdpnetool/pkg/domain"
+)
+
+// ListCookies 获取目标可见的浏览器 Cookie，urls 为空时返回当前页面及其子框架可见的 Cookie
+func (o *Orchestrator) ListCookies(ctx context.Context, id domain.SessionID, target domain.TargetID, urls []string) ([]domain.BrowserCookie, error) {
+	ts, err := o.targetSession(id, target)
+	if err != nil {
+		return nil, err
+	}
+	cookies, err := cdp.GetCookies(ctx, ts.Client, urls)
+	if err != nil {
+		o.log.Err(err, "获取浏览器 Cookie 失败", "target", string(target))
+		return nil, err
+	}
+	return cookies, nil
+}
+
+// SetCookie 将 Cookie 写入目标所在浏览器上下文的 Cookie 存储，已存在同名同域同路径的 Cookie 时覆盖；
+// 只读会话返回 ErrSessionReadOnly
+func (o *Orchestrator) SetCookie(ctx context.Context, id domain.SessionID, target domain.TargetID, cookie domain.BrowserCookie) error {
+	if err := cookie.Validate(); err != nil {
+		return err
+	}
+	if err := o.checkCookieWritable(id); err != nil {
+		return err
+	}
+	ts, err := o.targetSession(id, target)
+	if err != nil {
+		return err
+	}
+	if err := cdp.SetCookie(ctx, ts.Client, cookie); err != nil {
+		o.log.Err(err, "写入浏览器 Cookie 失败", "target", string(target), "name", cookie.Name)
+		return err
+	}
+	o.log.Info("写入浏览器 Cookie", "sessionID", string(id), "target", string(target), "name", cookie.Name)
+	return nil
+}
+
+// DeleteCookies 删除目标所在浏览器上下文中匹配条件的 Cookie，只读会话返回 ErrSessionReadOnly
+func (o *Orchestrator) DeleteCookies(ctx context.Context, id domain.SessionID, target domain.TargetID, filter domain.CookieFilter) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	if err := o.checkCookieWritable(id); err != nil {
+		return err
+	}
+	ts, err := o.targetSession(id, target)
+	if err != nil {
+		return err
+	}
+	if err := cdp.DeleteCookies(ctx, ts.Client, filter); err != nil {
+		o.log.Err(err, "删除浏览器 Cookie 失败", "target", string(target), "name", filter.Name)
+		return err
+	}
+	o.log.Info("删除浏览器 Cookie", "sessionID", string(id), "target", string(target), "name", filter.Name)
+	return nil
+}
+
+// checkCookieWritable 检查会话是否允许修改浏览器 Cookie：Cookie 会改变之后请求携带的内容，只读会话不允许
+func (o *Orchestrator) checkCookieWritable(id domain.SessionID) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	if state.cfg.ReadOnly {
+		return domain.ErrSessionReadOnly
+	}
+	return nil
+}
+
+// persistCookies 将 setBrowserCookie 行为生成的 Cookie 写入浏览器，失败时仅记录日志，不影响请求放行
+func (o *Orchestrator) persistCookies(state *sessionState, ts *cdp.TargetSession, cookies []domain.BrowserCookie) {
+	for _, c := range cookies {
+		if err := cdp.SetCookie(state.ctx, ts.Client, c); err != nil {
+			o.log.Err(err, "写入浏览器 Cookie 失败", "sessionID", string(state.id), "target", string(ts.ID), "name", c.Name)
+		}
+	}
+}
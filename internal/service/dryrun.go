@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	"cdpnetool/internal/processor"
+	"cdpnetool/pkg/domain"
+)
+
+// SetDryRun 开启或关闭会话的演练模式：规则照常评估，匹配事件记录规则本会产生的修改并标记 DryRun，
+// 但所有请求与响应原样放行，不节流、不合并重复请求，mirror 与 saveBody 动作不执行，用于在真实流量上安全地验证新规则集。
+// 断点中人工编辑的请求与响应仍按编辑内容下发
+func (o *Orchestrator) SetDryRun(ctx context.Context, id domain.SessionID, enabled bool) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	state.mu.Lock()
+	changed := state.dryRun != enabled
+	state.dryRun = enabled
+	state.mu.Unlock()
+	state.processor.SetDryRun(enabled)
+	if changed {
+		o.log.Info("切换演练模式", "sessionID", string(id), "dryRun", enabled)
+	}
+	return nil
+}
+
+// isDryRun 判断会话是否处于演练模式
+func (s *sessionState) isDryRun() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dryRun
+}
+
+// passThrough 返回原样放行的处理结果，响应体已以流方式取出时以 original 应答
+func passThrough(res processor.Result, original *domain.Response) processor.Result {
+	pass := processor.Result{Action: processor.ActionPass, WebSocket: res.WebSocket, HeadersOnly: res.HeadersOnly, Streamed: res.Streamed}
+	if res.Streamed {
+		pass.ModifiedRes = original
+	}
+	return pass
+}
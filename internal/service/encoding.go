@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+)
+
+// decodeResponseBody 按 Content-Encoding 将响应体解压后交给规则处理，返回解压所用的编码。
+// 响应体不是声明的编码（如浏览器已解压）、编码不受支持或解压后超过会话的响应体大小上限时保持原样并返回 EncodingNone
+func (o *Orchestrator) decodeResponseBody(state *sessionState, id fetch.RequestID, resp *domain.Response) transformer.ContentEncoding {
+	enc := transformer.EncodingFor(resp.Headers.Get("Content-Encoding"))
+	if enc == transformer.EncodingNone || len(resp.Body) == 0 {
+		return transformer.EncodingNone
+	}
+	decoded, err := transformer.DecodeContent(resp.Body, enc, state.cfg.MaxDecodedSize)
+	if err != nil {
+		if errors.Is(err, transformer.ErrDecodedTooLarge) {
+			o.log.Warn("解压后的响应体超过大小上限，规则按原始字节处理", "requestID", id, "encoding", enc, "limit", state.cfg.MaxDecodedSize)
+		} else {
+			o.log.Debug("响应体未按声明的编码压缩，按原样处理", "requestID", id, "encoding", enc, "error", err.Error())
+		}
+		return transformer.EncodingNone
+	}
+	resp.Body = decoded
+	return enc
+}
+
+// encodeResponseBody 让解压后交给规则处理的响应体与下发的头部一致：recompress 为 true 时按原编码重新压缩，
+// 否则移除 Content-Encoding 以明文下发；两种情况都按实际长度更新 Content-Length。
+// 规则移除或改写了 Content-Encoding 时不再处理
+func encodeResponseBody(res *domain.Response, enc transformer.ContentEncoding, recompress bool) {
+	key := headerKey(res.Headers, "Content-Encoding")
+	if key == "" || transformer.EncodingFor(res.Headers[key]) != enc {
+		setContentLength(res)
+		return
+	}
+	if recompress {
+		if encoded, err := transformer.EncodeContent(res.Body, enc); err == nil {
+			res.Body = encoded
+			setContentLength(res)
+			return
+		}
+	}
+	res.Headers.Del(key)
+	setContentLength(res)
+}
+
+// setContentLength 响应带有 Content-Length 时按实际响应体长度更新
+func setContentLength(res *domain.Response) {
+	if key := headerKey(res.Headers, "Content-Length"); key != "" {
+		res.Headers.Set(key, strconv.Itoa(len(res.Body)))
+	}
+}
+
+// headerKey 忽略大小写查找头部在 Header 中的实际名称，不存在时返回空
+func headerKey(h domain.Header, name string) string {
+	for k := range h {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return ""
+}
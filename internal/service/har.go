@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"cdpnetool/internal/eventstream"
+	"cdpnetool/internal/har"
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/redact"
+	"cdpnetool/pkg/domain"
+)
+
+// harExport 会话的持续 HAR 导出，从全量流量审计器的事件流中读取并写入文件
+type harExport struct {
+	writer   *har.Writer
+	stream   *eventstream.Stream
+	redactor *redact.Redactor // 写入前的脱敏器，为 nil 时原样写入
+	cancel   context.CancelFunc
+	done     chan struct{}
+	failed   atomic.Int64
+}
+
+// run 持续写入事件直到 ctx 取消；取消后先写完已缓冲的事件再退出
+func (h *harExport) run(ctx context.Context, l logger.Logger) {
+	defer close(h.done)
+	for {
+		d, err := h.stream.Next(ctx)
+		if err != nil {
+			return
+		}
+		// HAR 条目对应 HTTP 请求，WebSocket 消息帧事件不写入
+		if d.Event.Frame != nil {
+			_ = h.stream.Ack(d.Seq)
+			continue
+		}
+		if err := h.writer.Write(h.redactor.Event(d.Event)); err != nil {
+			h.failed.Add(1)
+			l.Warn("写入 HAR 条目失败", "path", h.writer.Path(), "requestID", d.Event.ID, "error", err)
+		}
+		_ = h.stream.Ack(d.Seq)
+	}
+}
+
+// status 返回导出状态
+func (h *harExport) status() domain.HARStreamStatus {
+	return domain.HARStreamStatus{
+		Active:  true,
+		Path:    h.writer.Path(),
+		Format:  h.writer.Format(),
+		Entries: h.writer.Entries(),
+		Dropped: h.stream.Stats().Dropped,
+		Failed:  h.failed.Load(),
+	}
+}
+
+// StartHARStream 开始将会话的全量流量持续写入 HAR 文件，导出期间流量捕获保持开启
+func (o *Orchestrator) StartHARStream(ctx context.Context, id domain.SessionID, opts domain.HARStreamOptions) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	if state.har != nil {
+		state.mu.Unlock()
+		return fmt.Errorf("%w: HAR stream already active", domain.ErrInvalidConfig)
+	}
+	w, err := har.Create(opts.Path, opts.Format)
+	if err != nil {
+		state.mu.Unlock()
+		return err
+	}
+	hctx, cancel := context.WithCancel(state.ctx)
+	h := &harExport{
+		writer:   w,
+		stream:   eventstream.New(domain.EventStreamOptions{}),
+		redactor: state.redactor,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	state.har = h
+	state.trafficAuditor.SetEnabled(true)
+	state.mu.Unlock()
+
+	state.trafficAuditor.AddStream(h.stream)
+	go h.run(hctx, o.log)
+
+	if err := o.updatePhysicalInterception(ctx, state); err != nil {
+		return err
+	}
+	o.log.Info("开始持续导出 HAR", "sessionID", string(id), "path", w.Path(), "format", w.Format())
+	return nil
+}
+
+// StopHARStream 停止持续 HAR 导出并返回最终状态，未在导出时返回 Active 为 false 的空状态
+func (o *Orchestrator) StopHARStream(ctx context.Context, id domain.SessionID) (domain.HARStreamStatus, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.HARStreamStatus{}, domain.ErrSessionNotFound
+	}
+
+	status, err := o.stopHAR(state)
+	if err != nil {
+		return status, err
+	}
+	if err := o.updatePhysicalInterception(ctx, state); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// GetHARStreamStatus 获取持续 HAR 导出的状态
+func (o *Orchestrator) GetHARStreamStatus(ctx context.Context, id domain.SessionID) (domain.HARStreamStatus, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.HARStreamStatus{}, domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	h := state.har
+	state.mu.Unlock()
+	if h == nil {
+		return domain.HARStreamStatus{}, nil
+	}
+	return h.status(), nil
+}
+
+// stopHAR 停止导出并关闭文件，流量捕获恢复为用户设置的状态
+func (o *Orchestrator) stopHAR(state *sessionState) (domain.HARStreamStatus, error) {
+	state.mu.Lock()
+	h := state.har
+	state.har = nil
+	state.trafficAuditor.SetEnabled(state.trafficCapture)
+	state.mu.Unlock()
+	if h == nil {
+		return domain.HARStreamStatus{}, nil
+	}
+
+	h.cancel()
+	<-h.done
+	status := h.status()
+	status.Active = false
+	_ = h.stream.Close()
+	if err := h.writer.Close(); err != nil {
+		return status, err
+	}
+	o.log.Info("持续导出 HAR 已停止", "sessionID", string(state.id), "path", status.Path, "entries", status.Entries, "dropped", status.Dropped)
+	return status, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"path/filepath"
+	"time"
+
+	"cdpnetool/internal/journal"
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/processor"
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+)
+
+// openJournal 在 dir 下打开会话的决策日志，dir 为空时不记录
+func openJournal(dir string, id domain.SessionID, l logger.Logger) (*journal.Journal, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	return journal.Open(filepath.Join(dir, "decisions-"+string(id)+".jsonl"), l)
+}
+
+// decisionEntry 根据暂停事件与处理结果生成决策记录，下发结果由调用方补充；target 未知时为空
+func decisionEntry(state *sessionState, target domain.TargetID, ev *fetch.RequestPausedReply, res processor.Result) domain.DecisionEntry {
+	stage := "request"
+	if ev.ResponseStatusCode != nil {
+		stage = "response"
+	}
+	var body []byte
+	if ev.Request.PostData != nil {
+		body = []byte(*ev.Request.PostData)
+	}
+	action := res.Action
+	if action == "" {
+		action = processor.ActionPass
+	}
+	return domain.DecisionEntry{
+		Time:        time.Now().UnixMilli(),
+		Session:     state.id,
+		Target:      target,
+		RequestID:   string(ev.RequestID),
+		Stage:       stage,
+		Method:      ev.Request.Method,
+		URL:         ev.Request.URL,
+		Fingerprint: journal.Fingerprint(ev.Request.Method, ev.Request.URL, body),
+		Rules:       res.RuleIDs,
+		Action:      string(action),
+		HeadersOnly: res.HeadersOnly,
+		Streamed:    res.Streamed,
+	}
+}
+
+// journalDegraded 记录未经规则处理即被降级放行的事件
+func journalDegraded(state *sessionState, target domain.TargetID, ev *fetch.RequestPausedReply, reason string, err error) {
+	if state.journal == nil {
+		return
+	}
+	entry := decisionEntry(state, target, ev, processor.Result{})
+	entry.Call = "Fetch.continueRequest"
+	if ev.ResponseStatusCode != nil {
+		entry.Call = "Fetch.continueResponse"
+	}
+	entry.Degraded = true
+	entry.Error = reason
+	if err != nil {
+		entry.Error += ": " + err.Error()
+	}
+	state.journal.Append(entry)
+}
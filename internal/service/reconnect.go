@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"cdpnetool/internal/adapter/cdp"
+	"cdpnetool/pkg/domain"
+)
+
+// connectionBuffer 连接事件订阅通道的容量，消费不及时时丢弃新事件
+const connectionBuffer = 16
+
+// reconnect 目标连接意外断开后按会话的重连策略以带随机抖动的指数退避重新附着：重新获取目标及其调试地址，
+// 以相同的拦截范围重新启用拦截并恢复各项覆盖设置，已加载的规则保持不变。原目标已不存在时（如浏览器重启）
+// 改为附着一个 URL 相同的页面。重连后尝试在新连接上放行断开时仍处于暂停状态的请求，无法放行的请求计为遗留请求
+func (o *Orchestrator) reconnect(state *sessionState, lost *cdp.TargetSession, paused []cdp.PausedRequest) {
+	target := lost.ID
+	o.log.Warn("目标连接意外断开，尝试重新附着", "sessionID", string(state.id), "target", string(target), "paused", len(paused))
+	state.mu.Lock()
+	state.reconnects.Disconnects++
+	state.mu.Unlock()
+	_ = state.clientMgr.DetachTarget(target)
+	o.notifyConnection(state, domain.ConnectionEvent{Type: domain.ConnectionDisconnected, TargetID: target})
+
+	policy := state.reconnectPolicy()
+	current, attempt := target, 0
+	var err error = errors.New("reconnect disabled")
+	for attempt < policy.Attempts() {
+		attempt++
+		select {
+		case <-state.ctx.Done():
+			o.countOrphaned(state, target, len(paused))
+			return
+		case <-time.After(jitter(policy.Delay(attempt))):
+		}
+		if !state.sess.HasTarget(target) {
+			// 重连期间目标被主动断开
+			o.countOrphaned(state, target, len(paused))
+			return
+		}
+		if current, err = o.reattach(state, target, lost.URL); err == nil {
+			break
+		}
+		o.log.Warn("重新附着目标失败，稍后重试", "target", string(target), "attempt", attempt, "error", err.Error())
+	}
+	if err != nil {
+		o.log.Err(err, "重新附着目标失败，已放弃", "sessionID", string(state.id), "target", string(target), "attempts", attempt)
+		o.countOrphaned(state, target, len(paused))
+		o.notifyConnection(state, domain.ConnectionEvent{
+			Type:     domain.ConnectionReconnectFailed,
+			TargetID: target,
+			Attempts: attempt,
+			Orphaned: len(paused),
+			Error:    err.Error(),
+		})
+		return
+	}
+
+	resumed := 0
+	if ts, ok := state.clientMgr.GetSession(current); ok && len(paused) > 0 {
+		resumed = state.interceptor.Resume(state.ctx, ts.Client, paused)
+	}
+	state.mu.Lock()
+	state.reconnects.Reconnects++
+	state.reconnects.Resumed += int64(resumed)
+	state.mu.Unlock()
+	o.countOrphaned(state, target, len(paused)-resumed)
+	o.log.Info("目标已重新附着", "sessionID", string(state.id), "target", string(current), "attempts", attempt)
+
+	ev := domain.ConnectionEvent{
+		Type:     domain.ConnectionReconnected,
+		TargetID: current,
+		Attempts: attempt,
+		Resumed:  resumed,
+		Orphaned: len(paused) - resumed,
+	}
+	if current != target {
+		ev.PreviousTarget = target
+	}
+	o.notifyConnection(state, ev)
+}
+
+// reattach 重新附着目标；原目标已不存在时改为附着一个尚未附着、URL 相同的页面，
+// 并以它替换会话中的原目标，原目标的地理位置与网络条件覆盖随之转移
+func (o *Orchestrator) reattach(state *sessionState, target domain.TargetID, url string) (domain.TargetID, error) {
+	err := o.AttachTarget(state.ctx, state.id, target)
+	if !errors.Is(err, domain.ErrTargetNotFound) || url == "" {
+		return target, err
+	}
+	infos, listErr := state.clientMgr.ListTargets(state.ctx)
+	if listErr != nil {
+		return target, err
+	}
+	for _, info := range infos {
+		if info.IsCurrent || info.URL != url || state.sess.HasTarget(info.ID) {
+			continue
+		}
+		// 覆盖在附着时生效，需先转移
+		state.mu.Lock()
+		if loc, ok := state.geoOverrides[target]; ok {
+			state.geoOverrides[info.ID] = loc
+		}
+		if c, ok := state.netOverrides[target]; ok {
+			state.netOverrides[info.ID] = c
+		}
+		state.mu.Unlock()
+		if err := o.AttachTarget(state.ctx, state.id, info.ID); err != nil {
+			return target, err
+		}
+		state.sess.RemoveTarget(target)
+		state.mu.Lock()
+		delete(state.geoOverrides, target)
+		delete(state.netOverrides, target)
+		state.mu.Unlock()
+		o.log.Info("原目标已不存在，改为附着 URL 相同的页面", "sessionID", string(state.id), "previous", string(target), "target", string(info.ID), "url", url)
+		return info.ID, nil
+	}
+	return target, err
+}
+
+// countOrphaned 记录断开时暂停、重连后无法放行的请求
+func (o *Orchestrator) countOrphaned(state *sessionState, target domain.TargetID, orphaned int) {
+	if orphaned <= 0 {
+		return
+	}
+	state.mu.Lock()
+	state.reconnects.Orphaned += int64(orphaned)
+	state.mu.Unlock()
+	o.log.Warn("断开时暂停的请求无法放行，只能等待浏览器超时", "sessionID", string(state.id), "target", string(target), "orphaned", orphaned)
+}
+
+// reconnectPolicy 返回会话的重连策略
+func (s *sessionState) reconnectPolicy() domain.ReconnectPolicy {
+	if s.cfg.Reconnect == nil {
+		return domain.ReconnectPolicy{}
+	}
+	return *s.cfg.Reconnect
+}
+
+// jitter 在 d 的一半到全部之间随机取值，避免多个目标断开后同时重连
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d-d/2+1)
+}
+
+// SubscribeConnection 订阅目标连接事件：意外断开、重新附着成功与放弃重连。
+// 消费不及时时丢弃新事件，ctx 结束或会话停止时通道关闭
+func (o *Orchestrator) SubscribeConnection(ctx context.Context, id domain.SessionID) (<-chan domain.ConnectionEvent, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	ch := make(chan domain.ConnectionEvent, connectionBuffer)
+	state.mu.Lock()
+	state.connWatchers = append(state.connWatchers, ch)
+	state.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-state.ctx.Done():
+		}
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		for i, w := range state.connWatchers {
+			if w == ch {
+				state.connWatchers = append(state.connWatchers[:i], state.connWatchers[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// notifyConnection 向订阅者推送连接事件
+func (o *Orchestrator) notifyConnection(state *sessionState, ev domain.ConnectionEvent) {
+	ev.Timestamp = time.Now().UnixMilli()
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	for _, ch := range state.connWatchers {
+		select {
+		case ch <- ev:
+		default:
+			o.log.Warn("连接事件订阅者消费不及时，丢弃事件", "sessionID", string(state.id), "type", string(ev.Type))
+		}
+	}
+}
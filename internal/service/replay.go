@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+
+	"cdpnetool/internal/adapter/cdp"
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+
+	"github.com/google/uuid"
+	"github.com/mafredri/cdp/protocol/runtime"
+)
+
+// replayClient 以 native 方式重放请求的 HTTP 客户端，不跟随重定向以便记录服务端的原始响应
+var replayClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// replayScript 在页面中以 fetch 发出请求，响应体以 base64 返回以保留二进制内容
+const replayScript = `(async (req) => {
+	const init = { method: req.method, headers: req.headers, credentials: 'include' };
+	if (req.body && req.method !== 'GET' && req.method !== 'HEAD') {
+		init.body = Uint8Array.from(atob(req.body), (c) => c.charCodeAt(0));
+	}
+	const res = await fetch(req.url, init);
+	const buf = new Uint8Array(await res.arrayBuffer());
+	let bin = '';
+	for (let i = 0; i < buf.length; i += 0x8000) {
+		bin += String.fromCharCode.apply(null, buf.subarray(i, i + 0x8000));
+	}
+	return { status: res.status, headers: Object.fromEntries(res.headers), body: btoa(bin) };
+})(%s)`
+
+// replayPayload 传给 replayScript 的请求
+type replayPayload struct {
+	URL     string        `json:"url"`
+	Method  string        `json:"method"`
+	Headers domain.Header `json:"headers"`
+	Body    []byte        `json:"body,omitempty"`
+}
+
+// replayReply replayScript 返回的响应
+type replayReply struct {
+	Status  int           `json:"status"`
+	Headers domain.Header `json:"headers"`
+	Body    []byte        `json:"body"`
+}
+
+// Replay 重新发出会话中记录的请求，opts.Request 非空时发出修改后的请求；
+// 响应作为 FinalResult 为 replayed 的新事件记录到事件流并返回
+func (o *Orchestrator) Replay(ctx context.Context, id domain.SessionID, opts domain.ReplayOptions) (domain.NetworkEvent, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.NetworkEvent{}, domain.ErrSessionNotFound
+	}
+
+	target := opts.Target
+	var req domain.Request
+	if opts.EventID != "" {
+		evt, ok := state.bus.Find(opts.EventID)
+		if !ok {
+			return domain.NetworkEvent{}, domain.ErrEventNotFound
+		}
+		req = evt.Request
+		if target == "" {
+			target = evt.Target
+		}
+	}
+	if opts.Request != nil {
+		req = *opts.Request
+	}
+	if req.URL == "" {
+		return domain.NetworkEvent{}, fmt.Errorf("%w: replay needs an event ID or a request with a URL", domain.ErrInvalidConfig)
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	req.ID = "replay-" + uuid.NewString()
+	req.Secrets = nil
+	req.Headers = maps.Clone(req.Headers)
+
+	start := time.Now()
+	var (
+		resp *domain.Response
+		err  error
+	)
+	switch opts.Mode {
+	case domain.ReplayPage, "":
+		var ts *cdp.TargetSession
+		if ts, err = o.targetSession(id, target); err != nil {
+			return domain.NetworkEvent{}, err
+		}
+		resp, err = replayInPage(ctx, ts, &req)
+	case domain.ReplayNative:
+		resp, err = replayNative(ctx, &req, state.cfg.MaxDecodedSize)
+	default:
+		return domain.NetworkEvent{}, fmt.Errorf("%w: unknown replay mode %q", domain.ErrInvalidConfig, opts.Mode)
+	}
+	if err != nil {
+		o.log.Warn("重放请求失败", "sessionID", string(id), "url", req.URL, "mode", opts.Mode, "error", err)
+		return domain.NetworkEvent{}, err
+	}
+	resp.Timing.StartTime, resp.Timing.EndTime = start.UnixMilli(), time.Now().UnixMilli()
+
+	// 页面方式的请求同时经过拦截流程，这里记录的是页面最终收到的响应
+	state.trafficAuditor.Record(string(id), string(target), &req, resp, domain.ReplayResult, nil)
+	state.matchedAuditor.Record(string(id), string(target), &req, resp, domain.ReplayResult, nil)
+	o.log.Info("重放请求", "sessionID", string(id), "eventID", opts.EventID, "url", req.URL, "mode", opts.Mode, "status", resp.StatusCode)
+	return domain.NetworkEvent{
+		ID:          req.ID,
+		Session:     id,
+		Target:      target,
+		Timestamp:   time.Now().UnixMilli(),
+		Request:     req,
+		Response:    resp,
+		FinalResult: domain.ReplayResult,
+	}, nil
+}
+
+// replayInPage 在目标页面中以 fetch 发出请求，浏览器禁止脚本设置的请求头（Cookie、Host 等）会被忽略
+func replayInPage(ctx context.Context, ts *cdp.TargetSession, req *domain.Request) (*domain.Response, error) {
+	payload, err := json.Marshal(replayPayload{URL: req.URL, Method: req.Method, Headers: pageHeaders(req.Headers), Body: req.Body})
+	if err != nil {
+		return nil, err
+	}
+	args := runtime.NewEvaluateArgs(fmt.Sprintf(replayScript, payload)).SetAwaitPromise(true).SetReturnByValue(true)
+	reply, err := ts.Client.Runtime.Evaluate(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate replay script: %w", err)
+	}
+	if reply.ExceptionDetails != nil {
+		return nil, fmt.Errorf("replay fetch in page: %w", reply.ExceptionDetails)
+	}
+	var out replayReply
+	if err := json.Unmarshal(reply.Result.Value, &out); err != nil {
+		return nil, fmt.Errorf("decode replay result: %w", err)
+	}
+	if out.Headers == nil {
+		out.Headers = domain.Header{}
+	}
+	return &domain.Response{StatusCode: out.Status, Headers: out.Headers, Body: out.Body}, nil
+}
+
+// replayNative 以 HTTP 客户端直接发出请求，压缩的响应体按 limit 解压后记录
+func replayNative(ctx context.Context, req *domain.Request, limit int64) (*domain.Response, error) {
+	hreq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+	}
+	for k, v := range req.Headers {
+		switch {
+		case strings.HasPrefix(k, ":"), strings.EqualFold(k, "Content-Length"):
+			// HTTP/2 伪头部与长度由客户端生成
+		case strings.EqualFold(k, "Host"):
+			hreq.Host = v
+		default:
+			hreq.Header.Set(k, v)
+		}
+	}
+
+	hres, err := replayClient.Do(hreq)
+	if err != nil {
+		return nil, err
+	}
+	defer hres.Body.Close()
+	body, err := io.ReadAll(hres.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read replay response: %w", err)
+	}
+
+	headers := make(domain.Header, len(hres.Header))
+	for k, v := range hres.Header {
+		headers[k] = strings.Join(v, ", ")
+	}
+	if enc := transformer.EncodingFor(headers.Get("Content-Encoding")); enc != transformer.EncodingNone {
+		if decoded, err := transformer.DecodeContent(body, enc, limit); err == nil {
+			body = decoded
+		}
+	}
+	return &domain.Response{StatusCode: hres.StatusCode, Headers: headers, Body: body}, nil
+}
+
+// pageHeaders 去掉页面 fetch 不能设置的 HTTP/2 伪头部
+func pageHeaders(h domain.Header) domain.Header {
+	out := make(domain.Header, len(h))
+	for k, v := range h {
+		if !strings.HasPrefix(k, ":") {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"cdpnetool/internal/adapter/cdp"
+	"cdpnetool/internal/processor"
+	"cdpnetool/internal/replaycache"
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/protocol/network"
+)
+
+// actionReplayCache 决策日志中由录制回放缓存应答或拒绝的请求的处理动作
+const actionReplayCache processor.Action = "replayCache"
+
+// maxReplayPending 等待录制响应的请求数上限，超出时整体清空
+const maxReplayPending = 4096
+
+// replayCache 会话的录制回放缓存，字段由 sessionState.mu 保护
+type replayCache struct {
+	opts    domain.ReplayCacheOptions
+	store   *replaycache.Store
+	pending map[fetch.RequestID]string // 等待录制响应的请求：请求 ID -> 请求阶段计算的匹配键
+	hits    int64
+	misses  int64
+	stored  int64
+}
+
+// SetReplayMode 设置会话的录制回放缓存：record 录制浏览器最终收到的响应，replay 以录制的响应应答命中的请求、
+// 未命中时照常发出并录制，offline 未命中时以网络错误结束请求；Mode 为空时关闭，已录制的响应保留。
+// 命中的请求不再经过规则与断点；保存目录变化时改用新目录中的记录。只读会话只允许录制
+func (o *Orchestrator) SetReplayMode(ctx context.Context, id domain.SessionID, opts domain.ReplayCacheOptions) (domain.ReplayCacheStatus, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ReplayCacheStatus{}, domain.ErrSessionNotFound
+	}
+	if err := opts.Validate(); err != nil {
+		return domain.ReplayCacheStatus{}, err
+	}
+	if state.cfg.ReadOnly && opts.Serves() {
+		// 以录制的响应应答会改变流量，只读会话不允许
+		return domain.ReplayCacheStatus{}, domain.ErrSessionReadOnly
+	}
+
+	state.mu.Lock()
+	var store *replaycache.Store
+	if state.replay != nil && state.replay.store.Dir() == opts.Dir {
+		store = state.replay.store
+	}
+	state.mu.Unlock()
+	if store == nil {
+		var err error
+		if store, err = replaycache.Open(opts.Dir); err != nil {
+			return domain.ReplayCacheStatus{}, fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+		}
+	}
+
+	state.mu.Lock()
+	if state.replay == nil || state.replay.store != store {
+		state.replay = &replayCache{store: store, pending: make(map[fetch.RequestID]string)}
+	}
+	state.replay.opts = opts
+	status := state.replay.status()
+	state.mu.Unlock()
+
+	if err := o.updatePhysicalInterception(ctx, state); err != nil {
+		return domain.ReplayCacheStatus{}, err
+	}
+	o.log.Info("设置录制回放缓存", "sessionID", string(id), "mode", string(opts.Mode), "dir", opts.Dir, "entries", status.Entries)
+	return status, nil
+}
+
+// GetReplayStatus 获取录制回放缓存的配置与命中统计，未设置过时返回空状态
+func (o *Orchestrator) GetReplayStatus(ctx context.Context, id domain.SessionID) (domain.ReplayCacheStatus, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ReplayCacheStatus{}, domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.replay == nil {
+		return domain.ReplayCacheStatus{}, nil
+	}
+	return state.replay.status(), nil
+}
+
+// status 汇总缓存状态，调用方需持有 sessionState.mu
+func (c *replayCache) status() domain.ReplayCacheStatus {
+	return domain.ReplayCacheStatus{
+		Options: c.opts,
+		Entries: c.store.Len(),
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Stored:  c.stored,
+	}
+}
+
+// replayActiveLocked 判断录制回放缓存是否开启，调用方需持有 s.mu
+func (s *sessionState) replayActiveLocked() bool {
+	return s.replay != nil && s.replay.opts.Mode != domain.ReplayCacheOff
+}
+
+// replayRequest 录制回放缓存开启时处理请求阶段的暂停事件：命中记录时以录制的响应应答，
+// offline 方式未命中时以网络错误结束，均返回 true；否则登记匹配键以便在响应阶段录制并返回 false。
+// 演练模式与只读会话不以录制的响应应答
+func (o *Orchestrator) replayRequest(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply, req *domain.Request) bool {
+	state.mu.Lock()
+	if !state.replayActiveLocked() {
+		state.mu.Unlock()
+		return false
+	}
+	c := state.replay
+	opts, store := c.opts, c.store
+	state.mu.Unlock()
+
+	key := replaycache.Key(req, opts)
+	if opts.Serves() && !state.isDryRun() && !state.cfg.ReadOnly {
+		if e, ok := store.Get(key, opts.TTL(), time.Now()); ok {
+			if o.fulfillFromReplay(state, ts, ev, e) {
+				state.mu.Lock()
+				c.hits++
+				state.mu.Unlock()
+				return true
+			}
+		} else {
+			state.mu.Lock()
+			c.misses++
+			state.mu.Unlock()
+			if opts.Mode == domain.ReplayCacheOffline {
+				o.failReplayMiss(state, ts, ev)
+				return true
+			}
+		}
+	}
+
+	state.mu.Lock()
+	// 仅用于在响应阶段找回匹配键，超出上限时整体清空
+	if len(c.pending) >= maxReplayPending {
+		clear(c.pending)
+	}
+	c.pending[ev.RequestID] = key
+	state.mu.Unlock()
+	return false
+}
+
+// fulfillFromReplay 以录制的响应应答请求，失败时返回 false 以便按规则正常处理
+func (o *Orchestrator) fulfillFromReplay(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply, e *replaycache.Entry) bool {
+	res := e.Response()
+	err := ts.Client.Fetch.FulfillRequest(state.ctx, &fetch.FulfillRequestArgs{
+		RequestID:       ev.RequestID,
+		ResponseCode:    res.StatusCode,
+		ResponseHeaders: cdp.ToHeaderEntries(res.Headers),
+		Body:            res.Body,
+	})
+	if state.journal != nil {
+		entry := decisionEntry(state, ts.ID, ev, processor.Result{Action: actionReplayCache})
+		entry.Call = "Fetch.fulfillRequest"
+		if err != nil {
+			entry.Error = err.Error()
+			entry.Degraded = true
+		}
+		state.journal.Append(entry)
+	}
+	if err != nil {
+		o.log.Err(err, "[Orchestrator] 以录制的响应应答失败，改为正常处理", "requestID", ev.RequestID)
+		return false
+	}
+	o.log.Debug("[Orchestrator] 以录制的响应应答", "requestID", ev.RequestID, "url", ev.Request.URL, "recordedAt", e.StoredAt)
+	return true
+}
+
+// failReplayMiss 离线回放未命中录制时以网络错误结束请求，失败时降级放行
+func (o *Orchestrator) failReplayMiss(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply) {
+	err := ts.Client.Fetch.FailRequest(state.ctx, &fetch.FailRequestArgs{RequestID: ev.RequestID, ErrorReason: network.ErrorReasonInternetDisconnected})
+	var entry domain.DecisionEntry
+	if state.journal != nil {
+		entry = decisionEntry(state, ts.ID, ev, processor.Result{Action: actionReplayCache})
+		entry.Call = "Fetch.failRequest"
+	}
+	if err != nil {
+		o.log.Err(err, "[Orchestrator] 结束未命中录制的请求失败，降级放行", "requestID", ev.RequestID)
+		entry.Error = err.Error()
+		entry.Degraded = true
+		_ = state.interceptor.ContinueRequest(state.ctx, ts.Client, ev.RequestID)
+	} else {
+		o.log.Debug("[Orchestrator] 离线回放未命中录制，请求以网络错误结束", "requestID", ev.RequestID, "url", ev.Request.URL)
+	}
+	if state.journal != nil {
+		state.journal.Append(entry)
+	}
+}
+
+// replayPending 判断该请求是否在等待录制响应
+func (s *sessionState) replayPending(id fetch.RequestID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.replay == nil {
+		return false
+	}
+	_, ok := s.replay.pending[id]
+	return ok
+}
+
+// forgetReplay 请求未得到可录制的响应（被规则拦截、以网络错误结束），不再等待录制
+func (s *sessionState) forgetReplay(id fetch.RequestID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.replay != nil {
+		delete(s.replay.pending, id)
+	}
+}
+
+// recordReplay 录制浏览器最终收到的响应，录制回放缓存已关闭或请求未登记时不做任何事
+func (o *Orchestrator) recordReplay(state *sessionState, ev *fetch.RequestPausedReply, res *domain.Response) {
+	state.mu.Lock()
+	c := state.replay
+	if c == nil {
+		state.mu.Unlock()
+		return
+	}
+	key, ok := c.pending[ev.RequestID]
+	delete(c.pending, ev.RequestID)
+	if !ok || !state.replayActiveLocked() || res == nil {
+		state.mu.Unlock()
+		return
+	}
+	store := c.store
+	c.stored++
+	state.mu.Unlock()
+
+	rec := replayResponse(res)
+	e := &replaycache.Entry{
+		Method:     ev.Request.Method,
+		URL:        ev.Request.URL,
+		StatusCode: rec.StatusCode,
+		Headers:    rec.Headers,
+		Body:       rec.Body,
+		StoredAt:   time.Now(),
+	}
+	if err := store.Put(key, e); err != nil {
+		o.log.Warn("保存录制的响应失败", "sessionID", string(state.id), "url", ev.Request.URL, "error", err)
+	}
+}
+
+// replayResponse 返回用于录制的响应副本：压缩的响应体解压后保存并移除 Content-Encoding，
+// 响应体已是明文时同样移除该头部，去掉与原始连接相关的头部并按实际长度更新 Content-Length
+func replayResponse(res *domain.Response) *domain.Response {
+	rec := &domain.Response{StatusCode: res.StatusCode, Headers: maps.Clone(res.Headers), Body: res.Body}
+	if rec.Headers == nil {
+		rec.Headers = make(domain.Header)
+	}
+	for _, name := range []string{"Transfer-Encoding", "Connection", "Keep-Alive"} {
+		if key := headerKey(rec.Headers, name); key != "" {
+			rec.Headers.Del(key)
+		}
+	}
+	if key := headerKey(rec.Headers, "Content-Encoding"); key != "" {
+		// 叠加或不受支持的编码无法判断响应体是否已解压，保持原样
+		if enc := transformer.EncodingFor(rec.Headers[key]); enc != transformer.EncodingNone {
+			if decoded, err := transformer.DecodeContent(rec.Body, enc, 0); err == nil {
+				rec.Body = decoded
+			}
+			rec.Headers.Del(key)
+		}
+	}
+	setContentLength(rec)
+	return rec
+}
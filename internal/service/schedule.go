@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"cdpnetool/internal/engine"
+	"cdpnetool/internal/processor"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// actionSwitchRules 决策日志中按定时计划切换规则集的记录动作
+const actionSwitchRules processor.Action = "switchRules"
+
+// ruleSchedule 会话的规则集定时切换状态
+type ruleSchedule struct {
+	plan     *rulespec.Schedule
+	applied  bool   // 是否已应用过规则集
+	window   int    // 当前所在的窗口序号，-1 表示默认规则集
+	configID string // 当前生效的规则集 ID
+	next     time.Time
+	timer    *time.Timer // 到达下一个窗口边界时重新检查的定时器
+}
+
+// SetRuleSchedule 设置规则集定时切换计划并立即应用当前时间生效的规则集，之后在窗口边界自动切换并记录；
+// schedule 为 nil 时取消计划，保留当前生效的规则。计划生效期间手动加载的规则在下一个窗口边界被计划覆盖
+func (o *Orchestrator) SetRuleSchedule(ctx context.Context, id domain.SessionID, schedule *rulespec.Schedule) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	if schedule != nil {
+		if err := schedule.Validate(); err != nil {
+			return err
+		}
+		for _, cfg := range schedule.Configs() {
+			if err := rulespec.CheckCapabilities(state.cfg.CapabilityProfile, cfg.Rules); err != nil {
+				return err
+			}
+			if err := engine.Validate(cfg); err != nil {
+				return err
+			}
+		}
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.schedule != nil {
+		state.schedule.timer.Stop()
+		state.schedule = nil
+	}
+	if schedule == nil {
+		o.log.Info("已取消规则集定时计划", "sessionID", string(id))
+		return nil
+	}
+	state.schedule = &ruleSchedule{plan: schedule}
+	o.applyScheduleLocked(state, time.Now())
+	return nil
+}
+
+// GetRuleSchedule 获取规则集定时切换计划的状态与会话内的切换记录
+func (o *Orchestrator) GetRuleSchedule(ctx context.Context, id domain.SessionID) (domain.ScheduleStatus, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ScheduleStatus{}, domain.ErrSessionNotFound
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	status := domain.ScheduleStatus{
+		Switches: append([]domain.RuleSwitch{}, state.ruleSwitches...),
+	}
+	if sch := state.schedule; sch != nil {
+		status.Scheduled = true
+		status.ActiveWindow = windowName(sch.plan, sch.window)
+		status.ActiveConfig = sch.configID
+		status.NextSwitch = sch.next.UnixMilli()
+	}
+	return status, nil
+}
+
+// applyScheduleLocked 应用 now 时生效的规则集，窗口发生变化时切换并记录，然后等待下一个窗口边界，调用方需持有 state.mu
+func (o *Orchestrator) applyScheduleLocked(state *sessionState, now time.Time) {
+	sch := state.schedule
+	window, cfg := sch.plan.Active(now)
+	if !sch.applied || window != sch.window {
+		// 设置计划时已校验各规则集，不会失败
+		_ = state.engine.Update(cfg)
+		state.sess.UpdateConfig(cfg)
+		state.setScopeLocked(cfg.Rules)
+		// 调用方持有 state.mu，在锁外按新规则集的拦截范围重新启用拦截
+		go o.refreshScope(state.ctx, state)
+		sch.applied, sch.window, sch.configID = true, window, cfg.ID
+
+		sw := domain.RuleSwitch{
+			Time:       now.UnixMilli(),
+			Window:     windowName(sch.plan, window),
+			ConfigID:   cfg.ID,
+			ConfigName: cfg.Name,
+		}
+		state.ruleSwitches = append(state.ruleSwitches, sw)
+		o.log.Info("按定时计划切换规则集", "sessionID", string(state.id), "window", sw.Window, "configID", cfg.ID, "configName", cfg.Name)
+		if state.journal != nil {
+			state.journal.Append(domain.DecisionEntry{
+				Time:    sw.Time,
+				Session: state.id,
+				Action:  string(actionSwitchRules),
+				Config:  cfg.ID,
+			})
+		}
+	}
+
+	sch.next = sch.plan.NextBoundary(now)
+	sch.timer = time.AfterFunc(time.Until(sch.next), func() {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		// 计划已被替换或取消、会话已停止时不再切换
+		if state.schedule != sch || state.ctx.Err() != nil {
+			return
+		}
+		o.applyScheduleLocked(state, time.Now())
+	})
+}
+
+// stopSchedule 停止定时计划的定时器，会话停止时调用
+func (s *sessionState) stopSchedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.schedule != nil {
+		s.schedule.timer.Stop()
+	}
+}
+
+// windowName 返回窗口名称，未命名时使用时间范围，-1 表示默认规则集返回空
+func windowName(plan *rulespec.Schedule, window int) string {
+	if window < 0 {
+		return ""
+	}
+	w := plan.Windows[window]
+	if w.Name != "" {
+		return w.Name
+	}
+	return w.Start + "-" + w.End
+}
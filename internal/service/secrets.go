@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"cdpnetool/internal/secrets"
+	"cdpnetool/pkg/domain"
+)
+
+// SetSecretDetectors 设置会话启用的敏感信息检测器，detectors 为空时关闭检测；
+// 检测到敏感信息的请求与响应记录到事件历史
+func (o *Orchestrator) SetSecretDetectors(ctx context.Context, id domain.SessionID, detectors []string) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+
+	scanner, err := secrets.New(detectors)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+	}
+	state.mu.Lock()
+	state.secrets = scanner
+	state.mu.Unlock()
+	state.processor.SetSecretScanner(scanner)
+
+	if err := o.updatePhysicalInterception(ctx, state); err != nil {
+		return err
+	}
+	o.log.Info("更新敏感信息检测器", "sessionID", string(id), "detectors", scanner.Names())
+	return nil
+}
+
+// GetSecretDetectors 获取会话启用的敏感信息检测器，未开启时返回空列表
+func (o *Orchestrator) GetSecretDetectors(ctx context.Context, id domain.SessionID) ([]string, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.secrets.Names(), nil
+}
@@ -3,25 +3,47 @@ package service
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"cdpnetool/internal/adapter/cdp"
 	"cdpnetool/internal/auditor"
+	"cdpnetool/internal/bench"
+	"cdpnetool/internal/contract"
 	"cdpnetool/internal/engine"
+	"cdpnetool/internal/eventbus"
+	"cdpnetool/internal/eventstream"
+	"cdpnetool/internal/grpcweb"
+	"cdpnetool/internal/journal"
 	"cdpnetool/internal/logger"
+	"cdpnetool/internal/mirror"
 	"cdpnetool/internal/pool"
 	"cdpnetool/internal/processor"
+	"cdpnetool/internal/redact"
+	"cdpnetool/internal/report"
+	"cdpnetool/internal/ruletest"
+	"cdpnetool/internal/saver"
+	"cdpnetool/internal/secrets"
 	"cdpnetool/internal/session"
+	"cdpnetool/internal/timing"
 	"cdpnetool/internal/tracker"
+	"cdpnetool/internal/transformer"
 	"cdpnetool/pkg/domain"
 	"cdpnetool/pkg/rulespec"
 
 	"github.com/google/uuid"
 	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/protocol/network"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxAuthAttempts 记录已提供凭据的请求数上限
+const maxAuthAttempts = 1024
+
 // sessionState 维护单个会话的所有新架构组件
 type sessionState struct {
 	id                  domain.SessionID
@@ -29,24 +51,61 @@ type sessionState struct {
 	sess                *session.Session
 	clientMgr           *cdp.ClientManager
 	interceptor         *cdp.Interceptor
+	resources           *cdp.ResourceFilter // 会话的资源类型筛选，为 nil 时拦截所有类型
 	engine              *engine.Engine
 	tracker             *tracker.Tracker
 	matchedAuditor      *auditor.Auditor
 	trafficAuditor      *auditor.Auditor
 	processor           *processor.Processor
+	mirror              *mirror.Mirror
 	events              chan domain.NetworkEvent
+	bus                 *eventbus.Bus // 为匹配事件编号并缓存，支持订阅者从指定序号回放
 	trafficEvs          chan domain.NetworkEvent
 	workPool            *pool.Pool
 	ctx                 context.Context
 	cancel              context.CancelFunc
 	interceptionEnabled bool
+	geoOverrides        map[domain.TargetID]*domain.GeoLocation       // 目标级地理位置覆盖，优先于 cfg.Geolocation
+	netOverrides        map[domain.TargetID]*domain.NetworkConditions // 目标级网络条件模拟，优先于 cfg.NetworkConditions
+	startedAt           time.Time
+	proxyAuth           *domain.ProxyCredentials              // 上游代理认证凭据，非空时接管代理认证质询
+	authAttempts        map[fetch.RequestID]time.Time         // 已提供过凭据的请求及提供时间，再次质询说明凭据无效
+	authRules           bool                                  // 已启用的规则中是否包含 auth 行为
+	challenges          map[fetch.RequestID]*pendingChallenge // 等待人工处理的认证质询
+	authWatchers        []chan []domain.AuthChallenge         // 认证质询订阅者，每次变化推送等待中的质询
+	trafficCapture      bool                                  // 用户是否开启了全量流量捕获
+	har                 *harExport                            // 持续 HAR 导出，为 nil 表示未在导出
+	contract            *contract.Spec                        // OpenAPI 契约检查使用的规范，为 nil 表示未开启
+	secrets             *secrets.Scanner                      // 敏感信息扫描器，为 nil 表示未开启
+	redactor            *redact.Redactor                      // 导出前的脱敏器，为 nil 表示不脱敏
+	reconnects          domain.ReconnectStats                 // 目标连接意外断开后的重连统计
+	breakpoint          *domain.BreakpointFilter              // 已布置的一次性断点，为 nil 表示未布置
+	held                map[fetch.RequestID]*heldRequest      // 被断点暂停、等待人工处理的请求
+	bpWatchers          []chan domain.BreakpointStatus        // 断点状态订阅者，每次变化推送最新状态
+	connWatchers        []chan domain.ConnectionEvent         // 目标连接事件订阅者
+	journal             *journal.Journal                      // 拦截决策日志，未开启时为 nil
+	coalesce            map[string]*coalesceGroup             // 请求合并窗口内的进行中请求组：请求指纹 -> 合并组
+	coalesceLeaders     map[fetch.RequestID]*coalesceGroup    // 等待响应的合并组：首个请求 ID -> 合并组
+	timing              *timing.Collector                     // 网络阶段计时采集器，未开启时为 nil
+	shaper              *shaper                               // 节流状态：会话带宽上限的模拟链路与节流统计
+	schedule            *ruleSchedule                         // 规则集定时切换计划，为 nil 表示未设置
+	ruleSwitches        []domain.RuleSwitch                   // 按定时计划进行的规则集切换记录
+	rulesWatch          *rulesWatch                           // 规则文件监听，为 nil 表示未监听
+	dryRun              bool                                  // 演练模式：规则只记录结果，流量原样放行
+	replay              *replayCache                          // 录制回放缓存，为 nil 表示未设置过
+	scope               []domain.InterceptPattern             // 按当前规则推导的拦截范围
+	scoped              bool                                  // 拦截范围是否已按规则收窄，为 false 时拦截所有请求
 	mu                  sync.Mutex
+
+	// traces 开启追踪时各请求最近一次处理暂停事件的 span，超出 maxTraces 时整体清空，由 mu 保护
+	traces map[fetch.RequestID]trace.SpanContext
 }
 
 // Orchestrator 新架构业务编排器
 type Orchestrator struct {
 	mu       sync.RWMutex
 	sessions map[domain.SessionID]*sessionState
+	finished map[domain.SessionID]domain.SessionSummary // 已结束会话的最终汇总（覆盖报告、流量统计）
 	log      logger.Logger
 }
 
@@ -57,18 +116,90 @@ func New(l logger.Logger) *Orchestrator {
 	}
 	return &Orchestrator{
 		sessions: make(map[domain.SessionID]*sessionState),
+		finished: make(map[domain.SessionID]domain.SessionSummary),
 		log:      l,
 	}
 }
 
 // StartSession 创建并启动一个新的拦截会话
 func (o *Orchestrator) StartSession(ctx context.Context, cfg domain.SessionConfig) (domain.SessionID, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if cfg.Geolocation != nil {
+		if err := cfg.Geolocation.Validate(); err != nil {
+			return "", err
+		}
+	}
+	if cfg.NetworkConditions != nil {
+		if err := cfg.NetworkConditions.Validate(); err != nil {
+			return "", err
+		}
+	}
+	if cfg.CorrelationHeader != "" {
+		if err := domain.ValidateHeaderName(cfg.CorrelationHeader); err != nil {
+			return "", err
+		}
+	}
+	var connOpts domain.ConnectionOptions
+	if cfg.Connection != nil {
+		if err := cfg.Connection.Validate(); err != nil {
+			return "", err
+		}
+		connOpts = *cfg.Connection
+	}
+	if cfg.Reconnect != nil {
+		if err := cfg.Reconnect.Validate(); err != nil {
+			return "", err
+		}
+	}
+	if err := domain.ValidateResourceTypes(cfg.IncludeResourceTypes); err != nil {
+		return "", err
+	}
+	if err := domain.ValidateResourceTypes(cfg.ExcludeResourceTypes); err != nil {
+		return "", err
+	}
+	profile, err := domain.ParseCapabilityProfile(string(cfg.CapabilityProfile))
+	if err != nil {
+		return "", err
+	}
+	cfg.CapabilityProfile = profile
+	if cfg.ReadOnly {
+		// 只读会话忽略所有会改变请求或响应的配置
+		cfg.HostMappings = nil
+		cfg.CorrelationHeader = ""
+		cfg.UserAgent = ""
+		cfg.CoalesceWindowMS = 0
+	}
+	grpcDecoder, err := grpcweb.LoadDecoder(cfg.GRPCDescriptorSet)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+	}
+	scanner, err := secrets.New(cfg.SecretDetectors)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+	}
+	var redactor *redact.Redactor
+	if cfg.Redaction != nil {
+		if redactor, err = redact.New(*cfg.Redaction); err != nil {
+			return "", fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+		}
+	}
+
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
 	id := domain.SessionID(fmt.Sprintf("sess_%s", uuid.New().String()[:8]))
 
-	sessionCtx, cancel := context.WithCancel(ctx)
+	// 会话生命周期独立于调用方：ctx 仅约束本次启动过程（如连通性检测），
+	// 会话本身由 StopSession 结束
+	sessionCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	jrn, err := openJournal(cfg.JournalDir, id, o.log)
+	if err != nil {
+		cancel()
+		return "", fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+	}
 
 	// 初始化会话级基础设施
 	workPool := pool.New(cfg.Concurrency, cfg.PendingCapacity)
@@ -81,43 +212,96 @@ func (o *Orchestrator) StartSession(ctx context.Context, cfg domain.SessionConfi
 	eng := engine.New(&rulespec.Config{})
 	matchedAud := auditor.New(events, o.log)
 	trafficAud := auditor.NewDisabled(trafficChan, o.log)
+	trafficAud.SetUnmatchedSampling(cfg.UnmatchedSampling)
 	trk := tracker.New(time.Duration(cfg.ProcessTimeoutMS)*time.Millisecond, o.log)
 	proc := processor.New(trk, eng, matchedAud, trafficAud, o.log)
+	proc.SetReadOnly(cfg.ReadOnly)
+	proc.SetCapabilityProfile(cfg.CapabilityProfile)
+	proc.SetHostMappings(cfg.HostMappings)
+	proc.SetCorrelationHeader(cfg.CorrelationHeader)
+	mir := mirror.New(o.log)
+	proc.SetMirror(mir)
+	proc.SetSaver(saver.New(o.log))
+	proc.SetGRPCDecoder(grpcDecoder)
+	proc.SetSecretScanner(scanner)
+	var timings *timing.Collector
+	if cfg.CaptureTiming {
+		timings = timing.New(timing.DefaultWait)
+		matchedAud.SetTiming(timings)
+		trafficAud.SetTiming(timings)
+	}
 
-	clientMgr := cdp.NewClientManager(cfg.DevToolsURL, o.log)
+	clientMgr, err := cdp.NewClientManager(cfg.DevToolsURL, connOpts, o.log)
+	if err != nil {
+		cancel()
+		workPool.Stop()
+		mir.Close()
+		_ = jrn.Close()
+		return "", err
+	}
 
 	// 验证连通性
-	if err := clientMgr.TestConnection(sessionCtx); err != nil {
+	if err := clientMgr.TestConnection(ctx); err != nil {
 		cancel()
 		workPool.Stop()
+		mir.Close()
+		_ = jrn.Close()
 		o.log.Err(err, "连接浏览器失败", "url", cfg.DevToolsURL)
-		return "", fmt.Errorf("无法连接到浏览器: %w", err)
+		return "", fmt.Errorf("%w: %w", domain.ErrDevToolsUnreachable, err)
 	}
 
 	intr := cdp.NewInterceptor(o.log, workPool)
+	resources := cdp.NewResourceFilter(cfg.IncludeResourceTypes, cfg.ExcludeResourceTypes)
+	intr.SetResourceFilter(resources)
 
 	sess := session.New(id)
+	bus := eventbus.New(eventbus.DefaultBufferSize)
+	go bus.Pump(sessionCtx, events)
 
 	state := &sessionState{
-		id:             id,
-		cfg:            cfg,
-		sess:           sess,
-		clientMgr:      clientMgr,
-		interceptor:    intr,
-		engine:         eng,
-		tracker:        trk,
-		matchedAuditor: matchedAud,
-		trafficAuditor: trafficAud,
-		processor:      proc,
-		events:         events,
-		trafficEvs:     trafficChan,
-		workPool:       workPool,
-		ctx:            sessionCtx,
-		cancel:         cancel,
+		id:              id,
+		cfg:             cfg,
+		sess:            sess,
+		clientMgr:       clientMgr,
+		interceptor:     intr,
+		resources:       resources,
+		engine:          eng,
+		tracker:         trk,
+		matchedAuditor:  matchedAud,
+		trafficAuditor:  trafficAud,
+		processor:       proc,
+		mirror:          mir,
+		events:          events,
+		bus:             bus,
+		trafficEvs:      trafficChan,
+		workPool:        workPool,
+		ctx:             sessionCtx,
+		cancel:          cancel,
+		geoOverrides:    make(map[domain.TargetID]*domain.GeoLocation),
+		netOverrides:    make(map[domain.TargetID]*domain.NetworkConditions),
+		startedAt:       time.Now(),
+		proxyAuth:       cfg.ProxyAuth,
+		authAttempts:    make(map[fetch.RequestID]time.Time),
+		challenges:      make(map[fetch.RequestID]*pendingChallenge),
+		held:            make(map[fetch.RequestID]*heldRequest),
+		journal:         jrn,
+		coalesce:        make(map[string]*coalesceGroup),
+		coalesceLeaders: make(map[fetch.RequestID]*coalesceGroup),
+		timing:          timings,
+		shaper:          newShaper(cfg.BandwidthLimit),
+		secrets:         scanner,
+		redactor:        redactor,
+	}
+
+	if jrn != nil {
+		intr.SetDegradeHook(func(ev *fetch.RequestPausedReply, reason string, err error) {
+			journalDegraded(state, "", ev, reason, err)
+		})
+		o.log.Info("决策日志已开启", "sessionID", string(id), "path", jrn.Path())
 	}
 
 	o.sessions[id] = state
-	o.log.Info("新架构会话已启动", "sessionID", string(id), "devtools", cfg.DevToolsURL)
+	o.log.Info("新架构会话已启动", "sessionID", string(id), "devtools", cfg.DevToolsURL, "readOnly", cfg.ReadOnly)
 	return id, nil
 }
 
@@ -134,9 +318,41 @@ func (o *Orchestrator) StopSession(ctx context.Context, id domain.SessionID) err
 		return domain.ErrSessionNotFound
 	}
 
+	// 保留最终汇总，供会话结束后查询覆盖报告或生成会话报告
+	summary := state.summary()
+	summary.EndedAt = summary.GeneratedAt
+	o.mu.Lock()
+	o.finished[id] = summary
+	o.mu.Unlock()
+	o.log.Info("规则覆盖报告", "sessionID", string(id),
+		"neverMatched", len(summary.Coverage.NeverMatched),
+		"noEffect", len(summary.Coverage.NoEffect),
+		"alwaysDegraded", len(summary.Coverage.AlwaysDegraded))
+	if a := summary.Assertions; a != nil && !a.Passed {
+		for _, r := range a.Results {
+			if !r.Passed {
+				o.log.Warn("规则断言未通过", "sessionID", string(id), "assertion", r.Assertion.String(), "matched", r.Matched)
+			}
+		}
+	}
+
+	if _, err := o.stopHAR(state); err != nil {
+		o.log.Err(err, "关闭 HAR 文件失败", "sessionID", string(id))
+	}
 	state.cancel()
+	state.stopSchedule()
+	state.stopRulesWatch()
+	state.mirror.Close()
+	state.matchedAuditor.CloseStreams()
+	state.clientMgr.Close()
 	state.tracker.Stop()
 	state.workPool.Stop()
+	if state.timing != nil {
+		state.timing.Close()
+	}
+	if err := state.journal.Close(); err != nil {
+		o.log.Err(err, "关闭决策日志失败", "sessionID", string(id))
+	}
 
 	// 安全关闭 channel
 	state.mu.Lock()
@@ -168,16 +384,65 @@ func (o *Orchestrator) AttachTarget(ctx context.Context, id domain.SessionID, ta
 		return err
 	}
 
+	// 先同步订阅事件流再启用拦截，避免启用后到订阅前的暂停事件丢失
+	rp, err := state.interceptor.Subscribe(state.ctx, ts.Client)
+	if err != nil {
+		_ = state.clientMgr.DetachTarget(target)
+		return err
+	}
+	ar, err := state.interceptor.SubscribeAuth(state.ctx, ts.Client)
+	if err != nil {
+		_ = rp.Close()
+		_ = state.clientMgr.DetachTarget(target)
+		return err
+	}
+
+	if state.timing != nil {
+		if err := cdp.SubscribeTiming(state.ctx, ts.Client, state.timing, o.log); err != nil {
+			o.log.Err(err, "订阅网络计时事件失败", "target", string(target))
+		}
+	}
+
+	if err := cdp.SubscribeWebSocket(state.ctx, ts.Client, &frameSink{state: state, target: target}, o.log); err != nil {
+		o.log.Err(err, "订阅 WebSocket 事件失败", "target", string(target))
+	}
+
+	// 启用 Network 域以便按需获取暂停事件中缺失的请求体
+	if err := cdp.EnableNetwork(ctx, ts.Client); err != nil {
+		o.log.Err(err, "启用 Network 域失败", "target", string(target))
+	}
+	if state.cfg.UserAgent != "" {
+		if err := o.overrideUserAgent(ctx, ts, state.cfg.UserAgent); err != nil {
+			o.log.Err(err, "设置 User-Agent 覆盖失败", "target", string(target))
+		}
+	}
+	if loc := state.geolocation(target); loc != nil {
+		if err := cdp.OverrideGeolocation(ctx, ts.Client, loc); err != nil {
+			o.log.Err(err, "设置地理位置覆盖失败", "target", string(target))
+		}
+	}
+	if state.cacheDisabled() {
+		if err := cdp.SetCacheDisabled(ctx, ts.Client, true); err != nil {
+			o.log.Err(err, "禁用浏览器缓存失败", "target", string(target))
+		}
+	}
+	if c := state.networkConditions(target); c != nil {
+		if err := cdp.EmulateNetworkConditions(ctx, ts.Client, c); err != nil {
+			o.log.Err(err, "设置网络条件模拟失败", "target", string(target))
+		}
+	}
+
 	state.sess.AddTarget(target)
 
 	// 启动 CDP 事件监听循环
-	go state.interceptor.Consume(state.ctx, ts.Client, func(ev *fetch.RequestPausedReply) {
-		o.handleEvent(state, ts, ev)
+	go o.consume(state, ts, rp)
+	go state.interceptor.ConsumeAuth(state.ctx, ar, func(ev *fetch.AuthRequiredReply) {
+		o.handleAuth(state, ts, ev)
 	})
 
 	// 根据当前业务状态决定是否启用该 Target 的物理拦截
 	if o.shouldEnablePhysicalInterception(state) {
-		if err := state.interceptor.Enable(state.ctx, ts.Client); err != nil {
+		if err := state.interceptor.Enable(ctx, ts.Client, state.handlesAuth(), state.interceptPatterns()); err != nil {
 			o.log.Err(err, "Attach 时启用拦截失败", "target", string(target))
 		}
 	}
@@ -185,12 +450,81 @@ func (o *Orchestrator) AttachTarget(ctx context.Context, id domain.SessionID, ta
 	return nil
 }
 
+// fetchResponseBody 获取暂停在响应阶段的请求的原始响应体。会话设置了分块大小时以流方式分块读取，
+// 避免大响应体以单条 CDP 消息传输；streamed 表示响应体已以流方式取出，此后请求需以 FulfillRequest 应答或失败
+func (o *Orchestrator) fetchResponseBody(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply) (body []byte, streamed bool, err error) {
+	span := startCDPSpan(state, ev.RequestID, "Fetch.getResponseBody")
+	defer func() {
+		span.SetAttributes(attribute.Bool("cdpnetool.streamed", streamed), attribute.Int("cdpnetool.body.size", len(body)))
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	if state.cfg.BodyChunkSize > 0 {
+		body, streamed, err = cdp.ReadResponseBodyStream(state.ctx, ts.Client, ev.RequestID, state.cfg.BodyChunkSize, 3*time.Second)
+		if err == nil || streamed {
+			return body, streamed, err
+		}
+		// 未能取出响应体时浏览器仍持有原始响应体，改为一次性获取
+		o.log.Warn("以流方式读取响应体失败，改为一次性获取", "requestID", ev.RequestID, "error", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(state.ctx, 3*time.Second)
+	defer cancel()
+	rb, err := ts.Client.Fetch.GetResponseBody(ctx, &fetch.GetResponseBodyArgs{RequestID: ev.RequestID})
+	if err != nil {
+		return nil, false, err
+	}
+	// GetResponseBody 返回的是 base64 编码的字符串，需要解码为原始字节
+	if !rb.Base64Encoded {
+		return []byte(rb.Body), false, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(rb.Body)
+	if err != nil {
+		o.log.Err(err, "解码响应体失败", "requestID", ev.RequestID)
+		return []byte(rb.Body), false, nil
+	}
+	return decoded, false, nil
+}
+
+// fetchPostData 为暂停事件中缺失的请求体按需获取 POST 数据，失败时保持请求体为空继续处理
+func (o *Orchestrator) fetchPostData(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply, req *domain.Request) {
+	if ev.NetworkID == nil {
+		o.log.Warn("暂停事件缺少网络请求 ID，无法获取请求体", "requestID", ev.RequestID)
+		return
+	}
+	ctx, cancel := context.WithTimeout(state.ctx, 3*time.Second)
+	defer cancel()
+	body, err := cdp.RequestPostData(ctx, ts.Client, *ev.NetworkID)
+	if err != nil {
+		o.log.Warn("获取请求体失败", "requestID", ev.RequestID, "error", err.Error())
+		return
+	}
+	req.Body = body
+}
+
+// consume 消费目标的拦截事件，事件流意外断开时重新附着目标
+func (o *Orchestrator) consume(state *sessionState, ts *cdp.TargetSession, rp fetch.RequestPausedClient) {
+	paused, err := state.interceptor.Consume(state.ctx, ts.Client, rp, func(ev *fetch.RequestPausedReply) {
+		o.handleEvent(state, ts, ev)
+	})
+	// 会话停止或目标被主动断开时无需重连
+	if err == nil || ts.Ctx.Err() != nil || !state.sess.HasTarget(ts.ID) {
+		return
+	}
+	o.reconnect(state, ts, paused)
+}
+
 // DetachTarget 断开指定目标与会话的连接
 func (o *Orchestrator) DetachTarget(ctx context.Context, id domain.SessionID, target domain.TargetID) error {
 	state, ok := o.get(id)
 	if !ok {
 		return domain.ErrSessionNotFound
 	}
+	if !state.sess.HasTarget(target) {
+		return domain.ErrTargetNotAttached
+	}
 	state.sess.RemoveTarget(target)
 	return state.clientMgr.DetachTarget(target)
 }
@@ -223,9 +557,12 @@ func (o *Orchestrator) EnableInterception(ctx context.Context, id domain.Session
 
 	// 遍历所有已附着的 Target 物理开启拦截
 	for _, tid := range targets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		ts, ok := state.clientMgr.GetSession(tid)
 		if ok {
-			if err := state.interceptor.Enable(ctx, ts.Client); err != nil {
+			if err := state.interceptor.Enable(ctx, ts.Client, state.handlesAuth(), state.interceptPatterns()); err != nil {
 				o.log.Err(err, "物理开启拦截失败", "target", string(tid))
 			}
 		}
@@ -261,14 +598,249 @@ func (o *Orchestrator) DisableInterception(ctx context.Context, id domain.Sessio
 	return nil
 }
 
-// LoadRules 加载规则配置到指定会话
+// SetProxyAuth 设置会话的上游代理认证凭据，creds 为 nil 时不再接管代理认证质询
+func (o *Orchestrator) SetProxyAuth(ctx context.Context, id domain.SessionID, creds *domain.ProxyCredentials) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+
+	state.mu.Lock()
+	state.proxyAuth = creds
+	clear(state.authAttempts)
+	state.mu.Unlock()
+
+	// 重新启用 Fetch 以更新 handleAuthRequests，无需拦截时关闭
+	if err := o.updatePhysicalInterception(ctx, state); err != nil {
+		return err
+	}
+	o.log.Info("更新代理认证凭据", "sessionID", string(id), "enabled", creds != nil)
+	return nil
+}
+
+// SetGeolocation 设置地理位置覆盖：target 为空时作用于整个会话（含之后附着的目标），
+// 否则仅作用于该目标；loc 为 nil 时清除对应层级的覆盖
+func (o *Orchestrator) SetGeolocation(ctx context.Context, id domain.SessionID, target domain.TargetID, loc *domain.GeoLocation) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	if loc != nil {
+		if err := loc.Validate(); err != nil {
+			return err
+		}
+	}
+
+	targets := state.sess.GetTargets()
+	state.mu.Lock()
+	if target == "" {
+		state.cfg.Geolocation = loc
+	} else {
+		if !state.sess.HasTarget(target) {
+			state.mu.Unlock()
+			return domain.ErrTargetNotAttached
+		}
+		if loc == nil {
+			delete(state.geoOverrides, target)
+		} else {
+			state.geoOverrides[target] = loc
+		}
+		targets = []domain.TargetID{target}
+	}
+	state.mu.Unlock()
+
+	for _, tid := range targets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ts, ok := state.clientMgr.GetSession(tid)
+		if !ok {
+			continue
+		}
+		if err := cdp.OverrideGeolocation(ctx, ts.Client, state.geolocation(tid)); err != nil {
+			o.log.Err(err, "设置地理位置覆盖失败", "target", string(tid))
+			return err
+		}
+	}
+	return nil
+}
+
+// SetNetworkConditions 设置网络条件模拟：target 为空时作用于整个会话（含之后附着的目标），
+// 否则仅作用于该目标；c 为 nil 时清除对应层级的模拟
+func (o *Orchestrator) SetNetworkConditions(ctx context.Context, id domain.SessionID, target domain.TargetID, c *domain.NetworkConditions) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	if c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+
+	targets := state.sess.GetTargets()
+	state.mu.Lock()
+	if target == "" {
+		state.cfg.NetworkConditions = c
+	} else {
+		if !state.sess.HasTarget(target) {
+			state.mu.Unlock()
+			return domain.ErrTargetNotAttached
+		}
+		if c == nil {
+			delete(state.netOverrides, target)
+		} else {
+			state.netOverrides[target] = c
+		}
+		targets = []domain.TargetID{target}
+	}
+	state.mu.Unlock()
+
+	for _, tid := range targets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ts, ok := state.clientMgr.GetSession(tid)
+		if !ok {
+			continue
+		}
+		if err := cdp.EmulateNetworkConditions(ctx, ts.Client, state.networkConditions(tid)); err != nil {
+			o.log.Err(err, "设置网络条件模拟失败", "target", string(tid))
+			return err
+		}
+	}
+	o.log.Info("更新网络条件模拟", "sessionID", string(id), "target", string(target), "enabled", c != nil)
+	return nil
+}
+
+// SetCacheDisabled 禁用或恢复会话内所有目标（含之后附着的目标）的浏览器 HTTP 缓存
+func (o *Orchestrator) SetCacheDisabled(ctx context.Context, id domain.SessionID, disabled bool) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	state.mu.Lock()
+	state.cfg.DisableCache = disabled
+	state.mu.Unlock()
+
+	for _, tid := range state.sess.GetTargets() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ts, ok := state.clientMgr.GetSession(tid)
+		if !ok {
+			continue
+		}
+		if err := cdp.SetCacheDisabled(ctx, ts.Client, disabled); err != nil {
+			o.log.Err(err, "设置浏览器缓存失败", "target", string(tid), "disabled", disabled)
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateRuntimeSettings 在会话运行期间应用处理超时、未匹配事件采样与缓存开关，
+// 并发数与队列容量等在会话启动时分配的资源仍需重启会话才能生效
+func (o *Orchestrator) UpdateRuntimeSettings(ctx context.Context, id domain.SessionID, settings domain.RuntimeSettings) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+	state.mu.Lock()
+	state.cfg.ProcessTimeoutMS = settings.ProcessTimeoutMS
+	state.cfg.UnmatchedSampling = settings.UnmatchedSampling
+	cacheChanged := state.cfg.DisableCache != settings.DisableCache
+	state.mu.Unlock()
+
+	state.tracker.SetTimeout(time.Duration(settings.ProcessTimeoutMS) * time.Millisecond)
+	state.trafficAuditor.SetUnmatchedSampling(settings.UnmatchedSampling)
+	o.log.Info("会话运行时设置已更新", "sessionID", string(id),
+		"processTimeoutMS", settings.ProcessTimeoutMS, "unmatchedSampling", settings.UnmatchedSampling)
+	if !cacheChanged {
+		return nil
+	}
+	return o.SetCacheDisabled(ctx, id, settings.DisableCache)
+}
+
+// SetTimezone 设置目标的时区覆盖，timezoneID 为空时清除覆盖
+func (o *Orchestrator) SetTimezone(ctx context.Context, id domain.SessionID, target domain.TargetID, timezoneID string) error {
+	if err := domain.ValidateTimezoneID(timezoneID); err != nil {
+		return err
+	}
+	ts, err := o.targetSession(id, target)
+	if err != nil {
+		return err
+	}
+	if err := cdp.OverrideTimezone(ctx, ts.Client, timezoneID); err != nil {
+		o.log.Err(err, "设置时区覆盖失败", "target", string(target), "timezone", timezoneID)
+		return err
+	}
+	return nil
+}
+
+// SetLocale 设置目标的区域覆盖，locale 为空时清除覆盖
+func (o *Orchestrator) SetLocale(ctx context.Context, id domain.SessionID, target domain.TargetID, locale string) error {
+	if err := domain.ValidateLocale(locale); err != nil {
+		return err
+	}
+	ts, err := o.targetSession(id, target)
+	if err != nil {
+		return err
+	}
+	if err := cdp.OverrideLocale(ctx, ts.Client, locale); err != nil {
+		o.log.Err(err, "设置区域覆盖失败", "target", string(target), "locale", locale)
+		return err
+	}
+	return nil
+}
+
+// targetSession 获取会话中已附着目标的 CDP 连接
+func (o *Orchestrator) targetSession(id domain.SessionID, target domain.TargetID) (*cdp.TargetSession, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+	if !state.sess.HasTarget(target) {
+		return nil, domain.ErrTargetNotAttached
+	}
+	ts, ok := state.clientMgr.GetSession(target)
+	if !ok {
+		return nil, domain.ErrTargetNotAttached
+	}
+	return ts, nil
+}
+
+// LoadRules 加载规则配置到指定会话，已启用规则包含会话能力配置档不允许的行为时返回 *domain.RuleError
 func (o *Orchestrator) LoadRules(ctx context.Context, id domain.SessionID, cfg *rulespec.Config) error {
 	state, ok := o.get(id)
 	if !ok {
 		return domain.ErrSessionNotFound
 	}
-	state.engine.Update(cfg)
+	if cfg == nil {
+		return domain.ErrInvalidConfig
+	}
+	if err := loadRules(state, cfg); err != nil {
+		return err
+	}
+	o.refreshScope(ctx, state)
+	return nil
+}
+
+// loadRules 校验规则配置并原子替换会话的规则引擎，校验失败时保留原规则
+func loadRules(state *sessionState, cfg *rulespec.Config) error {
+	if err := rulespec.ValidateRuleIDs(cfg.Rules); err != nil {
+		return err
+	}
+	if err := rulespec.CheckCapabilities(state.cfg.CapabilityProfile, cfg.Rules); err != nil {
+		return err
+	}
+	if err := state.engine.Update(cfg); err != nil {
+		return err
+	}
 	state.sess.UpdateConfig(cfg)
+	state.mu.Lock()
+	state.setScopeLocked(cfg.Rules)
+	state.mu.Unlock()
 	return nil
 }
 
@@ -284,19 +856,151 @@ func (o *Orchestrator) GetRuleStats(ctx context.Context, id domain.SessionID) (d
 		Matched: matched,
 		ByRule:  make(map[domain.RuleID]int64),
 	}
+	stats.Throttled, stats.ThrottleDelayMS = state.shaper.stats()
 	for k, v := range byRule {
 		stats.ByRule[domain.RuleID(k)] = v
 	}
 	return stats, nil
 }
 
-// SubscribeEvents 订阅指定会话的事件流
-func (o *Orchestrator) SubscribeEvents(ctx context.Context, id domain.SessionID) (<-chan domain.NetworkEvent, error) {
+// GetRuleCoverage 获取指定会话的规则覆盖报告，会话结束后返回结束时的最终报告
+func (o *Orchestrator) GetRuleCoverage(ctx context.Context, id domain.SessionID) (domain.CoverageReport, error) {
+	if state, ok := o.get(id); ok {
+		return state.engine.Coverage(), nil
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if summary, ok := o.finished[id]; ok {
+		return summary.Coverage, nil
+	}
+	return domain.CoverageReport{}, domain.ErrSessionNotFound
+}
+
+// CheckAssertions 按指定会话的规则覆盖情况校验匹配次数断言，会话结束后仍可校验
+func (o *Orchestrator) CheckAssertions(ctx context.Context, id domain.SessionID, assertions []domain.RuleAssertion) (domain.AssertionReport, error) {
+	cov, err := o.GetRuleCoverage(ctx, id)
+	if err != nil {
+		return domain.AssertionReport{}, err
+	}
+	return domain.CheckAssertions(cov, assertions), nil
+}
+
+// GetReconnectStats 获取指定会话中目标连接意外断开后的重连统计
+func (o *Orchestrator) GetReconnectStats(ctx context.Context, id domain.SessionID) (domain.ReconnectStats, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ReconnectStats{}, domain.ErrSessionNotFound
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.reconnects, nil
+}
+
+// GetTrafficStats 获取指定会话按域名与资源类型的流量统计
+func (o *Orchestrator) GetTrafficStats(ctx context.Context, id domain.SessionID) (domain.TrafficStats, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.TrafficStats{}, domain.ErrSessionNotFound
+	}
+	return state.processor.TrafficStats(), nil
+}
+
+// GenerateReport 生成会话汇总报告（主要域名、状态码分布、命中规则、实际修改、错误），会话结束后仍可生成
+func (o *Orchestrator) GenerateReport(ctx context.Context, id domain.SessionID, format domain.ReportFormat) ([]byte, error) {
+	var summary domain.SessionSummary
+	if state, ok := o.get(id); ok {
+		summary = state.summary()
+	} else {
+		o.mu.RLock()
+		s, ok := o.finished[id]
+		o.mu.RUnlock()
+		if !ok {
+			return nil, domain.ErrSessionNotFound
+		}
+		summary = s
+		summary.GeneratedAt = time.Now().UnixMilli()
+	}
+	return report.Render(summary, format)
+}
+
+// RunBenchmark 使用合成事件对指定规则配置执行吞吐基准测试，无需会话
+func (o *Orchestrator) RunBenchmark(ctx context.Context, cfg *rulespec.Config, opts domain.BenchmarkOptions) (domain.BenchmarkResult, error) {
+	if cfg == nil {
+		return domain.BenchmarkResult{}, domain.ErrInvalidConfig
+	}
+	if err := rulespec.ValidateRuleIDs(cfg.Rules); err != nil {
+		return domain.BenchmarkResult{}, err
+	}
+	return bench.Run(ctx, cfg, opts, o.log)
+}
+
+// BenchmarkRules 使用录制或合成的请求上下文逐条评估规则耗时，无需会话
+func (o *Orchestrator) BenchmarkRules(ctx context.Context, cfg *rulespec.Config, opts domain.RuleBenchmarkOptions) (domain.RuleBenchmarkResult, error) {
+	if cfg == nil {
+		return domain.RuleBenchmarkResult{}, domain.ErrInvalidConfig
+	}
+	if err := rulespec.ValidateRuleIDs(cfg.Rules); err != nil {
+		return domain.RuleBenchmarkResult{}, err
+	}
+	res, err := bench.ProfileRules(ctx, cfg, opts)
+	if err == nil && len(res.Rules) > 0 {
+		slowest := res.Rules[0]
+		o.log.Info("规则耗时分析完成", "rules", len(res.Rules), "contexts", res.Contexts, "slowestRule", string(slowest.RuleID), "avgNS", slowest.AvgNS)
+	}
+	return res, err
+}
+
+// TestRules 以候选规则配置离线重放捕获的事件，报告每个事件会命中的规则与产生的修改，无需会话
+func (o *Orchestrator) TestRules(ctx context.Context, cfg *rulespec.Config, opts domain.RuleTestOptions) (domain.RuleTestResult, error) {
+	if cfg == nil {
+		return domain.RuleTestResult{}, domain.ErrInvalidConfig
+	}
+	if err := rulespec.ValidateRuleIDs(cfg.Rules); err != nil {
+		return domain.RuleTestResult{}, err
+	}
+	return ruletest.Run(ctx, cfg, opts, o.log)
+}
+
+// SubscribeEvents 订阅指定会话中序号大于 after 的事件，先回放缓冲中的事件再推送新事件；
+// ctx 结束或会话停止时通道关闭
+func (o *Orchestrator) SubscribeEvents(ctx context.Context, id domain.SessionID, after uint64) (<-chan domain.NetworkEvent, error) {
 	state, ok := o.get(id)
 	if !ok {
 		return nil, domain.ErrSessionNotFound
 	}
-	return state.events, nil
+	return state.bus.Subscribe(ctx, after), nil
+}
+
+// GetEvent 在会话的事件缓冲中查找 eventID 对应的事件，已被新事件挤出缓冲时返回 ErrEventNotFound
+func (o *Orchestrator) GetEvent(ctx context.Context, id domain.SessionID, eventID string) (domain.NetworkEvent, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.NetworkEvent{}, domain.ErrSessionNotFound
+	}
+	evt, ok := state.bus.Find(eventID)
+	if !ok {
+		return domain.NetworkEvent{}, domain.ErrEventNotFound
+	}
+	return evt, nil
+}
+
+// SubscribeEventStream 以确认式迭代器订阅指定会话的匹配事件，ctx 取消或调用 Close 后释放事件流
+func (o *Orchestrator) SubscribeEventStream(ctx context.Context, id domain.SessionID, opts domain.EventStreamOptions) (domain.EventIterator, error) {
+	state, ok := o.get(id)
+	if !ok {
+		return nil, domain.ErrSessionNotFound
+	}
+	stream := eventstream.New(opts)
+	state.matchedAuditor.AddStream(stream)
+	// 订阅方的 ctx 取消或自行关闭后即移除事件流，不再为已离开的订阅方缓冲事件
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stream.Done():
+		}
+		state.matchedAuditor.RemoveStream(stream)
+	}()
+	return stream, nil
 }
 
 // SubscribeTraffic 订阅指定会话的全量流量流
@@ -315,8 +1019,11 @@ func (o *Orchestrator) EnableTrafficCapture(ctx context.Context, id domain.Sessi
 		return domain.ErrSessionNotFound
 	}
 
-	// 更新审计器状态
-	state.trafficAuditor.SetEnabled(enabled)
+	// 更新审计器状态，持续导出 HAR 期间保持开启
+	state.mu.Lock()
+	state.trafficCapture = enabled
+	state.trafficAuditor.SetEnabled(enabled || state.har != nil)
+	state.mu.Unlock()
 
 	// 根据新状态更新物理拦截
 	if err := o.updatePhysicalInterception(ctx, state); err != nil {
@@ -334,47 +1041,203 @@ func (o *Orchestrator) handleEvent(state *sessionState, ts *cdp.TargetSession, e
 		stage = "response"
 	}
 	o.log.Debug("[Orchestrator] 处理 CDP 事件", "requestID", ev.RequestID, "stage", stage, "url", ev.Request.URL, "method", ev.Request.Method)
+	state.finishAuthAttempt(ev.RequestID)
+	// 被资源类型筛选排除的请求通常已在浏览器侧放行，接管代理认证时仍会暂停，直接放行
+	if !state.resources.Allows(ev.ResourceType) {
+		o.continuePaused(state, ts, ev)
+		return
+	}
+	span := o.startEventSpan(state, ts, ev, stage)
+	defer span.End()
+
+	// 命中一次性断点的请求保持暂停，等待人工处理
+	if o.holdAtBreakpoint(state, ts, ev) {
+		span.SetAttributes(attribute.Bool("cdpnetool.breakpoint", true))
+		return
+	}
+	o.processEvent(state, ts, ev)
+}
+
+// processEvent 将暂停事件交给处理器并应用处理结果
+func (o *Orchestrator) processEvent(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply) {
+	// 仅为应答代理认证而开启物理拦截时，暂停的请求直接放行
+	if !state.processingEnabled() {
+		o.continuePaused(state, ts, ev)
+		return
+	}
+	if state.timing != nil && ev.NetworkID != nil {
+		state.timing.Track(string(ev.RequestID), string(*ev.NetworkID))
+	}
 
 	if ev.ResponseStatusCode == nil {
 		// 请求阶段
 		req := cdp.ToNeutralRequest(ev)
-		res := state.processor.ProcessRequest(state.ctx, string(state.id), string(ts.ID), req)
-		o.log.Debug("[Orchestrator] 请求处理结果", "requestID", ev.RequestID, "action", res.Action)
-		o.applyResult(state, ts, ev, res)
+		if len(req.Body) == 0 && ev.Request.HasPostData != nil && *ev.Request.HasPostData {
+			o.fetchPostData(state, ts, ev, req)
+		}
+		if o.replayRequest(state, ts, ev, req) {
+			return
+		}
+		if o.coalesceRequest(state, ts, ev, req) {
+			return
+		}
+		o.processRequest(state, ts, ev, req)
 	} else {
 		// 响应阶段
+		// 命中的规则只修改状态码与头部时跳过获取响应体；合并了重复请求或需要录制响应时需要完整响应体
+		if resp := cdp.ToNeutralResponse(ev, nil); !state.hasCoalesceGroup(ev.RequestID) && !state.replayPending(ev.RequestID) &&
+			!state.processor.NeedsResponseBody(string(ev.RequestID), resp) {
+			res := state.processor.ProcessResponse(state.traceContext(ev.RequestID), string(state.id), string(ts.ID), string(ev.RequestID), resp)
+			res.HeadersOnly = true
+			if state.isDryRun() {
+				res = passThrough(res, nil)
+			}
+			o.log.Debug("[Orchestrator] 响应处理结果（未获取响应体）", "requestID", ev.RequestID, "action", res.Action)
+			o.applyResult(state, ts, ev, res)
+			return
+		}
+
 		// 获取原始响应体
-		var body []byte
-		ctx2, cancel2 := context.WithTimeout(state.ctx, 3*time.Second)
-		rb, err := ts.Client.Fetch.GetResponseBody(ctx2, &fetch.GetResponseBodyArgs{RequestID: ev.RequestID})
-		cancel2()
+		body, streamed, err := o.fetchResponseBody(state, ts, ev)
 		if err != nil {
-			o.log.Warn("获取响应体失败，执行降级放行", "requestID", ev.RequestID, "error", err.Error())
-			if err := state.interceptor.ContinueResponse(state.ctx, ts.Client, ev.RequestID); err != nil {
-				o.log.Err(err, "降级放行响应失败", "requestID", ev.RequestID)
-			}
+			o.responseBodyFailed(state, ts, ev, streamed, err)
 			return
 		}
-		if rb != nil {
-			// GetResponseBody 返回的是 base64 编码的字符串，需要解码为原始字节
-			if rb.Base64Encoded {
-				decoded, err := base64.StdEncoding.DecodeString(rb.Body)
-				if err != nil {
-					o.log.Err(err, "解码响应体失败", "requestID", ev.RequestID)
-					body = []byte(rb.Body)
-				} else {
-					body = decoded
-				}
-			} else {
-				body = []byte(rb.Body)
+		o.processResponse(state, ts, ev, body, streamed, nil)
+	}
+}
+
+// continuePaused 不经处理直接放行暂停的请求或响应
+func (o *Orchestrator) continuePaused(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply) {
+	if ev.ResponseStatusCode == nil {
+		_ = state.interceptor.ContinueRequest(state.ctx, ts.Client, ev.RequestID)
+	} else {
+		_ = state.interceptor.ContinueResponse(state.ctx, ts.Client, ev.RequestID)
+		o.releaseCoalesced(state, ev.RequestID)
+	}
+}
+
+// responseBodyFailed 获取响应体失败：响应体已以流的方式取出时让请求失败，否则降级放行
+func (o *Orchestrator) responseBodyFailed(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply, streamed bool, err error) {
+	if streamed {
+		// 响应体已取出但读取中断，浏览器无法再收到原始响应体，只能让请求失败
+		o.log.Warn("流式读取响应体失败，请求以失败结束", "requestID", ev.RequestID, "error", err.Error())
+		ferr := ts.Client.Fetch.FailRequest(state.ctx, &fetch.FailRequestArgs{RequestID: ev.RequestID, ErrorReason: network.ErrorReasonFailed})
+		if ferr != nil {
+			o.log.Err(ferr, "结束响应体读取失败的请求失败", "requestID", ev.RequestID)
+		}
+		if state.journal != nil {
+			entry := decisionEntry(state, ts.ID, ev, processor.Result{Streamed: true})
+			entry.Call = "Fetch.failRequest"
+			entry.Degraded = true
+			entry.Error = "read response body stream: " + err.Error()
+			if ferr != nil {
+				entry.Error += ": " + ferr.Error()
 			}
+			state.journal.Append(entry)
+		}
+		state.forgetReplay(ev.RequestID)
+		o.releaseCoalesced(state, ev.RequestID)
+		return
+	}
+	o.log.Warn("获取响应体失败，执行降级放行", "requestID", ev.RequestID, "error", err.Error())
+	cerr := state.interceptor.ContinueResponse(state.ctx, ts.Client, ev.RequestID)
+	if cerr != nil {
+		o.log.Err(cerr, "降级放行响应失败", "requestID", ev.RequestID)
+	}
+	journalDegraded(state, ts.ID, ev, "get response body: "+err.Error(), cerr)
+	state.forgetReplay(ev.RequestID)
+	o.releaseCoalesced(state, ev.RequestID)
+}
+
+// processResponse 以取出的原始响应体处理响应阶段的暂停事件并应用处理结果。
+// edit 不为 nil 时以断点中人工编辑的状态码、头部与响应体代替原始响应交给规则处理，规则未作修改时仍以编辑后的响应应答
+func (o *Orchestrator) processResponse(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply, body []byte, streamed bool, edit *domain.Response) {
+	resp := cdp.ToNeutralResponse(ev, body)
+	// 压缩的响应体解压后交给规则处理，原样放行时仍以原始字节应答
+	original := resp
+	enc := o.decodeResponseBody(state, ev.RequestID, resp)
+	if enc != transformer.EncodingNone {
+		original = cdp.ToNeutralResponse(ev, body)
+	}
+	if edit != nil {
+		if edit.StatusCode != 0 {
+			resp.StatusCode = edit.StatusCode
+		}
+		if edit.Headers != nil {
+			resp.Headers = edit.Headers
+		}
+		if edit.Body != nil {
+			resp.Body = edit.Body
+		}
+	}
+
+	res := processor.Result{Action: processor.ActionPass}
+	if state.processingEnabled() {
+		res = state.processor.ProcessResponse(state.traceContext(ev.RequestID), string(state.id), string(ts.ID), string(ev.RequestID), resp)
+	}
+	if state.isDryRun() {
+		res = passThrough(res, original)
+	}
+	if edit != nil && res.Action == processor.ActionPass {
+		res.Action = processor.ActionModify
+		res.ModifiedRes = resp
+	}
+	if enc != transformer.EncodingNone && res.Action == processor.ActionModify && res.ModifiedRes != nil {
+		encodeResponseBody(res.ModifiedRes, enc, state.cfg.RecompressBody)
+	}
+	if streamed {
+		res.Streamed = true
+		if res.Action == processor.ActionPass {
+			// 响应体已取出，浏览器不再收到原始响应体，放行改为以读取到的原始响应应答
+			res.ModifiedRes = original
+		}
+	}
+	o.log.Debug("[Orchestrator] 响应处理结果", "requestID", ev.RequestID, "action", res.Action)
+	o.applyResult(state, ts, ev, res)
+	if res.Action == processor.ActionFail {
+		// 首个请求以网络错误结束，不录制，合并的请求各自处理
+		state.forgetReplay(ev.RequestID)
+		o.releaseCoalesced(state, ev.RequestID)
+		return
+	}
+	final := finalResponse(res, original)
+	o.recordReplay(state, ev, final)
+	o.fulfillCoalesced(state, ev.RequestID, final)
+}
+
+// processRequest 将请求阶段的暂停事件交给处理器并应用处理结果，
+// 首个请求以模拟响应拦截时以同一响应应答合并的请求，以其他方式终止时合并的请求各自处理
+func (o *Orchestrator) processRequest(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply, req *domain.Request) {
+	res := state.processor.ProcessRequest(state.traceContext(ev.RequestID), string(state.id), string(ts.ID), req)
+	if state.isDryRun() {
+		res = passThrough(res, nil)
+	}
+	o.log.Debug("[Orchestrator] 请求处理结果", "requestID", ev.RequestID, "action", res.Action)
+	o.applyResult(state, ts, ev, res)
+	switch res.Action {
+	case processor.ActionBlock:
+		state.forgetReplay(ev.RequestID)
+		if res.MockRes != nil && !res.WebSocket {
+			o.fulfillCoalesced(state, ev.RequestID, res.MockRes)
+		} else {
+			o.releaseCoalesced(state, ev.RequestID)
 		}
+	case processor.ActionFail:
+		state.forgetReplay(ev.RequestID)
+		o.releaseCoalesced(state, ev.RequestID)
+	}
+}
 
-		resp := cdp.ToNeutralResponse(ev, body)
-		res := state.processor.ProcessResponse(state.ctx, string(state.id), string(ts.ID), string(ev.RequestID), resp)
-		o.log.Debug("[Orchestrator] 响应处理结果", "requestID", ev.RequestID, "action", res.Action)
-		o.applyResult(state, ts, ev, res)
+// finalResponse 返回响应阶段处理后浏览器最终收到的响应
+func finalResponse(res processor.Result, original *domain.Response) *domain.Response {
+	switch {
+	case res.Action == processor.ActionBlock && res.MockRes != nil:
+		return res.MockRes
+	case res.Action == processor.ActionModify && res.ModifiedRes != nil:
+		return res.ModifiedRes
 	}
+	return original
 }
 
 // applyResult 将中立处理结果反馈给物理适配层
@@ -383,20 +1246,91 @@ func (o *Orchestrator) applyResult(state *sessionState, ts *cdp.TargetSession, e
 	isRequest := ev.ResponseStatusCode == nil
 
 	o.log.Debug("[Orchestrator] 开始应用结果", "requestID", id, "action", res.Action, "isRequest", isRequest)
+	if state.cfg.ReadOnly && res.Action != processor.ActionPass {
+		// 只读会话不允许任何修改，无论处理结果如何都原样放行
+		o.log.Warn("只读会话忽略修改结果，原样放行", "requestID", id, "action", res.Action)
+		res = processor.Result{Action: processor.ActionPass, WebSocket: res.WebSocket}
+	}
+	// Cookie 需在请求放行前写入，浏览器处理响应及之后的请求时才能看到
+	o.persistCookies(state, ts, res.BrowserCookies)
+	// 只读会话不改变流量的任何表现，包括不节流
+	if !state.cfg.ReadOnly {
+		if wait := state.shaper.wait(time.Now(), res.Throttle, throttleSize(ev, res)); wait > 0 {
+			// 节流的结果延迟下发，等待期间不占用处理并发
+			o.log.Debug("[Orchestrator] 节流延迟下发结果", "requestID", id, "wait", wait)
+			time.AfterFunc(wait, func() {
+				if state.ctx.Err() == nil {
+					o.sendResult(state, ts, ev, res)
+				}
+			})
+			return
+		}
+	}
+	o.sendResult(state, ts, ev, res)
+}
+
+// sendResult 以对应的 CDP 方法下发处理结果，失败时降级原样放行
+func (o *Orchestrator) sendResult(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply, res processor.Result) {
+	id := ev.RequestID
+	isRequest := ev.ResponseStatusCode == nil
+
+	// 决策日志：记录最终下发的 CDP 方法及其结果
+	var entry domain.DecisionEntry
+	if state.journal != nil {
+		entry = decisionEntry(state, ts.ID, ev, res)
+		defer func() { state.journal.Append(entry) }()
+	}
+	// 追踪：span 在下发后以实际调用的 CDP 方法命名
+	span := startCDPSpan(state, id, "Fetch.sendResult")
+	defer func() { endResultSpan(state, span, ev, res, entry) }()
+	// degrade 记录下发失败并降级原样放行
+	degrade := func(err error) {
+		entry.Error = err.Error()
+		entry.Degraded = true
+		state.engine.RecordDegraded(res.RuleIDs)
+		if isRequest {
+			_ = state.interceptor.ContinueRequest(state.ctx, ts.Client, id)
+		} else {
+			_ = state.interceptor.ContinueResponse(state.ctx, ts.Client, id)
+		}
+	}
 
 	switch res.Action {
+	case processor.ActionFail:
+		o.log.Info("[Orchestrator] 执行 Fail 动作", "requestID", id, "reason", res.FailReason)
+		entry.Call = "Fetch.failRequest"
+		err := ts.Client.Fetch.FailRequest(state.ctx, &fetch.FailRequestArgs{
+			RequestID:   id,
+			ErrorReason: network.ErrorReason(res.FailReason),
+		})
+		if err != nil {
+			o.log.Err(err, "[Orchestrator] 执行 Fail 动作失败，降级放行", "requestID", id)
+			degrade(err)
+		}
+
 	case processor.ActionBlock:
 		o.log.Info("[Orchestrator] 执行 Block 动作", "requestID", id, "statusCode", res.MockRes.StatusCode)
 		// 无论请求还是响应阶段，拦截都通过 FulfillRequest 模拟响应
 		if res.MockRes == nil {
 			o.log.Err(nil, "Block 动作但 MockRes 为 nil，执行降级放行", "requestID", id)
-			if isRequest {
-				_ = state.interceptor.ContinueRequest(state.ctx, ts.Client, id)
-			} else {
-				_ = state.interceptor.ContinueResponse(state.ctx, ts.Client, id)
+			entry.Call = "Fetch.fulfillRequest"
+			degrade(errors.New("block action without mock response"))
+			return
+		}
+		if res.WebSocket && isRequest {
+			// WebSocket 握手无法以普通 HTTP 响应应答，直接以客户端拦截的原因失败
+			entry.Call = "Fetch.failRequest"
+			err := ts.Client.Fetch.FailRequest(state.ctx, &fetch.FailRequestArgs{
+				RequestID:   id,
+				ErrorReason: network.ErrorReasonBlockedByClient,
+			})
+			if err != nil {
+				o.log.Err(err, "[Orchestrator] 拦截 WebSocket 握手失败，降级放行", "requestID", id)
+				degrade(err)
 			}
 			return
 		}
+		entry.Call = "Fetch.fulfillRequest"
 		err := ts.Client.Fetch.FulfillRequest(state.ctx, &fetch.FulfillRequestArgs{
 			RequestID:       id,
 			ResponseCode:    res.MockRes.StatusCode,
@@ -405,11 +1339,7 @@ func (o *Orchestrator) applyResult(state *sessionState, ts *cdp.TargetSession, e
 		})
 		if err != nil {
 			o.log.Err(err, "[Orchestrator] 执行 Block 响应失败，降级放行", "requestID", id)
-			if isRequest {
-				_ = state.interceptor.ContinueRequest(state.ctx, ts.Client, id)
-			} else {
-				_ = state.interceptor.ContinueResponse(state.ctx, ts.Client, id)
-			}
+			degrade(err)
 		} else {
 			o.log.Debug("[Orchestrator] Block 执行成功", "requestID", id)
 		}
@@ -418,6 +1348,7 @@ func (o *Orchestrator) applyResult(state *sessionState, ts *cdp.TargetSession, e
 		o.log.Debug("[Orchestrator] 执行 Modify 动作", "requestID", id, "isRequest", isRequest)
 		if isRequest {
 			// 请求阶段修改
+			entry.Call = "Fetch.continueRequest"
 			err := ts.Client.Fetch.ContinueRequest(state.ctx, &fetch.ContinueRequestArgs{
 				RequestID: id,
 				URL:       &res.ModifiedReq.URL,
@@ -427,10 +1358,25 @@ func (o *Orchestrator) applyResult(state *sessionState, ts *cdp.TargetSession, e
 			})
 			if err != nil {
 				o.log.Err(err, "[Orchestrator] 执行请求修改失败，降级原样放行", "requestID", id)
-				_ = state.interceptor.ContinueRequest(state.ctx, ts.Client, id)
+				degrade(err)
 			} else {
 				o.log.Debug("[Orchestrator] 请求修改成功", "requestID", id)
 			}
+		} else if res.HeadersOnly && res.ModifiedRes != nil {
+			// 未获取响应体：通过 ContinueResponse 覆盖状态码与头部，响应体由浏览器原样接收
+			code := res.ModifiedRes.StatusCode
+			entry.Call = "Fetch.continueResponse"
+			err := ts.Client.Fetch.ContinueResponse(state.ctx, &fetch.ContinueResponseArgs{
+				RequestID:       id,
+				ResponseCode:    &code,
+				ResponseHeaders: cdp.ToHeaderEntries(res.ModifiedRes.Headers),
+			})
+			if err != nil {
+				o.log.Err(err, "[Orchestrator] 执行响应头修改失败", "requestID", id)
+				degrade(err)
+			} else {
+				o.log.Debug("[Orchestrator] 响应头修改成功", "requestID", id)
+			}
 		} else {
 			// 响应阶段修改：统一使用 FulfillRequest 全量覆盖
 			code := 200
@@ -451,6 +1397,7 @@ func (o *Orchestrator) applyResult(state *sessionState, ts *cdp.TargetSession, e
 				}
 			}
 
+			entry.Call = "Fetch.fulfillRequest"
 			err := ts.Client.Fetch.FulfillRequest(state.ctx, &fetch.FulfillRequestArgs{
 				RequestID:       id,
 				ResponseCode:    code,
@@ -459,7 +1406,7 @@ func (o *Orchestrator) applyResult(state *sessionState, ts *cdp.TargetSession, e
 			})
 			if err != nil {
 				o.log.Err(err, "[Orchestrator] 执行响应 FulfillRequest 失败", "requestID", id)
-				_ = state.interceptor.ContinueResponse(state.ctx, ts.Client, id)
+				degrade(err)
 			} else {
 				o.log.Debug("[Orchestrator] 响应修改成功", "requestID", id)
 			}
@@ -467,14 +1414,33 @@ func (o *Orchestrator) applyResult(state *sessionState, ts *cdp.TargetSession, e
 
 	default:
 		if isRequest {
+			entry.Call = "Fetch.continueRequest"
 			if err := state.interceptor.ContinueRequest(state.ctx, ts.Client, id); err != nil {
 				o.log.Err(err, "[Orchestrator] 默认 ContinueRequest 失败", "requestID", id)
+				entry.Error = err.Error()
 			} else {
 				o.log.Debug("[Orchestrator] 请求放行成功", "requestID", id)
 			}
+		} else if res.Streamed && res.ModifiedRes != nil {
+			// 响应体已以流方式取出，以读取到的原始响应原样应答
+			entry.Call = "Fetch.fulfillRequest"
+			err := ts.Client.Fetch.FulfillRequest(state.ctx, &fetch.FulfillRequestArgs{
+				RequestID:       id,
+				ResponseCode:    res.ModifiedRes.StatusCode,
+				ResponseHeaders: cdp.ToHeaderEntries(res.ModifiedRes.Headers),
+				Body:            res.ModifiedRes.Body,
+			})
+			if err != nil {
+				o.log.Err(err, "[Orchestrator] 以原始响应应答失败", "requestID", id)
+				entry.Error = err.Error()
+			} else {
+				o.log.Debug("[Orchestrator] 响应放行成功", "requestID", id)
+			}
 		} else {
+			entry.Call = "Fetch.continueResponse"
 			if err := state.interceptor.ContinueResponse(state.ctx, ts.Client, id); err != nil {
 				o.log.Err(err, "[Orchestrator] 默认 ContinueResponse 失败", "requestID", id)
+				entry.Error = err.Error()
 			} else {
 				o.log.Debug("[Orchestrator] 响应放行成功", "requestID", id)
 			}
@@ -486,7 +1452,8 @@ func (o *Orchestrator) applyResult(state *sessionState, ts *cdp.TargetSession, e
 func (o *Orchestrator) shouldEnablePhysicalInterception(state *sessionState) bool {
 	state.mu.Lock()
 	defer state.mu.Unlock()
-	return state.interceptionEnabled || state.trafficAuditor.IsEnabled()
+	return state.interceptionEnabled || state.trafficAuditor.IsEnabled() || state.proxyAuth != nil || state.contract != nil || state.secrets != nil ||
+		state.breakpoint != nil || len(state.held) > 0 || len(state.challenges) > 0 || state.replayActiveLocked()
 }
 
 // updatePhysicalInterception 根据业务状态更新所有目标的物理拦截
@@ -495,13 +1462,16 @@ func (o *Orchestrator) updatePhysicalInterception(ctx context.Context, state *se
 	targets := state.sess.GetTargets()
 
 	for _, tid := range targets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		ts, ok := state.clientMgr.GetSession(tid)
 		if !ok {
 			continue
 		}
 
 		if shouldEnable {
-			if err := state.interceptor.Enable(ctx, ts.Client); err != nil {
+			if err := state.interceptor.Enable(ctx, ts.Client, state.handlesAuth(), state.interceptPatterns()); err != nil {
 				o.log.Err(err, "物理拦截启用失败", "target", string(tid))
 			}
 		} else {
@@ -514,6 +1484,37 @@ func (o *Orchestrator) updatePhysicalInterception(ctx context.Context, state *se
 	return nil
 }
 
+// setScopeLocked 按规则重新推导拦截范围及是否需要接管认证质询，调用方需持有 s.mu
+func (s *sessionState) setScopeLocked(rules []rulespec.Rule) {
+	s.scope, s.scoped = rulespec.InterceptPatterns(rules)
+	s.authRules = rulespec.HasAuthRules(rules)
+}
+
+// interceptPatterns 返回启用拦截时使用的范围，为 nil 表示拦截所有请求。全量流量捕获、代理认证、契约检查、
+// 敏感信息扫描、断点与录制回放需要经过所有请求，主机映射、关联 ID 注入、请求合并、带宽上限与只读模式
+// 作用于所有请求，这些功能开启时不按规则收窄
+func (s *sessionState) interceptPatterns() []domain.InterceptPattern {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg := &s.cfg
+	if !s.scoped || s.trafficAuditor.IsEnabled() || s.proxyAuth != nil || s.contract != nil || s.secrets != nil ||
+		s.breakpoint != nil || len(s.held) > 0 || s.replayActiveLocked() ||
+		len(cfg.HostMappings) > 0 || cfg.CorrelationHeader != "" || cfg.CoalesceWindowMS > 0 || cfg.BandwidthLimit > 0 || cfg.ReadOnly {
+		return nil
+	}
+	return append([]domain.InterceptPattern{}, s.scope...)
+}
+
+// refreshScope 规则变化后以新的拦截范围重新启用拦截，未启用物理拦截时无需处理
+func (o *Orchestrator) refreshScope(ctx context.Context, state *sessionState) {
+	if !o.shouldEnablePhysicalInterception(state) {
+		return
+	}
+	if err := o.updatePhysicalInterception(ctx, state); err != nil {
+		o.log.Err(err, "更新拦截范围失败", "sessionID", string(state.id))
+	}
+}
+
 // get 获取指定会话的状态
 func (o *Orchestrator) get(id domain.SessionID) (*sessionState, bool) {
 	o.mu.RLock()
@@ -521,3 +1522,62 @@ func (o *Orchestrator) get(id domain.SessionID) (*sessionState, bool) {
 	s, ok := o.sessions[id]
 	return s, ok
 }
+
+// overrideUserAgent 按预设名或自定义字符串覆盖目标的 User-Agent
+func (o *Orchestrator) overrideUserAgent(ctx context.Context, ts *cdp.TargetSession, value string) error {
+	preset, err := rulespec.ResolveUserAgent(value)
+	if err != nil {
+		return err
+	}
+	return cdp.OverrideUserAgent(ctx, ts.Client, preset)
+}
+
+// processingEnabled 判断暂停的请求是否需要交给处理器（拦截、全量流量捕获、契约检查、敏感信息检测或录制回放缓存已开启）
+func (s *sessionState) processingEnabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interceptionEnabled || s.trafficAuditor.IsEnabled() || s.contract != nil || s.secrets != nil || s.replayActiveLocked()
+}
+
+// summary 汇总会话当前的覆盖报告与流量统计
+func (s *sessionState) summary() domain.SessionSummary {
+	summary := domain.SessionSummary{
+		SessionID:   s.id,
+		StartedAt:   s.startedAt.UnixMilli(),
+		GeneratedAt: time.Now().UnixMilli(),
+		Coverage:    s.engine.Coverage(),
+		Traffic:     s.processor.TrafficStats(),
+	}
+	if len(s.cfg.Assertions) > 0 {
+		report := domain.CheckAssertions(summary.Coverage, s.cfg.Assertions)
+		summary.Assertions = &report
+	}
+	return summary
+}
+
+// geolocation 返回目标当前生效的地理位置覆盖，目标级优先于会话级
+func (s *sessionState) geolocation(target domain.TargetID) *domain.GeoLocation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if loc, ok := s.geoOverrides[target]; ok {
+		return loc
+	}
+	return s.cfg.Geolocation
+}
+
+// networkConditions 返回目标当前生效的网络条件模拟，目标级优先于会话级
+func (s *sessionState) networkConditions(target domain.TargetID) *domain.NetworkConditions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.netOverrides[target]; ok {
+		return c
+	}
+	return s.cfg.NetworkConditions
+}
+
+// cacheDisabled 返回会话是否禁用浏览器 HTTP 缓存
+func (s *sessionState) cacheDisabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg.DisableCache
+}
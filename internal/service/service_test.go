@@ -0,0 +1,3779 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/cdptest"
+	"cdpnetool/internal/logger"
+	"cdpnetool/internal/service"
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/mafredri/cdp/protocol/emulation"
+	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/protocol/network"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// record 为 true 时以当前输出重写夹具的期望结果：go test ./internal/service -run TestReplay -record
+var record = flag.Bool("record", false, "rewrite fixture expectations with current outputs")
+
+// startSession 基于模拟 DevTools 启动会话并附着目标、开启拦截
+func startSession(t *testing.T, srv *cdptest.Server, rules ...rulespec.Rule) (*service.Orchestrator, domain.SessionID) {
+	t.Helper()
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = rules
+	return startSessionConfig(t, srv, cfg)
+}
+
+// startSessionConfig 以指定配置启动会话
+func startSessionConfig(t *testing.T, srv *cdptest.Server, cfg *rulespec.Config) (*service.Orchestrator, domain.SessionID) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{
+		DevToolsURL:      srv.URL(),
+		Concurrency:      4,
+		PendingCapacity:  16,
+		ProcessTimeoutMS: 1000,
+	})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+
+	if err := svc.LoadRules(ctx, id, cfg); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+	return svc, id
+}
+
+// pauseUntil 推送暂停事件并等待期望的方法调用
+func pauseUntil(t *testing.T, srv *cdptest.Server, ev *fetch.RequestPausedReply, method string) cdptest.Call {
+	t.Helper()
+	if err := srv.Pause("page1", ev); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	call, err := srv.WaitCall(ctx, method, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return call
+}
+
+func pausedRequest(id, url string) *fetch.RequestPausedReply {
+	return &fetch.RequestPausedReply{
+		RequestID: fetch.RequestID(id),
+		Request: network.Request{
+			URL:     url,
+			Method:  "GET",
+			Headers: network.Headers([]byte(`{}`)),
+		},
+	}
+}
+
+func TestIntercept_PassThrough(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	startSession(t, srv)
+
+	call := pauseUntil(t, srv, pausedRequest("req1", "https://example.com/a"), "Fetch.continueRequest")
+	var args fetch.ContinueRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.RequestID != "req1" {
+		t.Errorf("got requestId %q, want req1", args.RequestID)
+	}
+	if args.URL != nil {
+		t.Errorf("pass-through should not rewrite URL, got %q", *args.URL)
+	}
+}
+
+func TestIntercept_Block(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	startSession(t, srv, rulespec.Rule{
+		ID:      "rule1",
+		Name:    "block rule",
+		Enabled: true,
+		Stage:   rulespec.StageRequest,
+		Match: rulespec.Match{
+			AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLContains, Value: "/blocked"},
+			},
+		},
+		Actions: []rulespec.Action{
+			{Type: rulespec.ActionBlock, StatusCode: 403, Body: "blocked"},
+		},
+	})
+
+	call := pauseUntil(t, srv, pausedRequest("req1", "https://example.com/blocked"), "Fetch.fulfillRequest")
+	var args fetch.FulfillRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ResponseCode != 403 {
+		t.Errorf("got status %d, want 403", args.ResponseCode)
+	}
+	if string(args.Body) != "blocked" {
+		t.Errorf("got body %q, want blocked", args.Body)
+	}
+}
+
+func TestSubscribeEvents_Replay(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "rule1", Name: "block rule", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/blocked"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 事件在订阅之前产生，订阅时从缓冲中回放
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/blocked"), "Fetch.fulfillRequest")
+	pauseUntil(t, srv, pausedRequest("req2", "https://example.com/blocked"), "Fetch.fulfillRequest")
+
+	recv := func(ch <-chan domain.NetworkEvent) domain.NetworkEvent {
+		t.Helper()
+		select {
+		case evt := <-ch:
+			return evt
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for event")
+			return domain.NetworkEvent{}
+		}
+	}
+	ch, err := svc.SubscribeEvents(ctx, id, 0)
+	if err != nil {
+		t.Fatalf("SubscribeEvents() error = %v", err)
+	}
+	if evt := recv(ch); evt.ID != "req1" || evt.Seq != 1 {
+		t.Errorf("got %s seq %d, want req1 seq 1", evt.ID, evt.Seq)
+	}
+	if evt := recv(ch); evt.ID != "req2" || evt.Seq != 2 {
+		t.Errorf("got %s seq %d, want req2 seq 2", evt.ID, evt.Seq)
+	}
+
+	// 从最后收到的序号重新订阅只接收之后的事件
+	ch, err = svc.SubscribeEvents(ctx, id, 2)
+	if err != nil {
+		t.Fatalf("SubscribeEvents(2) error = %v", err)
+	}
+	pauseUntil(t, srv, pausedRequest("req3", "https://example.com/blocked"), "Fetch.fulfillRequest")
+	if evt := recv(ch); evt.ID != "req3" || evt.Seq != 3 {
+		t.Errorf("got %s seq %d, want req3 seq 3", evt.ID, evt.Seq)
+	}
+
+	if _, err := svc.SubscribeEvents(ctx, "missing", 0); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestSubscribeEventStream_ReleasedOnCancel(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "rule1", Name: "block rule", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/blocked"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	subCtx, unsubscribe := context.WithCancel(ctx)
+	gone, err := svc.SubscribeEventStream(subCtx, id, domain.EventStreamOptions{BufferSize: 4})
+	if err != nil {
+		t.Fatalf("SubscribeEventStream() error = %v", err)
+	}
+	kept, err := svc.SubscribeEventStream(ctx, id, domain.EventStreamOptions{BufferSize: 4})
+	if err != nil {
+		t.Fatalf("SubscribeEventStream() error = %v", err)
+	}
+	defer kept.Close()
+
+	// 订阅方的 ctx 取消后未调用 Close，事件流也要被关闭并不再缓冲事件
+	unsubscribe()
+	if _, err := gone.Next(ctx); !errors.Is(err, domain.ErrStreamClosed) {
+		t.Fatalf("Next() after cancel: got %v, want ErrStreamClosed", err)
+	}
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/blocked"), "Fetch.fulfillRequest")
+	d, err := kept.Next(ctx)
+	if err != nil || d.Event.ID != "req1" {
+		t.Fatalf("Next() = %+v, %v, want req1 on the live stream", d, err)
+	}
+	if st := gone.Stats(); st.Pending != 0 || st.Dropped != 0 {
+		t.Errorf("got %+v, want the released stream to buffer nothing", st)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "block", Name: "block", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/blocked"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}, rulespec.Rule{
+		ID: "header", Name: "header", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionSetHeader, Name: "X-Test", Value: "1"}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch, err := svc.SubscribeEvents(ctx, id, 0)
+	if err != nil {
+		t.Fatalf("SubscribeEvents() error = %v", err)
+	}
+	recv := func() domain.NetworkEvent {
+		t.Helper()
+		select {
+		case evt := <-ch:
+			return evt
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for event")
+			return domain.NetworkEvent{}
+		}
+	}
+
+	if err := svc.SetDryRun(ctx, id, true); err != nil {
+		t.Fatalf("SetDryRun() error = %v", err)
+	}
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/blocked"), "Fetch.continueRequest")
+	if evt := recv(); !evt.DryRun || evt.FinalResult != "blocked" || len(evt.MatchedRules) != 1 {
+		t.Errorf("got event %+v, want dry-run blocked event", evt)
+	}
+
+	if err := srv.Pause("page1", pausedRequest("req2", "https://example.com/api")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.continueRequest", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.ContinueRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.RequestID != "req2" || len(args.Headers) != 0 || args.URL != nil {
+		t.Errorf("got continue %+v, want req2 continued untouched", args)
+	}
+
+	// 关闭演练后规则重新生效
+	if err := svc.SetDryRun(ctx, id, false); err != nil {
+		t.Fatalf("SetDryRun(false) error = %v", err)
+	}
+	pauseUntil(t, srv, pausedRequest("req3", "https://example.com/blocked"), "Fetch.fulfillRequest")
+
+	if err := svc.SetDryRun(ctx, "missing", true); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestIntercept_WebSocketBlock(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	startSession(t, srv, rulespec.Rule{
+		ID:      "rule1",
+		Name:    "block socket",
+		Enabled: true,
+		Stage:   rulespec.StageRequest,
+		Match: rulespec.Match{
+			AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLContains, Value: "/socket"},
+			},
+		},
+		Actions: []rulespec.Action{
+			{Type: rulespec.ActionBlock, StatusCode: 403},
+		},
+	})
+
+	ev := pausedRequest("req1", "wss://example.com/socket")
+	ev.ResourceType = network.ResourceTypeWebSocket
+	call := pauseUntil(t, srv, ev, "Fetch.failRequest")
+	var args fetch.FailRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ErrorReason != network.ErrorReasonBlockedByClient {
+		t.Errorf("got reason %v, want BlockedByClient", args.ErrorReason)
+	}
+}
+
+func TestIntercept_Fail(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	startSession(t, srv, rulespec.Rule{
+		ID:      "rule1",
+		Name:    "reset connection",
+		Enabled: true,
+		Stage:   rulespec.StageRequest,
+		Match: rulespec.Match{
+			AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLContains, Value: "/flaky"},
+			},
+		},
+		Actions: []rulespec.Action{
+			{Type: rulespec.ActionFail, Value: "ConnectionRefused"},
+		},
+	})
+
+	call := pauseUntil(t, srv, pausedRequest("req1", "https://example.com/flaky"), "Fetch.failRequest")
+	var args fetch.FailRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ErrorReason != network.ErrorReasonConnectionRefused {
+		t.Errorf("got reason %v, want ConnectionRefused", args.ErrorReason)
+	}
+}
+
+func TestIntercept_ModifyRequestHeader(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	startSession(t, srv, rulespec.Rule{
+		ID:      "rule1",
+		Name:    "modify header",
+		Enabled: true,
+		Stage:   rulespec.StageRequest,
+		Match: rulespec.Match{
+			AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLContains, Value: "example.com"},
+			},
+		},
+		Actions: []rulespec.Action{
+			{Type: rulespec.ActionSetHeader, Name: "X-Custom", Value: "test"},
+		},
+	})
+
+	call := pauseUntil(t, srv, pausedRequest("req1", "https://example.com/a"), "Fetch.continueRequest")
+	var args fetch.ContinueRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, h := range args.Headers {
+		if h.Name == "X-Custom" && h.Value == "test" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("X-Custom header not set, got %+v", args.Headers)
+	}
+}
+
+func TestIntercept_ResponseBody(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+		return fetch.GetResponseBodyReply{Body: `{"name":"old"}`}, nil
+	})
+
+	startSession(t, srv, rulespec.Rule{
+		ID:      "rule1",
+		Name:    "replace body",
+		Enabled: true,
+		Stage:   rulespec.StageResponse,
+		Match: rulespec.Match{
+			AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLContains, Value: "/api"},
+			},
+		},
+		Actions: []rulespec.Action{
+			{Type: rulespec.ActionSetBody, Value: "replaced"},
+		},
+	})
+
+	// 响应阶段依赖请求阶段登记的追踪状态
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api"), "Fetch.continueRequest")
+
+	status := 200
+	ev := pausedRequest("req1", "https://example.com/api")
+	ev.ResponseStatusCode = &status
+	ev.ResponseHeaders = []fetch.HeaderEntry{{Name: "Content-Type", Value: "text/plain"}}
+
+	call := pauseUntil(t, srv, ev, "Fetch.fulfillRequest")
+	var args fetch.FulfillRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if string(args.Body) != "replaced" {
+		t.Errorf("got body %q, want replaced", args.Body)
+	}
+}
+
+func TestIntercept_ResponseHeadersOnly(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	startSession(t, srv, rulespec.Rule{
+		ID:      "rule1",
+		Name:    "set headers",
+		Enabled: true,
+		Stage:   rulespec.StageResponse,
+		Match: rulespec.Match{
+			AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLContains, Value: "/api"},
+			},
+		},
+		Actions: []rulespec.Action{
+			{Type: rulespec.ActionSetStatus, Value: float64(503)},
+			{Type: rulespec.ActionSetHeader, Name: "X-Test", Value: "1"},
+		},
+	})
+
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api"), "Fetch.continueRequest")
+
+	status := 200
+	ev := pausedRequest("req1", "https://example.com/api")
+	ev.ResponseStatusCode = &status
+	ev.ResponseHeaders = []fetch.HeaderEntry{{Name: "Content-Type", Value: "text/plain"}}
+
+	call := pauseUntil(t, srv, ev, "Fetch.continueResponse")
+	var args fetch.ContinueResponseArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ResponseCode == nil || *args.ResponseCode != 503 {
+		t.Errorf("got response code %v, want 503", args.ResponseCode)
+	}
+	headers := make(map[string]string)
+	for _, h := range args.ResponseHeaders {
+		headers[h.Name] = h.Value
+	}
+	if headers["X-Test"] != "1" || headers["Content-Type"] != "text/plain" {
+		t.Errorf("got headers %v, want original headers plus X-Test", headers)
+	}
+	for _, c := range srv.Calls() {
+		if c.Method == "Fetch.getResponseBody" || c.Method == "Fetch.fulfillRequest" {
+			t.Errorf("header-only rules should not call %s", c.Method)
+		}
+	}
+}
+
+func TestIntercept_ResponseBodyStream(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.takeResponseBodyAsStream", func(targetID string, params json.RawMessage) (any, error) {
+		var args fetch.TakeResponseBodyAsStreamArgs
+		_ = json.Unmarshal(params, &args)
+		if args.RequestID == "req3" {
+			return nil, errors.New("stream unavailable")
+		}
+		return map[string]string{"stream": "stream-" + string(args.RequestID)}, nil
+	})
+	var mu sync.Mutex
+	reads := map[string]int{}
+	srv.Handle("IO.read", func(targetID string, params json.RawMessage) (any, error) {
+		var args struct {
+			Handle string `json:"handle"`
+			Offset *int   `json:"offset"`
+			Size   int    `json:"size"`
+		}
+		_ = json.Unmarshal(params, &args)
+		if args.Offset != nil || args.Size != 8 {
+			return nil, fmt.Errorf("unexpected read args %s", params)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		reads[args.Handle]++
+		switch {
+		case args.Handle == "stream-req4":
+			return nil, errors.New("stream closed")
+		case reads[args.Handle] == 1:
+			return map[string]any{"data": `{"name":`, "eof": false}, nil
+		default:
+			return map[string]any{"data": base64.StdEncoding.EncodeToString([]byte(`"old"}`)), "base64Encoded": true, "eof": true}, nil
+		}
+	})
+	srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+		return fetch.GetResponseBodyReply{Body: `{"name":"old","whole":true}`}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), PendingCapacity: 16, BodyChunkSize: 8})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "rule1", Name: "replace", Enabled: true, Stage: rulespec.StageResponse,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionReplaceBodyText, Search: "old", Replace: "new"}},
+	}, {
+		// 请求阶段命中的事件需要记录响应体
+		ID: "rule2", Name: "tag", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/other"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionSetHeader, Name: "X-Tag", Value: "1"}},
+	}}
+	if err := svc.LoadRules(ctx, id, cfg); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// respond 推送第 i 个请求的请求与响应阶段，等待第 n 次 method 调用
+	respond := func(i int, url, method string, n int) cdptest.Call {
+		t.Helper()
+		reqID := fmt.Sprintf("req%d", i)
+		if err := srv.Pause("page1", pausedRequest(reqID, url)); err != nil {
+			t.Fatalf("Pause() error = %v", err)
+		}
+		if _, err := srv.WaitCall(ctx, "Fetch.continueRequest", i); err != nil {
+			t.Fatal(err)
+		}
+		status := 200
+		ev := pausedRequest(reqID, url)
+		ev.ResponseStatusCode = &status
+		ev.ResponseHeaders = []fetch.HeaderEntry{{Name: "Content-Type", Value: "application/json"}}
+		if err := srv.Pause("page1", ev); err != nil {
+			t.Fatalf("Pause() error = %v", err)
+		}
+		call, err := srv.WaitCall(ctx, method, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return call
+	}
+	body := func(call cdptest.Call) string {
+		var args fetch.FulfillRequestArgs
+		if err := json.Unmarshal(call.Params, &args); err != nil {
+			t.Fatal(err)
+		}
+		return string(args.Body)
+	}
+
+	// 分块读取的响应体交给规则处理
+	if got := body(respond(1, "https://example.com/api", "Fetch.fulfillRequest", 1)); got != `{"name":"new"}` {
+		t.Errorf("got body %q, want modified streamed body", got)
+	}
+	// 未修改的响应体已被取出，以原始响应应答而不是 continueResponse
+	if got := body(respond(2, "https://example.com/other", "Fetch.fulfillRequest", 2)); got != `{"name":"old"}` {
+		t.Errorf("got body %q, want original streamed body", got)
+	}
+	// 无法取出响应体时改为一次性获取
+	if got := body(respond(3, "https://example.com/api", "Fetch.fulfillRequest", 3)); got != `{"name":"new","whole":true}` {
+		t.Errorf("got body %q, want body from getResponseBody", got)
+	}
+	// 取出后读取失败时浏览器已无法收到原始响应体，请求以失败结束
+	respond(4, "https://example.com/api", "Fetch.failRequest", 1)
+	if _, err := srv.WaitCall(ctx, "IO.close", 3); err != nil {
+		t.Errorf("streams not closed: %v", err)
+	}
+	for _, c := range srv.Calls() {
+		if c.Method == "Fetch.continueResponse" {
+			t.Errorf("got continueResponse for streamed body: %s", c.Params)
+		}
+	}
+}
+
+func TestAttachTarget_NotFound(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(context.Background(), domain.SessionConfig{DevToolsURL: srv.URL()})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	defer svc.StopSession(context.Background(), id)
+
+	err = svc.AttachTarget(context.Background(), id, "missing")
+	if !errors.Is(err, domain.ErrTargetNotFound) {
+		t.Errorf("got %v, want ErrTargetNotFound", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "fixtures", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no fixtures found")
+	}
+
+	for _, path := range paths {
+		fx, err := cdptest.LoadFixture(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(fx.Name, func(t *testing.T) {
+			cfg, _, err := rulespec.ParseConfig(fx.Config)
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+
+			srv := cdptest.NewServer()
+			defer srv.Close()
+			srv.AddTarget("page1", "https://example.com")
+			srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+				return fetch.GetResponseBodyReply{Body: `{"name":"old"}`}, nil
+			})
+			startSessionConfig(t, srv, cfg)
+
+			got, err := srv.Replay(context.Background(), "page1", fx, 5*time.Second)
+			if err != nil {
+				t.Fatalf("Replay() error = %v", err)
+			}
+
+			if *record {
+				for i := range fx.Steps {
+					fx.Steps[i].Expect = got[i]
+				}
+				if err := fx.Save(path); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			for i, step := range fx.Steps {
+				if diff := cdptest.Diff(step.Expect, got[i]); diff != "" {
+					t.Errorf("step %d (%s): %s", i+1, step.Paused.RequestID, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRuleCoverage(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	// 拦截响应下发失败时服务会降级放行
+	srv.Handle("Fetch.fulfillRequest", func(targetID string, params json.RawMessage) (any, error) {
+		return nil, errors.New("fulfill failed")
+	})
+
+	blockRule := rulespec.Rule{
+		ID: "block", Name: "block", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/blocked"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}
+	unusedRule := rulespec.Rule{
+		ID: "unused", Name: "unused", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/nothing"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionSetHeader, Name: "X-Test", Value: "1"}},
+	}
+	svc, id := startSession(t, srv, blockRule, unusedRule)
+
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/blocked"), "Fetch.continueRequest")
+
+	if err := svc.StopSession(context.Background(), id); err != nil {
+		t.Fatalf("StopSession() error = %v", err)
+	}
+
+	// 会话结束后仍可获取最终报告
+	report, err := svc.GetRuleCoverage(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetRuleCoverage() error = %v", err)
+	}
+	if len(report.NeverMatched) != 1 || report.NeverMatched[0] != "unused" {
+		t.Errorf("got NeverMatched %v, want [unused]", report.NeverMatched)
+	}
+	if len(report.AlwaysDegraded) != 1 || report.AlwaysDegraded[0] != "block" {
+		t.Errorf("got AlwaysDegraded %v, want [block]", report.AlwaysDegraded)
+	}
+
+	if _, err := svc.GetRuleCoverage(context.Background(), "missing"); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestDecisionJournal(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.fulfillRequest", func(targetID string, params json.RawMessage) (any, error) {
+		if strings.Contains(string(params), "req2") {
+			return nil, errors.New("fulfill failed")
+		}
+		return map[string]any{}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), JournalDir: dir})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "block", Name: "block", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/blocked"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}}
+	if err := svc.LoadRules(ctx, id, cfg); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/blocked"), "Fetch.fulfillRequest")
+	pauseUntil(t, srv, pausedRequest("req2", "https://example.com/blocked"), "Fetch.continueRequest")
+	if err := srv.Pause("page1", pausedRequest("req3", "https://example.com/other")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.continueRequest", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// 记录直接追加到文件，无需等待会话结束即可读取
+	path := filepath.Join(dir, "decisions-"+string(id)+".jsonl")
+	entries := map[string]domain.DecisionEntry{}
+	for deadline := time.Now().Add(2 * time.Second); len(entries) < 3 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read journal: %v", err)
+		}
+		entries = map[string]domain.DecisionEntry{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var e domain.DecisionEntry
+			if json.Unmarshal([]byte(line), &e) == nil {
+				entries[e.RequestID] = e
+			}
+		}
+	}
+	if err := svc.StopSession(context.Background(), id); err != nil {
+		t.Fatalf("StopSession() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d journal entries, want 3", len(entries))
+	}
+
+	if e := entries["req1"]; e.Action != "block" || e.Call != "Fetch.fulfillRequest" || e.Degraded ||
+		len(e.Rules) != 1 || e.Rules[0] != "block" || e.Target != "page1" || e.Fingerprint == "" {
+		t.Errorf("got req1 entry %+v, want block via Fetch.fulfillRequest", e)
+	}
+	// 下发失败时记录失败的调用与错误，并标记已降级放行
+	if e := entries["req2"]; !e.Degraded || e.Call != "Fetch.fulfillRequest" || !strings.Contains(e.Error, "fulfill failed") {
+		t.Errorf("got req2 entry %+v, want degraded fulfill", e)
+	}
+	if e := entries["req3"]; e.Action != "pass" || e.Call != "Fetch.continueRequest" || len(e.Rules) != 0 {
+		t.Errorf("got req3 entry %+v, want pass", e)
+	}
+	if entries["req1"].Fingerprint != entries["req2"].Fingerprint || entries["req1"].Fingerprint == entries["req3"].Fingerprint {
+		t.Errorf("got fingerprints %q %q %q, want equal for identical requests only",
+			entries["req1"].Fingerprint, entries["req2"].Fingerprint, entries["req3"].Fingerprint)
+	}
+}
+
+func TestRequestCoalescing(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+		if strings.Contains(string(params), "req4") {
+			return nil, errors.New("no body")
+		}
+		return fetch.GetResponseBodyReply{Body: `{"items":[1,2]}`}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), CoalesceWindowMS: 2000})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 首个请求正常发出，相同的请求暂停，不同的请求不受影响
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api/items"), "Fetch.continueRequest")
+	if err := srv.Pause("page1", pausedRequest("req2", "https://example.com/api/items")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if err := srv.Pause("page1", pausedRequest("req3", "https://example.com/api/other")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.continueRequest", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	status := 200
+	ev := pausedRequest("req1", "https://example.com/api/items")
+	ev.ResponseStatusCode = &status
+	ev.ResponseHeaders = []fetch.HeaderEntry{{Name: "Content-Type", Value: "application/json"}}
+	call := pauseUntil(t, srv, ev, "Fetch.fulfillRequest")
+
+	var args fetch.FulfillRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.RequestID != "req2" || args.ResponseCode != 200 || string(args.Body) != `{"items":[1,2]}` {
+		t.Errorf("got fulfill %s %d %q, want req2 fulfilled with the first response", args.RequestID, args.ResponseCode, args.Body)
+	}
+	if len(args.ResponseHeaders) != 1 || args.ResponseHeaders[0].Value != "application/json" {
+		t.Errorf("got headers %+v, want the first response headers", args.ResponseHeaders)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.continueResponse", 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range srv.Calls() {
+		if c.Method == "Fetch.continueRequest" && strings.Contains(string(c.Params), "req2") {
+			t.Errorf("coalesced request was sent to the network: %s", c.Params)
+		}
+	}
+
+	// 首个请求的响应无法复用时，合并的请求各自正常发出
+	if err := srv.Pause("page1", pausedRequest("req4", "https://example.com/api/fail")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.continueRequest", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Pause("page1", pausedRequest("req5", "https://example.com/api/fail")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	ev = pausedRequest("req4", "https://example.com/api/fail")
+	ev.ResponseStatusCode = &status
+	if err := srv.Pause("page1", ev); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	call, err = srv.WaitCall(ctx, "Fetch.continueRequest", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(call.Params), "req5") {
+		t.Errorf("got continueRequest %s, want req5 released", call.Params)
+	}
+}
+
+func TestAttachTarget_UserAgentOverride(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), UserAgent: "chrome-android"})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	defer svc.StopSession(context.Background(), id)
+
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Emulation.setUserAgentOverride", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args emulation.SetUserAgentOverrideArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(args.UserAgent, "Android") || args.UserAgentMetadata == nil || !args.UserAgentMetadata.Mobile {
+		t.Errorf("unexpected override args: %+v", args)
+	}
+}
+
+func TestSetCacheDisabled(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.AddTarget("page2", "https://example.org")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), DisableCache: true})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	defer svc.StopSession(context.Background(), id)
+
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Network.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+	call, err := srv.WaitCall(ctx, "Network.setCacheDisabled", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args network.SetCacheDisabledArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if !args.CacheDisabled {
+		t.Error("want cache disabled on attach")
+	}
+
+	// 运行时恢复缓存后，新附着的目标不再禁用缓存
+	if err := svc.SetCacheDisabled(ctx, id, false); err != nil {
+		t.Fatalf("SetCacheDisabled() error = %v", err)
+	}
+	call, err = srv.WaitCall(ctx, "Network.setCacheDisabled", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.CacheDisabled || call.TargetID != "page1" {
+		t.Errorf("got %+v on %s, want cache enabled on page1", args, call.TargetID)
+	}
+	if err := svc.AttachTarget(ctx, id, "page2"); err != nil {
+		t.Fatalf("AttachTarget(page2) error = %v", err)
+	}
+	for _, c := range srv.Calls() {
+		if c.TargetID == "page2" && c.Method == "Network.setCacheDisabled" {
+			t.Error("page2 should keep the browser cache")
+		}
+	}
+
+	if err := svc.SetCacheDisabled(ctx, "missing", true); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestUpdateRuntimeSettings(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.EnableTrafficCapture(ctx, id, true); err != nil {
+		t.Fatalf("EnableTrafficCapture() error = %v", err)
+	}
+	traffic, err := svc.SubscribeTraffic(ctx, id)
+	if err != nil {
+		t.Fatalf("SubscribeTraffic() error = %v", err)
+	}
+
+	// 运行中关闭未匹配事件推送并禁用缓存
+	if err := svc.UpdateRuntimeSettings(ctx, id, domain.RuntimeSettings{ProcessTimeoutMS: 2000, UnmatchedSampling: -1, DisableCache: true}); err != nil {
+		t.Fatalf("UpdateRuntimeSettings() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Network.setCacheDisabled", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args network.SetCacheDisabledArgs
+	_ = json.Unmarshal(call.Params, &args)
+	if !args.CacheDisabled {
+		t.Errorf("got Network.setCacheDisabled %s, want cache disabled", call.Params)
+	}
+	respond := func(id, url string, n int) {
+		t.Helper()
+		status := 200
+		ev := pausedRequest(id, url)
+		ev.ResponseStatusCode = &status
+		if err := srv.Pause("page1", pausedRequest(id, url)); err != nil {
+			t.Fatalf("Pause() error = %v", err)
+		}
+		if _, err := srv.WaitCall(ctx, "Fetch.continueRequest", n); err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.Pause("page1", ev); err != nil {
+			t.Fatalf("Pause() error = %v", err)
+		}
+		if _, err := srv.WaitCall(ctx, "Fetch.continueResponse", n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	respond("req1", "https://example.com/hidden", 1)
+
+	// 恢复未匹配事件推送，缓存开关未变化时不重复下发
+	if err := svc.UpdateRuntimeSettings(ctx, id, domain.RuntimeSettings{DisableCache: true}); err != nil {
+		t.Fatalf("UpdateRuntimeSettings() error = %v", err)
+	}
+	respond("req2", "https://example.com/shown", 2)
+	n := 0
+	for _, c := range srv.Calls() {
+		if c.Method == "Network.setCacheDisabled" {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("got %d Network.setCacheDisabled calls, want 1", n)
+	}
+
+	select {
+	case evt := <-traffic:
+		if evt.ID != "req2" {
+			t.Errorf("got traffic event %s, want req2", evt.ID)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for traffic event")
+	}
+
+	if err := svc.UpdateRuntimeSettings(ctx, "missing", domain.RuntimeSettings{}); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestSetGeolocation(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tokyo, _ := domain.LookupGeoPreset("tokyo")
+	if err := svc.SetGeolocation(ctx, id, "", &tokyo); err != nil {
+		t.Fatalf("SetGeolocation() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Emulation.setGeolocationOverride", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args emulation.SetGeolocationOverrideArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Latitude == nil || *args.Latitude != tokyo.Latitude {
+		t.Errorf("got latitude %v, want %v", args.Latitude, tokyo.Latitude)
+	}
+
+	// 清除目标级覆盖后回退到会话级覆盖
+	london, _ := domain.LookupGeoPreset("london")
+	if err := svc.SetGeolocation(ctx, id, "page1", &london); err != nil {
+		t.Fatalf("SetGeolocation(target) error = %v", err)
+	}
+	if err := svc.SetGeolocation(ctx, id, "page1", nil); err != nil {
+		t.Fatalf("SetGeolocation(target, nil) error = %v", err)
+	}
+	call, err = srv.WaitCall(ctx, "Emulation.setGeolocationOverride", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if *args.Latitude != tokyo.Latitude {
+		t.Errorf("got latitude %v, want session-level %v", *args.Latitude, tokyo.Latitude)
+	}
+
+	// 清除会话级覆盖
+	if err := svc.SetGeolocation(ctx, id, "", nil); err != nil {
+		t.Fatalf("SetGeolocation(nil) error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Emulation.clearGeolocationOverride", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.SetGeolocation(ctx, id, "page2", &tokyo); !errors.Is(err, domain.ErrTargetNotAttached) {
+		t.Errorf("got %v, want ErrTargetNotAttached", err)
+	}
+	if err := svc.SetGeolocation(ctx, id, "", &domain.GeoLocation{Latitude: 100}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestSetNetworkConditions(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	slow, _ := domain.LookupNetworkPreset("slow-3g")
+	if err := svc.SetNetworkConditions(ctx, id, "", &slow); err != nil {
+		t.Fatalf("SetNetworkConditions() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Network.emulateNetworkConditions", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args network.EmulateNetworkConditionsArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Offline || args.Latency != slow.LatencyMS || args.DownloadThroughput != slow.DownloadThroughput {
+		t.Errorf("got %+v, want slow-3g", args)
+	}
+
+	// 清除目标级模拟后回退到会话级模拟
+	if err := svc.SetNetworkConditions(ctx, id, "page1", &domain.NetworkConditions{Offline: true}); err != nil {
+		t.Fatalf("SetNetworkConditions(target) error = %v", err)
+	}
+	if err := svc.SetNetworkConditions(ctx, id, "page1", nil); err != nil {
+		t.Fatalf("SetNetworkConditions(target, nil) error = %v", err)
+	}
+	call, err = srv.WaitCall(ctx, "Network.emulateNetworkConditions", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Offline || args.Latency != slow.LatencyMS {
+		t.Errorf("got %+v, want session-level slow-3g", args)
+	}
+
+	// 清除会话级模拟后不限制吞吐
+	if err := svc.SetNetworkConditions(ctx, id, "", nil); err != nil {
+		t.Fatalf("SetNetworkConditions(nil) error = %v", err)
+	}
+	call, err = srv.WaitCall(ctx, "Network.emulateNetworkConditions", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args = network.EmulateNetworkConditionsArgs{}
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Offline || args.Latency != 0 || args.DownloadThroughput != -1 || args.UploadThroughput != -1 {
+		t.Errorf("got %+v, want conditions cleared", args)
+	}
+
+	if err := svc.SetNetworkConditions(ctx, id, "page2", &slow); !errors.Is(err, domain.ErrTargetNotAttached) {
+		t.Errorf("got %v, want ErrTargetNotAttached", err)
+	}
+	if err := svc.SetNetworkConditions(ctx, id, "", &domain.NetworkConditions{LatencyMS: -1}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+	if err := svc.SetNetworkConditions(ctx, "missing", "", nil); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestReplayCache(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+		return fetch.GetResponseBodyReply{Body: `{"v":1}`}, nil
+	})
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := svc.SetReplayMode(ctx, id, domain.ReplayCacheOptions{Mode: "rewind"}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got error %v, want ErrInvalidConfig", err)
+	}
+	opts := domain.ReplayCacheOptions{Mode: domain.ReplayCacheReplay, Dir: t.TempDir(), IgnoreQuery: []string{"ts"}}
+	if _, err := svc.SetReplayMode(ctx, id, opts); err != nil {
+		t.Fatalf("SetReplayMode() error = %v", err)
+	}
+
+	// 首个请求未命中，照常发出并录制浏览器收到的响应
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api/items?ts=1"), "Fetch.continueRequest")
+	code := 200
+	ev := pausedRequest("req1", "https://example.com/api/items?ts=1")
+	ev.ResponseStatusCode = &code
+	ev.ResponseHeaders = []fetch.HeaderEntry{{Name: "Content-Type", Value: "application/json"}}
+	pauseUntil(t, srv, ev, "Fetch.continueResponse")
+	var status domain.ReplayCacheStatus
+	for deadline := time.Now().Add(2 * time.Second); status.Stored == 0 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		status, _ = svc.GetReplayStatus(ctx, id)
+	}
+	if status.Stored != 1 || status.Misses != 1 || status.Entries != 1 {
+		t.Fatalf("got status %+v, want one miss and one stored entry", status)
+	}
+
+	// 只有忽略的查询参数不同的请求命中录制，以录制的响应应答
+	call := pauseUntil(t, srv, pausedRequest("req2", "https://example.com/api/items?ts=2"), "Fetch.fulfillRequest")
+	var args fetch.FulfillRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.RequestID != "req2" || args.ResponseCode != 200 || string(args.Body) != `{"v":1}` {
+		t.Errorf("got fulfill %s %d %q, want req2 fulfilled with the recorded response", args.RequestID, args.ResponseCode, args.Body)
+	}
+	for deadline := time.Now().Add(2 * time.Second); status.Hits == 0 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		status, _ = svc.GetReplayStatus(ctx, id)
+	}
+	if status.Hits != 1 {
+		t.Errorf("got %d hits, want 1", status.Hits)
+	}
+
+	// 离线回放时未命中的请求以网络错误结束
+	opts.Mode = domain.ReplayCacheOffline
+	if status, err := svc.SetReplayMode(ctx, id, opts); err != nil || status.Entries != 1 {
+		t.Fatalf("SetReplayMode(offline) = %+v, %v, want the recorded entry kept", status, err)
+	}
+	call = pauseUntil(t, srv, pausedRequest("req3", "https://example.com/api/other"), "Fetch.failRequest")
+	var fail fetch.FailRequestArgs
+	if err := json.Unmarshal(call.Params, &fail); err != nil {
+		t.Fatal(err)
+	}
+	if fail.ErrorReason != network.ErrorReasonInternetDisconnected {
+		t.Errorf("got reason %v, want InternetDisconnected", fail.ErrorReason)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "rule1", Name: "header rule", Enabled: true, Stage: rulespec.StageResponse,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionSetHeader, Name: "X-Test", Value: "1"}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch, err := svc.SubscribeEvents(ctx, id, 0)
+	if err != nil {
+		t.Fatalf("SubscribeEvents() error = %v", err)
+	}
+
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api"), "Fetch.continueRequest")
+	code := 200
+	ev := pausedRequest("req1", "https://example.com/api")
+	ev.ResponseStatusCode = &code
+	pauseUntil(t, srv, ev, "Fetch.continueResponse")
+
+	var evt domain.NetworkEvent
+	select {
+	case evt = <-ch:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for event")
+	}
+	if len(evt.Request.TraceID) != 32 {
+		t.Fatalf("got trace ID %q in event, want the request's trace ID", evt.Request.TraceID)
+	}
+
+	// 请求与响应两个阶段的处理链路位于同一条追踪中
+	want := []string{"handleEvent", "processor.ProcessRequest", "engine.Eval", "Fetch.continueRequest",
+		"processor.ProcessResponse", "engine.EvalResponse", "Fetch.continueResponse"}
+	names := make(map[string]int)
+	for deadline := time.Now().Add(2 * time.Second); names["Fetch.continueResponse"] == 0 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		clear(names)
+		for _, span := range recorder.Ended() {
+			if span.SpanContext().TraceID().String() != evt.Request.TraceID {
+				t.Errorf("span %s in trace %s, want %s", span.Name(), span.SpanContext().TraceID(), evt.Request.TraceID)
+			}
+			names[span.Name()]++
+		}
+	}
+	for _, name := range want {
+		if names[name] == 0 {
+			t.Errorf("no %s span recorded, got %v", name, names)
+		}
+	}
+	if names["handleEvent"] != 2 {
+		t.Errorf("got %d handleEvent spans, want one per stage", names["handleEvent"])
+	}
+}
+
+func TestSetTimezoneAndLocale(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.SetTimezone(ctx, id, "page1", "Asia/Tokyo"); err != nil {
+		t.Fatalf("SetTimezone() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Emulation.setTimezoneOverride", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tz emulation.SetTimezoneOverrideArgs
+	if err := json.Unmarshal(call.Params, &tz); err != nil {
+		t.Fatal(err)
+	}
+	if tz.TimezoneID != "Asia/Tokyo" {
+		t.Errorf("got timezone %q, want Asia/Tokyo", tz.TimezoneID)
+	}
+
+	if err := svc.SetLocale(ctx, id, "page1", "ja-JP"); err != nil {
+		t.Fatalf("SetLocale() error = %v", err)
+	}
+	if err := svc.SetLocale(ctx, id, "page1", ""); err != nil {
+		t.Fatalf("SetLocale(clear) error = %v", err)
+	}
+	call, err = srv.WaitCall(ctx, "Emulation.setLocaleOverride", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var locale emulation.SetLocaleOverrideArgs
+	if err := json.Unmarshal(call.Params, &locale); err != nil {
+		t.Fatal(err)
+	}
+	if locale.Locale != nil {
+		t.Errorf("got locale %q, want cleared", *locale.Locale)
+	}
+
+	if err := svc.SetTimezone(ctx, id, "page1", "Tokyo"); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+	if err := svc.SetLocale(ctx, id, "page2", "en-US"); !errors.Is(err, domain.ErrTargetNotAttached) {
+		t.Errorf("got %v, want ErrTargetNotAttached", err)
+	}
+}
+
+func TestGetTrafficStats(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+
+	pauseUntil(t, srv, pausedRequest("req1", "https://api.example.com/a"), "Fetch.continueRequest")
+	status := 200
+	ev := pausedRequest("req1", "https://api.example.com/a")
+	ev.ResponseStatusCode = &status
+	// 未命中规则的响应不获取响应体，下行字节数按 Content-Length 估算
+	ev.ResponseHeaders = []fetch.HeaderEntry{{Name: "Content-Length", Value: "10"}}
+	pauseUntil(t, srv, ev, "Fetch.continueResponse")
+
+	stats, err := svc.GetTrafficStats(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetTrafficStats() error = %v", err)
+	}
+	if len(stats.Domains) != 1 || stats.Domains[0].Domain != "api.example.com" {
+		t.Fatalf("unexpected domains %+v", stats.Domains)
+	}
+	// 头部 "Content-Length: 10\r\n" 20 字节加响应体 10 字节
+	if got := stats.Domains[0].Total; got.Requests != 1 || got.ResponseBytes != 30 {
+		t.Errorf("got %+v, want 1 request and 30 response bytes", got)
+	}
+
+	if _, err := svc.GetTrafficStats(context.Background(), "missing"); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestGenerateReport(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID:      "rule1",
+		Name:    "tag api",
+		Enabled: true,
+		Stage:   rulespec.StageRequest,
+		Match: rulespec.Match{
+			AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLContains, Value: "/api"},
+			},
+		},
+		Actions: []rulespec.Action{
+			{Type: rulespec.ActionSetHeader, Name: "X-Test", Value: "1"},
+		},
+	})
+
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api"), "Fetch.continueRequest")
+	status := 502
+	ev := pausedRequest("req1", "https://example.com/api")
+	ev.ResponseStatusCode = &status
+	// 请求阶段已修改的事务在响应阶段以 FulfillRequest 下发
+	pauseUntil(t, srv, ev, "Fetch.fulfillRequest")
+
+	if err := svc.StopSession(context.Background(), id); err != nil {
+		t.Fatalf("StopSession() error = %v", err)
+	}
+
+	// 会话结束后仍可生成报告
+	out, err := svc.GenerateReport(context.Background(), id, domain.ReportFormatMarkdown)
+	if err != nil {
+		t.Fatalf("GenerateReport() error = %v", err)
+	}
+	md := string(out)
+	for _, want := range []string{"| example.com | 1 | 0 |", "| 502 | 1 |", "- tag api (`rule1`): 1", "Server errors (5xx): 1"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("report missing %q:\n%s", want, md)
+		}
+	}
+
+	if _, err := svc.GenerateReport(context.Background(), id, "pdf"); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+	if _, err := svc.GenerateReport(context.Background(), "missing", domain.ReportFormatHTML); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestCheckAssertions(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "login-mock", Name: "login mock", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/login"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionSetHeader, Name: "X-Test", Value: "1"}},
+	})
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/login"), "Fetch.continueRequest")
+
+	var assertions []domain.RuleAssertion
+	for _, s := range []string{"login-mock>=1", "block-analytics==0", "login-mock<1"} {
+		a, err := domain.ParseRuleAssertion(s)
+		if err != nil {
+			t.Fatalf("ParseRuleAssertion(%q) error = %v", s, err)
+		}
+		assertions = append(assertions, a)
+	}
+
+	if err := svc.StopSession(context.Background(), id); err != nil {
+		t.Fatalf("StopSession() error = %v", err)
+	}
+	// 会话结束后仍可校验
+	report, err := svc.CheckAssertions(context.Background(), id, assertions)
+	if err != nil {
+		t.Fatalf("CheckAssertions() error = %v", err)
+	}
+	if report.Passed {
+		t.Error("got Passed = true, want false")
+	}
+	for i, want := range []bool{true, true, false} {
+		if report.Results[i].Passed != want {
+			t.Errorf("%s: got Passed = %v, want %v", report.Results[i].Assertion, report.Results[i].Passed, want)
+		}
+	}
+
+	if _, err := svc.CheckAssertions(context.Background(), "missing", assertions); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestSessionAssertionsInReport(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(context.Background(), domain.SessionConfig{
+		DevToolsURL: srv.URL(),
+		Assertions:  []domain.RuleAssertion{{RuleID: "login-mock", Min: 1}},
+	})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	if err := svc.StopSession(context.Background(), id); err != nil {
+		t.Fatalf("StopSession() error = %v", err)
+	}
+
+	out, err := svc.GenerateReport(context.Background(), id, domain.ReportFormatMarkdown)
+	if err != nil {
+		t.Fatalf("GenerateReport() error = %v", err)
+	}
+	if md := string(out); !strings.Contains(md, "## Assertions: FAIL") || !strings.Contains(md, "| `login-mock >= 1` | 0 | FAIL |") {
+		t.Errorf("report missing failed assertion:\n%s", md)
+	}
+}
+
+func TestReconnect_ResumesPausedRequests(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	// 第 1、3 次放行时断开连接；第 2 次为重连后补发放行 req1，第 4 次浏览器已不认识 req2
+	var mu sync.Mutex
+	calls := 0
+	srv.Handle("Fetch.continueRequest", func(targetID string, params json.RawMessage) (any, error) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		switch n {
+		case 1, 3:
+			_ = srv.Disconnect(targetID)
+			return nil, nil
+		case 4:
+			return nil, errors.New("Invalid InterceptionId.")
+		}
+		return nil, nil
+	})
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	waitStats := func(want domain.ReconnectStats) {
+		t.Helper()
+		for {
+			got, err := svc.GetReconnectStats(ctx, id)
+			if err != nil {
+				t.Fatalf("GetReconnectStats() error = %v", err)
+			}
+			if got == want {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				t.Fatalf("got stats %+v, want %+v", got, want)
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/a"), "Fetch.continueRequest")
+	// 重新附着后重新启用拦截，并在新连接上放行 req1
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 2); err != nil {
+		t.Fatal(err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.continueRequest", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(call.Params), `"req1"`) {
+		t.Errorf("got resume params %s, want req1", call.Params)
+	}
+	waitStats(domain.ReconnectStats{Disconnects: 1, Reconnects: 1, Resumed: 1})
+
+	pauseUntil(t, srv, pausedRequest("req2", "https://example.com/b"), "Fetch.continueRequest")
+	waitStats(domain.ReconnectStats{Disconnects: 2, Reconnects: 2, Resumed: 1, Orphaned: 1})
+
+	// 主动断开的目标不再重连
+	if err := svc.DetachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("DetachTarget() error = %v", err)
+	}
+	time.Sleep(700 * time.Millisecond)
+	enables := 0
+	for _, c := range srv.Calls() {
+		if c.Method == "Fetch.enable" {
+			enables++
+		}
+	}
+	if enables != 3 {
+		t.Errorf("got %d Fetch.enable calls after detach, want 3", enables)
+	}
+
+	if _, err := svc.GetReconnectStats(ctx, "missing"); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestReconnect_ResolvesReplacedTarget(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com/app")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{
+		DevToolsURL: srv.URL(),
+		Reconnect:   &domain.ReconnectPolicy{MaxAttempts: 3, InitialDelayMS: 20, MaxDelayMS: 40},
+	})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	defer svc.StopSession(context.Background(), id)
+	events, err := svc.SubscribeConnection(ctx, id)
+	if err != nil {
+		t.Fatalf("SubscribeConnection() error = %v", err)
+	}
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	next := func() domain.ConnectionEvent {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for a connection event")
+		}
+		return domain.ConnectionEvent{}
+	}
+
+	// 浏览器重启后原目标不复存在，出现一个 URL 相同的新页面
+	srv.RemoveTarget("page1")
+	srv.AddTarget("page2", "https://example.com/app")
+	if ev := next(); ev.Type != domain.ConnectionDisconnected || ev.TargetID != "page1" {
+		t.Errorf("got %+v, want disconnected page1", ev)
+	}
+	ev := next()
+	if ev.Type != domain.ConnectionReconnected || ev.TargetID != "page2" || ev.PreviousTarget != "page1" || ev.Attempts != 1 {
+		t.Errorf("got %+v, want page1 reconnected as page2", ev)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.enable", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.TargetID != "page2" {
+		t.Errorf("got Fetch.enable on %s, want page2", call.TargetID)
+	}
+
+	// 目标不复存在且没有可替代的页面时按策略重试后放弃
+	srv.RemoveTarget("page2")
+	if ev := next(); ev.Type != domain.ConnectionDisconnected || ev.TargetID != "page2" {
+		t.Errorf("got %+v, want disconnected page2", ev)
+	}
+	if ev := next(); ev.Type != domain.ConnectionReconnectFailed || ev.Attempts != 3 || ev.Error == "" {
+		t.Errorf("got %+v, want reconnectFailed after 3 attempts", ev)
+	}
+	stats, err := svc.GetReconnectStats(ctx, id)
+	if err != nil {
+		t.Fatalf("GetReconnectStats() error = %v", err)
+	}
+	if stats.Disconnects != 2 || stats.Reconnects != 1 {
+		t.Errorf("got stats %+v, want 2 disconnects and 1 reconnect", stats)
+	}
+
+	if _, err := svc.SubscribeConnection(ctx, "missing"); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+	if _, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), Reconnect: &domain.ReconnectPolicy{InitialDelayMS: -1}}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("StartSession() with negative delay = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestCorrelationHeader(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	if _, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), CorrelationHeader: "X Request"}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("StartSession() with invalid header = %v, want ErrInvalidConfig", err)
+	}
+
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), CorrelationHeader: "X-Request-ID"})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	defer svc.StopSession(context.Background(), id)
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未匹配任何规则的请求也携带关联 ID
+	call := pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api"), "Fetch.continueRequest")
+	var args fetch.ContinueRequestArgs
+	_ = json.Unmarshal(call.Params, &args)
+	found := false
+	for _, h := range args.Headers {
+		found = found || (h.Name == "X-Request-ID" && len(h.Value) == 36)
+	}
+	if !found {
+		t.Errorf("got Fetch.continueRequest %s, want X-Request-ID header", call.Params)
+	}
+}
+
+func TestProxyAuth(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{
+		DevToolsURL: srv.URL(),
+		ProxyAuth:   &domain.ProxyCredentials{Username: "alice", Password: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	defer svc.StopSession(context.Background(), id)
+
+	// 仅配置代理凭据、未开启拦截时也需启用 Fetch 以接收认证质询
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.enable", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enable fetch.EnableArgs
+	_ = json.Unmarshal(call.Params, &enable)
+	if enable.HandleAuthRequests == nil || !*enable.HandleAuthRequests {
+		t.Errorf("got Fetch.enable %s, want handleAuthRequests", call.Params)
+	}
+
+	proxy := "Proxy"
+	challenge := fetch.AuthRequiredReply{
+		RequestID:     "auth1",
+		AuthChallenge: fetch.AuthChallenge{Source: &proxy, Origin: "http://proxy:3128", Scheme: "basic"},
+	}
+	respond := func(n int) fetch.AuthChallengeResponse {
+		t.Helper()
+		if err := srv.Emit("page1", "Fetch.authRequired", challenge); err != nil {
+			t.Fatalf("Emit() error = %v", err)
+		}
+		call, err := srv.WaitCall(ctx, "Fetch.continueWithAuth", n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var args fetch.ContinueWithAuthArgs
+		_ = json.Unmarshal(call.Params, &args)
+		return args.AuthChallengeResponse
+	}
+
+	if r := respond(1); r.Response != "ProvideCredentials" || r.Username == nil || *r.Username != "alice" || *r.Password != "secret" {
+		t.Errorf("got %+v, want credentials", r)
+	}
+	// 同一请求再次质询说明凭据被拒绝，取消认证避免循环
+	if r := respond(2); r.Response != "CancelAuth" {
+		t.Errorf("got %q on repeated challenge, want CancelAuth", r.Response)
+	}
+
+	// 未开启拦截时暂停的请求直接放行
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/"), "Fetch.continueRequest")
+
+	if err := svc.SetProxyAuth(ctx, id, nil); err != nil {
+		t.Fatalf("SetProxyAuth() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.disable", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetProxyAuth(ctx, "missing", nil); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestConnectionOptions(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	// 以 TLS 网关模拟隧道：要求 Basic 认证、网关令牌与改写后的 Host，并转发到模拟 DevTools
+	backend, _ := url.Parse(srv.URL())
+	proxy := httputil.NewSingleHostReverseProxy(backend)
+	var upgrades atomic.Int32
+	gw := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "alice" || pass != "secret" || r.Header.Get("X-Tunnel-Token") != "t0k" || r.Host != "localhost:9222" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Upgrade") == "websocket" {
+			upgrades.Add(1)
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	defer gw.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: gw.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	svc := service.New(logger.NewNop())
+	conn := domain.ConnectionOptions{
+		Headers:  map[string]string{"X-Tunnel-Token": "t0k"},
+		Host:     "localhost:9222",
+		Username: "alice",
+		Password: "secret",
+		CAFile:   caFile,
+	}
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: gw.URL, Connection: &conn})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	defer svc.StopSession(context.Background(), id)
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	// 目标返回的调试地址指向模拟 DevTools 本身，应改写为经网关的 wss 地址
+	if n := upgrades.Load(); n != 1 {
+		t.Errorf("got %d WebSocket upgrades through the gateway, want 1", n)
+	}
+	if _, err := svc.ListTargets(ctx, id); err != nil {
+		t.Errorf("ListTargets() error = %v", err)
+	}
+
+	wrong := conn
+	wrong.Password = "wrong"
+	if _, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: gw.URL, Connection: &wrong}); !errors.Is(err, domain.ErrDevToolsUnreachable) {
+		t.Errorf("got %v with wrong credentials, want ErrDevToolsUnreachable", err)
+	}
+	untrusted := conn
+	untrusted.CAFile = ""
+	if _, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: gw.URL, Connection: &untrusted}); !errors.Is(err, domain.ErrDevToolsUnreachable) {
+		t.Errorf("got %v without the CA, want ErrDevToolsUnreachable", err)
+	}
+	for _, bad := range []domain.ConnectionOptions{
+		{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		{Password: "secret"},
+		{Proxy: "https://proxy:3128"},
+	} {
+		if _, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: gw.URL, Connection: &bad}); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("StartSession(%+v) error = %v, want ErrInvalidConfig", bad, err)
+		}
+	}
+}
+
+func TestHARStream(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+		return fetch.GetResponseBodyReply{Body: `{"ok":true}`}, nil
+	})
+
+	svc, id := startSession(t, srv)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "traffic.har")
+
+	if err := svc.StartHARStream(ctx, id, domain.HARStreamOptions{Path: path}); err != nil {
+		t.Fatalf("StartHARStream() error = %v", err)
+	}
+	if err := svc.StartHARStream(ctx, id, domain.HARStreamOptions{Path: path}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("second StartHARStream() = %v, want ErrInvalidConfig", err)
+	}
+
+	// 未匹配任何规则的请求同样写入 HAR
+	pauseUntil(t, srv, pausedRequest("req1", "https://api.example.com/a?x=1"), "Fetch.continueRequest")
+	status := 200
+	ev := pausedRequest("req1", "https://api.example.com/a?x=1")
+	ev.ResponseStatusCode = &status
+	pauseUntil(t, srv, ev, "Fetch.continueResponse")
+
+	final, err := svc.StopHARStream(ctx, id)
+	if err != nil {
+		t.Fatalf("StopHARStream() error = %v", err)
+	}
+	if final.Active || final.Entries != 1 || final.Format != domain.HARFormatJSON {
+		t.Errorf("unexpected final status %+v", final)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Log struct {
+			Entries []struct {
+				Request struct {
+					URL string `json:"url"`
+				} `json:"request"`
+				Response struct {
+					Status  int `json:"status"`
+					Content struct {
+						Text string `json:"text"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid HAR: %v\n%s", err, data)
+	}
+	if len(doc.Log.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(doc.Log.Entries))
+	}
+	e := doc.Log.Entries[0]
+	if e.Request.URL != "https://api.example.com/a?x=1" || e.Response.Status != 200 || e.Response.Content.Text != `{"ok":true}` {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	if got, err := svc.GetHARStreamStatus(ctx, id); err != nil || got.Active {
+		t.Errorf("GetHARStreamStatus() = %+v, %v; want inactive", got, err)
+	}
+}
+
+func TestContractCheck(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx := context.Background()
+
+	if _, err := svc.StartContractCheck(ctx, id, filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("StartContractCheck() with missing file = %v, want ErrInvalidConfig", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	spec := "openapi: 3.0.3\ninfo:\n  title: Items\n  version: '2'\npaths:\n  /items:\n    get:\n      responses:\n        '200':\n          description: ok\n"
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	status, err := svc.StartContractCheck(ctx, id, path)
+	if err != nil {
+		t.Fatalf("StartContractCheck() error = %v", err)
+	}
+	if !status.Active || status.Title != "Items" || status.Version != "2" || status.Operations != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if got, err := svc.GetContractStatus(ctx, id); err != nil || got != status {
+		t.Errorf("GetContractStatus() = %+v, %v; want %+v", got, err, status)
+	}
+
+	if err := svc.StopContractCheck(ctx, id); err != nil {
+		t.Fatalf("StopContractCheck() error = %v", err)
+	}
+	if got, err := svc.GetContractStatus(ctx, id); err != nil || got.Active {
+		t.Errorf("GetContractStatus() = %+v, %v; want inactive", got, err)
+	}
+	if _, err := svc.GetContractStatus(ctx, "missing"); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("GetContractStatus() for unknown session = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestSecretDetectors(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx := context.Background()
+
+	if err := svc.SetSecretDetectors(ctx, id, []string{"password"}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("SetSecretDetectors() with unknown detector = %v, want ErrInvalidConfig", err)
+	}
+	if err := svc.SetSecretDetectors(ctx, id, []string{"jwt", "creditCard"}); err != nil {
+		t.Fatalf("SetSecretDetectors() error = %v", err)
+	}
+	if got, err := svc.GetSecretDetectors(ctx, id); err != nil || strings.Join(got, ",") != "jwt,creditCard" {
+		t.Errorf("GetSecretDetectors() = %v, %v; want [jwt creditCard]", got, err)
+	}
+	if err := svc.SetSecretDetectors(ctx, id, nil); err != nil {
+		t.Fatalf("SetSecretDetectors(nil) error = %v", err)
+	}
+	if got, err := svc.GetSecretDetectors(ctx, id); err != nil || len(got) != 0 {
+		t.Errorf("GetSecretDetectors() = %v, %v; want empty", got, err)
+	}
+
+	bad := service.New(logger.NewNop())
+	if _, err := bad.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), SecretDetectors: []string{"password"}}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("StartSession() with unknown detector = %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestHARStream_Redaction(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+		return fetch.GetResponseBodyReply{Body: `{"token":"s3cret","ok":true}`}, nil
+	})
+
+	ctx := context.Background()
+	svc := service.New(logger.NewNop())
+	if _, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), Redaction: &domain.RedactionConfig{Patterns: []string{"("}}}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("StartSession() with invalid pattern = %v, want ErrInvalidConfig", err)
+	}
+	id, err := svc.StartSession(ctx, domain.SessionConfig{
+		DevToolsURL:      srv.URL(),
+		PendingCapacity:  16,
+		ProcessTimeoutMS: 1000,
+		Redaction:        &domain.RedactionConfig{JSONPaths: []string{"token"}, Patterns: []string{`key=(\w+)`}},
+	})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "traffic.har")
+	if err := svc.StartHARStream(ctx, id, domain.HARStreamOptions{Path: path}); err != nil {
+		t.Fatalf("StartHARStream() error = %v", err)
+	}
+	pauseUntil(t, srv, pausedRequest("req1", "https://api.example.com/a?key=abc"), "Fetch.continueRequest")
+	status := 200
+	ev := pausedRequest("req1", "https://api.example.com/a?key=abc")
+	ev.ResponseStatusCode = &status
+	pauseUntil(t, srv, ev, "Fetch.continueResponse")
+	if _, err := svc.StopHARStream(ctx, id); err != nil {
+		t.Fatalf("StopHARStream() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "abc") || strings.Contains(string(data), "s3cret") {
+		t.Errorf("HAR contains unredacted values:\n%s", data)
+	}
+	if !strings.Contains(string(data), "key=[REDACTED]") {
+		t.Errorf("HAR does not contain redacted URL:\n%s", data)
+	}
+}
+
+func TestIntercept_FetchesMissingPostData(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Network.getRequestPostData", func(targetID string, params json.RawMessage) (any, error) {
+		var args network.GetRequestPostDataArgs
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, err
+		}
+		if args.RequestID != "net1" {
+			return nil, fmt.Errorf("unexpected requestId %q", args.RequestID)
+		}
+		return network.GetRequestPostDataReply{PostData: "user=admin&file=large"}, nil
+	})
+
+	startSession(t, srv, rulespec.Rule{
+		ID:      "rule1",
+		Name:    "block admin upload",
+		Enabled: true,
+		Stage:   rulespec.StageRequest,
+		Match: rulespec.Match{
+			AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionBodyContains, Value: "user=admin"},
+			},
+		},
+		Actions: []rulespec.Action{
+			{Type: rulespec.ActionBlock, StatusCode: 403},
+		},
+	})
+
+	ev := pausedRequest("req1", "https://example.com/upload")
+	ev.Request.Method = "POST"
+	hasPostData := true
+	ev.Request.HasPostData = &hasPostData
+	networkID := network.RequestID("net1")
+	ev.NetworkID = &networkID
+
+	call := pauseUntil(t, srv, ev, "Fetch.fulfillRequest")
+	var args fetch.FulfillRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ResponseCode != 403 {
+		t.Errorf("got status %d, want 403", args.ResponseCode)
+	}
+}
+
+// waitHeld 等待断点暂停请求并返回断点状态
+func waitHeld(t *testing.T, ctx context.Context, svc *service.Orchestrator, id domain.SessionID) domain.BreakpointStatus {
+	t.Helper()
+	for {
+		status, err := svc.GetBreakpointStatus(ctx, id)
+		if err != nil {
+			t.Fatalf("GetBreakpointStatus() error = %v", err)
+		}
+		if len(status.Held) > 0 {
+			return status
+		}
+		if ctx.Err() != nil {
+			t.Fatal("request was not held at breakpoint")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBreakpoint_HoldsNextMatchingRequest(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.ArmBreakpoint(ctx, id, domain.BreakpointFilter{URLContains: "/checkout", Method: "GET"}); err != nil {
+		t.Fatalf("ArmBreakpoint() error = %v", err)
+	}
+
+	// 不匹配过滤条件的请求正常放行
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/home"), "Fetch.continueRequest")
+
+	if err := srv.Pause("page1", pausedRequest("req2", "https://example.com/checkout")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	status := waitHeld(t, ctx, svc, id)
+	if status.Armed || status.Filter != nil {
+		t.Errorf("breakpoint should disarm after first hit, got %+v", status)
+	}
+	if status.Held[0].ID != "req2" || status.Held[0].TargetID != "page1" {
+		t.Errorf("got held %+v, want req2 on page1", status.Held[0])
+	}
+
+	// 断点为一次性，之后的匹配请求不再暂停
+	if err := srv.Pause("page1", pausedRequest("req3", "https://example.com/checkout")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.continueRequest", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.ContinueRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.RequestID != "req3" {
+		t.Errorf("got continued %q, want req3", args.RequestID)
+	}
+
+	if err := svc.ResolveHeldRequest(ctx, id, "req2", true); err != nil {
+		t.Fatalf("ResolveHeldRequest() error = %v", err)
+	}
+	call, err = srv.WaitCall(ctx, "Fetch.continueRequest", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.RequestID != "req2" {
+		t.Errorf("got continued %q, want req2", args.RequestID)
+	}
+	if err := svc.ResolveHeldRequest(ctx, id, "req2", true); !errors.Is(err, domain.ErrRequestNotHeld) {
+		t.Errorf("resolving twice: got %v, want ErrRequestNotHeld", err)
+	}
+}
+
+func TestBreakpoint_Reject(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.ArmBreakpoint(ctx, id, domain.BreakpointFilter{}); err != nil {
+		t.Fatalf("ArmBreakpoint() error = %v", err)
+	}
+	if err := srv.Pause("page1", pausedRequest("req1", "https://example.com/a")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	waitHeld(t, ctx, svc, id)
+
+	if err := svc.ResolveHeldRequest(ctx, id, "req1", false); err != nil {
+		t.Fatalf("ResolveHeldRequest() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.failRequest", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.FailRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.RequestID != "req1" || args.ErrorReason != network.ErrorReasonBlockedByClient {
+		t.Errorf("got %+v, want req1 blocked by client", args)
+	}
+	for _, c := range srv.Calls() {
+		if c.Method == "Fetch.continueRequest" {
+			t.Error("rejected request should not be continued")
+		}
+	}
+}
+
+func TestBreakpoint_HoldTimeoutContinues(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.ArmBreakpoint(ctx, id, domain.BreakpointFilter{TimeoutMS: 200}); err != nil {
+		t.Fatalf("ArmBreakpoint() error = %v", err)
+	}
+	if err := srv.Pause("page1", pausedRequest("req1", "https://example.com/a")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	status := waitHeld(t, ctx, svc, id)
+	held := status.Held[0]
+	if held.ExpiresAt-held.HeldAt != 200 || held.RemainingMS <= 0 || held.RemainingMS > 200 {
+		t.Errorf("got held %+v, want 200ms timeout with remaining time", held)
+	}
+
+	// 超时后自动按规则放行
+	call, err := srv.WaitCall(ctx, "Fetch.continueRequest", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.ContinueRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.RequestID != "req1" {
+		t.Errorf("got continued %q, want req1", args.RequestID)
+	}
+	if err := svc.ResolveHeldRequest(ctx, id, "req1", true); !errors.Is(err, domain.ErrRequestNotHeld) {
+		t.Errorf("resolving expired request: got %v, want ErrRequestNotHeld", err)
+	}
+}
+
+func TestBreakpoint_SubscribeRedeliversQueue(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.ArmBreakpoint(ctx, id, domain.BreakpointFilter{}); err != nil {
+		t.Fatalf("ArmBreakpoint() error = %v", err)
+	}
+	if err := srv.Pause("page1", pausedRequest("req1", "https://example.com/a")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	waitHeld(t, ctx, svc, id)
+
+	// 模拟界面重新连接：新订阅者立即收到仍在等待的请求
+	subCtx, subCancel := context.WithCancel(ctx)
+	ch, err := svc.SubscribeBreakpoint(subCtx, id)
+	if err != nil {
+		t.Fatalf("SubscribeBreakpoint() error = %v", err)
+	}
+	status := <-ch
+	if len(status.Held) != 1 || status.Held[0].ID != "req1" {
+		t.Fatalf("got %+v, want req1 re-delivered", status)
+	}
+
+	if err := svc.ResolveHeldRequest(ctx, id, "req1", true); err != nil {
+		t.Fatalf("ResolveHeldRequest() error = %v", err)
+	}
+	select {
+	case status = <-ch:
+		if len(status.Held) != 0 {
+			t.Errorf("got %+v, want empty queue after resolve", status)
+		}
+	case <-ctx.Done():
+		t.Fatal("no status pushed after resolve")
+	}
+
+	subCancel()
+	for range ch {
+	}
+}
+
+func TestBreakpoint_ApproveEditedRequest(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.ArmBreakpoint(ctx, id, domain.BreakpointFilter{Stage: "body"}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("unknown stage: got %v, want ErrInvalidConfig", err)
+	}
+	if err := svc.ArmBreakpoint(ctx, id, domain.BreakpointFilter{}); err != nil {
+		t.Fatalf("ArmBreakpoint() error = %v", err)
+	}
+	ev := pausedRequest("req1", "https://example.com/api")
+	ev.Request.Method = "POST"
+	ev.Request.Headers = network.Headers(`{"Cookie":"a=1","X-Old":"1"}`)
+	hasBody := true
+	encoded := base64.StdEncoding.EncodeToString([]byte("hi"))
+	ev.Request.HasPostData = &hasBody
+	ev.Request.PostDataEntries = []network.PostDataEntry{{Bytes: &encoded}}
+	if err := srv.Pause("page1", ev); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	// 暂停的请求附带完整快照
+	held := waitHeld(t, ctx, svc, id).Held[0]
+	if held.Stage != domain.BreakpointRequest || held.Request == nil || held.Response != nil {
+		t.Fatalf("got held %+v, want request stage snapshot", held)
+	}
+	if string(held.Request.Body) != "hi" || held.Request.Cookies["a"] != "1" || held.Request.Headers["X-Old"] != "1" {
+		t.Errorf("got snapshot %+v, want body, cookies and headers", held.Request)
+	}
+
+	if err := svc.ApproveHeldResponse(ctx, id, "req1", &domain.Response{StatusCode: 200}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("approving response of request stage hold: got %v, want ErrInvalidConfig", err)
+	}
+	if err := svc.ApproveHeldRequest(ctx, id, "req1", &domain.Request{URL: "/relative"}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("relative url: got %v, want ErrInvalidConfig", err)
+	}
+	if status, _ := svc.GetBreakpointStatus(ctx, id); len(status.Held) != 1 {
+		t.Fatalf("rejected edits should keep the request held, got %+v", status)
+	}
+
+	body := []byte{0x00, 0x01, 0xfe, 0xff}
+	err := svc.ApproveHeldRequest(ctx, id, "req1", &domain.Request{
+		URL:     "https://api.example.com/v2?x=1",
+		Method:  "PUT",
+		Headers: domain.Header{"X-New": "1"},
+		Cookies: map[string]string{"sid": "abc"},
+		Body:    body,
+	})
+	if err != nil {
+		t.Fatalf("ApproveHeldRequest() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.continueRequest", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.ContinueRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.URL == nil || *args.URL != "https://api.example.com/v2?x=1" || args.Method == nil || *args.Method != "PUT" {
+		t.Errorf("got url %v method %v, want edited url and PUT", args.URL, args.Method)
+	}
+	if !bytes.Equal(args.PostData, body) {
+		t.Errorf("got post data %v, want %v", args.PostData, body)
+	}
+	headers := map[string]string{}
+	for _, h := range args.Headers {
+		headers[h.Name] = h.Value
+	}
+	if headers["X-New"] != "1" || headers["Cookie"] != "sid=abc" || headers["X-Old"] != "" {
+		t.Errorf("got headers %v, want X-New and rebuilt Cookie only", headers)
+	}
+}
+
+func TestBreakpoint_ApproveEditedResponse(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+		return fetch.GetResponseBodyReply{Body: `{"name":"old"}`}, nil
+	})
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := svc.ArmBreakpoint(ctx, id, domain.BreakpointFilter{Stage: domain.BreakpointResponse}); err != nil {
+		t.Fatalf("ArmBreakpoint() error = %v", err)
+	}
+	// 响应阶段的断点不暂停请求阶段
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api"), "Fetch.continueRequest")
+
+	status := 200
+	ev := pausedRequest("req1", "https://example.com/api")
+	ev.ResponseStatusCode = &status
+	ev.ResponseHeaders = []fetch.HeaderEntry{{Name: "Content-Type", Value: "application/json"}}
+	if err := srv.Pause("page1", ev); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	held := waitHeld(t, ctx, svc, id).Held[0]
+	if held.Stage != domain.BreakpointResponse || held.Response == nil || string(held.Response.Body) != `{"name":"old"}` {
+		t.Fatalf("got held %+v, want response stage snapshot with body", held)
+	}
+	if err := svc.ApproveHeldRequest(ctx, id, "req1", &domain.Request{Method: "PUT"}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("approving request of response stage hold: got %v, want ErrInvalidConfig", err)
+	}
+
+	if err := svc.ApproveHeldResponse(ctx, id, "req1", &domain.Response{StatusCode: 201, Body: []byte(`{"name":"new"}`)}); err != nil {
+		t.Fatalf("ApproveHeldResponse() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.fulfillRequest", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.FulfillRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ResponseCode != 201 || string(args.Body) != `{"name":"new"}` {
+		t.Errorf("got %d %q, want edited status and body", args.ResponseCode, args.Body)
+	}
+	if len(args.ResponseHeaders) != 1 || args.ResponseHeaders[0].Value != "application/json" {
+		t.Errorf("got headers %+v, want original headers kept", args.ResponseHeaders)
+	}
+}
+
+func TestCaptureTiming(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), CaptureTiming: true})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.EnableTrafficCapture(ctx, id, true); err != nil {
+		t.Fatalf("EnableTrafficCapture() error = %v", err)
+	}
+	traffic, err := svc.SubscribeTraffic(ctx, id)
+	if err != nil {
+		t.Fatalf("SubscribeTraffic() error = %v", err)
+	}
+
+	networkID := network.RequestID("net1")
+	ev := pausedRequest("req1", "https://example.com/api")
+	ev.NetworkID = &networkID
+	pauseUntil(t, srv, ev, "Fetch.continueRequest")
+
+	// 各阶段时间点为相对 requestTime 的毫秒数，-1 表示未发生（复用连接）
+	err = srv.Emit("page1", "Network.responseReceived", map[string]any{
+		"requestId": "net1",
+		"timestamp": 10.05,
+		"response": map[string]any{
+			"url":               "https://example.com/api",
+			"status":            200,
+			"encodedDataLength": 120,
+			"timing": map[string]any{
+				"requestTime":       10,
+				"dnsStart":          -1,
+				"dnsEnd":            -1,
+				"connectStart":      -1,
+				"connectEnd":        -1,
+				"sslStart":          -1,
+				"sslEnd":            -1,
+				"sendStart":         2,
+				"sendEnd":           3.5,
+				"receiveHeadersEnd": 43.5,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+
+	status := 200
+	resp := pausedRequest("req1", "https://example.com/api")
+	resp.NetworkID = &networkID
+	resp.ResponseStatusCode = &status
+	pauseUntil(t, srv, resp, "Fetch.continueResponse")
+
+	// 加载完成前事件等待计时
+	select {
+	case evt := <-traffic:
+		t.Fatalf("event %s delivered before loading finished", evt.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := srv.Emit("page1", "Network.loadingFinished", map[string]any{
+		"requestId":         "net1",
+		"timestamp":         10.0535,
+		"encodedDataLength": 1120,
+	}); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+
+	select {
+	case evt := <-traffic:
+		if evt.Response == nil {
+			t.Fatal("event has no response")
+		}
+		got := evt.Response.Timing
+		if got.DNS != 0 || got.Connect != 0 || got.Send != 1.5 || got.TTFB != 40 || got.Transfer != 10 {
+			t.Errorf("got phases %+v, want send 1.5 ttfb 40 transfer 10", got)
+		}
+		if got.TransferSize != 1120 || got.EncodedBodySize != 1000 {
+			t.Errorf("got sizes %d/%d, want 1120/1000", got.TransferSize, got.EncodedBodySize)
+		}
+	case <-ctx.Done():
+		t.Fatal("event not delivered after loading finished")
+	}
+}
+
+func TestReadOnlySession(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{
+		DevToolsURL:       srv.URL(),
+		PendingCapacity:   16,
+		ReadOnly:          true,
+		CorrelationHeader: "X-Request-ID",
+		CoalesceWindowMS:  1000,
+	})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "rule1", Name: "block rule", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/blocked"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}}
+	if err := svc.LoadRules(ctx, id, cfg); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.EnableTrafficCapture(ctx, id, true); err != nil {
+		t.Fatalf("EnableTrafficCapture() error = %v", err)
+	}
+	traffic, err := svc.SubscribeTraffic(ctx, id)
+	if err != nil {
+		t.Fatalf("SubscribeTraffic() error = %v", err)
+	}
+
+	// 命中拦截规则的请求与相同的重复请求都原样放行，且不注入关联 ID
+	call := pauseUntil(t, srv, pausedRequest("req1", "https://example.com/blocked"), "Fetch.continueRequest")
+	var args fetch.ContinueRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.URL != nil || len(args.Headers) != 0 {
+		t.Errorf("got modified continue %s, want unmodified", call.Params)
+	}
+	if err := srv.Pause("page1", pausedRequest("req2", "https://example.com/blocked")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.continueRequest", 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range srv.Calls() {
+		if c.Method == "Fetch.fulfillRequest" {
+			t.Errorf("read-only session fulfilled a request: %s", c.Params)
+		}
+	}
+
+	status := 200
+	ev := pausedRequest("req1", "https://example.com/blocked")
+	ev.ResponseStatusCode = &status
+	pauseUntil(t, srv, ev, "Fetch.continueResponse")
+	select {
+	case evt := <-traffic:
+		if evt.FinalResult != "passed" || evt.IsMatched {
+			t.Errorf("got result %s matched=%v, want passed without rule matches", evt.FinalResult, evt.IsMatched)
+		}
+	case <-ctx.Done():
+		t.Fatal("traffic event not recorded")
+	}
+
+	if err := svc.ArmBreakpoint(ctx, id, domain.BreakpointFilter{}); !errors.Is(err, domain.ErrSessionReadOnly) {
+		t.Errorf("ArmBreakpoint() error = %v, want ErrSessionReadOnly", err)
+	}
+}
+
+func TestCapabilityProfile(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	if _, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), CapabilityProfile: "unknown"}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Fatalf("StartSession() error = %v, want ErrInvalidConfig", err)
+	}
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), CapabilityProfile: domain.CapabilityMockOnly})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+
+	match := rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}}
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{
+		{ID: "mock", Name: "mock", Enabled: true, Stage: rulespec.StageRequest, Match: match,
+			Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 200, Body: "{}"}}},
+		// 已停用的规则不参与校验
+		{ID: "disabled", Name: "disabled", Enabled: false, Stage: rulespec.StageRequest, Match: match,
+			Actions: []rulespec.Action{{Type: rulespec.ActionSetUrl, Value: "https://other.example.com/"}}},
+	}
+	if err := svc.LoadRules(ctx, id, cfg); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+
+	cfg.Rules = append(cfg.Rules, rulespec.Rule{ID: "rewrite", Name: "rewrite", Enabled: true, Stage: rulespec.StageResponse, Match: match,
+		Actions: []rulespec.Action{{Type: rulespec.ActionSetHeader, Name: "X-Test", Value: "1"}}})
+	err = svc.LoadRules(ctx, id, cfg)
+	var ruleErr *domain.RuleError
+	if !errors.As(err, &ruleErr) || ruleErr.RuleID != "rewrite" {
+		t.Errorf("LoadRules() error = %v, want RuleError for rule rewrite", err)
+	}
+}
+
+func TestRuleSchedule(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), JournalDir: dir})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+
+	chaos := rulespec.NewConfig("chaos")
+	chaos.Rules = []rulespec.Rule{{
+		ID: "block", Name: "block", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 503}},
+	}}
+	baseline := rulespec.NewConfig("baseline")
+
+	invalid := &rulespec.Schedule{Windows: []rulespec.ScheduleWindow{{Start: "25:00", End: "04:00", Config: chaos}}}
+	if err := svc.SetRuleSchedule(ctx, id, invalid); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Fatalf("SetRuleSchedule() error = %v, want ErrInvalidConfig", err)
+	}
+
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 开始与结束时间相同的窗口全天生效
+	allDay := &rulespec.Schedule{
+		Windows: []rulespec.ScheduleWindow{{Name: "chaos", Start: "02:00", End: "02:00", Config: chaos}},
+		Default: baseline,
+	}
+	if err := svc.SetRuleSchedule(ctx, id, allDay); err != nil {
+		t.Fatalf("SetRuleSchedule() error = %v", err)
+	}
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api"), "Fetch.fulfillRequest")
+
+	// 当前时间不在窗口内时使用默认规则集
+	now := time.Now()
+	later := &rulespec.Schedule{
+		Windows: []rulespec.ScheduleWindow{{
+			Start:  now.Add(2 * time.Hour).Format("15:04"),
+			End:    now.Add(3 * time.Hour).Format("15:04"),
+			Config: chaos,
+		}},
+		Default: baseline,
+	}
+	if err := svc.SetRuleSchedule(ctx, id, later); err != nil {
+		t.Fatalf("SetRuleSchedule() error = %v", err)
+	}
+	pauseUntil(t, srv, pausedRequest("req2", "https://example.com/api"), "Fetch.continueRequest")
+
+	status, err := svc.GetRuleSchedule(ctx, id)
+	if err != nil {
+		t.Fatalf("GetRuleSchedule() error = %v", err)
+	}
+	if !status.Scheduled || status.ActiveWindow != "" || status.ActiveConfig != baseline.ID {
+		t.Errorf("got status %+v, want default config %s active", status, baseline.ID)
+	}
+	if status.NextSwitch <= now.UnixMilli() || status.NextSwitch > now.Add(2*time.Hour).UnixMilli() {
+		t.Errorf("got next switch %d, want window start within 2h", status.NextSwitch)
+	}
+	if len(status.Switches) != 2 || status.Switches[0].Window != "chaos" || status.Switches[0].ConfigID != chaos.ID ||
+		status.Switches[1].Window != "" || status.Switches[1].ConfigName != "baseline" {
+		t.Errorf("got switches %+v, want chaos then baseline", status.Switches)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "decisions-"+string(id)+".jsonl"))
+	if err != nil {
+		t.Fatalf("read journal: %v", err)
+	}
+	var configs []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e domain.DecisionEntry
+		if json.Unmarshal([]byte(line), &e) == nil && e.Action == "switchRules" {
+			configs = append(configs, e.Config)
+		}
+	}
+	if len(configs) != 2 || configs[0] != chaos.ID || configs[1] != baseline.ID {
+		t.Errorf("got journaled switches %v, want [%s %s]", configs, chaos.ID, baseline.ID)
+	}
+
+	// 取消计划后保留当前规则与切换记录
+	if err := svc.SetRuleSchedule(ctx, id, nil); err != nil {
+		t.Fatalf("SetRuleSchedule(nil) error = %v", err)
+	}
+	if status, _ := svc.GetRuleSchedule(ctx, id); status.Scheduled || len(status.Switches) != 2 {
+		t.Errorf("got status %+v after cancel, want unscheduled with history", status)
+	}
+}
+
+func TestWebSocketFrames(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "ws-error", Name: "ws error", Enabled: true, Stage: rulespec.StageWebSocket,
+		Match: rulespec.Match{AllOf: []rulespec.Condition{
+			{Type: rulespec.ConditionHeaderEquals, Name: "X-Client", Value: "app"},
+			{Type: rulespec.ConditionBodyContains, Value: "error"},
+		}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch, err := svc.SubscribeEvents(ctx, id, 0)
+	if err != nil {
+		t.Fatalf("SubscribeEvents() error = %v", err)
+	}
+
+	emit := func(method string, params any) {
+		t.Helper()
+		if err := srv.Emit("page1", method, params); err != nil {
+			t.Fatalf("Emit(%s) error = %v", method, err)
+		}
+	}
+	emit("Network.webSocketCreated", map[string]any{"requestId": "ws1", "url": "wss://example.com/socket?room=1"})
+	emit("Network.webSocketWillSendHandshakeRequest", map[string]any{
+		"requestId": "ws1", "timestamp": 1, "wallTime": 1,
+		"request": map[string]any{"headers": map[string]string{"X-Client": "app"}},
+	})
+	emit("Network.webSocketFrameSent", map[string]any{
+		"requestId": "ws1", "timestamp": 2,
+		"response": map[string]any{"opcode": 1, "mask": true, "payloadData": `{"op":"ping"}`},
+	})
+	emit("Network.webSocketFrameReceived", map[string]any{
+		"requestId": "ws1", "timestamp": 3,
+		"response": map[string]any{"opcode": 2, "mask": false, "payloadData": base64.StdEncoding.EncodeToString([]byte("error: busy"))},
+	})
+
+	// 只有匹配的帧进入匹配事件流，二进制帧按解码后的载荷匹配
+	select {
+	case evt := <-ch:
+		if evt.ID != "ws1#2" || evt.Frame == nil || evt.Frame.Direction != domain.WebSocketReceived || evt.Frame.Opcode != 2 {
+			t.Errorf("got event %s frame %+v, want received binary frame ws1#2", evt.ID, evt.Frame)
+		}
+		if string(evt.Request.Body) != "error: busy" || evt.Request.URL != "wss://example.com/socket?room=1" ||
+			evt.Request.ResourceType != domain.ResourceTypeWebSocket || len(evt.MatchedRules) != 1 {
+			t.Errorf("got request %+v with %d matched rules, want frame payload on handshake request", evt.Request, len(evt.MatchedRules))
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for frame event")
+	}
+
+	stats, err := svc.GetRuleStats(ctx, id)
+	if err != nil {
+		t.Fatalf("GetRuleStats() error = %v", err)
+	}
+	if stats.ByRule["ws-error"] != 1 {
+		t.Errorf("got %d matches for ws-error, want 1", stats.ByRule["ws-error"])
+	}
+}
+
+func TestLoadRules_InvalidRegex(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "block", Name: "block", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "regex:/blocked/\\d+$"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cfg := rulespec.NewConfig("invalid")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "broken", Name: "broken", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLRegex, Pattern: `(`}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}}
+	err := svc.LoadRules(ctx, id, cfg)
+	var ruleErr *domain.RuleError
+	if !errors.As(err, &ruleErr) || ruleErr.RuleID != "broken" {
+		t.Fatalf("LoadRules() error = %v, want RuleError for rule broken", err)
+	}
+
+	// 加载失败时原规则继续生效
+	pauseUntil(t, srv, pausedRequest("req1", "https://example.com/blocked/42"), "Fetch.fulfillRequest")
+}
+
+func TestWatchRulesFile(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	svc, id := startSession(t, srv)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	writeRules := func(path string, rules ...rulespec.Rule) {
+		t.Helper()
+		cfg := rulespec.NewConfig("watched")
+		cfg.Rules = rules
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block := func(id, url string) rulespec.Rule {
+		return rulespec.Rule{
+			ID: id, Name: id, Enabled: true, Stage: rulespec.StageRequest,
+			Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: url}}},
+			Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+		}
+	}
+	// outcome 推送暂停事件并返回对该请求的处理方法
+	outcome := func(reqID, url string) string {
+		t.Helper()
+		if err := srv.Pause("page1", pausedRequest(reqID, url)); err != nil {
+			t.Fatalf("Pause() error = %v", err)
+		}
+		for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			for _, c := range srv.Calls() {
+				var params struct {
+					RequestID string `json:"requestId"`
+				}
+				if json.Unmarshal(c.Params, &params) == nil && params.RequestID == reqID {
+					return c.Method
+				}
+			}
+		}
+		t.Fatalf("request %s was never resolved", reqID)
+		return ""
+	}
+	// waitOutcome 反复推送请求直到得到期望的处理方法，用于等待文件变化被重新加载
+	waitOutcome := func(prefix, url, want string) {
+		t.Helper()
+		for i := 0; i < 60; i++ {
+			if outcome(fmt.Sprintf("%s%d", prefix, i), url) == want {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("request to %s never resolved with %s", url, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := svc.WatchRulesFile(ctx, id, path); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Fatalf("WatchRulesFile() error = %v, want ErrInvalidConfig for missing file", err)
+	}
+
+	writeRules(path, block("first", "/first"))
+	if err := svc.WatchRulesFile(ctx, id, path); err != nil {
+		t.Fatalf("WatchRulesFile() error = %v", err)
+	}
+	if got := outcome("req1", "https://example.com/first"); got != "Fetch.fulfillRequest" {
+		t.Fatalf("got %s, want request blocked by watched rules", got)
+	}
+
+	// 保存后自动加载新规则
+	writeRules(path, block("second", "/second"))
+	waitOutcome("second", "https://example.com/second", "Fetch.fulfillRequest")
+
+	// 无效内容不替换当前规则
+	if err := os.WriteFile(path, []byte(`{"rules": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if got := outcome("req2", "https://example.com/second"); got != "Fetch.fulfillRequest" {
+		t.Errorf("got %s after invalid save, want previous rules kept", got)
+	}
+
+	// 停止监听后保存不再生效
+	if err := svc.WatchRulesFile(ctx, id, ""); err != nil {
+		t.Fatalf("WatchRulesFile(\"\") error = %v", err)
+	}
+	writeRules(path, block("third", "/third"))
+	time.Sleep(300 * time.Millisecond)
+	if got := outcome("req3", "https://example.com/third"); got != "Fetch.continueRequest" {
+		t.Errorf("got %s after unwatch, want file changes ignored", got)
+	}
+}
+
+func TestIntercept_Throttle(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), Concurrency: 1, PendingCapacity: 16, BandwidthLimit: 1000})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "slow", Name: "slow", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/slow"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionThrottle, LatencyMS: 300}},
+	}}
+	if err := svc.LoadRules(ctx, id, cfg); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 规则延迟的请求不占用唯一的处理并发，随后的请求先放行
+	start := time.Now()
+	if err := srv.Pause("page1", pausedRequest("req1", "https://example.com/slow")); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	postData := strings.Repeat("x", 100)
+	upload := pausedRequest("req2", "https://example.com/upload")
+	upload.Request.Method = "POST"
+	upload.Request.PostData = &postData
+	if err := srv.Pause("page1", upload); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.continueRequest", 2); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("throttled requests continued after %v, want at least 300ms", elapsed)
+	}
+	var order []fetch.RequestID
+	for _, c := range srv.Calls() {
+		if c.Method == "Fetch.continueRequest" {
+			var args fetch.ContinueRequestArgs
+			_ = json.Unmarshal(c.Params, &args)
+			order = append(order, args.RequestID)
+		}
+	}
+	// 100 字节的请求体按会话带宽上限 1000 字节/秒约需 100ms
+	if len(order) != 2 || order[0] != "req2" || order[1] != "req1" {
+		t.Errorf("got continue order %v, want [req2 req1]", order)
+	}
+
+	stats, err := svc.GetRuleStats(ctx, id)
+	if err != nil {
+		t.Fatalf("GetRuleStats() error = %v", err)
+	}
+	if stats.Throttled != 2 || stats.ThrottleDelayMS < 390 {
+		t.Errorf("got throttled %d with %dms delay, want 2 with about 400ms", stats.Throttled, stats.ThrottleDelayMS)
+	}
+}
+
+func TestIntercept_CompressedResponse(t *testing.T) {
+	plain := `{"name":"old"}`
+	compressed, err := transformer.EncodeContent([]byte(plain), transformer.EncodingGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		cfg        domain.SessionConfig
+		wantBody   string // 为空表示响应原样放行
+		wantHeader string // 下发的 Content-Encoding
+	}{
+		// 默认以明文应答并移除 Content-Encoding
+		{"明文应答", domain.SessionConfig{}, `{"name":"new"}`, ""},
+		{"重新压缩", domain.SessionConfig{RecompressBody: true}, `{"name":"new"}`, "gzip"},
+		// 解压后超过上限时保持压缩字节，文本替换跳过，响应原样放行
+		{"超过解压上限", domain.SessionConfig{MaxDecodedSize: 4}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := cdptest.NewServer()
+			defer srv.Close()
+			srv.AddTarget("page1", "https://example.com")
+			srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+				return fetch.GetResponseBodyReply{Body: base64.StdEncoding.EncodeToString(compressed), Base64Encoded: true}, nil
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			svc := service.New(logger.NewNop())
+			cfg := tt.cfg
+			cfg.DevToolsURL, cfg.PendingCapacity = srv.URL(), 16
+			id, err := svc.StartSession(ctx, cfg)
+			if err != nil {
+				t.Fatalf("StartSession() error = %v", err)
+			}
+			t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+			rules := rulespec.NewConfig("test")
+			rules.Rules = []rulespec.Rule{{
+				ID: "rule1", Name: "replace", Enabled: true, Stage: rulespec.StageResponse,
+				Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}},
+				Actions: []rulespec.Action{{Type: rulespec.ActionReplaceBodyText, Search: "old", Replace: "new"}},
+			}}
+			if err := svc.LoadRules(ctx, id, rules); err != nil {
+				t.Fatalf("LoadRules() error = %v", err)
+			}
+			if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+				t.Fatalf("AttachTarget() error = %v", err)
+			}
+			if err := svc.EnableInterception(ctx, id); err != nil {
+				t.Fatalf("EnableInterception() error = %v", err)
+			}
+			if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+				t.Fatal(err)
+			}
+
+			pauseUntil(t, srv, pausedRequest("req1", "https://example.com/api"), "Fetch.continueRequest")
+			status := 200
+			ev := pausedRequest("req1", "https://example.com/api")
+			ev.ResponseStatusCode = &status
+			ev.ResponseHeaders = []fetch.HeaderEntry{
+				{Name: "Content-Type", Value: "application/json"},
+				{Name: "Content-Encoding", Value: "gzip"},
+				{Name: "Content-Length", Value: strconv.Itoa(len(compressed))},
+			}
+			if tt.wantBody == "" {
+				pauseUntil(t, srv, ev, "Fetch.continueResponse")
+				return
+			}
+			call := pauseUntil(t, srv, ev, "Fetch.fulfillRequest")
+			var args fetch.FulfillRequestArgs
+			if err := json.Unmarshal(call.Params, &args); err != nil {
+				t.Fatal(err)
+			}
+			headers := make(map[string]string)
+			for _, h := range args.ResponseHeaders {
+				headers[h.Name] = h.Value
+			}
+			body := args.Body
+			if tt.wantHeader != "" {
+				if body, err = transformer.DecodeContent(args.Body, transformer.EncodingFor(tt.wantHeader), 0); err != nil {
+					t.Fatalf("fulfilled body is not %s: %v", tt.wantHeader, err)
+				}
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("got body %q, want %q", body, tt.wantBody)
+			}
+			if headers["Content-Encoding"] != tt.wantHeader {
+				t.Errorf("got Content-Encoding %q, want %q", headers["Content-Encoding"], tt.wantHeader)
+			}
+			if headers["Content-Length"] != strconv.Itoa(len(args.Body)) {
+				t.Errorf("got Content-Length %s, want %d", headers["Content-Length"], len(args.Body))
+			}
+		})
+	}
+}
+
+// recordedEvent 拦截一个请求并等待其匹配事件写入会话事件缓冲
+func recordedEvent(t *testing.T, srv *cdptest.Server, svc *service.Orchestrator, id domain.SessionID, url string) domain.NetworkEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := svc.SubscribeEvents(ctx, id, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := pausedRequest("req1", url)
+	ev.Request.Headers = network.Headers([]byte(`{"X-Token":"abc"}`))
+	pauseUntil(t, srv, ev, "Fetch.fulfillRequest")
+	select {
+	case evt := <-events:
+		return evt
+	case <-ctx.Done():
+		t.Fatal("no event recorded")
+		return domain.NetworkEvent{}
+	}
+}
+
+func TestReplayRequest_Native(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s %s", r.Method, r.Header.Get("X-Token"))
+	}))
+	defer upstream.Close()
+
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "rule1", Name: "block api", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	})
+	orig := recordedEvent(t, srv, svc, id, upstream.URL+"/api")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := svc.SubscribeEvents(ctx, id, orig.Seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 原样重放
+	evt, err := svc.Replay(ctx, id, domain.ReplayOptions{EventID: orig.ID, Mode: domain.ReplayNative})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if evt.FinalResult != domain.ReplayResult || evt.ID == orig.ID || evt.Target != "page1" {
+		t.Errorf("unexpected replay event: %+v", evt)
+	}
+	if evt.Response == nil || evt.Response.StatusCode != 200 || string(evt.Response.Body) != "GET abc" {
+		t.Errorf("got response %+v, want 200 GET abc", evt.Response)
+	}
+	select {
+	case recorded := <-events:
+		if recorded.ID != evt.ID || recorded.FinalResult != domain.ReplayResult {
+			t.Errorf("got recorded event %+v, want replay %s", recorded, evt.ID)
+		}
+	case <-ctx.Done():
+		t.Fatal("replay event not recorded")
+	}
+
+	// 修改后重放
+	mutated := orig.Request
+	mutated.Method = "POST"
+	mutated.Headers = domain.Header{"X-Token": "xyz"}
+	mutated.Body = []byte(`{"a":1}`)
+	evt, err = svc.Replay(ctx, id, domain.ReplayOptions{EventID: orig.ID, Mode: domain.ReplayNative, Request: &mutated})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if string(evt.Response.Body) != "POST xyz" {
+		t.Errorf("got body %q, want POST xyz", evt.Response.Body)
+	}
+	mu.Lock()
+	if len(bodies) != 2 || bodies[1] != `{"a":1}` {
+		t.Errorf("upstream got bodies %q, want the mutated body second", bodies)
+	}
+	mu.Unlock()
+	// 缓冲中的原事件不受修改影响
+	if orig.Request.Headers["X-Token"] != "abc" {
+		t.Errorf("original event headers changed: %v", orig.Request.Headers)
+	}
+
+	if _, err := svc.Replay(ctx, id, domain.ReplayOptions{EventID: "missing"}); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("got error %v, want ErrEventNotFound", err)
+	}
+	if _, err := svc.Replay(ctx, id, domain.ReplayOptions{EventID: orig.ID, Mode: "carrier-pigeon"}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got error %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestReplayRequest_Page(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Runtime.evaluate", func(_ string, params json.RawMessage) (any, error) {
+		return map[string]any{"result": map[string]any{
+			"type":  "object",
+			"value": map[string]any{"status": 201, "headers": map[string]string{"content-type": "text/plain"}, "body": base64.StdEncoding.EncodeToString([]byte("created"))},
+		}}, nil
+	})
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "rule1", Name: "block api", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	})
+	orig := recordedEvent(t, srv, svc, id, "https://example.com/api")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	evt, err := svc.Replay(ctx, id, domain.ReplayOptions{EventID: orig.ID})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if evt.Response == nil || evt.Response.StatusCode != 201 || string(evt.Response.Body) != "created" {
+		t.Errorf("got response %+v, want 201 created", evt.Response)
+	}
+
+	call, err := srv.WaitCall(ctx, "Runtime.evaluate", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args struct {
+		Expression   string `json:"expression"`
+		AwaitPromise bool   `json:"awaitPromise"`
+	}
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if call.TargetID != "page1" || !args.AwaitPromise || !strings.Contains(args.Expression, `"url":"https://example.com/api"`) || !strings.Contains(args.Expression, `"X-Token":"abc"`) {
+		t.Errorf("unexpected evaluate call on %s: %+v", call.TargetID, args)
+	}
+}
+
+func TestGetEvent(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "rule1", Name: "block api", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/api"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	})
+	orig := recordedEvent(t, srv, svc, id, "https://example.com/api")
+
+	ctx := context.Background()
+	evt, err := svc.GetEvent(ctx, id, orig.ID)
+	if err != nil {
+		t.Fatalf("GetEvent() error = %v", err)
+	}
+	if evt.Seq != orig.Seq || evt.Request.URL != orig.Request.URL || evt.FinalResult != "blocked" {
+		t.Errorf("got %+v, want %+v", evt, orig)
+	}
+	if _, err := svc.GetEvent(ctx, id, "missing"); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("got error %v, want ErrEventNotFound", err)
+	}
+	if _, err := svc.GetEvent(ctx, "missing", orig.ID); !errors.Is(err, domain.ErrSessionNotFound) {
+		t.Errorf("got error %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestInterceptScope(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	apiRule := rulespec.Rule{
+		ID:      "api",
+		Enabled: true,
+		Stage:   rulespec.StageResponse,
+		Match: rulespec.Match{AllOf: []rulespec.Condition{
+			{Type: rulespec.ConditionURLPrefix, Value: "https://api.example.com/v1/"},
+			{Type: rulespec.ConditionResourceType, Values: []string{"xhr", "fetch"}},
+		}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionSetStatus, Value: 500}},
+	}
+	svc, id := startSession(t, srv, apiRule)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	patterns := func(n int) []fetch.RequestPattern {
+		t.Helper()
+		call, err := srv.WaitCall(ctx, "Fetch.enable", n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var args fetch.EnableArgs
+		if err := json.Unmarshal(call.Params, &args); err != nil {
+			t.Fatal(err)
+		}
+		return args.Patterns
+	}
+	intercepts := func(ps []fetch.RequestPattern) []string {
+		var out []string
+		for _, p := range ps {
+			s := *p.URLPattern + " " + string(p.RequestStage)
+			if p.ResourceType != nil {
+				s += " " + string(*p.ResourceType)
+			}
+			out = append(out, s)
+		}
+		return out
+	}
+
+	want := []string{
+		"https://api.example.com/v1/* Request XHR",
+		"https://api.example.com/v1/* Response XHR",
+		"https://api.example.com/v1/* Request Fetch",
+		"https://api.example.com/v1/* Response Fetch",
+	}
+	if got := intercepts(patterns(1)); !slices.Equal(got, want) {
+		t.Errorf("got patterns %v, want %v", got, want)
+	}
+
+	// 正则条件无法收窄，重新加载规则后拦截所有请求
+	regexRule := apiRule
+	regexRule.Match = rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLRegex, Pattern: `/v[0-9]+/`}}}
+	if err := svc.LoadRules(ctx, id, &rulespec.Config{Rules: []rulespec.Rule{regexRule}}); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if got, want := intercepts(patterns(2)), []string{"* Request", "* Response"}; !slices.Equal(got, want) {
+		t.Errorf("got patterns %v, want %v", got, want)
+	}
+
+	// 全量流量捕获需要经过所有请求，不按规则收窄
+	if err := svc.LoadRules(ctx, id, &rulespec.Config{Rules: []rulespec.Rule{apiRule}}); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if got := intercepts(patterns(3)); len(got) != 4 {
+		t.Errorf("got patterns %v, want scoped patterns", got)
+	}
+	if err := svc.EnableTrafficCapture(ctx, id, true); err != nil {
+		t.Fatalf("EnableTrafficCapture() error = %v", err)
+	}
+	if got, want := intercepts(patterns(4)), []string{"* Request", "* Response"}; !slices.Equal(got, want) {
+		t.Errorf("got patterns %v, want %v", got, want)
+	}
+}
+
+func TestResourceTypeFilter(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	svc := service.New(logger.NewNop())
+	if _, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), ExcludeResourceTypes: []domain.ResourceType{"gif"}}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Fatalf("StartSession() with unknown type error = %v, want ErrInvalidConfig", err)
+	}
+	id, err := svc.StartSession(ctx, domain.SessionConfig{
+		DevToolsURL:          srv.URL(),
+		IncludeResourceTypes: []domain.ResourceType{domain.ResourceTypeDocument, domain.ResourceTypeXHR, domain.ResourceTypeFetch},
+		ExcludeResourceTypes: []domain.ResourceType{domain.ResourceTypeDocument},
+	})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	defer svc.StopSession(context.Background(), id)
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "block", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/blocked"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}}
+	if err := svc.LoadRules(ctx, id, cfg); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.enable", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.EnableArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range args.Patterns {
+		got = append(got, *p.URLPattern+" "+string(p.RequestStage)+" "+string(*p.ResourceType))
+	}
+	want := []string{"*/blocked* Request XHR", "*/blocked* Response XHR", "*/blocked* Request Fetch", "*/blocked* Response Fetch"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got patterns %v, want %v", got, want)
+	}
+
+	// 浏览器侧未筛掉的其他类型请求在处理时直接放行，不经过规则
+	img := pausedRequest("req1", "https://example.com/blocked.png")
+	img.ResourceType = network.ResourceTypeImage
+	pauseUntil(t, srv, img, "Fetch.continueRequest")
+	xhr := pausedRequest("req2", "https://example.com/blocked")
+	xhr.ResourceType = network.ResourceTypeXHR
+	pauseUntil(t, srv, xhr, "Fetch.fulfillRequest")
+	if stats, _ := svc.GetRuleStats(ctx, id); stats.Total != 1 {
+		t.Errorf("got %d evaluated requests, want 1", stats.Total)
+	}
+}
+
+func TestAuthRules(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	t.Setenv("TEST_AUTH_PASSWORD", "secret")
+
+	authRule := func(id, prefix string, spec rulespec.AuthSpec) rulespec.Rule {
+		return rulespec.Rule{
+			ID:      id,
+			Enabled: true,
+			Stage:   rulespec.StageRequest,
+			Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLPrefix, Value: prefix}}},
+			Actions: []rulespec.Action{{Type: rulespec.ActionAuth, Auth: &spec}},
+		}
+	}
+	svc, id := startSession(t, srv,
+		authRule("basic", "https://example.com/secure/", rulespec.AuthSpec{Source: domain.AuthSourceServer, Username: "alice", PasswordEnv: "TEST_AUTH_PASSWORD"}),
+		authRule("admin", "https://example.com/admin/", rulespec.AuthSpec{Mode: rulespec.AuthModeApprove}),
+		// 更高优先级的终止规则不含 auth 行为，不能遮蔽其后的 auth 规则
+		rulespec.Rule{
+			ID: "stop", Enabled: true, Priority: 100, Terminal: true, Stage: rulespec.StageRequest,
+			Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLPrefix, Value: "https://example.com/secure/"}}},
+			Actions: []rulespec.Action{{Type: rulespec.ActionSetHeader, Name: "X-Test", Value: "1"}},
+		},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	call, err := srv.WaitCall(ctx, "Fetch.enable", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enable fetch.EnableArgs
+	_ = json.Unmarshal(call.Params, &enable)
+	if enable.HandleAuthRequests == nil || !*enable.HandleAuthRequests {
+		t.Errorf("got Fetch.enable %s, want handleAuthRequests", call.Params)
+	}
+
+	calls := 0
+	respond := func(reqID fetch.RequestID, url string) fetch.AuthChallengeResponse {
+		t.Helper()
+		ev := fetch.AuthRequiredReply{
+			RequestID:     reqID,
+			Request:       network.Request{URL: url, Method: "GET"},
+			AuthChallenge: fetch.AuthChallenge{Origin: "https://example.com", Scheme: "basic", Realm: "test"},
+		}
+		if err := srv.Emit("page1", "Fetch.authRequired", ev); err != nil {
+			t.Fatalf("Emit() error = %v", err)
+		}
+		calls++
+		call, err := srv.WaitCall(ctx, "Fetch.continueWithAuth", calls)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var args fetch.ContinueWithAuthArgs
+		_ = json.Unmarshal(call.Params, &args)
+		return args.AuthChallengeResponse
+	}
+
+	if r := respond("auth1", "https://example.com/secure/a"); r.Response != "ProvideCredentials" || *r.Username != "alice" || *r.Password != "secret" {
+		t.Errorf("got %+v, want credentials from rule", r)
+	}
+	if r := respond("auth1", "https://example.com/secure/a"); r.Response != "CancelAuth" {
+		t.Errorf("got %q on repeated challenge, want CancelAuth", r.Response)
+	}
+	// 凭据被接受后请求再次暂停，记录随即删除，不会残留到之后的质询
+	if r := respond("auth4", "https://example.com/secure/b"); r.Response != "ProvideCredentials" {
+		t.Errorf("got %q, want credentials", r.Response)
+	}
+	pauseUntil(t, srv, pausedRequest("auth4", "https://example.com/secure/b"), "Fetch.continueRequest")
+	if r := respond("auth4", "https://example.com/secure/b"); r.Response != "ProvideCredentials" {
+		t.Errorf("got %q after the request paused again, want credentials for a fresh attempt", r.Response)
+	}
+	if r := respond("auth2", "https://example.com/public"); r.Response != "Default" {
+		t.Errorf("got %q without matching rule, want Default", r.Response)
+	}
+
+	// approve 方式暂停质询，等待人工处理
+	sub, err := svc.SubscribeAuthChallenges(ctx, id)
+	if err != nil {
+		t.Fatalf("SubscribeAuthChallenges() error = %v", err)
+	}
+	if initial := <-sub; len(initial) != 0 {
+		t.Errorf("got %d initial challenges, want 0", len(initial))
+	}
+	ev := fetch.AuthRequiredReply{
+		RequestID:     "auth3",
+		Request:       network.Request{URL: "https://example.com/admin/", Method: "GET"},
+		AuthChallenge: fetch.AuthChallenge{Origin: "https://example.com", Scheme: "ntlm"},
+	}
+	if err := srv.Emit("page1", "Fetch.authRequired", ev); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if pending := <-sub; len(pending) != 1 || pending[0].ID != "auth3" || pending[0].RuleID != "admin" || pending[0].Scheme != "ntlm" {
+		t.Fatalf("got %+v, want pending challenge auth3", pending)
+	}
+	if err := svc.ResolveAuthChallenge(ctx, id, "auth3", domain.AuthDecision{Response: domain.AuthProvide}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("providing without username: got %v, want ErrInvalidConfig", err)
+	}
+	if err := svc.ResolveAuthChallenge(ctx, id, "auth3", domain.AuthDecision{Response: domain.AuthProvide, Username: "bob", Password: "pw"}); err != nil {
+		t.Fatalf("ResolveAuthChallenge() error = %v", err)
+	}
+	call, err = srv.WaitCall(ctx, "Fetch.continueWithAuth", calls+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.ContinueWithAuthArgs
+	_ = json.Unmarshal(call.Params, &args)
+	if r := args.AuthChallengeResponse; r.Response != "ProvideCredentials" || *r.Username != "bob" {
+		t.Errorf("got %+v, want credentials from decision", r)
+	}
+	if err := svc.ResolveAuthChallenge(ctx, id, "auth3", domain.AuthDecision{Response: domain.AuthCancel}); !errors.Is(err, domain.ErrChallengeNotPending) {
+		t.Errorf("resolving twice: got %v, want ErrChallengeNotPending", err)
+	}
+	if list, _ := svc.ListAuthChallenges(ctx, id); len(list) != 0 {
+		t.Errorf("got %d pending challenges, want 0", len(list))
+	}
+}
+
+func TestAuthRules_ContinueFails(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.continueWithAuth", func(targetID string, params json.RawMessage) (any, error) {
+		return nil, errors.New("continue failed")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 应答失败时请求仍暂停在浏览器中，必须记录日志
+	dir := t.TempDir()
+	svc := service.New(logger.New(logger.Options{Level: "info", Format: "json", Writers: []string{"file"}, Dir: dir}))
+	id, err := svc.StartSession(ctx, domain.SessionConfig{DevToolsURL: srv.URL(), PendingCapacity: 16})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	t.Cleanup(func() { _ = svc.StopSession(context.Background(), id) })
+	cfg := rulespec.NewConfig("test")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "cancel", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLPrefix, Value: "https://example.com/secure/"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionAuth, Auth: &rulespec.AuthSpec{Mode: rulespec.AuthModeCancel}}},
+	}}
+	if err := svc.LoadRules(ctx, id, cfg); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+	if err := svc.AttachTarget(ctx, id, "page1"); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if err := svc.EnableInterception(ctx, id); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := fetch.AuthRequiredReply{
+		RequestID:     "auth1",
+		Request:       network.Request{URL: "https://example.com/secure/a", Method: "GET"},
+		AuthChallenge: fetch.AuthChallenge{Origin: "https://example.com", Scheme: "basic"},
+	}
+	if err := srv.Emit("page1", "Fetch.authRequired", ev); err != nil {
+		t.Fatalf("Emit() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.continueWithAuth", 1); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		data, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+		if line := logLine(data, "应答认证质询失败，请求仍暂停在浏览器中"); line != "" {
+			if !strings.Contains(line, `"sessionID":"`+string(id)+`"`) || !strings.Contains(line, "continue failed") {
+				t.Errorf("got log %s, want session id and cause", line)
+			}
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("continueWithAuth failure was not logged, log:\n%s", data)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// logLine 返回日志中包含 msg 的第一行，不存在时返回空
+func logLine(data []byte, msg string) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.Contains(line, msg) {
+			return line
+		}
+	}
+	return ""
+}
+
+func TestBrowserCookies(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Network.getCookies", func(targetID string, params json.RawMessage) (any, error) {
+		return network.GetCookiesReply{Cookies: []network.Cookie{
+			{Name: "sid", Value: "abc", Domain: "example.com", Path: "/", Expires: -1, Session: true, HTTPOnly: true},
+		}}, nil
+	})
+
+	svc, id := startSession(t, srv, rulespec.Rule{
+		ID: "login", Enabled: true, Stage: rulespec.StageRequest,
+		Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/app"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionSetBrowserCookie, Name: "token", Value: "t1",
+			Cookie: &rulespec.CookieSpec{Path: "/", MaxAge: 3600, HTTPOnly: true}}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cookies, err := svc.ListCookies(ctx, id, "page1", nil)
+	if err != nil {
+		t.Fatalf("ListCookies() error = %v", err)
+	}
+	if len(cookies) != 1 || cookies[0].Name != "sid" || !cookies[0].Session || cookies[0].Expires != 0 {
+		t.Errorf("got cookies %+v, want session cookie sid", cookies)
+	}
+
+	if err := svc.SetCookie(ctx, id, "page1", domain.BrowserCookie{Name: "lang", Value: "en", URL: "https://example.com"}); err != nil {
+		t.Fatalf("SetCookie() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Network.setCookie", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set network.SetCookieArgs
+	if err := json.Unmarshal(call.Params, &set); err != nil {
+		t.Fatal(err)
+	}
+	if set.Name != "lang" || set.Value != "en" || set.URL == nil || *set.URL != "https://example.com" {
+		t.Errorf("got setCookie %+v, want lang=en for https://example.com", set)
+	}
+	if err := svc.SetCookie(ctx, id, "page1", domain.BrowserCookie{Name: "lang", Value: "en"}); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+
+	if err := svc.DeleteCookies(ctx, id, "page1", domain.CookieFilter{Name: "sid", Domain: "example.com"}); err != nil {
+		t.Fatalf("DeleteCookies() error = %v", err)
+	}
+	call, err = srv.WaitCall(ctx, "Network.deleteCookies", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var del network.DeleteCookiesArgs
+	if err := json.Unmarshal(call.Params, &del); err != nil {
+		t.Fatal(err)
+	}
+	if del.Name != "sid" || del.Domain == nil || *del.Domain != "example.com" {
+		t.Errorf("got deleteCookies %+v, want sid on example.com", del)
+	}
+	if _, err := svc.ListCookies(ctx, id, "page2", nil); !errors.Is(err, domain.ErrTargetNotAttached) {
+		t.Errorf("got %v, want ErrTargetNotAttached", err)
+	}
+
+	// 规则写入的 Cookie 在请求放行前写入浏览器，当前请求同时携带该 Cookie
+	call = pauseUntil(t, srv, pausedRequest("req1", "https://example.com/app"), "Fetch.continueRequest")
+	var cont fetch.ContinueRequestArgs
+	if err := json.Unmarshal(call.Params, &cont); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(cont.Headers, func(h fetch.HeaderEntry) bool {
+		return strings.EqualFold(h.Name, "Cookie") && strings.Contains(h.Value, "token=t1")
+	}) {
+		t.Errorf("got headers %+v, want Cookie with token=t1", cont.Headers)
+	}
+	call, err = srv.WaitCall(ctx, "Network.setCookie", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set = network.SetCookieArgs{}
+	if err := json.Unmarshal(call.Params, &set); err != nil {
+		t.Fatal(err)
+	}
+	if set.Name != "token" || set.Value != "t1" || set.URL == nil || *set.URL != "https://example.com/app" ||
+		set.HTTPOnly == nil || !*set.HTTPOnly || set.Expires == 0 {
+		t.Errorf("got setCookie %+v, want persistent token=t1 for the request URL", set)
+	}
+	var setAt, contAt int
+	for i, c := range srv.Calls() {
+		switch c.Method {
+		case "Network.setCookie":
+			setAt = i
+		case "Fetch.continueRequest":
+			contAt = i
+		}
+	}
+	if setAt > contAt {
+		t.Error("cookie persisted after the request was continued")
+	}
+}
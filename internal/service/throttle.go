@@ -0,0 +1,91 @@
+package service
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cdpnetool/internal/processor"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+)
+
+// shaper 会话的节流状态：按会话带宽上限模拟的共享链路与节流统计，并发安全
+type shaper struct {
+	mu        sync.Mutex
+	limit     int       // 会话带宽上限（字节/秒），0 表示不限制
+	busyUntil time.Time // 共享链路传输完已排队消息体的时间
+	count     int64
+	total     time.Duration
+}
+
+// newShaper 创建会话的节流状态
+func newShaper(limit int) *shaper {
+	return &shaper{limit: limit}
+}
+
+// wait 计算下发结果前需要等待的时间并计入统计。消息体依次排队在共享链路上按会话带宽上限传输，
+// 与按规则带宽上限单独传输的时间取较长者，再加上规则要求的额外延迟
+func (s *shaper) wait(now time.Time, t processor.Throttle, size int) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transfer := t.Transfer(size)
+	if s.limit > 0 && size > 0 {
+		start := now
+		if s.busyUntil.After(now) {
+			start = s.busyUntil
+		}
+		s.busyUntil = start.Add(processor.TransferTime(size, s.limit))
+		transfer = max(transfer, s.busyUntil.Sub(now))
+	}
+	d := t.Latency + transfer
+	if d > 0 {
+		s.count++
+		s.total += d
+	}
+	return d
+}
+
+// stats 返回被延迟放行的次数与累计延迟毫秒数
+func (s *shaper) stats() (int64, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count, s.total.Milliseconds()
+}
+
+// throttleSize 估算结果下发时经过模拟链路的消息体字节数：
+// 应答或修改后的消息体按实际大小，原样放行的请求按暂停事件中的请求体，原样放行的响应按 Content-Length，
+// 以网络错误结束的请求不传输消息体
+func throttleSize(ev *fetch.RequestPausedReply, res processor.Result) int {
+	isRequest := ev.ResponseStatusCode == nil
+	switch {
+	case res.Action == processor.ActionFail:
+		return 0
+	case res.Action == processor.ActionBlock && res.MockRes != nil:
+		return len(res.MockRes.Body)
+	case isRequest && res.ModifiedReq != nil:
+		return len(res.ModifiedReq.Body)
+	case isRequest:
+		if ev.Request.PostData != nil {
+			return len(*ev.Request.PostData)
+		}
+		n := 0
+		for _, e := range ev.Request.PostDataEntries {
+			if e.Bytes != nil {
+				n += base64.StdEncoding.DecodedLen(len(*e.Bytes))
+			}
+		}
+		return n
+	case !res.HeadersOnly && res.ModifiedRes != nil:
+		return len(res.ModifiedRes.Body)
+	}
+	for _, h := range ev.ResponseHeaders {
+		if strings.EqualFold(h.Name, "Content-Length") {
+			n, _ := strconv.Atoi(strings.TrimSpace(h.Value))
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+
+	"cdpnetool/internal/adapter/cdp"
+	"cdpnetool/internal/processor"
+	"cdpnetool/internal/tracing"
+	"cdpnetool/pkg/domain"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxTraces 保留追踪上下文的请求数上限，超出时整体清空
+const maxTraces = 4096
+
+// startEventSpan 开始处理暂停事件的 span。同一请求的响应阶段沿用请求阶段的追踪，使一个请求的处理链路位于同一条 trace 中；
+// 开启追踪时记下该 span，之后的行为执行与 CDP 调用（包括断点恢复与节流后的下发）都记录在它之下
+func (o *Orchestrator) startEventSpan(state *sessionState, ts *cdp.TargetSession, ev *fetch.RequestPausedReply, stage string) trace.Span {
+	_, span := tracing.Tracer().Start(state.traceContext(ev.RequestID), "handleEvent", trace.WithAttributes(
+		attribute.String("cdpnetool.session.id", string(state.id)),
+		attribute.String("cdpnetool.target.id", string(ts.ID)),
+		attribute.String("cdpnetool.request.id", string(ev.RequestID)),
+		attribute.String("cdpnetool.stage", stage),
+		attribute.String("http.request.method", ev.Request.Method),
+		attribute.String("url.full", ev.Request.URL),
+	))
+	if sc := span.SpanContext(); sc.IsValid() {
+		state.mu.Lock()
+		if state.traces == nil || len(state.traces) >= maxTraces {
+			state.traces = make(map[fetch.RequestID]trace.SpanContext)
+		}
+		state.traces[ev.RequestID] = sc
+		state.mu.Unlock()
+	}
+	return span
+}
+
+// traceContext 返回处理该请求时使用的 context，带有最近一次处理其暂停事件的 span；未开启追踪时即会话的 context
+func (s *sessionState) traceContext(id fetch.RequestID) context.Context {
+	s.mu.Lock()
+	sc, ok := s.traces[id]
+	s.mu.Unlock()
+	if !ok {
+		return s.ctx
+	}
+	return trace.ContextWithSpanContext(s.ctx, sc)
+}
+
+// startCDPSpan 开始一次 CDP 调用的 span
+func startCDPSpan(state *sessionState, id fetch.RequestID, method string) trace.Span {
+	_, span := tracing.Tracer().Start(state.traceContext(id), method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("rpc.system", "cdp"), attribute.String("rpc.method", method)))
+	return span
+}
+
+// endResultSpan 以最终下发的 CDP 方法命名并结束下发结果的 span；请求不会再有后续阶段时不再保留其追踪上下文
+func endResultSpan(state *sessionState, span trace.Span, ev *fetch.RequestPausedReply, res processor.Result, entry domain.DecisionEntry) {
+	if entry.Call != "" {
+		span.SetName(entry.Call)
+		span.SetAttributes(attribute.String("rpc.method", entry.Call))
+	}
+	span.SetAttributes(attribute.String("cdpnetool.action", string(res.Action)), attribute.Bool("cdpnetool.degraded", entry.Degraded))
+	if entry.Error != "" {
+		span.SetStatus(codes.Error, entry.Error)
+	}
+	span.End()
+
+	if ev.ResponseStatusCode != nil || res.WebSocket || res.Action == processor.ActionBlock || res.Action == processor.ActionFail {
+		state.mu.Lock()
+		delete(state.traces, ev.RequestID)
+		state.mu.Unlock()
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// rulesWatchDebounce 文件变化后等待的时间，合并编辑器一次保存产生的多个写入事件
+const rulesWatchDebounce = 100 * time.Millisecond
+
+// rulesWatch 会话的规则文件监听状态
+type rulesWatch struct {
+	path    string // 规则文件的绝对路径
+	watcher *fsnotify.Watcher
+}
+
+// WatchRulesFile 从 path 加载规则配置 JSON，并在文件变化时重新解析、校验后原子替换规则引擎；
+// 重新加载失败时记录日志并保留当前规则。监听的是文件所在目录，编辑器以重命名方式保存时同样生效。
+// 已在监听时替换为新文件，path 为空时停止监听并保留当前规则
+func (o *Orchestrator) WatchRulesFile(ctx context.Context, id domain.SessionID, path string) error {
+	state, ok := o.get(id)
+	if !ok {
+		return domain.ErrSessionNotFound
+	}
+
+	var w *rulesWatch
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+		}
+		if err := reloadRulesFile(state, abs); err != nil {
+			return err
+		}
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		if err := watcher.Add(filepath.Dir(abs)); err != nil {
+			_ = watcher.Close()
+			return fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+		}
+		w = &rulesWatch{path: abs, watcher: watcher}
+	}
+
+	state.mu.Lock()
+	old := state.rulesWatch
+	state.rulesWatch = w
+	state.mu.Unlock()
+	if old != nil {
+		_ = old.watcher.Close()
+	}
+
+	if w == nil {
+		o.log.Info("已停止监听规则文件", "sessionID", string(id))
+		return nil
+	}
+	go o.watchRules(state, w)
+	o.log.Info("开始监听规则文件", "sessionID", string(id), "path", w.path)
+	return nil
+}
+
+// watchRules 处理文件变化事件，防抖后重新加载规则，直到监听被关闭
+func (o *Orchestrator) watchRules(state *sessionState, w *rulesWatch) {
+	var reload <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// 删除与重命名不触发加载，以重命名方式保存时随后的 Create 事件会触发
+			if filepath.Clean(ev.Name) == w.path && ev.Has(fsnotify.Write|fsnotify.Create) {
+				reload = time.After(rulesWatchDebounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			o.log.Err(err, "监听规则文件出错", "sessionID", string(state.id), "path", w.path)
+		case <-reload:
+			reload = nil
+			state.mu.Lock()
+			current := state.rulesWatch == w
+			state.mu.Unlock()
+			if !current {
+				return
+			}
+			if err := reloadRulesFile(state, w.path); err != nil {
+				o.log.Err(err, "重新加载规则文件失败，保留当前规则", "sessionID", string(state.id), "path", w.path)
+				continue
+			}
+			o.refreshScope(state.ctx, state)
+			o.log.Info("已重新加载规则文件", "sessionID", string(state.id), "path", w.path)
+		}
+	}
+}
+
+// reloadRulesFile 读取并解析规则文件，校验通过后加载到会话
+func reloadRulesFile(state *sessionState, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+	}
+	cfg, _, err := rulespec.ParseConfig(data)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidConfig) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+	}
+	return loadRules(state, cfg)
+}
+
+// stopRulesWatch 关闭规则文件监听，会话停止时调用
+func (s *sessionState) stopRulesWatch() {
+	s.mu.Lock()
+	w := s.rulesWatch
+	s.rulesWatch = nil
+	s.mu.Unlock()
+	if w != nil {
+		_ = w.watcher.Close()
+	}
+}
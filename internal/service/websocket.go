@@ -0,0 +1,19 @@
+package service
+
+import (
+	"cdpnetool/pkg/domain"
+)
+
+// frameSink 将目标的 WebSocket 消息帧交给处理器，实现 cdp.WebSocketSink
+type frameSink struct {
+	state  *sessionState
+	target domain.TargetID
+}
+
+// Frame 与 HTTP 请求一致，拦截、全量流量捕获等均未开启时不处理
+func (s *frameSink) Frame(conn *domain.Request, frame domain.WebSocketFrame, payload []byte) {
+	if !s.state.processingEnabled() {
+		return
+	}
+	s.state.processor.ProcessFrame(string(s.state.id), string(s.target), conn, frame, payload)
+}
@@ -38,6 +38,14 @@ func (s *Session) RemoveTarget(id domain.TargetID) {
 	delete(s.targets, id)
 }
 
+// HasTarget 判断目标是否已关联
+func (s *Session) HasTarget(id domain.TargetID) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.targets[id]
+	return ok
+}
+
 // GetTargets 获取所有关联的目标 ID
 func (s *Session) GetTargets() []domain.TargetID {
 	s.mu.RLock()
@@ -79,6 +79,18 @@ func TestRemoveTarget(t *testing.T) {
 	}
 }
 
+func TestHasTarget(t *testing.T) {
+	sess := session.New("session1")
+	sess.AddTarget("target1")
+
+	if !sess.HasTarget("target1") {
+		t.Error("HasTarget(target1) = false, want true")
+	}
+	if sess.HasTarget("target2") {
+		t.Error("HasTarget(target2) = true, want false")
+	}
+}
+
 func TestRemoveTarget_NotExists(t *testing.T) {
 	sess := session.New("session1")
 	sess.AddTarget("target1")
@@ -0,0 +1,157 @@
+// Package sessiondiff 对比两次会话的事件历史，用于规则集或应用版本变更前后的对照
+package sessiondiff
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"cdpnetool/internal/storage/model"
+	"cdpnetool/pkg/domain"
+)
+
+// key 接口标识
+type key struct {
+	method   string
+	endpoint string
+}
+
+// aggregate 单个接口的累计数据
+type aggregate struct {
+	count         int64
+	status        map[int]int64
+	requestBytes  int64
+	responseBytes int64
+}
+
+// Compare 对比 from 与 to 两次会话的事件记录，接口按方法与不含查询参数的 URL 归并
+func Compare(fromID, toID domain.SessionID, from, to []model.NetworkEventRecord) domain.SessionDiff {
+	diff := domain.SessionDiff{
+		From:    fromID,
+		To:      toID,
+		Added:   []domain.EndpointStats{},
+		Removed: []domain.EndpointStats{},
+		Changed: []domain.EndpointChange{},
+	}
+
+	before := collect(from)
+	after := collect(to)
+
+	for k, a := range after {
+		b, ok := before[k]
+		if !ok {
+			diff.Added = append(diff.Added, a)
+			continue
+		}
+		change := domain.EndpointChange{
+			Method:             k.method,
+			Endpoint:           k.endpoint,
+			From:               b,
+			To:                 a,
+			StatusChanged:      a.MainStatus != b.MainStatus,
+			RequestBytesDelta:  a.AvgRequestBytes - b.AvgRequestBytes,
+			ResponseBytesDelta: a.AvgResponseBytes - b.AvgResponseBytes,
+		}
+		if change.StatusChanged || change.RequestBytesDelta != 0 || change.ResponseBytesDelta != 0 {
+			diff.Changed = append(diff.Changed, change)
+		} else {
+			diff.Unchanged++
+		}
+	}
+	for k, b := range before {
+		if _, ok := after[k]; !ok {
+			diff.Removed = append(diff.Removed, b)
+		}
+	}
+
+	sortStats(diff.Added)
+	sortStats(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return less(diff.Changed[i].Endpoint, diff.Changed[i].Method, diff.Changed[j].Endpoint, diff.Changed[j].Method)
+	})
+	return diff
+}
+
+// collect 按接口汇总事件记录
+func collect(records []model.NetworkEventRecord) map[key]domain.EndpointStats {
+	aggs := make(map[key]*aggregate)
+	for i := range records {
+		rec := &records[i]
+		k := key{method: strings.ToUpper(rec.Method), endpoint: Endpoint(rec.URL)}
+		a, ok := aggs[k]
+		if !ok {
+			a = &aggregate{status: make(map[int]int64)}
+			aggs[k] = a
+		}
+		a.count++
+		a.status[rec.StatusCode]++
+		a.requestBytes += bodySize[domain.Request](rec.RequestJSON, func(r *domain.Request) []byte { return r.Body })
+		a.responseBytes += bodySize[domain.Response](rec.ResponseJSON, func(r *domain.Response) []byte { return r.Body })
+	}
+
+	stats := make(map[key]domain.EndpointStats, len(aggs))
+	for k, a := range aggs {
+		stats[k] = domain.EndpointStats{
+			Method:           k.method,
+			Endpoint:         k.endpoint,
+			Count:            a.count,
+			Status:           a.status,
+			MainStatus:       mainStatus(a.status),
+			AvgRequestBytes:  a.requestBytes / a.count,
+			AvgResponseBytes: a.responseBytes / a.count,
+		}
+	}
+	return stats
+}
+
+// Endpoint 去掉 URL 的查询参数与片段，无法解析时原样返回
+func Endpoint(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// bodySize 从序列化的请求或响应中取出消息体长度，解析失败视为 0
+func bodySize[T any](raw string, body func(*T) []byte) int64 {
+	if raw == "" || raw == "null" {
+		return 0
+	}
+	var v T
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return 0
+	}
+	return int64(len(body(&v)))
+}
+
+// mainStatus 返回出现次数最多的状态码，次数相同时取较小的状态码
+func mainStatus(status map[int]int64) int {
+	best, bestN := 0, int64(-1)
+	for code, n := range status {
+		if n > bestN || (n == bestN && code < best) {
+			best, bestN = code, n
+		}
+	}
+	return best
+}
+
+// sortStats 按接口与方法排序
+func sortStats(stats []domain.EndpointStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		return less(stats[i].Endpoint, stats[i].Method, stats[j].Endpoint, stats[j].Method)
+	})
+}
+
+// less 先按接口、再按方法比较
+func less(endpointA, methodA, endpointB, methodB string) bool {
+	if endpointA != endpointB {
+		return endpointA < endpointB
+	}
+	return methodA < methodB
+}
@@ -0,0 +1,71 @@
+package sessiondiff_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"cdpnetool/internal/sessiondiff"
+	"cdpnetool/internal/storage/model"
+	"cdpnetool/pkg/domain"
+)
+
+func record(method, url string, status int, body string) model.NetworkEventRecord {
+	res, _ := json.Marshal(domain.Response{StatusCode: status, Body: []byte(body)})
+	req, _ := json.Marshal(domain.Request{URL: url, Method: method})
+	return model.NetworkEventRecord{
+		URL:          url,
+		Method:       method,
+		StatusCode:   status,
+		RequestJSON:  string(req),
+		ResponseJSON: string(res),
+	}
+}
+
+func TestCompare(t *testing.T) {
+	from := []model.NetworkEventRecord{
+		record("GET", "https://example.com/api/user?id=1", 200, "0123456789"),
+		record("GET", "https://example.com/api/user?id=2", 200, "0123456789"),
+		record("GET", "https://example.com/api/legacy", 200, ""),
+		record("POST", "https://example.com/api/login", 200, "ok"),
+		record("GET", "https://example.com/static/app.js", 200, "js"),
+	}
+	to := []model.NetworkEventRecord{
+		record("GET", "https://example.com/api/user?id=3", 200, "01234567890123456789"),
+		record("POST", "https://example.com/api/login", 500, "ok"),
+		record("GET", "https://example.com/api/v2/items", 200, ""),
+		record("GET", "https://example.com/static/app.js#top", 200, "js"),
+	}
+
+	diff := sessiondiff.Compare("a", "b", from, to)
+	if diff.From != "a" || diff.To != "b" {
+		t.Errorf("unexpected session ids %q -> %q", diff.From, diff.To)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Endpoint != "https://example.com/api/v2/items" {
+		t.Errorf("unexpected added endpoints %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Endpoint != "https://example.com/api/legacy" {
+		t.Errorf("unexpected removed endpoints %+v", diff.Removed)
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("got %d unchanged endpoints, want 1", diff.Unchanged)
+	}
+	if len(diff.Changed) != 2 {
+		t.Fatalf("got %d changed endpoints, want 2: %+v", len(diff.Changed), diff.Changed)
+	}
+
+	// 按接口排序：login 在 user 之前
+	login, user := diff.Changed[0], diff.Changed[1]
+	if login.Method != "POST" || !login.StatusChanged || login.From.MainStatus != 200 || login.To.MainStatus != 500 {
+		t.Errorf("unexpected login change %+v", login)
+	}
+	if user.StatusChanged || user.ResponseBytesDelta != 10 || user.From.Count != 2 || user.To.Count != 1 {
+		t.Errorf("unexpected user change %+v", user)
+	}
+}
+
+func TestCompare_Empty(t *testing.T) {
+	diff := sessiondiff.Compare("a", "b", nil, nil)
+	if diff.Added == nil || diff.Removed == nil || diff.Changed == nil || diff.Unchanged != 0 {
+		t.Errorf("got %+v, want empty non-nil slices", diff)
+	}
+}
@@ -0,0 +1,259 @@
+// Package signer 在请求被规则修改后重新计算签名，避免服务端因签名与修改后的内容不一致而拒绝请求
+package signer
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+// HMAC 签名的默认值
+const (
+	DefaultHeader    = "X-Signature"
+	DefaultAlgorithm = "sha256"
+	DefaultEncoding  = "hex"
+	DefaultPayload   = "{body}"
+	DefaultTemplate  = "{signature}"
+)
+
+// HMACOptions HMAC 签名参数，空字段使用默认值
+type HMACOptions struct {
+	Key       []byte // 签名密钥
+	Header    string // 写入签名的请求头
+	Algorithm string // sha256、sha1 或 sha512
+	Encoding  string // hex 或 base64
+	Payload   string // 待签名内容模板
+	Template  string // 请求头值模板，可引用 {signature}
+}
+
+// HMAC 按模板计算请求的 HMAC 签名并写入请求头。
+// 模板支持 {body}、{method}、{url}、{host}、{path}、{timestamp}、{timestampMs}、{bodySha256} 与 {header:名称}
+func HMAC(req *domain.Request, opts HMACOptions, now time.Time) error {
+	newHash, err := hashFunc(cmp.Or(opts.Algorithm, DefaultAlgorithm))
+	if err != nil {
+		return err
+	}
+	encode, err := encoder(cmp.Or(opts.Encoding, DefaultEncoding))
+	if err != nil {
+		return err
+	}
+	vars, err := templateVars(req, now)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(newHash, opts.Key)
+	mac.Write([]byte(expand(cmp.Or(opts.Payload, DefaultPayload), vars, req.Headers)))
+	vars["signature"] = encode(mac.Sum(nil))
+
+	name := cmp.Or(opts.Header, DefaultHeader)
+	delHeader(req.Headers, name)
+	req.Headers.Set(name, expand(cmp.Or(opts.Template, DefaultTemplate), vars, req.Headers))
+	return nil
+}
+
+// Credentials AWS 访问凭证
+type Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // 临时凭证的会话令牌，可为空
+}
+
+// SigV4 按 AWS Signature Version 4 重新签名请求，覆盖原有的 Authorization 与 X-Amz-* 签名头部。
+// 仅签名 host、x-amz-date 与 x-amz-security-token，S3 额外签名 x-amz-content-sha256
+func SigV4(req *domain.Request, cred Credentials, region, service string, now time.Time) error {
+	if cred.AccessKey == "" || cred.SecretKey == "" {
+		return fmt.Errorf("missing AWS access key or secret key")
+	}
+	if region == "" || service == "" {
+		return fmt.Errorf("AWS region and service are required")
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{"Authorization", "X-Amz-Date", "X-Amz-Security-Token", "X-Amz-Content-Sha256"} {
+		delHeader(req.Headers, name)
+	}
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(req.Body)
+
+	signed := map[string]string{"host": u.Host, "x-amz-date": amzDate}
+	req.Headers.Set("X-Amz-Date", amzDate)
+	if cred.SessionToken != "" {
+		signed["x-amz-security-token"] = cred.SessionToken
+		req.Headers.Set("X-Amz-Security-Token", cred.SessionToken)
+	}
+	if service == "s3" {
+		signed["x-amz-content-sha256"] = payloadHash
+		req.Headers.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + strings.TrimSpace(signed[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		canonicalURI(u, service),
+		canonicalQuery(u.Query()),
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+cred.SecretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Headers.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		cred.AccessKey, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalURI 返回规范请求中的路径：除 S3 外，每个路径段都要编码两次，
+// 即对浏览器实际发送的、已编码一次的路径再按 RFC 3986 编码一次；S3 仅对解码后的路径段编码一次
+func canonicalURI(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if service == "s3" {
+		path = u.Path
+	}
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		segments[i] = awsEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery 按键与值排序并以 RFC 3986 编码查询参数
+func canonicalQuery(q url.Values) string {
+	var pairs []string
+	for k, vs := range q {
+		for _, v := range vs {
+			pairs = append(pairs, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape 按 RFC 3986 编码，空格编码为 %20
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// varPattern 模板变量，如 {body}、{header:X-Nonce}
+var varPattern = regexp.MustCompile(`\{([A-Za-z0-9]+)(?::([^{}]+))?\}`)
+
+// templateVars 返回模板可引用的请求变量，{path} 为浏览器发送的已编码路径（含查询串），不做二次编码
+func templateVars(req *domain.Request, now time.Time) (map[string]string, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return map[string]string{
+		"body":        string(req.Body),
+		"method":      req.Method,
+		"url":         req.URL,
+		"host":        u.Host,
+		"path":        path,
+		"timestamp":   strconv.FormatInt(now.Unix(), 10),
+		"timestampMs": strconv.FormatInt(now.UnixMilli(), 10),
+		"bodySha256":  sha256Hex(req.Body),
+	}, nil
+}
+
+// expand 替换模板中的变量，未知变量保持原样
+func expand(tmpl string, vars map[string]string, h domain.Header) string {
+	return varPattern.ReplaceAllStringFunc(tmpl, func(m string) string {
+		sub := varPattern.FindStringSubmatch(m)
+		if sub[1] == "header" && sub[2] != "" {
+			return h.Get(sub[2])
+		}
+		if v, ok := vars[sub[1]]; ok && sub[2] == "" {
+			return v
+		}
+		return m
+	})
+}
+
+// hashFunc 返回签名算法对应的哈希函数
+func hashFunc(name string) (func() hash.Hash, error) {
+	switch strings.ToLower(name) {
+	case "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", name)
+	}
+}
+
+// encoder 返回签名的编码方式
+func encoder(name string) (func([]byte) string, error) {
+	switch strings.ToLower(name) {
+	case "hex":
+		return hex.EncodeToString, nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString, nil
+	default:
+		return nil, fmt.Errorf("unsupported signature encoding %q", name)
+	}
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// delHeader 不区分大小写地删除头部，避免与新写入的签名头重复
+func delHeader(h domain.Header, name string) {
+	for k := range h {
+		if strings.EqualFold(k, name) {
+			delete(h, k)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package signer_test
+
+import (
+	"testing"
+	"time"
+
+	"cdpnetool/internal/signer"
+	"cdpnetool/pkg/domain"
+)
+
+func TestHMAC(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name   string
+		opts   signer.HMACOptions
+		header string
+		want   string
+	}{
+		{
+			name:   "模板",
+			opts:   signer.HMACOptions{Header: "X-Sig", Payload: "{method}\n{path}\n{timestamp}\n{body}", Template: "t={timestamp},v1={signature}"},
+			header: "X-Sig",
+			want:   "t=1700000000,v1=116f3d09a6ded35a7ca4535206850d350a864c5b370b3baf1cf95c38e8ec025d",
+		},
+		{
+			name:   "默认请求头与 base64 编码",
+			opts:   signer.HMACOptions{Algorithm: "sha512", Encoding: "base64"},
+			header: "X-Signature",
+			want:   "lNY1dRTTPmkhijr7qrT4wqQKaEy9AynFMyfR6P9bkYnu40EZhYxpWKU9TJsFDpIcLQTBMVbtHmwc3euRdq7adw==",
+		},
+		{
+			name:   "引用请求头",
+			opts:   signer.HMACOptions{Template: "{header:x-nonce}:{unknown}"},
+			header: "X-Signature",
+			want:   "n1:{unknown}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &domain.Request{
+				Method:  "POST",
+				URL:     "https://example.com/api/pay?x=1",
+				Headers: domain.Header{"x-signature": "stale", "X-Nonce": "n1"},
+				Body:    []byte(`{"amount":2}`),
+			}
+			tt.opts.Key = []byte("k3y")
+			if err := signer.HMAC(req, tt.opts, now); err != nil {
+				t.Fatalf("HMAC() error = %v", err)
+			}
+			if got := req.Headers.Get(tt.header); got != tt.want {
+				t.Errorf("got %s %q, want %q", tt.header, got, tt.want)
+			}
+			if _, ok := req.Headers["x-signature"]; ok && tt.header == "X-Signature" {
+				t.Error("stale signature header not removed")
+			}
+		})
+	}
+
+	req := &domain.Request{URL: "https://example.com", Headers: domain.Header{}}
+	if err := signer.HMAC(req, signer.HMACOptions{Algorithm: "md5"}, now); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
+
+func TestSigV4(t *testing.T) {
+	// AWS Signature Version 4 测试套件中的 get-vanilla 与 get-vanilla-query-order-key-case
+	cred := signer.Credentials{AccessKey: "AKIDEXAMPLE", SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	tests := []struct {
+		url       string
+		signature string
+	}{
+		{"https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+	for _, tt := range tests {
+		req := &domain.Request{Method: "GET", URL: tt.url, Headers: domain.Header{"authorization": "old"}}
+		if err := signer.SigV4(req, cred, "us-east-1", "service", now); err != nil {
+			t.Fatalf("SigV4() error = %v", err)
+		}
+		want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + tt.signature
+		if got := req.Headers.Get("Authorization"); got != want {
+			t.Errorf("%s: got Authorization %q, want %q", tt.url, got, want)
+		}
+		if req.Headers.Get("X-Amz-Date") != "20150830T123600Z" {
+			t.Errorf("%s: got X-Amz-Date %q", tt.url, req.Headers.Get("X-Amz-Date"))
+		}
+		if _, ok := req.Headers["authorization"]; ok {
+			t.Errorf("%s: stale authorization header not removed", tt.url)
+		}
+	}
+
+	req := &domain.Request{Method: "PUT", URL: "https://bucket.s3.amazonaws.com/key", Headers: domain.Header{}, Body: []byte("data")}
+	cred.SessionToken = "token"
+	if err := signer.SigV4(req, cred, "us-east-1", "s3", now); err != nil {
+		t.Fatalf("SigV4() error = %v", err)
+	}
+	if req.Headers.Get("X-Amz-Content-Sha256") == "" || req.Headers.Get("X-Amz-Security-Token") != "token" {
+		t.Errorf("got headers %v, want content hash and session token", req.Headers)
+	}
+	if err := signer.SigV4(req, signer.Credentials{}, "us-east-1", "s3", now); err == nil {
+		t.Error("expected error for missing credentials")
+	}
+}
+
+func TestSigV4_PathEncoding(t *testing.T) {
+	// AWS SDK 签名测试中的独立签名用例：浏览器发送的 /logs-%2A/_search 在规范请求中应再编码为 /logs-%252A/_search
+	cred := signer.Credentials{AccessKey: "AKID", SecretKey: "SECRET", SessionToken: "SESSION"}
+	req := &domain.Request{Method: "GET", URL: "https://hostname-clusterkey.us-west-2.es.amazonaws.com/logs-%2A/_search?pretty=true", Headers: domain.Header{}}
+	if err := signer.SigV4(req, cred, "us-west-2", "es", time.Unix(0, 0)); err != nil {
+		t.Fatalf("SigV4() error = %v", err)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKID/19700101/us-west-2/es/aws4_request, SignedHeaders=host;x-amz-date;x-amz-security-token, Signature=79d0760751907af16f64a537c1242416dacf51204a7dd5284492d15577973b91"
+	if got := req.Headers.Get("Authorization"); got != want {
+		t.Errorf("got Authorization %q, want %q", got, want)
+	}
+
+	// S3 只编码一次：同一对象键无论浏览器是否编码保留字符，签名都相同
+	sign := func(rawURL, service string) string {
+		req := &domain.Request{Method: "GET", URL: rawURL, Headers: domain.Header{}}
+		if err := signer.SigV4(req, cred, "us-east-1", service, time.Unix(0, 0)); err != nil {
+			t.Fatalf("SigV4() error = %v", err)
+		}
+		return req.Headers.Get("Authorization")
+	}
+	if a, b := sign("https://bucket.s3.amazonaws.com/photos/a(1).jpg", "s3"), sign("https://bucket.s3.amazonaws.com/photos/a%281%29.jpg", "s3"); a != b {
+		t.Errorf("s3 signatures differ for equivalent keys: %q vs %q", a, b)
+	}
+	if a, b := sign("https://example.amazonaws.com/a%2Fb", "service"), sign("https://example.amazonaws.com/a/b", "service"); a == b {
+		t.Error("encoded slash inside a path segment must not be signed as a separator")
+	}
+}
@@ -19,6 +19,41 @@ const (
 	SettingKeyBrowserPath  = "browser_path"   // 浏览器可执行文件路径
 	SettingKeyWindowBounds = "window_bounds"  // 窗口大小和位置
 	SettingKeyLastConfigID = "last_config_id" // 上次使用的配置 ID
+
+	SettingKeyLogLevel           = "log_level"            // 日志级别，修改后立即生效
+	SettingKeyEventRetentionDays = "event_retention_days" // 事件记录保留天数，0 表示不自动清理
+
+	SettingKeyBrowserHeadless          = "browser_headless"           // 是否以无头模式启动浏览器
+	SettingKeyBrowserEngine            = "browser_engine"             // 未设置浏览器路径时查找的浏览器：auto / chrome / edge / brave / chromium
+	SettingKeySessionConcurrency       = "session_concurrency"        // 会话处理并发数，0 表示不限制
+	SettingKeySessionPendingCapacity   = "session_pending_capacity"   // 会话待处理队列容量，0 表示使用默认值
+	SettingKeySessionProcessTimeout    = "session_process_timeout"    // 单个请求处理超时
+	SettingKeySessionDisableCache      = "session_disable_cache"      // 会话期间是否禁用浏览器 HTTP 缓存
+	SettingKeySessionCorrelationHeader = "session_correlation_header" // 注入关联 ID 的请求头，为空表示不注入
+	SettingKeySessionUnmatchedSampling = "session_unmatched_sampling" // 全量流量中未匹配事件的推送采样，N 表示每 N 个推送 1 个，-1 表示不推送
+	SettingKeySessionJournalDir        = "session_journal_dir"        // 拦截决策日志目录，为空表示不记录
+	SettingKeySessionCoalesceWindow    = "session_coalesce_window"    // 相同进行中请求的合并窗口，0 表示不合并
+	SettingKeySessionBodyChunkSize     = "session_body_chunk_size"    // 以流方式分块读取响应体的分块大小（字节），0 表示一次性获取
+	SettingKeySessionBandwidthLimit    = "session_bandwidth_limit"    // 模拟慢速网络的会话带宽上限（字节/秒），0 表示不限制
+	SettingKeySessionMaxDecodedSize    = "session_max_decoded_size"   // 压缩响应体解压后的大小上限（字节），超过时规则按原始字节处理，0 表示不限制
+	SettingKeySessionRecompressBody    = "session_recompress_body"    // 修改过的压缩响应体是否按原编码重新压缩
+	SettingKeySessionCaptureTiming     = "session_capture_timing"     // 是否为事件采集网络阶段计时与传输大小
+	SettingKeySessionReadOnly          = "session_read_only"          // 是否以只读观察模式启动会话，只记录流量不修改
+	SettingKeySessionCapabilityProfile = "session_capability_profile" // 会话的能力配置档，限制规则可执行的行为
+	SettingKeySessionIncludeTypes      = "session_include_types"      // 只拦截的资源类型，逗号分隔，为空表示不限
+	SettingKeySessionExcludeTypes      = "session_exclude_types"      // 不拦截的资源类型，逗号分隔，如 image,font,media
+	SettingKeyHostMappings             = "host_mappings"              // 主机映射表，每行 "主机名 目标"
+	SettingKeyHostMappingMode          = "host_mapping_mode"          // 主机映射生效方式
+	SettingKeyUserAgent                = "user_agent"                 // 会话级 User-Agent 覆盖，预设名或自定义字符串
+	SettingKeyProxyServer              = "proxy_server"               // 上游代理地址，为空表示直连
+	SettingKeyProxyBypass              = "proxy_bypass"               // 不经过代理的主机，每行一个
+	SettingKeyProxyUsername            = "proxy_username"             // 上游代理认证用户名
+	SettingKeyProxyPassword            = "proxy_password"             // 上游代理认证密码
+	SettingKeyGRPCDescriptorSet        = "grpc_descriptor_set"        // gRPC-web 解码使用的 FileDescriptorSet 文件路径
+	SettingKeyRedactHeaders            = "redact_headers"             // 持久化与导出前脱敏的头部名称，每行或逗号分隔
+	SettingKeyRedactCookies            = "redact_cookies"             // 持久化与导出前脱敏的 Cookie 名称，每行或逗号分隔
+	SettingKeyRedactJSONPaths          = "redact_json_paths"          // 持久化与导出前脱敏的 JSON 字段路径，每行或逗号分隔
+	SettingKeyRedactPatterns           = "redact_patterns"            // 持久化与导出前脱敏的正则表达式，每行一个
 )
 
 // ConfigRecord 配置表（存储规则配置）
@@ -42,6 +77,7 @@ type NetworkEventRecord struct {
 	Method           string    `json:"method"`
 	StatusCode       int       `json:"statusCode"`                        // 状态码
 	FinalResult      string    `gorm:"index" json:"finalResult"`          // blocked / modified / passed
+	CorrelationID    string    `gorm:"index" json:"correlationId"`        // 注入或沿用的关联 ID，未开启时为空
 	MatchedRulesJSON string    `gorm:"type:text" json:"matchedRulesJson"` // 匹配规则 JSON 数组
 	RequestJSON      string    `gorm:"type:text" json:"requestJson"`      // 请求信息 JSON
 	ResponseJSON     string    `gorm:"type:text" json:"responseJson"`     // 响应信息 JSON
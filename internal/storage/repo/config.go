@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"cdpnetool/internal/storage/model"
+	"cdpnetool/pkg/domain"
 	"cdpnetool/pkg/rulespec"
 
 	"gorm.io/gorm"
@@ -138,11 +139,36 @@ func (r *ConfigRepo) ToRulespecConfig(record *model.ConfigRecord) (*rulespec.Con
 		return nil, nil
 	}
 
-	var cfg rulespec.Config
-	if err := json.Unmarshal([]byte(record.ConfigJSON), &cfg); err != nil {
+	cfg, _, err := rulespec.ParseConfig([]byte(record.ConfigJSON))
+	if err != nil {
 		return nil, fmt.Errorf("解析配置失败: %w", err)
 	}
-	return &cfg, nil
+	return cfg, nil
+}
+
+// MigrateAll 将所有旧版本配置升级到当前版本并回写，返回迁移的配置数量
+func (r *ConfigRepo) MigrateAll(ctx context.Context) (int, error) {
+	records, err := r.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range records {
+		record := &records[i]
+		cfg, migrated, err := rulespec.ParseConfig([]byte(record.ConfigJSON))
+		if err != nil {
+			return count, fmt.Errorf("配置 '%s' 迁移失败: %w", record.ConfigID, err)
+		}
+		if !migrated {
+			continue
+		}
+		if err := r.Update(ctx, record.ID, cfg); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
 }
 
 // Save 保存配置（根据数据库 ID 判断新增或更新）
@@ -181,6 +207,70 @@ func (r *ConfigRepo) Upsert(ctx context.Context, cfg *rulespec.Config) (*model.C
 	return r.Create(ctx, cfg)
 }
 
+// MergeRuleSets 将来源配置的规则合并进目标配置并保存，来源配置保持不变
+func (r *ConfigRepo) MergeRuleSets(ctx context.Context, targetID, sourceID uint, strategy rulespec.MergeStrategy) (*model.ConfigRecord, rulespec.MergeResult, error) {
+	if targetID == sourceID {
+		return nil, rulespec.MergeResult{}, fmt.Errorf("%w: 不能将配置合并到自身", domain.ErrInvalidConfig)
+	}
+
+	target, err := r.loadConfig(ctx, targetID)
+	if err != nil {
+		return nil, rulespec.MergeResult{}, err
+	}
+	source, err := r.loadConfig(ctx, sourceID)
+	if err != nil {
+		return nil, rulespec.MergeResult{}, err
+	}
+
+	rules, res, err := rulespec.MergeRules(target.Rules, source.Rules, strategy)
+	if err != nil {
+		return nil, rulespec.MergeResult{}, err
+	}
+	target.Rules = rules
+
+	if err := r.Update(ctx, targetID, target); err != nil {
+		return nil, rulespec.MergeResult{}, err
+	}
+	record, err := r.FindOne(ctx, targetID)
+	if err != nil {
+		return nil, rulespec.MergeResult{}, err
+	}
+	return record, res, nil
+}
+
+// DiffConfigs 计算两个已存储配置之间的差异（从 fromID 到 toID）
+func (r *ConfigRepo) DiffConfigs(ctx context.Context, fromID, toID uint) (rulespec.ConfigDiff, error) {
+	from, err := r.loadConfig(ctx, fromID)
+	if err != nil {
+		return rulespec.ConfigDiff{}, err
+	}
+	to, err := r.loadConfig(ctx, toID)
+	if err != nil {
+		return rulespec.ConfigDiff{}, err
+	}
+	return rulespec.Diff(from, to), nil
+}
+
+// loadConfig 按数据库 ID 读取并解析配置
+func (r *ConfigRepo) loadConfig(ctx context.Context, id uint) (*rulespec.Config, error) {
+	record, err := r.FindOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// FindOne 未找到时返回零值记录
+	if record == nil || record.ID == 0 {
+		return nil, fmt.Errorf("%w: %d", domain.ErrConfigNotFound, id)
+	}
+	cfg, err := r.ToRulespecConfig(record)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("%w: 配置 %d 内容为空", domain.ErrInvalidConfig, id)
+	}
+	return cfg, nil
+}
+
 // Rename 重命名配置
 func (r *ConfigRepo) Rename(ctx context.Context, id uint, newName string) error {
 	record, err := r.FindOne(ctx, id)
@@ -208,15 +298,5 @@ func (r *ConfigRepo) Rename(ctx context.Context, id uint, newName string) error
 
 // validateRuleIDs 校验规则 ID 格式和唯一性
 func (r *ConfigRepo) validateRuleIDs(rules []rulespec.Rule) error {
-	seen := make(map[string]bool)
-	for _, rule := range rules {
-		if err := rulespec.ValidateRuleID(rule.ID); err != nil {
-			return fmt.Errorf("规则 '%s': %w", rule.Name, err)
-		}
-		if seen[rule.ID] {
-			return fmt.Errorf("规则 ID '%s' 重复", rule.ID)
-		}
-		seen[rule.ID] = true
-	}
-	return nil
+	return rulespec.ValidateRuleIDs(rules)
 }
@@ -2,11 +2,13 @@ package repo_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"cdpnetool/internal/storage/db"
 	"cdpnetool/internal/storage/model"
 	"cdpnetool/internal/storage/repo"
+	"cdpnetool/pkg/domain"
 	"cdpnetool/pkg/rulespec"
 )
 
@@ -154,3 +156,207 @@ func TestConfigRepo_Rename(t *testing.T) {
 		t.Errorf("配置 JSON 内部名称未更新，预期 %s，实际 %s", newName, parsed.Name)
 	}
 }
+
+// TestConfigRepo_MigrateAll 测试旧版本配置在加载时被升级并回写。
+func TestConfigRepo_MigrateAll(t *testing.T) {
+	r := setupTestDB(t)
+	legacy := &model.ConfigRecord{
+		ConfigID:   "legacy-config",
+		Name:       "旧配置",
+		ConfigJSON: `{"id":"legacy-config","name":"旧配置","rules":[{"name":"r","enabled":true,"match":{},"actions":null}]}`,
+	}
+	if err := r.Db.Create(legacy).Error; err != nil {
+		t.Fatalf("写入旧配置失败: %v", err)
+	}
+
+	n, err := r.MigrateAll(context.Background())
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("预期迁移 1 个配置，实际 %d", n)
+	}
+
+	updated, _ := r.FindOne(context.Background(), legacy.ID)
+	if updated.Version != rulespec.DefaultConfigVersion {
+		t.Errorf("预期版本为 %s，实际为 %q", rulespec.DefaultConfigVersion, updated.Version)
+	}
+	cfg, err := r.ToRulespecConfig(updated)
+	if err != nil {
+		t.Fatalf("解析迁移后配置失败: %v", err)
+	}
+	if cfg.Rules[0].ID != "rule-001" || cfg.Rules[0].Stage != rulespec.StageRequest {
+		t.Errorf("规则未被补全: %+v", cfg.Rules[0])
+	}
+
+	// 再次迁移应为空操作
+	if n, _ := r.MigrateAll(context.Background()); n != 0 {
+		t.Errorf("预期无需再次迁移，实际迁移 %d 个", n)
+	}
+}
+
+// TestConfigRepo_FutureVersion 测试拒绝高于当前支持的配置版本。
+func TestConfigRepo_FutureVersion(t *testing.T) {
+	r := setupTestDB(t)
+	record := &model.ConfigRecord{ConfigJSON: `{"id":"future","version":"99.0","rules":[]}`}
+	if _, err := r.ToRulespecConfig(record); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("预期返回 ErrInvalidConfig，实际 %v", err)
+	}
+}
+
+// TestConfigRepo_MergeRuleSets 测试不同冲突策略下的规则集合并。
+func TestConfigRepo_MergeRuleSets(t *testing.T) {
+	ctx := context.Background()
+
+	newRule := func(id, name string) rulespec.Rule {
+		rule := rulespec.NewRule(name, 0)
+		rule.ID = id
+		return rule
+	}
+
+	tests := []struct {
+		strategy rulespec.MergeStrategy
+		wantIDs  []string
+		wantName string // 冲突规则 shared 合并后的名称
+		want     rulespec.MergeResult
+	}{
+		{rulespec.MergeRename, []string{"shared", "base-only", "shared-2", "personal"}, "基础", rulespec.MergeResult{Added: 1, Renamed: 1}},
+		{rulespec.MergeSkip, []string{"shared", "base-only", "personal"}, "基础", rulespec.MergeResult{Added: 1, Skipped: 1}},
+		{rulespec.MergeOverwrite, []string{"shared", "base-only", "personal"}, "个人", rulespec.MergeResult{Added: 1, Overwritten: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			r := setupTestDB(t)
+
+			base := rulespec.NewConfig("基础配置")
+			base.Rules = []rulespec.Rule{newRule("shared", "基础"), newRule("base-only", "基础独有")}
+			baseRecord, err := r.Create(ctx, base)
+			if err != nil {
+				t.Fatalf("创建目标配置失败: %v", err)
+			}
+
+			personal := rulespec.NewConfig("个人配置")
+			personal.Rules = []rulespec.Rule{newRule("shared", "个人"), newRule("personal", "个人独有")}
+			personalRecord, err := r.Create(ctx, personal)
+			if err != nil {
+				t.Fatalf("创建来源配置失败: %v", err)
+			}
+
+			record, res, err := r.MergeRuleSets(ctx, baseRecord.ID, personalRecord.ID, tt.strategy)
+			if err != nil {
+				t.Fatalf("合并失败: %v", err)
+			}
+			if res != tt.want {
+				t.Errorf("预期合并统计 %+v，实际 %+v", tt.want, res)
+			}
+
+			merged, _ := r.ToRulespecConfig(record)
+			if len(merged.Rules) != len(tt.wantIDs) {
+				t.Fatalf("预期 %d 条规则，实际 %d 条", len(tt.wantIDs), len(merged.Rules))
+			}
+			for i, id := range tt.wantIDs {
+				if merged.Rules[i].ID != id {
+					t.Errorf("第 %d 条规则预期 ID %s，实际 %s", i+1, id, merged.Rules[i].ID)
+				}
+			}
+			if merged.Rules[0].Name != tt.wantName {
+				t.Errorf("预期冲突规则名称为 %s，实际为 %s", tt.wantName, merged.Rules[0].Name)
+			}
+
+			// 来源配置保持不变
+			source, _ := r.FindOne(ctx, personalRecord.ID)
+			sourceCfg, _ := r.ToRulespecConfig(source)
+			if len(sourceCfg.Rules) != 2 {
+				t.Errorf("来源配置不应被修改，实际规则数 %d", len(sourceCfg.Rules))
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		r := setupTestDB(t)
+		record, _ := r.Create(ctx, rulespec.NewConfig("配置"))
+
+		if _, _, err := r.MergeRuleSets(ctx, record.ID, record.ID, rulespec.MergeRename); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("合并到自身预期 ErrInvalidConfig，实际 %v", err)
+		}
+		if _, _, err := r.MergeRuleSets(ctx, record.ID, 999, rulespec.MergeRename); !errors.Is(err, domain.ErrConfigNotFound) {
+			t.Errorf("来源不存在预期 ErrConfigNotFound，实际 %v", err)
+		}
+		other, _ := r.Create(ctx, rulespec.NewConfig("其他"))
+		if _, _, err := r.MergeRuleSets(ctx, record.ID, other.ID, "unknown"); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("未知策略预期 ErrInvalidConfig，实际 %v", err)
+		}
+	})
+}
+
+// TestConfigRepo_DiffConfigs 测试两个配置之间的结构化差异。
+func TestConfigRepo_DiffConfigs(t *testing.T) {
+	ctx := context.Background()
+	r := setupTestDB(t)
+
+	newRule := func(id string) rulespec.Rule {
+		rule := rulespec.NewRule(id, 0)
+		rule.ID = id
+		return rule
+	}
+
+	from := rulespec.NewConfig("旧配置")
+	from.Rules = []rulespec.Rule{newRule("a"), newRule("b"), newRule("c"), newRule("removed")}
+	fromRecord, err := r.Create(ctx, from)
+	if err != nil {
+		t.Fatalf("创建配置失败: %v", err)
+	}
+
+	to := rulespec.NewConfig("新配置")
+	changed := newRule("b")
+	changed.Enabled = false
+	changed.Priority = 10
+	// c 移到最前，b 修改字段，新增 added，删除 removed
+	to.Rules = []rulespec.Rule{newRule("c"), newRule("a"), changed, newRule("added")}
+	toRecord, err := r.Create(ctx, to)
+	if err != nil {
+		t.Fatalf("创建配置失败: %v", err)
+	}
+
+	diff, err := r.DiffConfigs(ctx, fromRecord.ID, toRecord.ID)
+	if err != nil {
+		t.Fatalf("计算差异失败: %v", err)
+	}
+
+	if len(diff.Fields) != 1 || diff.Fields[0].Field != "name" {
+		t.Errorf("预期仅名称字段变化，实际 %+v", diff.Fields)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].ID != "added" {
+		t.Errorf("预期新增 added，实际 %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != "removed" {
+		t.Errorf("预期删除 removed，实际 %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].RuleID != "b" {
+		t.Fatalf("预期 b 发生变更，实际 %+v", diff.Changed)
+	}
+	fields := map[string]bool{}
+	for _, f := range diff.Changed[0].Fields {
+		fields[f.Field] = true
+	}
+	if len(fields) != 2 || !fields["enabled"] || !fields["priority"] {
+		t.Errorf("预期 enabled 与 priority 变化，实际 %+v", diff.Changed[0].Fields)
+	}
+	if len(diff.Moved) != 1 || diff.Moved[0] != "c" {
+		t.Errorf("预期 c 顺序变化，实际 %v", diff.Moved)
+	}
+
+	// 相同配置无差异（nil 与空切片视为相同）
+	same, err := r.DiffConfigs(ctx, fromRecord.ID, fromRecord.ID)
+	if err != nil {
+		t.Fatalf("计算差异失败: %v", err)
+	}
+	if !same.IsEmpty() {
+		t.Errorf("预期无差异，实际 %+v", same)
+	}
+
+	if _, err := r.DiffConfigs(ctx, fromRecord.ID, 999); !errors.Is(err, domain.ErrConfigNotFound) {
+		t.Errorf("预期 ErrConfigNotFound，实际 %v", err)
+	}
+}
@@ -2,11 +2,15 @@ package repo
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"cdpnetool/internal/logger"
+	"cdpnetool/internal/redact"
 	"cdpnetool/internal/storage/model"
 	"cdpnetool/pkg/domain"
 
@@ -39,6 +43,7 @@ type EventRepo struct {
 	flushCh  chan struct{}
 	stopCh   chan struct{}
 	wg       sync.WaitGroup
+	redactor atomic.Pointer[redact.Redactor] // 写入前的脱敏器，为 nil 时原样保存
 }
 
 // NewEventRepo 创建事件仓库实例
@@ -105,6 +110,11 @@ func (r *EventRepo) Stop() {
 	r.wg.Wait()
 }
 
+// SetRedactor 设置写入数据库前使用的脱敏器，为 nil 时原样保存；仅影响之后记录的事件
+func (r *EventRepo) SetRedactor(rd *redact.Redactor) {
+	r.redactor.Store(rd)
+}
+
 // Record 记录网络事件（异步写入数据库，只存储匹配事件）
 func (r *EventRepo) Record(evt *domain.NetworkEvent) {
 	// 只记录匹配事件
@@ -121,18 +131,27 @@ func (r *EventRepo) Record(evt *domain.NetworkEvent) {
 	}
 	r.bufferMu.Unlock()
 
+	redacted := r.redactor.Load().Event(*evt)
+	evt = &redacted
+
 	// 序列化规则列表
 	matchedRulesJSON, _ := json.Marshal(evt.MatchedRules)
 	requestJSON, _ := json.Marshal(evt.Request)
 	responseJSON, _ := json.Marshal(evt.Response)
+	// WebSocket 握手等事件没有响应
+	statusCode := 0
+	if evt.Response != nil {
+		statusCode = evt.Response.StatusCode
+	}
 
 	record := model.NetworkEventRecord{
 		SessionID:        string(evt.Session),
 		TargetID:         string(evt.Target),
 		URL:              evt.Request.URL,
 		Method:           evt.Request.Method,
-		StatusCode:       evt.Response.StatusCode,
+		StatusCode:       statusCode,
 		FinalResult:      evt.FinalResult,
+		CorrelationID:    evt.Request.CorrelationID,
 		MatchedRulesJSON: string(matchedRulesJSON),
 		RequestJSON:      string(requestJSON),
 		ResponseJSON:     string(responseJSON),
@@ -155,27 +174,90 @@ func (r *EventRepo) Record(evt *domain.NetworkEvent) {
 
 // QueryOptions 查询选项
 type QueryOptions struct {
-	SessionID   string
-	FinalResult string // blocked / modified / passed
-	URL         string
-	Method      string
-	StartTime   int64
-	EndTime     int64
-	Offset      int
-	Limit       int
-}
-
-// Query 查询匹配事件历史
-func (r *EventRepo) Query(ctx context.Context, opts QueryOptions) ([]model.NetworkEventRecord, int64, error) {
-	query := r.Db.WithContext(ctx).Model(&model.NetworkEventRecord{})
+	SessionID     string
+	FinalResult   string // blocked / modified / passed
+	CorrelationID string
+	URL           string
+	Method        string
+	StartTime     int64
+	EndTime       int64
+	Cursor        string // 上一页返回的 NextCursor，为空表示从最新的记录开始
+	Limit         int
+}
+
+// EventPage 一页匹配事件
+type EventPage struct {
+	Records    []model.NetworkEventRecord
+	Total      int64  // 满足过滤条件的总数，仅首页（Cursor 为空）计算，翻页时为 0
+	NextCursor string // 下一页游标，为空表示没有更多记录
+}
+
+// exportBatchSize 流式导出时每批读取的记录数
+const exportBatchSize = 1000
+
+// Query 按时间倒序分页查询匹配事件历史。
+// 使用 (timestamp, id) 键集游标翻页，翻到深处也无需扫描并跳过前面的记录
+func (r *EventRepo) Query(ctx context.Context, opts QueryOptions) (EventPage, error) {
+	var page EventPage
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	if opts.Limit > 1000 {
+		opts.Limit = 1000
+	}
+
+	if opts.Cursor == "" {
+		if err := r.filter(ctx, opts).Count(&page.Total).Error; err != nil {
+			return EventPage{}, err
+		}
+	}
+
+	records, err := r.page(ctx, opts, opts.Cursor, opts.Limit+1)
+	if err != nil {
+		return EventPage{}, err
+	}
+	// 多取一条判断是否还有下一页
+	if len(records) > opts.Limit {
+		records = records[:opts.Limit]
+		page.NextCursor = encodeCursor(records[len(records)-1])
+	}
+	page.Records = records
+	return page, nil
+}
 
-	// 应用过滤条件
+// Export 按时间倒序逐条回调满足过滤条件的全部匹配事件，忽略 Cursor 与 Limit。
+// 内部按游标分批读取，内存占用与历史记录总数无关；回调返回错误时停止导出
+func (r *EventRepo) Export(ctx context.Context, opts QueryOptions, fn func(rec *model.NetworkEventRecord) error) error {
+	cursor := ""
+	for {
+		records, err := r.page(ctx, opts, cursor, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range records {
+			if err := fn(&records[i]); err != nil {
+				return err
+			}
+		}
+		if len(records) < exportBatchSize {
+			return nil
+		}
+		cursor = encodeCursor(records[len(records)-1])
+	}
+}
+
+// filter 构建应用了过滤条件的查询
+func (r *EventRepo) filter(ctx context.Context, opts QueryOptions) *gorm.DB {
+	query := r.Db.WithContext(ctx).Model(&model.NetworkEventRecord{})
 	if opts.SessionID != "" {
 		query = query.Where("session_id = ?", opts.SessionID)
 	}
 	if opts.FinalResult != "" {
 		query = query.Where("final_result = ?", opts.FinalResult)
 	}
+	if opts.CorrelationID != "" {
+		query = query.Where("correlation_id = ?", opts.CorrelationID)
+	}
 	if opts.URL != "" {
 		query = query.Where("url LIKE ?", "%"+opts.URL+"%")
 	}
@@ -188,28 +270,52 @@ func (r *EventRepo) Query(ctx context.Context, opts QueryOptions) ([]model.Netwo
 	if opts.EndTime > 0 {
 		query = query.Where("timestamp <= ?", opts.EndTime)
 	}
+	return query
+}
 
-	// 计算总数
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+// page 读取游标之后（更早）的至多 limit 条记录
+func (r *EventRepo) page(ctx context.Context, opts QueryOptions, cursor string, limit int) ([]model.NetworkEventRecord, error) {
+	query := r.filter(ctx, opts)
+	if cursor != "" {
+		ts, id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("timestamp < ? OR (timestamp = ? AND id < ?)", ts, ts, id)
 	}
 
-	// 分页
-	if opts.Limit <= 0 {
-		opts.Limit = 100
+	var records []model.NetworkEventRecord
+	err := query.Order("timestamp DESC").Order("id DESC").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+// encodeCursor 将记录的排序键编码为不透明游标
+func encodeCursor(rec model.NetworkEventRecord) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", rec.Timestamp, rec.ID)))
+}
+
+// decodeCursor 解析游标中的时间戳与记录 ID
+func decodeCursor(cursor string) (int64, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: 无效的分页游标", domain.ErrInvalidConfig)
 	}
-	if opts.Limit > 1000 {
-		opts.Limit = 1000
+	var ts int64
+	var id uint
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &ts, &id); err != nil {
+		return 0, 0, fmt.Errorf("%w: 无效的分页游标", domain.ErrInvalidConfig)
 	}
+	return ts, id, nil
+}
 
+// ListBySession 按时间顺序返回指定会话的全部匹配事件，不分页
+func (r *EventRepo) ListBySession(ctx context.Context, sessionID string) ([]model.NetworkEventRecord, error) {
 	var records []model.NetworkEventRecord
-	err := query.Order("timestamp DESC").
-		Offset(opts.Offset).
-		Limit(opts.Limit).
+	err := r.Db.WithContext(ctx).
+		Where("session_id = ?", sessionID).
+		Order("timestamp ASC").
 		Find(&records).Error
-
-	return records, total, err
+	return records, err
 }
 
 // DeleteOldEvents 删除旧事件（数据清理）
@@ -2,10 +2,15 @@ package repo_test
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"cdpnetool/internal/logger"
+	"cdpnetool/internal/redact"
 	"cdpnetool/internal/storage/db"
 	"cdpnetool/internal/storage/model"
 	"cdpnetool/internal/storage/repo"
@@ -65,13 +70,14 @@ func TestEventRepo_AsyncWrite(t *testing.T) {
 	time.Sleep(200 * time.Millisecond)
 
 	// 验证数据是否写入数据库
-	events, total, err := r.Query(context.Background(), repo.QueryOptions{
+	page, err := r.Query(context.Background(), repo.QueryOptions{
 		SessionID: "test-session",
 		Limit:     100,
 	})
 	if err != nil {
 		t.Fatalf("查询事件失败: %v", err)
 	}
+	events, total := page.Records, page.Total
 
 	if total != 10 {
 		t.Errorf("预期写入 10 条记录，实际为 %d", total)
@@ -100,7 +106,7 @@ func TestEventRepo_QueryWithFilters(t *testing.T) {
 		{
 			Session:     "s1",
 			IsMatched:   true,
-			Request:     domain.Request{URL: "http://b.com", Method: "POST"},
+			Request:     domain.Request{URL: "http://b.com", Method: "POST", CorrelationID: "corr-b"},
 			Response:    &domain.Response{StatusCode: 403},
 			FinalResult: "blocked",
 			Timestamp:   2000,
@@ -122,19 +128,20 @@ func TestEventRepo_QueryWithFilters(t *testing.T) {
 	time.Sleep(200 * time.Millisecond)
 
 	// 按 SessionID 过滤
-	_, total, _ := r.Query(context.Background(), repo.QueryOptions{
+	page, _ := r.Query(context.Background(), repo.QueryOptions{
 		SessionID: "s1",
 		Limit:     100,
 	})
-	if total != 2 {
+	if total := page.Total; total != 2 {
 		t.Errorf("SessionID 过滤预期 2 条，实际 %d", total)
 	}
 
 	// 按 FinalResult 过滤
-	results, total, _ := r.Query(context.Background(), repo.QueryOptions{
+	page, _ = r.Query(context.Background(), repo.QueryOptions{
 		FinalResult: "blocked",
 		Limit:       100,
 	})
+	results, total := page.Records, page.Total
 	if total != 1 {
 		t.Errorf("FinalResult 过滤预期 1 条，实际 %d", total)
 	}
@@ -143,11 +150,203 @@ func TestEventRepo_QueryWithFilters(t *testing.T) {
 	}
 
 	// 按 Method 过滤
-	_, total, _ = r.Query(context.Background(), repo.QueryOptions{
+	page, _ = r.Query(context.Background(), repo.QueryOptions{
 		Method: "POST",
 		Limit:  100,
 	})
+	total = page.Total
 	if total != 1 {
 		t.Errorf("Method 过滤预期 1 条，实际 %d", total)
 	}
+
+	// 按关联 ID 过滤
+	page, _ = r.Query(context.Background(), repo.QueryOptions{
+		CorrelationID: "corr-b",
+		Limit:         100,
+	})
+	results, total = page.Records, page.Total
+	if total != 1 || len(results) != 1 || results[0].CorrelationID != "corr-b" || results[0].URL != "http://b.com" {
+		t.Errorf("关联 ID 过滤结果不符: total=%d %+v", total, results)
+	}
+}
+
+// recordTimestamps 以给定时间戳写入同一会话的匹配事件并等待落库。
+func recordTimestamps(t *testing.T, r *repo.EventRepo, timestamps ...int64) {
+	t.Helper()
+	for i, ts := range timestamps {
+		r.Record(&domain.NetworkEvent{
+			Session:     "s1",
+			IsMatched:   true,
+			Request:     domain.Request{URL: fmt.Sprintf("http://example.com/%d", i), Method: "GET"},
+			Response:    &domain.Response{StatusCode: 200},
+			FinalResult: "passed",
+			Timestamp:   ts,
+		})
+	}
+	time.Sleep(200 * time.Millisecond)
+}
+
+// TestEventRepo_CursorPagination 测试游标翻页不重复、不遗漏，时间戳相同的记录按 ID 排序。
+func TestEventRepo_CursorPagination(t *testing.T) {
+	r := setupEventTestDB(t)
+	defer r.Stop()
+
+	recordTimestamps(t, r, 1000, 1000, 2000, 2000, 2000, 3000, 4000)
+
+	var got []int64
+	seen := make(map[uint]bool)
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("翻页未结束")
+		}
+		page, err := r.Query(context.Background(), repo.QueryOptions{SessionID: "s1", Cursor: cursor, Limit: 2})
+		if err != nil {
+			t.Fatalf("查询事件失败: %v", err)
+		}
+		if cursor == "" && page.Total != 7 {
+			t.Errorf("首页预期总数 7，实际 %d", page.Total)
+		}
+		if cursor != "" && page.Total != 0 {
+			t.Errorf("翻页时不应重复计数，实际 %d", page.Total)
+		}
+		for _, rec := range page.Records {
+			if seen[rec.ID] {
+				t.Errorf("记录 %d 重复出现", rec.ID)
+			}
+			seen[rec.ID] = true
+			got = append(got, rec.Timestamp)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	want := []int64{4000, 3000, 2000, 2000, 2000, 1000, 1000}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("翻页结果预期 %v，实际 %v", want, got)
+	}
+
+	_, err := r.Query(context.Background(), repo.QueryOptions{Cursor: "not a cursor"})
+	if !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("无效游标预期返回 ErrInvalidConfig，实际为 %v", err)
+	}
+}
+
+// TestEventRepo_Export 测试流式导出按过滤条件返回全部记录，并在回调出错时停止。
+func TestEventRepo_Export(t *testing.T) {
+	r := setupEventTestDB(t)
+	defer r.Stop()
+
+	recordTimestamps(t, r, 1000, 2000, 3000, 4000, 5000)
+
+	var got []int64
+	err := r.Export(context.Background(), repo.QueryOptions{SessionID: "s1", StartTime: 2000, Limit: 1}, func(rec *model.NetworkEventRecord) error {
+		got = append(got, rec.Timestamp)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	if want := []int64{5000, 4000, 3000, 2000}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("导出结果预期 %v，实际 %v", want, got)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = r.Export(context.Background(), repo.QueryOptions{SessionID: "s1"}, func(rec *model.NetworkEventRecord) error {
+		count++
+		return stop
+	})
+	if !errors.Is(err, stop) || count != 1 {
+		t.Errorf("回调出错时预期立即停止，实际 err=%v count=%d", err, count)
+	}
+}
+
+// TestEventRepo_ListBySession 测试按会话按时间顺序列出全部事件，以及无响应事件的记录。
+func TestEventRepo_ListBySession(t *testing.T) {
+	r := setupEventTestDB(t)
+	defer r.Stop()
+
+	events := []*domain.NetworkEvent{
+		{
+			Session:     "s1",
+			IsMatched:   true,
+			Request:     domain.Request{URL: "http://a.com/2", Method: "GET"},
+			Response:    &domain.Response{StatusCode: 200},
+			FinalResult: "passed",
+			Timestamp:   2000,
+		},
+		{
+			Session:     "s1",
+			IsMatched:   true,
+			Request:     domain.Request{URL: "ws://a.com/socket", Method: "GET"},
+			FinalResult: "modified",
+			Timestamp:   1000,
+		},
+		{
+			Session:     "s2",
+			IsMatched:   true,
+			Request:     domain.Request{URL: "http://b.com", Method: "GET"},
+			Response:    &domain.Response{StatusCode: 200},
+			FinalResult: "passed",
+			Timestamp:   3000,
+		},
+	}
+	for _, evt := range events {
+		r.Record(evt)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	records, err := r.ListBySession(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("列出事件失败: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("预期 2 条记录，实际 %d", len(records))
+	}
+	if records[0].URL != "ws://a.com/socket" || records[0].StatusCode != 0 {
+		t.Errorf("预期按时间升序且无响应事件状态码为 0，实际 %+v", records[0])
+	}
+}
+
+// TestEventRepo_Redaction 测试写入数据库前的脱敏。
+func TestEventRepo_Redaction(t *testing.T) {
+	r := setupEventTestDB(t)
+	defer r.Stop()
+
+	rd, err := redact.New(domain.RedactionConfig{Headers: []string{"Authorization"}, Patterns: []string{`key=(\w+)`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetRedactor(rd)
+
+	evt := &domain.NetworkEvent{
+		Session:     "s1",
+		IsMatched:   true,
+		Request:     domain.Request{URL: "http://a.com/?key=abc", Method: "GET", Headers: domain.Header{"Authorization": "Bearer xyz"}},
+		Response:    &domain.Response{StatusCode: 200, Body: []byte(`{"key=def"}`)},
+		FinalResult: "matched",
+		Timestamp:   1000,
+	}
+	r.Record(evt)
+	time.Sleep(200 * time.Millisecond)
+
+	records, err := r.ListBySession(context.Background(), "s1")
+	if err != nil || len(records) != 1 {
+		t.Fatalf("列出事件失败: %v, %d", err, len(records))
+	}
+	rec := records[0]
+	if rec.URL != "http://a.com/?key=[REDACTED]" || strings.Contains(rec.RequestJSON, "xyz") || strings.Contains(rec.RequestJSON, "abc") {
+		t.Errorf("请求未脱敏: %s %s", rec.URL, rec.RequestJSON)
+	}
+	if strings.Contains(rec.ResponseJSON, base64.StdEncoding.EncodeToString([]byte(`{"key=def"}`))) {
+		t.Errorf("响应未脱敏: %s", rec.ResponseJSON)
+	}
+	// 原事件不被修改
+	if evt.Request.Headers["Authorization"] != "Bearer xyz" {
+		t.Error("原事件不应被修改")
+	}
 }
@@ -2,17 +2,35 @@ package repo
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"cdpnetool/internal/config"
 	"cdpnetool/internal/storage/model"
+	"cdpnetool/pkg/domain"
 
 	"gorm.io/gorm"
 )
 
-// SettingsRepo 设置仓库
+// SettingChange 设置变更通知
+type SettingChange struct {
+	Key      string
+	OldValue string
+	NewValue string
+}
+
+// SettingsListener 设置变更监听函数，在写入成功后同步调用
+type SettingsListener func(change SettingChange)
+
+// SettingsRepo 设置仓库，写入前按 config.SettingSchema 校验已声明的设置项
 type SettingsRepo struct {
 	BaseRepository[model.Setting]
+
+	mu        sync.RWMutex
+	listeners []SettingsListener
 }
 
 // NewSettingsRepo 创建设置仓库实例
@@ -32,6 +50,28 @@ func (r *SettingsRepo) Get(ctx context.Context, key string) (string, error) {
 	return setting.Value, nil
 }
 
+// OnChange 注册设置变更监听，值未变化的写入不会触发通知
+func (r *SettingsRepo) OnChange(fn SettingsListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// notify 向所有监听者派发变更
+func (r *SettingsRepo) notify(changes []SettingChange) {
+	r.mu.RLock()
+	listeners := append([]SettingsListener(nil), r.listeners...)
+	r.mu.RUnlock()
+	for _, change := range changes {
+		if change.OldValue == change.NewValue {
+			continue
+		}
+		for _, fn := range listeners {
+			fn(change)
+		}
+	}
+}
+
 // GetWithDefault 获取设置值，不存在时返回默认值
 func (r *SettingsRepo) GetWithDefault(ctx context.Context, key, defaultValue string) string {
 	val, err := r.Get(ctx, key)
@@ -41,19 +81,23 @@ func (r *SettingsRepo) GetWithDefault(ctx context.Context, key, defaultValue str
 	return val
 }
 
-// Set 设置值（存在则更新，不存在则创建）
+// Set 设置值（存在则更新，不存在则创建），值不合法时返回 domain.ErrInvalidSetting
 func (r *SettingsRepo) Set(ctx context.Context, key, value string) error {
-	setting := model.Setting{
-		Key:       key,
-		Value:     value,
-		UpdatedAt: time.Now(),
-	}
-	return r.Db.WithContext(ctx).Save(&setting).Error
+	return r.SetMultiple(ctx, map[string]string{key: value})
 }
 
-// DeleteByKey 根据 key 删除设置
+// DeleteByKey 根据 key 删除设置，已声明的设置项删除后恢复为默认值
 func (r *SettingsRepo) DeleteByKey(ctx context.Context, key string) error {
-	return r.Db.WithContext(ctx).Delete(&model.Setting{}, "key = ?", key).Error
+	old, _ := r.Get(ctx, key)
+	if err := r.Db.WithContext(ctx).Delete(&model.Setting{}, "key = ?", key).Error; err != nil {
+		return err
+	}
+	next := ""
+	if spec, ok := config.LookupSetting(key); ok {
+		next = spec.Default
+	}
+	r.notify([]SettingChange{{Key: key, OldValue: old, NewValue: next}})
+	return nil
 }
 
 // GetAll 获取所有设置
@@ -70,11 +114,32 @@ func (r *SettingsRepo) GetAll(ctx context.Context) (map[string]string, error) {
 	return result, nil
 }
 
-// SetMultiple 批量设置
+// SetMultiple 批量设置，任一值不合法时整体不写入
 func (r *SettingsRepo) SetMultiple(ctx context.Context, kvs map[string]string) error {
-	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	normalized := make(map[string]string, len(kvs))
+	for key, value := range kvs {
+		v, err := config.NormalizeSetting(key, value)
+		if err != nil {
+			return err
+		}
+		normalized[key] = v
+	}
+
+	var changes []SettingChange
+	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		now := time.Now()
-		for key, value := range kvs {
+		for key, value := range normalized {
+			var prev model.Setting
+			if err := tx.Where("key = ?", key).Limit(1).Find(&prev).Error; err != nil {
+				return err
+			}
+			old := prev.Value
+			if prev.Key == "" {
+				if spec, ok := config.LookupSetting(key); ok {
+					old = spec.Default
+				}
+			}
+
 			setting := model.Setting{
 				Key:       key,
 				Value:     value,
@@ -83,9 +148,158 @@ func (r *SettingsRepo) SetMultiple(ctx context.Context, kvs map[string]string) e
 			if err := tx.Save(&setting).Error; err != nil {
 				return err
 			}
+			changes = append(changes, SettingChange{Key: key, OldValue: old, NewValue: value})
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	r.notify(changes)
+	return nil
+}
+
+// GetInt 获取整数设置，未设置或存储值不合法时返回 schema 默认值
+func (r *SettingsRepo) GetInt(ctx context.Context, key string) int {
+	n, _ := strconv.Atoi(r.getValid(ctx, key))
+	return n
+}
+
+// GetBool 获取布尔设置，未设置或存储值不合法时返回 schema 默认值
+func (r *SettingsRepo) GetBool(ctx context.Context, key string) bool {
+	b, _ := strconv.ParseBool(r.getValid(ctx, key))
+	return b
+}
+
+// GetDuration 获取时长设置，未设置或存储值不合法时返回 schema 默认值
+func (r *SettingsRepo) GetDuration(ctx context.Context, key string) time.Duration {
+	d, _ := time.ParseDuration(r.getValid(ctx, key))
+	return d
+}
+
+// GetRuntimeSettings 读取可在会话运行期间热更新的设置
+func (r *SettingsRepo) GetRuntimeSettings(ctx context.Context) domain.RuntimeSettings {
+	return domain.RuntimeSettings{
+		ProcessTimeoutMS:  int(r.GetDuration(ctx, model.SettingKeySessionProcessTimeout).Milliseconds()),
+		UnmatchedSampling: r.GetInt(ctx, model.SettingKeySessionUnmatchedSampling),
+		DisableCache:      r.GetBool(ctx, model.SettingKeySessionDisableCache),
+	}
+}
+
+// GetSessionConfig 根据会话相关设置构建会话配置
+func (r *SettingsRepo) GetSessionConfig(ctx context.Context, devToolsURL string) domain.SessionConfig {
+	cfg := domain.SessionConfig{
+		DevToolsURL:      devToolsURL,
+		Concurrency:      r.GetInt(ctx, model.SettingKeySessionConcurrency),
+		PendingCapacity:  r.GetInt(ctx, model.SettingKeySessionPendingCapacity),
+		ProcessTimeoutMS: int(r.GetDuration(ctx, model.SettingKeySessionProcessTimeout).Milliseconds()),
+		UserAgent:        r.getValid(ctx, model.SettingKeyUserAgent),
+		DisableCache:     r.GetBool(ctx, model.SettingKeySessionDisableCache),
+
+		CorrelationHeader: r.getValid(ctx, model.SettingKeySessionCorrelationHeader),
+		UnmatchedSampling: r.GetInt(ctx, model.SettingKeySessionUnmatchedSampling),
+		JournalDir:        r.getValid(ctx, model.SettingKeySessionJournalDir),
+		CoalesceWindowMS:  int(r.GetDuration(ctx, model.SettingKeySessionCoalesceWindow).Milliseconds()),
+		BodyChunkSize:     r.GetInt(ctx, model.SettingKeySessionBodyChunkSize),
+		BandwidthLimit:    r.GetInt(ctx, model.SettingKeySessionBandwidthLimit),
+		MaxDecodedSize:    int64(r.GetInt(ctx, model.SettingKeySessionMaxDecodedSize)),
+		RecompressBody:    r.GetBool(ctx, model.SettingKeySessionRecompressBody),
+		CaptureTiming:     r.GetBool(ctx, model.SettingKeySessionCaptureTiming),
+		ReadOnly:          r.GetBool(ctx, model.SettingKeySessionReadOnly),
+		CapabilityProfile: domain.CapabilityProfile(r.getValid(ctx, model.SettingKeySessionCapabilityProfile)),
+
+		GRPCDescriptorSet: r.getValid(ctx, model.SettingKeyGRPCDescriptorSet),
+	}
+	if mode, mappings := r.GetHostMappings(ctx); mode == domain.HostMappingRewrite {
+		cfg.HostMappings = mappings
+	}
+	cfg.ProxyAuth = r.GetProxyConfig(ctx).Credentials()
+	// 存储的值已按 schema 校验
+	cfg.IncludeResourceTypes, _ = domain.ParseResourceTypes(r.getValid(ctx, model.SettingKeySessionIncludeTypes))
+	cfg.ExcludeResourceTypes, _ = domain.ParseResourceTypes(r.getValid(ctx, model.SettingKeySessionExcludeTypes))
+	if redaction := r.GetRedactionConfig(ctx); !redaction.IsZero() {
+		cfg.Redaction = &redaction
+	}
+	return cfg
+}
+
+// GetRedactionConfig 获取持久化与导出前的脱敏配置，名称与路径按行或逗号分隔，正则表达式每行一个
+func (r *SettingsRepo) GetRedactionConfig(ctx context.Context) domain.RedactionConfig {
+	return domain.RedactionConfig{
+		Headers:   splitList(r.getValid(ctx, model.SettingKeyRedactHeaders), "\n,"),
+		Cookies:   splitList(r.getValid(ctx, model.SettingKeyRedactCookies), "\n,"),
+		JSONPaths: splitList(r.getValid(ctx, model.SettingKeyRedactJSONPaths), "\n,"),
+		Patterns:  splitList(r.getValid(ctx, model.SettingKeyRedactPatterns), "\n"),
+	}
+}
+
+// splitList 按 seps 中的任一字符拆分列表，去除空白与空项
+func splitList(value, seps string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(c rune) bool { return strings.ContainsRune(seps, c) }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetProxyConfig 获取上游代理配置，代理绕过列表按行或逗号分隔
+func (r *SettingsRepo) GetProxyConfig(ctx context.Context) domain.ProxyConfig {
+	cfg := domain.ProxyConfig{
+		Server:   r.getValid(ctx, model.SettingKeyProxyServer),
+		Username: r.getValid(ctx, model.SettingKeyProxyUsername),
+		Password: r.getValid(ctx, model.SettingKeyProxyPassword),
+	}
+	bypass := strings.FieldsFunc(r.getValid(ctx, model.SettingKeyProxyBypass), func(c rune) bool {
+		return c == '\n' || c == ',' || c == ';'
+	})
+	for _, b := range bypass {
+		if b = strings.TrimSpace(b); b != "" {
+			cfg.Bypass = append(cfg.Bypass, b)
+		}
+	}
+	return cfg
+}
+
+// SetProxyConfig 校验并保存上游代理配置
+func (r *SettingsRepo) SetProxyConfig(ctx context.Context, cfg domain.ProxyConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidSetting, err)
+	}
+	return r.SetMultiple(ctx, map[string]string{
+		model.SettingKeyProxyServer:   cfg.Server,
+		model.SettingKeyProxyBypass:   strings.Join(cfg.Bypass, "\n"),
+		model.SettingKeyProxyUsername: cfg.Username,
+		model.SettingKeyProxyPassword: cfg.Password,
+	})
+}
+
+// GetHostMappings 获取主机映射表及其生效方式
+func (r *SettingsRepo) GetHostMappings(ctx context.Context) (domain.HostMappingMode, []domain.HostMapping) {
+	mode := domain.HostMappingMode(r.getValid(ctx, model.SettingKeyHostMappingMode))
+	mappings, _ := domain.ParseHostMappings(r.getValid(ctx, model.SettingKeyHostMappings))
+	if mode == domain.HostMappingOff || len(mappings) == 0 {
+		return domain.HostMappingOff, nil
+	}
+	return mode, mappings
+}
+
+// getValid 读取已声明设置项的值，数据库中遗留的非法值回退为默认值
+func (r *SettingsRepo) getValid(ctx context.Context, key string) string {
+	spec, ok := config.LookupSetting(key)
+	if !ok {
+		return ""
+	}
+	val, err := r.Get(ctx, key)
+	if err != nil {
+		return spec.Default
+	}
+	v, err := spec.Normalize(val)
+	if err != nil {
+		return spec.Default
+	}
+	return v
 }
 
 // GetTheme 获取主题
@@ -115,13 +329,7 @@ func (r *SettingsRepo) GetAllWithDefaults(ctx context.Context) (map[string]strin
 		return nil, err
 	}
 
-	defaults := config.GetDefaultSettings()
-	result := map[string]string{
-		model.SettingKeyLanguage:    defaults.Language,
-		model.SettingKeyTheme:       defaults.Theme,
-		model.SettingKeyBrowserArgs: defaults.BrowserArgs,
-		model.SettingKeyBrowserPath: defaults.BrowserPath,
-	}
+	result := config.DefaultSettingValues()
 
 	// 用数据库中的值覆盖默认值
 	for k, v := range settings {
@@ -160,3 +368,8 @@ func (r *SettingsRepo) GetBrowserPath(ctx context.Context) string {
 func (r *SettingsRepo) SetBrowserPath(ctx context.Context, path string) error {
 	return r.Set(ctx, model.SettingKeyBrowserPath, path)
 }
+
+// GetBrowserEngine 获取未设置浏览器路径时查找的浏览器种类
+func (r *SettingsRepo) GetBrowserEngine(ctx context.Context) string {
+	return r.GetWithDefault(ctx, model.SettingKeyBrowserEngine, config.GetDefaultSettings().BrowserEngine)
+}
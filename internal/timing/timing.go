@@ -0,0 +1,193 @@
+// Package timing 关联被拦截请求与 Network 域的加载事件，为响应事件补充网络阶段计时与传输大小
+package timing
+
+import (
+	"sync"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+const (
+	// DefaultWait 事件等待加载完成的默认最长时间，超时后以已采集到的计时发出
+	DefaultWait = 5 * time.Second
+	// trackTTL 已登记但未等待事件的请求保留时间，避免未完成加载的请求长期占用内存
+	trackTTL = time.Minute
+)
+
+// Collector 计时采集器，并发安全
+type Collector struct {
+	mu        sync.Mutex
+	wait      time.Duration
+	entries   map[string]*entry // 网络请求 ID -> 采集状态
+	byRequest map[string]string // 拦截请求 ID -> 网络请求 ID
+	closed    bool
+	emitting  sync.WaitGroup // 进行中的事件发出，Close 等待其结束
+}
+
+// entry 单个请求的采集状态
+type entry struct {
+	requestID  string
+	timing     domain.ResponseTiming
+	headersEnd float64 // 收到响应头的单调时钟时间（秒），为 0 表示尚未收到
+	headerSize int64   // 响应头的传输字节数
+	finished   bool    // 已加载完成或失败
+	waiters    []func(domain.ResponseTiming)
+	timer      *time.Timer
+}
+
+// New 创建计时采集器，wait 为事件等待加载完成的最长时间，非正数时使用 DefaultWait
+func New(wait time.Duration) *Collector {
+	if wait <= 0 {
+		wait = DefaultWait
+	}
+	return &Collector{
+		wait:      wait,
+		entries:   make(map[string]*entry),
+		byRequest: make(map[string]string),
+	}
+}
+
+// Track 登记被拦截请求对应的网络请求 ID，重复登记时忽略。
+// 重定向后的请求沿用同一网络请求 ID，此时上一跳不会再收到计时，其等待中的事件立即发出
+func (c *Collector) Track(requestID, networkID string) {
+	if requestID == "" || networkID == "" {
+		return
+	}
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	prev, ok := c.entries[networkID]
+	if ok && prev.requestID == requestID {
+		c.mu.Unlock()
+		return
+	}
+	if ok {
+		c.removeLocked(networkID, prev)
+		c.emitting.Add(1)
+	}
+	e := &entry{requestID: requestID}
+	e.timer = time.AfterFunc(trackTTL, func() { c.flush(networkID) })
+	c.entries[networkID] = e
+	c.byRequest[requestID] = networkID
+	c.mu.Unlock()
+	if ok {
+		c.emit(prev)
+	}
+}
+
+// Defer 让请求的事件等待计时：请求已登记时保存 emit 并返回 true，加载完成或等待超时后以计时调用 emit；
+// 已加载完成时立即调用。未登记的请求返回 false，由调用方直接发出事件
+func (c *Collector) Defer(requestID string, emit func(domain.ResponseTiming)) bool {
+	c.mu.Lock()
+	networkID, ok := c.byRequest[requestID]
+	if !ok || c.closed {
+		c.mu.Unlock()
+		return false
+	}
+	e := c.entries[networkID]
+	if e.finished {
+		c.removeLocked(networkID, e)
+		c.mu.Unlock()
+		emit(e.timing)
+		return true
+	}
+	e.waiters = append(e.waiters, emit)
+	e.timer.Reset(c.wait)
+	c.mu.Unlock()
+	return true
+}
+
+// Response 记录收到响应头时的阶段计时，headersEnd 为收到响应头的单调时钟时间（秒），headerSize 为响应头的传输字节数
+func (c *Collector) Response(networkID string, t domain.ResponseTiming, headersEnd float64, headerSize int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[networkID]
+	if !ok {
+		return
+	}
+	t.Transfer, t.TransferSize, t.EncodedBodySize = e.timing.Transfer, e.timing.TransferSize, e.timing.EncodedBodySize
+	e.timing = t
+	e.headersEnd = headersEnd
+	e.headerSize = headerSize
+}
+
+// Finish 请求加载完成或失败，timestamp 为完成时的单调时钟时间（秒），encodedLength 为网络传输的总字节数。
+// 补充传输耗时与大小后发出等待中的事件
+func (c *Collector) Finish(networkID string, timestamp float64, encodedLength int64) {
+	c.mu.Lock()
+	e, ok := c.entries[networkID]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	e.finished = true
+	if e.headersEnd > 0 && timestamp >= e.headersEnd {
+		e.timing.Transfer = round((timestamp - e.headersEnd) * 1000)
+	}
+	if encodedLength > 0 {
+		e.timing.TransferSize = encodedLength
+		if body := encodedLength - e.headerSize; body > 0 {
+			e.timing.EncodedBodySize = body
+		}
+	}
+	if len(e.waiters) == 0 {
+		// 事件尚未等待计时，保留结果直到 Defer 或超时
+		e.timer.Reset(c.wait)
+		c.mu.Unlock()
+		return
+	}
+	c.removeLocked(networkID, e)
+	c.emitting.Add(1)
+	c.mu.Unlock()
+	c.emit(e)
+}
+
+// Close 停止采集并丢弃等待中的事件，等待进行中的事件发出结束后返回，会话停止时调用
+func (c *Collector) Close() {
+	c.mu.Lock()
+	c.closed = true
+	for id, e := range c.entries {
+		c.removeLocked(id, e)
+	}
+	c.mu.Unlock()
+	c.emitting.Wait()
+}
+
+// flush 等待超时，以已采集到的计时发出等待中的事件
+func (c *Collector) flush(networkID string) {
+	c.mu.Lock()
+	e, ok := c.entries[networkID]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	c.removeLocked(networkID, e)
+	c.emitting.Add(1)
+	c.mu.Unlock()
+	c.emit(e)
+}
+
+// removeLocked 移除采集状态，调用方需持有锁
+func (c *Collector) removeLocked(networkID string, e *entry) {
+	e.timer.Stop()
+	delete(c.entries, networkID)
+	if c.byRequest[e.requestID] == networkID {
+		delete(c.byRequest, e.requestID)
+	}
+}
+
+// emit 以当前计时调用所有等待中的事件，调用方需在释放锁前登记 emitting
+func (c *Collector) emit(e *entry) {
+	defer c.emitting.Done()
+	for _, fn := range e.waiters {
+		fn(e.timing)
+	}
+}
+
+// round 保留三位小数（微秒精度）
+func round(ms float64) float64 {
+	return float64(int64(ms*1000+0.5)) / 1000
+}
@@ -0,0 +1,148 @@
+package timing_test
+
+import (
+	"testing"
+	"time"
+
+	"cdpnetool/internal/timing"
+	"cdpnetool/pkg/domain"
+)
+
+// recv 等待一次计时回调
+func recv(t *testing.T, ch <-chan domain.ResponseTiming) domain.ResponseTiming {
+	t.Helper()
+	select {
+	case got := <-ch:
+		return got
+	case <-time.After(time.Second):
+		t.Fatal("timing not emitted")
+		return domain.ResponseTiming{}
+	}
+}
+
+// expectNone 确认一段时间内没有计时回调
+func expectNone(t *testing.T, ch <-chan domain.ResponseTiming) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		t.Fatalf("unexpected timing emitted: %+v", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestCollector_FinishAfterDefer(t *testing.T) {
+	c := timing.New(time.Second)
+	defer c.Close()
+	c.Track("req1", "net1")
+
+	ch := make(chan domain.ResponseTiming, 1)
+	if !c.Defer("req1", func(rt domain.ResponseTiming) { ch <- rt }) {
+		t.Fatal("Defer returned false for tracked request")
+	}
+	c.Response("net1", domain.ResponseTiming{DNS: 1, Connect: 2, TTFB: 30}, 100, 200)
+	expectNone(t, ch)
+
+	c.Finish("net1", 100.0155, 1200)
+	got := recv(t, ch)
+	if got.DNS != 1 || got.Connect != 2 || got.TTFB != 30 {
+		t.Errorf("got phases %+v, want response timing kept", got)
+	}
+	if got.Transfer != 15.5 {
+		t.Errorf("got transfer %v, want 15.5", got.Transfer)
+	}
+	if got.TransferSize != 1200 || got.EncodedBodySize != 1000 {
+		t.Errorf("got sizes %d/%d, want 1200/1000", got.TransferSize, got.EncodedBodySize)
+	}
+	if !got.HasPhases() {
+		t.Error("HasPhases() = false, want true")
+	}
+}
+
+func TestCollector_FinishBeforeDefer(t *testing.T) {
+	c := timing.New(time.Second)
+	defer c.Close()
+	c.Track("req1", "net1")
+	c.Response("net1", domain.ResponseTiming{TTFB: 5}, 1, 0)
+	c.Finish("net1", 1.002, 300)
+
+	ch := make(chan domain.ResponseTiming, 1)
+	if !c.Defer("req1", func(rt domain.ResponseTiming) { ch <- rt }) {
+		t.Fatal("Defer returned false for finished request")
+	}
+	got := recv(t, ch)
+	if got.TTFB != 5 || got.Transfer != 2 || got.TransferSize != 300 {
+		t.Errorf("got %+v", got)
+	}
+
+	// 计时已交付，再次等待视为未登记
+	if c.Defer("req1", func(domain.ResponseTiming) {}) {
+		t.Error("Defer returned true after timing was delivered")
+	}
+}
+
+func TestCollector_Untracked(t *testing.T) {
+	c := timing.New(time.Second)
+	defer c.Close()
+	if c.Defer("unknown", func(domain.ResponseTiming) {}) {
+		t.Error("Defer returned true for untracked request")
+	}
+	// 未登记的网络请求忽略
+	c.Response("net1", domain.ResponseTiming{TTFB: 1}, 1, 0)
+	c.Finish("net1", 2, 10)
+}
+
+func TestCollector_WaitTimeout(t *testing.T) {
+	c := timing.New(30 * time.Millisecond)
+	defer c.Close()
+	c.Track("req1", "net1")
+	c.Response("net1", domain.ResponseTiming{TTFB: 7}, 1, 0)
+
+	ch := make(chan domain.ResponseTiming, 1)
+	c.Defer("req1", func(rt domain.ResponseTiming) { ch <- rt })
+	// 未收到加载完成事件时以已采集到的计时发出
+	got := recv(t, ch)
+	if got.TTFB != 7 || got.Transfer != 0 {
+		t.Errorf("got %+v, want partial timing", got)
+	}
+}
+
+func TestCollector_Redirect(t *testing.T) {
+	c := timing.New(time.Second)
+	defer c.Close()
+	c.Track("req1", "net1")
+	ch := make(chan domain.ResponseTiming, 2)
+	c.Defer("req1", func(rt domain.ResponseTiming) { ch <- rt })
+
+	// 重复登记同一请求不影响等待中的事件
+	c.Track("req1", "net1")
+	expectNone(t, ch)
+
+	// 重定向后的请求沿用网络请求 ID，上一跳立即发出
+	c.Track("req2", "net1")
+	recv(t, ch)
+	if c.Defer("req1", func(domain.ResponseTiming) {}) {
+		t.Error("previous hop should no longer be tracked")
+	}
+
+	c.Defer("req2", func(rt domain.ResponseTiming) { ch <- rt })
+	c.Finish("net1", 1, 50)
+	if got := recv(t, ch); got.TransferSize != 50 {
+		t.Errorf("got transfer size %d, want 50", got.TransferSize)
+	}
+}
+
+func TestCollector_Close(t *testing.T) {
+	c := timing.New(20 * time.Millisecond)
+	c.Track("req1", "net1")
+	ch := make(chan domain.ResponseTiming, 1)
+	c.Defer("req1", func(rt domain.ResponseTiming) { ch <- rt })
+	c.Close()
+
+	// 关闭后丢弃等待中的事件，也不再登记新请求
+	c.Finish("net1", 1, 10)
+	expectNone(t, ch)
+	c.Track("req2", "net2")
+	if c.Defer("req2", func(domain.ResponseTiming) {}) {
+		t.Error("Defer returned true after Close")
+	}
+}
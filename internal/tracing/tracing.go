@@ -0,0 +1,94 @@
+// Package tracing 拦截链路的 OpenTelemetry 追踪：按环境变量设置导出器，
+// 并为事件处理、规则评估、行为执行与 CDP 调用提供共用的 Tracer
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"cdpnetool/pkg/domain"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 本工具产生的 span 的插桩名称，也是未设置 OTEL_SERVICE_NAME 时的服务名
+const instrumentationName = "cdpnetool"
+
+// 导出器相关的环境变量，OTLP 导出器的地址、头部、超时等沿用 OTEL_EXPORTER_OTLP_* 标准变量，
+// 采样沿用 OTEL_TRACES_SAMPLER 与 OTEL_TRACES_SAMPLER_ARG
+const (
+	EnvExporter      = "OTEL_TRACES_EXPORTER"               // otlp / console / none，未设置时不开启追踪
+	EnvProtocol      = "OTEL_EXPORTER_OTLP_PROTOCOL"        // grpc / http/protobuf，默认 http/protobuf
+	EnvTraceProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL" // 仅对追踪生效，优先于 OTEL_EXPORTER_OTLP_PROTOCOL
+)
+
+// Tracer 返回拦截链路使用的 Tracer；未调用 Setup 或未开启追踪时产生的 span 不做记录
+func Tracer() trace.Tracer {
+	// 每次从全局 Provider 获取，使 Setup 之后设置的 Provider 同样生效
+	return otel.Tracer(instrumentationName)
+}
+
+// TraceID 返回 ctx 中 span 所属追踪的 ID，未开启追踪时为空
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Setup 按环境变量设置全局 TracerProvider：OTEL_TRACES_EXPORTER 为 otlp 时按 OTLP 协议导出，
+// 为 console 时以 JSON 写入 stderr，未设置或为 none 时不开启追踪。
+// 返回的 shutdown 在退出前调用以导出尚未发送的 span，未开启追踪时不做任何事
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	shutdown = func(context.Context) error { return nil }
+	exporter, err := newExporter(ctx)
+	if err != nil || exporter == nil {
+		return shutdown, err
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", instrumentationName)),
+		resource.WithFromEnv(), // OTEL_SERVICE_NAME 与 OTEL_RESOURCE_ATTRIBUTES 覆盖默认值
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return shutdown, fmt.Errorf("%w: otel resource: %v", domain.ErrInvalidConfig, err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// newExporter 按环境变量创建导出器，不开启追踪时返回 nil
+func newExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	switch name := strings.TrimSpace(os.Getenv(EnvExporter)); name {
+	case "", "none":
+		return nil, nil
+	case "console":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case "otlp":
+		protocol := os.Getenv(EnvTraceProtocol)
+		if protocol == "" {
+			protocol = os.Getenv(EnvProtocol)
+		}
+		switch protocol {
+		case "", "http/protobuf":
+			return otlptracehttp.New(ctx)
+		case "grpc":
+			return otlptracegrpc.New(ctx)
+		default:
+			return nil, fmt.Errorf("%w: unsupported OTLP protocol %q", domain.ErrInvalidConfig, protocol)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown %s %q", domain.ErrInvalidConfig, EnvExporter, name)
+	}
+}
@@ -0,0 +1,64 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cdpnetool/internal/tracing"
+	"cdpnetool/pkg/domain"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetup(t *testing.T) {
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	tests := []struct {
+		exporter, protocol string
+		wantErr            bool
+	}{
+		{"", "", false},
+		{"none", "", false},
+		{"console", "", false},
+		{"otlp", "", false},
+		{"otlp", "grpc", false},
+		{"otlp", "http/json", true},
+		{"jaeger", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv(tracing.EnvExporter, tt.exporter)
+		t.Setenv(tracing.EnvProtocol, tt.protocol)
+		t.Setenv(tracing.EnvTraceProtocol, "")
+		shutdown, err := tracing.Setup(context.Background())
+		if tt.wantErr {
+			if !errors.Is(err, domain.ErrInvalidConfig) {
+				t.Errorf("Setup(%q, %q) error = %v, want ErrInvalidConfig", tt.exporter, tt.protocol, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Setup(%q, %q) error = %v", tt.exporter, tt.protocol, err)
+			continue
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown after Setup(%q, %q) error = %v", tt.exporter, tt.protocol, err)
+		}
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	if id := tracing.TraceID(context.Background()); id != "" {
+		t.Errorf("TraceID() without a span = %q, want empty", id)
+	}
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	ctx, span := tracing.Tracer().Start(context.Background(), "test")
+	defer span.End()
+	if got, want := tracing.TraceID(ctx), span.SpanContext().TraceID().String(); got != want || len(got) != 32 {
+		t.Errorf("TraceID() = %q, want %q", got, want)
+	}
+}
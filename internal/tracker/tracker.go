@@ -3,6 +3,7 @@ package tracker
 import (
 	"cdpnetool/internal/logger"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,28 +17,41 @@ type Entry struct {
 // Tracker 事务追踪器，负责管理请求/响应生命周期内的上下文
 type Tracker struct {
 	pool    sync.Map
-	timeout time.Duration
+	timeout atomic.Int64 // 事务过期时长，可在运行期间调整
 	log     logger.Logger
 	done    chan struct{}
 }
 
+// defaultTimeout 未指定超时时使用的事务过期时长
+const defaultTimeout = 60 * time.Second
+
 // New 创建一个新的事务追踪器
 func New(timeout time.Duration, l logger.Logger) *Tracker {
-	if timeout <= 0 {
-		timeout = 60 * time.Second
-	}
 	if l == nil {
 		l = logger.NewNop()
 	}
 	t := &Tracker{
-		timeout: timeout,
-		log:     l,
-		done:    make(chan struct{}),
+		log:  l,
+		done: make(chan struct{}),
 	}
+	t.SetTimeout(timeout)
 	go t.cleanupLoop()
 	return t
 }
 
+// SetTimeout 调整事务过期时长，对已存在的事务同样生效；非正数时使用默认值
+func (t *Tracker) SetTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	t.timeout.Store(int64(timeout))
+}
+
+// Timeout 返回当前的事务过期时长
+func (t *Tracker) Timeout() time.Duration {
+	return time.Duration(t.timeout.Load())
+}
+
 // Set 存入事务关联数据
 func (t *Tracker) Set(id string, data any) {
 	t.pool.Store(id, &Entry{
@@ -91,9 +105,10 @@ func (t *Tracker) cleanupLoop() {
 			return
 		case <-ticker.C:
 			now := time.Now()
+			timeout := t.Timeout()
 			t.pool.Range(func(key, value any) bool {
 				entry := value.(*Entry)
-				if now.Sub(entry.StartTime) > t.timeout {
+				if now.Sub(entry.StartTime) > timeout {
 					t.pool.Delete(key)
 					t.log.Debug("清理过期事务数据", "id", key, "startTime", entry.StartTime)
 				}
@@ -31,6 +31,24 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestSetTimeout(t *testing.T) {
+	tr := tracker.New(0, logger.NewNop())
+	defer tr.Stop()
+
+	if got := tr.Timeout(); got != 60*time.Second {
+		t.Errorf("Timeout() = %v, want 60s", got)
+	}
+	tr.SetTimeout(5 * time.Second)
+	if got := tr.Timeout(); got != 5*time.Second {
+		t.Errorf("Timeout() after SetTimeout(5s) = %v, want 5s", got)
+	}
+	// 非正数恢复默认值
+	tr.SetTimeout(-1)
+	if got := tr.Timeout(); got != 60*time.Second {
+		t.Errorf("Timeout() after SetTimeout(-1) = %v, want 60s", got)
+	}
+}
+
 func TestSetAndGet(t *testing.T) {
 	tr := tracker.New(5*time.Second, logger.NewNop())
 	defer tr.Stop()
@@ -0,0 +1,210 @@
+package transformer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// BodyCodec 可与 JSON 互相转换的二进制消息体编码
+type BodyCodec string
+
+const (
+	CodecNone    BodyCodec = ""        // 非二进制编码，按原文处理
+	CodecMsgpack BodyCodec = "msgpack" // MessagePack
+	CodecCBOR    BodyCodec = "cbor"    // CBOR (RFC 8949)
+)
+
+// CodecFor 根据 Content-Type 判断消息体编码，支持 application/msgpack、application/x-msgpack、
+// application/vnd.msgpack、application/cbor 以及 +msgpack / +cbor 结构化后缀
+func CodecFor(contentType string) BodyCodec {
+	mt, _, _ := strings.Cut(contentType, ";")
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	switch {
+	case mt == "application/msgpack", mt == "application/x-msgpack", mt == "application/vnd.msgpack",
+		strings.HasSuffix(mt, "+msgpack"):
+		return CodecMsgpack
+	case mt == "application/cbor", strings.HasSuffix(mt, "+cbor"):
+		return CodecCBOR
+	default:
+		return CodecNone
+	}
+}
+
+// DecodeToJSON 将二进制消息体解码为 JSON。
+// 二进制串转为 base64 字符串，非字符串的 map 键转为字符串，时间转为 RFC 3339 字符串
+func DecodeToJSON(body []byte, codec BodyCodec) (string, error) {
+	var v any
+	switch codec {
+	case CodecMsgpack:
+		dec := msgpack.NewDecoder(bytes.NewReader(body))
+		dec.SetMapDecoder(func(d *msgpack.Decoder) (any, error) {
+			return d.DecodeUntypedMap()
+		})
+		if err := dec.Decode(&v); err != nil {
+			return "", err
+		}
+	case CodecCBOR:
+		if err := cbor.Unmarshal(body, &v); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported body codec %q", codec)
+	}
+
+	data, err := json.Marshal(toJSONValue(v))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// EncodeFromJSON 将 JSON 编码为二进制消息体，整数保持整数类型，map 键按字典序输出
+func EncodeFromJSON(doc string, codec BodyCodec) ([]byte, error) {
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	v = fromJSONValue(v)
+
+	switch codec {
+	case CodecMsgpack:
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetSortMapKeys(true)
+		enc.UseCompactInts(true)
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CodecCBOR:
+		em, err := cbor.CoreDetEncOptions().EncMode()
+		if err != nil {
+			return nil, err
+		}
+		return em.Marshal(v)
+	default:
+		return nil, fmt.Errorf("unsupported body codec %q", codec)
+	}
+}
+
+// PatchBinary 对二进制消息体应用 JSON Patch：解码为 JSON、修改后重新编码
+func PatchBinary(body []byte, codec BodyCodec, patches []rulespec.JSONPatchOp) ([]byte, error) {
+	if len(body) == 0 || len(patches) == 0 {
+		return body, nil
+	}
+	doc, err := DecodeToJSON(body, codec)
+	if err != nil {
+		return body, err
+	}
+	patched, err := PatchJSON(doc, patches)
+	if err != nil {
+		return body, err
+	}
+	if patched == doc {
+		return body, nil
+	}
+	out, err := EncodeFromJSON(patched, codec)
+	if err != nil {
+		return body, err
+	}
+	return out, nil
+}
+
+// toJSONValue 将解码出的值转换为可序列化为 JSON 的形式
+func toJSONValue(v any) any {
+	switch x := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(x))
+		for k, val := range x {
+			key, ok := k.(string)
+			if !ok {
+				key = fmt.Sprint(toJSONValue(k))
+			}
+			m[key] = toJSONValue(val)
+		}
+		return m
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, val := range x {
+			m[k] = toJSONValue(val)
+		}
+		return m
+	case []any:
+		s := make([]any, len(x))
+		for i, val := range x {
+			s[i] = toJSONValue(val)
+		}
+		return s
+	case []byte:
+		return base64.StdEncoding.EncodeToString(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case big.Int:
+		return json.Number(x.String())
+	case *big.Int:
+		return json.Number(x.String())
+	case cbor.Tag:
+		return toJSONValue(x.Content)
+	case cbor.SimpleValue:
+		return nil
+	case float32:
+		return finite(float64(x))
+	case float64:
+		return finite(x)
+	default:
+		return v
+	}
+}
+
+// finite 将 JSON 无法表示的 NaN 与无穷值转为 null
+func finite(f float64) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	return f
+}
+
+// fromJSONValue 将 JSON 数字还原为整数或浮点数
+func fromJSONValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, val := range x {
+			x[k] = fromJSONValue(val)
+		}
+		return x
+	case []any:
+		for i, val := range x {
+			x[i] = fromJSONValue(val)
+		}
+		return x
+	case json.Number:
+		if i, err := strconv.ParseInt(string(x), 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(string(x), 10, 64); err == nil {
+			return u
+		}
+		f, _ := x.Float64()
+		return f
+	default:
+		return v
+	}
+}
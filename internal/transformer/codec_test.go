@@ -0,0 +1,115 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestCodecFor(t *testing.T) {
+	tests := []struct {
+		ct   string
+		want transformer.BodyCodec
+	}{
+		{"application/msgpack", transformer.CodecMsgpack},
+		{"application/x-msgpack; charset=binary", transformer.CodecMsgpack},
+		{"application/vnd.msgpack", transformer.CodecMsgpack},
+		{"application/vnd.api+msgpack", transformer.CodecMsgpack},
+		{"Application/CBOR", transformer.CodecCBOR},
+		{"application/senml+cbor", transformer.CodecCBOR},
+		{"application/json", transformer.CodecNone},
+		{"", transformer.CodecNone},
+	}
+	for _, tt := range tests {
+		if got := transformer.CodecFor(tt.ct); got != tt.want {
+			t.Errorf("CodecFor(%q) = %q, want %q", tt.ct, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeToJSON(t *testing.T) {
+	value := map[string]any{"user": map[string]any{"id": 42, "name": "alice"}, "tags": []string{"a", "b"}, "blob": []byte{1, 2}}
+	mp, _ := msgpack.Marshal(value)
+	cb, _ := cbor.Marshal(value)
+	cbIntKey, _ := cbor.Marshal(map[int]string{1: "one"})
+
+	tests := []struct {
+		name  string
+		body  []byte
+		codec transformer.BodyCodec
+		want  string
+	}{
+		{"MessagePack", mp, transformer.CodecMsgpack, `{"blob":"AQI=","tags":["a","b"],"user":{"id":42,"name":"alice"}}`},
+		{"CBOR", cb, transformer.CodecCBOR, `{"blob":"AQI=","tags":["a","b"],"user":{"id":42,"name":"alice"}}`},
+		{"CBOR 整数键", cbIntKey, transformer.CodecCBOR, `{"1":"one"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.DecodeToJSON(tt.body, tt.codec)
+			if err != nil {
+				t.Fatalf("DecodeToJSON error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := transformer.DecodeToJSON([]byte{0xc1}, transformer.CodecMsgpack); err == nil {
+		t.Error("expected error for invalid MessagePack")
+	}
+}
+
+func TestPatchBinary(t *testing.T) {
+	patches := []rulespec.JSONPatchOp{
+		{Op: "replace", Path: "/user/name", Value: "bob"},
+		{Op: "remove", Path: "/debug"},
+	}
+	value := map[string]any{"user": map[string]any{"id": 42, "name": "alice"}, "debug": true}
+
+	for _, codec := range []transformer.BodyCodec{transformer.CodecMsgpack, transformer.CodecCBOR} {
+		t.Run(string(codec), func(t *testing.T) {
+			var body []byte
+			if codec == transformer.CodecMsgpack {
+				body, _ = msgpack.Marshal(value)
+			} else {
+				body, _ = cbor.Marshal(value)
+			}
+			out, err := transformer.PatchBinary(body, codec, patches)
+			if err != nil {
+				t.Fatalf("PatchBinary error: %v", err)
+			}
+			got, err := transformer.DecodeToJSON(out, codec)
+			if err != nil {
+				t.Fatalf("re-encoded body is invalid: %v", err)
+			}
+			if want := `{"user":{"id":42,"name":"bob"}}`; got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+
+			// 整数重新编码后仍为整数
+			var decoded map[string]map[string]any
+			if codec == transformer.CodecMsgpack {
+				_ = msgpack.Unmarshal(out, &decoded)
+			} else {
+				_ = cbor.Unmarshal(out, &decoded)
+			}
+			switch decoded["user"]["id"].(type) {
+			case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+			default:
+				t.Errorf("got id of type %T, want integer", decoded["user"]["id"])
+			}
+		})
+	}
+
+	// 解码失败时保持原消息体
+	body := []byte{0xc1}
+	out, err := transformer.PatchBinary(body, transformer.CodecMsgpack, patches)
+	if err == nil || string(out) != string(body) {
+		t.Errorf("got %v, %v; want original body and error", out, err)
+	}
+}
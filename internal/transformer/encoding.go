@@ -0,0 +1,106 @@
+package transformer
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ContentEncoding 可透明解压与重新压缩的消息体内容编码
+type ContentEncoding string
+
+const (
+	EncodingNone    ContentEncoding = ""        // 未压缩或不支持的编码，按原文处理
+	EncodingGzip    ContentEncoding = "gzip"    // gzip (RFC 1952)
+	EncodingDeflate ContentEncoding = "deflate" // zlib 封装的 deflate (RFC 1950)，解压时兼容裸 deflate
+	EncodingBrotli  ContentEncoding = "br"      // Brotli (RFC 7932)
+)
+
+// ErrDecodedTooLarge 解压后的消息体超过大小上限
+var ErrDecodedTooLarge = errors.New("decoded body exceeds size limit")
+
+// EncodingFor 根据 Content-Encoding 判断内容编码，支持 gzip、x-gzip、deflate 与 br；
+// 叠加了多个编码或编码不受支持时返回 EncodingNone
+func EncodingFor(contentEncoding string) ContentEncoding {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "gzip", "x-gzip":
+		return EncodingGzip
+	case "deflate":
+		return EncodingDeflate
+	case "br":
+		return EncodingBrotli
+	default:
+		return EncodingNone
+	}
+}
+
+// DecodeContent 解压消息体，limit 大于 0 时解压后超过 limit 字节返回 ErrDecodedTooLarge。
+// 消息体不是对应编码的数据（如已被浏览器解压）时返回错误
+func DecodeContent(body []byte, enc ContentEncoding, limit int64) ([]byte, error) {
+	var r io.Reader
+	switch enc {
+	case EncodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case EncodingDeflate:
+		// 按规范应为 zlib 封装，部分服务端直接发送裸 deflate 数据
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			fr := flate.NewReader(bytes.NewReader(body))
+			defer fr.Close()
+			r = fr
+		} else {
+			defer zr.Close()
+			r = zr
+		}
+	case EncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", enc)
+	}
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(out)) > limit {
+		return nil, ErrDecodedTooLarge
+	}
+	return out, nil
+}
+
+// EncodeContent 以指定内容编码压缩消息体
+func EncodeContent(body []byte, enc ContentEncoding) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch enc {
+	case EncodingGzip:
+		w = gzip.NewWriter(&buf)
+	case EncodingDeflate:
+		w = zlib.NewWriter(&buf)
+	case EncodingBrotli:
+		w = brotli.NewWriter(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", enc)
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,90 @@
+package transformer_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"testing"
+
+	"cdpnetool/internal/transformer"
+)
+
+func TestEncodingFor(t *testing.T) {
+	tests := []struct {
+		ce   string
+		want transformer.ContentEncoding
+	}{
+		{"gzip", transformer.EncodingGzip},
+		{"X-GZIP", transformer.EncodingGzip},
+		{" deflate ", transformer.EncodingDeflate},
+		{"br", transformer.EncodingBrotli},
+		{"identity", transformer.EncodingNone},
+		{"gzip, br", transformer.EncodingNone},
+		{"zstd", transformer.EncodingNone},
+		{"", transformer.EncodingNone},
+	}
+	for _, tt := range tests {
+		if got := transformer.EncodingFor(tt.ce); got != tt.want {
+			t.Errorf("EncodingFor(%q) = %q, want %q", tt.ce, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeDecodeContent_RoundTrip(t *testing.T) {
+	body := []byte(`{"message":"` + string(bytes.Repeat([]byte("hello "), 100)) + `"}`)
+	for _, enc := range []transformer.ContentEncoding{transformer.EncodingGzip, transformer.EncodingDeflate, transformer.EncodingBrotli} {
+		encoded, err := transformer.EncodeContent(body, enc)
+		if err != nil {
+			t.Fatalf("EncodeContent(%s) failed: %v", enc, err)
+		}
+		if bytes.Equal(encoded, body) || len(encoded) >= len(body) {
+			t.Errorf("EncodeContent(%s) did not compress: %d bytes", enc, len(encoded))
+		}
+		decoded, err := transformer.DecodeContent(encoded, enc, 0)
+		if err != nil {
+			t.Fatalf("DecodeContent(%s) failed: %v", enc, err)
+		}
+		if !bytes.Equal(decoded, body) {
+			t.Errorf("DecodeContent(%s) = %q, want %q", enc, decoded, body)
+		}
+	}
+}
+
+func TestDecodeContent_RawDeflate(t *testing.T) {
+	// 部分服务端以 deflate 声明却发送不带 zlib 头的裸数据
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	w.Write([]byte("raw deflate"))
+	w.Close()
+	decoded, err := transformer.DecodeContent(buf.Bytes(), transformer.EncodingDeflate, 0)
+	if err != nil {
+		t.Fatalf("DecodeContent failed: %v", err)
+	}
+	if string(decoded) != "raw deflate" {
+		t.Errorf("expected raw deflate, got %q", decoded)
+	}
+}
+
+func TestDecodeContent_Limit(t *testing.T) {
+	body := bytes.Repeat([]byte("a"), 1000)
+	encoded, _ := transformer.EncodeContent(body, transformer.EncodingGzip)
+
+	// 上限针对解压后的大小
+	if _, err := transformer.DecodeContent(encoded, transformer.EncodingGzip, 999); !errors.Is(err, transformer.ErrDecodedTooLarge) {
+		t.Errorf("expected ErrDecodedTooLarge, got %v", err)
+	}
+	decoded, err := transformer.DecodeContent(encoded, transformer.EncodingGzip, 1000)
+	if err != nil || len(decoded) != 1000 {
+		t.Errorf("expected 1000 bytes within limit, got %d, err: %v", len(decoded), err)
+	}
+}
+
+func TestDecodeContent_NotEncoded(t *testing.T) {
+	// 浏览器已解压的消息体不是对应编码的数据
+	if _, err := transformer.DecodeContent([]byte(`{"plain":true}`), transformer.EncodingGzip, 0); err == nil {
+		t.Error("expected error for plain body declared as gzip")
+	}
+	if _, err := transformer.DecodeContent([]byte(`{"plain":true}`), transformer.EncodingNone, 0); err == nil {
+		t.Error("expected error for unsupported encoding")
+	}
+}
@@ -0,0 +1,94 @@
+package transformer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+// jqTimeout 单次 jq 程序执行的最长时间，避免死循环的程序阻塞请求处理
+const jqTimeout = time.Second
+
+// jqCache 已编译的 jq 程序：程序文本 -> *gojq.Code
+var jqCache sync.Map
+
+// CompileJQ 解析并编译 jq 程序，结果按程序文本缓存。
+// 程序中的 $ENV 与 env 不读取进程环境变量，避免规则配置泄露本机信息
+func CompileJQ(program string) (*gojq.Code, error) {
+	if val, ok := jqCache.Load(program); ok {
+		return val.(*gojq.Code), nil
+	}
+	query, err := gojq.Parse(program)
+	if err != nil {
+		return nil, fmt.Errorf("parse jq program: %w", err)
+	}
+	code, err := gojq.Compile(query, gojq.WithEnvironLoader(func() []string { return nil }))
+	if err != nil {
+		return nil, fmt.Errorf("compile jq program: %w", err)
+	}
+	jqCache.Store(program, code)
+	return code, nil
+}
+
+// TransformJQ 以 JSON 消息体为输入执行 jq 程序，返回第一个输出的 JSON 文本。
+// 消息体或程序为空时原样返回；程序无输出、出错或超时时返回错误与原消息体
+func TransformJQ(body, program string) (string, error) {
+	if body == "" || strings.TrimSpace(program) == "" {
+		return body, nil
+	}
+	code, err := CompileJQ(program)
+	if err != nil {
+		return body, err
+	}
+
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var input any
+	if err := dec.Decode(&input); err != nil {
+		return body, fmt.Errorf("body is not valid JSON: %w", err)
+	}
+	if dec.More() {
+		return body, errors.New("body is not valid JSON: unexpected trailing data")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jqTimeout)
+	defer cancel()
+	out, ok := code.RunWithContext(ctx, input).Next()
+	if !ok {
+		return body, errors.New("jq program produced no output")
+	}
+	if err, ok := out.(error); ok {
+		return body, fmt.Errorf("run jq program: %w", err)
+	}
+	data, err := gojq.Marshal(out)
+	if err != nil {
+		return body, err
+	}
+	return string(data), nil
+}
+
+// TransformBinaryJQ 对 MessagePack 与 CBOR 消息体执行 jq 程序：解码为 JSON、转换后重新编码
+func TransformBinaryJQ(body []byte, codec BodyCodec, program string) ([]byte, error) {
+	if len(body) == 0 || strings.TrimSpace(program) == "" {
+		return body, nil
+	}
+	doc, err := DecodeToJSON(body, codec)
+	if err != nil {
+		return body, err
+	}
+	transformed, err := TransformJQ(doc, program)
+	if err != nil {
+		return body, err
+	}
+	out, err := EncodeFromJSON(transformed, codec)
+	if err != nil {
+		return body, err
+	}
+	return out, nil
+}
@@ -0,0 +1,129 @@
+package transformer_test
+
+import (
+	"strings"
+	"testing"
+
+	"cdpnetool/internal/transformer"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestTransformJQ(t *testing.T) {
+	const body = `{"data":{"items":[{"id":1,"price":10,"tags":["a"]},{"id":2,"price":25,"tags":[]}]},"total":9007199254740993}`
+
+	tests := []struct {
+		name    string
+		body    string
+		program string
+		want    string
+	}{
+		{
+			name:    "重组结构",
+			body:    body,
+			program: `{ids: [.data.items[].id], count: (.data.items | length)}`,
+			want:    `{"count":2,"ids":[1,2]}`,
+		},
+		{
+			name:    "过滤与更新",
+			body:    body,
+			program: `.data.items |= map(select(.price > 20) | .price *= 2) | del(.total)`,
+			want:    `{"data":{"items":[{"id":2,"price":50,"tags":[]}]}}`,
+		},
+		{
+			name:    "大整数不丢失精度",
+			body:    body,
+			program: `.total`,
+			want:    `9007199254740993`,
+		},
+		{
+			name:    "多个输出取第一个",
+			body:    body,
+			program: `.data.items[]`,
+			want:    `{"id":1,"price":10,"tags":["a"]}`,
+		},
+		{
+			name:    "HTML 字符不转义",
+			body:    `{"html":"<b>&</b>"}`,
+			program: `.`,
+			want:    `{"html":"<b>&</b>"}`,
+		},
+		{
+			name:    "空消息体原样返回",
+			body:    "",
+			program: `{a: 1}`,
+			want:    "",
+		},
+		{
+			name:    "空程序原样返回",
+			body:    body,
+			program: "  ",
+			want:    body,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.TransformJQ(tt.body, tt.program)
+			if err != nil {
+				t.Fatalf("TransformJQ error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformJQ_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		program string
+		wantErr string
+	}{
+		{"语法错误", `{}`, `.a |`, "parse jq program"},
+		{"未定义函数", `{}`, `nosuchfn`, "compile jq program"},
+		{"非 JSON 消息体", `not json`, `.`, "not valid JSON"},
+		{"多个 JSON 值", `{} {}`, `.`, "trailing data"},
+		{"运行时错误", `{"a":"x"}`, `.a + 1`, "run jq program"},
+		{"无输出", `{"a":1}`, `empty`, "no output"},
+		{"死循环超时", `0`, `def f: f; f`, "run jq program"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.TransformJQ(tt.body, tt.program)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if got != tt.body {
+				t.Errorf("got body %s on error, want original", got)
+			}
+		})
+	}
+}
+
+func TestTransformJQ_NoEnvironment(t *testing.T) {
+	t.Setenv("CDPNETOOL_JQ_SECRET", "s3cret")
+	got, err := transformer.TransformJQ(`{}`, `{env: env, ENV: $ENV}`)
+	if err != nil {
+		t.Fatalf("TransformJQ error: %v", err)
+	}
+	if strings.Contains(got, "s3cret") {
+		t.Errorf("jq program read process environment: %s", got)
+	}
+}
+
+func TestTransformBinaryJQ(t *testing.T) {
+	body, _ := msgpack.Marshal(map[string]any{"user": map[string]any{"name": "alice", "id": 1}})
+	out, err := transformer.TransformBinaryJQ(body, transformer.CodecMsgpack, `.user.name |= ascii_upcase`)
+	if err != nil {
+		t.Fatalf("TransformBinaryJQ error: %v", err)
+	}
+	doc, err := transformer.DecodeToJSON(out, transformer.CodecMsgpack)
+	if err != nil {
+		t.Fatalf("DecodeToJSON error: %v", err)
+	}
+	if doc != `{"user":{"id":1,"name":"ALICE"}}` {
+		t.Errorf("got %s, want upper-cased name", doc)
+	}
+}
@@ -0,0 +1,190 @@
+package transformer
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// bracketPattern 匹配路径中的 [*] 与 [n] 写法
+var bracketPattern = regexp.MustCompile(`\[(\*|\d+)\]`)
+
+// MaskJSON 按路径模式移除或置空 JSON 中的字段，返回修改后的 JSON。
+// 路径以 . 分隔：* 匹配任意键或数组元素，** 匹配任意层级（含零层），数字匹配数组下标，
+// 也支持 users[*].email 写法；普通键作用于数组时自动对每个元素生效，如 data.users.email。
+func MaskJSON(body string, paths []string, mode rulespec.MaskMode) (string, error) {
+	if body == "" || len(paths) == 0 {
+		return body, nil
+	}
+	if !gjson.Valid(body) {
+		return body, errors.New("body is not valid JSON")
+	}
+	if mode != rulespec.MaskModeRemove && mode != rulespec.MaskModeNull {
+		return body, errors.New("unknown mask mode: " + string(mode))
+	}
+
+	targets := maskTargets(body, paths)
+
+	// 按文档逆序修改，先删除的数组元素不会影响前面元素的下标
+	current := body
+	for i := len(targets) - 1; i >= 0; i-- {
+		var err error
+		if mode == rulespec.MaskModeNull {
+			current, err = sjson.SetRaw(current, targets[i], "null")
+		} else {
+			current, err = sjson.Delete(current, targets[i])
+		}
+		if err != nil {
+			return body, err
+		}
+	}
+	return current, nil
+}
+
+// RedactJSON 按路径模式将 JSON 中的字段值替换为 replacement 字符串，路径写法同 MaskJSON
+func RedactJSON(body string, paths []string, replacement string) (string, error) {
+	if body == "" || len(paths) == 0 {
+		return body, nil
+	}
+	if !gjson.Valid(body) {
+		return body, errors.New("body is not valid JSON")
+	}
+
+	targets := maskTargets(body, paths)
+	current := body
+	for _, target := range targets {
+		if hasParentTarget(targets, target) {
+			// 上层字段已整体替换
+			continue
+		}
+		var err error
+		if current, err = sjson.Set(current, target, replacement); err != nil {
+			return body, err
+		}
+	}
+	return current, nil
+}
+
+// hasParentTarget 判断目标的上层字段是否也在替换范围内
+func hasParentTarget(targets []string, target string) bool {
+	for _, t := range targets {
+		if strings.HasPrefix(target, t+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// maskTargets 返回路径模式匹配到的字段的 sjson 路径，按文档顺序去重
+func maskTargets(body string, paths []string) []string {
+	root := gjson.Parse(body)
+	seen := make(map[string]bool)
+	var targets []string
+	for _, p := range paths {
+		segs := splitMaskPath(p)
+		if len(segs) == 0 {
+			continue
+		}
+		collectMaskTargets(root, segs, nil, func(path []string) {
+			key := strings.Join(path, ".")
+			if !seen[key] {
+				seen[key] = true
+				targets = append(targets, key)
+			}
+		})
+	}
+	return targets
+}
+
+// splitMaskPath 将路径模式拆分为段
+func splitMaskPath(p string) []string {
+	p = bracketPattern.ReplaceAllString(strings.TrimSpace(p), ".$1")
+	var segs []string
+	for _, seg := range strings.Split(p, ".") {
+		if seg != "" {
+			segs = append(segs, seg)
+		}
+	}
+	return segs
+}
+
+// collectMaskTargets 按文档顺序收集与模式匹配的具体路径（sjson 格式的已转义段）
+func collectMaskTargets(v gjson.Result, segs []string, path []string, emit func([]string)) {
+	if len(segs) == 0 {
+		if len(path) > 0 {
+			emit(path)
+		}
+		return
+	}
+
+	seg := segs[0]
+	if seg == "**" {
+		collectMaskTargets(v, segs[1:], path, emit)
+		forEachChild(v, func(key string, child gjson.Result) {
+			collectMaskTargets(child, segs, appendSeg(path, key), emit)
+		})
+		return
+	}
+
+	switch {
+	case v.IsArray():
+		index, err := strconv.Atoi(seg)
+		explicit := seg == "*" || err == nil
+		forEachChild(v, func(key string, child gjson.Result) {
+			switch {
+			case seg == "*" || (err == nil && key == strconv.Itoa(index)):
+				collectMaskTargets(child, segs[1:], appendSeg(path, key), emit)
+			case !explicit:
+				// 普通键作用于数组时对每个元素生效
+				collectMaskTargets(child, segs, appendSeg(path, key), emit)
+			}
+		})
+	case v.IsObject():
+		forEachChild(v, func(key string, child gjson.Result) {
+			if seg == "*" || key == seg {
+				collectMaskTargets(child, segs[1:], appendSeg(path, escapeSJSONKey(key)), emit)
+			}
+		})
+	}
+}
+
+// forEachChild 遍历对象的键或数组的下标
+func forEachChild(v gjson.Result, fn func(key string, child gjson.Result)) {
+	switch {
+	case v.IsArray():
+		for i, child := range v.Array() {
+			fn(strconv.Itoa(i), child)
+		}
+	case v.IsObject():
+		v.ForEach(func(key, child gjson.Result) bool {
+			fn(key.String(), child)
+			return true
+		})
+	}
+}
+
+// appendSeg 复制路径并追加一段，避免兄弟分支共享底层数组
+func appendSeg(path []string, seg string) []string {
+	out := make([]string, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, seg)
+}
+
+// escapeSJSONKey 转义 sjson 路径中有特殊含义的字符
+func escapeSJSONKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '.', '*', '?', '|', '#', '@', '\\', '!', '=', '<', '>', '%':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
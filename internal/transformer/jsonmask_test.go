@@ -0,0 +1,119 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"cdpnetool/internal/transformer"
+	"cdpnetool/pkg/rulespec"
+)
+
+func TestMaskJSON(t *testing.T) {
+	const gql = `{"data":{"users":[{"id":1,"email":"a@x","profile":{"avatar":"a.png"}},{"id":2,"email":"b@x","profile":{"avatar":null}}]},"extensions":{"avatar":"keep"}}`
+
+	tests := []struct {
+		name  string
+		body  string
+		paths []string
+		mode  rulespec.MaskMode
+		want  string
+	}{
+		{
+			name:  "数组自动展开",
+			body:  gql,
+			paths: []string{"data.users.email"},
+			mode:  rulespec.MaskModeRemove,
+			want:  `{"data":{"users":[{"id":1,"profile":{"avatar":"a.png"}},{"id":2,"profile":{"avatar":null}}]},"extensions":{"avatar":"keep"}}`,
+		},
+		{
+			name:  "通配符置空",
+			body:  gql,
+			paths: []string{"data.users[*].profile"},
+			mode:  rulespec.MaskModeNull,
+			want:  `{"data":{"users":[{"id":1,"email":"a@x","profile":null},{"id":2,"email":"b@x","profile":null}]},"extensions":{"avatar":"keep"}}`,
+		},
+		{
+			name:  "任意层级",
+			body:  gql,
+			paths: []string{"data.**.avatar"},
+			mode:  rulespec.MaskModeRemove,
+			want:  `{"data":{"users":[{"id":1,"email":"a@x","profile":{}},{"id":2,"email":"b@x","profile":{}}]},"extensions":{"avatar":"keep"}}`,
+		},
+		{
+			name:  "数组下标",
+			body:  gql,
+			paths: []string{"data.users.1"},
+			mode:  rulespec.MaskModeRemove,
+			want:  `{"data":{"users":[{"id":1,"email":"a@x","profile":{"avatar":"a.png"}}]},"extensions":{"avatar":"keep"}}`,
+		},
+		{
+			name:  "删除多个数组元素",
+			body:  `{"items":[1,2,3]}`,
+			paths: []string{"items.*"},
+			mode:  rulespec.MaskModeRemove,
+			want:  `{"items":[]}`,
+		},
+		{
+			name:  "键名包含特殊字符",
+			body:  `{"a.b":1,"c":2}`,
+			paths: []string{"*"},
+			mode:  rulespec.MaskModeNull,
+			want:  `{"a.b":null,"c":null}`,
+		},
+		{
+			name:  "路径不存在",
+			body:  `{"a":1}`,
+			paths: []string{"b.c"},
+			mode:  rulespec.MaskModeRemove,
+			want:  `{"a":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.MaskJSON(tt.body, tt.paths, tt.mode)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskJSON_Invalid(t *testing.T) {
+	if got, err := transformer.MaskJSON("<html>", []string{"a"}, rulespec.MaskModeRemove); err == nil || got != "<html>" {
+		t.Errorf("got %q, %v; want original body and error", got, err)
+	}
+	if _, err := transformer.MaskJSON(`{"a":1}`, []string{"a"}, "hide"); err == nil {
+		t.Error("expected error for unknown mask mode")
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		paths []string
+		want  string
+	}{
+		{"数组自动展开", `{"users":[{"id":1,"token":"a"},{"id":2,"token":"b"}]}`, []string{"users.token"}, `{"users":[{"id":1,"token":"***"},{"id":2,"token":"***"}]}`},
+		{"上层字段整体替换", `{"auth":{"token":"a","key":"b"}}`, []string{"**.token", "auth"}, `{"auth":"***"}`},
+		{"未匹配", `{"a":1}`, []string{"b"}, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.RedactJSON(tt.body, tt.paths, "***")
+			if err != nil {
+				t.Fatalf("RedactJSON error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if got, err := transformer.RedactJSON("<html>", []string{"a"}, "***"); err == nil || got != "<html>" {
+		t.Errorf("got %q, %v; want original body and error", got, err)
+	}
+}
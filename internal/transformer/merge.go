@@ -0,0 +1,75 @@
+package transformer
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// MergeJSON 将次级 JSON 文档 extra 合并到 body 中，返回合并后的 JSON。
+// keys 为目标路径到来源路径的映射，路径以 . 分隔，来源路径为空表示整个 extra，目标路径为空表示响应根对象；
+// keys 为空时将 extra 整体合并到根对象。两侧均为对象时逐键深度合并，否则以 extra 的值覆盖目标；
+// 来源路径在 extra 中不存在时跳过该项
+func MergeJSON(body, extra string, keys map[string]string) (string, error) {
+	if body == "" {
+		return body, nil
+	}
+	if !gjson.Valid(body) {
+		return body, errors.New("body is not valid JSON")
+	}
+	if !gjson.Valid(extra) {
+		return body, errors.New("augment source is not valid JSON")
+	}
+	if len(keys) == 0 {
+		keys = map[string]string{"": ""}
+	}
+
+	// 按目标路径排序，多个映射写入同一位置时结果可预期
+	targets := make([]string, 0, len(keys))
+	for target := range keys {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	current := body
+	for _, target := range targets {
+		value := gjson.Parse(extra)
+		if src := strings.TrimSpace(keys[target]); src != "" {
+			if value = gjson.Get(extra, src); !value.Exists() {
+				continue
+			}
+		}
+		var err error
+		if current, err = mergeAt(current, strings.Trim(strings.TrimSpace(target), "."), value); err != nil {
+			return body, err
+		}
+	}
+	return current, nil
+}
+
+// mergeAt 将 value 合并到 doc 的 path 位置，path 为空表示根对象
+func mergeAt(doc, path string, value gjson.Result) (string, error) {
+	existing := gjson.Parse(doc)
+	if path != "" {
+		existing = gjson.Get(doc, path)
+	}
+	if value.IsObject() && existing.IsObject() {
+		var err error
+		value.ForEach(func(key, child gjson.Result) bool {
+			childPath := escapeSJSONKey(key.String())
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			doc, err = mergeAt(doc, childPath, child)
+			return err == nil
+		})
+		return doc, err
+	}
+	if path == "" {
+		return doc, errors.New("cannot merge into non-object JSON root")
+	}
+	return sjson.SetRaw(doc, path, value.Raw)
+}
@@ -0,0 +1,91 @@
+package transformer_test
+
+import (
+	"strings"
+	"testing"
+
+	"cdpnetool/internal/transformer"
+)
+
+func TestMergeJSON(t *testing.T) {
+	const body = `{"user":{"id":1,"name":"alice","tags":["a"]},"total":9007199254740993}`
+	const extra = `{"user":{"name":"bob","beta":true,"tags":["x","y"]},"flags":{"newUI":true},"a.b":1}`
+
+	tests := []struct {
+		name string
+		body string
+		keys map[string]string
+		want string
+	}{
+		{
+			name: "整体深度合并到根对象",
+			body: body,
+			want: `{"user":{"id":1,"name":"bob","tags":["x","y"],"beta":true},"total":9007199254740993,"flags":{"newUI":true},"a.b":1}`,
+		},
+		{
+			name: "按映射合并到指定键",
+			body: body,
+			keys: map[string]string{"user.extra": "flags", "features": ""},
+			want: `{"user":{"id":1,"name":"alice","tags":["a"],"extra":{"newUI":true}},"total":9007199254740993,"features":{"user":{"name":"bob","beta":true,"tags":["x","y"]},"flags":{"newUI":true},"a.b":1}}`,
+		},
+		{
+			name: "目标为对象时深度合并",
+			body: body,
+			keys: map[string]string{"user": "user"},
+			want: `{"user":{"id":1,"name":"bob","tags":["x","y"],"beta":true},"total":9007199254740993}`,
+		},
+		{
+			name: "标量覆盖目标",
+			body: body,
+			keys: map[string]string{"total": "user.beta"},
+			want: `{"user":{"id":1,"name":"alice","tags":["a"]},"total":true}`,
+		},
+		{
+			name: "来源路径不存在时跳过",
+			body: body,
+			keys: map[string]string{"missing": "nope"},
+			want: body,
+		},
+		{
+			name: "空消息体原样返回",
+			body: "",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.MergeJSON(tt.body, extra, tt.keys)
+			if err != nil {
+				t.Fatalf("MergeJSON error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		extra   string
+		wantErr string
+	}{
+		{"非 JSON 消息体", `not json`, `{}`, "body is not valid JSON"},
+		{"非 JSON 数据源", `{}`, `<html>`, "augment source is not valid JSON"},
+		{"根为数组", `[1,2]`, `{"a":1}`, "non-object JSON root"},
+		{"数据源为标量", `{}`, `1`, "non-object JSON root"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.MergeJSON(tt.body, tt.extra, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if got != tt.body {
+				t.Errorf("got body %s on error, want original", got)
+			}
+		})
+	}
+}
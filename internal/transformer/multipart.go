@@ -0,0 +1,231 @@
+package transformer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// MultipartBoundary 返回 multipart/form-data 的分隔符，不是 multipart 表单时返回空
+func MultipartBoundary(contentType string) string {
+	mt, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mt != "multipart/form-data" {
+		return ""
+	}
+	return params["boundary"]
+}
+
+// formPart multipart 表单的一个部分，未修改的部分按原始头部与内容输出
+type formPart struct {
+	headers  []string // 原始头部行，保持顺序与大小写
+	name     string
+	filename string
+	file     bool // Content-Disposition 中带有 filename 参数
+	content  []byte
+}
+
+// multipartForm 解析后的 multipart 表单，序列化时沿用原分隔符与换行符
+type multipartForm struct {
+	boundary string
+	newline  string
+	preamble []byte // 第一个分隔符之前的内容
+	parts    []*formPart
+	epilogue []byte // 结束分隔符之后的内容
+}
+
+// parseMultipart 按分隔符切分 multipart 表单，不解码各部分的内容
+func parseMultipart(body []byte, boundary string) (*multipartForm, error) {
+	if boundary == "" {
+		return nil, errors.New("multipart boundary is empty")
+	}
+	delim := []byte("--" + boundary)
+	start := bytes.Index(body, delim)
+	if start < 0 {
+		return nil, fmt.Errorf("multipart boundary %q not found", boundary)
+	}
+	form := &multipartForm{boundary: boundary, newline: "\r\n", preamble: body[:start]}
+	rest := body[start+len(delim):]
+	if !bytes.HasPrefix(rest, []byte("\r\n")) && bytes.HasPrefix(rest, []byte("\n")) {
+		form.newline = "\n"
+	}
+	next := []byte(form.newline + string(delim))
+	for {
+		if bytes.HasPrefix(rest, []byte("--")) {
+			form.epilogue = rest[2:]
+			return form, nil
+		}
+		// 跳过分隔符行末尾的空白与换行
+		eol := bytes.IndexByte(rest, '\n')
+		if eol < 0 {
+			return nil, errors.New("multipart body is truncated")
+		}
+		rest = rest[eol+1:]
+		end := bytes.Index(rest, next)
+		if end < 0 {
+			return nil, errors.New("multipart closing boundary not found")
+		}
+		part, err := parsePart(rest[:end], form.newline)
+		if err != nil {
+			return nil, err
+		}
+		form.parts = append(form.parts, part)
+		rest = rest[end+len(next):]
+	}
+}
+
+// parsePart 解析一个部分的头部并记录字段名与文件名
+func parsePart(raw []byte, newline string) (*formPart, error) {
+	part := &formPart{}
+	sep := []byte(newline + newline)
+	head, content, ok := bytes.Cut(raw, sep)
+	if bytes.HasPrefix(raw, []byte(newline)) {
+		// 没有头部的部分
+		head, content, ok = nil, raw[len(newline):], true
+	}
+	if !ok {
+		return nil, errors.New("multipart part has no header terminator")
+	}
+	part.content = content
+	if len(head) > 0 {
+		part.headers = strings.Split(string(head), newline)
+	}
+	if _, params, err := mime.ParseMediaType(part.header("Content-Disposition")); err == nil {
+		part.name = params["name"]
+		part.filename, part.file = params["filename"]
+	}
+	return part, nil
+}
+
+// header 忽略大小写获取头部值
+func (p *formPart) header(name string) string {
+	for _, line := range p.headers {
+		if k, v, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(k), name) {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// setHeader 替换同名头部行，不存在时追加
+func (p *formPart) setHeader(name, value string) {
+	line := name + ": " + value
+	for i, l := range p.headers {
+		if k, _, ok := strings.Cut(l, ":"); ok && strings.EqualFold(strings.TrimSpace(k), name) {
+			p.headers[i] = line
+			return
+		}
+	}
+	p.headers = append(p.headers, line)
+}
+
+// encode 序列化表单
+func (f *multipartForm) encode() []byte {
+	var buf bytes.Buffer
+	delim := "--" + f.boundary
+	buf.Write(f.preamble)
+	for _, p := range f.parts {
+		buf.WriteString(delim + f.newline)
+		for _, h := range p.headers {
+			buf.WriteString(h + f.newline)
+		}
+		buf.WriteString(f.newline)
+		buf.Write(p.content)
+		buf.WriteString(f.newline)
+	}
+	buf.WriteString(delim + "--")
+	buf.Write(f.epilogue)
+	return buf.Bytes()
+}
+
+// set 以 update 修改第一个同名部分并移除其余同名部分，不存在时追加新部分
+func (f *multipartForm) set(name string, update func(p *formPart)) {
+	parts := f.parts[:0]
+	found := false
+	for _, p := range f.parts {
+		if p.name != name {
+			parts = append(parts, p)
+			continue
+		}
+		if !found {
+			update(p)
+			parts = append(parts, p)
+			found = true
+		}
+	}
+	f.parts = parts
+	if !found {
+		p := &formPart{name: name}
+		update(p)
+		f.parts = append(f.parts, p)
+	}
+}
+
+// quoteParam 转义 Content-Disposition 参数值中的引号与反斜杠
+func quoteParam(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// SetMultipartField 设置 multipart 表单的普通字段，替换第一个同名部分的内容并移除其余同名部分，不存在时追加。
+// 同名的文件部分被替换为普通字段；其他部分与分隔符保持原样
+func SetMultipartField(body []byte, boundary, name, value string) ([]byte, error) {
+	form, err := parseMultipart(body, boundary)
+	if err != nil {
+		return body, err
+	}
+	form.set(name, func(p *formPart) {
+		if p.file || len(p.headers) == 0 {
+			p.headers = []string{fmt.Sprintf(`Content-Disposition: form-data; name="%s"`, quoteParam(name))}
+			p.file, p.filename = false, ""
+		}
+		p.content = []byte(value)
+	})
+	return form.encode(), nil
+}
+
+// RemoveMultipartField 移除 multipart 表单中所有同名的部分，包括文件部分
+func RemoveMultipartField(body []byte, boundary, name string) ([]byte, error) {
+	form, err := parseMultipart(body, boundary)
+	if err != nil {
+		return body, err
+	}
+	parts := form.parts[:0]
+	for _, p := range form.parts {
+		if p.name != name {
+			parts = append(parts, p)
+		}
+	}
+	form.parts = parts
+	return form.encode(), nil
+}
+
+// SetMultipartFile 替换 multipart 表单中同名文件部分的内容，不存在时追加。
+// filename 与 contentType 为空时保留原值，新增的文件部分默认使用字段名与 application/octet-stream
+func SetMultipartFile(body []byte, boundary, name, filename, contentType string, content []byte) ([]byte, error) {
+	form, err := parseMultipart(body, boundary)
+	if err != nil {
+		return body, err
+	}
+	form.set(name, func(p *formPart) {
+		if filename == "" {
+			filename = p.filename
+		}
+		if filename == "" {
+			filename = name
+		}
+		if contentType == "" {
+			contentType = p.header("Content-Type")
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		if !p.file || filename != p.filename {
+			p.setHeader("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteParam(name), quoteParam(filename)))
+		}
+		p.setHeader("Content-Type", contentType)
+		p.file, p.filename = true, filename
+		p.content = content
+	})
+	return form.encode(), nil
+}
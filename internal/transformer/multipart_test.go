@@ -0,0 +1,193 @@
+package transformer_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"cdpnetool/internal/transformer"
+)
+
+// multipartBody 构造包含普通字段与文件的 multipart 表单
+func multipartBody(t *testing.T) ([]byte, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	_ = w.WriteField("title", "hello")
+	_ = w.WriteField("tag", "a")
+	_ = w.WriteField("tag", "b")
+	fw, err := w.CreateFormFile("avatar", "me.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write([]byte{0x89, 'P', 'N', 'G', 0x00, '\r', '\n'})
+	_ = w.Close()
+	return buf.Bytes(), w.Boundary()
+}
+
+// readForm 以标准库解析 multipart 表单，返回字段值与文件
+func readForm(t *testing.T, body []byte, boundary string) (map[string][]string, map[string]*multipart.Part, map[string][]byte) {
+	t.Helper()
+	values := map[string][]string{}
+	files := map[string]*multipart.Part{}
+	contents := map[string][]byte{}
+	r := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart error: %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		if part.FileName() != "" {
+			files[part.FormName()] = part
+			contents[part.FormName()] = data
+		} else {
+			values[part.FormName()] = append(values[part.FormName()], string(data))
+		}
+	}
+	return values, files, contents
+}
+
+func TestMultipartBoundary(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"multipart/form-data; boundary=abc", "abc"},
+		{`Multipart/Form-Data; boundary="a b"`, "a b"},
+		{"multipart/mixed; boundary=abc", ""},
+		{"application/x-www-form-urlencoded", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := transformer.MultipartBoundary(tt.contentType); got != tt.want {
+			t.Errorf("MultipartBoundary(%q) = %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestSetMultipartField(t *testing.T) {
+	body, boundary := multipartBody(t)
+
+	got, err := transformer.SetMultipartField(body, boundary, "tag", "c")
+	if err != nil {
+		t.Fatalf("SetMultipartField error: %v", err)
+	}
+	got, err = transformer.SetMultipartField(got, boundary, "extra", "x")
+	if err != nil {
+		t.Fatalf("SetMultipartField error: %v", err)
+	}
+	values, files, contents := readForm(t, got, boundary)
+	if v := values["tag"]; len(v) != 1 || v[0] != "c" {
+		t.Errorf("got tag %v, want [c]", v)
+	}
+	if v := values["extra"]; len(v) != 1 || v[0] != "x" {
+		t.Errorf("got extra %v, want [x]", v)
+	}
+	if v := values["title"]; len(v) != 1 || v[0] != "hello" {
+		t.Errorf("got title %v, want [hello]", v)
+	}
+	// 文件部分原样保留
+	if files["avatar"] == nil || !bytes.Equal(contents["avatar"], []byte{0x89, 'P', 'N', 'G', 0x00, '\r', '\n'}) {
+		t.Errorf("file part changed: %q", contents["avatar"])
+	}
+	if !bytes.HasSuffix(bytes.TrimSpace(got), []byte("--"+boundary+"--")) {
+		t.Errorf("closing boundary not preserved: %q", got)
+	}
+}
+
+func TestRemoveMultipartField(t *testing.T) {
+	body, boundary := multipartBody(t)
+	got, err := transformer.RemoveMultipartField(body, boundary, "tag")
+	if err != nil {
+		t.Fatalf("RemoveMultipartField error: %v", err)
+	}
+	got, err = transformer.RemoveMultipartField(got, boundary, "avatar")
+	if err != nil {
+		t.Fatalf("RemoveMultipartField error: %v", err)
+	}
+	values, files, _ := readForm(t, got, boundary)
+	if _, ok := values["tag"]; ok {
+		t.Errorf("tag not removed: %v", values)
+	}
+	if len(files) != 0 {
+		t.Errorf("file not removed: %v", files)
+	}
+	if v := values["title"]; len(v) != 1 || v[0] != "hello" {
+		t.Errorf("got title %v, want [hello]", v)
+	}
+}
+
+func TestSetMultipartFile(t *testing.T) {
+	body, boundary := multipartBody(t)
+
+	// 保留原文件名与 Content-Type
+	got, err := transformer.SetMultipartFile(body, boundary, "avatar", "", "", []byte("new"))
+	if err != nil {
+		t.Fatalf("SetMultipartFile error: %v", err)
+	}
+	_, files, contents := readForm(t, got, boundary)
+	if p := files["avatar"]; p == nil || p.FileName() != "me.png" || p.Header.Get("Content-Type") != "application/octet-stream" || string(contents["avatar"]) != "new" {
+		t.Errorf("got file %v with content %q", p, contents["avatar"])
+	}
+
+	// 替换文件名与 Content-Type，新增不存在的文件字段
+	got, err = transformer.SetMultipartFile(got, boundary, "avatar", `a"b.txt`, "text/plain", []byte("text"))
+	if err != nil {
+		t.Fatalf("SetMultipartFile error: %v", err)
+	}
+	got, err = transformer.SetMultipartFile(got, boundary, "doc", "", "", []byte("doc"))
+	if err != nil {
+		t.Fatalf("SetMultipartFile error: %v", err)
+	}
+	values, files, contents := readForm(t, got, boundary)
+	if p := files["avatar"]; p == nil || p.FileName() != `a"b.txt` || p.Header.Get("Content-Type") != "text/plain" || string(contents["avatar"]) != "text" {
+		t.Errorf("got file %v with content %q", p, contents["avatar"])
+	}
+	if p := files["doc"]; p == nil || p.FileName() != "doc" || string(contents["doc"]) != "doc" {
+		t.Errorf("got added file %v with content %q", p, contents["doc"])
+	}
+	if len(values["tag"]) != 2 {
+		t.Errorf("got tag %v, want both values kept", values["tag"])
+	}
+}
+
+func TestMultipart_LFAndPreamble(t *testing.T) {
+	body := "preamble\n--xyz\nContent-Disposition: form-data; name=\"a\"\n\n1\n--xyz--\nepilogue"
+	got, err := transformer.SetMultipartField([]byte(body), "xyz", "a", "2")
+	if err != nil {
+		t.Fatalf("SetMultipartField error: %v", err)
+	}
+	if want := strings.Replace(body, "\n\n1\n", "\n\n2\n", 1); string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMultipart_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		boundary string
+	}{
+		{"分隔符为空", "--a\r\n\r\nx\r\n--a--", ""},
+		{"找不到分隔符", "name=value", "abc"},
+		{"缺少结束分隔符", "--abc\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\nx", "abc"},
+		{"缺少头部结束", "--abc\r\nContent-Disposition: form-data; name=\"a\"\r\n--abc--", "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := transformer.SetMultipartField([]byte(tt.body), tt.boundary, "a", "b")
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if string(got) != tt.body {
+				t.Errorf("got body %q on error, want original", got)
+			}
+		})
+	}
+}
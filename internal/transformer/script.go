@@ -0,0 +1,205 @@
+package transformer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// scriptTimeout 单次脚本执行的最长时间，避免死循环的脚本阻塞请求处理
+const scriptTimeout = time.Second
+
+// scriptCache 已编译的脚本：脚本文本 -> *goja.Program
+var scriptCache sync.Map
+
+// ScriptRequest 脚本中以 request 对象读取的请求
+type ScriptRequest struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Query   map[string]string
+	Cookies map[string]string
+	Body    string
+}
+
+// ScriptResponse 脚本中以 response 对象读取的响应，仅响应阶段提供
+type ScriptResponse struct {
+	Status  int
+	Headers map[string]string
+	Body    string
+}
+
+// ScriptResult 脚本返回的修改，为 nil 的字段不修改；映射中值为 nil 的项表示移除
+type ScriptResult struct {
+	URL     *string
+	Method  *string
+	Status  *int
+	Headers map[string]*string
+	Query   map[string]*string
+	Cookies map[string]*string
+	Body    *string
+}
+
+// CompileScript 编译 script 行为的 JavaScript 脚本，结果按脚本文本缓存
+func CompileScript(src string) (*goja.Program, error) {
+	if val, ok := scriptCache.Load(src); ok {
+		return val.(*goja.Program), nil
+	}
+	prog, err := goja.Compile("script", src, true)
+	if err != nil {
+		return nil, fmt.Errorf("compile script: %w", err)
+	}
+	scriptCache.Store(src, prog)
+	return prog, nil
+}
+
+// RunScript 执行脚本并解析其返回的修改。脚本可读取 request 与响应阶段的 response 对象，
+// 使用 util 提供的摘要与编码函数，最后一个表达式的值为修改对象，为 undefined 或 null 时不修改。
+// 脚本无法访问文件、网络与环境变量，超时或出错时返回错误
+func RunScript(src string, req ScriptRequest, res *ScriptResponse) (ScriptResult, error) {
+	prog, err := CompileScript(src)
+	if err != nil {
+		return ScriptResult{}, err
+	}
+
+	vm := goja.New()
+	_ = vm.Set("request", map[string]any{
+		"method":  req.Method,
+		"url":     req.URL,
+		"headers": stringMap(req.Headers),
+		"query":   stringMap(req.Query),
+		"cookies": stringMap(req.Cookies),
+		"body":    req.Body,
+	})
+	if res != nil {
+		_ = vm.Set("response", map[string]any{
+			"status":  res.Status,
+			"headers": stringMap(res.Headers),
+			"body":    res.Body,
+		})
+	}
+	_ = vm.Set("util", scriptUtil())
+
+	timer := time.AfterFunc(scriptTimeout, func() { vm.Interrupt("script timed out") })
+	defer timer.Stop()
+	v, err := vm.RunProgram(prog)
+	if err != nil {
+		return ScriptResult{}, fmt.Errorf("run script: %w", err)
+	}
+	return parseScriptResult(vm, v)
+}
+
+// scriptUtil 脚本中 util 对象提供的函数
+func scriptUtil() map[string]any {
+	return map[string]any{
+		"sha256": func(data string) string {
+			sum := sha256.Sum256([]byte(data))
+			return hex.EncodeToString(sum[:])
+		},
+		"hmacSHA256": func(key, data string) string {
+			mac := hmac.New(sha256.New, []byte(key))
+			mac.Write([]byte(data))
+			return hex.EncodeToString(mac.Sum(nil))
+		},
+		"base64Encode": func(data string) string {
+			return base64.StdEncoding.EncodeToString([]byte(data))
+		},
+		"base64Decode": func(data string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(data)
+			return string(decoded), err
+		},
+	}
+}
+
+// stringMap 复制映射，避免脚本修改原消息
+func stringMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// parseScriptResult 解析脚本返回的修改对象
+func parseScriptResult(vm *goja.Runtime, v goja.Value) (ScriptResult, error) {
+	var result ScriptResult
+	if goja.IsUndefined(v) || goja.IsNull(v) {
+		return result, nil
+	}
+	obj, ok := v.(*goja.Object)
+	if !ok || obj.ClassName() != "Object" {
+		return result, errors.New("script must evaluate to an object of changes")
+	}
+
+	str := func(key string) *string {
+		if f := obj.Get(key); f != nil && !goja.IsUndefined(f) && !goja.IsNull(f) {
+			s := f.String()
+			return &s
+		}
+		return nil
+	}
+	result.URL = str("url")
+	result.Method = str("method")
+	if s := str("status"); s != nil {
+		code, err := strconv.Atoi(*s)
+		if err != nil || code < 100 || code > 599 {
+			return ScriptResult{}, fmt.Errorf("script returned invalid status %q", *s)
+		}
+		result.Status = &code
+	}
+	if f := obj.Get("body"); f != nil && !goja.IsUndefined(f) && !goja.IsNull(f) {
+		body := f.String()
+		if _, isObj := f.(*goja.Object); isObj {
+			// 对象与数组按 JSON 序列化
+			data, err := json.Marshal(f.Export())
+			if err != nil {
+				return ScriptResult{}, fmt.Errorf("script returned invalid body: %w", err)
+			}
+			body = string(data)
+		}
+		result.Body = &body
+	}
+
+	var err error
+	if result.Headers, err = changeMap(obj.Get("headers"), "headers"); err != nil {
+		return ScriptResult{}, err
+	}
+	if result.Query, err = changeMap(obj.Get("query"), "query"); err != nil {
+		return ScriptResult{}, err
+	}
+	if result.Cookies, err = changeMap(obj.Get("cookies"), "cookies"); err != nil {
+		return ScriptResult{}, err
+	}
+	return result, nil
+}
+
+// changeMap 解析名称到新值的映射，值为 null 或 undefined 表示移除
+func changeMap(v goja.Value, field string) (map[string]*string, error) {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil, nil
+	}
+	obj, ok := v.(*goja.Object)
+	if !ok {
+		return nil, fmt.Errorf("script returned invalid %s: want an object", field)
+	}
+	out := make(map[string]*string)
+	for _, k := range obj.Keys() {
+		val := obj.Get(k)
+		if goja.IsUndefined(val) || goja.IsNull(val) {
+			out[k] = nil
+			continue
+		}
+		s := val.String()
+		out[k] = &s
+	}
+	return out, nil
+}
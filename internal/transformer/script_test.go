@@ -0,0 +1,124 @@
+package transformer_test
+
+import (
+	"strings"
+	"testing"
+
+	"cdpnetool/internal/transformer"
+)
+
+func TestRunScript_Request(t *testing.T) {
+	req := transformer.ScriptRequest{
+		Method:  "POST",
+		URL:     "https://example.com/api?a=1",
+		Headers: map[string]string{"X-Old": "1", "Content-Type": "application/json"},
+		Query:   map[string]string{"a": "1"},
+		Cookies: map[string]string{"sid": "abc"},
+		Body:    `{"n":1}`,
+	}
+	src := `
+		const body = JSON.parse(request.body);
+		body.n += request.query.a * 1;
+		({
+			method: "PUT",
+			headers: {"X-Sign": util.hmacSHA256("key", request.body), "X-Old": null},
+			query: {b: 2},
+			cookies: {sid: null},
+			body: body,
+		})`
+	got, err := transformer.RunScript(src, req, nil)
+	if err != nil {
+		t.Fatalf("RunScript error: %v", err)
+	}
+	if got.URL != nil || got.Status != nil {
+		t.Errorf("got url %v status %v, want unchanged", got.URL, got.Status)
+	}
+	if got.Method == nil || *got.Method != "PUT" {
+		t.Errorf("got method %v, want PUT", got.Method)
+	}
+	// HMAC-SHA256("key", `{"n":1}`)
+	if v := got.Headers["X-Sign"]; v == nil || len(*v) != 64 {
+		t.Errorf("got X-Sign %v, want hex digest", v)
+	}
+	if v, ok := got.Headers["X-Old"]; !ok || v != nil {
+		t.Errorf("got X-Old %v, want removal", v)
+	}
+	if v := got.Query["b"]; v == nil || *v != "2" {
+		t.Errorf("got query b %v, want 2", v)
+	}
+	if v, ok := got.Cookies["sid"]; !ok || v != nil {
+		t.Errorf("got cookie sid %v, want removal", v)
+	}
+	if got.Body == nil || *got.Body != `{"n":2}` {
+		t.Errorf("got body %v, want JSON object", got.Body)
+	}
+	// 脚本修改的是副本
+	if req.Headers["X-Old"] != "1" {
+		t.Errorf("script mutated request headers: %v", req.Headers)
+	}
+}
+
+func TestRunScript_Response(t *testing.T) {
+	req := transformer.ScriptRequest{Method: "GET", URL: "https://example.com/time"}
+	res := &transformer.ScriptResponse{Status: 200, Headers: map[string]string{}, Body: "hello"}
+	got, err := transformer.RunScript(`({status: response.status + 1, body: util.base64Encode(response.body) + ":" + util.sha256("")})`, req, res)
+	if err != nil {
+		t.Fatalf("RunScript error: %v", err)
+	}
+	if got.Status == nil || *got.Status != 201 {
+		t.Errorf("got status %v, want 201", got.Status)
+	}
+	if want := "aGVsbG8=:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"; got.Body == nil || *got.Body != want {
+		t.Errorf("got body %v, want %s", got.Body, want)
+	}
+
+	// 请求阶段没有 response 对象
+	if _, err := transformer.RunScript(`({body: response.body})`, req, nil); err == nil {
+		t.Error("got nil error reading response in request stage")
+	}
+}
+
+func TestRunScript_NoChange(t *testing.T) {
+	for _, src := range []string{"", "undefined", "null", "let x = 1;"} {
+		got, err := transformer.RunScript(src, transformer.ScriptRequest{}, nil)
+		if err != nil {
+			t.Fatalf("RunScript(%q) error: %v", src, err)
+		}
+		if got.URL != nil || got.Method != nil || got.Body != nil || got.Headers != nil {
+			t.Errorf("RunScript(%q) = %+v, want no changes", src, got)
+		}
+	}
+}
+
+func TestRunScript_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"语法错误", `({`, "compile script"},
+		{"运行时错误", `nosuchfn()`, "run script"},
+		{"返回非对象", `"text"`, "object of changes"},
+		{"返回数组", `[1]`, "object of changes"},
+		{"无效状态码", `({status: 42})`, "invalid status"},
+		{"无效头部", `({headers: "x"})`, "invalid headers"},
+		{"死循环超时", `for (;;) {}`, "timed out"},
+		{"Base64 解码失败", `util.base64Decode("!")`, "run script"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := transformer.RunScript(tt.src, transformer.ScriptRequest{}, &transformer.ScriptResponse{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunScript_NoHostAccess(t *testing.T) {
+	for _, src := range []string{`require("fs")`, `process.env`, `fetch("http://example.com")`} {
+		if _, err := transformer.RunScript(src, transformer.ScriptRequest{}, nil); err == nil {
+			t.Errorf("RunScript(%q) succeeded, want no host access", src)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package transformer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// BodyKind 按内容嗅探得到的消息体类型
+type BodyKind string
+
+const (
+	BodyBinary BodyKind = "binary" // 二进制内容
+	BodyText   BodyKind = "text"   // UTF-8 文本
+	BodyJSON   BodyKind = "json"   // 合法的 JSON 对象或数组
+)
+
+// sniffLen 检查控制字符的最大字节数
+const sniffLen = 1024
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// IsGenericContentType 判断 Content-Type 是否缺失或为 application/octet-stream 等无法说明实际内容的通用类型
+func IsGenericContentType(contentType string) bool {
+	mt, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mt)) {
+	case "", "application/octet-stream", "binary/octet-stream", "application/unknown", "unknown/unknown", "application/x-unknown-content-type":
+		return true
+	default:
+		return false
+	}
+}
+
+// SniffBody 按内容判断消息体类型：合法的 JSON 对象或数组为 json，不含控制字符的 UTF-8 文本为 text，其余为 binary
+func SniffBody(body []byte) BodyKind {
+	body = bytes.TrimPrefix(body, utf8BOM)
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return BodyJSON
+	}
+	if !utf8.Valid(body) {
+		return BodyBinary
+	}
+	for _, c := range body[:min(len(body), sniffLen)] {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' || c == 0x7f {
+			return BodyBinary
+		}
+	}
+	return BodyText
+}
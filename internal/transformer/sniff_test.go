@@ -0,0 +1,51 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"cdpnetool/internal/transformer"
+)
+
+func TestIsGenericContentType(t *testing.T) {
+	tests := []struct {
+		ct   string
+		want bool
+	}{
+		{"", true},
+		{"application/octet-stream", true},
+		{"Binary/Octet-Stream; charset=binary", true},
+		{"application/json", false},
+		{"text/plain", false},
+		{"image/png", false},
+	}
+	for _, tt := range tests {
+		if got := transformer.IsGenericContentType(tt.ct); got != tt.want {
+			t.Errorf("IsGenericContentType(%q) = %v, want %v", tt.ct, got, tt.want)
+		}
+	}
+}
+
+func TestSniffBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want transformer.BodyKind
+	}{
+		{"JSON 对象", ` {"a":1}` + "\n", transformer.BodyJSON},
+		{"JSON 数组带 BOM", "\xef\xbb\xbf[1,2]", transformer.BodyJSON},
+		{"不完整的 JSON", `{"a":`, transformer.BodyText},
+		{"JSON 标量按文本处理", `"str"`, transformer.BodyText},
+		{"文本", "hello\tworld\r\n", transformer.BodyText},
+		{"空消息体", "", transformer.BodyText},
+		{"PNG", "\x89PNG\r\n\x1a\n\x00\x00", transformer.BodyBinary},
+		{"控制字符", "abc\x00def", transformer.BodyBinary},
+		{"非法 UTF-8", "caf\xe9", transformer.BodyBinary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transformer.SniffBody([]byte(tt.body)); got != tt.want {
+				t.Errorf("SniffBody(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
@@ -31,6 +31,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.Startup,
+		OnDomReady:       app.DomReady,
 		OnShutdown:       app.Shutdown,
 		OnBeforeClose:    app.BeforeClose,
 		Bind: []any{
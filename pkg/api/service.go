@@ -10,6 +10,9 @@ import (
 )
 
 // Service 服务接口
+//
+// 所有方法的 ctx 仅约束本次调用（包括其中的 CDP 调用），取消或超时会使调用尽快返回 ctx.Err()；
+// 已启动的会话与已附着的目标不受调用方 ctx 取消的影响，需通过 StopSession/DetachTarget 结束。
 type Service interface {
 	// StartSession 启动会话
 	StartSession(ctx context.Context, cfg domain.SessionConfig) (domain.SessionID, error)
@@ -32,20 +35,158 @@ type Service interface {
 	// DisableInterception 禁用拦截
 	DisableInterception(ctx context.Context, id domain.SessionID) error
 
+	// SetProxyAuth 设置上游代理认证凭据，通过 Fetch.authRequired 应答代理质询，creds 为 nil 时清除
+	SetProxyAuth(ctx context.Context, id domain.SessionID, creds *domain.ProxyCredentials) error
+
+	// SetGeolocation 设置地理位置覆盖，target 为空时作用于整个会话，loc 为 nil 时清除覆盖
+	SetGeolocation(ctx context.Context, id domain.SessionID, target domain.TargetID, loc *domain.GeoLocation) error
+
+	// SetNetworkConditions 设置网络条件模拟（断网、延迟、吞吐），target 为空时作用于整个会话，c 为 nil 时清除模拟
+	SetNetworkConditions(ctx context.Context, id domain.SessionID, target domain.TargetID, c *domain.NetworkConditions) error
+
+	// SetCacheDisabled 禁用或恢复会话内所有目标的浏览器 HTTP 缓存
+	SetCacheDisabled(ctx context.Context, id domain.SessionID, disabled bool) error
+
+	// UpdateRuntimeSettings 在会话运行期间应用可热更新的设置，无需重启会话
+	UpdateRuntimeSettings(ctx context.Context, id domain.SessionID, settings domain.RuntimeSettings) error
+
+	// SetDryRun 开启或关闭演练模式：规则照常评估并记录本会产生的修改，但所有流量原样放行
+	SetDryRun(ctx context.Context, id domain.SessionID, enabled bool) error
+
+	// SetReplayMode 设置录制回放缓存：录制真实响应，之后以录制的响应应答相同的请求，可离线回放
+	SetReplayMode(ctx context.Context, id domain.SessionID, opts domain.ReplayCacheOptions) (domain.ReplayCacheStatus, error)
+
+	// GetReplayStatus 获取录制回放缓存的配置、记录数与命中统计
+	GetReplayStatus(ctx context.Context, id domain.SessionID) (domain.ReplayCacheStatus, error)
+
+	// SetTimezone 设置目标的时区覆盖（IANA 时区 ID），为空时清除覆盖
+	SetTimezone(ctx context.Context, id domain.SessionID, target domain.TargetID, timezoneID string) error
+
+	// SetLocale 设置目标的区域覆盖（BCP 47 语言标签），为空时清除覆盖
+	SetLocale(ctx context.Context, id domain.SessionID, target domain.TargetID, locale string) error
+
+	// ListCookies 获取目标可见的浏览器 Cookie，urls 为空时返回当前页面可见的 Cookie
+	ListCookies(ctx context.Context, id domain.SessionID, target domain.TargetID, urls []string) ([]domain.BrowserCookie, error)
+
+	// SetCookie 将 Cookie 写入目标所在浏览器上下文的 Cookie 存储
+	SetCookie(ctx context.Context, id domain.SessionID, target domain.TargetID, cookie domain.BrowserCookie) error
+
+	// DeleteCookies 删除目标所在浏览器上下文中匹配条件的 Cookie
+	DeleteCookies(ctx context.Context, id domain.SessionID, target domain.TargetID, filter domain.CookieFilter) error
+
 	// LoadRules 加载规则配置
 	LoadRules(ctx context.Context, id domain.SessionID, cfg *rulespec.Config) error
 
+	// WatchRulesFile 从文件加载规则配置并在文件变化时自动重新加载，path 为空时停止监听
+	WatchRulesFile(ctx context.Context, id domain.SessionID, path string) error
+
+	// SetRuleSchedule 设置规则集定时切换计划，nil 表示取消
+	SetRuleSchedule(ctx context.Context, id domain.SessionID, schedule *rulespec.Schedule) error
+
+	// GetRuleSchedule 获取规则集定时切换计划的状态与切换记录
+	GetRuleSchedule(ctx context.Context, id domain.SessionID) (domain.ScheduleStatus, error)
+
 	// GetRuleStats 获取规则统计信息
 	GetRuleStats(ctx context.Context, id domain.SessionID) (domain.EngineStats, error)
 
-	// SubscribeEvents 订阅事件
-	SubscribeEvents(ctx context.Context, id domain.SessionID) (<-chan domain.NetworkEvent, error)
+	// GetRuleCoverage 获取规则覆盖报告（从未命中、命中但无实际修改、总是被降级的规则），会话结束后仍可查询
+	GetRuleCoverage(ctx context.Context, id domain.SessionID) (domain.CoverageReport, error)
+
+	// CheckAssertions 按规则匹配次数校验断言，会话结束后仍可校验
+	CheckAssertions(ctx context.Context, id domain.SessionID, assertions []domain.RuleAssertion) (domain.AssertionReport, error)
+
+	// GetReconnectStats 获取目标连接意外断开后的重连统计（断开次数、重连次数、补发放行与遗留的暂停请求数）
+	GetReconnectStats(ctx context.Context, id domain.SessionID) (domain.ReconnectStats, error)
+
+	// SubscribeConnection 订阅目标连接事件（意外断开、重新附着成功、放弃重连）
+	SubscribeConnection(ctx context.Context, id domain.SessionID) (<-chan domain.ConnectionEvent, error)
+
+	// GetTrafficStats 获取按域名与资源类型的流量统计（请求数、拦截数、上下行字节数）
+	GetTrafficStats(ctx context.Context, id domain.SessionID) (domain.TrafficStats, error)
+
+	// GenerateReport 生成 HTML 或 Markdown 格式的会话汇总报告，会话结束后仍可生成
+	GenerateReport(ctx context.Context, id domain.SessionID, format domain.ReportFormat) ([]byte, error)
+
+	// RunBenchmark 以合成事件驱动规则引擎与处理器，报告指定配置下的吞吐与延迟
+	RunBenchmark(ctx context.Context, cfg *rulespec.Config, opts domain.BenchmarkOptions) (domain.BenchmarkResult, error)
+
+	// BenchmarkRules 逐条评估配置中已启用的规则，报告每条规则的匹配耗时，用于定位慢规则
+	BenchmarkRules(ctx context.Context, cfg *rulespec.Config, opts domain.RuleBenchmarkOptions) (domain.RuleBenchmarkResult, error)
+
+	// TestRules 以候选规则配置离线重放捕获的事件，报告每个事件会命中的规则、产生的修改及与捕获时的差异
+	TestRules(ctx context.Context, cfg *rulespec.Config, opts domain.RuleTestOptions) (domain.RuleTestResult, error)
+
+	// SubscribeEvents 订阅序号大于 after 的事件，先回放会话缓冲中的最近事件，after 为 0 时从缓冲起点开始
+	SubscribeEvents(ctx context.Context, id domain.SessionID, after uint64) (<-chan domain.NetworkEvent, error)
+
+	// GetEvent 获取会话事件缓冲中的单个事件
+	GetEvent(ctx context.Context, id domain.SessionID, eventID string) (domain.NetworkEvent, error)
+
+	// SubscribeEventStream 以确认式迭代器订阅事件，提供至少一次投递语义；ctx 取消或调用 Close 后释放事件流
+	SubscribeEventStream(ctx context.Context, id domain.SessionID, opts domain.EventStreamOptions) (domain.EventIterator, error)
 
 	// SubscribeTraffic 订阅全量流量流
 	SubscribeTraffic(ctx context.Context, id domain.SessionID) (<-chan domain.NetworkEvent, error)
 
 	// EnableTrafficCapture 启用/禁用流量捕获
 	EnableTrafficCapture(ctx context.Context, id domain.SessionID, enabled bool) error
+
+	// StartHARStream 开始将全量流量持续写入 HAR 文件，导出期间流量捕获保持开启
+	StartHARStream(ctx context.Context, id domain.SessionID, opts domain.HARStreamOptions) error
+
+	// StopHARStream 停止持续 HAR 导出并返回最终状态
+	StopHARStream(ctx context.Context, id domain.SessionID) (domain.HARStreamStatus, error)
+
+	// GetHARStreamStatus 获取持续 HAR 导出的状态
+	GetHARStreamStatus(ctx context.Context, id domain.SessionID) (domain.HARStreamStatus, error)
+
+	// StartContractCheck 加载 OpenAPI 规范并开启契约检查，违规记录到事件历史
+	StartContractCheck(ctx context.Context, id domain.SessionID, specPath string) (domain.ContractStatus, error)
+
+	// StopContractCheck 关闭契约检查
+	StopContractCheck(ctx context.Context, id domain.SessionID) error
+
+	// GetContractStatus 获取契约检查状态
+	GetContractStatus(ctx context.Context, id domain.SessionID) (domain.ContractStatus, error)
+
+	// SetSecretDetectors 设置启用的敏感信息检测器，为空时关闭检测
+	SetSecretDetectors(ctx context.Context, id domain.SessionID, detectors []string) error
+
+	// GetSecretDetectors 获取启用的敏感信息检测器
+	GetSecretDetectors(ctx context.Context, id domain.SessionID) ([]string, error)
+
+	// ArmBreakpoint 布置一次性断点，下一个匹配的请求暂停等待人工处理后断点自动解除
+	ArmBreakpoint(ctx context.Context, id domain.SessionID, filter domain.BreakpointFilter) error
+
+	// DisarmBreakpoint 解除尚未命中的断点
+	DisarmBreakpoint(ctx context.Context, id domain.SessionID) error
+
+	// GetBreakpointStatus 获取断点状态及等待处理的请求
+	GetBreakpointStatus(ctx context.Context, id domain.SessionID) (domain.BreakpointStatus, error)
+
+	// ResolveHeldRequest 放行或拒绝被断点暂停的请求
+	ResolveHeldRequest(ctx context.Context, id domain.SessionID, requestID string, approve bool) error
+
+	// ApproveHeldRequest 以编辑后的请求（URL、方法、头部、Cookie、请求体）放行在请求阶段暂停的请求
+	ApproveHeldRequest(ctx context.Context, id domain.SessionID, requestID string, edit *domain.Request) error
+
+	// ApproveHeldResponse 以编辑后的响应（状态码、头部、响应体）放行在响应阶段暂停的请求
+	ApproveHeldResponse(ctx context.Context, id domain.SessionID, requestID string, edit *domain.Response) error
+
+	// Replay 以页面 fetch 或本地 HTTP 客户端重新发出事件中的请求（可先修改），响应记录为新的事件并返回
+	Replay(ctx context.Context, id domain.SessionID, opts domain.ReplayOptions) (domain.NetworkEvent, error)
+
+	// SubscribeBreakpoint 订阅断点状态，先推送当前状态再推送每次变化，用于界面重新连接后恢复待处理队列
+	SubscribeBreakpoint(ctx context.Context, id domain.SessionID) (<-chan domain.BreakpointStatus, error)
+
+	// ListAuthChallenges 获取 approve 方式的 auth 规则暂停、等待人工处理的认证质询
+	ListAuthChallenges(ctx context.Context, id domain.SessionID) ([]domain.AuthChallenge, error)
+
+	// ResolveAuthChallenge 以凭据应答、取消或交由浏览器处理等待中的认证质询
+	ResolveAuthChallenge(ctx context.Context, id domain.SessionID, challengeID string, decision domain.AuthDecision) error
+
+	// SubscribeAuthChallenges 订阅等待中的认证质询，先推送当前列表再推送每次变化
+	SubscribeAuthChallenges(ctx context.Context, id domain.SessionID) (<-chan []domain.AuthChallenge, error)
 }
 
 // NewService 创建并返回服务接口实现
@@ -0,0 +1,116 @@
+package apigrpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TokenMetadataKey 客户端携带访问令牌的元数据键，值为 "Bearer <token>"
+const TokenMetadataKey = "authorization"
+
+// Scope 访问令牌的权限范围
+type Scope string
+
+const (
+	ScopeFull     Scope = "full"     // 完全控制
+	ScopeReadOnly Scope = "readOnly" // 只读：只能列出目标、查看统计与断点状态、订阅事件
+)
+
+// Key 访问令牌及其权限范围
+type Key struct {
+	Token string
+	Scope Scope
+}
+
+// readOnlyMethods 只读令牌可以调用的方法，其余方法会启动会话、修改规则或放行请求，需要完全控制权限
+var readOnlyMethods = map[string]bool{
+	Control_ListTargets_FullMethodName:         true,
+	Control_GetRuleStats_FullMethodName:        true,
+	Control_SubscribeEvents_FullMethodName:     true,
+	Control_GetBreakpointStatus_FullMethodName: true,
+}
+
+// ServerOptions 返回以 keys 校验每次调用的拦截器选项，keys 为空时不校验
+func ServerOptions(keys ...Key) []grpc.ServerOption {
+	if len(keys) == 0 {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryAuthInterceptor(keys...)),
+		grpc.ChainStreamInterceptor(StreamAuthInterceptor(keys...)),
+	}
+}
+
+// UnaryAuthInterceptor 拒绝未携带有效访问令牌或令牌权限不足的一元调用
+func UnaryAuthInterceptor(keys ...Key) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, keys, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor 拒绝未携带有效访问令牌或令牌权限不足的流式调用
+func StreamAuthInterceptor(keys ...Key) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), keys, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// authorize 以恒定时间比较调用携带的访问令牌，并校验令牌的权限范围是否允许调用该方法
+func authorize(ctx context.Context, keys []Key, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var scope Scope
+	for _, v := range md.Get(TokenMetadataKey) {
+		got, ok := strings.CutPrefix(v, "Bearer ")
+		if !ok {
+			continue
+		}
+		for _, k := range keys {
+			if k.Token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(k.Token)) == 1 {
+				scope = k.Scope
+			}
+		}
+	}
+	switch {
+	case scope == "":
+		return status.Error(codes.Unauthenticated, "missing or invalid access token")
+	case scope != ScopeFull && !readOnlyMethods[method]:
+		return status.Errorf(codes.PermissionDenied, "access token is read-only and cannot call %s", method)
+	}
+	return nil
+}
+
+// CheckListenAddr 校验控制面的监听地址：控制面可以启动会话、读取全部流量并加载读写本地文件或执行脚本的规则，
+// 监听非回环地址时必须同时启用 TLS 与访问令牌
+func CheckListenAddr(addr string, tls, token bool) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid gRPC listen address %q: %w", addr, err)
+	}
+	if isLoopback(host) || (tls && token) {
+		return nil
+	}
+	return fmt.Errorf("gRPC listen address %q is not loopback; TLS and an access token are required", addr)
+}
+
+// isLoopback 判断主机是否为回环地址，空主机表示监听所有网卡
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,778 @@
+// cdpnetool gRPC 控制面：与 api.Service 对应的类型化接口，事件以服务端流推送。
+//
+// 重新生成：
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative cdpnetool.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: cdpnetool.proto
+
+package apigrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Control_StartSession_FullMethodName          = "/cdpnetool.v1.Control/StartSession"
+	Control_StopSession_FullMethodName           = "/cdpnetool.v1.Control/StopSession"
+	Control_ListTargets_FullMethodName           = "/cdpnetool.v1.Control/ListTargets"
+	Control_AttachTarget_FullMethodName          = "/cdpnetool.v1.Control/AttachTarget"
+	Control_DetachTarget_FullMethodName          = "/cdpnetool.v1.Control/DetachTarget"
+	Control_EnableInterception_FullMethodName    = "/cdpnetool.v1.Control/EnableInterception"
+	Control_DisableInterception_FullMethodName   = "/cdpnetool.v1.Control/DisableInterception"
+	Control_LoadRules_FullMethodName             = "/cdpnetool.v1.Control/LoadRules"
+	Control_GetRuleStats_FullMethodName          = "/cdpnetool.v1.Control/GetRuleStats"
+	Control_SubscribeEvents_FullMethodName       = "/cdpnetool.v1.Control/SubscribeEvents"
+	Control_ArmBreakpoint_FullMethodName         = "/cdpnetool.v1.Control/ArmBreakpoint"
+	Control_DisarmBreakpoint_FullMethodName      = "/cdpnetool.v1.Control/DisarmBreakpoint"
+	Control_GetBreakpointStatus_FullMethodName   = "/cdpnetool.v1.Control/GetBreakpointStatus"
+	Control_Approve_FullMethodName               = "/cdpnetool.v1.Control/Approve"
+	Control_Reject_FullMethodName                = "/cdpnetool.v1.Control/Reject"
+	Control_ApproveEditedRequest_FullMethodName  = "/cdpnetool.v1.Control/ApproveEditedRequest"
+	Control_ApproveEditedResponse_FullMethodName = "/cdpnetool.v1.Control/ApproveEditedResponse"
+)
+
+// ControlClient is the client API for Control service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Control 会话控制服务
+type ControlClient interface {
+	// StartSession 连接浏览器并启动会话
+	StartSession(ctx context.Context, in *StartSessionRequest, opts ...grpc.CallOption) (*StartSessionResponse, error)
+	// StopSession 停止会话
+	StopSession(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error)
+	// ListTargets 列出浏览器中的页面目标
+	ListTargets(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*ListTargetsResponse, error)
+	// AttachTarget 附着目标
+	AttachTarget(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Empty, error)
+	// DetachTarget 分离目标
+	DetachTarget(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Empty, error)
+	// EnableInterception 开启拦截
+	EnableInterception(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error)
+	// DisableInterception 关闭拦截
+	DisableInterception(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error)
+	// LoadRules 加载规则配置
+	LoadRules(ctx context.Context, in *LoadRulesRequest, opts ...grpc.CallOption) (*Empty, error)
+	// GetRuleStats 获取规则匹配统计
+	GetRuleStats(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*RuleStats, error)
+	// SubscribeEvents 订阅匹配事件，先回放会话缓冲中序号大于 after 的事件
+	SubscribeEvents(ctx context.Context, in *SubscribeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// ArmBreakpoint 布置一次性断点
+	ArmBreakpoint(ctx context.Context, in *ArmBreakpointRequest, opts ...grpc.CallOption) (*Empty, error)
+	// DisarmBreakpoint 解除尚未命中的断点
+	DisarmBreakpoint(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error)
+	// GetBreakpointStatus 获取断点状态及等待处理的请求
+	GetBreakpointStatus(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*BreakpointStatus, error)
+	// Approve 放行被断点暂停的请求
+	Approve(ctx context.Context, in *HeldRequestRef, opts ...grpc.CallOption) (*Empty, error)
+	// Reject 拒绝被断点暂停的请求
+	Reject(ctx context.Context, in *HeldRequestRef, opts ...grpc.CallOption) (*Empty, error)
+	// ApproveEditedRequest 以编辑后的请求放行在请求阶段暂停的请求
+	ApproveEditedRequest(ctx context.Context, in *EditedRequest, opts ...grpc.CallOption) (*Empty, error)
+	// ApproveEditedResponse 以编辑后的响应放行在响应阶段暂停的请求
+	ApproveEditedResponse(ctx context.Context, in *EditedResponse, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type controlClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewControlClient(cc grpc.ClientConnInterface) ControlClient {
+	return &controlClient{cc}
+}
+
+func (c *controlClient) StartSession(ctx context.Context, in *StartSessionRequest, opts ...grpc.CallOption) (*StartSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartSessionResponse)
+	err := c.cc.Invoke(ctx, Control_StartSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) StopSession(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_StopSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) ListTargets(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*ListTargetsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTargetsResponse)
+	err := c.cc.Invoke(ctx, Control_ListTargets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) AttachTarget(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_AttachTarget_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) DetachTarget(ctx context.Context, in *TargetRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_DetachTarget_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) EnableInterception(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_EnableInterception_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) DisableInterception(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_DisableInterception_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) LoadRules(ctx context.Context, in *LoadRulesRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_LoadRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) GetRuleStats(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*RuleStats, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RuleStats)
+	err := c.cc.Invoke(ctx, Control_GetRuleStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) SubscribeEvents(ctx context.Context, in *SubscribeEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Control_ServiceDesc.Streams[0], Control_SubscribeEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Control_SubscribeEventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *controlClient) ArmBreakpoint(ctx context.Context, in *ArmBreakpointRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_ArmBreakpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) DisarmBreakpoint(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_DisarmBreakpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) GetBreakpointStatus(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*BreakpointStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BreakpointStatus)
+	err := c.cc.Invoke(ctx, Control_GetBreakpointStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Approve(ctx context.Context, in *HeldRequestRef, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_Approve_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Reject(ctx context.Context, in *HeldRequestRef, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_Reject_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) ApproveEditedRequest(ctx context.Context, in *EditedRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_ApproveEditedRequest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) ApproveEditedResponse(ctx context.Context, in *EditedResponse, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Control_ApproveEditedResponse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ControlServer is the server API for Control service.
+// All implementations must embed UnimplementedControlServer
+// for forward compatibility.
+//
+// Control 会话控制服务
+type ControlServer interface {
+	// StartSession 连接浏览器并启动会话
+	StartSession(context.Context, *StartSessionRequest) (*StartSessionResponse, error)
+	// StopSession 停止会话
+	StopSession(context.Context, *SessionRequest) (*Empty, error)
+	// ListTargets 列出浏览器中的页面目标
+	ListTargets(context.Context, *SessionRequest) (*ListTargetsResponse, error)
+	// AttachTarget 附着目标
+	AttachTarget(context.Context, *TargetRequest) (*Empty, error)
+	// DetachTarget 分离目标
+	DetachTarget(context.Context, *TargetRequest) (*Empty, error)
+	// EnableInterception 开启拦截
+	EnableInterception(context.Context, *SessionRequest) (*Empty, error)
+	// DisableInterception 关闭拦截
+	DisableInterception(context.Context, *SessionRequest) (*Empty, error)
+	// LoadRules 加载规则配置
+	LoadRules(context.Context, *LoadRulesRequest) (*Empty, error)
+	// GetRuleStats 获取规则匹配统计
+	GetRuleStats(context.Context, *SessionRequest) (*RuleStats, error)
+	// SubscribeEvents 订阅匹配事件，先回放会话缓冲中序号大于 after 的事件
+	SubscribeEvents(*SubscribeEventsRequest, grpc.ServerStreamingServer[Event]) error
+	// ArmBreakpoint 布置一次性断点
+	ArmBreakpoint(context.Context, *ArmBreakpointRequest) (*Empty, error)
+	// DisarmBreakpoint 解除尚未命中的断点
+	DisarmBreakpoint(context.Context, *SessionRequest) (*Empty, error)
+	// GetBreakpointStatus 获取断点状态及等待处理的请求
+	GetBreakpointStatus(context.Context, *SessionRequest) (*BreakpointStatus, error)
+	// Approve 放行被断点暂停的请求
+	Approve(context.Context, *HeldRequestRef) (*Empty, error)
+	// Reject 拒绝被断点暂停的请求
+	Reject(context.Context, *HeldRequestRef) (*Empty, error)
+	// ApproveEditedRequest 以编辑后的请求放行在请求阶段暂停的请求
+	ApproveEditedRequest(context.Context, *EditedRequest) (*Empty, error)
+	// ApproveEditedResponse 以编辑后的响应放行在响应阶段暂停的请求
+	ApproveEditedResponse(context.Context, *EditedResponse) (*Empty, error)
+	mustEmbedUnimplementedControlServer()
+}
+
+// UnimplementedControlServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedControlServer struct{}
+
+func (UnimplementedControlServer) StartSession(context.Context, *StartSessionRequest) (*StartSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartSession not implemented")
+}
+func (UnimplementedControlServer) StopSession(context.Context, *SessionRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopSession not implemented")
+}
+func (UnimplementedControlServer) ListTargets(context.Context, *SessionRequest) (*ListTargetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTargets not implemented")
+}
+func (UnimplementedControlServer) AttachTarget(context.Context, *TargetRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AttachTarget not implemented")
+}
+func (UnimplementedControlServer) DetachTarget(context.Context, *TargetRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DetachTarget not implemented")
+}
+func (UnimplementedControlServer) EnableInterception(context.Context, *SessionRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableInterception not implemented")
+}
+func (UnimplementedControlServer) DisableInterception(context.Context, *SessionRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableInterception not implemented")
+}
+func (UnimplementedControlServer) LoadRules(context.Context, *LoadRulesRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadRules not implemented")
+}
+func (UnimplementedControlServer) GetRuleStats(context.Context, *SessionRequest) (*RuleStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRuleStats not implemented")
+}
+func (UnimplementedControlServer) SubscribeEvents(*SubscribeEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeEvents not implemented")
+}
+func (UnimplementedControlServer) ArmBreakpoint(context.Context, *ArmBreakpointRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ArmBreakpoint not implemented")
+}
+func (UnimplementedControlServer) DisarmBreakpoint(context.Context, *SessionRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisarmBreakpoint not implemented")
+}
+func (UnimplementedControlServer) GetBreakpointStatus(context.Context, *SessionRequest) (*BreakpointStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBreakpointStatus not implemented")
+}
+func (UnimplementedControlServer) Approve(context.Context, *HeldRequestRef) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Approve not implemented")
+}
+func (UnimplementedControlServer) Reject(context.Context, *HeldRequestRef) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reject not implemented")
+}
+func (UnimplementedControlServer) ApproveEditedRequest(context.Context, *EditedRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveEditedRequest not implemented")
+}
+func (UnimplementedControlServer) ApproveEditedResponse(context.Context, *EditedResponse) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveEditedResponse not implemented")
+}
+func (UnimplementedControlServer) mustEmbedUnimplementedControlServer() {}
+func (UnimplementedControlServer) testEmbeddedByValue()                 {}
+
+// UnsafeControlServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ControlServer will
+// result in compilation errors.
+type UnsafeControlServer interface {
+	mustEmbedUnimplementedControlServer()
+}
+
+func RegisterControlServer(s grpc.ServiceRegistrar, srv ControlServer) {
+	// If the following call pancis, it indicates UnimplementedControlServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Control_ServiceDesc, srv)
+}
+
+func _Control_StartSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).StartSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_StartSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).StartSession(ctx, req.(*StartSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_StopSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).StopSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_StopSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).StopSession(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_ListTargets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ListTargets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_ListTargets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ListTargets(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_AttachTarget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).AttachTarget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_AttachTarget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).AttachTarget(ctx, req.(*TargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_DetachTarget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).DetachTarget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_DetachTarget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).DetachTarget(ctx, req.(*TargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_EnableInterception_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).EnableInterception(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_EnableInterception_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).EnableInterception(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_DisableInterception_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).DisableInterception(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_DisableInterception_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).DisableInterception(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_LoadRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).LoadRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_LoadRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).LoadRules(ctx, req.(*LoadRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_GetRuleStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).GetRuleStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_GetRuleStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).GetRuleStats(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_SubscribeEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServer).SubscribeEvents(m, &grpc.GenericServerStream[SubscribeEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Control_SubscribeEventsServer = grpc.ServerStreamingServer[Event]
+
+func _Control_ArmBreakpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ArmBreakpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ArmBreakpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_ArmBreakpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ArmBreakpoint(ctx, req.(*ArmBreakpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_DisarmBreakpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).DisarmBreakpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_DisarmBreakpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).DisarmBreakpoint(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_GetBreakpointStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).GetBreakpointStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_GetBreakpointStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).GetBreakpointStatus(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Approve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeldRequestRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Approve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_Approve_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Approve(ctx, req.(*HeldRequestRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Reject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeldRequestRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Reject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_Reject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Reject(ctx, req.(*HeldRequestRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_ApproveEditedRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ApproveEditedRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_ApproveEditedRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ApproveEditedRequest(ctx, req.(*EditedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_ApproveEditedResponse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditedResponse)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ApproveEditedResponse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_ApproveEditedResponse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ApproveEditedResponse(ctx, req.(*EditedResponse))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Control_ServiceDesc is the grpc.ServiceDesc for Control service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Control_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cdpnetool.v1.Control",
+	HandlerType: (*ControlServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "StartSession",
+			Handler:    _Control_StartSession_Handler,
+		},
+		{
+			MethodName: "StopSession",
+			Handler:    _Control_StopSession_Handler,
+		},
+		{
+			MethodName: "ListTargets",
+			Handler:    _Control_ListTargets_Handler,
+		},
+		{
+			MethodName: "AttachTarget",
+			Handler:    _Control_AttachTarget_Handler,
+		},
+		{
+			MethodName: "DetachTarget",
+			Handler:    _Control_DetachTarget_Handler,
+		},
+		{
+			MethodName: "EnableInterception",
+			Handler:    _Control_EnableInterception_Handler,
+		},
+		{
+			MethodName: "DisableInterception",
+			Handler:    _Control_DisableInterception_Handler,
+		},
+		{
+			MethodName: "LoadRules",
+			Handler:    _Control_LoadRules_Handler,
+		},
+		{
+			MethodName: "GetRuleStats",
+			Handler:    _Control_GetRuleStats_Handler,
+		},
+		{
+			MethodName: "ArmBreakpoint",
+			Handler:    _Control_ArmBreakpoint_Handler,
+		},
+		{
+			MethodName: "DisarmBreakpoint",
+			Handler:    _Control_DisarmBreakpoint_Handler,
+		},
+		{
+			MethodName: "GetBreakpointStatus",
+			Handler:    _Control_GetBreakpointStatus_Handler,
+		},
+		{
+			MethodName: "Approve",
+			Handler:    _Control_Approve_Handler,
+		},
+		{
+			MethodName: "Reject",
+			Handler:    _Control_Reject_Handler,
+		},
+		{
+			MethodName: "ApproveEditedRequest",
+			Handler:    _Control_ApproveEditedRequest_Handler,
+		},
+		{
+			MethodName: "ApproveEditedResponse",
+			Handler:    _Control_ApproveEditedResponse_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeEvents",
+			Handler:       _Control_SubscribeEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cdpnetool.proto",
+}
@@ -0,0 +1,35 @@
+package apigrpc
+
+import "cdpnetool/pkg/domain"
+
+// FromEvent 将 SubscribeEvents 推送的事件转回领域事件，供客户端以与本地会话相同的格式输出
+func FromEvent(e *Event) domain.NetworkEvent {
+	evt := domain.NetworkEvent{
+		ID:          e.GetId(),
+		Seq:         e.GetSeq(),
+		Session:     domain.SessionID(e.GetSessionId()),
+		Target:      domain.TargetID(e.GetTargetId()),
+		Timestamp:   e.GetTimestamp(),
+		IsMatched:   e.GetIsMatched(),
+		FinalResult: e.GetFinalResult(),
+		Request: domain.Request{
+			ID:           e.GetRequest().GetId(),
+			URL:          e.GetRequest().GetUrl(),
+			Method:       e.GetRequest().GetMethod(),
+			Headers:      e.GetRequest().GetHeaders(),
+			Body:         e.GetRequest().GetBody(),
+			ResourceType: domain.ResourceType(e.GetRequest().GetResourceType()),
+		},
+	}
+	if r := e.GetResponse(); r != nil {
+		evt.Response = &domain.Response{
+			StatusCode: int(r.GetStatusCode()),
+			Headers:    r.GetHeaders(),
+			Body:       r.GetBody(),
+		}
+	}
+	for _, m := range e.GetMatchedRules() {
+		evt.MatchedRules = append(evt.MatchedRules, domain.RuleMatch{RuleID: m.GetRuleId(), RuleName: m.GetRuleName(), Actions: m.GetActions()})
+	}
+	return evt
+}
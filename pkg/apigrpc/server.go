@@ -0,0 +1,297 @@
+package apigrpc
+
+import (
+	"context"
+	"errors"
+
+	"cdpnetool/pkg/api"
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server 以 gRPC 暴露 api.Service 的控制面
+type Server struct {
+	UnimplementedControlServer
+	svc api.Service
+}
+
+// NewServer 创建基于 svc 的 gRPC 控制面实现
+func NewServer(svc api.Service) *Server {
+	return &Server{svc: svc}
+}
+
+// Register 将控制面注册到 gRPC 服务器
+func (s *Server) Register(gs *grpc.Server) {
+	RegisterControlServer(gs, s)
+}
+
+// StartSession 连接浏览器并启动会话
+func (s *Server) StartSession(ctx context.Context, req *StartSessionRequest) (*StartSessionResponse, error) {
+	id, err := s.svc.StartSession(ctx, domain.SessionConfig{
+		DevToolsURL:       req.GetDevtoolsUrl(),
+		Concurrency:       int(req.GetConcurrency()),
+		PendingCapacity:   int(req.GetPendingCapacity()),
+		ProcessTimeoutMS:  int(req.GetProcessTimeoutMs()),
+		ReadOnly:          req.GetReadOnly(),
+		CapabilityProfile: domain.CapabilityProfile(req.GetCapabilityProfile()),
+		CorrelationHeader: req.GetCorrelationHeader(),
+		DisableCache:      req.GetDisableCache(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &StartSessionResponse{SessionId: string(id)}, nil
+}
+
+// StopSession 停止会话
+func (s *Server) StopSession(ctx context.Context, req *SessionRequest) (*Empty, error) {
+	return empty(s.svc.StopSession(ctx, domain.SessionID(req.GetSessionId())))
+}
+
+// ListTargets 列出浏览器中的页面目标
+func (s *Server) ListTargets(ctx context.Context, req *SessionRequest) (*ListTargetsResponse, error) {
+	infos, err := s.svc.ListTargets(ctx, domain.SessionID(req.GetSessionId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &ListTargetsResponse{Targets: make([]*Target, 0, len(infos))}
+	for _, t := range infos {
+		resp.Targets = append(resp.Targets, &Target{Id: string(t.ID), Type: t.Type, Url: t.URL, Title: t.Title, Attached: t.IsCurrent})
+	}
+	return resp, nil
+}
+
+// AttachTarget 附着目标
+func (s *Server) AttachTarget(ctx context.Context, req *TargetRequest) (*Empty, error) {
+	return empty(s.svc.AttachTarget(ctx, domain.SessionID(req.GetSessionId()), domain.TargetID(req.GetTargetId())))
+}
+
+// DetachTarget 分离目标
+func (s *Server) DetachTarget(ctx context.Context, req *TargetRequest) (*Empty, error) {
+	return empty(s.svc.DetachTarget(ctx, domain.SessionID(req.GetSessionId()), domain.TargetID(req.GetTargetId())))
+}
+
+// EnableInterception 开启拦截
+func (s *Server) EnableInterception(ctx context.Context, req *SessionRequest) (*Empty, error) {
+	return empty(s.svc.EnableInterception(ctx, domain.SessionID(req.GetSessionId())))
+}
+
+// DisableInterception 关闭拦截
+func (s *Server) DisableInterception(ctx context.Context, req *SessionRequest) (*Empty, error) {
+	return empty(s.svc.DisableInterception(ctx, domain.SessionID(req.GetSessionId())))
+}
+
+// LoadRules 解析规则配置 JSON 并加载到会话
+func (s *Server) LoadRules(ctx context.Context, req *LoadRulesRequest) (*Empty, error) {
+	cfg, _, err := rulespec.ParseConfig([]byte(req.GetConfigJson()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return empty(s.svc.LoadRules(ctx, domain.SessionID(req.GetSessionId()), cfg))
+}
+
+// GetRuleStats 获取规则匹配统计
+func (s *Server) GetRuleStats(ctx context.Context, req *SessionRequest) (*RuleStats, error) {
+	stats, err := s.svc.GetRuleStats(ctx, domain.SessionID(req.GetSessionId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	byRule := make(map[string]int64, len(stats.ByRule))
+	for id, n := range stats.ByRule {
+		byRule[string(id)] = n
+	}
+	return &RuleStats{
+		Total:           stats.Total,
+		Matched:         stats.Matched,
+		ByRule:          byRule,
+		Throttled:       stats.Throttled,
+		ThrottleDelayMs: stats.ThrottleDelayMS,
+	}, nil
+}
+
+// SubscribeEvents 将会话的匹配事件推送到流中，直到客户端取消或会话结束
+func (s *Server) SubscribeEvents(req *SubscribeEventsRequest, stream grpc.ServerStreamingServer[Event]) error {
+	id := domain.SessionID(req.GetSessionId())
+	events, err := s.svc.SubscribeEvents(stream.Context(), id, req.GetAfter())
+	if err != nil {
+		return toStatus(err)
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case evt, ok := <-events:
+			if !ok {
+				return nil
+			}
+			evt.Session = id
+			if err := stream.Send(toEvent(evt)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ArmBreakpoint 布置一次性断点
+func (s *Server) ArmBreakpoint(ctx context.Context, req *ArmBreakpointRequest) (*Empty, error) {
+	return empty(s.svc.ArmBreakpoint(ctx, domain.SessionID(req.GetSessionId()), domain.BreakpointFilter{
+		URLContains: req.GetUrlContains(),
+		Method:      req.GetMethod(),
+		Stage:       domain.BreakpointStage(req.GetStage()),
+		TimeoutMS:   req.GetTimeoutMs(),
+	}))
+}
+
+// DisarmBreakpoint 解除尚未命中的断点
+func (s *Server) DisarmBreakpoint(ctx context.Context, req *SessionRequest) (*Empty, error) {
+	return empty(s.svc.DisarmBreakpoint(ctx, domain.SessionID(req.GetSessionId())))
+}
+
+// GetBreakpointStatus 获取断点状态及等待处理的请求
+func (s *Server) GetBreakpointStatus(ctx context.Context, req *SessionRequest) (*BreakpointStatus, error) {
+	st, err := s.svc.GetBreakpointStatus(ctx, domain.SessionID(req.GetSessionId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &BreakpointStatus{Armed: st.Armed, Held: make([]*HeldRequest, 0, len(st.Held))}
+	if st.Filter != nil {
+		resp.UrlContains, resp.Method, resp.TimeoutMs = st.Filter.URLContains, st.Filter.Method, st.Filter.TimeoutMS
+		resp.Stage = string(st.Filter.HoldStage())
+	}
+	for _, h := range st.Held {
+		resp.Held = append(resp.Held, &HeldRequest{
+			Id:          h.ID,
+			TargetId:    string(h.TargetID),
+			Url:         h.URL,
+			Method:      h.Method,
+			HeldAt:      h.HeldAt,
+			ExpiresAt:   h.ExpiresAt,
+			RemainingMs: h.RemainingMS,
+			Stage:       string(h.Stage),
+			Request:     toRequestSnapshot(h.Request),
+			Response:    toResponseSnapshot(h.Response),
+		})
+	}
+	return resp, nil
+}
+
+// Approve 放行被断点暂停的请求
+func (s *Server) Approve(ctx context.Context, req *HeldRequestRef) (*Empty, error) {
+	return empty(s.svc.ResolveHeldRequest(ctx, domain.SessionID(req.GetSessionId()), req.GetRequestId(), true))
+}
+
+// Reject 拒绝被断点暂停的请求
+func (s *Server) Reject(ctx context.Context, req *HeldRequestRef) (*Empty, error) {
+	return empty(s.svc.ResolveHeldRequest(ctx, domain.SessionID(req.GetSessionId()), req.GetRequestId(), false))
+}
+
+// ApproveEditedRequest 以编辑后的请求放行在请求阶段暂停的请求
+func (s *Server) ApproveEditedRequest(ctx context.Context, req *EditedRequest) (*Empty, error) {
+	var edit *domain.Request
+	if r := req.GetRequest(); r != nil {
+		edit = &domain.Request{URL: r.GetUrl(), Method: r.GetMethod(), Body: r.Body}
+		if len(r.GetHeaders()) > 0 {
+			edit.Headers = r.GetHeaders()
+		}
+		if len(r.GetCookies()) > 0 {
+			edit.Cookies = r.GetCookies()
+		}
+	}
+	return empty(s.svc.ApproveHeldRequest(ctx, domain.SessionID(req.GetSessionId()), req.GetRequestId(), edit))
+}
+
+// ApproveEditedResponse 以编辑后的响应放行在响应阶段暂停的请求
+func (s *Server) ApproveEditedResponse(ctx context.Context, req *EditedResponse) (*Empty, error) {
+	var edit *domain.Response
+	if r := req.GetResponse(); r != nil {
+		edit = &domain.Response{StatusCode: int(r.GetStatusCode()), Body: r.Body}
+		if len(r.GetHeaders()) > 0 {
+			edit.Headers = r.GetHeaders()
+		}
+	}
+	return empty(s.svc.ApproveHeldResponse(ctx, domain.SessionID(req.GetSessionId()), req.GetRequestId(), edit))
+}
+
+// empty 将无返回数据的调用结果转为响应
+func empty(err error) (*Empty, error) {
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &Empty{}, nil
+}
+
+// toEvent 将领域事件转为 gRPC 消息
+func toEvent(evt domain.NetworkEvent) *Event {
+	out := &Event{
+		Id:          evt.ID,
+		Seq:         evt.Seq,
+		SessionId:   string(evt.Session),
+		TargetId:    string(evt.Target),
+		Timestamp:   evt.Timestamp,
+		IsMatched:   evt.IsMatched,
+		FinalResult: evt.FinalResult,
+		Request: &Request{
+			Id:           evt.Request.ID,
+			Url:          evt.Request.URL,
+			Method:       evt.Request.Method,
+			Headers:      evt.Request.Headers,
+			Body:         evt.Request.Body,
+			ResourceType: string(evt.Request.ResourceType),
+		},
+	}
+	if evt.Response != nil {
+		out.Response = &Response{
+			StatusCode: int32(evt.Response.StatusCode),
+			Headers:    evt.Response.Headers,
+			Body:       evt.Response.Body,
+		}
+	}
+	for _, m := range evt.MatchedRules {
+		out.MatchedRules = append(out.MatchedRules, &RuleMatch{RuleId: m.RuleID, RuleName: m.RuleName, Actions: m.Actions})
+	}
+	return out
+}
+
+// toRequestSnapshot 将暂停请求的快照转为 gRPC 消息
+func toRequestSnapshot(r *domain.Request) *RequestSnapshot {
+	if r == nil {
+		return nil
+	}
+	return &RequestSnapshot{Url: r.URL, Method: r.Method, Headers: r.Headers, Body: r.Body, Cookies: r.Cookies}
+}
+
+// toResponseSnapshot 将暂停响应的快照转为 gRPC 消息
+func toResponseSnapshot(r *domain.Response) *ResponseSnapshot {
+	if r == nil {
+		return nil
+	}
+	return &ResponseSnapshot{StatusCode: int32(r.StatusCode), Headers: r.Headers, Body: r.Body}
+}
+
+// toStatus 将领域错误映射为 gRPC 状态码
+func toStatus(err error) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, domain.ErrSessionNotFound), errors.Is(err, domain.ErrTargetNotFound), errors.Is(err, domain.ErrRequestNotHeld),
+		errors.Is(err, domain.ErrEventNotFound), errors.Is(err, domain.ErrChallengeNotPending):
+		code = codes.NotFound
+	case errors.Is(err, domain.ErrInvalidConfig), errors.Is(err, domain.ErrRuleInvalid):
+		code = codes.InvalidArgument
+	case errors.Is(err, domain.ErrNoTargetAttached), errors.Is(err, domain.ErrTargetNotAttached),
+		errors.Is(err, domain.ErrSessionAlreadyStop), errors.Is(err, domain.ErrSessionReadOnly):
+		code = codes.FailedPrecondition
+	case errors.Is(err, domain.ErrDevToolsUnreachable), errors.Is(err, domain.ErrConnectionRefused):
+		code = codes.Unavailable
+	default:
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,421 @@
+package apigrpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"cdpnetool/internal/cdptest"
+	"cdpnetool/internal/logger"
+	"cdpnetool/pkg/api"
+	"cdpnetool/pkg/apigrpc"
+	"cdpnetool/pkg/rulespec"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/protocol/network"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newClient 以 opts 启动内存中的 gRPC 服务并返回客户端
+func newClient(t *testing.T, opts ...grpc.ServerOption) apigrpc.ControlClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer(opts...)
+	apigrpc.NewServer(api.NewService(logger.NewNop())).Register(gs)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return apigrpc.NewControlClient(conn)
+}
+
+func TestServer_StreamsEvents(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	client := newClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	started, err := client.StartSession(ctx, &apigrpc.StartSessionRequest{DevtoolsUrl: srv.URL(), PendingCapacity: 16, ProcessTimeoutMs: 1000})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	id := started.GetSessionId()
+
+	targets, err := client.ListTargets(ctx, &apigrpc.SessionRequest{SessionId: id})
+	if err != nil || len(targets.GetTargets()) != 1 || targets.GetTargets()[0].GetId() != "page1" {
+		t.Fatalf("ListTargets() = %v, %v", targets, err)
+	}
+	if _, err := client.AttachTarget(ctx, &apigrpc.TargetRequest{SessionId: id, TargetId: "page1"}); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+
+	cfg := rulespec.NewConfig("grpc")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "block-ads", Name: "block ads", Enabled: true, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/ads"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}}
+	data, _ := json.Marshal(cfg)
+	if _, err := client.LoadRules(ctx, &apigrpc.LoadRulesRequest{SessionId: id, ConfigJson: string(data)}); err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+
+	stream, err := client.SubscribeEvents(ctx, &apigrpc.SubscribeEventsRequest{SessionId: id})
+	if err != nil {
+		t.Fatalf("SubscribeEvents() error = %v", err)
+	}
+	if _, err := client.EnableInterception(ctx, &apigrpc.SessionRequest{SessionId: id}); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	if _, err := srv.WaitCall(ctx, "Fetch.enable", 1); err != nil {
+		t.Fatal(err)
+	}
+	ev := &fetch.RequestPausedReply{
+		RequestID: "req1",
+		Request:   network.Request{URL: "https://example.com/ads/banner.js", Method: "GET", Headers: network.Headers([]byte(`{}`))},
+	}
+	if err := srv.Pause("page1", ev); err != nil {
+		t.Fatal(err)
+	}
+
+	evt, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if evt.GetSessionId() != id || evt.GetTargetId() != "page1" || evt.GetRequest().GetUrl() != ev.Request.URL || evt.GetFinalResult() != "blocked" {
+		t.Errorf("unexpected event: %v", evt)
+	}
+	if len(evt.GetMatchedRules()) != 1 || evt.GetMatchedRules()[0].GetRuleId() != "block-ads" {
+		t.Errorf("got matched rules %v, want block-ads", evt.GetMatchedRules())
+	}
+	if got := apigrpc.FromEvent(evt); string(got.Session) != id || got.Request.URL != ev.Request.URL || got.FinalResult != "blocked" ||
+		len(got.MatchedRules) != 1 || got.MatchedRules[0].RuleID != "block-ads" {
+		t.Errorf("FromEvent() = %+v, want the pushed event", got)
+	}
+
+	stats, err := client.GetRuleStats(ctx, &apigrpc.SessionRequest{SessionId: id})
+	if err != nil || stats.GetMatched() != 1 || stats.GetByRule()["block-ads"] != 1 {
+		t.Errorf("GetRuleStats() = %v, %v", stats, err)
+	}
+	if _, err := client.StopSession(ctx, &apigrpc.SessionRequest{SessionId: id}); err != nil {
+		t.Fatalf("StopSession() error = %v", err)
+	}
+}
+
+func TestServer_ErrorCodes(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	client := newClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	started, err := client.StartSession(ctx, &apigrpc.StartSessionRequest{DevtoolsUrl: srv.URL()})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	id := started.GetSessionId()
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"unknown session", func() error {
+			_, err := client.EnableInterception(ctx, &apigrpc.SessionRequest{SessionId: "missing"})
+			return err
+		}, codes.NotFound},
+		{"invalid rules json", func() error {
+			_, err := client.LoadRules(ctx, &apigrpc.LoadRulesRequest{SessionId: id, ConfigJson: "{"})
+			return err
+		}, codes.InvalidArgument},
+		{"approve not held", func() error {
+			_, err := client.Approve(ctx, &apigrpc.HeldRequestRef{SessionId: id, RequestId: "nope"})
+			return err
+		}, codes.NotFound},
+		{"reject not held", func() error {
+			_, err := client.Reject(ctx, &apigrpc.HeldRequestRef{SessionId: id, RequestId: "nope"})
+			return err
+		}, codes.NotFound},
+		{"unreachable devtools", func() error {
+			_, err := client.StartSession(ctx, &apigrpc.StartSessionRequest{DevtoolsUrl: "http://127.0.0.1:1"})
+			return err
+		}, codes.Unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(tt.call()); got != tt.want {
+				t.Errorf("got code %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_RequiresToken(t *testing.T) {
+	client := newClient(t, apigrpc.ServerOptions(apigrpc.Key{Token: "s3cret", Scope: apigrpc.ScopeFull})...)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.StartSession(ctx, &apigrpc.StartSessionRequest{DevtoolsUrl: "http://127.0.0.1:1"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("got %v without token, want Unauthenticated", err)
+	}
+	wrong := metadata.AppendToOutgoingContext(ctx, apigrpc.TokenMetadataKey, "Bearer guess")
+	stream, err := client.SubscribeEvents(wrong, &apigrpc.SubscribeEventsRequest{SessionId: "s1"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("got %v with a wrong token, want Unauthenticated", err)
+	}
+
+	authed := metadata.AppendToOutgoingContext(ctx, apigrpc.TokenMetadataKey, "Bearer s3cret")
+	if _, err := client.EnableInterception(authed, &apigrpc.SessionRequest{SessionId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("got %v with the token, want NotFound from the service", err)
+	}
+}
+
+func TestServer_ReadOnlyToken(t *testing.T) {
+	client := newClient(t, apigrpc.ServerOptions(
+		apigrpc.Key{Token: "admin", Scope: apigrpc.ScopeFull},
+		apigrpc.Key{Token: "viewer", Scope: apigrpc.ScopeReadOnly},
+	)...)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	viewer := metadata.AppendToOutgoingContext(ctx, apigrpc.TokenMetadataKey, "Bearer viewer")
+
+	// 只读令牌不能启动会话、修改规则或放行请求
+	denied := map[string]func() error{
+		"StartSession": func() error {
+			_, err := client.StartSession(viewer, &apigrpc.StartSessionRequest{DevtoolsUrl: "http://127.0.0.1:1"})
+			return err
+		},
+		"LoadRules": func() error {
+			_, err := client.LoadRules(viewer, &apigrpc.LoadRulesRequest{SessionId: "missing", ConfigJson: "{}"})
+			return err
+		},
+		"Approve": func() error {
+			_, err := client.Approve(viewer, &apigrpc.HeldRequestRef{SessionId: "missing", RequestId: "r1"})
+			return err
+		},
+		"Reject": func() error {
+			_, err := client.Reject(viewer, &apigrpc.HeldRequestRef{SessionId: "missing", RequestId: "r1"})
+			return err
+		},
+		"ApproveEditedRequest": func() error {
+			_, err := client.ApproveEditedRequest(viewer, &apigrpc.EditedRequest{SessionId: "missing", RequestId: "r1"})
+			return err
+		},
+		"ApproveEditedResponse": func() error {
+			_, err := client.ApproveEditedResponse(viewer, &apigrpc.EditedResponse{SessionId: "missing", RequestId: "r1"})
+			return err
+		},
+	}
+	for name, call := range denied {
+		if err := call(); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s: got %v with a read-only token, want PermissionDenied", name, err)
+		}
+	}
+
+	// 只读方法交由服务处理
+	if _, err := client.GetRuleStats(viewer, &apigrpc.SessionRequest{SessionId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetRuleStats: got %v with a read-only token, want NotFound from the service", err)
+	}
+	stream, err := client.SubscribeEvents(viewer, &apigrpc.SubscribeEventsRequest{SessionId: "missing"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("SubscribeEvents: got %v with a read-only token, want NotFound from the service", err)
+	}
+
+	admin := metadata.AppendToOutgoingContext(ctx, apigrpc.TokenMetadataKey, "Bearer admin")
+	if _, err := client.Approve(admin, &apigrpc.HeldRequestRef{SessionId: "missing", RequestId: "r1"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Approve: got %v with a full token, want NotFound from the service", err)
+	}
+}
+
+func TestCheckListenAddr(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:50051", "localhost:0", "[::1]:50051"} {
+		if err := apigrpc.CheckListenAddr(addr, false, false); err != nil {
+			t.Errorf("CheckListenAddr(%q) error = %v", addr, err)
+		}
+	}
+	for _, addr := range []string{":50051", "0.0.0.0:50051", "192.168.1.5:50051", "example.com:50051", "nonsense"} {
+		if err := apigrpc.CheckListenAddr(addr, true, false); err == nil {
+			t.Errorf("CheckListenAddr(%q) without token should fail", addr)
+		}
+	}
+	if err := apigrpc.CheckListenAddr(":50051", true, true); err != nil {
+		t.Errorf("CheckListenAddr with TLS and token error = %v", err)
+	}
+}
+
+// startHolding 通过 gRPC 启动会话、附着 page1 并开启拦截
+func startHolding(t *testing.T, ctx context.Context, srv *cdptest.Server, client apigrpc.ControlClient) string {
+	t.Helper()
+	started, err := client.StartSession(ctx, &apigrpc.StartSessionRequest{DevtoolsUrl: srv.URL(), PendingCapacity: 16, ProcessTimeoutMs: 1000})
+	if err != nil {
+		t.Fatalf("StartSession() error = %v", err)
+	}
+	id := started.GetSessionId()
+	t.Cleanup(func() { client.StopSession(context.Background(), &apigrpc.SessionRequest{SessionId: id}) })
+	if _, err := client.AttachTarget(ctx, &apigrpc.TargetRequest{SessionId: id, TargetId: "page1"}); err != nil {
+		t.Fatalf("AttachTarget() error = %v", err)
+	}
+	if _, err := client.EnableInterception(ctx, &apigrpc.SessionRequest{SessionId: id}); err != nil {
+		t.Fatalf("EnableInterception() error = %v", err)
+	}
+	return id
+}
+
+// waitHeldVia 轮询断点状态，直到有请求等待处理
+func waitHeldVia(t *testing.T, ctx context.Context, client apigrpc.ControlClient, id string) *apigrpc.HeldRequest {
+	t.Helper()
+	for {
+		st, err := client.GetBreakpointStatus(ctx, &apigrpc.SessionRequest{SessionId: id})
+		if err != nil {
+			t.Fatalf("GetBreakpointStatus() error = %v", err)
+		}
+		if len(st.GetHeld()) > 0 {
+			return st.GetHeld()[0]
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("no request held")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestServer_ApproveEditedRequest(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	client := newClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	id := startHolding(t, ctx, srv, client)
+
+	if _, err := client.ArmBreakpoint(ctx, &apigrpc.ArmBreakpointRequest{SessionId: id, UrlContains: "/api"}); err != nil {
+		t.Fatalf("ArmBreakpoint() error = %v", err)
+	}
+	ev := &fetch.RequestPausedReply{
+		RequestID: "req1",
+		Request:   network.Request{URL: "https://example.com/api", Method: "GET", Headers: network.Headers([]byte(`{"X-Old":"1"}`))},
+	}
+	if err := srv.Pause("page1", ev); err != nil {
+		t.Fatal(err)
+	}
+	held := waitHeldVia(t, ctx, client, id)
+	if held.GetStage() != "request" || held.GetRequest().GetUrl() != ev.Request.URL || held.GetRequest().GetHeaders()["X-Old"] != "1" {
+		t.Fatalf("got held %v, want request stage snapshot", held)
+	}
+
+	_, err := client.ApproveEditedResponse(ctx, &apigrpc.EditedResponse{SessionId: id, RequestId: "req1", Response: &apigrpc.ResponseSnapshot{StatusCode: 201}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("approving a response for a request stage hold: got %v, want InvalidArgument", err)
+	}
+	_, err = client.ApproveEditedRequest(ctx, &apigrpc.EditedRequest{SessionId: id, RequestId: "req1", Request: &apigrpc.RequestSnapshot{
+		Url:     "https://example.com/api/v2",
+		Method:  "POST",
+		Headers: map[string]string{"X-New": "1"},
+		Body:    []byte(`{"a":1}`),
+	}})
+	if err != nil {
+		t.Fatalf("ApproveEditedRequest() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.continueRequest", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.ContinueRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.URL == nil || *args.URL != "https://example.com/api/v2" || args.Method == nil || *args.Method != "POST" || string(args.PostData) != `{"a":1}` {
+		t.Errorf("got %+v, want edited url, method and body", args)
+	}
+	for _, h := range args.Headers {
+		if h.Name == "X-Old" {
+			t.Errorf("got headers %+v, want X-Old replaced", args.Headers)
+		}
+	}
+}
+
+func TestServer_ApproveEditedResponse(t *testing.T) {
+	srv := cdptest.NewServer()
+	defer srv.Close()
+	srv.AddTarget("page1", "https://example.com")
+	srv.Handle("Fetch.getResponseBody", func(targetID string, params json.RawMessage) (any, error) {
+		return fetch.GetResponseBodyReply{Body: `{"name":"old"}`}, nil
+	})
+	client := newClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	id := startHolding(t, ctx, srv, client)
+
+	if _, err := client.ArmBreakpoint(ctx, &apigrpc.ArmBreakpointRequest{SessionId: id, Stage: "response"}); err != nil {
+		t.Fatalf("ArmBreakpoint() error = %v", err)
+	}
+	st, err := client.GetBreakpointStatus(ctx, &apigrpc.SessionRequest{SessionId: id})
+	if err != nil || !st.GetArmed() || st.GetStage() != "response" {
+		t.Fatalf("GetBreakpointStatus() = %v, %v, want armed at the response stage", st, err)
+	}
+	code := 200
+	ev := &fetch.RequestPausedReply{
+		RequestID:          "req1",
+		Request:            network.Request{URL: "https://example.com/api", Method: "GET", Headers: network.Headers([]byte(`{}`))},
+		ResponseStatusCode: &code,
+		ResponseHeaders:    []fetch.HeaderEntry{{Name: "Content-Type", Value: "application/json"}},
+	}
+	if err := srv.Pause("page1", ev); err != nil {
+		t.Fatal(err)
+	}
+	held := waitHeldVia(t, ctx, client, id)
+	if held.GetStage() != "response" || held.GetResponse().GetStatusCode() != 200 || string(held.GetResponse().GetBody()) != `{"name":"old"}` {
+		t.Fatalf("got held %v, want response stage snapshot", held)
+	}
+
+	_, err = client.ApproveEditedResponse(ctx, &apigrpc.EditedResponse{SessionId: id, RequestId: "req1", Response: &apigrpc.ResponseSnapshot{StatusCode: 1000}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("invalid status code: got %v, want InvalidArgument", err)
+	}
+	_, err = client.ApproveEditedResponse(ctx, &apigrpc.EditedResponse{SessionId: id, RequestId: "req1", Response: &apigrpc.ResponseSnapshot{
+		StatusCode: 201,
+		Body:       []byte(`{"name":"new"}`),
+	}})
+	if err != nil {
+		t.Fatalf("ApproveEditedResponse() error = %v", err)
+	}
+	call, err := srv.WaitCall(ctx, "Fetch.fulfillRequest", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var args fetch.FulfillRequestArgs
+	if err := json.Unmarshal(call.Params, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ResponseCode != 201 || string(args.Body) != `{"name":"new"}` {
+		t.Errorf("got %d %q, want edited status and body", args.ResponseCode, args.Body)
+	}
+	if len(args.ResponseHeaders) != 1 || args.ResponseHeaders[0].Value != "application/json" {
+		t.Errorf("got headers %+v, want original headers kept", args.ResponseHeaders)
+	}
+	if _, err := client.ApproveEditedRequest(ctx, &apigrpc.EditedRequest{SessionId: id, RequestId: "req1"}); status.Code(err) != codes.NotFound {
+		t.Errorf("approving a released request: got %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,364 @@
+// Package diff 计算规则修改前后请求与响应的结构化差异（头部增删改、URL 变化、消息体补丁），
+// 供界面渲染语义化对比
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"cdpnetool/pkg/domain"
+)
+
+// MaxBodySize 计算消息体补丁的大小上限（字节），任一侧超过时只报告大小
+const MaxBodySize = 1 << 20
+
+// BodyKind 消息体的对比方式
+type BodyKind string
+
+const (
+	BodyText   BodyKind = "text"   // 按行对比
+	BodyJSON   BodyKind = "json"   // 按字段对比，补丁基于格式化后的 JSON
+	BodyBinary BodyKind = "binary" // 只报告大小
+)
+
+// Change 单个值的变更
+type Change struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// StatusChange 状态码的变更
+type StatusChange struct {
+	Old int `json:"old"`
+	New int `json:"new"`
+}
+
+// FieldChange 单个头部或查询参数的变更，新增时 Old 为空，删除时 New 为空
+type FieldChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// Fields 一组名值对（头部或查询参数）的差异，各列表按名称排序
+type Fields struct {
+	Added   []FieldChange `json:"added"`
+	Removed []FieldChange `json:"removed"`
+	Changed []FieldChange `json:"changed"`
+}
+
+// URLDelta URL 的差异，只列出发生变化的部分
+type URLDelta struct {
+	Old    string  `json:"old"`
+	New    string  `json:"new"`
+	Scheme *Change `json:"scheme,omitempty"`
+	Host   *Change `json:"host,omitempty"`
+	Path   *Change `json:"path,omitempty"`
+	Query  *Fields `json:"query,omitempty"`
+}
+
+// JSONChange JSON 消息体中一处值的变更
+type JSONChange struct {
+	Op   string `json:"op"`   // add、remove 或 replace
+	Path string `json:"path"` // JSON Pointer
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// BodyPatch 消息体的差异
+type BodyPatch struct {
+	Kind     BodyKind     `json:"kind"`
+	OldSize  int          `json:"oldSize"`
+	NewSize  int          `json:"newSize"`
+	Patch    string       `json:"patch,omitempty"`    // 统一 diff 格式的补丁，二进制或超过 MaxBodySize 时为空
+	JSON     []JSONChange `json:"json,omitempty"`     // JSON 消息体的逐字段变更
+	TooLarge bool         `json:"tooLarge,omitempty"` // 超过 MaxBodySize，未计算补丁
+}
+
+// RequestDiff 请求的差异
+type RequestDiff struct {
+	Method  *Change    `json:"method,omitempty"`
+	URL     *URLDelta  `json:"url,omitempty"`
+	Headers *Fields    `json:"headers,omitempty"`
+	Body    *BodyPatch `json:"body,omitempty"`
+}
+
+// ResponseDiff 响应的差异
+type ResponseDiff struct {
+	StatusCode *StatusChange `json:"statusCode,omitempty"`
+	Headers    *Fields       `json:"headers,omitempty"`
+	Body       *BodyPatch    `json:"body,omitempty"`
+}
+
+// EventDiff 事件中规则修改前后的差异，未被修改的一方为空
+type EventDiff struct {
+	Request  *RequestDiff  `json:"request,omitempty"`
+	Response *ResponseDiff `json:"response,omitempty"`
+}
+
+// Event 计算事件的 OriginalRequest/OriginalResponse 与最终 Request/Response 之间的差异
+func Event(evt domain.NetworkEvent) EventDiff {
+	var d EventDiff
+	if evt.OriginalRequest != nil {
+		d.Request = Request(evt.OriginalRequest, &evt.Request)
+	}
+	if evt.OriginalResponse != nil && evt.Response != nil {
+		d.Response = Response(evt.OriginalResponse, evt.Response)
+	}
+	return d
+}
+
+// Request 计算两个请求的差异，没有差异时返回 nil
+func Request(old, new *domain.Request) *RequestDiff {
+	d := &RequestDiff{
+		URL:     URL(old.URL, new.URL),
+		Headers: Headers(old.Headers, new.Headers),
+		Body:    Body(old.Body, new.Body, contentType(new.Headers)),
+	}
+	if old.Method != new.Method {
+		d.Method = &Change{Old: old.Method, New: new.Method}
+	}
+	if d.Method == nil && d.URL == nil && d.Headers == nil && d.Body == nil {
+		return nil
+	}
+	return d
+}
+
+// Response 计算两个响应的差异，没有差异时返回 nil
+func Response(old, new *domain.Response) *ResponseDiff {
+	d := &ResponseDiff{
+		Headers: Headers(old.Headers, new.Headers),
+		Body:    Body(old.Body, new.Body, contentType(new.Headers)),
+	}
+	if old.StatusCode != new.StatusCode {
+		d.StatusCode = &StatusChange{Old: old.StatusCode, New: new.StatusCode}
+	}
+	if d.StatusCode == nil && d.Headers == nil && d.Body == nil {
+		return nil
+	}
+	return d
+}
+
+// Headers 计算两组头部的差异，名称不区分大小写，没有差异时返回 nil
+func Headers(old, new domain.Header) *Fields {
+	return fields(fold(old), fold(new))
+}
+
+// URL 计算两个 URL 的差异，没有差异时返回 nil；无法解析时只给出新旧 URL
+func URL(old, new string) *URLDelta {
+	if old == new {
+		return nil
+	}
+	d := &URLDelta{Old: old, New: new}
+	ou, oerr := url.Parse(old)
+	nu, nerr := url.Parse(new)
+	if oerr != nil || nerr != nil {
+		return d
+	}
+	d.Scheme = change(ou.Scheme, nu.Scheme)
+	d.Host = change(ou.Host, nu.Host)
+	d.Path = change(ou.EscapedPath(), nu.EscapedPath())
+	d.Query = fields(query(ou.Query()), query(nu.Query()))
+	return d
+}
+
+// Body 计算两个消息体的差异，没有差异时返回 nil；contentType 用于判断是否按 JSON 对比
+func Body(old, new []byte, contentType string) *BodyPatch {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	p := &BodyPatch{Kind: BodyBinary, OldSize: len(old), NewSize: len(new)}
+	if !utf8.Valid(old) || !utf8.Valid(new) {
+		return p
+	}
+	p.Kind = BodyText
+	if isJSON(contentType, old, new) {
+		p.Kind = BodyJSON
+	}
+	if len(old) > MaxBodySize || len(new) > MaxBodySize {
+		p.TooLarge = true
+		return p
+	}
+
+	oldText, newText := string(old), string(new)
+	if p.Kind == BodyJSON {
+		ov, _ := decodeJSON(old)
+		nv, _ := decodeJSON(new)
+		p.JSON = compareJSON("", ov, nv, nil)
+		// 按格式化后的文本生成补丁，单行的紧凑 JSON 也能看出改动位置
+		oldText, newText = indent(old), indent(new)
+	}
+	p.Patch = unified(oldText, newText)
+	return p
+}
+
+// contentType 不区分大小写地获取 Content-Type
+func contentType(h domain.Header) string {
+	for k, v := range h {
+		if strings.EqualFold(k, "Content-Type") {
+			return v
+		}
+	}
+	return ""
+}
+
+// isJSON 判断消息体是否按 JSON 对比：Content-Type 声明为 JSON 或两侧都是 JSON 对象或数组
+func isJSON(contentType string, old, new []byte) bool {
+	declared := strings.Contains(strings.ToLower(contentType), "json")
+	for _, b := range [][]byte{old, new} {
+		t := bytes.TrimSpace(b)
+		if len(t) == 0 && declared {
+			continue
+		}
+		if !json.Valid(t) || (!declared && t[0] != '{' && t[0] != '[') {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeJSON 解码 JSON，数字保留原始文本，空消息体解码为 nil
+func decodeJSON(b []byte) (any, error) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	err := dec.Decode(&v)
+	return v, err
+}
+
+// indent 格式化 JSON，失败时原样返回
+func indent(b []byte) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return string(b)
+	}
+	return buf.String()
+}
+
+// compareJSON 递归对比两个 JSON 值，对象按键、数组按下标对应
+func compareJSON(path string, old, new any, out []JSONChange) []JSONChange {
+	switch o := old.(type) {
+	case map[string]any:
+		n, ok := new.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(o)+len(n))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		for k := range n {
+			if _, ok := o[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := path + "/" + escapePointer(k)
+			ov, inOld := o[k]
+			nv, inNew := n[k]
+			switch {
+			case !inOld:
+				out = append(out, JSONChange{Op: "add", Path: p, New: nv})
+			case !inNew:
+				out = append(out, JSONChange{Op: "remove", Path: p, Old: ov})
+			default:
+				out = compareJSON(p, ov, nv, out)
+			}
+		}
+		return out
+	case []any:
+		n, ok := new.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(o) || i < len(n); i++ {
+			p := path + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(o):
+				out = append(out, JSONChange{Op: "add", Path: p, New: n[i]})
+			case i >= len(n):
+				out = append(out, JSONChange{Op: "remove", Path: p, Old: o[i]})
+			default:
+				out = compareJSON(p, o[i], n[i], out)
+			}
+		}
+		return out
+	}
+	if !jsonEqual(old, new) {
+		out = append(out, JSONChange{Op: "replace", Path: path, Old: old, New: new})
+	}
+	return out
+}
+
+// jsonEqual 判断两个标量或类型不同的 JSON 值是否相同
+func jsonEqual(a, b any) bool {
+	ab, _ := json.Marshal(a)
+	bb, _ := json.Marshal(b)
+	return bytes.Equal(ab, bb)
+}
+
+// escapePointer 按 RFC 6901 转义 JSON Pointer 中的键
+func escapePointer(k string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(k)
+}
+
+// fold 将头部名称转为小写，便于不区分大小写地对比
+func fold(h domain.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+// query 将查询参数展平，同名参数的多个值以逗号连接
+func query(q url.Values) map[string]string {
+	out := make(map[string]string, len(q))
+	for k, v := range q {
+		out[k] = strings.Join(v, ",")
+	}
+	return out
+}
+
+// fields 计算两组名值对的差异，没有差异时返回 nil
+func fields(old, new map[string]string) *Fields {
+	f := &Fields{Added: []FieldChange{}, Removed: []FieldChange{}, Changed: []FieldChange{}}
+	for k, nv := range new {
+		ov, ok := old[k]
+		switch {
+		case !ok:
+			f.Added = append(f.Added, FieldChange{Name: k, New: nv})
+		case ov != nv:
+			f.Changed = append(f.Changed, FieldChange{Name: k, Old: ov, New: nv})
+		}
+	}
+	for k, ov := range old {
+		if _, ok := new[k]; !ok {
+			f.Removed = append(f.Removed, FieldChange{Name: k, Old: ov})
+		}
+	}
+	if len(f.Added) == 0 && len(f.Removed) == 0 && len(f.Changed) == 0 {
+		return nil
+	}
+	for _, list := range [][]FieldChange{f.Added, f.Removed, f.Changed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	return f
+}
+
+// change 两个值不同时返回变更
+func change(old, new string) *Change {
+	if old == new {
+		return nil
+	}
+	return &Change{Old: old, New: new}
+}
@@ -0,0 +1,125 @@
+package diff_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cdpnetool/pkg/diff"
+	"cdpnetool/pkg/domain"
+)
+
+func TestHeaders(t *testing.T) {
+	got := diff.Headers(
+		domain.Header{"Accept": "*/*", "X-Old": "1", "Authorization": "a"},
+		domain.Header{"accept": "*/*", "X-New": "2", "authorization": "b"},
+	)
+	want := &diff.Fields{
+		Added:   []diff.FieldChange{{Name: "x-new", New: "2"}},
+		Removed: []diff.FieldChange{{Name: "x-old", Old: "1"}},
+		Changed: []diff.FieldChange{{Name: "authorization", Old: "a", New: "b"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if d := diff.Headers(domain.Header{"A": "1"}, domain.Header{"a": "1"}); d != nil {
+		t.Errorf("headers differing only in case should be equal, got %+v", d)
+	}
+}
+
+func TestURL(t *testing.T) {
+	d := diff.URL("https://api.example.com/v1/users?page=1&debug=1", "http://staging.example.com/v2/users?page=2&lang=en")
+	if d == nil || d.Scheme == nil || d.Host == nil || d.Path == nil || d.Query == nil {
+		t.Fatalf("got %+v, want scheme, host, path and query changes", d)
+	}
+	if d.Host.Old != "api.example.com" || d.Host.New != "staging.example.com" || d.Path.New != "/v2/users" {
+		t.Errorf("got host %+v path %+v", d.Host, d.Path)
+	}
+	wantQuery := &diff.Fields{
+		Added:   []diff.FieldChange{{Name: "lang", New: "en"}},
+		Removed: []diff.FieldChange{{Name: "debug", Old: "1"}},
+		Changed: []diff.FieldChange{{Name: "page", Old: "1", New: "2"}},
+	}
+	if !reflect.DeepEqual(d.Query, wantQuery) {
+		t.Errorf("got query %+v, want %+v", d.Query, wantQuery)
+	}
+
+	if d := diff.URL("https://a.com/x?q=1", "https://a.com/x?q=1"); d != nil {
+		t.Errorf("equal URLs got %+v", d)
+	}
+	if d := diff.URL("https://a.com/x", "https://a.com/x?q=1"); d.Host != nil || d.Path != nil || len(d.Query.Added) != 1 {
+		t.Errorf("only the query should change, got %+v", d)
+	}
+}
+
+func TestBody_JSON(t *testing.T) {
+	p := diff.Body(
+		[]byte(`{"user":{"name":"a","age":1},"tags":["x","y"],"flag":true}`),
+		[]byte(`{"user":{"name":"b","age":1,"vip":true},"tags":["x"],"n":1.50}`),
+		"application/json; charset=utf-8",
+	)
+	if p == nil || p.Kind != diff.BodyJSON {
+		t.Fatalf("got %+v, want a json patch", p)
+	}
+	var got []map[string]any
+	data, _ := json.Marshal(p.JSON)
+	_ = json.Unmarshal(data, &got)
+	want := []map[string]any{
+		{"op": "remove", "path": "/flag", "old": true},
+		{"op": "add", "path": "/n", "new": 1.5},
+		{"op": "remove", "path": "/tags/1", "old": "y"},
+		{"op": "replace", "path": "/user/name", "old": "a", "new": "b"},
+		{"op": "add", "path": "/user/vip", "new": true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got changes %v, want %v", got, want)
+	}
+	// 紧凑 JSON 按格式化后的文本生成补丁
+	if !strings.Contains(p.Patch, "\n-    \"name\": \"a\",\n") || !strings.Contains(p.Patch, "\n+    \"name\": \"b\",\n") {
+		t.Errorf("patch should be computed on indented JSON, got\n%s", p.Patch)
+	}
+
+	// 未声明类型但两侧都是 JSON 对象时同样按字段对比
+	if p := diff.Body([]byte(`{"a":1}`), []byte(`{"a":2}`), ""); p.Kind != diff.BodyJSON || len(p.JSON) != 1 {
+		t.Errorf("got %+v, want one json change", p)
+	}
+}
+
+func TestBody_KindsAndLimits(t *testing.T) {
+	if p := diff.Body([]byte("same"), []byte("same"), ""); p != nil {
+		t.Errorf("equal bodies got %+v", p)
+	}
+	p := diff.Body([]byte{0xff, 0x00}, []byte{0xfe}, "application/octet-stream")
+	if p == nil || p.Kind != diff.BodyBinary || p.Patch != "" || p.OldSize != 2 || p.NewSize != 1 {
+		t.Errorf("got %+v, want a binary size-only patch", p)
+	}
+	big := bytes.Repeat([]byte("a\n"), diff.MaxBodySize)
+	p = diff.Body(big, []byte("b\n"), "text/plain")
+	if p == nil || !p.TooLarge || p.Patch != "" || p.OldSize != len(big) {
+		t.Errorf("got kind %s tooLarge %v, want a size-only patch for large bodies", p.Kind, p.TooLarge)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	evt := domain.NetworkEvent{
+		Request:         domain.Request{URL: "https://a.com/x", Method: "POST", Headers: domain.Header{"X-A": "2"}},
+		OriginalRequest: &domain.Request{URL: "https://a.com/x", Method: "GET", Headers: domain.Header{"X-A": "1"}},
+		Response:        &domain.Response{StatusCode: 200, Headers: domain.Header{}},
+	}
+	d := diff.Event(evt)
+	if d.Request == nil || d.Request.Method == nil || d.Request.Headers == nil || d.Request.URL != nil || d.Request.Body != nil {
+		t.Errorf("got request diff %+v, want method and header changes", d.Request)
+	}
+	if d.Response != nil {
+		t.Errorf("unmodified response got %+v", d.Response)
+	}
+
+	evt.OriginalRequest = nil
+	evt.OriginalResponse = &domain.Response{StatusCode: 500, Headers: domain.Header{}}
+	d = diff.Event(evt)
+	if d.Request != nil || d.Response == nil || *d.Response.StatusCode != (diff.StatusChange{Old: 500, New: 200}) {
+		t.Errorf("got %+v, want only a status change", d)
+	}
+}
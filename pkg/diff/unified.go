@@ -0,0 +1,156 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines 补丁中每处改动前后保留的上下文行数
+const contextLines = 3
+
+// maxLCSCells 逐行求最长公共子序列的表格上限，超过时改动部分按整体替换输出
+const maxLCSCells = 1 << 20
+
+// line 补丁中的一行，kind 为 ' '、'-' 或 '+'
+type line struct {
+	kind byte
+	text string
+}
+
+// unified 生成从 old 到 new 的统一 diff 格式补丁，两者相同时返回空串
+func unified(old, new string) string {
+	ops := lineOps(splitLines(old), splitLines(new))
+
+	// 每行之前已出现的旧、新行数，用于计算块头的起始行号
+	oldPos := make([]int, len(ops)+1)
+	newPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if op.kind != '+' {
+			oldPos[i+1]++
+		}
+		if op.kind != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == ' ' {
+			continue
+		}
+		// 相邻改动之间的相同行不超过两倍上下文时合并为一块
+		start, last := max(0, i-contextLines), i
+		for j := i; j < len(ops) && j-last-1 <= 2*contextLines; j++ {
+			if ops[j].kind != ' ' {
+				last = j
+			}
+		}
+		end := min(len(ops), last+contextLines+1)
+
+		if b.Len() == 0 {
+			b.WriteString("--- original\n+++ modified\n")
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(oldPos[start], oldPos[end]-oldPos[start]),
+			hunkRange(newPos[start], newPos[end]-newPos[start]))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.text)
+			b.WriteByte('\n')
+		}
+		i = end - 1
+	}
+	return b.String()
+}
+
+// hunkRange 格式化块头中的行范围，行数为 0 时起始行为该位置之前的一行
+func hunkRange(before, count int) string {
+	start := before + 1
+	if count == 0 {
+		start = before
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines 按换行拆分文本，末尾的换行不产生空行
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineOps 计算从 a 到 b 的逐行编辑序列，先去掉公共的首尾行再对中间部分求最长公共子序列
+func lineOps(a, b []string) []line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]line, 0, len(a)+len(b))
+	for _, t := range a[:prefix] {
+		ops = append(ops, line{' ', t})
+	}
+	ops = append(ops, lcsOps(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, t := range a[len(a)-suffix:] {
+		ops = append(ops, line{' ', t})
+	}
+	return ops
+}
+
+// lcsOps 以最长公共子序列计算编辑序列，规模过大时输出整体删除与插入
+func lcsOps(a, b []string) []line {
+	var ops []line
+	if (len(a)+1)*(len(b)+1) > maxLCSCells {
+		for _, t := range a {
+			ops = append(ops, line{'-', t})
+		}
+		for _, t := range b {
+			ops = append(ops, line{'+', t})
+		}
+		return ops
+	}
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	w := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*w)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else {
+				lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, line{' ', a[i]})
+			i++
+			j++
+		case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+			ops = append(ops, line{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, line{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, line{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, line{'+', b[j]})
+	}
+	return ops
+}
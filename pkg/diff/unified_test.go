@@ -0,0 +1,59 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"cdpnetool/pkg/diff"
+)
+
+func TestBody_TextPatch(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	new := "a\nb\nC\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	p := diff.Body([]byte(old), []byte(new), "text/plain")
+	if p == nil || p.Kind != diff.BodyText {
+		t.Fatalf("got %+v, want a text patch", p)
+	}
+	want := strings.Join([]string{
+		"--- original",
+		"+++ modified",
+		"@@ -1,6 +1,6 @@",
+		" a",
+		" b",
+		"-c",
+		"+C",
+		" d",
+		" e",
+		" f",
+		"@@ -11,3 +11,4 @@",
+		" k",
+		" l",
+		" m",
+		"+n",
+		"",
+	}, "\n")
+	if p.Patch != want {
+		t.Errorf("got patch\n%s\nwant\n%s", p.Patch, want)
+	}
+}
+
+func TestBody_TextPatchEdges(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"from empty", "", "x\ny\n", "--- original\n+++ modified\n@@ -0,0 +1,2 @@\n+x\n+y\n"},
+		{"to empty", "x\n", "", "--- original\n+++ modified\n@@ -1 +0,0 @@\n-x\n"},
+		{"merged hunks", "1\n2\n3\n4\n5\n6\n7\n8\n", "0\n2\n3\n4\n5\n6\n7\n9\n",
+			"--- original\n+++ modified\n@@ -1,8 +1,8 @@\n-1\n+0\n 2\n 3\n 4\n 5\n 6\n 7\n-8\n+9\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := diff.Body([]byte(tt.old), []byte(tt.new), "")
+			if p == nil || p.Patch != tt.want {
+				t.Errorf("got %+v, want patch %q", p, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// RuleAssertion 规则匹配次数断言，用于在 CI 中校验会话内规则的命中情况
+type RuleAssertion struct {
+	RuleID RuleID `json:"ruleId"`
+	Min    int64  `json:"min"`           // 最少匹配次数
+	Max    *int64 `json:"max,omitempty"` // 最多匹配次数，为 nil 表示不限
+}
+
+// assertionPattern 断言表达式，如 login-mock>=1、block-analytics==0
+var assertionPattern = regexp.MustCompile(`^\s*([^\s<>=!]+)\s*(>=|<=|==|=|>|<)\s*(\d+)\s*$`)
+
+// ParseRuleAssertion 解析 "规则ID 运算符 次数" 形式的断言，运算符支持 >=、>、<=、<、== 与 =
+func ParseRuleAssertion(s string) (RuleAssertion, error) {
+	m := assertionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return RuleAssertion{}, fmt.Errorf("%w: invalid assertion %q, expected e.g. \"rule-id>=1\"", ErrInvalidConfig, s)
+	}
+	n, err := strconv.ParseInt(m[3], 10, 64)
+	if err != nil {
+		return RuleAssertion{}, fmt.Errorf("%w: invalid assertion count %q", ErrInvalidConfig, m[3])
+	}
+
+	a := RuleAssertion{RuleID: RuleID(m[1])}
+	switch m[2] {
+	case ">=":
+		a.Min = n
+	case ">":
+		a.Min = n + 1
+	case "<=":
+		a.Max = &n
+	case "<":
+		if n == 0 {
+			return RuleAssertion{}, fmt.Errorf("%w: assertion %q can never pass", ErrInvalidConfig, s)
+		}
+		n--
+		a.Max = &n
+	default:
+		a.Min, a.Max = n, &n
+	}
+	return a, nil
+}
+
+// String 返回断言的可读形式
+func (a RuleAssertion) String() string {
+	switch {
+	case a.Max == nil:
+		return fmt.Sprintf("%s >= %d", a.RuleID, a.Min)
+	case *a.Max == a.Min:
+		return fmt.Sprintf("%s == %d", a.RuleID, a.Min)
+	case a.Min == 0:
+		return fmt.Sprintf("%s <= %d", a.RuleID, *a.Max)
+	default:
+		return fmt.Sprintf("%d <= %s <= %d", a.Min, a.RuleID, *a.Max)
+	}
+}
+
+// Check 判断匹配次数是否满足断言
+func (a RuleAssertion) Check(matched int64) bool {
+	return matched >= a.Min && (a.Max == nil || matched <= *a.Max)
+}
+
+// AssertionResult 单条断言的校验结果
+type AssertionResult struct {
+	Assertion RuleAssertion `json:"assertion"`
+	Matched   int64         `json:"matched"` // 规则实际匹配次数
+	Passed    bool          `json:"passed"`
+}
+
+// AssertionReport 断言校验报告
+type AssertionReport struct {
+	Passed  bool              `json:"passed"` // 全部断言均通过
+	Results []AssertionResult `json:"results"`
+}
+
+// CheckAssertions 按覆盖报告中的规则匹配次数校验断言，未启用或不存在的规则匹配次数为 0
+func CheckAssertions(cov CoverageReport, assertions []RuleAssertion) AssertionReport {
+	matched := make(map[RuleID]int64, len(cov.Rules))
+	for _, r := range cov.Rules {
+		matched[r.RuleID] = r.Matched
+	}
+	report := AssertionReport{Passed: true, Results: make([]AssertionResult, 0, len(assertions))}
+	for _, a := range assertions {
+		res := AssertionResult{Assertion: a, Matched: matched[a.RuleID]}
+		res.Passed = a.Check(res.Matched)
+		if !res.Passed {
+			report.Passed = false
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report
+}
@@ -0,0 +1,62 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestParseRuleAssertion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"login-mock>=1", "login-mock >= 1"},
+		{" login-mock > 1 ", "login-mock >= 2"},
+		{"block-analytics==0", "block-analytics == 0"},
+		{"block-analytics=0", "block-analytics == 0"},
+		{"retry<=3", "retry <= 3"},
+		{"retry<3", "retry <= 2"},
+	}
+	for _, tt := range tests {
+		a, err := domain.ParseRuleAssertion(tt.in)
+		if err != nil {
+			t.Errorf("ParseRuleAssertion(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got := a.String(); got != tt.want {
+			t.Errorf("ParseRuleAssertion(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "login-mock", ">=1", "login-mock>=x", "login-mock<0", "login-mock!=1"} {
+		if _, err := domain.ParseRuleAssertion(bad); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("ParseRuleAssertion(%q) error = %v, want ErrInvalidConfig", bad, err)
+		}
+	}
+}
+
+func TestCheckAssertions(t *testing.T) {
+	cov := domain.CoverageReport{Rules: []domain.RuleCoverage{
+		{RuleID: "login-mock", Matched: 2},
+		{RuleID: "block-analytics", Matched: 1},
+	}}
+	parse := func(s string) domain.RuleAssertion {
+		a, err := domain.ParseRuleAssertion(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	report := domain.CheckAssertions(cov, []domain.RuleAssertion{parse("login-mock>=1"), parse("missing==0")})
+	if !report.Passed || len(report.Results) != 2 || report.Results[0].Matched != 2 {
+		t.Errorf("got %+v, want all assertions passed", report)
+	}
+
+	report = domain.CheckAssertions(cov, []domain.RuleAssertion{parse("login-mock>=1"), parse("block-analytics==0")})
+	if report.Passed || !report.Results[0].Passed || report.Results[1].Passed {
+		t.Errorf("got %+v, want block-analytics assertion failed", report)
+	}
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultAuthTimeout 认证质询等待人工处理的默认时长，超时后交由浏览器默认处理
+const DefaultAuthTimeout = 2 * time.Minute
+
+// AuthSource 认证质询的来源
+type AuthSource string
+
+const (
+	AuthSourceServer AuthSource = "server" // 目标站点要求认证（401）
+	AuthSourceProxy  AuthSource = "proxy"  // 上游代理要求认证（407）
+)
+
+// AuthChallenge 等待人工提供凭据、取消或交由浏览器处理的认证质询
+type AuthChallenge struct {
+	ID           string     `json:"id"` // 发起质询的请求 ID
+	TargetID     TargetID   `json:"targetId"`
+	URL          string     `json:"url"`
+	Method       string     `json:"method"`
+	Source       AuthSource `json:"source"`
+	Origin       string     `json:"origin"`           // 发起质询的源
+	Scheme       string     `json:"scheme"`           // 认证方案，如 basic、digest、ntlm
+	Realm        string     `json:"realm"`            // 质询的领域
+	RuleID       string     `json:"ruleId,omitempty"` // 命中的 auth 规则
+	ChallengedAt int64      `json:"challengedAt"`     // 收到质询的时间（毫秒时间戳）
+	ExpiresAt    int64      `json:"expiresAt"`        // 超时交由浏览器处理的时间（毫秒时间戳）
+}
+
+// AuthResponse 对认证质询的应答方式
+type AuthResponse string
+
+const (
+	AuthProvide AuthResponse = "provide" // 以凭据应答
+	AuthCancel  AuthResponse = "cancel"  // 取消认证，页面收到 401/407 响应
+	AuthDefault AuthResponse = "default" // 交由浏览器默认处理，通常弹出认证对话框
+)
+
+// AuthDecision 人工对认证质询的处理
+type AuthDecision struct {
+	Response AuthResponse `json:"response"`
+	Username string       `json:"username,omitempty"` // 应答的用户名 (provide)，为空时使用规则中的凭据
+	Password string       `json:"password,omitempty"`
+}
+
+// Validate 校验应答方式
+func (d AuthDecision) Validate() error {
+	switch d.Response {
+	case AuthProvide, AuthCancel, AuthDefault:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown auth response %q", ErrInvalidConfig, d.Response)
+}
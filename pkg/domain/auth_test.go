@@ -0,0 +1,21 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestAuthDecision_Validate(t *testing.T) {
+	for _, r := range []domain.AuthResponse{domain.AuthProvide, domain.AuthCancel, domain.AuthDefault} {
+		if err := (domain.AuthDecision{Response: r}).Validate(); err != nil {
+			t.Errorf("Validate(%q) error = %v", r, err)
+		}
+	}
+	for _, r := range []domain.AuthResponse{"", "ProvideCredentials"} {
+		if err := (domain.AuthDecision{Response: r}).Validate(); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("Validate(%q) = %v, want ErrInvalidConfig", r, err)
+		}
+	}
+}
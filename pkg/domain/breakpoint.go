@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultHoldTimeout 断点暂停的请求等待人工处理的默认时长，超时后按规则自动放行
+const DefaultHoldTimeout = 5 * time.Minute
+
+// BreakpointStage 断点暂停请求的阶段
+type BreakpointStage string
+
+const (
+	BreakpointRequest  BreakpointStage = "request"  // 请求发出前暂停，可编辑整个请求
+	BreakpointResponse BreakpointStage = "response" // 收到响应后暂停，可编辑整个响应
+)
+
+// BreakpointFilter 一次性断点的匹配条件，字段为空表示不限制，全部为空时匹配下一个任意请求
+type BreakpointFilter struct {
+	URLContains string          `json:"urlContains,omitempty"` // URL 包含的子串
+	Method      string          `json:"method,omitempty"`      // HTTP 方法，忽略大小写
+	Stage       BreakpointStage `json:"stage,omitempty"`       // 暂停的阶段，为空时在请求阶段暂停
+	TimeoutMS   int64           `json:"timeoutMS,omitempty"`   // 暂停等待处理的最长时间，为 0 时使用 DefaultHoldTimeout
+}
+
+// HoldStage 返回断点暂停请求的阶段
+func (f BreakpointFilter) HoldStage() BreakpointStage {
+	if f.Stage == "" {
+		return BreakpointRequest
+	}
+	return f.Stage
+}
+
+// HoldTimeout 返回命中断点的请求最长等待时间
+func (f BreakpointFilter) HoldTimeout() time.Duration {
+	if f.TimeoutMS <= 0 {
+		return DefaultHoldTimeout
+	}
+	return time.Duration(f.TimeoutMS) * time.Millisecond
+}
+
+// Match 判断请求是否命中断点
+func (f BreakpointFilter) Match(url, method string) bool {
+	if f.URLContains != "" && !strings.Contains(url, f.URLContains) {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(f.Method, method) {
+		return false
+	}
+	return true
+}
+
+// HeldRequest 被断点暂停、等待人工放行或拒绝的请求，附带暂停时的完整快照供编辑后放行
+type HeldRequest struct {
+	ID          string          `json:"id"`
+	TargetID    TargetID        `json:"targetId"`
+	URL         string          `json:"url"`
+	Method      string          `json:"method"`
+	Stage       BreakpointStage `json:"stage"`
+	HeldAt      int64           `json:"heldAt"`             // 暂停时间（毫秒时间戳）
+	ExpiresAt   int64           `json:"expiresAt"`          // 超时自动放行的时间（毫秒时间戳）
+	RemainingMS int64           `json:"remainingMS"`        // 获取状态时距超时的剩余时间
+	Request     *Request        `json:"request,omitempty"`  // 请求的完整快照（头部、Cookie、请求体），请求体以 base64 序列化
+	Response    *Response       `json:"response,omitempty"` // 响应阶段暂停时响应的完整快照，压缩的响应体为解压后的内容
+}
+
+// BreakpointStatus 会话的断点状态
+type BreakpointStatus struct {
+	Armed  bool              `json:"armed"`
+	Filter *BreakpointFilter `json:"filter,omitempty"` // 已布置断点的匹配条件
+	Held   []HeldRequest     `json:"held"`             // 按暂停时间排序的待处理请求
+}
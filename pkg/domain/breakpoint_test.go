@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestBreakpointFilter_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter domain.BreakpointFilter
+		url    string
+		method string
+		want   bool
+	}{
+		{"空条件匹配任意请求", domain.BreakpointFilter{}, "https://example.com/a", "GET", true},
+		{"URL 包含", domain.BreakpointFilter{URLContains: "/api/"}, "https://example.com/api/user", "GET", true},
+		{"URL 不包含", domain.BreakpointFilter{URLContains: "/api/"}, "https://example.com/static/a.js", "GET", false},
+		{"方法忽略大小写", domain.BreakpointFilter{Method: "post"}, "https://example.com/a", "POST", true},
+		{"方法不符", domain.BreakpointFilter{Method: "POST"}, "https://example.com/a", "GET", false},
+		{"条件同时满足", domain.BreakpointFilter{URLContains: "/login", Method: "POST"}, "https://example.com/login", "POST", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.url, tt.method); got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.url, tt.method, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakpointFilter_HoldTimeout(t *testing.T) {
+	if got := (domain.BreakpointFilter{}).HoldTimeout(); got != domain.DefaultHoldTimeout {
+		t.Errorf("default timeout = %v, want %v", got, domain.DefaultHoldTimeout)
+	}
+	if got := (domain.BreakpointFilter{TimeoutMS: 1500}).HoldTimeout(); got != 1500*time.Millisecond {
+		t.Errorf("custom timeout = %v, want 1.5s", got)
+	}
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "fmt"
+
+// CapabilityProfile 能力配置档，限制会话内规则可执行的行为，防止共享的规则集在敏感环境中执行破坏性操作
+type CapabilityProfile string
+
+const (
+	CapabilityFull           CapabilityProfile = "full"           // 不限制
+	CapabilityNoBodyMutation CapabilityProfile = "noBodyMutation" // 禁止修改请求体与响应体
+	CapabilityNoBlock        CapabilityProfile = "noBlock"        // 禁止拦截请求或以伪造的失败响应应答
+	CapabilityMockOnly       CapabilityProfile = "mockOnly"       // 仅允许以伪造响应应答请求，真实请求与响应不被修改
+)
+
+// CapabilityProfiles 所有能力配置档
+var CapabilityProfiles = []CapabilityProfile{CapabilityFull, CapabilityNoBodyMutation, CapabilityNoBlock, CapabilityMockOnly}
+
+// ParseCapabilityProfile 解析能力配置档，空字符串视为不限制
+func ParseCapabilityProfile(s string) (CapabilityProfile, error) {
+	if s == "" {
+		return CapabilityFull, nil
+	}
+	for _, p := range CapabilityProfiles {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown capability profile %q", ErrInvalidConfig, s)
+}
@@ -0,0 +1,22 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestParseCapabilityProfile(t *testing.T) {
+	if p, err := domain.ParseCapabilityProfile(""); err != nil || p != domain.CapabilityFull {
+		t.Errorf("空字符串预期为 full，实际为 %q, %v", p, err)
+	}
+	for _, want := range domain.CapabilityProfiles {
+		if p, err := domain.ParseCapabilityProfile(string(want)); err != nil || p != want {
+			t.Errorf("解析 %q 得到 %q, %v", want, p, err)
+		}
+	}
+	if _, err := domain.ParseCapabilityProfile("readOnly"); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("未知配置档预期返回 ErrInvalidConfig，实际为 %v", err)
+	}
+}
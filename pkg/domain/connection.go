@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ConnectionOptions 连接 DevTools 端点的选项，用于经隧道、反向代理或 TLS 暴露的远程浏览器（如运行在 Docker、Kubernetes 中）。
+// 选项同时作用于 /json 系列 HTTP 请求与目标的 WebSocket 握手
+type ConnectionOptions struct {
+	Headers            map[string]string `json:"headers,omitempty"`            // 附加的请求头，如网关要求的令牌
+	Host               string            `json:"host,omitempty"`               // 覆盖 Host 请求头，如 localhost:9222，使浏览器接受经域名转发的连接
+	Username           string            `json:"username,omitempty"`           // Basic 认证用户名，为空表示无需认证
+	Password           string            `json:"password,omitempty"`           // Basic 认证密码
+	CAFile             string            `json:"caFile,omitempty"`             // 额外信任的 CA 证书文件（PEM），用于自签名证书的 https/wss 端点
+	ServerName         string            `json:"serverName,omitempty"`         // TLS 校验证书使用的主机名，为空时取端点地址中的主机
+	InsecureSkipVerify bool              `json:"insecureSkipVerify,omitempty"` // 不校验服务端证书，仅用于测试环境
+	Proxy              string            `json:"proxy,omitempty"`              // 连接端点使用的代理，支持 http:// 与 socks5://，为空表示直连
+}
+
+// connectionProxySchemes 连接 DevTools 端点时支持的代理协议（WebSocket 握手不支持 https 代理）
+var connectionProxySchemes = []string{"http", "socks5"}
+
+// IsZero 判断是否未设置任何选项
+func (c ConnectionOptions) IsZero() bool {
+	return len(c.Headers) == 0 && c.Host == "" && c.Username == "" && c.Password == "" &&
+		c.CAFile == "" && c.ServerName == "" && !c.InsecureSkipVerify && c.Proxy == ""
+}
+
+// Validate 校验请求头名称、Host、认证信息与代理地址
+func (c ConnectionOptions) Validate() error {
+	for name := range c.Headers {
+		if err := ValidateHeaderName(name); err != nil {
+			return err
+		}
+		if strings.EqualFold(name, "Host") {
+			return fmt.Errorf("%w: use host instead of a Host header", ErrInvalidConfig)
+		}
+		if c.Username != "" && strings.EqualFold(name, "Authorization") {
+			return fmt.Errorf("%w: Authorization header conflicts with username", ErrInvalidConfig)
+		}
+	}
+	if c.Host != "" {
+		if u, err := url.Parse("http://" + c.Host); err != nil || u.Host != c.Host || u.Hostname() == "" {
+			return fmt.Errorf("%w: invalid host %q", ErrInvalidConfig, c.Host)
+		}
+	}
+	if c.Username == "" && c.Password != "" {
+		return fmt.Errorf("%w: password set without username", ErrInvalidConfig)
+	}
+	if strings.Contains(c.Username, ":") {
+		return fmt.Errorf("%w: username must not contain ':'", ErrInvalidConfig)
+	}
+	if c.Proxy != "" {
+		if err := ValidateProxyServer(c.Proxy); err != nil {
+			return err
+		}
+		if u, _ := url.Parse(c.Proxy); !strings.Contains(c.Proxy, "://") || !slices.Contains(connectionProxySchemes, u.Scheme) {
+			return fmt.Errorf("%w: connection proxy %q must be http:// or socks5://", ErrInvalidConfig, c.Proxy)
+		}
+	}
+	return nil
+}
+
+// 重连策略的默认值
+const (
+	DefaultReconnectAttempts = 5
+	DefaultReconnectDelay    = 500 * time.Millisecond
+	DefaultReconnectMaxDelay = 30 * time.Second
+)
+
+// ReconnectPolicy 目标连接意外断开后的重连策略：每次失败后等待时间翻倍直到上限，实际等待在其一半到全部之间随机取值，
+// 避免多个目标同时重连。字段为 0 时使用默认值
+type ReconnectPolicy struct {
+	MaxAttempts    int `json:"maxAttempts,omitempty"`    // 最多尝试次数，默认 5，负数表示不重连
+	InitialDelayMS int `json:"initialDelayMS,omitempty"` // 首次尝试前的等待，默认 500ms
+	MaxDelayMS     int `json:"maxDelayMS,omitempty"`     // 单次等待的上限，默认 30s
+}
+
+// Validate 校验等待时间不为负数
+func (p ReconnectPolicy) Validate() error {
+	if p.InitialDelayMS < 0 || p.MaxDelayMS < 0 {
+		return fmt.Errorf("%w: reconnect delays must not be negative", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Attempts 返回最多尝试次数，不重连时为 0
+func (p ReconnectPolicy) Attempts() int {
+	switch {
+	case p.MaxAttempts < 0:
+		return 0
+	case p.MaxAttempts == 0:
+		return DefaultReconnectAttempts
+	}
+	return p.MaxAttempts
+}
+
+// Delay 返回第 attempt 次（从 1 开始）尝试前等待时间的上界
+func (p ReconnectPolicy) Delay(attempt int) time.Duration {
+	delay := DefaultReconnectDelay
+	if p.InitialDelayMS > 0 {
+		delay = time.Duration(p.InitialDelayMS) * time.Millisecond
+	}
+	limit := DefaultReconnectMaxDelay
+	if p.MaxDelayMS > 0 {
+		limit = time.Duration(p.MaxDelayMS) * time.Millisecond
+	}
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// ConnectionEventType 目标连接事件类型
+type ConnectionEventType string
+
+const (
+	ConnectionDisconnected    ConnectionEventType = "disconnected"    // 事件流意外断开，开始重连
+	ConnectionReconnected     ConnectionEventType = "reconnected"     // 已重新附着并恢复拦截
+	ConnectionReconnectFailed ConnectionEventType = "reconnectFailed" // 尝试次数用尽或目标已不存在，放弃重连
+)
+
+// ConnectionEvent 目标连接状态变化
+type ConnectionEvent struct {
+	Type           ConnectionEventType `json:"type"`
+	TargetID       TargetID            `json:"targetId"`                 // 重连后的目标，按 URL 重新找到目标时与 PreviousTarget 不同
+	PreviousTarget TargetID            `json:"previousTarget,omitempty"` // 断开的目标，与 TargetID 相同时为空
+	Attempts       int                 `json:"attempts,omitempty"`       // 已进行的尝试次数
+	Resumed        int                 `json:"resumed,omitempty"`        // 重连后补发放行的暂停请求数
+	Orphaned       int                 `json:"orphaned,omitempty"`       // 无法放行、只能等待浏览器超时的暂停请求数
+	Error          string              `json:"error,omitempty"`          // 放弃重连时最后一次失败的原因
+	Timestamp      int64               `json:"timestamp"`                // 毫秒时间戳
+}
@@ -0,0 +1,63 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestConnectionOptions_Validate(t *testing.T) {
+	valid := domain.ConnectionOptions{
+		Headers:  map[string]string{"X-Tunnel-Token": "t0k"},
+		Host:     "localhost:9222",
+		Username: "alice",
+		Password: "secret",
+		Proxy:    "socks5://127.0.0.1:1080",
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if valid.IsZero() || !(domain.ConnectionOptions{}).IsZero() {
+		t.Errorf("IsZero() 结果不符合预期")
+	}
+
+	for _, bad := range []domain.ConnectionOptions{
+		{Headers: map[string]string{"Bad Name": "v"}},
+		{Headers: map[string]string{"Host": "localhost"}},
+		{Headers: map[string]string{"Authorization": "Bearer x"}, Username: "alice"},
+		{Host: "localhost/path"},
+		{Host: ":9222"},
+		{Password: "secret"},
+		{Username: "a:b"},
+		{Proxy: "https://proxy:3128"},
+		{Proxy: "proxy:3128"},
+	} {
+		if err := bad.Validate(); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("Validate(%+v) 预期返回 ErrInvalidConfig，实际为 %v", bad, err)
+		}
+	}
+}
+
+func TestReconnectPolicy(t *testing.T) {
+	var def domain.ReconnectPolicy
+	if got := def.Attempts(); got != domain.DefaultReconnectAttempts {
+		t.Errorf("Attempts() = %d, want %d", got, domain.DefaultReconnectAttempts)
+	}
+	if got := (domain.ReconnectPolicy{MaxAttempts: -1}).Attempts(); got != 0 {
+		t.Errorf("Attempts() with negative MaxAttempts = %d, want 0", got)
+	}
+	for attempt, want := range map[int]time.Duration{1: 500 * time.Millisecond, 2: time.Second, 3: 2 * time.Second, 20: domain.DefaultReconnectMaxDelay} {
+		if got := def.Delay(attempt); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	p := domain.ReconnectPolicy{InitialDelayMS: 100, MaxDelayMS: 250}
+	if got := p.Delay(3); got != 250*time.Millisecond {
+		t.Errorf("Delay(3) = %v, want capped 250ms", got)
+	}
+	if err := (domain.ReconnectPolicy{MaxDelayMS: -1}).Validate(); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("Validate() = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CookieSameSite Cookie 的 SameSite 属性
+type CookieSameSite string
+
+const (
+	CookieSameSiteStrict CookieSameSite = "Strict"
+	CookieSameSiteLax    CookieSameSite = "Lax"
+	CookieSameSiteNone   CookieSameSite = "None"
+)
+
+// BrowserCookie 浏览器 Cookie 存储中的 Cookie
+type BrowserCookie struct {
+	Name     string         `json:"name"`
+	Value    string         `json:"value"`
+	URL      string         `json:"url,omitempty"`    // 写入时据此推导域名、路径与 Secure，读取时为空
+	Domain   string         `json:"domain,omitempty"` // 以 . 开头时对子域名同样生效
+	Path     string         `json:"path,omitempty"`
+	Expires  float64        `json:"expires,omitempty"` // 过期时间（秒级时间戳），为 0 时为会话 Cookie
+	HTTPOnly bool           `json:"httpOnly,omitempty"`
+	Secure   bool           `json:"secure,omitempty"`
+	SameSite CookieSameSite `json:"sameSite,omitempty"` // 为空时由浏览器决定
+	Session  bool           `json:"session,omitempty"`  // 是否为会话 Cookie，仅读取时有效
+}
+
+// Validate 校验写入浏览器的 Cookie：名称合法、URL 与域名至少设置其一，SameSite=None 必须同时设置 Secure
+func (c BrowserCookie) Validate() error {
+	if err := ValidateCookieName(c.Name); err != nil {
+		return err
+	}
+	if strings.ContainsAny(c.Value, ";\r\n") {
+		return fmt.Errorf("%w: cookie %s value must not contain ';' or line breaks", ErrInvalidConfig, c.Name)
+	}
+	if c.URL == "" && c.Domain == "" {
+		return fmt.Errorf("%w: cookie %s needs a url or domain", ErrInvalidConfig, c.Name)
+	}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: cookie %s url %q is not an absolute http(s) url", ErrInvalidConfig, c.Name, c.URL)
+		}
+	}
+	if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+		return fmt.Errorf("%w: cookie %s path must start with '/'", ErrInvalidConfig, c.Name)
+	}
+	if c.Expires < 0 {
+		return fmt.Errorf("%w: cookie %s expires must not be negative", ErrInvalidConfig, c.Name)
+	}
+	switch c.SameSite {
+	case "", CookieSameSiteStrict, CookieSameSiteLax:
+	case CookieSameSiteNone:
+		if !c.Secure && !strings.HasPrefix(c.URL, "https://") {
+			return fmt.Errorf("%w: cookie %s with SameSite=None must be secure", ErrInvalidConfig, c.Name)
+		}
+	default:
+		return fmt.Errorf("%w: cookie %s has unknown sameSite %q", ErrInvalidConfig, c.Name, c.SameSite)
+	}
+	return nil
+}
+
+// CookieFilter 删除浏览器 Cookie 的匹配条件，名称必填，其余条件为空时不限制
+type CookieFilter struct {
+	Name   string `json:"name"`
+	URL    string `json:"url,omitempty"` // 删除该 URL 可见的同名 Cookie
+	Domain string `json:"domain,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// Validate 校验删除条件
+func (f CookieFilter) Validate() error {
+	return ValidateCookieName(f.Name)
+}
+
+// ValidateCookieName 校验 Cookie 名称：不能为空，且不能包含 '='、';'、',' 或空白字符
+func ValidateCookieName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: cookie name is required", ErrInvalidConfig)
+	}
+	if strings.ContainsAny(name, "=;, \t\r\n") {
+		return fmt.Errorf("%w: cookie name %q contains invalid characters", ErrInvalidConfig, name)
+	}
+	return nil
+}
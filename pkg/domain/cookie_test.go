@@ -0,0 +1,49 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestBrowserCookie_Validate(t *testing.T) {
+	valid := []domain.BrowserCookie{
+		{Name: "sid", Value: "abc", URL: "https://example.com/app"},
+		{Name: "sid", Domain: ".example.com", Path: "/", HTTPOnly: true},
+		{Name: "sid", URL: "https://example.com", SameSite: domain.CookieSameSiteNone},
+		{Name: "sid", Domain: "example.com", SameSite: domain.CookieSameSiteNone, Secure: true, Expires: 1893456000},
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("%+v 应合法，实际为 %v", c, err)
+		}
+	}
+
+	invalid := []domain.BrowserCookie{
+		{Value: "abc", URL: "https://example.com"},
+		{Name: "a=b", URL: "https://example.com"},
+		{Name: "sid", Value: "a;b", URL: "https://example.com"},
+		{Name: "sid"},
+		{Name: "sid", URL: "/relative"},
+		{Name: "sid", URL: "ftp://example.com"},
+		{Name: "sid", Domain: "example.com", Path: "app"},
+		{Name: "sid", Domain: "example.com", Expires: -1},
+		{Name: "sid", URL: "http://example.com", SameSite: domain.CookieSameSiteNone},
+		{Name: "sid", Domain: "example.com", SameSite: "Loose"},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("%+v 预期返回 ErrInvalidConfig，实际为 %v", c, err)
+		}
+	}
+}
+
+func TestCookieFilter_Validate(t *testing.T) {
+	if err := (domain.CookieFilter{Name: "sid", Domain: "example.com"}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (domain.CookieFilter{URL: "https://example.com"}).Validate(); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("got %v, want ErrInvalidConfig", err)
+	}
+}
@@ -1,18 +1,23 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // 会话相关错误
 var (
 	ErrSessionNotFound    = errors.New("session not found")
 	ErrSessionAlreadyStop = errors.New("session already stopped")
 	ErrSessionStartFailed = errors.New("session start failed")
+	ErrSessionReadOnly    = errors.New("session is read-only")
 )
 
 // 目标相关错误
 var (
-	ErrNoTargetAttached = errors.New("no target attached")
-	ErrTargetNotFound   = errors.New("target not found")
+	ErrNoTargetAttached  = errors.New("no target attached")
+	ErrTargetNotFound    = errors.New("target not found")
+	ErrTargetNotAttached = errors.New("target not attached")
 )
 
 // 连接相关错误
@@ -28,14 +33,63 @@ var (
 	ErrConfigNotFound = errors.New("config not found")
 )
 
+// 规则相关错误
+var (
+	ErrRuleInvalid = errors.New("rule invalid")
+)
+
+// 事件流相关错误
+var (
+	ErrStreamClosed    = errors.New("event stream closed")
+	ErrStreamFull      = errors.New("event stream buffer full")
+	ErrUnknownDelivery = errors.New("unknown event delivery")
+	ErrEventNotFound   = errors.New("event not found")
+)
+
+// 断点相关错误
+var (
+	ErrRequestNotHeld = errors.New("request not held")
+)
+
+// 认证质询相关错误
+var (
+	ErrChallengeNotPending = errors.New("auth challenge not pending")
+)
+
 // 浏览器相关错误
 var (
 	ErrBrowserNotRunning  = errors.New("browser not running")
 	ErrBrowserStartFailed = errors.New("browser start failed")
 )
 
+// 设置相关错误
+var (
+	ErrInvalidSetting = errors.New("invalid setting")
+)
+
 // 数据库相关错误
 var (
 	ErrDatabaseNotInitialized = errors.New("database not initialized")
 	ErrRecordNotFound         = errors.New("record not found")
 )
+
+// RuleError 单条规则校验错误，可通过 errors.Is(err, ErrRuleInvalid) 判断
+type RuleError struct {
+	RuleID string // 出错的规则 ID
+	Err    error  // 具体原因
+}
+
+// Error 实现 error 接口
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("rule %q: %v", e.RuleID, e.Err)
+}
+
+// Unwrap 返回具体原因
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使 RuleError 始终匹配 ErrRuleInvalid
+func (e *RuleError) Is(target error) bool {
+	return target == ErrRuleInvalid
+}
@@ -0,0 +1,29 @@
+package domain_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestRuleError(t *testing.T) {
+	cause := errors.New("bad id")
+	err := fmt.Errorf("load: %w", &domain.RuleError{RuleID: "r1", Err: cause})
+
+	if !errors.Is(err, domain.ErrRuleInvalid) {
+		t.Error("RuleError should match ErrRuleInvalid")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("RuleError should unwrap to its cause")
+	}
+
+	var re *domain.RuleError
+	if !errors.As(err, &re) || re.RuleID != "r1" {
+		t.Errorf("errors.As got %v, want RuleID r1", re)
+	}
+	if errors.Is(err, domain.ErrInvalidConfig) {
+		t.Error("RuleError should not match ErrInvalidConfig")
+	}
+}
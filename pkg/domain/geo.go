@@ -0,0 +1,64 @@
+package domain
+
+import "fmt"
+
+// GeoLocation 地理位置
+type GeoLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Accuracy  float64 `json:"accuracy"` // 精度（米）
+}
+
+// Validate 校验经纬度与精度范围
+func (g GeoLocation) Validate() error {
+	if g.Latitude < -90 || g.Latitude > 90 {
+		return fmt.Errorf("%w: latitude %v out of range [-90, 90]", ErrInvalidConfig, g.Latitude)
+	}
+	if g.Longitude < -180 || g.Longitude > 180 {
+		return fmt.Errorf("%w: longitude %v out of range [-180, 180]", ErrInvalidConfig, g.Longitude)
+	}
+	if g.Accuracy < 0 {
+		return fmt.Errorf("%w: accuracy must not be negative", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// GeoPreset 命名地理位置预设
+type GeoPreset struct {
+	Name     string      `json:"name"`
+	Label    string      `json:"label"`
+	Location GeoLocation `json:"location"`
+}
+
+// geoPresets 内置预设，精度统一为 100 米
+var geoPresets = []GeoPreset{
+	{Name: "beijing", Label: "北京 / Beijing", Location: GeoLocation{Latitude: 39.9042, Longitude: 116.4074, Accuracy: 100}},
+	{Name: "shanghai", Label: "上海 / Shanghai", Location: GeoLocation{Latitude: 31.2304, Longitude: 121.4737, Accuracy: 100}},
+	{Name: "shenzhen", Label: "深圳 / Shenzhen", Location: GeoLocation{Latitude: 22.5431, Longitude: 114.0579, Accuracy: 100}},
+	{Name: "hongkong", Label: "香港 / Hong Kong", Location: GeoLocation{Latitude: 22.3193, Longitude: 114.1694, Accuracy: 100}},
+	{Name: "tokyo", Label: "东京 / Tokyo", Location: GeoLocation{Latitude: 35.6762, Longitude: 139.6503, Accuracy: 100}},
+	{Name: "singapore", Label: "新加坡 / Singapore", Location: GeoLocation{Latitude: 1.3521, Longitude: 103.8198, Accuracy: 100}},
+	{Name: "london", Label: "伦敦 / London", Location: GeoLocation{Latitude: 51.5074, Longitude: -0.1278, Accuracy: 100}},
+	{Name: "berlin", Label: "柏林 / Berlin", Location: GeoLocation{Latitude: 52.5200, Longitude: 13.4050, Accuracy: 100}},
+	{Name: "new-york", Label: "纽约 / New York", Location: GeoLocation{Latitude: 40.7128, Longitude: -74.0060, Accuracy: 100}},
+	{Name: "san-francisco", Label: "旧金山 / San Francisco", Location: GeoLocation{Latitude: 37.7749, Longitude: -122.4194, Accuracy: 100}},
+	{Name: "sydney", Label: "悉尼 / Sydney", Location: GeoLocation{Latitude: -33.8688, Longitude: 151.2093, Accuracy: 100}},
+	{Name: "sao-paulo", Label: "圣保罗 / São Paulo", Location: GeoLocation{Latitude: -23.5505, Longitude: -46.6333, Accuracy: 100}},
+}
+
+// GeoPresets 返回所有内置地理位置预设
+func GeoPresets() []GeoPreset {
+	presets := make([]GeoPreset, len(geoPresets))
+	copy(presets, geoPresets)
+	return presets
+}
+
+// LookupGeoPreset 按名称查找地理位置预设
+func LookupGeoPreset(name string) (GeoLocation, bool) {
+	for _, p := range geoPresets {
+		if p.Name == name {
+			return p.Location, true
+		}
+	}
+	return GeoLocation{}, false
+}
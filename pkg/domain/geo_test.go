@@ -0,0 +1,37 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestGeoLocation_Validate(t *testing.T) {
+	for _, p := range domain.GeoPresets() {
+		if err := p.Location.Validate(); err != nil {
+			t.Errorf("预设 %s 不合法: %v", p.Name, err)
+		}
+	}
+
+	invalid := []domain.GeoLocation{
+		{Latitude: 91},
+		{Longitude: -181},
+		{Accuracy: -1},
+	}
+	for _, loc := range invalid {
+		if err := loc.Validate(); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("%+v 预期返回 ErrInvalidConfig，实际为 %v", loc, err)
+		}
+	}
+}
+
+func TestLookupGeoPreset(t *testing.T) {
+	loc, ok := domain.LookupGeoPreset("tokyo")
+	if !ok || loc.Latitude != 35.6762 {
+		t.Errorf("got %+v %v, want tokyo preset", loc, ok)
+	}
+	if _, ok := domain.LookupGeoPreset("atlantis"); ok {
+		t.Error("未知预设不应命中")
+	}
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// HostMapping 主机映射：将请求的主机名解析到另一个 IP 或主机
+type HostMapping struct {
+	Host   string `json:"host"`   // 原主机名，支持 *.example.com 形式的通配
+	Target string `json:"target"` // 目标 IP 或主机，可带端口
+}
+
+// HostMappingMode 主机映射的生效方式
+type HostMappingMode string
+
+const (
+	HostMappingOff      HostMappingMode = "off"      // 不启用
+	HostMappingResolver HostMappingMode = "resolver" // 启动浏览器时通过 --host-resolver-rules 生效
+	HostMappingRewrite  HostMappingMode = "rewrite"  // 会话内改写请求 URL 并保留原 Host 头
+)
+
+// ParseHostMappings 解析主机映射表，每行一条 "主机名 目标"，# 开头为注释
+func ParseHostMappings(text string) ([]HostMapping, error) {
+	var mappings []HostMapping
+	for i, line := range strings.Split(text, "\n") {
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"host target\", got %q", i+1, strings.TrimSpace(line))
+		}
+		m := HostMapping{Host: strings.ToLower(fields[0]), Target: fields[1]}
+		if err := m.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, nil
+}
+
+// Validate 校验单条映射
+func (m HostMapping) Validate() error {
+	host := strings.TrimPrefix(m.Host, "*.")
+	if host == "" || strings.ContainsAny(host, "/:*") {
+		return fmt.Errorf("invalid host %q", m.Host)
+	}
+	target := m.Target
+	if h, port, err := net.SplitHostPort(target); err == nil {
+		if port == "" {
+			return fmt.Errorf("invalid target %q", m.Target)
+		}
+		target = h
+	}
+	if target == "" || strings.ContainsAny(target, "/*") {
+		return fmt.Errorf("invalid target %q", m.Target)
+	}
+	return nil
+}
+
+// Match 判断主机名是否命中该映射
+func (m HostMapping) Match(host string) bool {
+	host = strings.ToLower(host)
+	if suffix, ok := strings.CutPrefix(m.Host, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == m.Host
+}
+
+// ResolverRules 生成 Chromium --host-resolver-rules 参数值
+func ResolverRules(mappings []HostMapping) string {
+	rules := make([]string, len(mappings))
+	for i, m := range mappings {
+		rules[i] = fmt.Sprintf("MAP %s %s", m.Host, bracketIPv6(m.Target))
+	}
+	return strings.Join(rules, ",")
+}
+
+// MapURL 按映射表改写 URL 的主机部分，返回新 URL 与原始 Host 头的值；未命中时 ok 为 false
+func MapURL(mappings []HostMapping, rawURL string) (mapped, host string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL, "", false
+	}
+	for _, m := range mappings {
+		if !m.Match(u.Hostname()) {
+			continue
+		}
+		host = u.Host
+		if _, _, err := net.SplitHostPort(m.Target); err == nil {
+			u.Host = m.Target
+		} else if port := u.Port(); port != "" {
+			u.Host = net.JoinHostPort(m.Target, port)
+		} else {
+			u.Host = bracketIPv6(m.Target)
+		}
+		return u.String(), host, true
+	}
+	return rawURL, "", false
+}
+
+// bracketIPv6 为不带端口的 IPv6 地址加上方括号
+func bracketIPv6(target string) string {
+	if ip := net.ParseIP(target); ip != nil && ip.To4() == nil {
+		return "[" + target + "]"
+	}
+	return target
+}
@@ -0,0 +1,65 @@
+package domain_test
+
+import (
+	"testing"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestParseHostMappings(t *testing.T) {
+	text := `
+# 本地联调
+api.example.com   127.0.0.1
+*.cdn.example.com localhost:8080  # 静态资源
+`
+	mappings, err := domain.ParseHostMappings(text)
+	if err != nil {
+		t.Fatalf("ParseHostMappings() error = %v", err)
+	}
+	if len(mappings) != 2 {
+		t.Fatalf("got %d mappings, want 2", len(mappings))
+	}
+	if mappings[1].Host != "*.cdn.example.com" || mappings[1].Target != "localhost:8080" {
+		t.Errorf("unexpected mapping %+v", mappings[1])
+	}
+
+	for _, bad := range []string{"api.example.com", "a b c", "https://a.com 1.2.3.4", "a.com 1.2.3.4/8"} {
+		if _, err := domain.ParseHostMappings(bad); err == nil {
+			t.Errorf("ParseHostMappings(%q) 应返回错误", bad)
+		}
+	}
+}
+
+func TestResolverRules(t *testing.T) {
+	got := domain.ResolverRules([]domain.HostMapping{
+		{Host: "api.example.com", Target: "127.0.0.1"},
+		{Host: "v6.example.com", Target: "::1"},
+	})
+	want := "MAP api.example.com 127.0.0.1,MAP v6.example.com [::1]"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMapURL(t *testing.T) {
+	mappings := []domain.HostMapping{
+		{Host: "api.example.com", Target: "127.0.0.1"},
+		{Host: "*.cdn.example.com", Target: "localhost:8080"},
+	}
+	tests := []struct {
+		url, want, host string
+		ok              bool
+	}{
+		{"https://api.example.com/v1?a=1", "https://127.0.0.1/v1?a=1", "api.example.com", true},
+		{"http://API.example.com:3000/", "http://127.0.0.1:3000/", "API.example.com:3000", true},
+		{"https://img.cdn.example.com/a.png", "https://localhost:8080/a.png", "img.cdn.example.com", true},
+		{"https://cdn.example.com/a.png", "https://cdn.example.com/a.png", "", false},
+		{"https://other.com/", "https://other.com/", "", false},
+	}
+	for _, tt := range tests {
+		got, host, ok := domain.MapURL(mappings, tt.url)
+		if got != tt.want || host != tt.host || ok != tt.ok {
+			t.Errorf("MapURL(%q) = %q, %q, %v; want %q, %q, %v", tt.url, got, host, ok, tt.want, tt.host, tt.ok)
+		}
+	}
+}
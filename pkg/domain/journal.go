@@ -0,0 +1,21 @@
+package domain
+
+// DecisionEntry 决策日志中的一条记录，描述一次拦截决策及其下发结果
+type DecisionEntry struct {
+	Time        int64     `json:"time"` // 记录时间（毫秒时间戳）
+	Session     SessionID `json:"session"`
+	Target      TargetID  `json:"target"`
+	RequestID   string    `json:"requestId"`
+	Stage       string    `json:"stage"` // request / response
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	Fingerprint string    `json:"fingerprint"`           // 请求指纹：方法、URL 与请求体的摘要
+	Rules       []string  `json:"rules,omitempty"`       // 产生该决策的规则
+	Action      string    `json:"action"`                // pass / modify / block / fail / coalesce / replayCache / switchRules
+	Call        string    `json:"call"`                  // 下发的 CDP 方法，降级时为失败的那次调用
+	Error       string    `json:"error,omitempty"`       // 下发或处理失败的错误信息
+	Degraded    bool      `json:"degraded,omitempty"`    // 是否因失败降级放行
+	HeadersOnly bool      `json:"headersOnly,omitempty"` // 响应阶段是否未获取响应体
+	Streamed    bool      `json:"streamed,omitempty"`    // 响应阶段是否以流方式分块读取响应体
+	Config      string    `json:"config,omitempty"`      // 定时切换后生效的规则集 ID，仅 switchRules 记录
+}
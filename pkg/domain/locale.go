@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var (
+	// timezonePattern IANA 时区 ID，如 UTC、Asia/Shanghai、America/Argentina/Buenos_Aires、Etc/GMT+8
+	timezonePattern = regexp.MustCompile(`^(UTC|GMT|[A-Za-z]+(/[A-Za-z0-9_+\-]+)+)$`)
+	// localePattern BCP 47 语言标签，如 zh-CN、en-US、sr-Latn-RS
+	localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+)
+
+// ValidateTimezoneID 校验 IANA 时区 ID 格式，空字符串表示清除覆盖
+func ValidateTimezoneID(id string) error {
+	if id != "" && !timezonePattern.MatchString(id) {
+		return fmt.Errorf("%w: invalid timezone %q", ErrInvalidConfig, id)
+	}
+	return nil
+}
+
+// ValidateLocale 校验 BCP 47 语言标签格式，空字符串表示清除覆盖
+func ValidateLocale(locale string) error {
+	if locale != "" && !localePattern.MatchString(locale) {
+		return fmt.Errorf("%w: invalid locale %q", ErrInvalidConfig, locale)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestValidateTimezoneID(t *testing.T) {
+	for _, id := range []string{"", "UTC", "Asia/Shanghai", "America/Argentina/Buenos_Aires", "Etc/GMT+8"} {
+		if err := domain.ValidateTimezoneID(id); err != nil {
+			t.Errorf("ValidateTimezoneID(%q) error = %v", id, err)
+		}
+	}
+	for _, id := range []string{"Shanghai", "Asia/", "+08:00", "Asia/Shang hai"} {
+		if err := domain.ValidateTimezoneID(id); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("ValidateTimezoneID(%q) 预期返回 ErrInvalidConfig，实际为 %v", id, err)
+		}
+	}
+}
+
+func TestValidateLocale(t *testing.T) {
+	for _, locale := range []string{"", "en", "zh-CN", "sr-Latn-RS", "en_US"} {
+		if err := domain.ValidateLocale(locale); err != nil {
+			t.Errorf("ValidateLocale(%q) error = %v", locale, err)
+		}
+	}
+	for _, locale := range []string{"e", "zh CN", "english-language-tag-x", "12-US"} {
+		if err := domain.ValidateLocale(locale); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("ValidateLocale(%q) 预期返回 ErrInvalidConfig，实际为 %v", locale, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// NetworkConditions 浏览器网络条件模拟，通过 Network.emulateNetworkConditions 生效，
+// 作用于目标的全部请求（含未被拦截的请求与 WebSocket），与 SessionConfig.BandwidthLimit 的拦截链路节流相互独立
+type NetworkConditions struct {
+	Offline            bool    `json:"offline,omitempty"`            // 模拟断网，所有请求失败
+	LatencyMS          float64 `json:"latencyMS,omitempty"`          // 请求发出到收到响应头的最小延迟（毫秒）
+	DownloadThroughput float64 `json:"downloadThroughput,omitempty"` // 下行吞吐上限（字节/秒），0 表示不限制
+	UploadThroughput   float64 `json:"uploadThroughput,omitempty"`   // 上行吞吐上限（字节/秒），0 表示不限制
+}
+
+// Validate 校验延迟与吞吐量不为负数
+func (c NetworkConditions) Validate() error {
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"latencyMS", c.LatencyMS},
+		{"downloadThroughput", c.DownloadThroughput},
+		{"uploadThroughput", c.UploadThroughput},
+	} {
+		if f.value < 0 || math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+			return fmt.Errorf("%w: %s must be a non-negative number", ErrInvalidConfig, f.name)
+		}
+	}
+	return nil
+}
+
+// NetworkPreset 命名网络条件预设
+type NetworkPreset struct {
+	Name       string            `json:"name"`
+	Label      string            `json:"label"`
+	Conditions NetworkConditions `json:"conditions"`
+}
+
+// networkPresets 内置预设，数值与 Chrome DevTools 的同名节流档一致
+var networkPresets = []NetworkPreset{
+	{Name: "offline", Label: "离线 / Offline", Conditions: NetworkConditions{Offline: true}},
+	{Name: "slow-3g", Label: "慢速 3G / Slow 3G", Conditions: NetworkConditions{LatencyMS: 2000, DownloadThroughput: 50000, UploadThroughput: 50000}},
+	{Name: "fast-3g", Label: "快速 3G / Fast 3G", Conditions: NetworkConditions{LatencyMS: 562.5, DownloadThroughput: 180000, UploadThroughput: 84375}},
+	{Name: "4g", Label: "4G", Conditions: NetworkConditions{LatencyMS: 60, DownloadThroughput: 1125000, UploadThroughput: 1125000}},
+}
+
+// NetworkPresets 返回所有内置网络条件预设
+func NetworkPresets() []NetworkPreset {
+	presets := make([]NetworkPreset, len(networkPresets))
+	copy(presets, networkPresets)
+	return presets
+}
+
+// LookupNetworkPreset 按名称查找网络条件预设
+func LookupNetworkPreset(name string) (NetworkConditions, bool) {
+	for _, p := range networkPresets {
+		if p.Name == name {
+			return p.Conditions, true
+		}
+	}
+	return NetworkConditions{}, false
+}
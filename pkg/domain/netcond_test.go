@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestNetworkConditions_Validate(t *testing.T) {
+	for _, p := range domain.NetworkPresets() {
+		if err := p.Conditions.Validate(); err != nil {
+			t.Errorf("预设 %s 不合法: %v", p.Name, err)
+		}
+	}
+
+	invalid := []domain.NetworkConditions{
+		{LatencyMS: -1},
+		{DownloadThroughput: -1},
+		{UploadThroughput: math.NaN()},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("%+v 预期返回 ErrInvalidConfig，实际为 %v", c, err)
+		}
+	}
+}
+
+func TestLookupNetworkPreset(t *testing.T) {
+	c, ok := domain.LookupNetworkPreset("slow-3g")
+	if !ok || c.LatencyMS != 2000 {
+		t.Errorf("got %+v %v, want slow-3g preset", c, ok)
+	}
+	if c, ok := domain.LookupNetworkPreset("offline"); !ok || !c.Offline {
+		t.Errorf("got %+v %v, want offline preset", c, ok)
+	}
+	if _, ok := domain.LookupNetworkPreset("5g"); ok {
+		t.Error("未知预设不应命中")
+	}
+}
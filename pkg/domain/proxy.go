@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ProxyCredentials 上游代理认证凭据
+type ProxyCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ProxyConfig 浏览器上游代理配置
+type ProxyConfig struct {
+	Server   string   `json:"server"`   // 代理地址，如 http://127.0.0.1:8888、socks5://host:1080，为空表示直连
+	Bypass   []string `json:"bypass"`   // 不经过代理的主机，如 localhost、*.internal
+	Username string   `json:"username"` // 代理认证用户名，为空表示无需认证
+	Password string   `json:"password"`
+}
+
+// proxySchemes Chromium --proxy-server 支持的代理协议
+var proxySchemes = []string{"http", "https", "socks4", "socks5"}
+
+// Validate 校验代理配置，Server 为空时视为未配置代理
+func (p ProxyConfig) Validate() error {
+	if p.Server == "" {
+		return nil
+	}
+	if err := ValidateProxyServer(p.Server); err != nil {
+		return err
+	}
+	for _, b := range p.Bypass {
+		if strings.TrimSpace(b) == "" || strings.ContainsAny(b, " ;,") {
+			return fmt.Errorf("%w: invalid proxy bypass entry %q", ErrInvalidConfig, b)
+		}
+	}
+	if p.Username == "" && p.Password != "" {
+		return fmt.Errorf("%w: proxy password set without username", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Credentials 返回代理认证凭据，未配置代理或用户名时返回 nil
+func (p ProxyConfig) Credentials() *ProxyCredentials {
+	if p.Server == "" || p.Username == "" {
+		return nil
+	}
+	return &ProxyCredentials{Username: p.Username, Password: p.Password}
+}
+
+// ValidateProxyServer 校验代理地址格式：协议://主机:端口，协议省略时按 http 处理；凭据不能写在地址中
+func ValidateProxyServer(server string) error {
+	if server == "" {
+		return nil
+	}
+	raw := server
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: invalid proxy server %q", ErrInvalidConfig, server)
+	}
+	known := false
+	for _, s := range proxySchemes {
+		known = known || u.Scheme == s
+	}
+	if !known {
+		return fmt.Errorf("%w: unsupported proxy scheme %q", ErrInvalidConfig, u.Scheme)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: proxy credentials must not be embedded in the server address", ErrInvalidConfig)
+	}
+	if _, port, err := net.SplitHostPort(u.Host); err != nil || port == "" || u.Hostname() == "" {
+		return fmt.Errorf("%w: proxy server %q must be host:port", ErrInvalidConfig, server)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return fmt.Errorf("%w: proxy server %q must not contain a path", ErrInvalidConfig, server)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package domain
+
+// ReplayMode 重放请求的发送方式
+type ReplayMode string
+
+const (
+	ReplayPage   ReplayMode = "page"   // 在目标页面中以 fetch 发出，携带页面的 Cookie 与凭据，并经过会话的拦截规则
+	ReplayNative ReplayMode = "native" // 由本程序以 HTTP 客户端直接发出，不经过浏览器，请求头原样发送
+)
+
+// ReplayResult 重放产生的事件的 FinalResult
+const ReplayResult = "replayed"
+
+// ReplayOptions 重放请求的选项
+type ReplayOptions struct {
+	EventID string     `json:"eventId,omitempty"` // 要重放的事件 ID，需仍在会话的事件缓冲中
+	Request *Request   `json:"request,omitempty"` // 非空时代替原请求发出，用于重放修改后的请求，此时 EventID 可为空
+	Mode    ReplayMode `json:"mode,omitempty"`    // 为空时使用 ReplayPage
+	Target  TargetID   `json:"target,omitempty"`  // ReplayPage 方式发出请求的目标，为空时使用原事件的目标
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReplayCacheMode 录制回放缓存的工作方式
+type ReplayCacheMode string
+
+const (
+	ReplayCacheOff     ReplayCacheMode = ""        // 关闭，已录制的响应仍保留，重新开启后可继续使用
+	ReplayCacheRecord  ReplayCacheMode = "record"  // 请求照常发出，录制浏览器最终收到的响应，覆盖相同匹配键的旧记录
+	ReplayCacheReplay  ReplayCacheMode = "replay"  // 命中记录时以录制的响应应答，未命中时照常发出并录制
+	ReplayCacheOffline ReplayCacheMode = "offline" // 命中记录时以录制的响应应答，未命中时以网络错误结束，请求不发往服务器
+)
+
+// ReplayCacheOptions 录制回放缓存的配置，匹配键由方法、URL、请求体与 KeyHeaders 中的请求头计算
+type ReplayCacheOptions struct {
+	Mode        ReplayCacheMode `json:"mode"`                  // 工作方式，为空表示关闭
+	Dir         string          `json:"dir,omitempty"`         // 录制保存目录，每条记录一个 JSON 文件，可跨会话复用；为空时只保存在内存中，会话结束后丢弃
+	TTLMS       int64           `json:"ttlMS,omitempty"`       // 记录的有效期（毫秒），过期的记录视为未命中，0 表示不过期
+	IgnoreQuery []string        `json:"ignoreQuery,omitempty"` // 计算匹配键时忽略的查询参数，如时间戳与随机数
+	IgnoreBody  bool            `json:"ignoreBody,omitempty"`  // 计算匹配键时不区分请求体
+	KeyHeaders  []string        `json:"keyHeaders,omitempty"`  // 参与计算匹配键的请求头，如 Accept-Language，名称不区分大小写
+}
+
+// Validate 校验工作方式与有效期
+func (o ReplayCacheOptions) Validate() error {
+	switch o.Mode {
+	case ReplayCacheOff, ReplayCacheRecord, ReplayCacheReplay, ReplayCacheOffline:
+	default:
+		return fmt.Errorf("%w: unknown replay cache mode %q", ErrInvalidConfig, o.Mode)
+	}
+	if o.TTLMS < 0 {
+		return fmt.Errorf("%w: ttlMS must not be negative", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// TTL 返回记录的有效期，0 表示不过期
+func (o ReplayCacheOptions) TTL() time.Duration {
+	return time.Duration(o.TTLMS) * time.Millisecond
+}
+
+// Serves 判断是否以录制的响应应答命中的请求
+func (o ReplayCacheOptions) Serves() bool {
+	return o.Mode == ReplayCacheReplay || o.Mode == ReplayCacheOffline
+}
+
+// ReplayCacheStatus 录制回放缓存的状态
+type ReplayCacheStatus struct {
+	Options ReplayCacheOptions `json:"options"` // 当前配置
+	Entries int                `json:"entries"` // 已录制的记录数，含保存目录中以往会话的记录
+	Hits    int64              `json:"hits"`    // 以录制的响应应答的请求数
+	Misses  int64              `json:"misses"`  // 回放时未命中的请求数
+	Stored  int64              `json:"stored"`  // 本会话录制的响应数
+}
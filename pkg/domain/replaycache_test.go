@@ -0,0 +1,36 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+func TestReplayCacheOptions_Validate(t *testing.T) {
+	valid := []domain.ReplayCacheOptions{
+		{},
+		{Mode: domain.ReplayCacheRecord},
+		{Mode: domain.ReplayCacheOffline, TTLMS: 60000},
+	}
+	for _, o := range valid {
+		if err := o.Validate(); err != nil {
+			t.Errorf("%+v 不应报错: %v", o, err)
+		}
+	}
+	invalid := []domain.ReplayCacheOptions{
+		{Mode: "rewind"},
+		{Mode: domain.ReplayCacheReplay, TTLMS: -1},
+	}
+	for _, o := range invalid {
+		if err := o.Validate(); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("%+v 预期返回 ErrInvalidConfig，实际为 %v", o, err)
+		}
+	}
+
+	o := domain.ReplayCacheOptions{Mode: domain.ReplayCacheRecord, TTLMS: 1500}
+	if o.Serves() || o.TTL() != 1500*time.Millisecond {
+		t.Errorf("got serves=%v ttl=%v, want record-only with 1.5s TTL", o.Serves(), o.TTL())
+	}
+}
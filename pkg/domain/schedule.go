@@ -0,0 +1,18 @@
+package domain
+
+// RuleSwitch 一次按定时计划进行的规则集切换
+type RuleSwitch struct {
+	Time       int64  `json:"time"`             // 切换时间（毫秒时间戳）
+	Window     string `json:"window,omitempty"` // 进入的时间窗口名称，为空表示回到默认规则集
+	ConfigID   string `json:"configId"`         // 切换后生效的规则集 ID
+	ConfigName string `json:"configName"`       // 切换后生效的规则集名称
+}
+
+// ScheduleStatus 规则集定时切换计划的状态
+type ScheduleStatus struct {
+	Scheduled    bool         `json:"scheduled"`              // 是否设置了定时计划
+	ActiveWindow string       `json:"activeWindow,omitempty"` // 当前所在的时间窗口名称，为空表示默认规则集
+	ActiveConfig string       `json:"activeConfig,omitempty"` // 当前生效的规则集 ID
+	NextSwitch   int64        `json:"nextSwitch,omitempty"`   // 下一次检查切换的时间（毫秒时间戳）
+	Switches     []RuleSwitch `json:"switches"`               // 会话内的切换记录，按时间先后排列
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "context"
+
+// EventStreamOptions 确认式事件流配置
+type EventStreamOptions struct {
+	BufferSize   int `json:"bufferSize"`   // 缓冲容量（待投递 + 待确认），<=0 时使用默认值
+	AckTimeoutMS int `json:"ackTimeoutMS"` // 投递后未确认的超时时间，超时后重新投递，<=0 表示不超时
+}
+
+// EventDelivery 一次事件投递
+type EventDelivery struct {
+	Seq      uint64       `json:"seq"`      // 投递序号，用于 Ack/Nack
+	Attempts int          `json:"attempts"` // 第几次投递（从 1 开始）
+	Event    NetworkEvent `json:"event"`
+}
+
+// EventStreamStats 事件流统计信息
+type EventStreamStats struct {
+	Pending   int   `json:"pending"`   // 等待投递的事件数
+	InFlight  int   `json:"inFlight"`  // 已投递未确认的事件数
+	Delivered int64 `json:"delivered"` // 累计投递次数（含重投）
+	Acked     int64 `json:"acked"`     // 累计确认数
+	Dropped   int64 `json:"dropped"`   // 因缓冲已满被拒绝的事件数
+}
+
+// EventIterator 拉取式事件迭代器，提供至少一次（at-least-once）投递语义
+//
+// 每个通过 Next 取得的事件都必须 Ack 或 Nack；Nack 或确认超时的事件会被重新投递。
+// 缓冲区满时新事件会被拒绝并计入 Dropped，而不会静默丢失。
+type EventIterator interface {
+	// Next 阻塞等待下一个事件，ctx 取消或迭代器关闭时返回错误
+	Next(ctx context.Context) (EventDelivery, error)
+
+	// Ack 确认事件已被处理
+	Ack(seq uint64) error
+
+	// Nack 拒绝事件，事件将被重新投递
+	Nack(seq uint64) error
+
+	// Stats 返回统计信息
+	Stats() EventStreamStats
+
+	// Close 关闭迭代器，未确认的事件将被丢弃
+	Close() error
+}
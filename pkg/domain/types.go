@@ -1,7 +1,9 @@
 package domain
 
 import (
+	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 )
 
@@ -31,6 +33,40 @@ const (
 	ResourceTypeOther      ResourceType = "other"      // 其他未分类类型（包含所有特殊类型）
 )
 
+// resourceTypes 所有资源类型
+var resourceTypes = []ResourceType{
+	ResourceTypeDocument, ResourceTypeStylesheet, ResourceTypeImage, ResourceTypeMedia, ResourceTypeFont,
+	ResourceTypeScript, ResourceTypeXHR, ResourceTypeFetch, ResourceTypeWebSocket, ResourceTypeOther,
+}
+
+// ParseResourceTypes 解析以逗号、空白或换行分隔的资源类型列表，不区分大小写
+func ParseResourceTypes(text string) ([]ResourceType, error) {
+	var types []ResourceType
+	for _, f := range strings.FieldsFunc(text, func(c rune) bool { return c == ',' || c == ' ' || c == '\t' || c == '\n' }) {
+		types = append(types, ResourceType(strings.ToLower(f)))
+	}
+	if err := ValidateResourceTypes(types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// ValidateResourceTypes 校验资源类型名称
+func ValidateResourceTypes(types []ResourceType) error {
+	for _, t := range types {
+		if !slices.Contains(resourceTypes, t) {
+			return fmt.Errorf("%w: unknown resource type %q", ErrInvalidConfig, t)
+		}
+	}
+	return nil
+}
+
+// InterceptPattern 浏览器暂停请求的范围，不在任何范围内的请求不经过拦截直接发出
+type InterceptPattern struct {
+	URLPattern   string       `json:"urlPattern"`             // URL 通配模式：* 匹配任意字符，? 匹配单个字符，\ 转义
+	ResourceType ResourceType `json:"resourceType,omitempty"` // 资源类型，为空时不限
+}
+
 // SessionConfig 会话配置
 type SessionConfig struct {
 	DevToolsURL       string `json:"devToolsURL"`
@@ -38,6 +74,69 @@ type SessionConfig struct {
 	BodySizeThreshold int64  `json:"bodySizeThreshold"`
 	PendingCapacity   int    `json:"pendingCapacity"`
 	ProcessTimeoutMS  int    `json:"processTimeoutMS"`
+
+	Connection *ConnectionOptions `json:"connection,omitempty"` // 连接 DevTools 端点的选项（请求头、Host 改写、Basic 认证、TLS、代理），为 nil 时直连
+	Reconnect  *ReconnectPolicy   `json:"reconnect,omitempty"`  // 目标连接意外断开后的重连策略，为 nil 时使用默认策略
+
+	HostMappings []HostMapping     `json:"hostMappings,omitempty"` // 以 URL 改写方式生效的主机映射
+	UserAgent    string            `json:"userAgent,omitempty"`    // 会话级 User-Agent 覆盖，预设名或自定义字符串
+	Geolocation  *GeoLocation      `json:"geolocation,omitempty"`  // 会话级地理位置覆盖
+	ProxyAuth    *ProxyCredentials `json:"proxyAuth,omitempty"`    // 上游代理认证凭据，响应代理发起的认证质询
+	DisableCache bool              `json:"disableCache,omitempty"` // 禁用浏览器 HTTP 缓存，避免请求直接命中缓存而不经过拦截
+
+	NetworkConditions *NetworkConditions `json:"networkConditions,omitempty"` // 会话级网络条件模拟（断网、延迟、吞吐），为 nil 时不模拟
+
+	CorrelationHeader string `json:"correlationHeader,omitempty"` // 为每个被拦截的请求注入关联 ID 的请求头，为空时不注入
+
+	UnmatchedSampling int `json:"unmatchedSampling,omitempty"` // 全量流量中未匹配事件的推送采样：0 或 1 全部推送，N 每 N 个推送 1 个，负数不推送
+
+	JournalDir string `json:"journalDir,omitempty"` // 决策日志目录，每个会话写入 decisions-<会话ID>.jsonl，为空时不记录
+
+	MaxDecodedSize int64 `json:"maxDecodedSize,omitempty"` // 压缩响应体解压后的大小上限（字节），超过时不解压，规则按原始字节处理；原生方式重放的响应同样受此限制，0 表示不限制
+
+	RecompressBody bool `json:"recompressBody,omitempty"` // 以 gzip、deflate 或 br 压缩的响应体解压后交给规则处理，修改后按原编码重新压缩；为 false 时移除 Content-Encoding 以明文应答
+
+	BodyChunkSize int `json:"bodyChunkSize,omitempty"` // 响应体分块大小（字节）：大于 0 时以 Fetch.takeResponseBodyAsStream 与 IO.read 分块读取需要处理的响应体，0 表示以 GetResponseBody 一次性获取
+
+	BandwidthLimit int `json:"bandwidthLimit,omitempty"` // 会话级带宽上限（字节/秒）：被拦截请求的请求体与响应体共享一条按该速率传输的模拟链路，依次延迟放行，0 表示不限制
+
+	CoalesceWindowMS int `json:"coalesceWindowMS,omitempty"` // 请求合并窗口：首个请求发出后该时长内的相同请求（方法、URL 与请求体相同）暂停并以首个请求的响应应答，0 表示不合并
+
+	ReadOnly bool `json:"readOnly,omitempty"` // 只读观察模式：所有请求原样放行，规则、主机映射、关联 ID 注入、User-Agent 覆盖与请求合并均不生效，仅记录流量
+
+	CapabilityProfile CapabilityProfile `json:"capabilityProfile,omitempty"` // 能力配置档：加载规则时拒绝、执行时跳过不被允许的行为，为空时不限制
+
+	IncludeResourceTypes []ResourceType `json:"includeResourceTypes,omitempty"` // 只拦截这些资源类型的请求，为空时不限；按浏览器上报的类型判断，不按 URL 扩展名推断
+	ExcludeResourceTypes []ResourceType `json:"excludeResourceTypes,omitempty"` // 不拦截的资源类型，如 image、font、media，这些请求直接发出，不经过规则、断点与流量捕获
+
+	CaptureTiming bool `json:"captureTiming,omitempty"` // 是否订阅 Network 域的加载事件，为响应事件补充 DNS、连接、首字节与传输耗时及传输大小；开启后事件在加载完成后才推送
+
+	GRPCDescriptorSet string `json:"grpcDescriptorSet,omitempty"` // gRPC-web 解码使用的 FileDescriptorSet 文件路径，为空时按线格式解码
+
+	SecretDetectors []string         `json:"secretDetectors,omitempty"` // 启用的敏感信息检测器，为空时不检测
+	Redaction       *RedactionConfig `json:"redaction,omitempty"`       // 事件写入 HAR 等导出文件前的脱敏配置
+
+	Assertions []RuleAssertion `json:"assertions,omitempty"` // 会话结束时校验的规则匹配次数断言
+}
+
+// RuntimeSettings 可在会话运行期间调整、无需重启会话即生效的设置
+type RuntimeSettings struct {
+	ProcessTimeoutMS  int  `json:"processTimeoutMS"`  // 单个请求处理超时，0 表示使用默认值
+	UnmatchedSampling int  `json:"unmatchedSampling"` // 同 SessionConfig.UnmatchedSampling
+	DisableCache      bool `json:"disableCache"`      // 禁用浏览器 HTTP 缓存
+}
+
+// RedactionConfig 事件持久化与导出前的脱敏配置，匹配到的内容替换为 [REDACTED]
+type RedactionConfig struct {
+	Headers   []string `json:"headers,omitempty"`   // 请求头与响应头名称，不区分大小写
+	Cookies   []string `json:"cookies,omitempty"`   // Cookie 名称，作用于 Cookie 与 Set-Cookie 头
+	JSONPaths []string `json:"jsonPaths,omitempty"` // JSON 消息体中的字段路径，写法同 maskJson 行为
+	Patterns  []string `json:"patterns,omitempty"`  // 正则表达式，作用于 URL、头部值与消息体；含捕获组时仅替换捕获组
+}
+
+// IsZero 判断是否未配置任何脱敏项
+func (c RedactionConfig) IsZero() bool {
+	return len(c.Headers) == 0 && len(c.Cookies) == 0 && len(c.JSONPaths) == 0 && len(c.Patterns) == 0
 }
 
 // EngineStats 引擎统计信息
@@ -45,6 +144,216 @@ type EngineStats struct {
 	Total   int64            `json:"total"`
 	Matched int64            `json:"matched"`
 	ByRule  map[RuleID]int64 `json:"byRule"`
+
+	Throttled       int64 `json:"throttled"`       // 被 throttle 行为或会话带宽上限延迟放行的次数
+	ThrottleDelayMS int64 `json:"throttleDelayMS"` // 累计的节流延迟毫秒数
+}
+
+// RuleCoverage 单条规则的覆盖情况
+type RuleCoverage struct {
+	RuleID    RuleID `json:"ruleId"`
+	Name      string `json:"name"`
+	Matched   int64  `json:"matched"`   // 命中次数
+	Effective int64  `json:"effective"` // 产生实际修改的次数
+	Degraded  int64  `json:"degraded"`  // 结果下发失败被降级放行的次数
+
+	Variants map[string]int64 `json:"variants,omitempty"` // 各变体的分配次数（variant、canary 动作）
+}
+
+// CoverageReport 规则覆盖报告，用于发现无效或失效的规则
+type CoverageReport struct {
+	Rules          []RuleCoverage `json:"rules"`
+	NeverMatched   []RuleID       `json:"neverMatched"`   // 从未命中的规则
+	NoEffect       []RuleID       `json:"noEffect"`       // 命中但从未产生实际修改的规则
+	AlwaysDegraded []RuleID       `json:"alwaysDegraded"` // 每次命中都被降级的规则
+}
+
+// TrafficCounter 流量计数，字节数按解码后的请求头与请求体/响应体大小近似统计
+type TrafficCounter struct {
+	Requests      int64 `json:"requests"`      // 请求数
+	Blocked       int64 `json:"blocked"`       // 被规则拦截的请求数
+	RequestBytes  int64 `json:"requestBytes"`  // 上行字节数
+	ResponseBytes int64 `json:"responseBytes"` // 下行字节数
+}
+
+// DomainTraffic 单个域名的流量统计
+type DomainTraffic struct {
+	Domain string                          `json:"domain"`
+	Total  TrafficCounter                  `json:"total"`
+	ByType map[ResourceType]TrafficCounter `json:"byType"`
+}
+
+// TrafficStats 会话的按域名、按资源类型流量统计
+type TrafficStats struct {
+	Total   TrafficCounter                  `json:"total"`
+	Domains []DomainTraffic                 `json:"domains"` // 按总字节数降序
+	ByType  map[ResourceType]TrafficCounter `json:"byType"`
+	Status  map[int]int64                   `json:"status"` // 按响应状态码统计的响应数
+}
+
+// ReconnectStats 目标连接意外断开后的重连统计
+type ReconnectStats struct {
+	Disconnects int64 `json:"disconnects"` // 事件流意外断开的次数
+	Reconnects  int64 `json:"reconnects"`  // 成功重新附着的次数
+	Resumed     int64 `json:"resumed"`     // 重连后补发放行的暂停请求数
+	Orphaned    int64 `json:"orphaned"`    // 断开时仍暂停且重连后无法放行的请求数，只能等待浏览器超时
+}
+
+// SessionSummary 会话汇总数据，用于生成会话报告
+type SessionSummary struct {
+	SessionID   SessionID      `json:"sessionId"`
+	StartedAt   int64          `json:"startedAt"`   // 会话启动时间（毫秒时间戳）
+	EndedAt     int64          `json:"endedAt"`     // 会话结束时间，仍在运行时为 0
+	GeneratedAt int64          `json:"generatedAt"` // 汇总生成时间
+	Coverage    CoverageReport `json:"coverage"`
+	Traffic     TrafficStats   `json:"traffic"`
+
+	Assertions *AssertionReport `json:"assertions,omitempty"` // 会话配置了断言时的校验结果
+}
+
+// EndpointStats 单个接口（方法 + 不含查询参数的 URL）在一次会话中的汇总
+type EndpointStats struct {
+	Method           string        `json:"method"`
+	Endpoint         string        `json:"endpoint"`
+	Count            int64         `json:"count"`
+	Status           map[int]int64 `json:"status"`           // 按状态码统计的次数，0 表示无响应
+	MainStatus       int           `json:"mainStatus"`       // 出现次数最多的状态码
+	AvgRequestBytes  int64         `json:"avgRequestBytes"`  // 平均请求体大小
+	AvgResponseBytes int64         `json:"avgResponseBytes"` // 平均响应体大小
+}
+
+// EndpointChange 两次会话中均出现的接口的变化
+type EndpointChange struct {
+	Method             string        `json:"method"`
+	Endpoint           string        `json:"endpoint"`
+	From               EndpointStats `json:"from"`
+	To                 EndpointStats `json:"to"`
+	StatusChanged      bool          `json:"statusChanged"`      // 主要状态码是否变化
+	RequestBytesDelta  int64         `json:"requestBytesDelta"`  // 平均请求体大小之差（To - From）
+	ResponseBytesDelta int64         `json:"responseBytesDelta"` // 平均响应体大小之差（To - From）
+}
+
+// SessionDiff 两次会话事件历史的对比结果
+type SessionDiff struct {
+	From      SessionID        `json:"from"`
+	To        SessionID        `json:"to"`
+	Added     []EndpointStats  `json:"added"`     // 仅出现在 To 中的接口
+	Removed   []EndpointStats  `json:"removed"`   // 仅出现在 From 中的接口
+	Changed   []EndpointChange `json:"changed"`   // 状态码或载荷大小发生变化的接口
+	Unchanged int              `json:"unchanged"` // 无变化的接口数
+}
+
+// ReportFormat 会话报告格式
+type ReportFormat string
+
+const (
+	ReportFormatHTML     ReportFormat = "html"
+	ReportFormatMarkdown ReportFormat = "markdown"
+)
+
+// HARFormat 持续导出的 HAR 文件格式
+type HARFormat string
+
+const (
+	HARFormatJSON  HARFormat = "har"   // 标准 HAR 文件，每写入一条后仍为合法 JSON
+	HARFormatJSONL HARFormat = "jsonl" // 每行一条 HAR entry，便于 tail 与增量处理
+)
+
+// HARStreamOptions 持续 HAR 导出选项
+type HARStreamOptions struct {
+	Path   string    `json:"path"`   // 输出文件路径，已存在时被覆盖
+	Format HARFormat `json:"format"` // 为空时按扩展名推断，.jsonl 为 jsonl，其余为 har
+}
+
+// HARStreamStatus 持续 HAR 导出状态
+type HARStreamStatus struct {
+	Active  bool      `json:"active"`
+	Path    string    `json:"path"`
+	Format  HARFormat `json:"format"`
+	Entries int64     `json:"entries"` // 已写入的条目数
+	Dropped int64     `json:"dropped"` // 因缓冲已满未写入的条目数
+	Failed  int64     `json:"failed"`  // 写入失败的条目数
+}
+
+// ContractStatus OpenAPI 契约检查状态
+type ContractStatus struct {
+	Active     bool   `json:"active"`
+	Path       string `json:"path"`       // 规范文件路径
+	Title      string `json:"title"`      // 规范的 info.title
+	Version    string `json:"version"`    // 规范的 info.version
+	Operations int    `json:"operations"` // 规范中定义的操作数
+}
+
+// BenchmarkOptions 吞吐基准测试选项
+type BenchmarkOptions struct {
+	Requests        int      `json:"requests"`        // 合成请求总数
+	Concurrency     int      `json:"concurrency"`     // 并发工作协程数，含义同 SessionConfig.Concurrency
+	PendingCapacity int      `json:"pendingCapacity"` // 工作池队列容量，含义同 SessionConfig.PendingCapacity
+	Rate            int      `json:"rate"`            // 每秒投递的事件数，0 表示在不丢弃的前提下尽可能快
+	BodySize        int      `json:"bodySize"`        // 合成响应体大小（字节）
+	URLs            []string `json:"urls"`            // 合成请求 URL，为空时根据规则条件生成
+}
+
+// BenchmarkResult 吞吐基准测试结果
+type BenchmarkResult struct {
+	Requests   int64   `json:"requests"`   // 完成处理的请求数
+	Dropped    int64   `json:"dropped"`    // 因队列已满被降级放行的请求数
+	Matched    int64   `json:"matched"`    // 命中规则的请求数
+	DurationMS int64   `json:"durationMS"` // 总耗时
+	RPS        float64 `json:"rps"`        // 每秒完成的请求数
+	P50MS      float64 `json:"p50MS"`      // 延迟中位数（含排队时间）
+	P99MS      float64 `json:"p99MS"`      // 99 分位延迟（含排队时间）
+	MaxMS      float64 `json:"maxMS"`      // 最大延迟
+}
+
+// RuleBenchmarkOptions 规则评估耗时分析选项
+type RuleBenchmarkOptions struct {
+	Iterations int       `json:"iterations"` // 每条规则对全部请求重复评估的轮数
+	Requests   []Request `json:"requests"`   // 录制的请求上下文，为空时根据规则条件合成
+}
+
+// RuleCost 单条规则的评估耗时
+type RuleCost struct {
+	RuleID      RuleID  `json:"ruleId"`
+	Name        string  `json:"name"`
+	Stage       string  `json:"stage"`
+	Evaluations int64   `json:"evaluations"` // 评估次数
+	Matched     int64   `json:"matched"`     // 命中次数
+	TotalMS     float64 `json:"totalMS"`     // 评估总耗时
+	AvgNS       float64 `json:"avgNS"`       // 单次评估平均耗时（纳秒）
+}
+
+// RuleBenchmarkResult 规则评估耗时分析结果
+type RuleBenchmarkResult struct {
+	Contexts   int        `json:"contexts"`   // 参与评估的请求上下文数
+	Iterations int        `json:"iterations"` // 评估轮数
+	Rules      []RuleCost `json:"rules"`      // 按平均耗时降序排列的已启用规则
+}
+
+// RuleTestOptions 规则离线测试选项
+type RuleTestOptions struct {
+	Events   []NetworkEvent `json:"events"`             // 捕获的事件，以规则修改前的请求与响应作为输入
+	Requests []Request      `json:"requests,omitempty"` // 只有请求快照时使用，不评估响应阶段
+}
+
+// RuleTestCase 单个捕获事件在候选规则下的评估结果
+type RuleTestCase struct {
+	EventID         string       `json:"eventId"`
+	Event           NetworkEvent `json:"event"`                     // 候选规则下本会记录的事件：命中的规则、最终结果与修改前后的请求和响应
+	CapturedResult  string       `json:"capturedResult,omitempty"`  // 捕获时的最终结果，请求快照为空
+	CapturedRules   []string     `json:"capturedRules,omitempty"`   // 捕获时命中的规则 ID
+	Changed         bool         `json:"changed"`                   // 命中的规则或最终结果与捕获时不同
+	ResponseSkipped bool         `json:"responseSkipped,omitempty"` // 没有捕获到服务端响应，未评估响应阶段
+}
+
+// RuleTestResult 规则离线测试结果
+type RuleTestResult struct {
+	Events   int            `json:"events"`   // 参与评估的事件数
+	Matched  int            `json:"matched"`  // 命中至少一条规则的事件数
+	Modified int            `json:"modified"` // 会被修改或拦截的事件数
+	Changed  int            `json:"changed"`  // 结果与捕获时不同的事件数
+	Rules    map[string]int `json:"rules"`    // 每条规则命中的事件数
+	Cases    []RuleTestCase `json:"cases"`
 }
 
 // TargetInfo 目标信息
@@ -59,12 +368,19 @@ type TargetInfo struct {
 // Header 封装通用的头部操作
 type Header map[string]string
 
-// Get 获取指定 Header 的值
+// Get 获取指定 Header 的值，名称不区分大小写；优先取完全相同的键，
+// 同一头部以多种大小写出现时取字典序最小的键，保证结果确定
 func (h Header) Get(key string) string {
-	if h == nil {
-		return ""
+	if v, ok := h[key]; ok {
+		return v
+	}
+	var found, value string
+	for k, v := range h {
+		if strings.EqualFold(k, key) && (found == "" || k < found) {
+			found, value = k, v
+		}
 	}
-	return h[key]
+	return value
 }
 
 // Set 设置指定 Header 的值
@@ -77,30 +393,116 @@ func (h Header) Del(key string) {
 	delete(h, key)
 }
 
+// ValidateHeaderName 校验头部名称是否为合法的 HTTP token
+func ValidateHeaderName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty header name", ErrInvalidConfig)
+	}
+	for _, c := range name {
+		if c > 0x7e || !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("!#$%&'*+-.^_`|~", c)) {
+			return fmt.Errorf("%w: invalid header name %q", ErrInvalidConfig, name)
+		}
+	}
+	return nil
+}
+
 // Request 请求模型
 type Request struct {
-	ID           string            `json:"id"`                     // 事务唯一ID
-	URL          string            `json:"url"`                    // 完整URL
-	Method       string            `json:"method"`                 // HTTP方法
-	Headers      Header            `json:"headers"`                // 请求头
-	Body         []byte            `json:"body"`                   // 请求体原始数据
-	ResourceType ResourceType      `json:"resourceType,omitempty"` // 资源类型
-	Query        map[string]string `json:"query,omitempty"`        // 预解析的查询参数
-	Cookies      map[string]string `json:"cookies,omitempty"`      // 预解析的Cookie
+	ID            string            `json:"id"`                      // 事务唯一ID
+	URL           string            `json:"url"`                     // 完整URL
+	Method        string            `json:"method"`                  // HTTP方法
+	Headers       Header            `json:"headers"`                 // 请求头
+	Body          []byte            `json:"body"`                    // 请求体原始数据
+	ResourceType  ResourceType      `json:"resourceType,omitempty"`  // 资源类型
+	Query         map[string]string `json:"query,omitempty"`         // 预解析的查询参数
+	Cookies       map[string]string `json:"cookies,omitempty"`       // 预解析的Cookie
+	Decoded       string            `json:"decoded,omitempty"`       // gRPC-web 等二进制消息解码后的 JSON，用于展示与 Body 条件匹配
+	Secrets       []SecretFinding   `json:"secrets,omitempty"`       // 发往服务端的请求中检测到的敏感信息
+	CorrelationID string            `json:"correlationId,omitempty"` // 注入或沿用的关联 ID，用于与代理、后端日志对应
+	TraceID       string            `json:"traceId,omitempty"`       // 处理该请求的 OpenTelemetry 追踪 ID，未开启追踪时为空
+}
+
+// MatchBody 返回用于 Body 条件匹配的文本，有解码结果时使用解码后的 JSON
+func (r *Request) MatchBody() string {
+	if r.Decoded != "" {
+		return r.Decoded
+	}
+	return string(r.Body)
+}
+
+// IsWebSocket 判断请求是否为 WebSocket 握手请求
+func (r *Request) IsWebSocket() bool {
+	if r.ResourceType == ResourceTypeWebSocket {
+		return true
+	}
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, "Upgrade") && strings.EqualFold(strings.TrimSpace(v), "websocket") {
+			return true
+		}
+	}
+	return false
 }
 
 // Response 响应模型
 type Response struct {
-	StatusCode int            `json:"statusCode"`
-	Headers    Header         `json:"headers"`
-	Body       []byte         `json:"body"`
-	Timing     ResponseTiming `json:"timing,omitempty"`
+	StatusCode int               `json:"statusCode"`
+	Headers    Header            `json:"headers"`
+	Body       []byte            `json:"body"`
+	Decoded    string            `json:"decoded,omitempty"`    // gRPC-web 等二进制消息解码后的 JSON，用于展示
+	Violations []SchemaViolation `json:"violations,omitempty"` // validateSchema 动作发现的 JSON Schema 违规
+	Secrets    []SecretFinding   `json:"secrets,omitempty"`    // 返回给页面的响应中检测到的敏感信息
+	Timing     ResponseTiming    `json:"timing,omitempty"`
+}
+
+// SchemaViolation 响应体不符合 JSON Schema 或请求/响应不符合 OpenAPI 契约的一处违规
+type SchemaViolation struct {
+	RuleID  string `json:"ruleId,omitempty"`  // 执行校验的规则，契约检查产生的违规为空
+	Kind    string `json:"kind,omitempty"`    // 契约违规类型：path、method、status、requestBody、responseBody
+	Path    string `json:"path"`              // 违规值在响应体中的位置（JSON Pointer）
+	Keyword string `json:"keyword,omitempty"` // 未通过的 schema 关键字位置
+	Message string `json:"message"`           // 违规说明
 }
 
-// ResponseTiming 响应时间信息
+// SecretLocation 敏感信息在请求或响应中的位置
+type SecretLocation string
+
+const (
+	SecretInURL            SecretLocation = "url"            // 请求 URL
+	SecretInRequestHeader  SecretLocation = "requestHeader"  // 请求头
+	SecretInRequestBody    SecretLocation = "requestBody"    // 请求体
+	SecretInResponseHeader SecretLocation = "responseHeader" // 响应头
+	SecretInResponseBody   SecretLocation = "responseBody"   // 响应体
+)
+
+// SecretFinding 流量中检测到的一处敏感信息
+type SecretFinding struct {
+	Detector string         `json:"detector"`        // 检测器：awsKey、jwt、apiKey、creditCard
+	Location SecretLocation `json:"location"`        // 所在位置
+	Field    string         `json:"field,omitempty"` // 所在的头部名称，位置为头部时有效
+	Offset   int            `json:"offset"`          // 在 URL、头部值或消息体中的字节偏移
+	Match    string         `json:"match"`           // 脱敏后的匹配内容，仅保留首尾少量字符
+}
+
+// ResponseTiming 响应时间信息。
+// 阶段耗时单位为毫秒，来自 Network 域的 ResourceTiming，仅在会话开启计时采集时填充；复用连接时 DNS、连接耗时为 0
 type ResponseTiming struct {
 	StartTime int64 `json:"startTime"`
 	EndTime   int64 `json:"endTime"`
+
+	DNS      float64 `json:"dns,omitempty"`      // DNS 解析耗时
+	Connect  float64 `json:"connect,omitempty"`  // 建立连接耗时，包含 TLS 握手
+	SSL      float64 `json:"ssl,omitempty"`      // TLS 握手耗时
+	Send     float64 `json:"send,omitempty"`     // 发送请求耗时
+	TTFB     float64 `json:"ttfb,omitempty"`     // 请求发出到收到响应头的等待时间
+	Transfer float64 `json:"transfer,omitempty"` // 收到响应头到响应体接收完成的耗时
+
+	TransferSize    int64 `json:"transferSize,omitempty"`    // 网络传输的总字节数，包含响应头
+	EncodedBodySize int64 `json:"encodedBodySize,omitempty"` // 网络传输的响应体字节数（压缩后）
+}
+
+// HasPhases 判断是否采集到了网络阶段计时
+func (t ResponseTiming) HasPhases() bool {
+	return t.TTFB > 0 || t.Transfer > 0 || t.TransferSize > 0
 }
 
 // RuleMatch 规则匹配信息
@@ -112,15 +514,41 @@ type RuleMatch struct {
 
 // NetworkEvent 网络请求事件（统一所有拦截事件）
 type NetworkEvent struct {
-	ID           string      `json:"id"` // 事务唯一ID (CDP RequestID)
-	Session      SessionID   `json:"session"`
-	Target       TargetID    `json:"target"`
-	Timestamp    int64       `json:"timestamp"`
-	IsMatched    bool        `json:"isMatched"` // 是否匹配规则
-	Request      Request     `json:"request"`
-	Response     *Response   `json:"response,omitempty"`
-	FinalResult  string      `json:"finalResult,omitempty"`  // blocked / modified / passed
-	MatchedRules []RuleMatch `json:"matchedRules,omitempty"` // 匹配的规则列表
+	ID           string          `json:"id"`            // 事务唯一ID (CDP RequestID)
+	Seq          uint64          `json:"seq,omitempty"` // 会话内单调递增的事件序号，用于断线后从指定位置回放
+	Session      SessionID       `json:"session"`
+	Target       TargetID        `json:"target"`
+	Timestamp    int64           `json:"timestamp"`
+	IsMatched    bool            `json:"isMatched"` // 是否匹配规则
+	Request      Request         `json:"request"`
+	Response     *Response       `json:"response,omitempty"`
+	FinalResult  string          `json:"finalResult,omitempty"`  // blocked / modified / passed
+	MatchedRules []RuleMatch     `json:"matchedRules,omitempty"` // 匹配的规则列表
+	Frame        *WebSocketFrame `json:"frame,omitempty"`        // WebSocket 消息帧事件的帧信息，Request 为所属连接的握手请求，Body 为帧载荷
+	DryRun       bool            `json:"dryRun,omitempty"`       // 演练模式下记录的事件：FinalResult 与修改后的请求、响应为规则本会产生的结果，实际流量原样放行
+
+	// 规则修改前的请求与响应，只包含 URL、方法、头部、状态码与消息体；未被修改的一方为空，修改后的即 Request 与 Response
+	OriginalRequest  *Request  `json:"originalRequest,omitempty"`
+	OriginalResponse *Response `json:"originalResponse,omitempty"`
+}
+
+// WebSocketDirection WebSocket 消息帧方向
+type WebSocketDirection string
+
+const (
+	WebSocketSent     WebSocketDirection = "sent"     // 页面发出的帧
+	WebSocketReceived WebSocketDirection = "received" // 服务端发来的帧
+	WebSocketError    WebSocketDirection = "error"    // 帧处理出错
+)
+
+// WebSocketFrame WebSocket 消息帧，只读观察，不支持修改
+type WebSocketFrame struct {
+	Direction WebSocketDirection `json:"direction"`
+	Index     int                `json:"index"`               // 连接内的帧序号，从 1 开始
+	Opcode    int                `json:"opcode"`              // 1 文本帧，2 二进制帧，8 关闭帧等
+	Size      int                `json:"size"`                // 载荷原始字节数
+	Truncated bool               `json:"truncated,omitempty"` // 载荷超出记录上限，事件中只保留开头部分
+	Error     string             `json:"error,omitempty"`     // 帧处理出错时的错误信息
 }
 
 // NewRequest 创建初始化请求对象
@@ -1,6 +1,7 @@
 package domain_test
 
 import (
+	"errors"
 	"testing"
 
 	"cdpnetool/pkg/domain"
@@ -212,3 +213,45 @@ func TestGuessTypeFromURL(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateHeaderName(t *testing.T) {
+	for _, name := range []string{"X-Request-ID", "traceparent", "X_Trace.Id~1"} {
+		if err := domain.ValidateHeaderName(name); err != nil {
+			t.Errorf("ValidateHeaderName(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "X Request", "X-Id:", "X-Id\r\n", "请求"} {
+		if err := domain.ValidateHeaderName(name); !errors.Is(err, domain.ErrInvalidConfig) {
+			t.Errorf("ValidateHeaderName(%q) = %v, want ErrInvalidConfig", name, err)
+		}
+	}
+}
+
+func TestHeader_Get(t *testing.T) {
+	h := domain.Header{"content-type": "application/json", "X-Trace": "1"}
+	tests := map[string]string{
+		"content-type": "application/json",
+		"Content-Type": "application/json",
+		"x-trace":      "1",
+		"Missing":      "",
+	}
+	for name, want := range tests {
+		if got := h.Get(name); got != want {
+			t.Errorf("Get(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got := domain.Header(nil).Get("Content-Type"); got != "" {
+		t.Errorf("nil Header Get() = %q, want empty", got)
+	}
+
+	// 同一头部以多种大小写出现时：完全相同的键优先，否则取字典序最小的键
+	dup := domain.Header{"x-token": "lower", "X-Token": "canonical", "X-TOKEN": "upper"}
+	for i := 0; i < 50; i++ {
+		if got := dup.Get("X-Token"); got != "canonical" {
+			t.Fatalf("Get(X-Token) = %q, want the exact key", got)
+		}
+		if got := dup.Get("x-TOKEN"); got != "upper" {
+			t.Fatalf("Get(x-TOKEN) = %q, want the lexically smallest key X-TOKEN", got)
+		}
+	}
+}
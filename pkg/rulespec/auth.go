@@ -0,0 +1,57 @@
+package rulespec
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+// GetMode 获取应答方式，默认为 provide
+func (s *AuthSpec) GetMode() AuthMode {
+	if s.Mode == "" {
+		return AuthModeProvide
+	}
+	return s.Mode
+}
+
+// Answers 判断是否应答该来源的质询
+func (s *AuthSpec) Answers(source domain.AuthSource) bool {
+	return s.Source == "" || s.Source == source
+}
+
+// Timeout 返回等待人工处理的最长时间
+func (s *AuthSpec) Timeout() time.Duration {
+	if s.TimeoutMS <= 0 {
+		return domain.DefaultAuthTimeout
+	}
+	return time.Duration(s.TimeoutMS) * time.Millisecond
+}
+
+// Credentials 返回应答质询的用户名与密码，设置了 passwordEnv 时从环境变量读取密码，变量未设置时返回错误
+func (s *AuthSpec) Credentials() (username, password string, err error) {
+	if s.PasswordEnv == "" {
+		return s.Username, s.Password, nil
+	}
+	password, ok := os.LookupEnv(s.PasswordEnv)
+	if !ok {
+		return "", "", fmt.Errorf("environment variable %s is not set", s.PasswordEnv)
+	}
+	return s.Username, password, nil
+}
+
+// HasAuthRules 判断是否有已启用的规则包含 auth 行为，此时需要接管认证质询
+func HasAuthRules(rules []Rule) bool {
+	for i := range rules {
+		if !rules[i].Enabled {
+			continue
+		}
+		for _, a := range rules[i].Actions {
+			if a.Type == ActionAuth {
+				return true
+			}
+		}
+	}
+	return false
+}
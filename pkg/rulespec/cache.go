@@ -0,0 +1,67 @@
+package rulespec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setCache 行为的预设值，其他值视为缓存时长
+const (
+	CachePresetDisable   = "disable"   // 禁用缓存
+	CachePresetImmutable = "immutable" // 长期缓存且在有效期内不再验证
+)
+
+// immutableMaxAge immutable 预设的缓存时长
+const immutableMaxAge = 365 * 24 * time.Hour
+
+// CachePolicy setCache 行为解析后的缓存策略
+type CachePolicy struct {
+	CacheControl string        // Cache-Control 响应头
+	MaxAge       time.Duration // 缓存时长，禁用缓存时为 0
+	Disable      bool          // 是否禁用缓存，禁用时同时设置 Pragma: no-cache 与过期的 Expires
+}
+
+// ResolveCache 解析 setCache 的值：disable、immutable 或缓存时长（如 30m、1h、7d）
+func ResolveCache(value string) (CachePolicy, error) {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "":
+		return CachePolicy{}, fmt.Errorf("empty cache preset")
+	case CachePresetDisable:
+		return CachePolicy{CacheControl: "no-store, no-cache, must-revalidate, max-age=0", Disable: true}, nil
+	case CachePresetImmutable:
+		return CachePolicy{
+			CacheControl: fmt.Sprintf("public, max-age=%d, immutable", int64(immutableMaxAge.Seconds())),
+			MaxAge:       immutableMaxAge,
+		}, nil
+	}
+
+	d, err := parseCacheDuration(value)
+	if err != nil {
+		return CachePolicy{}, fmt.Errorf("invalid cache preset %q: want %s, %s or a duration like 1h", value, CachePresetDisable, CachePresetImmutable)
+	}
+	return CachePolicy{CacheControl: fmt.Sprintf("public, max-age=%d", int64(d.Seconds())), MaxAge: d}, nil
+}
+
+// parseCacheDuration 解析缓存时长，在 time.ParseDuration 基础上支持以天为单位的 d 后缀
+func parseCacheDuration(value string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil {
+			return 0, err
+		}
+	}
+	if d < time.Second {
+		return 0, fmt.Errorf("cache duration must be at least 1s")
+	}
+	return d, nil
+}
@@ -0,0 +1,77 @@
+package rulespec
+
+import (
+	"fmt"
+
+	"cdpnetool/pkg/domain"
+)
+
+// ActionAllowed 判断能力配置档是否允许执行该行为；variant 行为需其所有变体中的行为都被允许
+func ActionAllowed(profile domain.CapabilityProfile, a *Action) bool {
+	if a.Type == ActionVariant {
+		for _, v := range a.Variants {
+			for i := range v.Actions {
+				if !ActionAllowed(profile, &v.Actions[i]) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	switch profile {
+	case domain.CapabilityNoBodyMutation:
+		return !mutatesBody(a)
+	case domain.CapabilityNoBlock:
+		return !failsRequest(a)
+	case domain.CapabilityMockOnly:
+		switch a.Type {
+		case ActionBlock, ActionNotModified, ActionRateLimit, ActionRedirect, ActionMapLocal, ActionSaveBody:
+			return true
+		case ActionValidateSchema:
+			return a.GetOnViolation() == ViolationReport
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// CheckCapabilities 校验已启用规则中的行为是否都被能力配置档允许，失败时返回 *domain.RuleError
+func CheckCapabilities(profile domain.CapabilityProfile, rules []Rule) error {
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		for i := range rule.Actions {
+			if a := &rule.Actions[i]; !ActionAllowed(profile, a) {
+				return &domain.RuleError{RuleID: rule.ID, Err: fmt.Errorf("能力配置档 %s 不允许 %s 行为", profile, a.Type)}
+			}
+		}
+	}
+	return nil
+}
+
+// mutatesBody 判断行为是否会修改请求体或响应体
+func mutatesBody(a *Action) bool {
+	switch a.Type {
+	case ActionSetBody, ActionAppendBody, ActionReplaceBodyText, ActionPatchBodyJson, ActionJqTransform,
+		ActionSetFormField, ActionRemoveFormField, ActionSetFormFile, ActionMaskJson, ActionAugmentJson, ActionScript,
+		ActionTruncateBody, ActionCorruptJson:
+		return true
+	case ActionValidateSchema:
+		// 违规时以 502 与违规详情替换响应体
+		return a.GetOnViolation() == ViolationFail
+	}
+	return false
+}
+
+// failsRequest 判断行为是否会拦截请求（包括以伪造的重定向应答与网络错误）或以伪造的失败响应应答
+func failsRequest(a *Action) bool {
+	switch a.Type {
+	case ActionBlock, ActionRateLimit, ActionNotModified, ActionRedirect, ActionFail, ActionRandomStatus:
+		return true
+	case ActionValidateSchema:
+		return a.GetOnViolation() == ViolationFail
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package rulespec
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// FieldChange 单个字段的变更
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// RuleChange 同 ID 规则的字段变更
+type RuleChange struct {
+	RuleID string        `json:"ruleId"`
+	Name   string        `json:"name"`
+	Fields []FieldChange `json:"fields"`
+}
+
+// ConfigDiff 两个配置之间的结构化差异
+type ConfigDiff struct {
+	Fields  []FieldChange `json:"fields"`  // 配置自身字段（名称、描述、设置等）的变更
+	Added   []Rule        `json:"added"`   // 仅存在于新配置中的规则
+	Removed []Rule        `json:"removed"` // 仅存在于旧配置中的规则
+	Changed []RuleChange  `json:"changed"` // 两侧都存在但内容不同的规则
+	Moved   []string      `json:"moved"`   // 相对顺序发生变化的规则 ID
+}
+
+// IsEmpty 判断两个配置是否没有差异
+func (d *ConfigDiff) IsEmpty() bool {
+	return len(d.Fields) == 0 && len(d.Added) == 0 && len(d.Removed) == 0 &&
+		len(d.Changed) == 0 && len(d.Moved) == 0
+}
+
+// Diff 计算从 from 到 to 的差异，规则按 ID 对应
+func Diff(from, to *Config) ConfigDiff {
+	if from == nil {
+		from = &Config{}
+	}
+	if to == nil {
+		to = &Config{}
+	}
+
+	diff := ConfigDiff{
+		Fields:  []FieldChange{},
+		Added:   []Rule{},
+		Removed: []Rule{},
+		Changed: []RuleChange{},
+		Moved:   []string{},
+	}
+	diff.Fields = appendChange(diff.Fields, "name", from.Name, to.Name)
+	diff.Fields = appendChange(diff.Fields, "description", from.Description, to.Description)
+	diff.Fields = appendChange(diff.Fields, "version", from.Version, to.Version)
+	diff.Fields = appendChange(diff.Fields, "settings", from.Settings, to.Settings)
+
+	oldRules := make(map[string]*Rule, len(from.Rules))
+	for i := range from.Rules {
+		oldRules[from.Rules[i].ID] = &from.Rules[i]
+	}
+	newRules := make(map[string]bool, len(to.Rules))
+	for _, rule := range to.Rules {
+		newRules[rule.ID] = true
+	}
+
+	// 两侧共有规则在各自列表中的顺序，用于检测移动
+	var oldOrder, newOrder []string
+	for _, rule := range from.Rules {
+		if newRules[rule.ID] {
+			oldOrder = append(oldOrder, rule.ID)
+		} else {
+			diff.Removed = append(diff.Removed, rule)
+		}
+	}
+
+	for _, rule := range to.Rules {
+		prev, ok := oldRules[rule.ID]
+		if !ok {
+			diff.Added = append(diff.Added, rule)
+			continue
+		}
+		newOrder = append(newOrder, rule.ID)
+		if fields := diffRule(prev, &rule); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, RuleChange{RuleID: rule.ID, Name: rule.Name, Fields: fields})
+		}
+	}
+
+	diff.Moved = append(diff.Moved, moved(oldOrder, newOrder)...)
+	return diff
+}
+
+// diffRule 比较同 ID 规则的各字段
+func diffRule(from, to *Rule) []FieldChange {
+	var fields []FieldChange
+	fields = appendChange(fields, "name", from.Name, to.Name)
+	fields = appendChange(fields, "enabled", from.Enabled, to.Enabled)
+	fields = appendChange(fields, "priority", from.Priority, to.Priority)
+	fields = appendChange(fields, "terminal", from.Terminal, to.Terminal)
+	fields = appendChange(fields, "stage", from.Stage, to.Stage)
+	fields = appendChange(fields, "match", from.Match, to.Match)
+	fields = appendChange(fields, "actions", from.Actions, to.Actions)
+	return fields
+}
+
+// appendChange 字段值不同时追加变更记录，复合值按 JSON 形式比较以忽略 nil 与空切片的差别
+func appendChange(fields []FieldChange, name string, from, to any) []FieldChange {
+	if jsonEqual(from, to) {
+		return fields
+	}
+	return append(fields, FieldChange{Field: name, Old: from, New: to})
+}
+
+// jsonEqual 比较两个值序列化后的 JSON 是否等价
+func jsonEqual(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	var va, vb any
+	_ = json.Unmarshal(ja, &va)
+	_ = json.Unmarshal(jb, &vb)
+	return reflect.DeepEqual(normalizeEmpty(va), normalizeEmpty(vb))
+}
+
+// normalizeEmpty 将空数组、空对象统一视为 nil
+func normalizeEmpty(v any) any {
+	switch t := v.(type) {
+	case []any:
+		if len(t) == 0 {
+			return nil
+		}
+		for i := range t {
+			t[i] = normalizeEmpty(t[i])
+		}
+	case map[string]any:
+		if len(t) == 0 {
+			return nil
+		}
+		for k := range t {
+			t[k] = normalizeEmpty(t[k])
+		}
+	}
+	return v
+}
+
+// moved 返回不在最长公共子序列中的元素，即相对顺序发生变化的规则
+func moved(from, to []string) []string {
+	n, m := len(from), len(to)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	kept := make(map[string]bool, lcs[0][0])
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case from[i] == to[j]:
+			kept[from[i]] = true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	var out []string
+	for _, id := range to {
+		if !kept[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package rulespec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultFailReason fail 行为未指定错误原因时使用的网络错误，效果类似 TCP 连接被重置
+const DefaultFailReason = "ConnectionReset"
+
+// FailReasons fail 行为可用的网络错误原因，与 CDP Network.ErrorReason 一致
+var FailReasons = []string{
+	"Failed", "Aborted", "TimedOut", "AccessDenied", "ConnectionClosed", "ConnectionReset",
+	"ConnectionRefused", "ConnectionAborted", "ConnectionFailed", "NameNotResolved",
+	"InternetDisconnected", "AddressUnreachable", "BlockedByClient", "BlockedByResponse",
+}
+
+// defaultFaultStatuses randomStatus 行为未指定候选状态码时使用的状态码
+var defaultFaultStatuses = []int{500, 502, 503, 504}
+
+// IsFault 判断是否为故障注入行为，故障注入行为按 probability 概率执行
+func (a *Action) IsFault() bool {
+	switch a.Type {
+	case ActionFail, ActionRandomStatus, ActionTruncateBody, ActionCorruptJson:
+		return true
+	}
+	return false
+}
+
+// GetFailReason 获取 fail 行为的网络错误原因，未指定时为 ConnectionReset
+func (a *Action) GetFailReason() string {
+	if v, ok := a.Value.(string); ok && v != "" {
+		return v
+	}
+	return DefaultFailReason
+}
+
+// FaultStatuses 解析 randomStatus 行为的候选状态码，未指定时为 500、502、503、504
+func (a *Action) FaultStatuses() ([]int, error) {
+	v, _ := a.Value.(string)
+	if strings.TrimSpace(v) == "" {
+		return defaultFaultStatuses, nil
+	}
+	var codes []int
+	for _, item := range strings.Split(v, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("无效的状态码 %q", strings.TrimSpace(item))
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// validFailReason 判断是否为 CDP 支持的网络错误原因
+func validFailReason(reason string) bool {
+	for _, r := range FailReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package rulespec
+
+import (
+	"fmt"
+
+	"cdpnetool/pkg/domain"
+)
+
+// MergeStrategy 合并规则集时处理规则 ID 冲突的策略
+type MergeStrategy string
+
+const (
+	MergeRename    MergeStrategy = "rename"    // 为来源规则生成新 ID 后追加
+	MergeSkip      MergeStrategy = "skip"      // 保留目标规则，丢弃来源规则
+	MergeOverwrite MergeStrategy = "overwrite" // 用来源规则原位替换目标规则
+)
+
+// MergeResult 合并结果统计
+type MergeResult struct {
+	Added       int `json:"added"`       // 无冲突直接追加的规则数
+	Renamed     int `json:"renamed"`     // 重命名后追加的规则数
+	Skipped     int `json:"skipped"`     // 因冲突被跳过的规则数
+	Overwritten int `json:"overwritten"` // 覆盖目标中同 ID 规则的数量
+}
+
+// ParseMergeStrategy 解析合并策略，空字符串默认为 rename
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	switch MergeStrategy(s) {
+	case "":
+		return MergeRename, nil
+	case MergeRename, MergeSkip, MergeOverwrite:
+		return MergeStrategy(s), nil
+	default:
+		return "", fmt.Errorf("%w: 未知的合并策略 %q", domain.ErrInvalidConfig, s)
+	}
+}
+
+// MergeRules 将 source 中的规则合并到 target 之后，返回新的规则列表，不修改入参
+func MergeRules(target, source []Rule, strategy MergeStrategy) ([]Rule, MergeResult, error) {
+	if _, err := ParseMergeStrategy(string(strategy)); err != nil {
+		return nil, MergeResult{}, err
+	}
+
+	merged := make([]Rule, len(target), len(target)+len(source))
+	copy(merged, target)
+
+	index := make(map[string]int, len(merged)+len(source))
+	for i, rule := range merged {
+		index[rule.ID] = i
+	}
+
+	var res MergeResult
+	for _, rule := range source {
+		i, exists := index[rule.ID]
+		if !exists {
+			index[rule.ID] = len(merged)
+			merged = append(merged, rule)
+			res.Added++
+			continue
+		}
+
+		switch strategy {
+		case MergeSkip:
+			res.Skipped++
+		case MergeOverwrite:
+			merged[i] = rule
+			res.Overwritten++
+		default:
+			rule.ID = uniqueRuleID(rule.ID, index)
+			index[rule.ID] = len(merged)
+			merged = append(merged, rule)
+			res.Renamed++
+		}
+	}
+	return merged, res, nil
+}
+
+// uniqueRuleID 基于原 ID 追加数字后缀生成不冲突的规则 ID
+func uniqueRuleID(id string, taken map[string]int) string {
+	for n := 2; ; n++ {
+		suffix := fmt.Sprintf("-%d", n)
+		base := id
+		if len(base)+len(suffix) > RuleIDMaxLen {
+			base = base[:RuleIDMaxLen-len(suffix)]
+		}
+		if _, ok := taken[base+suffix]; !ok {
+			return base + suffix
+		}
+	}
+}
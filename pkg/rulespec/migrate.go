@@ -0,0 +1,141 @@
+package rulespec
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cdpnetool/pkg/domain"
+)
+
+// Migration 单步配置迁移，将 From 版本的原始 JSON 结构升级为 To 版本
+type Migration struct {
+	From  string
+	To    string
+	Apply func(raw map[string]any) error
+}
+
+// migrations 按版本顺序排列的迁移链，新增配置字段时在此追加
+var migrations = []Migration{
+	{From: "", To: "1.0", Apply: migrateLegacy},
+}
+
+// ParseConfig 解析配置 JSON，必要时自动迁移到当前版本
+// 返回值 migrated 表示是否发生了迁移（调用方可据此回写存储）
+func ParseConfig(data []byte) (cfg *Config, migrated bool, err error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, false, err
+	}
+	if raw == nil {
+		return nil, false, fmt.Errorf("%w: 配置为空", domain.ErrInvalidConfig)
+	}
+
+	migrated, err = MigrateRaw(raw)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if migrated {
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, false, err
+		}
+	}
+
+	cfg = &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, false, err
+	}
+	return cfg, migrated, nil
+}
+
+// MigrateRaw 对原始 JSON 结构依次应用迁移，直到达到当前版本
+func MigrateRaw(raw map[string]any) (bool, error) {
+	version, _ := raw["version"].(string)
+	if err := CheckVersion(version); err != nil {
+		return false, err
+	}
+
+	migrated := false
+	for _, m := range migrations {
+		if version != m.From {
+			continue
+		}
+		if err := m.Apply(raw); err != nil {
+			return migrated, fmt.Errorf("配置从版本 %q 迁移到 %q 失败: %w", m.From, m.To, err)
+		}
+		version = m.To
+		raw["version"] = version
+		migrated = true
+	}
+	return migrated, nil
+}
+
+// CheckVersion 版本握手：拒绝比当前程序更新的主版本
+func CheckVersion(version string) error {
+	if version == "" {
+		return nil
+	}
+	major, _, ok := parseVersion(version)
+	if !ok {
+		return fmt.Errorf("%w: 无法识别的配置版本 %q", domain.ErrInvalidConfig, version)
+	}
+	currentMajor, _, _ := parseVersion(DefaultConfigVersion)
+	if major > currentMajor {
+		return fmt.Errorf("%w: 配置版本 %s 高于当前支持的版本 %s", domain.ErrInvalidConfig, version, DefaultConfigVersion)
+	}
+	return nil
+}
+
+// parseVersion 解析 "major.minor" 格式的版本号
+func parseVersion(v string) (major, minor int, ok bool) {
+	parts := strings.SplitN(v, ".", 2)
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	if len(parts) == 2 {
+		if minor, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, false
+		}
+	}
+	return major, minor, true
+}
+
+// migrateLegacy 迁移无版本号的早期配置：补全规则 ID、阶段和空的匹配/行为列表
+func migrateLegacy(raw map[string]any) error {
+	rules, _ := raw["rules"].([]any)
+	for i, r := range rules {
+		rule, ok := r.(map[string]any)
+		if !ok {
+			return fmt.Errorf("第 %d 条规则格式错误", i+1)
+		}
+		if id, _ := rule["id"].(string); id == "" {
+			rule["id"] = GenerateRuleID(i)
+		}
+		if stage, _ := rule["stage"].(string); stage == "" {
+			rule["stage"] = string(StageRequest)
+		}
+		match, _ := rule["match"].(map[string]any)
+		if match == nil {
+			match = map[string]any{}
+			rule["match"] = match
+		}
+		for _, key := range []string{"allOf", "anyOf"} {
+			if match[key] == nil {
+				match[key] = []any{}
+			}
+		}
+		if rule["actions"] == nil {
+			rule["actions"] = []any{}
+		}
+	}
+	if raw["rules"] == nil {
+		raw["rules"] = []any{}
+	}
+	if raw["settings"] == nil {
+		raw["settings"] = map[string]any{}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package rulespec
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Range statusCode 与 responseTime 条件使用的闭区间
+type Range struct {
+	Min int64 `json:"min"`
+	Max int64 `json:"max"`
+}
+
+// Contains 判断 v 是否在区间内
+func (r Range) Contains(v int64) bool {
+	return v >= r.Min && v <= r.Max
+}
+
+// ParseRanges 解析逗号分隔的范围列表，每项为 N、Nxx（如 5xx）、N-M、>N、>=N、<N 或 <=N
+func ParseRanges(s string) ([]Range, error) {
+	var out []Range
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		r, err := parseRange(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty range")
+	}
+	return out, nil
+}
+
+// parseRange 解析单个范围
+func parseRange(s string) (Range, error) {
+	num := func(v string) (int64, error) {
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid range %q", s)
+		}
+		return n, nil
+	}
+
+	switch {
+	case strings.HasPrefix(s, ">="):
+		n, err := num(s[2:])
+		return Range{Min: n, Max: math.MaxInt64}, err
+	case strings.HasPrefix(s, ">"):
+		n, err := num(s[1:])
+		return Range{Min: n + 1, Max: math.MaxInt64}, err
+	case strings.HasPrefix(s, "<="):
+		n, err := num(s[2:])
+		return Range{Min: 0, Max: n}, err
+	case strings.HasPrefix(s, "<"):
+		n, err := num(s[1:])
+		if err == nil && n == 0 {
+			err = fmt.Errorf("invalid range %q", s)
+		}
+		return Range{Min: 0, Max: n - 1}, err
+	case len(s) == 3 && strings.EqualFold(s[1:], "xx"):
+		n, err := num(s[:1])
+		return Range{Min: n * 100, Max: n*100 + 99}, err
+	}
+
+	if lo, hi, ok := strings.Cut(s, "-"); ok {
+		min, err := num(lo)
+		if err != nil {
+			return Range{}, err
+		}
+		max, err := num(hi)
+		if err != nil {
+			return Range{}, err
+		}
+		if min > max {
+			return Range{}, fmt.Errorf("invalid range %q: min greater than max", s)
+		}
+		return Range{Min: min, Max: max}, nil
+	}
+	n, err := num(s)
+	return Range{Min: n, Max: n}, err
+}
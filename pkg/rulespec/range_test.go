@@ -0,0 +1,50 @@
+package rulespec_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"cdpnetool/pkg/rulespec"
+)
+
+func TestParseRanges(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []rulespec.Range
+	}{
+		{"404", []rulespec.Range{{Min: 404, Max: 404}}},
+		{"5xx", []rulespec.Range{{Min: 500, Max: 599}}},
+		{"5XX, 429", []rulespec.Range{{Min: 500, Max: 599}, {Min: 429, Max: 429}}},
+		{"200-299", []rulespec.Range{{Min: 200, Max: 299}}},
+		{">1000", []rulespec.Range{{Min: 1001, Max: math.MaxInt64}}},
+		{">=1000", []rulespec.Range{{Min: 1000, Max: math.MaxInt64}}},
+		{"<200", []rulespec.Range{{Min: 0, Max: 199}}},
+		{"<=200", []rulespec.Range{{Min: 0, Max: 200}}},
+	}
+	for _, tt := range tests {
+		got, err := rulespec.ParseRanges(tt.in)
+		if err != nil {
+			t.Errorf("ParseRanges(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRanges(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", " , ", "fast", "5x", "300-200", "<0", ">-1", "1-2-3"} {
+		if _, err := rulespec.ParseRanges(in); err == nil {
+			t.Errorf("ParseRanges(%q) should fail", in)
+		}
+	}
+}
+
+func TestRange_Contains(t *testing.T) {
+	r := rulespec.Range{Min: 500, Max: 599}
+	for v, want := range map[int64]bool{499: false, 500: true, 599: true, 600: false} {
+		if got := r.Contains(v); got != want {
+			t.Errorf("Contains(%d) = %v, want %v", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package rulespec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+// ScheduleWindow 定时生效的规则集时间窗口，按本地时间每天重复
+type ScheduleWindow struct {
+	Name   string  `json:"name,omitempty"` // 窗口名称，用于日志与切换记录
+	Start  string  `json:"start"`          // 开始时间 HH:MM（包含）
+	End    string  `json:"end"`            // 结束时间 HH:MM（不包含），早于开始时间时跨越午夜，与开始时间相同时全天生效
+	Config *Config `json:"config"`         // 窗口内生效的规则集
+}
+
+// Schedule 规则集定时切换计划：当前时间落在某个窗口内时使用该窗口的规则集（多个窗口重叠时取靠前的），否则使用 Default
+type Schedule struct {
+	Windows []ScheduleWindow `json:"windows"`
+	Default *Config          `json:"default,omitempty"` // 窗口之外生效的规则集，为 nil 时使用空规则集
+}
+
+// Validate 校验时间格式与各规则集的规则 ID，失败时返回 ErrInvalidConfig 或 *domain.RuleError
+func (s *Schedule) Validate() error {
+	if len(s.Windows) == 0 {
+		return fmt.Errorf("%w: 定时计划没有时间窗口", domain.ErrInvalidConfig)
+	}
+	for i, w := range s.Windows {
+		if _, err := parseClock(w.Start); err != nil {
+			return fmt.Errorf("%w: 窗口 %d 的开始时间: %v", domain.ErrInvalidConfig, i+1, err)
+		}
+		if _, err := parseClock(w.End); err != nil {
+			return fmt.Errorf("%w: 窗口 %d 的结束时间: %v", domain.ErrInvalidConfig, i+1, err)
+		}
+		if w.Config == nil {
+			return fmt.Errorf("%w: 窗口 %d 没有规则集", domain.ErrInvalidConfig, i+1)
+		}
+	}
+	for _, cfg := range s.Configs() {
+		if err := ValidateRuleIDs(cfg.Rules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Configs 返回计划中的所有规则集，不含为 nil 的 Default
+func (s *Schedule) Configs() []*Config {
+	configs := make([]*Config, 0, len(s.Windows)+1)
+	for _, w := range s.Windows {
+		configs = append(configs, w.Config)
+	}
+	if s.Default != nil {
+		configs = append(configs, s.Default)
+	}
+	return configs
+}
+
+// Active 返回 now 时生效的窗口序号与规则集，不在任何窗口内时序号为 -1。需先通过 Validate 校验
+func (s *Schedule) Active(now time.Time) (int, *Config) {
+	minute := now.Hour()*60 + now.Minute()
+	for i, w := range s.Windows {
+		start, _ := parseClock(w.Start)
+		end, _ := parseClock(w.End)
+		if inWindow(minute, start, end) {
+			return i, w.Config
+		}
+	}
+	if s.Default == nil {
+		return -1, &Config{}
+	}
+	return -1, s.Default
+}
+
+// NextBoundary 返回 now 之后最近的窗口开始或结束时间（本地时间整分钟）。需先通过 Validate 校验
+func (s *Schedule) NextBoundary(now time.Time) time.Time {
+	var next time.Time
+	for _, w := range s.Windows {
+		for _, clock := range []string{w.Start, w.End} {
+			m, _ := parseClock(clock)
+			t := time.Date(now.Year(), now.Month(), now.Day(), m/60, m%60, 0, 0, now.Location())
+			if !t.After(now) {
+				t = time.Date(now.Year(), now.Month(), now.Day()+1, m/60, m%60, 0, 0, now.Location())
+			}
+			if next.IsZero() || t.Before(next) {
+				next = t
+			}
+		}
+	}
+	return next
+}
+
+// inWindow 判断一天中的第 minute 分钟是否在 [start, end) 内，end 早于 start 时跨越午夜
+func inWindow(minute, start, end int) bool {
+	switch {
+	case start == end:
+		return true
+	case start < end:
+		return minute >= start && minute < end
+	default:
+		return minute >= start || minute < end
+	}
+}
+
+// parseClock 解析 HH:MM，返回一天中的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.New("时间格式应为 HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,154 @@
+package rulespec
+
+import (
+	"slices"
+	"strings"
+
+	"cdpnetool/pkg/domain"
+)
+
+// maxInterceptPatterns 拦截范围最多包含的模式数，超出时不再收窄，避免浏览器为每个请求逐一比对过多模式
+const maxInterceptPatterns = 256
+
+// scopeResourceTypes 可用于收窄拦截范围的资源类型。其余类型会按 URL 扩展名推断（如 .js 计为 script），
+// 浏览器上报的类型可能与之不同，无法据此收窄
+var scopeResourceTypes = []domain.ResourceType{domain.ResourceTypeDocument, domain.ResourceTypeXHR, domain.ResourceTypeFetch}
+
+// InterceptPatterns 根据已启用的规则推导浏览器需要暂停的请求范围：每条规则取 allOf 中第一个可转换为 URL 通配模式的条件，
+// 或在 anyOf 的条件都可转换时取它们的并集，再按 allOf 中的资源类型条件进一步收窄。
+// 任一规则无法收窄（没有可转换的条件、条件使用正则、行为会改写请求 URL）或模式过多时 scoped 为 false，表示需要拦截所有请求；
+// 没有已启用的规则时返回空范围
+func InterceptPatterns(rules []Rule) (patterns []domain.InterceptPattern, scoped bool) {
+	for i := range rules {
+		r := &rules[i]
+		if !r.Enabled {
+			continue
+		}
+		ps, ok := rulePatterns(r)
+		if !ok {
+			return nil, false
+		}
+		for _, p := range ps {
+			if !slices.Contains(patterns, p) {
+				patterns = append(patterns, p)
+			}
+		}
+		if len(patterns) > maxInterceptPatterns {
+			return nil, false
+		}
+	}
+	return patterns, true
+}
+
+// rulePatterns 返回覆盖规则所有可能匹配请求的范围
+func rulePatterns(r *Rule) ([]domain.InterceptPattern, bool) {
+	if slices.ContainsFunc(r.Actions, rewritesURL) {
+		return nil, false
+	}
+	urls, narrowed := matchURLPatterns(&r.Match)
+	types := matchResourceTypes(r.Match.AllOf)
+	if !narrowed && types == nil {
+		return nil, false
+	}
+	if types == nil {
+		types = []domain.ResourceType{""}
+	}
+	out := make([]domain.InterceptPattern, 0, len(urls)*len(types))
+	for _, u := range urls {
+		for _, t := range types {
+			out = append(out, domain.InterceptPattern{URLPattern: u, ResourceType: t})
+		}
+	}
+	return out, true
+}
+
+// matchURLPatterns 返回覆盖匹配规则的 URL 通配模式，无法按 URL 收窄时返回 "*" 与 false
+func matchURLPatterns(m *Match) ([]string, bool) {
+	for i := range m.AllOf {
+		if ps, ok := conditionURLPatterns(&m.AllOf[i]); ok {
+			return ps, true
+		}
+	}
+	if len(m.AnyOf) == 0 {
+		return []string{"*"}, false
+	}
+	var out []string
+	for i := range m.AnyOf {
+		ps, ok := conditionURLPatterns(&m.AnyOf[i])
+		if !ok {
+			return []string{"*"}, false
+		}
+		out = append(out, ps...)
+	}
+	return out, true
+}
+
+// conditionURLPatterns 将 URL 与域名条件转换为 URL 通配模式，正则条件与其他条件无法转换
+func conditionURLPatterns(c *Condition) ([]string, bool) {
+	if _, ok := c.RegexPattern(); ok {
+		return nil, false
+	}
+	v := escapeURLPattern(c.Value)
+	switch c.Type {
+	case ConditionURLEquals:
+		return []string{v}, true
+	case ConditionURLPrefix:
+		return []string{v + "*"}, true
+	case ConditionURLSuffix:
+		return []string{"*" + v}, true
+	case ConditionURLContains:
+		return []string{"*" + v + "*"}, true
+	case ConditionHost:
+		// 浏览器上报的 URL 中主机名为小写，与域名条件的规范化一致；模式分别覆盖域名本身与其子域名
+		host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(c.Value)), ".")
+		if host == "" {
+			return nil, false
+		}
+		host = escapeURLPattern(host)
+		return []string{"*://" + host + "*", "*." + host + "*"}, true
+	}
+	return nil, false
+}
+
+// matchResourceTypes 返回 allOf 中第一个可用于收窄的资源类型条件所列的类型，没有时返回 nil
+func matchResourceTypes(conds []Condition) []domain.ResourceType {
+	for i := range conds {
+		c := &conds[i]
+		if c.Type != ConditionResourceType || len(c.Values) == 0 {
+			continue
+		}
+		types := make([]domain.ResourceType, 0, len(c.Values))
+		for _, v := range c.Values {
+			t := domain.ResourceType(strings.ToLower(v))
+			if !slices.Contains(scopeResourceTypes, t) {
+				types = nil
+				break
+			}
+			types = append(types, t)
+		}
+		if types != nil {
+			return types
+		}
+	}
+	return nil
+}
+
+// rewritesURL 判断行为是否可能改写请求 URL：改写后浏览器按新 URL 决定是否在响应阶段暂停，不能按原 URL 收窄
+func rewritesURL(a Action) bool {
+	switch a.Type {
+	case ActionSetUrl, ActionSetQueryParam, ActionRemoveQueryParam, ActionMapRemote, ActionCanary, ActionScript:
+		return true
+	case ActionVariant:
+		for _, v := range a.Variants {
+			if slices.ContainsFunc(v.Actions, rewritesURL) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// escapeURLPattern 转义 URL 通配模式中的特殊字符
+func escapeURLPattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
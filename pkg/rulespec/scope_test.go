@@ -0,0 +1,92 @@
+package rulespec_test
+
+import (
+	"reflect"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+func TestInterceptPatterns(t *testing.T) {
+	rule := func(match rulespec.Match, actions ...rulespec.Action) rulespec.Rule {
+		return rulespec.Rule{ID: "r", Enabled: true, Stage: rulespec.StageRequest, Match: match, Actions: actions}
+	}
+	allOf := func(conds ...rulespec.Condition) rulespec.Match {
+		return rulespec.Match{AllOf: conds}
+	}
+	block := rulespec.Action{Type: rulespec.ActionBlock}
+
+	tests := []struct {
+		name   string
+		rules  []rulespec.Rule
+		want   []domain.InterceptPattern
+		scoped bool
+	}{
+		{"no rules", nil, nil, true},
+		{"disabled rule", []rulespec.Rule{{Enabled: false}}, nil, true},
+		{
+			"url conditions",
+			[]rulespec.Rule{
+				rule(allOf(rulespec.Condition{Type: rulespec.ConditionURLEquals, Value: "https://a.com/x?y=*"}), block),
+				rule(allOf(rulespec.Condition{Type: rulespec.ConditionURLSuffix, Value: ".json"}), block),
+				rule(allOf(rulespec.Condition{Type: rulespec.ConditionURLContains, Value: "/api/"}), block),
+			},
+			[]domain.InterceptPattern{{URLPattern: `https://a.com/x\?y=\*`}, {URLPattern: "*.json"}, {URLPattern: "*/api/*"}},
+			true,
+		},
+		{
+			"host and resource types",
+			[]rulespec.Rule{rule(allOf(
+				rulespec.Condition{Type: rulespec.ConditionMethod, Values: []string{"POST"}},
+				rulespec.Condition{Type: rulespec.ConditionHost, Value: "API.Example.com."},
+				rulespec.Condition{Type: rulespec.ConditionResourceType, Values: []string{"XHR"}},
+			), block)},
+			[]domain.InterceptPattern{
+				{URLPattern: "*://api.example.com*", ResourceType: domain.ResourceTypeXHR},
+				{URLPattern: "*.api.example.com*", ResourceType: domain.ResourceTypeXHR},
+			},
+			true,
+		},
+		{
+			"anyOf union",
+			[]rulespec.Rule{rule(rulespec.Match{AnyOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLPrefix, Value: "https://a.com/"},
+				{Type: rulespec.ConditionURLPrefix, Value: "https://b.com/"},
+			}}, block)},
+			[]domain.InterceptPattern{{URLPattern: "https://a.com/*"}, {URLPattern: "https://b.com/*"}},
+			true,
+		},
+		{
+			"resource type only",
+			[]rulespec.Rule{rule(allOf(rulespec.Condition{Type: rulespec.ConditionResourceType, Values: []string{"document"}}), block)},
+			[]domain.InterceptPattern{{URLPattern: "*", ResourceType: domain.ResourceTypeDocument}},
+			true,
+		},
+		{"no conditions", []rulespec.Rule{rule(rulespec.Match{}, block)}, nil, false},
+		{"regex value", []rulespec.Rule{rule(allOf(rulespec.Condition{Type: rulespec.ConditionURLPrefix, Value: "regex:^https://"}), block)}, nil, false},
+		{"inferred resource type", []rulespec.Rule{rule(allOf(rulespec.Condition{Type: rulespec.ConditionResourceType, Values: []string{"script"}}), block)}, nil, false},
+		{
+			"anyOf with unscopable condition",
+			[]rulespec.Rule{rule(rulespec.Match{AnyOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLPrefix, Value: "https://a.com/"},
+				{Type: rulespec.ConditionHeaderExists, Name: "X-Debug"},
+			}}, block)},
+			nil, false,
+		},
+		{
+			"rewrites url",
+			[]rulespec.Rule{rule(
+				allOf(rulespec.Condition{Type: rulespec.ConditionURLPrefix, Value: "https://a.com/"}),
+				rulespec.Action{Type: rulespec.ActionVariant, Variants: []rulespec.Variant{{Actions: []rulespec.Action{{Type: rulespec.ActionSetQueryParam}}}}},
+			)},
+			nil, false,
+		},
+	}
+	for _, tt := range tests {
+		got, scoped := rulespec.InterceptPatterns(tt.rules)
+		if scoped != tt.scoped || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: InterceptPatterns() = %v, %v, want %v, %v", tt.name, got, scoped, tt.want, tt.scoped)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package rulespec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// setSecurityHeaders 行为的内置预设
+const (
+	SecurityPresetStrict   = "strict"   // 严格策略：仅允许同源资源，禁止嵌入
+	SecurityPresetBaseline = "baseline" // 基础策略：常见站点可直接启用，不限制资源来源
+	SecurityPresetStrip    = "strip"    // 移除全部安全头部，用于测试站点在缺少防护时的表现
+)
+
+// securityHeaderNames setSecurityHeaders 管理的安全相关响应头，strip 预设会全部移除
+var securityHeaderNames = []string{
+	"Content-Security-Policy",
+	"Content-Security-Policy-Report-Only",
+	"Strict-Transport-Security",
+	"X-Frame-Options",
+	"X-Content-Type-Options",
+	"X-XSS-Protection",
+	"Referrer-Policy",
+	"Permissions-Policy",
+	"Cross-Origin-Opener-Policy",
+	"Cross-Origin-Embedder-Policy",
+	"Cross-Origin-Resource-Policy",
+}
+
+// securityPresets 预设名到头部集合的映射
+var securityPresets = map[string]map[string]string{
+	SecurityPresetStrict: {
+		"Content-Security-Policy":      "default-src 'self'; object-src 'none'; base-uri 'self'; frame-ancestors 'none'",
+		"Strict-Transport-Security":    "max-age=63072000; includeSubDomains; preload",
+		"X-Frame-Options":              "DENY",
+		"X-Content-Type-Options":       "nosniff",
+		"Referrer-Policy":              "no-referrer",
+		"Permissions-Policy":           "camera=(), microphone=(), geolocation=()",
+		"Cross-Origin-Opener-Policy":   "same-origin",
+		"Cross-Origin-Resource-Policy": "same-origin",
+	},
+	SecurityPresetBaseline: {
+		"Strict-Transport-Security": "max-age=31536000",
+		"X-Frame-Options":           "SAMEORIGIN",
+		"X-Content-Type-Options":    "nosniff",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+	},
+	SecurityPresetStrip: {},
+}
+
+// SecurityHeaders setSecurityHeaders 行为解析后的头部变更
+type SecurityHeaders struct {
+	Set    map[string]string // 需要设置的头部
+	Remove []string          // 需要移除的头部，不区分大小写
+}
+
+// ResolveSecurityHeaders 解析 setSecurityHeaders 的预设与自定义头部。
+// 预设为空时仅应用 overrides；overrides 在预设之后生效，值为空表示移除该头部
+func ResolveSecurityHeaders(preset string, overrides map[string]string) (SecurityHeaders, error) {
+	preset = strings.TrimSpace(preset)
+	if preset == "" && len(overrides) == 0 {
+		return SecurityHeaders{}, fmt.Errorf("empty security headers bundle")
+	}
+
+	bundle := SecurityHeaders{Set: make(map[string]string)}
+	if preset != "" {
+		headers, ok := securityPresets[preset]
+		if !ok {
+			return SecurityHeaders{}, fmt.Errorf("unknown security headers preset %q: want %s, %s or %s",
+				preset, SecurityPresetStrict, SecurityPresetBaseline, SecurityPresetStrip)
+		}
+		// 预设接管全部安全头部：未在预设中设置的一律移除，避免与原有策略混合
+		for _, name := range securityHeaderNames {
+			if v, ok := headers[name]; ok {
+				bundle.Set[name] = v
+			} else {
+				bundle.Remove = append(bundle.Remove, name)
+			}
+		}
+	}
+
+	for name, v := range overrides {
+		for k := range bundle.Set {
+			if strings.EqualFold(k, name) {
+				delete(bundle.Set, k)
+			}
+		}
+		if v == "" {
+			bundle.Remove = append(bundle.Remove, name)
+		} else {
+			bundle.Set[name] = v
+		}
+	}
+	return bundle, nil
+}
@@ -0,0 +1,70 @@
+package rulespec
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"cdpnetool/pkg/domain"
+)
+
+// SharePrefix 分享码前缀，带版本号以便日后调整编码方式
+const SharePrefix = "cdpn1:"
+
+// maxShareSize 分享码解压后的最大字节数，防止恶意构造的分享码占用过多内存
+const maxShareSize = 8 << 20
+
+// EncodeShareCode 将配置编码为分享码：紧凑 JSON 经 DEFLATE 压缩后以 URL 安全的 base64 编码，便于通过聊天工具传递
+func EncodeShareCode(cfg *Config) (string, error) {
+	if cfg == nil {
+		return "", fmt.Errorf("%w: 配置为空", domain.ErrInvalidConfig)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return SharePrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeShareCode 解码分享码并解析为配置，旧版本配置会自动迁移。
+// 分享码中的空白字符（如聊天工具自动换行）与 base64 填充会被忽略
+func DecodeShareCode(code string) (*Config, error) {
+	code = strings.Join(strings.Fields(code), "")
+	payload, ok := strings.CutPrefix(code, SharePrefix)
+	if !ok {
+		return nil, fmt.Errorf("%w: 不是有效的分享码", domain.ErrInvalidConfig)
+	}
+	compressed, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(payload, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: 分享码已损坏: %v", domain.ErrInvalidConfig, err)
+	}
+	r := flate.NewReader(bytes.NewReader(compressed))
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, maxShareSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: 分享码已损坏: %v", domain.ErrInvalidConfig, err)
+	}
+	if len(data) > maxShareSize {
+		return nil, fmt.Errorf("%w: 分享码内容超过 %d 字节", domain.ErrInvalidConfig, maxShareSize)
+	}
+	cfg, _, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidConfig, err)
+	}
+	return cfg, nil
+}
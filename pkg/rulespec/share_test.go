@@ -0,0 +1,130 @@
+package rulespec_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"cdpnetool/pkg/domain"
+	"cdpnetool/pkg/rulespec"
+)
+
+// shareConfig 用于分享码测试的配置
+func shareConfig() *rulespec.Config {
+	cfg := rulespec.NewConfig("share")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "block-ads", Name: "屏蔽广告", Enabled: true, Priority: 10, Stage: rulespec.StageRequest,
+		Match:   rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionURLContains, Value: "/ads"}}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403, Body: "blocked"}},
+	}}
+	return cfg
+}
+
+// shareCode 将任意内容按分享码格式压缩编码
+func shareCode(t *testing.T, data []byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return rulespec.SharePrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestShareCode_RoundTrip(t *testing.T) {
+	code, err := rulespec.EncodeShareCode(shareConfig())
+	if err != nil {
+		t.Fatalf("EncodeShareCode() error = %v", err)
+	}
+	if !strings.HasPrefix(code, rulespec.SharePrefix) || strings.ContainsAny(code, "+/= \n") {
+		t.Errorf("got %q, want URL-safe code without padding", code)
+	}
+	cfg, err := rulespec.DecodeShareCode(code)
+	if err != nil {
+		t.Fatalf("DecodeShareCode() error = %v", err)
+	}
+	if cfg.Name != "share" || len(cfg.Rules) != 1 {
+		t.Fatalf("got %+v, want the shared config", cfg)
+	}
+	r := cfg.Rules[0]
+	if r.ID != "block-ads" || r.Name != "屏蔽广告" || r.Priority != 10 || len(r.Actions) != 1 || r.Actions[0].StatusCode != 403 {
+		t.Errorf("got rule %+v, want block-ads", r)
+	}
+
+	if _, err := rulespec.EncodeShareCode(nil); !errors.Is(err, domain.ErrInvalidConfig) {
+		t.Errorf("EncodeShareCode(nil): got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestShareCode_ToleratesWhitespaceAndPadding(t *testing.T) {
+	code, err := rulespec.EncodeShareCode(shareConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模拟聊天工具的自动换行、首尾空白与补齐的 base64 填充
+	var wrapped strings.Builder
+	wrapped.WriteString("  \n")
+	for i := 0; i < len(code); i += 20 {
+		wrapped.WriteString(code[i:min(i+20, len(code))])
+		wrapped.WriteString("\r\n\t")
+	}
+	wrapped.WriteString("==  ")
+	cfg, err := rulespec.DecodeShareCode(wrapped.String())
+	if err != nil {
+		t.Fatalf("DecodeShareCode() error = %v", err)
+	}
+	if len(cfg.Rules) != 1 || cfg.Rules[0].ID != "block-ads" {
+		t.Errorf("got %+v, want the shared config", cfg)
+	}
+}
+
+func TestShareCode_Invalid(t *testing.T) {
+	code, err := rulespec.EncodeShareCode(shareConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := strings.TrimPrefix(code, rulespec.SharePrefix)
+	tests := map[string]string{
+		"empty":           "",
+		"no prefix":       payload,
+		"wrong prefix":    "cdpx1:" + payload,
+		"future version":  "cdpn2:" + payload,
+		"bad base64":      rulespec.SharePrefix + "!!!" + payload,
+		"truncated":       code[:len(code)/2],
+		"not deflate":     rulespec.SharePrefix + base64.RawURLEncoding.EncodeToString([]byte("plain text, not compressed")),
+		"not config json": shareCode(t, []byte("not json")),
+	}
+	for name, in := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := rulespec.DecodeShareCode(in); !errors.Is(err, domain.ErrInvalidConfig) {
+				t.Errorf("got %v, want ErrInvalidConfig", err)
+			}
+		})
+	}
+}
+
+func TestShareCode_DecompressionLimit(t *testing.T) {
+	// 64 MiB 的空白压缩后只有数十 KB，解码时不能整体解压
+	const size = 64 << 20
+	bomb := shareCode(t, bytes.Repeat([]byte(" "), size))
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	_, err := rulespec.DecodeShareCode(bomb)
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, domain.ErrInvalidConfig) || !strings.Contains(err.Error(), "超过") {
+		t.Errorf("got %v, want size limit error", err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/2 {
+		t.Errorf("decoding allocated %d bytes, want it bounded by the decompression limit", allocated)
+	}
+}
@@ -2,9 +2,14 @@ package rulespec
 
 import (
 	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
+
+	"cdpnetool/pkg/domain"
 )
 
 // 配置版本常量
@@ -69,6 +74,21 @@ func ValidateRuleID(id string) error {
 	return nil
 }
 
+// ValidateRuleIDs 校验规则 ID 格式和唯一性，失败时返回 *domain.RuleError
+func ValidateRuleIDs(rules []Rule) error {
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if err := ValidateRuleID(rule.ID); err != nil {
+			return &domain.RuleError{RuleID: rule.ID, Err: err}
+		}
+		if seen[rule.ID] {
+			return &domain.RuleError{RuleID: rule.ID, Err: errors.New("规则 ID 重复")}
+		}
+		seen[rule.ID] = true
+	}
+	return nil
+}
+
 // generateRandomString 生成指定长度的随机字符串（字母+数字）
 func generateRandomString(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
@@ -100,17 +120,22 @@ type Stage string
 const (
 	StageRequest  Stage = "request"  // 请求阶段
 	StageResponse Stage = "response" // 响应阶段
+	// StageWebSocket WebSocket 消息帧阶段：按所属连接的 URL、握手请求头与帧载荷匹配收发的帧，
+	// 帧无法通过 CDP 修改，规则只记录匹配，不执行行为；改写握手请使用请求阶段规则
+	StageWebSocket Stage = "websocket"
 )
 
 // Rule 规则定义
 type Rule struct {
-	ID       string   `json:"id"`       // 规则唯一标识符
-	Name     string   `json:"name"`     // 规则名称
-	Enabled  bool     `json:"enabled"`  // 是否启用
-	Priority int      `json:"priority"` // 优先级，数值越大越先执行
-	Stage    Stage    `json:"stage"`    // 生命周期阶段
-	Match    Match    `json:"match"`    // 匹配规则
-	Actions  []Action `json:"actions"`  // 执行行为列表
+	ID       string   `json:"id"`                 // 规则唯一标识符
+	Name     string   `json:"name"`               // 规则名称
+	Enabled  bool     `json:"enabled"`            // 是否启用
+	Priority int      `json:"priority"`           // 优先级，数值越大越先执行
+	Stage    Stage    `json:"stage"`              // 生命周期阶段
+	Match    Match    `json:"match"`              // 匹配规则
+	Actions  []Action `json:"actions"`            // 执行行为列表
+	NoSniff  bool     `json:"noSniff,omitempty"`  // 关闭消息体内容嗅探，文本与 JSON 行为仅按 Content-Type 判断消息体类型
+	Terminal bool     `json:"terminal,omitempty"` // 终止规则：命中后不再匹配同阶段中排在其后的规则
 }
 
 // NewRule 创建一个新的空规则，index 为当前规则列表中的索引
@@ -142,6 +167,7 @@ const (
 	ConditionURLSuffix   ConditionType = "urlSuffix"   // URL 后缀匹配
 	ConditionURLContains ConditionType = "urlContains" // URL 包含匹配
 	ConditionURLRegex    ConditionType = "urlRegex"    // URL 正则匹配
+	ConditionHost        ConditionType = "host"        // 域名或其子域名匹配
 
 	// Method 和 ResourceType 条件类型
 	ConditionMethod       ConditionType = "method"       // HTTP 方法
@@ -172,16 +198,70 @@ const (
 	ConditionBodyContains ConditionType = "bodyContains" // Body 包含
 	ConditionBodyRegex    ConditionType = "bodyRegex"    // Body 正则
 	ConditionBodyJsonPath ConditionType = "bodyJsonPath" // JSON Path 匹配
+
+	// 规则依赖条件类型
+	ConditionRuleMatched ConditionType = "ruleMatched" // 指定规则此前已匹配过
+
+	// 响应条件类型，仅在响应阶段可用
+	ConditionStatusCode   ConditionType = "statusCode"   // 响应状态码在范围内
+	ConditionContentType  ConditionType = "contentType"  // 响应 Content-Type 包含（不区分大小写）
+	ConditionResponseTime ConditionType = "responseTime" // 请求发出到收到响应头的耗时（毫秒）在范围内
+)
+
+// MatchScope ruleMatched 条件回溯的范围
+type MatchScope string
+
+const (
+	ScopeSession  MatchScope = "session"  // 会话内任意时刻
+	ScopePageLoad MatchScope = "pageLoad" // 最近一次页面加载（文档请求）之后
 )
 
 // Condition 条件定义
 type Condition struct {
 	Type    ConditionType `json:"type"`              // 条件类型
-	Value   string        `json:"value,omitempty"`   // 匹配值 (url*, *Equals, *Contains, bodyContains)
+	Value   string        `json:"value,omitempty"`   // 匹配值 (url*, host, *Equals, *Contains, bodyContains, contentType)，以 regex: 开头时按正则匹配；ruleMatched 为规则 ID；statusCode、responseTime 为范围列表
 	Values  []string      `json:"values,omitempty"`  // 匹配值列表 (method, resourceType)
 	Pattern string        `json:"pattern,omitempty"` // 正则表达式 (*Regex)
 	Name    string        `json:"name,omitempty"`    // 键名 (header*, query*, cookie*)
 	Path    string        `json:"path,omitempty"`    // JSON Path (bodyJsonPath)
+	Scope   MatchScope    `json:"scope,omitempty"`   // 回溯范围 (ruleMatched)，默认为 session
+}
+
+// RegexPrefix 条件匹配值的正则前缀，如 "regex:^/api/v[0-9]+/"
+const RegexPrefix = "regex:"
+
+// RegexPattern 返回条件使用的正则表达式：*Regex 条件为 Pattern；
+// URL、*Equals、*Contains、bodyContains、bodyJsonPath 与 contentType 条件的匹配值以 regex: 开头时为去掉前缀后的部分。
+// 正则在文本中查找匹配，需要完整匹配时使用 ^ 与 $
+func (c *Condition) RegexPattern() (string, bool) {
+	switch c.Type {
+	case ConditionURLRegex, ConditionHeaderRegex, ConditionQueryRegex, ConditionCookieRegex, ConditionBodyRegex:
+		return c.Pattern, true
+	case ConditionURLEquals, ConditionURLPrefix, ConditionURLSuffix, ConditionURLContains,
+		ConditionHeaderEquals, ConditionHeaderContains, ConditionQueryEquals, ConditionQueryContains,
+		ConditionCookieEquals, ConditionCookieContains, ConditionBodyContains, ConditionBodyJsonPath, ConditionContentType:
+		if strings.HasPrefix(c.Value, RegexPrefix) {
+			return strings.TrimPrefix(c.Value, RegexPrefix), true
+		}
+	}
+	return "", false
+}
+
+// IsResponseCondition 判断是否为只能在响应阶段评估的条件
+func (c *Condition) IsResponseCondition() bool {
+	switch c.Type {
+	case ConditionStatusCode, ConditionContentType, ConditionResponseTime:
+		return true
+	}
+	return false
+}
+
+// GetScope 获取 ruleMatched 条件的回溯范围，默认为 session
+func (c *Condition) GetScope() MatchScope {
+	if c.Scope == "" {
+		return ScopeSession
+	}
+	return c.Scope
 }
 
 // ActionType 行为类型
@@ -195,9 +275,21 @@ const (
 	ActionRemoveQueryParam ActionType = "removeQueryParam" // 移除查询参数
 	ActionSetCookie        ActionType = "setCookie"        // 设置 Cookie
 	ActionRemoveCookie     ActionType = "removeCookie"     // 移除 Cookie
+	ActionSetBrowserCookie ActionType = "setBrowserCookie" // 将 Cookie 写入浏览器的 Cookie 存储，并为当前请求设置该 Cookie
 	ActionSetFormField     ActionType = "setFormField"     // 设置表单字段
 	ActionRemoveFormField  ActionType = "removeFormField"  // 移除表单字段
+	ActionSetFormFile      ActionType = "setFormFile"      // 替换 multipart 表单中上传文件的内容
+	ActionSetUserAgent     ActionType = "setUserAgent"     // 设置 User-Agent 及 Sec-CH-UA 客户端提示
+	ActionMirror           ActionType = "mirror"           // 将请求异步复制到影子后端，不影响真实请求
+	ActionCanary           ActionType = "canary"           // 按百分比将请求路由到备用后端
+	ActionMapRemote        ActionType = "mapRemote"        // 改写请求的协议、主机、端口与路径前缀，保留其余部分
+	ActionSign             ActionType = "sign"             // 在所有修改完成后重新计算请求签名
+	ActionNotModified      ActionType = "notModified"      // 以伪造的 304 响应条件请求
+	ActionRedirect         ActionType = "redirect"         // 以 30x 响应重定向到按模板生成的地址
+	ActionMapLocal         ActionType = "mapLocal"         // 以本地文件或目录中的文件内容应答请求
 	ActionBlock            ActionType = "block"            // 拦截请求
+	ActionRateLimit        ActionType = "rateLimit"        // 按键计数，超出窗口内阈值后返回 429
+	ActionAuth             ActionType = "auth"             // 应答站点或上游代理的认证质询（Basic、NTLM 等），不修改请求
 
 	// 请求/响应阶段通用行为类型
 	ActionSetHeader       ActionType = "setHeader"       // 设置头部
@@ -206,9 +298,27 @@ const (
 	ActionAppendBody      ActionType = "appendBody"      // 追加 Body
 	ActionReplaceBodyText ActionType = "replaceBodyText" // 字符串替换 Body
 	ActionPatchBodyJson   ActionType = "patchBodyJson"   // JSON Patch 修改 Body
+	ActionJqTransform     ActionType = "jqTransform"     // 以 jq 程序转换 JSON Body
+	ActionVariant         ActionType = "variant"         // 按客户端固定选择一组变体行为执行
+	ActionStripValidators ActionType = "stripValidators" // 移除缓存验证头部，强制返回完整响应
+	ActionScript          ActionType = "script"          // 执行 JavaScript 脚本，按返回的对象修改请求或响应
+	ActionThrottle        ActionType = "throttle"        // 延迟放行并按带宽上限模拟慢速网络
+	ActionFail            ActionType = "fail"            // 以网络错误（如连接重置）结束请求，浏览器收不到任何响应
+	ActionTruncateBody    ActionType = "truncateBody"    // 截断消息体，模拟传输中断
+	ActionCorruptJson     ActionType = "corruptJson"     // 破坏 JSON 消息体使其无法解析
 
 	// 响应阶段行为类型
 	ActionSetStatus ActionType = "setStatus" // 设置响应状态码
+	ActionSetCache  ActionType = "setCache"  // 按预设设置或移除 Cache-Control、Expires、Pragma 响应头
+	ActionSaveBody  ActionType = "saveBody"  // 将最终响应体保存到本地目录
+	ActionMaskJson  ActionType = "maskJson"  // 按路径模式移除或置空 JSON 响应中的字段
+
+	ActionRandomStatus ActionType = "randomStatus" // 将响应状态码随机替换为候选状态码之一
+
+	ActionSetSecurityHeaders ActionType = "setSecurityHeaders" // 按预设一次性设置或移除 CSP、HSTS、X-Frame-Options 等安全响应头
+
+	ActionValidateSchema ActionType = "validateSchema" // 按 JSON Schema 校验响应体并记录违规
+	ActionAugmentJson    ActionType = "augmentJson"    // 获取次级数据源的 JSON 并合并到响应体
 )
 
 // BodyEncoding Body 编码方式
@@ -219,20 +329,179 @@ const (
 	BodyEncodingBase64 BodyEncoding = "base64" // Base64 编码
 )
 
+// MaskMode JSON 字段屏蔽方式
+type MaskMode string
+
+const (
+	MaskModeRemove MaskMode = "remove" // 移除字段
+	MaskModeNull   MaskMode = "null"   // 将字段值置为 null
+)
+
+// ViolationMode 响应体不符合 JSON Schema 时的处理方式
+type ViolationMode string
+
+const (
+	ViolationReport ViolationMode = "report" // 仅在事件中记录违规
+	ViolationFlag   ViolationMode = "flag"   // 记录违规并添加 X-Schema-Violation 响应头
+	ViolationFail   ViolationMode = "fail"   // 记录违规并将响应替换为 502 与违规详情
+)
+
+// RateLimitKey rateLimit 行为的计数键来源
+type RateLimitKey string
+
+const (
+	RateKeyURL    RateLimitKey = "url"    // 去除查询参数的请求 URL
+	RateKeyHeader RateLimitKey = "header" // 指定请求头的值
+	RateKeyCookie RateLimitKey = "cookie" // 指定 Cookie 的值
+)
+
+// DefaultRateWindow rateLimit 行为的默认计数窗口
+const DefaultRateWindow = time.Minute
+
+// DefaultAugmentTimeout augmentJson 行为获取次级数据源的默认超时
+const DefaultAugmentTimeout = 3 * time.Second
+
+// StickyKey variant 行为区分客户端的键来源
+type StickyKey string
+
+const (
+	StickyCookie StickyKey = "cookie" // 指定 Cookie 的值
+	StickyHeader StickyKey = "header" // 指定请求头的值
+)
+
+// SignMethod sign 行为的签名方式
+type SignMethod string
+
+const (
+	SignHMAC     SignMethod = "hmac"     // 按模板计算 HMAC 并写入请求头
+	SignAWSSigV4 SignMethod = "awsSigV4" // AWS Signature Version 4
+)
+
+// SignSpec sign 行为的签名参数，密钥均以环境变量名引用，不直接保存在配置中
+type SignSpec struct {
+	Method    SignMethod `json:"method"`              // 签名方式
+	SecretEnv string     `json:"secretEnv,omitempty"` // 保存 HMAC 密钥的环境变量名 (hmac)
+	Header    string     `json:"header,omitempty"`    // 写入签名的请求头 (hmac)，默认 X-Signature
+	Algorithm string     `json:"algorithm,omitempty"` // sha256、sha1、sha512 (hmac)，默认 sha256
+	Encoding  string     `json:"encoding,omitempty"`  // hex、base64 (hmac)，默认 hex
+	Payload   string     `json:"payload,omitempty"`   // 待签名内容模板 (hmac)，默认 {body}
+	Template  string     `json:"template,omitempty"`  // 请求头值模板 (hmac)，默认 {signature}
+
+	Region          string `json:"region,omitempty"`          // AWS 区域 (awsSigV4)
+	Service         string `json:"service,omitempty"`         // AWS 服务名 (awsSigV4)，如 execute-api、s3
+	AccessKeyEnv    string `json:"accessKeyEnv,omitempty"`    // 访问密钥 ID 的环境变量名 (awsSigV4)，默认 AWS_ACCESS_KEY_ID
+	SecretKeyEnv    string `json:"secretKeyEnv,omitempty"`    // 私有访问密钥的环境变量名 (awsSigV4)，默认 AWS_SECRET_ACCESS_KEY
+	SessionTokenEnv string `json:"sessionTokenEnv,omitempty"` // 会话令牌的环境变量名 (awsSigV4)，默认 AWS_SESSION_TOKEN
+}
+
+// AuthMode auth 行为应答认证质询的方式
+type AuthMode string
+
+const (
+	AuthModeProvide AuthMode = "provide" // 以规则中的凭据自动应答
+	AuthModeApprove AuthMode = "approve" // 暂停质询等待人工提供凭据、取消或交由浏览器处理
+	AuthModeCancel  AuthMode = "cancel"  // 取消认证，页面收到 401/407 响应
+)
+
+// AuthSpec auth 行为的应答方式与凭据，密码可以环境变量名引用，避免保存在配置中
+type AuthSpec struct {
+	Mode        AuthMode          `json:"mode,omitempty"`        // 应答方式，默认 provide
+	Source      domain.AuthSource `json:"source,omitempty"`      // 应答的质询来源：server、proxy，为空时都应答
+	Username    string            `json:"username,omitempty"`    // 用户名 (provide)；approve 时作为人工确认的默认凭据
+	Password    string            `json:"password,omitempty"`    // 密码
+	PasswordEnv string            `json:"passwordEnv,omitempty"` // 保存密码的环境变量名，设置后取代 password
+	TimeoutMS   int64             `json:"timeoutMS,omitempty"`   // 等待人工处理的最长时间 (approve)，为 0 时使用 domain.DefaultAuthTimeout
+}
+
+// CookieSpec setBrowserCookie 行为写入浏览器的 Cookie 属性，名称与值取自行为的 name、value
+type CookieSpec struct {
+	Domain   string                `json:"domain,omitempty"`   // Cookie 域名，为空时仅对当前请求的主机生效
+	Path     string                `json:"path,omitempty"`     // Cookie 路径，为空时由浏览器按请求 URL 推导
+	MaxAge   int                   `json:"maxAge,omitempty"`   // 有效秒数，为 0 时为会话 Cookie
+	HTTPOnly bool                  `json:"httpOnly,omitempty"` // 禁止页面脚本读取
+	Secure   bool                  `json:"secure,omitempty"`   // 仅在 HTTPS 请求中携带
+	SameSite domain.CookieSameSite `json:"sameSite,omitempty"` // Strict、Lax、None，为空时由浏览器决定
+}
+
+// AugmentSpec augmentJson 行为的次级数据源与合并方式
+type AugmentSpec struct {
+	Source  string            `json:"source"`            // 次级数据源：http(s) URL 或本地 JSON 文件路径（可带 file:// 前缀）
+	Keys    map[string]string `json:"keys,omitempty"`    // 合并映射：响应中的目标路径 -> 次级数据中的来源路径，来源路径为空表示整个文档；未设置时整体合并到响应根对象
+	Timeout string            `json:"timeout,omitempty"` // 获取超时时长，如 500ms、2s，默认 3s
+}
+
+// MapRemoteSpec mapRemote 行为改写后的地址，为空的部分保留请求中的原值
+type MapRemoteSpec struct {
+	Scheme        string `json:"scheme,omitempty"`        // 新协议，如 https
+	Host          string `json:"host,omitempty"`          // 新主机名，不含端口
+	Port          int    `json:"port,omitempty"`          // 新端口，为 0 时保留原端口，与协议默认端口相同时省略
+	PathPrefix    string `json:"pathPrefix,omitempty"`    // 要替换的原路径前缀，按路径段匹配，不匹配时不改写请求
+	NewPathPrefix string `json:"newPathPrefix,omitempty"` // 替换后的路径前缀，为空时去掉原前缀
+}
+
+// DelayDistribution throttle 行为延迟的分布类型
+type DelayDistribution string
+
+const (
+	DelayUniform    DelayDistribution = "uniform"    // 在 minMS 与 maxMS 之间均匀分布
+	DelayNormal     DelayDistribution = "normal"     // 以 meanMS 为均值、stdDevMS 为标准差的正态分布，按 minMS、maxMS 截断
+	DelayPercentile DelayDistribution = "percentile" // 按百分位表在相邻两项之间线性插值
+)
+
+// DelaySpec throttle 行为的延迟分布，设置后取代固定的 latencyMS，每条消息独立抽样
+type DelaySpec struct {
+	Distribution DelayDistribution `json:"distribution,omitempty"` // 分布类型，默认 uniform
+	MinMS        int               `json:"minMS,omitempty"`        // 最小延迟毫秒数 (uniform, normal)
+	MaxMS        int               `json:"maxMS,omitempty"`        // 最大延迟毫秒数 (uniform, normal)，normal 为 0 时不设上限
+	MeanMS       int               `json:"meanMS,omitempty"`       // 延迟均值毫秒数 (normal)
+	StdDevMS     int               `json:"stdDevMS,omitempty"`     // 延迟标准差毫秒数 (normal)
+	Percentiles  []DelayPoint      `json:"percentiles,omitempty"`  // 百分位表 (percentile)，按百分位升序排列
+}
+
+// DelayPoint 延迟百分位表中的一项，如 {"p": 99, "ms": 800} 表示 99% 的消息延迟不超过 800 毫秒
+type DelayPoint struct {
+	P  float64 `json:"p"`  // 百分位，0-100
+	MS int     `json:"ms"` // 该百分位的延迟毫秒数
+}
+
 // Action 行为定义
 type Action struct {
-	Type         ActionType        `json:"type"`                   // 行为类型
-	Value        any               `json:"value,omitempty"`        // 目标值 (setUrl, setMethod, setStatus, setBody)
-	Name         string            `json:"name,omitempty"`         // 键名 (setHeader, removeHeader, setQueryParam, setCookie, setFormField)
-	Encoding     BodyEncoding      `json:"encoding,omitempty"`     // Body 编码方式 (setBody)
-	Search       string            `json:"search,omitempty"`       // 搜索内容 (replaceBodyText)
-	Replace      string            `json:"replace,omitempty"`      // 替换内容 (replaceBodyText)
-	ReplaceAll   bool              `json:"replaceAll,omitempty"`   // 是否全部替换 (replaceBodyText)
-	Patches      []JSONPatchOp     `json:"patches,omitempty"`      // JSON Patch 操作列表 (patchBodyJson)
-	StatusCode   int               `json:"statusCode,omitempty"`   // HTTP 状态码 (block)
-	Headers      map[string]string `json:"headers,omitempty"`      // 响应头 (block)
-	Body         string            `json:"body,omitempty"`         // 响应体 (block)
-	BodyEncoding BodyEncoding      `json:"bodyEncoding,omitempty"` // Body 编码方式 (block)
+	Type           ActionType        `json:"type"`                     // 行为类型
+	Value          any               `json:"value,omitempty"`          // 目标值 (setUrl, setMethod, setStatus, setBody, setFormFile 为文件内容, setUserAgent, mirror, canary 为备用后端地址, setCache 为缓存预设, setSecurityHeaders 为安全头部预设, saveBody 为保存目录, jqTransform 为 jq 程序, redirect 为 Location 模板, mapLocal 为本地文件或目录, script 为 JavaScript 脚本, fail 为网络错误原因, randomStatus 为逗号分隔的候选状态码)
+	Name           string            `json:"name,omitempty"`           // 键名 (setHeader, removeHeader, setQueryParam, setCookie, setBrowserCookie, setFormField, setFormFile 为文件字段名, rateLimit 与 variant 的头部或 Cookie 名)
+	Encoding       BodyEncoding      `json:"encoding,omitempty"`       // Body 编码方式 (setBody, setFormFile)
+	Search         string            `json:"search,omitempty"`         // 搜索内容 (replaceBodyText)
+	Replace        string            `json:"replace,omitempty"`        // 替换内容 (replaceBodyText)
+	ReplaceAll     bool              `json:"replaceAll,omitempty"`     // 是否全部替换 (replaceBodyText)
+	Patches        []JSONPatchOp     `json:"patches,omitempty"`        // JSON Patch 操作列表 (patchBodyJson)
+	StatusCode     int               `json:"statusCode,omitempty"`     // HTTP 状态码 (block, redirect, mapLocal)
+	Headers        map[string]string `json:"headers,omitempty"`        // 响应头 (block, rateLimit, notModified, redirect, mapLocal)，setSecurityHeaders 为覆盖预设的头部，值为空表示移除
+	Body           string            `json:"body,omitempty"`           // 响应体 (block, rateLimit, redirect)
+	BodyEncoding   BodyEncoding      `json:"bodyEncoding,omitempty"`   // Body 编码方式 (block, rateLimit, redirect)
+	Pattern        string            `json:"pattern,omitempty"`        // 匹配请求 URL 的正则 (redirect, mapLocal)，Location 模板与 mapLocal 的文件路径模板可用 $1、${name} 引用捕获组，不匹配时继续执行后续行为
+	PreserveMethod bool              `json:"preserveMethod,omitempty"` // 浏览器以原请求方法与请求体重发 (redirect)，301 与 302/303 分别改用 308 与 307
+	Filename       string            `json:"filename,omitempty"`       // 文件名模板 (saveBody)，支持 {host}、{name}、{ext}、{ts} 等变量；mapLocal 为目录下的文件路径模板，默认 {path}；setFormFile 为上传的文件名，为空时保留原文件名
+	ContentType    string            `json:"contentType,omitempty"`    // 文件的 Content-Type (setFormFile)，为空时保留原值
+	Paths          []string          `json:"paths,omitempty"`          // 字段路径模式 (maskJson)，如 data.users.*.email、**.avatar
+	MaskMode       MaskMode          `json:"maskMode,omitempty"`       // 屏蔽方式 (maskJson)，默认 remove
+	Schema         any               `json:"schema,omitempty"`         // JSON Schema (validateSchema)，可为对象或 JSON 文本
+	OnViolation    ViolationMode     `json:"onViolation,omitempty"`    // 违规处理方式 (validateSchema)，默认 report
+	Limit          int               `json:"limit,omitempty"`          // 窗口内允许的请求数 (rateLimit)
+	Window         string            `json:"window,omitempty"`         // 计数窗口时长 (rateLimit)，如 10s、1m，默认 1m
+	RateKey        RateLimitKey      `json:"rateKey,omitempty"`        // 计数键来源 (rateLimit)，默认 url
+	RetryAfter     int               `json:"retryAfter,omitempty"`     // Retry-After 秒数 (rateLimit)，为 0 时使用窗口剩余时间
+	Variants       []Variant         `json:"variants,omitempty"`       // 候选变体 (variant)
+	StickyBy       StickyKey         `json:"stickyBy,omitempty"`       // 区分客户端的键来源 (variant)，默认 cookie
+	Percent        int               `json:"percent,omitempty"`        // 路由到备用后端的请求百分比 (canary)，0-100；truncateBody 为保留的消息体百分比，0 表示在随机位置截断
+	Sign           *SignSpec         `json:"sign,omitempty"`           // 签名参数 (sign)
+	Augment        *AugmentSpec      `json:"augment,omitempty"`        // 次级数据源与合并方式 (augmentJson)
+	Auth           *AuthSpec         `json:"auth,omitempty"`           // 认证质询的应答方式与凭据 (auth)
+	Cookie         *CookieSpec       `json:"cookie,omitempty"`         // 写入浏览器的 Cookie 属性 (setBrowserCookie)
+	Remote         *MapRemoteSpec    `json:"remote,omitempty"`         // 改写后的地址 (mapRemote)
+	LatencyMS      int               `json:"latencyMS,omitempty"`      // 放行前的固定额外延迟毫秒数 (throttle)
+	Delay          *DelaySpec        `json:"delay,omitempty"`          // 放行前额外延迟的分布 (throttle)，设置后取代 latencyMS
+	Bandwidth      int               `json:"bandwidth,omitempty"`      // 带宽上限（字节/秒）(throttle)，请求阶段按请求体、响应阶段按响应体大小延迟，0 表示不限制
+	Probability    float64           `json:"probability,omitempty"`    // 执行概率 (fail, randomStatus, truncateBody, corruptJson)，0-1，0 表示每次都执行
 }
 
 // JSONPatchOp JSON Patch 操作
@@ -243,24 +512,35 @@ type JSONPatchOp struct {
 	From  string `json:"from,omitempty"`  // 源路径 (move, copy)
 }
 
+// Variant variant 行为的一个候选变体
+type Variant struct {
+	Name    string   `json:"name"`             // 变体名称
+	Weight  int      `json:"weight,omitempty"` // 分配权重，全部为 0 时平均分配
+	Actions []Action `json:"actions"`          // 选中时执行的行为，不支持嵌套 variant
+}
+
 // IsTerminal 判断行为是否为终结性行为
 func (a *Action) IsTerminal() bool {
 	return a.Type == ActionBlock
 }
 
-// IsValidForStage 判断行为是否适用于指定阶段
+// IsValidForStage 判断行为是否适用于指定阶段，WebSocket 阶段的规则不执行行为
 func (a *Action) IsValidForStage(stage Stage) bool {
 	switch a.Type {
 	// 仅请求阶段
 	case ActionSetUrl, ActionSetMethod, ActionSetQueryParam, ActionRemoveQueryParam,
-		ActionSetCookie, ActionRemoveCookie, ActionSetFormField, ActionRemoveFormField, ActionBlock:
+		ActionSetCookie, ActionRemoveCookie, ActionSetBrowserCookie, ActionSetFormField, ActionRemoveFormField, ActionSetFormFile, ActionSetUserAgent, ActionMirror, ActionBlock,
+		ActionRateLimit, ActionCanary, ActionMapRemote, ActionSign, ActionNotModified, ActionRedirect, ActionMapLocal, ActionAuth:
 		return stage == StageRequest
 	// 仅响应阶段
-	case ActionSetStatus:
+	case ActionSetStatus, ActionSetCache, ActionSaveBody, ActionMaskJson, ActionValidateSchema, ActionSetSecurityHeaders,
+		ActionAugmentJson, ActionRandomStatus:
 		return stage == StageResponse
 	// 两阶段通用
-	case ActionSetHeader, ActionRemoveHeader, ActionSetBody, ActionAppendBody, ActionReplaceBodyText, ActionPatchBodyJson:
-		return true
+	case ActionSetHeader, ActionRemoveHeader, ActionSetBody, ActionAppendBody, ActionReplaceBodyText, ActionPatchBodyJson,
+		ActionJqTransform, ActionVariant, ActionStripValidators, ActionScript, ActionThrottle,
+		ActionFail, ActionTruncateBody, ActionCorruptJson:
+		return stage == StageRequest || stage == StageResponse
 	default:
 		return false
 	}
@@ -274,7 +554,96 @@ func (a *Action) GetEncoding() BodyEncoding {
 	return a.Encoding
 }
 
-// GetBodyEncoding 获取 block 行为的 Body 编码方式，默认为 text
+// GetMaskMode 获取 maskJson 行为的屏蔽方式，默认为 remove
+func (a *Action) GetMaskMode() MaskMode {
+	if a.MaskMode == "" {
+		return MaskModeRemove
+	}
+	return a.MaskMode
+}
+
+// GetOnViolation 获取 validateSchema 行为的违规处理方式，默认为 report
+func (a *Action) GetOnViolation() ViolationMode {
+	if a.OnViolation == "" {
+		return ViolationReport
+	}
+	return a.OnViolation
+}
+
+// SchemaText 返回 validateSchema 行为的 JSON Schema 文本，未设置时返回空字符串
+func (a *Action) SchemaText() string {
+	switch v := a.Schema.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// GetRedirectStatus 获取 redirect 行为的状态码：未设置或不是 301、302、303、307、308 时为 302；
+// 保留请求方法时 301 改用 308，302 与 303 改用 307
+func (a *Action) GetRedirectStatus() int {
+	code := a.StatusCode
+	switch code {
+	case 301, 302, 303, 307, 308:
+	default:
+		code = 302
+	}
+	if a.PreserveMethod {
+		switch code {
+		case 301:
+			code = 308
+		case 302, 303:
+			code = 307
+		}
+	}
+	return code
+}
+
+// GetRateKey 获取 rateLimit 行为的计数键来源，默认为 url
+func (a *Action) GetRateKey() RateLimitKey {
+	if a.RateKey == "" {
+		return RateKeyURL
+	}
+	return a.RateKey
+}
+
+// GetWindow 获取 rateLimit 行为的计数窗口，未设置或无效时为 DefaultRateWindow
+func (a *Action) GetWindow() time.Duration {
+	d, err := time.ParseDuration(a.Window)
+	if err != nil || d <= 0 {
+		return DefaultRateWindow
+	}
+	return d
+}
+
+// GetAugmentTimeout 获取 augmentJson 行为的获取超时，未设置或无效时为 DefaultAugmentTimeout
+func (a *Action) GetAugmentTimeout() time.Duration {
+	if a.Augment == nil {
+		return DefaultAugmentTimeout
+	}
+	d, err := time.ParseDuration(a.Augment.Timeout)
+	if err != nil || d <= 0 {
+		return DefaultAugmentTimeout
+	}
+	return d
+}
+
+// GetStickyBy 获取 variant 行为区分客户端的键来源，默认为 cookie
+func (a *Action) GetStickyBy() StickyKey {
+	if a.StickyBy == "" {
+		return StickyCookie
+	}
+	return a.StickyBy
+}
+
+// GetBodyEncoding 获取 block、rateLimit 行为的 Body 编码方式，默认为 text
 func (a *Action) GetBodyEncoding() BodyEncoding {
 	if a.BodyEncoding == "" {
 		return BodyEncodingText
@@ -0,0 +1,140 @@
+package rulespec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UABrand 客户端提示中的品牌与主版本号
+type UABrand struct {
+	Brand   string `json:"brand"`
+	Version string `json:"version"`
+}
+
+// UserAgentPreset User-Agent 预设，Brands 为空表示该浏览器不发送 Sec-CH-UA 客户端提示
+type UserAgentPreset struct {
+	Name            string    `json:"name"`            // 预设名，用作 setUserAgent 的 value
+	Label           string    `json:"label"`           // 展示名称
+	UserAgent       string    `json:"userAgent"`       // User-Agent 头
+	Platform        string    `json:"platform"`        // navigator.platform
+	Mobile          bool      `json:"mobile"`          // Sec-CH-UA-Mobile
+	Brands          []UABrand `json:"brands"`          // Sec-CH-UA
+	UAPlatform      string    `json:"uaPlatform"`      // Sec-CH-UA-Platform
+	PlatformVersion string    `json:"platformVersion"` // Sec-CH-UA-Platform-Version
+	Model           string    `json:"model"`           // Sec-CH-UA-Model
+}
+
+// chromiumBrands 构造 Chromium 系浏览器的品牌列表
+func chromiumBrands(brand, version string) []UABrand {
+	brands := []UABrand{{Brand: "Not/A)Brand", Version: "8"}, {Brand: "Chromium", Version: version}}
+	if brand != "" {
+		brands = append(brands, UABrand{Brand: brand, Version: version})
+	}
+	return brands
+}
+
+// userAgentPresets 内置预设
+var userAgentPresets = []UserAgentPreset{
+	{
+		Name:       "chrome-windows",
+		Label:      "Chrome / Windows",
+		UserAgent:  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+		Platform:   "Win32",
+		Brands:     chromiumBrands("Google Chrome", "126"),
+		UAPlatform: "Windows", PlatformVersion: "15.0.0",
+	},
+	{
+		Name:       "chrome-mac",
+		Label:      "Chrome / macOS",
+		UserAgent:  "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+		Platform:   "MacIntel",
+		Brands:     chromiumBrands("Google Chrome", "126"),
+		UAPlatform: "macOS", PlatformVersion: "14.5.0",
+	},
+	{
+		Name:       "edge-windows",
+		Label:      "Edge / Windows",
+		UserAgent:  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0",
+		Platform:   "Win32",
+		Brands:     chromiumBrands("Microsoft Edge", "126"),
+		UAPlatform: "Windows", PlatformVersion: "15.0.0",
+	},
+	{
+		Name:      "firefox-windows",
+		Label:     "Firefox / Windows",
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:127.0) Gecko/20100101 Firefox/127.0",
+		Platform:  "Win32",
+	},
+	{
+		Name:      "safari-mac",
+		Label:     "Safari / macOS",
+		UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15",
+		Platform:  "MacIntel",
+	},
+	{
+		Name:      "safari-iphone",
+		Label:     "Safari / iPhone",
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+		Platform:  "iPhone",
+		Mobile:    true,
+	},
+	{
+		Name:       "chrome-android",
+		Label:      "Chrome / Android (Pixel 8)",
+		UserAgent:  "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+		Platform:   "Linux armv8l",
+		Mobile:     true,
+		Brands:     chromiumBrands("Google Chrome", "126"),
+		UAPlatform: "Android", PlatformVersion: "14.0.0", Model: "Pixel 8",
+	},
+	{
+		Name:       "wechat-android",
+		Label:      "WeChat / Android",
+		UserAgent:  "Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/AP2A.240605.024; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/126.0.6478.71 Mobile Safari/537.36 XWEB/1260059 MMWEBSDK/20240501 MicroMessenger/8.0.50.2701(0x28003235) WeChat/arm64 Weixin NetType/WIFI Language/zh_CN ABI/arm64",
+		Platform:   "Linux armv8l",
+		Mobile:     true,
+		Brands:     chromiumBrands("Android WebView", "126"),
+		UAPlatform: "Android", PlatformVersion: "14.0.0", Model: "Pixel 8",
+	},
+}
+
+// UserAgentPresets 返回所有内置 User-Agent 预设
+func UserAgentPresets() []UserAgentPreset {
+	presets := make([]UserAgentPreset, len(userAgentPresets))
+	copy(presets, userAgentPresets)
+	return presets
+}
+
+// ResolveUserAgent 解析 setUserAgent 的值：预设名返回对应预设，否则视为自定义 User-Agent 字符串
+func ResolveUserAgent(value string) (UserAgentPreset, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return UserAgentPreset{}, fmt.Errorf("empty user agent")
+	}
+	for _, p := range userAgentPresets {
+		if p.Name == value {
+			return p, nil
+		}
+	}
+	return UserAgentPreset{Name: "custom", UserAgent: value}, nil
+}
+
+// ClientHints 生成该预设对应的低熵客户端提示请求头，不发送客户端提示的浏览器返回空
+func (p UserAgentPreset) ClientHints() map[string]string {
+	if len(p.Brands) == 0 {
+		return nil
+	}
+	brands := make([]string, len(p.Brands))
+	for i, b := range p.Brands {
+		brands[i] = fmt.Sprintf("%q;v=%q", b.Brand, b.Version)
+	}
+	mobile := "?0"
+	if p.Mobile {
+		mobile = "?1"
+	}
+	return map[string]string{
+		"Sec-CH-UA":          strings.Join(brands, ", "),
+		"Sec-CH-UA-Mobile":   mobile,
+		"Sec-CH-UA-Platform": fmt.Sprintf("%q", p.UAPlatform),
+	}
+}
@@ -0,0 +1,718 @@
+package rulespec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"cdpnetool/pkg/domain"
+)
+
+// Severity 诊断的严重程度
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // 规则无法按预期工作，应在加载前修正
+	SeverityWarning Severity = "warning" // 规则可以加载，但可能不是预期的行为
+)
+
+// Diagnostic 单条校验诊断
+type Diagnostic struct {
+	Severity  Severity `json:"severity"`
+	RuleID    string   `json:"ruleId,omitempty"` // 所属规则 ID，为空表示配置级诊断
+	RuleIndex int      `json:"ruleIndex"`        // 所属规则在配置中的下标，配置级诊断为 -1
+	Field     string   `json:"field,omitempty"`  // 出错字段的路径，如 match.allOf[0].pattern、actions[1].value
+	Message   string   `json:"message"`
+}
+
+// String 以 "rule <id> <field>: message" 的形式返回诊断
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	b.WriteString(string(d.Severity))
+	if d.RuleIndex >= 0 {
+		fmt.Fprintf(&b, " rule %q", d.RuleID)
+	}
+	if d.Field != "" {
+		b.WriteString(" " + d.Field)
+	}
+	return b.String() + ": " + d.Message
+}
+
+// ValidationReport 规则配置的校验结果
+type ValidationReport struct {
+	Valid       bool         `json:"valid"` // 没有 error 级别的诊断
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Errors 返回 error 级别的诊断
+func (r *ValidationReport) Errors() []Diagnostic {
+	var out []Diagnostic
+	for _, d := range r.Diagnostics {
+		if d.Severity == SeverityError {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Validate 在加载前逐条检查规则配置：阶段与条件、行为类型是否已知，必填字段是否缺失，
+// 正则能否编译，行为是否适用于规则阶段，allOf 中是否存在不可能同时满足的条件组合等。
+// 加载时这些问题多数会被静默忽略（条件恒不满足、行为不执行），Validate 将其逐条列出。
+// 禁用的规则同样检查；脚本与 jq 程序的语法在加载时由规则引擎校验
+func Validate(cfg *Config) ValidationReport {
+	v := &validator{ruleIndex: -1}
+	if cfg == nil {
+		v.errorf("", "配置为空")
+		return v.report()
+	}
+	if err := CheckVersion(cfg.Version); err != nil {
+		v.errorf("version", "%v", err)
+	}
+	if cfg.ID != "" {
+		if err := ValidateConfigID(cfg.ID); err != nil {
+			v.warnf("id", "%v", err)
+		}
+	}
+
+	ids := make(map[string]int, len(cfg.Rules))
+	for i := range cfg.Rules {
+		if id := cfg.Rules[i].ID; id != "" {
+			if _, ok := ids[id]; !ok {
+				ids[id] = i
+			}
+		}
+	}
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		v.ruleIndex, v.ruleID = i, rule.ID
+		if err := ValidateRuleID(rule.ID); err != nil {
+			v.errorf("id", "%v", err)
+		} else if first := ids[rule.ID]; first != i {
+			v.errorf("id", "规则 ID 与第 %d 条规则重复", first+1)
+		}
+		v.validateRule(rule, ids)
+	}
+	return v.report()
+}
+
+// validator 收集诊断，ruleIndex 与 ruleID 为当前检查的规则
+type validator struct {
+	diags     []Diagnostic
+	ruleIndex int
+	ruleID    string
+}
+
+func (v *validator) add(sev Severity, field, format string, args ...any) {
+	v.diags = append(v.diags, Diagnostic{
+		Severity:  sev,
+		RuleID:    v.ruleID,
+		RuleIndex: v.ruleIndex,
+		Field:     field,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) errorf(field, format string, args ...any) {
+	v.add(SeverityError, field, format, args...)
+}
+
+func (v *validator) warnf(field, format string, args ...any) {
+	v.add(SeverityWarning, field, format, args...)
+}
+
+func (v *validator) report() ValidationReport {
+	r := ValidationReport{Valid: true, Diagnostics: v.diags}
+	if r.Diagnostics == nil {
+		r.Diagnostics = []Diagnostic{}
+	}
+	for _, d := range r.Diagnostics {
+		if d.Severity == SeverityError {
+			r.Valid = false
+		}
+	}
+	return r
+}
+
+// validateRule 检查单条规则的阶段、条件与行为
+func (v *validator) validateRule(rule *Rule, ids map[string]int) {
+	switch rule.Stage {
+	case StageRequest, StageResponse, StageWebSocket:
+	case "":
+		v.errorf("stage", "缺少阶段，应为 %s、%s 或 %s", StageRequest, StageResponse, StageWebSocket)
+	default:
+		v.errorf("stage", "未知的阶段 %q，应为 %s、%s 或 %s", rule.Stage, StageRequest, StageResponse, StageWebSocket)
+	}
+
+	if len(rule.Match.AllOf) == 0 && len(rule.Match.AnyOf) == 0 {
+		v.warnf("match", "没有匹配条件，规则匹配该阶段的所有请求")
+	}
+	for i := range rule.Match.AllOf {
+		v.validateCondition(fmt.Sprintf("match.allOf[%d]", i), rule, &rule.Match.AllOf[i], ids)
+	}
+	for i := range rule.Match.AnyOf {
+		v.validateCondition(fmt.Sprintf("match.anyOf[%d]", i), rule, &rule.Match.AnyOf[i], ids)
+	}
+	v.checkConflicts(rule.Match.AllOf)
+
+	if rule.Stage == StageWebSocket {
+		if len(rule.Actions) > 0 {
+			v.warnf("actions", "websocket 阶段的规则只记录匹配，不执行行为")
+		}
+		return
+	}
+	if len(rule.Actions) == 0 && rule.Stage != "" {
+		v.warnf("actions", "没有行为，规则只记录匹配")
+	}
+	v.validateActions("actions", rule.Stage, rule.Actions, false)
+}
+
+// validateCondition 检查条件类型、必填字段与正则
+func (v *validator) validateCondition(field string, rule *Rule, c *Condition, ids map[string]int) {
+	switch c.Type {
+	case ConditionURLEquals, ConditionURLPrefix, ConditionURLSuffix, ConditionURLContains, ConditionHost, ConditionBodyContains,
+		ConditionContentType:
+		if c.Value == "" {
+			v.errorf(field+".value", "%s 条件缺少 value", c.Type)
+		}
+	case ConditionMethod, ConditionResourceType:
+		if len(c.Values) == 0 {
+			v.errorf(field+".values", "%s 条件缺少 values，条件恒不满足", c.Type)
+		}
+	case ConditionURLRegex, ConditionBodyRegex:
+		if c.Pattern == "" {
+			v.errorf(field+".pattern", "%s 条件缺少 pattern", c.Type)
+		}
+	case ConditionHeaderExists, ConditionHeaderNotExists, ConditionQueryExists, ConditionQueryNotExists,
+		ConditionCookieExists, ConditionCookieNotExists:
+		if c.Name == "" {
+			v.errorf(field+".name", "%s 条件缺少 name", c.Type)
+		}
+	case ConditionHeaderEquals, ConditionHeaderContains, ConditionQueryEquals, ConditionQueryContains,
+		ConditionCookieEquals, ConditionCookieContains:
+		if c.Name == "" {
+			v.errorf(field+".name", "%s 条件缺少 name", c.Type)
+		}
+	case ConditionHeaderRegex, ConditionQueryRegex, ConditionCookieRegex:
+		if c.Name == "" {
+			v.errorf(field+".name", "%s 条件缺少 name", c.Type)
+		}
+		if c.Pattern == "" {
+			v.errorf(field+".pattern", "%s 条件缺少 pattern", c.Type)
+		}
+	case ConditionBodyJsonPath:
+		if c.Path == "" {
+			v.errorf(field+".path", "%s 条件缺少 path", c.Type)
+		}
+	case ConditionRuleMatched:
+		if c.Value == "" {
+			v.errorf(field+".value", "ruleMatched 条件缺少依赖的规则 ID")
+		} else if c.Value == rule.ID {
+			v.warnf(field+".value", "ruleMatched 条件依赖规则自身，首次匹配前恒不满足")
+		} else if _, ok := ids[c.Value]; !ok {
+			v.errorf(field+".value", "ruleMatched 条件依赖的规则 %q 不存在，条件恒不满足", c.Value)
+		}
+		switch c.Scope {
+		case "", ScopeSession, ScopePageLoad:
+		default:
+			v.errorf(field+".scope", "未知的回溯范围 %q，应为 %s 或 %s", c.Scope, ScopeSession, ScopePageLoad)
+		}
+	case ConditionStatusCode, ConditionResponseTime:
+		if c.Value == "" {
+			v.errorf(field+".value", "%s 条件缺少 value", c.Type)
+		} else if _, err := ParseRanges(c.Value); err != nil {
+			v.errorf(field+".value", "%s 条件的范围无法解析，条件恒不满足: %v", c.Type, err)
+		}
+	case "":
+		v.errorf(field+".type", "缺少条件类型")
+		return
+	default:
+		v.errorf(field+".type", "未知的条件类型 %q，条件恒不满足", c.Type)
+		return
+	}
+
+	if c.IsResponseCondition() && rule.Stage != StageResponse && rule.Stage != "" {
+		v.errorf(field+".type", "%s 条件只能用于 %s 阶段，条件恒不满足", c.Type, StageResponse)
+	}
+
+	if p, ok := c.RegexPattern(); ok && p != "" {
+		if _, err := regexp.Compile(p); err != nil {
+			sub := ".value"
+			if p == c.Pattern {
+				sub = ".pattern"
+			}
+			v.errorf(field+sub, "正则无法编译，条件恒不满足: %v", err)
+		}
+	}
+}
+
+// checkConflicts 检查 allOf 中不可能同时满足的条件组合
+func (v *validator) checkConflicts(conds []Condition) {
+	type keyed struct {
+		kind, name string
+	}
+	exists := make(map[keyed]int)
+	equals := make(map[keyed]int)
+	urlEquals := -1
+	sets := make(map[ConditionType]int)
+
+	for i := range conds {
+		c := &conds[i]
+		field := fmt.Sprintf("match.allOf[%d]", i)
+		kind, name := keyKind(c)
+		switch c.Type {
+		case ConditionHeaderExists, ConditionQueryExists, ConditionCookieExists:
+			exists[keyed{kind, name}] = i
+		case ConditionHeaderEquals, ConditionQueryEquals, ConditionCookieEquals:
+			if strings.HasPrefix(c.Value, RegexPrefix) {
+				continue
+			}
+			k := keyed{kind, name}
+			if j, ok := equals[k]; ok && conds[j].Value != c.Value {
+				v.errorf(field, "与 match.allOf[%d] 要求 %s %q 同时等于两个不同的值，规则永远不会匹配", j, kind, c.Name)
+			} else if !ok {
+				equals[k] = i
+			}
+		case ConditionURLEquals:
+			if strings.HasPrefix(c.Value, RegexPrefix) {
+				continue
+			}
+			if urlEquals >= 0 && conds[urlEquals].Value != c.Value {
+				v.errorf(field, "与 match.allOf[%d] 要求 URL 同时等于两个不同的值，规则永远不会匹配", urlEquals)
+			} else if urlEquals < 0 {
+				urlEquals = i
+			}
+		case ConditionMethod, ConditionResourceType:
+			if j, ok := sets[c.Type]; ok && !intersects(conds[j].Values, c.Values, c.Type == ConditionMethod) {
+				v.errorf(field, "与 match.allOf[%d] 的 %s 取值没有交集，规则永远不会匹配", j, c.Type)
+			} else if !ok {
+				sets[c.Type] = i
+			}
+		}
+	}
+	// 按配置顺序报告，保证诊断顺序稳定
+	for i := range conds {
+		switch conds[i].Type {
+		case ConditionHeaderNotExists, ConditionQueryNotExists, ConditionCookieNotExists:
+		default:
+			continue
+		}
+		kind, name := keyKind(&conds[i])
+		k := keyed{kind, name}
+		if j, ok := exists[k]; ok {
+			v.errorf(fmt.Sprintf("match.allOf[%d]", i), "与 match.allOf[%d] 要求 %s %q 同时存在与不存在，规则永远不会匹配", j, kind, conds[i].Name)
+		}
+		if j, ok := equals[k]; ok {
+			v.errorf(fmt.Sprintf("match.allOf[%d]", i), "与 match.allOf[%d] 要求 %s %q 不存在却又等于某个值，规则永远不会匹配", j, kind, conds[i].Name)
+		}
+	}
+
+	// 精确 URL 与其他不含正则的 URL 条件冲突
+	if urlEquals >= 0 {
+		url := conds[urlEquals].Value
+		for i := range conds {
+			c := &conds[i]
+			if strings.HasPrefix(c.Value, RegexPrefix) || c.Value == "" {
+				continue
+			}
+			var ok bool
+			switch c.Type {
+			case ConditionURLPrefix:
+				ok = strings.HasPrefix(url, c.Value)
+			case ConditionURLSuffix:
+				ok = strings.HasSuffix(url, c.Value)
+			case ConditionURLContains:
+				ok = strings.Contains(url, c.Value)
+			default:
+				continue
+			}
+			if !ok {
+				v.errorf(fmt.Sprintf("match.allOf[%d]", i), "与 match.allOf[%d] 的精确 URL %q 矛盾，规则永远不会匹配", urlEquals, url)
+			}
+		}
+	}
+}
+
+// keyKind 返回 header*、query*、cookie* 条件的键类别与用于比较的键名，Header 名不区分大小写
+func keyKind(c *Condition) (kind, name string) {
+	switch c.Type {
+	case ConditionHeaderExists, ConditionHeaderNotExists, ConditionHeaderEquals:
+		return "header", strings.ToLower(c.Name)
+	case ConditionQueryExists, ConditionQueryNotExists, ConditionQueryEquals:
+		return "query", c.Name
+	case ConditionCookieExists, ConditionCookieNotExists, ConditionCookieEquals:
+		return "cookie", c.Name
+	}
+	return "", ""
+}
+
+// intersects 判断两个取值列表是否有共同元素，foldCase 为 true 时不区分大小写
+func intersects(a, b []string, foldCase bool) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y || (foldCase && strings.EqualFold(x, y)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateActions 检查行为类型、阶段与必填字段，inVariant 表示位于 variant 行为的变体中
+func (v *validator) validateActions(field string, stage Stage, actions []Action, inVariant bool) {
+	terminal := -1
+	for i := range actions {
+		a := &actions[i]
+		f := fmt.Sprintf("%s[%d]", field, i)
+		if terminal >= 0 {
+			v.warnf(f, "位于 %s[%d] 的 %s 行为之后，不会执行", field, terminal, actions[terminal].Type)
+		}
+		if a.Type == "" {
+			v.errorf(f+".type", "缺少行为类型")
+			continue
+		}
+		if !a.IsValidForStage(StageRequest) && !a.IsValidForStage(StageResponse) {
+			v.errorf(f+".type", "未知的行为类型 %q，行为不会执行", a.Type)
+			continue
+		}
+		if (stage == StageRequest || stage == StageResponse) && !a.IsValidForStage(stage) {
+			v.errorf(f+".type", "%s 行为不适用于 %s 阶段，行为不会执行", a.Type, stage)
+			continue
+		}
+		if a.IsTerminal() && terminal < 0 {
+			terminal = i
+		}
+		if a.Probability < 0 || a.Probability > 1 {
+			v.errorf(f+".probability", "probability 必须在 0-1 之间")
+		} else if a.Probability > 0 && !a.IsFault() {
+			v.warnf(f+".probability", "probability 仅对故障注入行为生效，%s 行为每次都会执行", a.Type)
+		}
+		v.validateAction(f, stage, a, inVariant)
+	}
+}
+
+// validateAction 检查单个行为的必填字段与取值
+func (v *validator) validateAction(f string, stage Stage, a *Action, inVariant bool) {
+	str, isStr := a.Value.(string)
+	needValue := func(what string) {
+		if !isStr || strings.TrimSpace(str) == "" {
+			v.errorf(f+".value", "%s 行为缺少%s", a.Type, what)
+		}
+	}
+	needName := func() {
+		if a.Name == "" {
+			v.errorf(f+".name", "%s 行为缺少 name", a.Type)
+		}
+	}
+	checkEncoding := func(sub string, enc BodyEncoding) {
+		switch enc {
+		case "", BodyEncodingText, BodyEncodingBase64:
+		default:
+			v.errorf(f+"."+sub, "未知的编码方式 %q，应为 %s 或 %s", enc, BodyEncodingText, BodyEncodingBase64)
+		}
+	}
+	checkPattern := func() {
+		if a.Pattern == "" {
+			return
+		}
+		if _, err := regexp.Compile(a.Pattern); err != nil {
+			v.errorf(f+".pattern", "正则无法编译: %v", err)
+		}
+	}
+
+	switch a.Type {
+	case ActionSetUrl:
+		needValue("目标 URL")
+	case ActionSetMethod:
+		needValue("请求方法")
+	case ActionSetHeader, ActionSetQueryParam, ActionSetCookie, ActionSetFormField:
+		needName()
+		if !isStr {
+			v.errorf(f+".value", "%s 行为的 value 必须为字符串", a.Type)
+		}
+	case ActionRemoveHeader, ActionRemoveQueryParam, ActionRemoveCookie, ActionRemoveFormField:
+		needName()
+	case ActionSetBrowserCookie:
+		needName()
+		if !isStr {
+			v.errorf(f+".value", "setBrowserCookie 行为的 value 必须为字符串")
+		} else if strings.ContainsAny(str, ";\r\n") {
+			v.errorf(f+".value", "Cookie 值不能包含分号或换行")
+		}
+		if a.Name != "" && domain.ValidateCookieName(a.Name) != nil {
+			v.errorf(f+".name", "Cookie 名称 %q 包含非法字符", a.Name)
+		}
+		if c := a.Cookie; c != nil {
+			if c.Path != "" && !strings.HasPrefix(c.Path, "/") {
+				v.errorf(f+".cookie.path", "Cookie 路径必须以 / 开头")
+			}
+			if c.MaxAge < 0 {
+				v.errorf(f+".cookie.maxAge", "maxAge 不能为负数")
+			}
+			switch c.SameSite {
+			case "", domain.CookieSameSiteStrict, domain.CookieSameSiteLax:
+			case domain.CookieSameSiteNone:
+				if !c.Secure {
+					v.warnf(f+".cookie.secure", "SameSite=None 未设置 secure，仅在 HTTPS 请求上写入")
+				}
+			default:
+				v.errorf(f+".cookie.sameSite", "未知的 SameSite 值 %q，应为 Strict、Lax 或 None", c.SameSite)
+			}
+		}
+	case ActionSetFormFile:
+		needName()
+		if !isStr {
+			v.errorf(f+".value", "setFormFile 行为缺少文件内容")
+		}
+		checkEncoding("encoding", a.Encoding)
+	case ActionSetUserAgent:
+		needValue(" User-Agent 或预设名")
+	case ActionMirror:
+		needValue("影子后端地址")
+	case ActionCanary:
+		needValue("备用后端地址")
+		if a.Percent < 0 || a.Percent > 100 {
+			v.errorf(f+".percent", "percent 必须在 0-100 之间")
+		} else if a.Percent == 0 {
+			v.warnf(f+".percent", "percent 为 0，不会路由任何请求")
+		}
+	case ActionMapRemote:
+		if a.Remote == nil || *a.Remote == (MapRemoteSpec{}) {
+			v.errorf(f+".remote", "mapRemote 行为缺少改写后的地址")
+		} else if a.Remote.Port < 0 || a.Remote.Port > 65535 {
+			v.errorf(f+".remote.port", "端口必须在 0-65535 之间")
+		}
+	case ActionSign:
+		switch {
+		case a.Sign == nil:
+			v.errorf(f+".sign", "sign 行为缺少签名参数")
+		case a.Sign.Method == SignHMAC:
+			if a.Sign.SecretEnv == "" {
+				v.errorf(f+".sign.secretEnv", "hmac 签名缺少保存密钥的环境变量名")
+			}
+		case a.Sign.Method == SignAWSSigV4:
+			if a.Sign.Region == "" || a.Sign.Service == "" {
+				v.errorf(f+".sign", "awsSigV4 签名缺少 region 或 service")
+			}
+		default:
+			v.errorf(f+".sign.method", "未知的签名方式 %q，应为 %s 或 %s", a.Sign.Method, SignHMAC, SignAWSSigV4)
+		}
+	case ActionAuth:
+		if inVariant {
+			v.errorf(f+".type", "变体中不支持 auth 行为")
+			return
+		}
+		if a.Auth == nil {
+			v.errorf(f+".auth", "auth 行为缺少应答方式")
+			return
+		}
+		switch a.Auth.Source {
+		case "", domain.AuthSourceServer, domain.AuthSourceProxy:
+		default:
+			v.errorf(f+".auth.source", "未知的质询来源 %q，应为 %s 或 %s", a.Auth.Source, domain.AuthSourceServer, domain.AuthSourceProxy)
+		}
+		switch a.Auth.GetMode() {
+		case AuthModeProvide:
+			if a.Auth.Username == "" {
+				v.errorf(f+".auth.username", "provide 方式缺少用户名")
+			}
+		case AuthModeApprove, AuthModeCancel:
+		default:
+			v.errorf(f+".auth.mode", "未知的应答方式 %q", a.Auth.Mode)
+		}
+		if a.Auth.Password != "" && a.Auth.PasswordEnv != "" {
+			v.warnf(f+".auth.password", "已设置 passwordEnv，password 不会生效")
+		}
+		if a.Auth.TimeoutMS < 0 {
+			v.errorf(f+".auth.timeoutMS", "timeoutMS 不能为负数")
+		}
+	case ActionRedirect:
+		needValue(" Location 模板")
+		checkPattern()
+		checkEncoding("bodyEncoding", a.BodyEncoding)
+	case ActionMapLocal:
+		needValue("本地文件或目录")
+		checkPattern()
+	case ActionBlock:
+		if a.StatusCode != 0 && (a.StatusCode < 100 || a.StatusCode > 599) {
+			v.errorf(f+".statusCode", "状态码 %d 无效", a.StatusCode)
+		}
+		checkEncoding("bodyEncoding", a.BodyEncoding)
+	case ActionRateLimit:
+		if a.Limit <= 0 {
+			v.errorf(f+".limit", "rateLimit 行为的 limit 必须大于 0")
+		}
+		if a.Window != "" {
+			if d, err := time.ParseDuration(a.Window); err != nil || d <= 0 {
+				v.errorf(f+".window", "无效的计数窗口 %q", a.Window)
+			}
+		}
+		switch a.RateKey {
+		case "", RateKeyURL:
+		case RateKeyHeader, RateKeyCookie:
+			needName()
+		default:
+			v.errorf(f+".rateKey", "未知的计数键来源 %q", a.RateKey)
+		}
+		checkEncoding("bodyEncoding", a.BodyEncoding)
+	case ActionSetBody, ActionAppendBody:
+		if !isStr {
+			v.errorf(f+".value", "%s 行为的 value 必须为字符串", a.Type)
+		}
+		checkEncoding("encoding", a.Encoding)
+	case ActionReplaceBodyText:
+		if a.Search == "" {
+			v.errorf(f+".search", "replaceBodyText 行为缺少 search")
+		}
+	case ActionPatchBodyJson:
+		if len(a.Patches) == 0 {
+			v.errorf(f+".patches", "patchBodyJson 行为缺少 patches")
+		}
+		for j, p := range a.Patches {
+			pf := fmt.Sprintf("%s.patches[%d]", f, j)
+			switch p.Op {
+			case "add", "replace", "test", "remove":
+			case "move", "copy":
+				if p.From == "" {
+					v.errorf(pf+".from", "%s 操作缺少 from", p.Op)
+				}
+			default:
+				v.errorf(pf+".op", "未知的 JSON Patch 操作 %q", p.Op)
+			}
+		}
+	case ActionJqTransform:
+		needValue(" jq 程序")
+	case ActionScript:
+		needValue(" JavaScript 脚本")
+	case ActionVariant:
+		if inVariant {
+			v.errorf(f+".type", "变体中不支持嵌套 variant 行为")
+			return
+		}
+		if len(a.Variants) == 0 {
+			v.errorf(f+".variants", "variant 行为缺少候选变体")
+		}
+		switch a.StickyBy {
+		case "", StickyCookie, StickyHeader:
+		default:
+			v.errorf(f+".stickyBy", "未知的键来源 %q", a.StickyBy)
+		}
+		for j, vr := range a.Variants {
+			if vr.Weight < 0 {
+				v.errorf(fmt.Sprintf("%s.variants[%d].weight", f, j), "权重不能为负数")
+			}
+			v.validateActions(fmt.Sprintf("%s.variants[%d].actions", f, j), stage, vr.Actions, true)
+		}
+	case ActionThrottle:
+		if a.LatencyMS < 0 || a.Bandwidth < 0 {
+			v.errorf(f, "latencyMS 与 bandwidth 不能为负数")
+		} else if a.LatencyMS == 0 && a.Bandwidth == 0 && a.Delay == nil {
+			v.warnf(f, "throttle 行为未设置 latencyMS、delay 或 bandwidth，不会延迟")
+		}
+		if a.Delay != nil {
+			if a.LatencyMS > 0 {
+				v.warnf(f+".latencyMS", "设置 delay 时忽略 latencyMS")
+			}
+			v.validateDelay(f+".delay", a.Delay)
+		}
+	case ActionFail:
+		if a.Value != nil && (!isStr || str != "" && !validFailReason(str)) {
+			v.errorf(f+".value", "未知的网络错误原因 %v，应为 %s 之一", a.Value, strings.Join(FailReasons, "、"))
+		}
+	case ActionRandomStatus:
+		if a.Value != nil && !isStr {
+			v.errorf(f+".value", "randomStatus 行为的 value 必须为逗号分隔的状态码")
+		} else if _, err := a.FaultStatuses(); err != nil {
+			v.errorf(f+".value", "%v", err)
+		}
+	case ActionTruncateBody:
+		if a.Percent < 0 || a.Percent >= 100 {
+			v.errorf(f+".percent", "percent 必须在 0-99 之间")
+		}
+	case ActionSetStatus:
+		code, ok := a.Value.(float64)
+		if n, isInt := a.Value.(int); isInt {
+			code, ok = float64(n), true
+		}
+		if !ok || code < 100 || code > 599 {
+			v.errorf(f+".value", "setStatus 行为的 value 必须是 100-599 之间的数字")
+		}
+	case ActionSetCache:
+		if _, err := ResolveCache(str); err != nil {
+			v.errorf(f+".value", "%v", err)
+		}
+	case ActionSetSecurityHeaders:
+		if _, err := ResolveSecurityHeaders(str, a.Headers); err != nil {
+			v.errorf(f+".value", "%v", err)
+		}
+	case ActionSaveBody:
+		needValue("保存目录")
+	case ActionMaskJson:
+		if len(a.Paths) == 0 {
+			v.errorf(f+".paths", "maskJson 行为缺少 paths")
+		}
+		switch a.MaskMode {
+		case "", MaskModeRemove, MaskModeNull:
+		default:
+			v.errorf(f+".maskMode", "未知的屏蔽方式 %q", a.MaskMode)
+		}
+	case ActionValidateSchema:
+		if a.SchemaText() == "" {
+			v.errorf(f+".schema", "validateSchema 行为缺少 schema")
+		}
+		switch a.OnViolation {
+		case "", ViolationReport, ViolationFlag, ViolationFail:
+		default:
+			v.errorf(f+".onViolation", "未知的违规处理方式 %q", a.OnViolation)
+		}
+	case ActionAugmentJson:
+		if a.Augment == nil || a.Augment.Source == "" {
+			v.errorf(f+".augment.source", "augmentJson 行为缺少次级数据源")
+		} else if a.Augment.Timeout != "" {
+			if d, err := time.ParseDuration(a.Augment.Timeout); err != nil || d <= 0 {
+				v.errorf(f+".augment.timeout", "无效的超时时长 %q", a.Augment.Timeout)
+			}
+		}
+	}
+}
+
+// validateDelay 校验 throttle 行为的延迟分布
+func (v *validator) validateDelay(f string, d *DelaySpec) {
+	if d.MinMS < 0 || d.MaxMS < 0 || d.MeanMS < 0 || d.StdDevMS < 0 {
+		v.errorf(f, "延迟毫秒数不能为负数")
+		return
+	}
+	switch d.Distribution {
+	case "", DelayUniform:
+		if d.MaxMS < d.MinMS {
+			v.errorf(f+".maxMS", "maxMS 不能小于 minMS")
+		} else if d.MaxMS == 0 {
+			v.warnf(f, "均匀分布未设置 maxMS，不会延迟")
+		}
+	case DelayNormal:
+		if d.MaxMS > 0 && d.MaxMS < d.MinMS {
+			v.errorf(f+".maxMS", "maxMS 不能小于 minMS")
+		} else if d.MeanMS == 0 && d.StdDevMS == 0 && d.MinMS == 0 {
+			v.warnf(f, "正态分布未设置 meanMS 或 stdDevMS，不会延迟")
+		}
+	case DelayPercentile:
+		if len(d.Percentiles) == 0 {
+			v.errorf(f+".percentiles", "百分位分布缺少百分位表")
+		}
+		for i, pt := range d.Percentiles {
+			pf := fmt.Sprintf("%s.percentiles[%d]", f, i)
+			switch {
+			case pt.P < 0 || pt.P > 100:
+				v.errorf(pf+".p", "百分位必须在 0-100 之间")
+			case pt.MS < 0:
+				v.errorf(pf+".ms", "延迟毫秒数不能为负数")
+			case i > 0 && pt.P <= d.Percentiles[i-1].P:
+				v.errorf(pf+".p", "百分位必须按升序排列且不能重复")
+			case i > 0 && pt.MS < d.Percentiles[i-1].MS:
+				v.errorf(pf+".ms", "延迟不能小于前一个百分位的延迟")
+			}
+		}
+	default:
+		v.errorf(f+".distribution", "未知的延迟分布 %q", d.Distribution)
+	}
+}
@@ -0,0 +1,151 @@
+package rulespec_test
+
+import (
+	"testing"
+
+	"cdpnetool/pkg/rulespec"
+)
+
+// diagFields 返回指定严重程度的诊断字段，形如 "<规则 ID> <字段>"
+func diagFields(r rulespec.ValidationReport, sev rulespec.Severity) map[string]bool {
+	out := make(map[string]bool)
+	for _, d := range r.Diagnostics {
+		if d.Severity == sev {
+			out[d.RuleID+" "+d.Field] = true
+		}
+	}
+	return out
+}
+
+func TestValidate_Valid(t *testing.T) {
+	cfg := rulespec.NewConfig("ok")
+	cfg.Rules = []rulespec.Rule{{
+		ID: "block", Name: "block", Enabled: true, Stage: rulespec.StageRequest,
+		Match: rulespec.Match{AllOf: []rulespec.Condition{
+			{Type: rulespec.ConditionURLPrefix, Value: "https://example.com/api"},
+			{Type: rulespec.ConditionHeaderExists, Name: "Authorization"},
+		}},
+		Actions: []rulespec.Action{{Type: rulespec.ActionBlock, StatusCode: 403}},
+	}}
+	report := rulespec.Validate(cfg)
+	if !report.Valid || len(report.Diagnostics) != 0 {
+		t.Errorf("got %+v, want valid report without diagnostics", report)
+	}
+}
+
+func TestValidate_Diagnostics(t *testing.T) {
+	cfg := rulespec.NewConfig("broken")
+	cfg.Rules = []rulespec.Rule{
+		{
+			ID: "regex", Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionURLRegex, Pattern: "("},
+				{Type: rulespec.ConditionURLContains, Value: "regex:[a-"},
+				{Type: "urlGlob", Value: "*"},
+				{Type: rulespec.ConditionStatusCode, Value: "5xx"},
+				{Type: rulespec.ConditionResponseTime, Value: "fast"},
+			}},
+			Actions: []rulespec.Action{{Type: rulespec.ActionBlock}},
+		},
+		{
+			ID: "actions", Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AnyOf: []rulespec.Condition{{Type: rulespec.ConditionHost, Value: "example.com"}}},
+			Actions: []rulespec.Action{
+				{Type: "explode"},
+				{Type: rulespec.ActionSetStatus, Value: 500},
+				{Type: rulespec.ActionSetHeader, Value: "x"},
+				{Type: rulespec.ActionRateLimit},
+				{Type: rulespec.ActionFail, Value: "Exploded"},
+				{Type: rulespec.ActionTruncateBody, Percent: 100},
+				{Type: rulespec.ActionRandomStatus},
+				{Type: rulespec.ActionSetHeader, Name: "X", Value: "1", Probability: 0.5},
+				{Type: rulespec.ActionCorruptJson, Probability: 2},
+				{Type: rulespec.ActionThrottle, Delay: &rulespec.DelaySpec{Distribution: rulespec.DelayPercentile,
+					Percentiles: []rulespec.DelayPoint{{P: 90, MS: 300}, {P: 50, MS: 400}}}},
+				{Type: rulespec.ActionThrottle, LatencyMS: 100, Delay: &rulespec.DelaySpec{MinMS: 200, MaxMS: 100}},
+				{Type: rulespec.ActionThrottle, Delay: &rulespec.DelaySpec{Distribution: "poisson"}},
+				{Type: rulespec.ActionAuth},
+				{Type: rulespec.ActionAuth, Auth: &rulespec.AuthSpec{Password: "secret"}},
+				{Type: rulespec.ActionAuth, Auth: &rulespec.AuthSpec{Mode: "prompt", Source: "client"}},
+				{Type: rulespec.ActionSetBrowserCookie, Name: "a b", Value: "x;y"},
+				{Type: rulespec.ActionSetBrowserCookie, Name: "sid", Value: "1", Cookie: &rulespec.CookieSpec{Path: "app", MaxAge: -1, SameSite: "Loose"}},
+				{Type: rulespec.ActionSetBrowserCookie, Name: "sid", Value: "1", Cookie: &rulespec.CookieSpec{SameSite: "None"}},
+			},
+		},
+		{
+			ID: "impossible", Stage: rulespec.StageRequest,
+			Match: rulespec.Match{AllOf: []rulespec.Condition{
+				{Type: rulespec.ConditionHeaderExists, Name: "X-Token"},
+				{Type: rulespec.ConditionHeaderNotExists, Name: "x-token"},
+				{Type: rulespec.ConditionMethod, Values: []string{"GET"}},
+				{Type: rulespec.ConditionMethod, Values: []string{"POST"}},
+				{Type: rulespec.ConditionURLEquals, Value: "https://example.com/a"},
+				{Type: rulespec.ConditionURLPrefix, Value: "https://example.com/b"},
+			}},
+			Actions: []rulespec.Action{{Type: rulespec.ActionBlock}, {Type: rulespec.ActionSetHeader, Name: "X", Value: "1"}},
+		},
+		{
+			ID: "impossible", Stage: "body",
+			Match: rulespec.Match{AllOf: []rulespec.Condition{{Type: rulespec.ConditionRuleMatched, Value: "missing"}}},
+		},
+	}
+
+	report := rulespec.Validate(cfg)
+	if report.Valid {
+		t.Fatal("got valid report, want errors")
+	}
+	errs := diagFields(report, rulespec.SeverityError)
+	for _, want := range []string{
+		"regex match.allOf[0].pattern",
+		"regex match.allOf[1].value",
+		"regex match.allOf[2].type",
+		"regex match.allOf[3].type",
+		"regex match.allOf[4].value",
+		"actions actions[0].type",
+		"actions actions[1].type",
+		"actions actions[2].name",
+		"actions actions[3].limit",
+		"actions actions[4].value",
+		"actions actions[5].percent",
+		"actions actions[6].type",
+		"actions actions[8].probability",
+		"actions actions[9].delay.percentiles[1].p",
+		"actions actions[10].delay.maxMS",
+		"actions actions[11].delay.distribution",
+		"actions actions[12].auth",
+		"actions actions[13].auth.username",
+		"actions actions[14].auth.mode",
+		"actions actions[14].auth.source",
+		"actions actions[15].name",
+		"actions actions[15].value",
+		"actions actions[16].cookie.path",
+		"actions actions[16].cookie.maxAge",
+		"actions actions[16].cookie.sameSite",
+		"impossible match.allOf[1]",
+		"impossible match.allOf[3]",
+		"impossible match.allOf[5]",
+		"impossible id",
+		"impossible stage",
+		"impossible match.allOf[0].value",
+	} {
+		if !errs[want] {
+			t.Errorf("missing error %q in %v", want, report.Diagnostics)
+		}
+	}
+	warns := diagFields(report, rulespec.SeverityWarning)
+	if !warns["impossible actions[1]"] {
+		t.Errorf("missing warning for action after block in %v", report.Diagnostics)
+	}
+	if !warns["actions actions[7].probability"] {
+		t.Errorf("missing warning for probability on a non-fault action in %v", report.Diagnostics)
+	}
+	if !warns["actions actions[10].latencyMS"] {
+		t.Errorf("missing warning for latencyMS ignored by delay in %v", report.Diagnostics)
+	}
+	if !warns["actions actions[17].cookie.secure"] {
+		t.Errorf("missing warning for SameSite=None without secure in %v", report.Diagnostics)
+	}
+	if len(report.Errors()) != len(errs) {
+		t.Errorf("got %d errors from Errors(), want %d", len(report.Errors()), len(errs))
+	}
+}